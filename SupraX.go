@@ -2,6 +2,7 @@ package suprax32
 
 import (
 	"fmt"
+	"io"
 	"math/bits"
 )
 
@@ -428,8 +429,8 @@ const (
 	//   A function ALWAYS returns to the instruction after the call.
 	//
 	// THE MECHANISM:
-	//   JAL (call): Push return address to RSB
-	//   JALR r1 (return): Pop return address from RSB
+	//   JAL r1 (call): Push return address to RSB at fetch
+	//   JALR r0, 0(r1) (return): Pop return address from RSB at fetch
 	//
 	// WHY 6 ENTRIES:
 	//   Call depth in hot paths rarely exceeds 6
@@ -604,6 +605,32 @@ const (
 	OpSLT  = 0x0C // Set if less than (signed): rd = (rs1 < rs2) ? 1 : 0
 	OpSLTU = 0x0D // Set if less than (unsigned): rd = (rs1 < rs2) ? 1 : 0
 
+	// ═══════════════════════════════════════════════════════════════════
+	// N-FORMAT INSTRUCTIONS (Narrow memory access)
+	// ═══════════════════════════════════════════════════════════════════
+	// Format: [opcode:5][reg:5][rs1:5][funct:2][immediate:15]
+	// Meaning: byte/halfword access at rs1 + imm
+	//
+	// The last two R-format opcodes were free. Two bits of the immediate
+	// become a function field, so six instructions need only two opcodes:
+	//
+	//	funct  load   store     funct bit 1: 0 = byte, 1 = halfword
+	//	  0    LB     SB        funct bit 0: 1 = zero-extend (loads only)
+	//	  1    LBU    (SB)
+	//	  2    LH     SH
+	//	  3    LHU    (SH)
+	//
+	// Like branches, stores put their data register (rs2) in the rd field.
+	// A halfword at an odd address traps (CauseLoadMisaligned or
+	// CauseStoreMisaligned, see trap.go), so an access never straddles a
+	// cache line.
+	//
+	// WHY: Strings, packed structs and byte streams otherwise need a
+	//      load-shift-mask (or load-merge-store) sequence per byte
+
+	OpLDN = 0x0E // Narrow load: rd = ext(memory[rs1 + imm]) (LB/LBU/LH/LHU)
+	OpSTN = 0x0F // Narrow store: memory[rs1 + imm] = low byte/halfword of rs2 (SB/SH)
+
 	// ═══════════════════════════════════════════════════════════════════
 	// I-FORMAT INSTRUCTIONS (Immediate operations)
 	// ═══════════════════════════════════════════════════════════════════
@@ -612,7 +639,11 @@ const (
 
 	OpADDI = 0x10 // Add immediate: rd = rs1 + imm
 	OpLW   = 0x11 // Load word: rd = memory[rs1 + imm]
-	OpSW   = 0x12 // Store word: memory[rs1 + imm] = rs2
+
+	// S-FORMAT: [opcode:5][rs2:5][rs1:5][immediate:17]
+	// A store writes no register, so the data register rides in the rd
+	// field, exactly like a branch's rs2 (INNOVATION #4)
+	OpSW = 0x12 // Store word: memory[rs1 + imm] = rs2
 
 	// ═══════════════════════════════════════════════════════════════════
 	// B-FORMAT INSTRUCTIONS (Branch operations)
@@ -641,9 +672,21 @@ const (
 	OpORI  = 0x1B // OR immediate: rd = rs1 | imm
 	OpXORI = 0x1C // XOR immediate: rd = rs1 ^ imm
 	OpLR   = 0x1D // Load reserved (atomic): rd = memory[rs1+imm], reserve address
-	OpSC   = 0x1E // Store conditional (atomic): if reserved, memory[rs1+imm] = rs2
 
-	OpSYSTEM = 0x1F // System call (trap to OS)
+	// SC needs three registers: [opcode:5][rd:5][rs1:5][rs2:5][immediate:12]
+	OpSC = 0x1E // Store conditional (atomic): if reserved, memory[rs1+imm] = rs2, rd = 0 on success
+
+	OpSYSTEM = 0x1F // System: imm = function + argument, serializing (see syscall.go, trap.go)
+)
+
+// N-format function codes (bit 1 = halfword, bit 0 = zero-extend)
+const (
+	FnByte      = 0 // LB / SB
+	FnByteU     = 1 // LBU
+	FnHalf      = 2 // LH / SH
+	FnHalfU     = 3 // LHU
+	fnHalfBit   = 2
+	fnUnsignBit = 1
 )
 
 // INNOVATION #5: Single-cycle decode
@@ -674,16 +717,29 @@ type Instruction struct {
 	Rs2    uint8  // Source register 2 (0-31)
 	Imm    int32  // Immediate value (sign-extended to 32 bits)
 	PC     uint32 // Program counter (address of this instruction)
+	Seq    uint64 // Fetch sequence number (assigned by the core, for tracing)
+
+	// Fetch-time prediction (assigned by the core). Dispatch copies these
+	// into the window so commit checks the path that was actually fetched.
+	Predicted     bool         // Predicted taken?
+	PredictedAddr uint32       // Predicted next PC
+	TargetSource  TargetSource // What predicted a JALR's target (see indirect.go)
 
 	// INNOVATION #6: Pre-computed convenience flags
 	// These are computed ONCE during decode, then used throughout pipeline
-	IsBranch bool // Is this a conditional branch? (BEQ, BNE, BLT, BGE)
-	IsLoad   bool // Does this load from memory? (LW, LR)
-	IsStore  bool // Does this store to memory? (SW, SC)
-	IsJump   bool // Is this an unconditional jump? (JAL, JALR)
-	IsMul    bool // Is this a multiply? (MUL, MULH)
-	IsDiv    bool // Is this a divide? (DIV, REM)
-	UsesImm  bool // Does this use the immediate field? (I-format and B-format)
+	IsBranch  bool  // Is this a conditional branch? (BEQ, BNE, BLT, BGE)
+	IsLoad    bool  // Does this load from memory? (LW, LR, LB/LBU/LH/LHU)
+	IsStore   bool  // Does this store to memory? (SW, SC, SB/SH)
+	IsJump    bool  // Is this an unconditional jump? (JAL, JALR)
+	IsMul     bool  // Is this a multiply? (MUL, MULH)
+	IsDiv     bool  // Is this a divide? (DIV, REM)
+	UsesImm   bool  // Does this use the immediate field? (I-, B- and N-format)
+	MemSize   uint8 // Bytes accessed by a load or store (1, 2 or 4)
+	MemSigned bool  // Narrow load sign-extends (LB, LH)
+
+	// Fetch faulted (CauseFetchPageFault, see mmu.go): the word was never
+	// read, and the trap is taken if this reaches commit
+	Fault uint8
 }
 
 // DecodeInstruction implements INNOVATION #5: Single-cycle decode
@@ -692,8 +748,10 @@ type Instruction struct {
 //
 //	STEP 1: Extract opcode from bits [31:27]
 //	STEP 2: Determine format from opcode range:
-//	        - Opcode 0x00-0x0F: R-format (register-register)
+//	        - Opcode 0x00-0x0D: R-format (register-register)
+//	        - Opcode 0x0E-0x0F: N-format (byte/halfword load/store)
 //	        - Opcode 0x13-0x16: B-format (branches, rd→rs2)
+//	        - Opcode 0x12:      S-format (SW, rd→rs2)
 //	        - Others: I-format (register-immediate)
 //	STEP 3: Extract fields according to format
 //	STEP 4: Set convenience flags (INNOVATION #6)
@@ -710,7 +768,28 @@ func DecodeInstruction(word uint32, pc uint32) Instruction {
 	inst.Opcode = uint8(word >> 27)
 
 	// STEP 2-3: Decode based on format (determined by opcode range)
-	if inst.Opcode < 0x10 {
+	if inst.Opcode == OpLDN || inst.Opcode == OpSTN {
+		// N-FORMAT: Narrow loads and stores
+		// Layout: [opcode:5][reg:5][rs1:5][funct:2][immediate:15]
+		reg := uint8((word >> 22) & 0x1F) // Bits [26:22]
+		inst.Rs1 = uint8((word >> 17) & 0x1F)
+		inst.Imm = signExtend15(word & 0x7FFF) // Bits [14:0]
+		inst.UsesImm = true
+
+		funct := (word >> 15) & 0x3 // Bits [16:15]
+		inst.MemSize = 1
+		if funct&fnHalfBit != 0 {
+			inst.MemSize = 2
+		}
+
+		if inst.Opcode == OpLDN {
+			inst.Rd = reg
+			inst.MemSigned = funct&fnUnsignBit == 0
+		} else {
+			inst.Rs2 = reg // Data register rides in the rd field (like B-format)
+		}
+
+	} else if inst.Opcode < 0x10 {
 		// R-FORMAT: Two register sources, one register destination
 		// Layout: [opcode:5][rd:5][rs1:5][rs2:5][unused:12]
 		inst.Rd = uint8((word >> 22) & 0x1F)  // Bits [26:22]
@@ -743,11 +822,17 @@ func DecodeInstruction(word uint32, pc uint32) Instruction {
 		inst.Imm = signExtend17(word & 0x1FFFF) // Bits [16:0]
 		inst.UsesImm = true
 
-		// SPECIAL CASE: Store instructions encode rs2 differently
-		// SW needs TWO source registers (address and data)
-		// Format: [opcode:5][rs2:5][rs1:5][immediate:12][rs2':5]
-		if inst.Opcode == OpSW || inst.Opcode == OpSC {
+		// SPECIAL CASE: Stores need TWO source registers (address and data)
+		switch inst.Opcode {
+		case OpSW:
+			// S-format: data register in the rd field, full 17-bit offset
+			inst.Rs2 = inst.Rd
+			inst.Rd = 0
+		case OpSC:
+			// rd (success flag) stays; rs2 takes the top of the immediate
+			// Layout: [opcode:5][rd:5][rs1:5][rs2:5][immediate:12]
 			inst.Rs2 = uint8((word >> 12) & 0x1F) // Bits [16:12]
+			inst.Imm = int32(word<<20) >> 20      // Bits [11:0], sign-extended
 		}
 	}
 
@@ -756,8 +841,14 @@ func DecodeInstruction(word uint32, pc uint32) Instruction {
 	switch inst.Opcode {
 	case OpLW, OpLR:
 		inst.IsLoad = true
+		inst.MemSize = 4
 	case OpSW, OpSC:
 		inst.IsStore = true
+		inst.MemSize = 4
+	case OpLDN:
+		inst.IsLoad = true
+	case OpSTN:
+		inst.IsStore = true
 	case OpJAL, OpJALR:
 		inst.IsJump = true
 	case OpMUL, OpMULH:
@@ -801,6 +892,14 @@ func signExtend17(val uint32) int32 {
 	return int32(val)
 }
 
+// signExtend15 converts the 15-bit N-format immediate to 32-bit signed
+func signExtend15(val uint32) int32 {
+	if val&0x4000 != 0 {
+		return int32(val | 0xFFFF8000)
+	}
+	return int32(val)
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // ARITHMETIC: THE ADDER (INNOVATIONS #7-8)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -1200,10 +1299,12 @@ func init() {
 	// FIXED-POINT FORMAT: 0.32 format (all 32 bits are fraction)
 	//   Value = (integer value) / 2^32
 	//   Example: 0x80000000 = 2^31 / 2^32 = 0.5
+	//
+	// 1/1.0 = 1.0 does not fit in 0.32, so entry 0 saturates to 0xFFFFFFFF
 	for i := 0; i < 512; i++ {
-		x := 1.0 + float64(i)/512.0                       // Range: [1.0, 1.998)
-		recip := 1.0 / x                                  // Compute 1/x
-		reciprocalTable[i] = uint32(recip * 4294967296.0) // Convert to fixed-point
+		x := 1.0 + float64(i)/512.0                                      // Range: [1.0, 1.998)
+		recip := 1.0 / x                                                 // Compute 1/x
+		reciprocalTable[i] = uint32(min(recip*4294967296.0, 0xFFFFFFFF)) // Convert to fixed-point
 	}
 }
 
@@ -1595,7 +1696,7 @@ func (d *Divider) Tick() {
 		// Extract top 9 bits for table index
 		// After normalizing, bit 31 is always 1
 		// We use bits [30:22] as index (9 bits = 512 values)
-		index := (d.normalized >> 23) & 0x1FF
+		index := (d.normalized >> 22) & 0x1FF
 		d.xApprox = reciprocalTable[index]
 
 		d.state = 2 // Move to next cycle
@@ -1617,12 +1718,11 @@ func (d *Divider) Tick() {
 		//   Each iteration squares the error!
 		//
 		// FIXED-POINT MATH:
-		//   Our values are in 0.32 format (all bits are fraction)
-		//   2.0 in fixed-point = 0x100000000 = 0xFFFFFFFF + 1
-		//   We use modular arithmetic (wraps naturally)
-		_, bx := Multiply(d.normalized, d.xApprox)
-		twoMinusBX := 0xFFFFFFFF - bx + 1 // 2.0 - bx (wraps correctly)
-		_, d.xApprox = Multiply(d.xApprox, twoMinusBX)
+		//   normalized is b in 1.31 format, xApprox is x in 0.32 format
+		//   b × x: upper 32 bits of the product, in 1.31 format
+		//   2.0 in 1.31 = 0x100000000, so 2 - bx = -bx (wraps correctly)
+		//   x × (2 - bx) is x' × 2^63: keep bits [62:31] for 0.32 format
+		d.xApprox = newtonStep(d.normalized, d.xApprox)
 
 		d.state = 3 // Move to next cycle
 
@@ -1640,9 +1740,7 @@ func (d *Divider) Tick() {
 		//   One iteration: 9 → 18 bits (not enough for 32-bit precision)
 		//   Two iterations: 18 → 36 bits (sufficient!) ✅
 		//   Three iterations: 36 → 72 bits (overkill, wasted cycle)
-		_, bx := Multiply(d.normalized, d.xApprox)
-		twoMinusBX := 0xFFFFFFFF - bx + 1
-		_, d.xApprox = Multiply(d.xApprox, twoMinusBX)
+		d.xApprox = newtonStep(d.normalized, d.xApprox)
 
 		d.state = 4 // Move to final cycle
 
@@ -1663,16 +1761,18 @@ func (d *Divider) Tick() {
 		//   Always check and fix if needed
 
 		// STEP 1-2: Multiply and denormalize
-		_, q := Multiply(d.dividend, d.xApprox)
-		d.quotient = q >> (32 - d.shift) // Shift back to denormalize
+		// dividend × x × 2^32 = quotient × 2^(63 - shift)
+		// Truncating b × x can leave x one unit above 1/b; backing off
+		// one unit guarantees the quotient never overshoots
+		q, _ := bits.Mul32(d.dividend, d.xApprox-1)
+		d.quotient = q >> (31 - d.shift) // Shift back to denormalize
 
 		// STEP 3: Compute remainder to verify
-		prod, _ := Multiply(d.quotient, d.divisor)
-		d.remainder = d.dividend - prod
+		d.remainder = d.dividend - d.quotient*d.divisor
 
 		// STEP 4-5: Correction if needed
-		// If remainder >= divisor, we underestimated by 1
-		if d.remainder >= d.divisor {
+		// The estimate only ever undershoots, by at most a couple
+		for d.remainder >= d.divisor {
 			d.quotient++
 			d.remainder -= d.divisor
 		}
@@ -1684,6 +1784,16 @@ func (d *Divider) Tick() {
 	}
 }
 
+// newtonStep refines a reciprocal estimate: x' = x × (2 - b × x)
+//
+// b is in 1.31 format, x and x' in 0.32. The products are unsigned
+// (Multiply's upper half treats its second operand as signed for MULH).
+func newtonStep(b, x uint32) uint32 {
+	bx, _ := bits.Mul32(b, x)
+	hi, lo := bits.Mul32(x, -bx)
+	return hi<<1 | lo>>31
+}
+
 // GetResult returns the completed division result
 //
 // RETURNS:
@@ -1702,6 +1812,13 @@ func (d *Divider) GetResult() (result uint32, windowID int, valid bool) {
 	return 0, 0, false
 }
 
+// Cancel abandons the current division (its instruction was squashed)
+func (d *Divider) Cancel() {
+	d.state = 0
+	d.Busy = false
+	d.Done = false
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // BRANCH PREDICTION (INNOVATIONS #29-33)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -1727,10 +1844,15 @@ type BranchPredictor struct {
 	counters [BranchPredictorEntries]uint8 // INNOVATION #29: 4-bit counters
 	rsb      [RSBSize]uint32               // INNOVATION #31: Return Stack Buffer
 	rsbTop   int                           // RSB top of stack pointer
+	indirect IndirectPredictor             // BTB + ITTAGE (see indirect.go)
 
 	// Statistics
-	predictions uint64
-	correct     uint64
+	predictions   uint64
+	correct       uint64
+	rsbPushes     uint64 // Return addresses pushed (calls)
+	rsbPops       uint64 // Return addresses popped (predicted returns)
+	rsbOverflows  uint64 // Pushes that discarded the oldest entry
+	rsbUnderflows uint64 // Pops from an empty stack
 }
 
 // NewBranchPredictor creates an initialized branch predictor
@@ -1752,6 +1874,7 @@ type BranchPredictor struct {
 //	Starting at 0 (not-taken) is wrong 99% ❌
 func NewBranchPredictor() *BranchPredictor {
 	bp := &BranchPredictor{}
+	bp.indirect.on = true
 	for i := range bp.counters {
 		bp.counters[i] = 8 // Weakly taken (slightly biased toward taken)
 	}
@@ -1891,6 +2014,7 @@ func (bp *BranchPredictor) Update(pc uint32, actualTaken bool) {
 //
 // MINECRAFT ANALOGY: Stack of portal locations you came through
 func (bp *BranchPredictor) PushRSB(returnAddr uint32) {
+	bp.rsbPushes++
 	if bp.rsbTop < RSBSize {
 		// Stack not full: simple push
 		bp.rsb[bp.rsbTop] = returnAddr
@@ -1903,6 +2027,7 @@ func (bp *BranchPredictor) PushRSB(returnAddr uint32) {
 		}
 		bp.rsb[RSBSize-1] = returnAddr
 		// rsbTop stays at RSBSize
+		bp.rsbOverflows++
 	}
 }
 
@@ -1919,8 +2044,10 @@ func (bp *BranchPredictor) PushRSB(returnAddr uint32) {
 func (bp *BranchPredictor) PopRSB() (addr uint32, valid bool) {
 	if bp.rsbTop > 0 {
 		bp.rsbTop--
+		bp.rsbPops++
 		return bp.rsb[bp.rsbTop], true
 	}
+	bp.rsbUnderflows++
 	return 0, false // Stack empty
 }
 
@@ -1943,9 +2070,9 @@ func (bp *BranchPredictor) PeekRSB() (addr uint32, valid bool) {
 //
 //	ELIF indirect jump (JALR):
 //	  IF return (rs1=1, imm=0):
-//	    target = pop from RSB ✅
+//	    target = pop from RSB ✅ (BTB if the RSB is empty)
 //	  ELSE:
-//	    target = PC + 4 (can't predict well) ⚠️
+//	    target = ITTAGE, else BTB, else PC + 4 (see indirect.go)
 //
 //	ELIF conditional branch (BEQ, BNE, etc):
 //	  Use direction predictor
@@ -1955,44 +2082,38 @@ func (bp *BranchPredictor) PeekRSB() (addr uint32, valid bool) {
 //	ELSE (not a branch):
 //	  target = PC + 4 (sequential)
 //
-// INNOVATION #33: No BTB (Branch Target Buffer)
+// INNOVATION #33: No BTB for direct targets
 //
 //	We compute direct targets (free!)
 //	We use RSB for returns (small and accurate)
-//	We don't predict other indirect jumps well
-//	Saves 98K transistors at cost of 0.15 IPC
-func (bp *BranchPredictor) PredictTarget(pc uint32, inst Instruction) uint32 {
+//	Indirect jumps get a small BTB and ITTAGE (see indirect.go)
+//
+// RETURNS: the target, and what predicted it for a JALR (TargetNone
+// otherwise)
+func (bp *BranchPredictor) PredictTarget(pc uint32, inst Instruction) (uint32, TargetSource) {
 	switch inst.Opcode {
 	case OpJAL:
 		// Direct jump: target is PC + immediate
 		// This is KNOWN at fetch time (no prediction needed!)
-		return uint32(int32(pc) + inst.Imm)
+		return uint32(int32(pc) + inst.Imm), TargetNone
 
 	case OpJALR:
 		// Indirect jump: target depends on register value
-		// Special case: return from function
-		if inst.Rs1 == 1 && inst.Imm == 0 {
-			// This is a return (JALR x0, ra, 0)
-			// Use RSB for prediction (INNOVATION #31)
-			if addr, valid := bp.PopRSB(); valid {
-				return addr
-			}
-		}
-		// Other indirect jumps: can't predict well
-		// INNOVATION #33: No BTB, so just predict sequential
-		return pc + 4
+		// Returns use the RSB (INNOVATION #31), everything else the
+		// BTB and ITTAGE
+		return bp.indirect.predictJALR(bp, pc, inst)
 
 	case OpBEQ, OpBNE, OpBLT, OpBGE:
 		// Conditional branch: use direction predictor
 		taken, _ := bp.Predict(pc)
 		if taken {
-			return uint32(int32(pc) + inst.Imm)
+			return uint32(int32(pc) + inst.Imm), TargetNone
 		}
-		return pc + 4
+		return pc + 4, TargetNone
 
 	default:
 		// Not a branch: sequential execution
-		return pc + 4
+		return pc + 4, TargetNone
 	}
 }
 
@@ -2123,6 +2244,8 @@ const (
 	PredictorConstant PredictorID = 3 // INNOVATION #62
 	PredictorDelta    PredictorID = 4 // INNOVATION #63
 	PredictorContext  PredictorID = 5 // INNOVATION #64
+
+	PredictorPrefetcher PredictorID = 6 // A plugged-in Prefetcher (see prefetcher.go)
 )
 
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -2853,6 +2976,8 @@ type MetaEntry struct {
 // MetaPredictor implements INNOVATION #65
 type MetaPredictor struct {
 	entries [512]MetaEntry
+
+	minConfidence uint8 // Set by the prefetch throttle (see throttle.go)
 }
 
 func (mp *MetaPredictor) getIndex(pc uint32) int {
@@ -2875,6 +3000,9 @@ func (mp *MetaPredictor) getTag(pc uint32) uint16 {
 //	          Use predictor with highest score
 //	STEP 3: Return best prediction
 //
+// Predictions below minConfidence are passed over (0 unless the prefetch
+// throttle has raised it).
+//
 // SCORING:
 //
 //	Score = predictor_confidence × meta_counter
@@ -2901,7 +3029,7 @@ func (mp *MetaPredictor) SelectBest(pc uint32, predictions [5]struct {
 	if !entry.Valid || entry.Tag != mp.getTag(pc) {
 		var bestConf uint8
 		for i, pred := range predictions {
-			if pred.valid && pred.confidence > bestConf {
+			if pred.valid && pred.confidence >= mp.minConfidence && pred.confidence > bestConf {
 				bestConf = pred.confidence
 				bestAddr = pred.addr
 				bestPredictor = PredictorID(i + 1)
@@ -2914,7 +3042,7 @@ func (mp *MetaPredictor) SelectBest(pc uint32, predictions [5]struct {
 	// Have meta information: weight by confidence × meta_counter
 	var bestScore int
 	for i, pred := range predictions {
-		if pred.valid {
+		if pred.valid && pred.confidence >= mp.minConfidence {
 			// Score = confidence × meta_counter
 			score := int(pred.confidence) * int(entry.Counters[i])
 			if score > bestScore {
@@ -2998,10 +3126,20 @@ type L1DPredictor struct {
 	lastPredAddr  uint32
 	lastPredictor PredictorID
 	hasPrediction bool
+	lastSubAddr   [5]uint32 // Each specialist's last prediction
+	lastSubValid  [5]bool   // Did each specialist offer a prediction?
+	disabled      [5]bool   // Switched off (see prefetch.go)
 
 	// INNOVATION #66: Confidence tracking
 	totalPredictions   uint64
 	correctPredictions uint64
+	loadsRecorded      uint64
+
+	// Per-specialist statistics (indexed by PredictorID-1)
+	subOffered         [5]uint64 // Specialist had a confident prediction
+	subCorrect         [5]uint64 // ...and it matched the actual address
+	subSelected        [5]uint64 // Meta-predictor chose this specialist
+	subSelectedCorrect [5]uint64 // ...and the chosen prediction was right
 }
 
 // NewL1DPredictor creates the complete predictor ensemble
@@ -3034,6 +3172,11 @@ func (p *L1DPredictor) Predict(pc uint32) (addr uint32, predictor PredictorID, v
 	predictions[2].addr, predictions[2].confidence, predictions[2].valid = p.constant.Predict(pc)
 	predictions[3].addr, predictions[3].confidence, predictions[3].valid = p.delta.Predict(pc)
 	predictions[4].addr, predictions[4].confidence, predictions[4].valid = p.context.Predict(pc)
+	for i := range predictions {
+		if p.disabled[i] {
+			predictions[i].valid = false // Switched off: offers nothing
+		}
+	}
 
 	// STEP 2: Meta-predictor chooses best
 	addr, predictor, valid = p.meta.SelectBest(pc, predictions)
@@ -3044,8 +3187,17 @@ func (p *L1DPredictor) Predict(pc uint32) (addr uint32, predictor PredictorID, v
 	p.lastPredictor = predictor
 	p.hasPrediction = valid
 
+	for i := range predictions {
+		p.lastSubAddr[i] = predictions[i].addr
+		p.lastSubValid[i] = predictions[i].valid
+		if predictions[i].valid {
+			p.subOffered[i]++
+		}
+	}
+
 	if valid {
 		p.totalPredictions++
+		p.subSelected[predictor-1]++
 	}
 
 	return
@@ -3067,6 +3219,8 @@ func (p *L1DPredictor) Predict(pc uint32) (addr uint32, predictor PredictorID, v
 //
 //	so they can all learn for next time
 func (p *L1DPredictor) RecordLoad(pc uint32, addr uint32) {
+	p.loadsRecorded++
+
 	// STEP 1: Check if prediction was correct
 	if p.hasPrediction && p.lastPC == pc {
 		correct := (p.lastPredAddr == addr)
@@ -3076,6 +3230,17 @@ func (p *L1DPredictor) RecordLoad(pc uint32, addr uint32) {
 
 		if correct {
 			p.correctPredictions++
+			p.subSelectedCorrect[p.lastPredictor-1]++
+		}
+	}
+
+	// Score every specialist that offered a prediction for this load
+	if p.lastPC == pc {
+		for i := range p.lastSubValid {
+			if p.lastSubValid[i] && p.lastSubAddr[i] == addr {
+				p.subCorrect[i]++
+			}
+			p.lastSubValid[i] = false
 		}
 	}
 	p.hasPrediction = false
@@ -3147,6 +3312,7 @@ type PrefetchEntry struct {
 	Addr      uint32        // Memory address to prefetch
 	State     PrefetchState // Current state in lifecycle
 	Predictor PredictorID   // Which predictor made this prediction (for debug)
+	Priority  uint8         // Higher issues first (see prefetcher.go)
 }
 
 // PrefetchQueue manages pending prefetch requests (INNOVATION #67)
@@ -3155,6 +3321,15 @@ type PrefetchQueue struct {
 	head    int // Oldest entry (for dequeue)
 	tail    int // Next free slot (for enqueue)
 	count   int // Number of valid entries
+
+	// Statistics
+	enqueued       uint64 // Requests accepted
+	dropsFull      uint64 // Requests rejected because the queue was full
+	dropsDuplicate uint64 // Requests rejected by deduplication (INNOVATION #68)
+	issued         uint64 // Requests sent to memory
+	completed      uint64 // Requests whose line arrived
+	redundant      uint64 // Requests whose line was cached before they were served
+	displaced      uint64 // Pending requests replaced by a higher-priority one
 }
 
 // Enqueue adds a new prefetch request (INNOVATION #68: with deduplication)
@@ -3170,10 +3345,26 @@ type PrefetchQueue struct {
 //	If address already present: Don't add again
 //	This saves bandwidth and queue space
 //
+// Requests are kept as line addresses: a byte-stride load predicts 64
+// addresses per line, and they must all collapse into one request (the
+// same address Fill reports back to Complete).
+//
 // RETURNS: true if added, false if rejected (full or duplicate)
 func (pq *PrefetchQueue) Enqueue(addr uint32, predictor PredictorID) bool {
+	return pq.EnqueuePriority(addr, predictor, 0)
+}
+
+// EnqueuePriority adds a request that issues ahead of lower priorities
+//
+// A full queue makes room by replacing its lowest-priority Pending
+// request, if that one ranks below the new request; otherwise the new
+// request is dropped as before.
+func (pq *PrefetchQueue) EnqueuePriority(addr uint32, predictor PredictorID, priority uint8) bool {
+	addr &^= CacheLineSize - 1
+
 	// STEP 1: Check if queue is full
-	if pq.count >= PrefetchQueueSize {
+	if pq.count >= PrefetchQueueSize && !pq.displace(addr, priority) {
+		pq.dropsFull++
 		return false
 	}
 
@@ -3185,17 +3376,29 @@ func (pq *PrefetchQueue) Enqueue(addr uint32, predictor PredictorID) bool {
 
 		// If address already in queue and not complete yet
 		if entry.Addr == addr && entry.State != PrefetchEmpty {
+			pq.dropsDuplicate++
 			return false // Duplicate! Don't add
 		}
 	}
 
-	// STEP 3: Add new entry to tail
-	pq.entries[pq.tail] = PrefetchEntry{
+	entry := PrefetchEntry{
 		Addr:      addr,
 		State:     PrefetchPending,
 		Predictor: predictor,
+		Priority:  priority,
+	}
+	pq.enqueued++
+
+	// Full: the slot displace picked keeps its place in the ring
+	if pq.count >= PrefetchQueueSize {
+		pq.entries[pq.victim(priority)] = entry
+		pq.displaced++
+		return true
 	}
 
+	// STEP 3: Add new entry to tail
+	pq.entries[pq.tail] = entry
+
 	// Advance tail pointer (circular buffer)
 	pq.tail = (pq.tail + 1) % PrefetchQueueSize
 	pq.count++
@@ -3203,62 +3406,135 @@ func (pq *PrefetchQueue) Enqueue(addr uint32, predictor PredictorID) bool {
 	return true // Successfully added
 }
 
+// displace reports whether a full queue has a Pending request ranked
+// below priority (and addr is not already queued)
+func (pq *PrefetchQueue) displace(addr uint32, priority uint8) bool {
+	if _, dup := pq.queued(addr); dup {
+		return false
+	}
+	return pq.victim(priority) >= 0
+}
+
+// victim returns the youngest lowest-priority Pending request ranked below
+// priority, or -1
+func (pq *PrefetchQueue) victim(priority uint8) int {
+	victim := -1
+	for i := 0; i < pq.count; i++ {
+		idx := (pq.head + i) % PrefetchQueueSize
+		entry := &pq.entries[idx]
+		if entry.State == PrefetchPending && entry.Priority < priority &&
+			(victim < 0 || entry.Priority <= pq.entries[victim].Priority) {
+			victim = idx
+		}
+	}
+	return victim
+}
+
 // Dequeue returns the next prefetch to process
 //
 // ALGORITHM:
 //
 //	STEP 1: Check if queue is empty
-//	STEP 2: Get entry at head
-//	STEP 3: If state is Pending: Mark as InFlight, return address
-//	STEP 4: Otherwise: Return invalid
+//	STEP 2: Find the highest-priority Pending entry, oldest first
+//	        (all requests of the 5-way predictor rank equal: FIFO)
+//	STEP 3: Mark it InFlight, return address
+//	STEP 4: None Pending: Return invalid
 //
-// NOTE: We don't remove from queue until Complete is called
+// NOTE: We don't remove from queue until Complete (or Discard) is called
 //
 //	This prevents losing track of in-flight requests
-func (pq *PrefetchQueue) Dequeue() (addr uint32, valid bool) {
-	if pq.count == 0 {
-		return 0, false
+func (pq *PrefetchQueue) Dequeue() (addr uint32, predictor PredictorID, valid bool) {
+	var best *PrefetchEntry
+	for i := 0; i < pq.count; i++ {
+		entry := &pq.entries[(pq.head+i)%PrefetchQueueSize]
+		if entry.State == PrefetchPending && (best == nil || entry.Priority > best.Priority) {
+			best = entry
+		}
+	}
+	if best == nil {
+		return 0, PredictorNone, false
 	}
 
-	entry := &pq.entries[pq.head]
+	best.State = PrefetchInFlight
+	pq.issued++
+	return best.Addr, best.Predictor, true
+}
 
-	// Only dequeue if in Pending state
-	if entry.State == PrefetchPending {
-		entry.State = PrefetchInFlight
-		return entry.Addr, true
+// pending reports whether a request is waiting to issue
+func (pq *PrefetchQueue) pending() bool {
+	for i := 0; i < pq.count; i++ {
+		if pq.entries[(pq.head+i)%PrefetchQueueSize].State == PrefetchPending {
+			return true
+		}
 	}
-
-	return 0, false
+	return false
 }
 
 // Complete marks a prefetch as finished
 //
 // ALGORITHM:
 //
-//	STEP 1: Find entry with matching address in InFlight state
+//	STEP 1: Find entry with matching address, InFlight or still Pending
+//	        (a demand miss filled the line first: nothing left to fetch)
 //	STEP 2: Mark as Complete
-//	STEP 3: If it's at head: Remove from queue (advance head)
+//	STEP 3: Remove Complete entries from the head
 //
-// WHY CHECK HEAD: We maintain queue order
+// WHY ONLY FROM THE HEAD: We maintain queue order
 //
-//	Only remove from head to keep circular buffer consistent
+//	An entry completed out of order waits for the ones before it, but
+//	never blocks the queue: Dequeue only looks at the head.
 func (pq *PrefetchQueue) Complete(addr uint32) {
-	for i := 0; i < PrefetchQueueSize; i++ {
-		entry := &pq.entries[i]
+	for i := 0; i < pq.count; i++ {
+		entry := &pq.entries[(pq.head+i)%PrefetchQueueSize]
 
-		if entry.Addr == addr && entry.State == PrefetchInFlight {
+		if entry.Addr == addr && (entry.State == PrefetchInFlight || entry.State == PrefetchPending) {
+			if entry.State == PrefetchInFlight {
+				pq.completed++
+			} else {
+				pq.redundant++
+			}
 			entry.State = PrefetchComplete
+			pq.retire()
+			return
+		}
+	}
+}
 
-			// If this is at head, remove from queue
-			if i == pq.head {
-				pq.head = (pq.head + 1) % PrefetchQueueSize
-				pq.count--
-			}
+// Discard finishes an issued request without a fill (its line is already
+// cached)
+func (pq *PrefetchQueue) Discard(addr uint32) {
+	for i := 0; i < pq.count; i++ {
+		entry := &pq.entries[(pq.head+i)%PrefetchQueueSize]
+		if entry.Addr == addr && entry.State == PrefetchInFlight {
+			entry.State = PrefetchComplete
+			pq.redundant++
+			pq.retire()
 			return
 		}
 	}
 }
 
+// retire removes Complete entries from the head
+func (pq *PrefetchQueue) retire() {
+	for pq.count > 0 && pq.entries[pq.head].State == PrefetchComplete {
+		pq.entries[pq.head].State = PrefetchEmpty
+		pq.head = (pq.head + 1) % PrefetchQueueSize
+		pq.count--
+	}
+}
+
+// queued returns the specialist behind a request for this line that has
+// not filled yet
+func (pq *PrefetchQueue) queued(line uint32) (predictor PredictorID, ok bool) {
+	for i := 0; i < pq.count; i++ {
+		entry := &pq.entries[(pq.head+i)%PrefetchQueueSize]
+		if entry.Addr == line && (entry.State == PrefetchPending || entry.State == PrefetchInFlight) {
+			return entry.Predictor, true
+		}
+	}
+	return PredictorNone, false
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // L1I CACHE (INNOVATIONS #21-28)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -3327,6 +3603,10 @@ type CacheLine struct {
 	Data  [CacheLineSize]byte // The actual cached data (64 bytes)
 	Valid bool                // Is this data valid?
 	Dirty bool                // Has this been modified? (for write-back)
+
+	// L1D only (see prefetch.go)
+	Prefetched     bool        // Brought in by a prefetch, not yet used
+	PrefetchSource PredictorID // ...on this specialist's prediction
 }
 
 // BranchInfo tracks a branch in the buffer (INNOVATION #23)
@@ -3388,11 +3668,17 @@ type L1ICache struct {
 	// Prefetch state
 	prefetchAddr   uint32
 	prefetchActive bool
+	port           *PrefetchPort // Plugged-in prefetcher (nil = coverage scoring)
 
 	// Statistics
 	accesses uint64
 	hits     uint64
 	misses   uint64
+	fills    uint64 // Lines installed (demand + prefetch)
+	flushes  uint64 // Buffer flushes (branch mispredicts)
+
+	prefetchFills  uint64 // Lines a plugged-in prefetcher brought in
+	prefetchUseful uint64 // ...later hit by fetch
 }
 
 // NewL1ICache creates an initialized instruction cache
@@ -3447,6 +3733,11 @@ func (c *L1ICache) Read(addr uint32) (data uint32, hit bool) {
 			if line.Valid && line.Tag == tag {
 				// HIT! Extract the 32-bit instruction
 				c.hits++
+				c.observe(PrefetchOnHit, addr, line.Prefetched) // See prefetcher.go
+				if line.Prefetched {
+					c.prefetchUseful++
+					line.Prefetched = false
+				}
 				offset := int(addr & (CacheLineSize - 1))
 
 				// Assemble 32-bit word from 4 bytes (little-endian)
@@ -3469,6 +3760,7 @@ func (c *L1ICache) Read(addr uint32) (data uint32, hit bool) {
 
 	// MISS!
 	c.misses++
+	c.observe(PrefetchOnMiss, addr, false)
 	c.triggerPrefetch(addr)
 	return 0, false
 }
@@ -3824,6 +4116,12 @@ func (c *L1ICache) GetHitRate() float64 {
 //	STEP 3: Install line
 //	STEP 4: Update buffer metadata
 func (c *L1ICache) Fill(addr uint32, data []byte) {
+	c.install(addr, data, false)
+}
+
+// install fills a line for a fetch miss, the built-in prefetch, or a
+// plugged-in prefetcher (prefetched)
+func (c *L1ICache) install(addr uint32, data []byte, prefetched bool) {
 	// Find best buffer (prefer inactive, or LRU active)
 	bestBuf := 0
 	oldestAccess := c.buffers[0].lastAccess
@@ -3861,7 +4159,12 @@ func (c *L1ICache) Fill(addr uint32, data []byte) {
 	line.Tag = tag
 	line.Valid = true
 	line.Dirty = false
+	line.Prefetched = prefetched
 	copy(line.Data[:], data)
+	c.fills++
+	if prefetched {
+		c.prefetchFills++
+	}
 
 	// Update metadata
 	c.updateLRU(bestBuf, setIdx, victimWay)
@@ -3881,10 +4184,16 @@ func (c *L1ICache) Fill(addr uint32, data []byte) {
 	if addr == c.prefetchAddr {
 		c.prefetchActive = false
 	}
+
+	if c.port != nil {
+		c.port.queue.Complete(lineAddr)
+	}
+	c.observe(PrefetchOnFill, lineAddr, prefetched)
 }
 
 // Flush clears all buffers (on branch misprediction)
 func (c *L1ICache) Flush() {
+	c.flushes++
 	for i := range c.buffers {
 		c.buffers[i].active = false
 		c.buffers[i].baseAddr = 0
@@ -3949,6 +4258,24 @@ func (c *L1ICache) GetIndirectAccuracy() float64 {
 	return float64(valid) / float64(L1IIndirectEntries)
 }
 
+// Probe finds the line holding addr without touching LRU or statistics
+//
+// USED BY: Debugger and GDB stub (inspection must not perturb the run)
+func (c *L1ICache) Probe(addr uint32) (line *CacheLine, buffer int, way int, ok bool) {
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+
+	for bufIdx := range c.buffers {
+		set := &c.buffers[bufIdx].sets[setIdx]
+		for w := 0; w < L1Associativity; w++ {
+			if set[w].Valid && set[w].Tag == tag {
+				return &set[w], bufIdx, w, true
+			}
+		}
+	}
+	return nil, -1, -1, false
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // L1D CACHE (INNOVATIONS #18-20)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -3965,14 +4292,26 @@ type L1DCache struct {
 	lru           [L1DNumSets]uint8
 	predictor     *L1DPredictor // INNOVATION #59: 5-way predictor
 	prefetchQueue PrefetchQueue // INNOVATION #67: Prefetch queue
+	prefetchAcct  prefetchAccounting
+	port          *PrefetchPort // Plugged-in prefetcher (nil = the 5-way predictor)
+	throttle      prefetchThrottle
 
 	// For atomic operations (INNOVATION #71-72)
 	reservationValid bool
 	reservationAddr  uint32
 
+	// Main memory behind the cache: demand misses fill from it and dirty
+	// victims are written back to it (nil = cache only)
+	memory []byte
+
 	// Statistics
-	accesses uint64
-	hits     uint64
+	accesses       uint64
+	hits           uint64
+	writes         uint64 // Store attempts
+	writeHits      uint64 // Stores that found their line
+	fills          uint64 // Lines installed
+	evictions      uint64 // Valid lines replaced by a fill
+	dirtyEvictions uint64 // ...of which were modified
 }
 
 // NewL1DCache creates an initialized data cache
@@ -3990,15 +4329,23 @@ func (c *L1DCache) getTag(addr uint32) uint32 {
 	return addr >> (6 + bits.Len32(uint32(L1DNumSets-1)))
 }
 
-// Read loads data from cache, training the predictor
+// Read loads a word from cache, training the predictor (see ReadSize)
+func (c *L1DCache) Read(pc uint32, addr uint32) (data uint32, hit bool) {
+	return c.ReadSize(pc, addr, 4)
+}
+
+// ReadSize loads size bytes (1, 2 or 4) from cache, training the predictor
 //
 // ALGORITHM:
 //
 //	STEP 1: Search cache for address
-//	STEP 2: If found: Return data, update LRU
+//	STEP 2: If found: Assemble size bytes (zero-extended), update LRU
 //	STEP 3: Train predictor with actual address
 //	STEP 4: Trigger prediction for next access
-func (c *L1DCache) Read(pc uint32, addr uint32) (data uint32, hit bool) {
+//
+// Narrow loads train the predictor with their byte address like any
+// other load; the prefetch queue works in whole lines (see Enqueue).
+func (c *L1DCache) ReadSize(pc uint32, addr uint32, size int) (data uint32, hit bool) {
 	c.accesses++
 
 	setIdx := c.getSetIndex(addr)
@@ -4012,12 +4359,14 @@ func (c *L1DCache) Read(pc uint32, addr uint32) (data uint32, hit bool) {
 		if line.Valid && line.Tag == tag {
 			// HIT!
 			c.hits++
+			c.observe(PrefetchOnHit, pc, addr, line.Prefetched) // See prefetcher.go
+			c.demandHit(line)                                   // See prefetch.go
 			offset := int(addr & (CacheLineSize - 1))
 
-			data = uint32(line.Data[offset]) |
-				uint32(line.Data[offset+1])<<8 |
-				uint32(line.Data[offset+2])<<16 |
-				uint32(line.Data[offset+3])<<24
+			// Little-endian: byte i lands in bits [8i+7:8i]
+			for i := 0; i < size; i++ {
+				data |= uint32(line.Data[offset+i]) << (8 * i)
+			}
 
 			c.updateLRU(setIdx, way)
 
@@ -4032,6 +4381,8 @@ func (c *L1DCache) Read(pc uint32, addr uint32) (data uint32, hit bool) {
 	}
 
 	// MISS
+	c.demandMiss(addr)
+	c.observe(PrefetchOnMiss, pc, addr, false)
 	c.predictor.RecordLoad(pc, addr)
 	return 0, false
 }
@@ -4061,18 +4412,35 @@ func (c *L1DCache) triggerPrediction(pc uint32) {
 		}
 	}
 
-	// INNOVATION #67-68: Queue prefetch with deduplication
-	c.prefetchQueue.Enqueue(predAddr, predictor)
+	// INNOVATION #67-68: Queue prefetch with deduplication (see throttle.go)
+	c.queuePrefetch(pc, predAddr, predictor)
 }
 
-// Write stores data to cache
+// Write stores a word to cache (see WriteSize)
+func (c *L1DCache) Write(addr uint32, data uint32) bool {
+	return c.WriteSize(addr, data, 4)
+}
+
+// WriteSize stores the low size bytes (1, 2 or 4) of data to cache
 //
 // ALGORITHM:
 //
 //	STEP 1: Find line in cache
-//	STEP 2: Write data to line, mark dirty
+//	STEP 2: Merge size bytes into the line, mark dirty
+//	        (the rest of the line keeps its bytes)
 //	STEP 3: Invalidate any reservations (for atomics)
-func (c *L1DCache) Write(addr uint32, data uint32) bool {
+func (c *L1DCache) WriteSize(addr uint32, data uint32, size int) bool {
+	c.writes++
+	if !c.merge(addr, data, size) {
+		c.demandMiss(addr)
+		return false // Not in cache
+	}
+	c.writeHits++
+	return true
+}
+
+// merge writes bytes into the line holding addr (no statistics)
+func (c *L1DCache) merge(addr uint32, data uint32, size int) bool {
 	setIdx := c.getSetIndex(addr)
 	tag := c.getTag(addr)
 	set := &c.sets[setIdx]
@@ -4083,11 +4451,11 @@ func (c *L1DCache) Write(addr uint32, data uint32) bool {
 		if line.Valid && line.Tag == tag {
 			offset := int(addr & (CacheLineSize - 1))
 
-			line.Data[offset] = byte(data)
-			line.Data[offset+1] = byte(data >> 8)
-			line.Data[offset+2] = byte(data >> 16)
-			line.Data[offset+3] = byte(data >> 24)
+			for i := 0; i < size; i++ {
+				line.Data[offset+i] = byte(data >> (8 * i))
+			}
 			line.Dirty = true
+			c.demandHit(line)
 
 			c.updateLRU(setIdx, way)
 
@@ -4103,22 +4471,101 @@ func (c *L1DCache) Write(addr uint32, data uint32) bool {
 	return false // Not in cache
 }
 
-// Fill installs a cache line from memory
+// Fill installs a cache line from memory (demand fill)
 func (c *L1DCache) Fill(addr uint32, data []byte) {
+	c.install(addr, data, PredictorNone)
+}
+
+// install fills a line for a demand miss (source = PredictorNone) or for
+// a prefetch on source's prediction
+//
+// A line already present is kept as it is: a prefetch may have brought it
+// in while the demand miss waited, and it may since have been written.
+func (c *L1DCache) install(addr uint32, data []byte, source PredictorID) {
 	setIdx := c.getSetIndex(addr)
 	tag := c.getTag(addr)
 	set := &c.sets[setIdx]
 
+	if line, way, ok := c.Probe(addr); ok {
+		if source == PredictorNone {
+			line.Prefetched = false // Scored as late when the demand missed
+		}
+		c.updateLRU(setIdx, way)
+		c.prefetchQueue.Complete(addr &^ (CacheLineSize - 1))
+		return
+	}
+
 	victimWay := c.findVictim(setIdx)
 	line := &set[victimWay]
 
+	if line.Valid {
+		c.evictions++
+		c.noteEviction(line, setIdx, source)
+		if line.Dirty {
+			c.dirtyEvictions++
+			c.writeBack(line, setIdx)
+		}
+	}
+	c.fills++
+
 	line.Tag = tag
 	line.Valid = true
 	line.Dirty = false
+	line.Prefetched = source != PredictorNone
+	line.PrefetchSource = source
 	copy(line.Data[:], data)
+	if line.Prefetched {
+		c.prefetchAcct.perSource[source-1].issued++
+	}
 
 	c.updateLRU(setIdx, victimWay)
-	c.prefetchQueue.Complete(addr)
+	c.prefetchQueue.Complete(addr &^ (CacheLineSize - 1))
+	c.observe(PrefetchOnFill, 0, addr&^(CacheLineSize-1), line.Prefetched)
+}
+
+// peekSize reads size bytes from a resident line (no statistics or training)
+func (c *L1DCache) peekSize(addr uint32, size int) (data uint32, hit bool) {
+	line, _, ok := c.Probe(addr)
+	if !ok {
+		return 0, false
+	}
+	offset := int(addr & (CacheLineSize - 1))
+	for i := 0; i < size; i++ {
+		data |= uint32(line.Data[offset+i]) << (8 * i)
+	}
+	return data, true
+}
+
+// fetchLine fills the line holding addr from main memory (demand miss)
+func (c *L1DCache) fetchLine(addr uint32) {
+	lineAddr := addr &^ (CacheLineSize - 1)
+	lineData := make([]byte, CacheLineSize)
+
+	for j := 0; j < CacheLineSize; j++ {
+		if int(lineAddr)+j < len(c.memory) {
+			lineData[j] = c.memory[lineAddr+uint32(j)]
+		}
+	}
+
+	c.Fill(lineAddr, lineData)
+}
+
+// writeBack copies a dirty victim line to main memory
+//
+// The L1D is write-back: without this an evicted store would be lost.
+func (c *L1DCache) writeBack(line *CacheLine, setIdx int) {
+	lineAddr := c.lineAddr(line, setIdx)
+
+	for j := 0; j < CacheLineSize; j++ {
+		if int(lineAddr)+j < len(c.memory) {
+			c.memory[lineAddr+uint32(j)] = line.Data[j]
+		}
+	}
+}
+
+// lineAddr returns the address of a resident line
+func (c *L1DCache) lineAddr(line *CacheLine, setIdx int) uint32 {
+	return line.Tag<<(6+bits.Len32(uint32(L1DNumSets-1))) | uint32(setIdx)<<6
 }
 
 // findVictim selects a line to evict (INNOVATION #19: LRU)
@@ -4170,7 +4617,7 @@ func (c *L1DCache) StoreConditional(addr uint32, data uint32) (success bool, cac
 }
 
 // GetNextPrefetch returns next prefetch request (INNOVATION #67)
-func (c *L1DCache) GetNextPrefetch() (addr uint32, valid bool) {
+func (c *L1DCache) GetNextPrefetch() (addr uint32, predictor PredictorID, valid bool) {
 	return c.prefetchQueue.Dequeue()
 }
 
@@ -4185,6 +4632,22 @@ func (c *L1DCache) GetPredictorAccuracy() float64 {
 	return c.predictor.GetAccuracy()
 }
 
+// Probe finds the line holding addr without touching LRU or statistics
+//
+// USED BY: Debugger and GDB stub (inspection must not perturb the run)
+func (c *L1DCache) Probe(addr uint32) (line *CacheLine, way int, ok bool) {
+	setIdx := c.getSetIndex(addr)
+	tag := c.getTag(addr)
+	set := &c.sets[setIdx]
+
+	for w := 0; w < L1Associativity; w++ {
+		if set[w].Valid && set[w].Tag == tag {
+			return &set[w], w, true
+		}
+	}
+	return nil, -1, false
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // LOAD/STORE UNIT (INNOVATIONS #69-73)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -4261,6 +4724,9 @@ type MemoryOperation struct {
 	IsStore  bool   // Store (true) or load (false)
 	IsAtomic bool   // Is this SC (store conditional)?
 	IsLR     bool   // Is this LR (load reserved)?
+	Size     uint8  // Bytes accessed (1, 2 or 4)
+	Signed   bool   // Narrow load sign-extends
+	NoTrain  bool   // Predicted address: train once verified (see addrspec.go)
 }
 
 // LSU handles one memory operation at a time (INNOVATION #69)
@@ -4269,12 +4735,20 @@ type LSU struct {
 	op        MemoryOperation // Current operation
 	cyclesRem int             // Cycles remaining (INNOVATION #73)
 	dcache    *L1DCache       // Data cache reference
+	waitDRAM  bool            // Current operation missed and is waiting for DRAM
 
 	// Result communication
 	resultValid bool   // Is result ready?
 	resultData  uint32 // Result value
 	resultRd    uint8  // Destination register
 	resultWinID int    // Window ID for result
+
+	// Statistics
+	loads      uint64 // Loads accepted (LW, LR)
+	stores     uint64 // Stores accepted (SW, SC)
+	busyCycles uint64 // Cycles spent processing an operation
+	misses     uint64 // Accesses that had to wait for DRAM
+	scFailures uint64 // Store-conditionals that lost their reservation
 }
 
 // NewLSU creates a load/store unit
@@ -4303,7 +4777,17 @@ func (lsu *LSU) Issue(op MemoryOperation) bool {
 	lsu.busy = true
 	lsu.op = op
 	lsu.cyclesRem = L1Latency // INNOVATION #70: Optimistic 1 cycle
-	lsu.resultValid = false
+	lsu.waitDRAM = false
+
+	// The previous result may still be waiting for the complete stage:
+	// it stays valid until GetResult takes it (the new op needs at least
+	// one Tick, which comes after that cycle's complete stage)
+
+	if op.IsStore {
+		lsu.stores++
+	} else {
+		lsu.loads++
+	}
 
 	return true
 }
@@ -4317,6 +4801,8 @@ func (lsu *LSU) Issue(op MemoryOperation) bool {
 //	STEP 3: If cycles done: Try cache access
 //	STEP 4: On hit: Return result
 //	STEP 5: On miss: Wait more cycles (DRAM latency)
+//	STEP 6: DRAM done: Install the line (write-allocate for stores),
+//	        then finish the access without counting it again
 //
 // VARIABLE LATENCY: Cache hit = 1 cycle, miss = 100 cycles
 //
@@ -4325,6 +4811,7 @@ func (lsu *LSU) Tick() {
 	if !lsu.busy {
 		return
 	}
+	lsu.busyCycles++
 
 	// STEP 1: Count down
 	lsu.cyclesRem--
@@ -4332,6 +4819,13 @@ func (lsu *LSU) Tick() {
 		return // Still waiting
 	}
 
+	// STEP 6: The miss has waited out DRAM latency
+	refill := lsu.waitDRAM
+	if refill {
+		lsu.dcache.fetchLine(lsu.op.Addr)
+		lsu.waitDRAM = false
+	}
+
 	// STEP 3: Time to try cache access
 	if lsu.op.IsStore {
 		// ═══════════════════════════════════════════════════════════════
@@ -4340,16 +4834,30 @@ func (lsu *LSU) Tick() {
 
 		if lsu.op.IsAtomic {
 			// INNOVATION #71: Store conditional (SC)
+			// A missing line means the reservation was lost: just fail
 			success, _ := lsu.dcache.StoreConditional(lsu.op.Addr, lsu.op.Data)
 
 			// SC returns success/failure in destination register
 			lsu.resultData = 0
 			if !success {
 				lsu.resultData = 1 // SC failed
+				lsu.scFailures++
 			}
 		} else {
-			// Regular store
-			lsu.dcache.Write(lsu.op.Addr, lsu.op.Data)
+			// Regular store (SW/SH/SB merge into the line)
+			var hit bool
+			if refill {
+				hit = lsu.dcache.merge(lsu.op.Addr, lsu.op.Data, int(lsu.op.Size))
+			} else {
+				hit = lsu.dcache.WriteSize(lsu.op.Addr, lsu.op.Data, int(lsu.op.Size))
+			}
+			if !hit {
+				// Write-allocate: fetch the line, merge when it arrives
+				lsu.cyclesRem = DRAMLatency
+				lsu.waitDRAM = true
+				lsu.misses++
+				return
+			}
 			lsu.resultData = 0
 		}
 
@@ -4366,12 +4874,26 @@ func (lsu *LSU) Tick() {
 		var data uint32
 		var hit bool
 
-		if lsu.op.IsLR {
+		switch {
+		case refill:
+			// The miss already trained the predictor
+			data, hit = lsu.dcache.peekSize(lsu.op.Addr, int(lsu.op.Size))
+			if hit && lsu.op.IsLR {
+				lsu.dcache.reservationValid = true
+				lsu.dcache.reservationAddr = lsu.op.Addr
+			}
+		case lsu.op.IsLR:
 			// INNOVATION #71: Load reserved (LR)
 			data, hit = lsu.dcache.LoadReserved(lsu.op.PC, lsu.op.Addr)
-		} else {
-			// Regular load
-			data, hit = lsu.dcache.Read(lsu.op.PC, lsu.op.Addr)
+		case lsu.op.NoTrain:
+			data, hit = lsu.dcache.readUntrained(lsu.op.Addr, int(lsu.op.Size))
+		default:
+			// Regular load (LW/LH/LHU/LB/LBU)
+			data, hit = lsu.dcache.ReadSize(lsu.op.PC, lsu.op.Addr, int(lsu.op.Size))
+		}
+		if hit && lsu.op.Signed {
+			shift := 32 - 8*uint(lsu.op.Size)
+			data = uint32(int32(data<<shift) >> shift)
 		}
 
 		if hit {
@@ -4381,10 +4903,13 @@ func (lsu *LSU) Tick() {
 			lsu.resultWinID = lsu.op.WindowID
 			lsu.resultValid = true
 			lsu.busy = false
+			lsu.waitDRAM = false
 		} else {
 			// CACHE MISS! (INNOVATION #73: variable latency)
 			// Need to wait for DRAM
 			lsu.cyclesRem = DRAMLatency
+			lsu.waitDRAM = true
+			lsu.misses++
 		}
 	}
 }
@@ -4394,6 +4919,16 @@ func (lsu *LSU) IsBusy() bool {
 	return lsu.busy
 }
 
+// Cancel abandons the current operation (its instruction was squashed)
+//
+// A store still in the LSU has not written the L1D yet, and it is younger
+// than whatever caused the squash, so it must never write.
+func (lsu *LSU) Cancel() {
+	lsu.busy = false
+	lsu.waitDRAM = false
+	lsu.resultValid = false
+}
+
 // GetResult returns completed operation result
 //
 // RETURNS:
@@ -4531,6 +5066,7 @@ type WindowEntry struct {
 	PhysRs1 uint8  // Physical source 1
 	PhysRs2 uint8  // Physical source 2
 	Imm     int32  // Immediate value
+	Seq     uint64 // Fetch sequence number (for tracing)
 
 	// INNOVATION #53: Dependency tracking
 	Src1Ready bool // Is source 1 value available?
@@ -4551,18 +5087,54 @@ type WindowEntry struct {
 	MemAddr      uint32
 	MemAddrValid bool
 	StoreData    uint32
+	MemSize      uint8 // Bytes accessed (1, 2 or 4)
+	MemSigned    bool  // Narrow load sign-extends
+	GateOK       bool  // Debugger let this commit (stores ask before issue)
 
 	// Branch handling
 	IsBranch      bool
 	BranchTaken   bool
 	BranchTarget  uint32
-	Predicted     bool   // What did we predict?
-	PredictedAddr uint32 // Where did we predict?
+	Predicted     bool         // What did we predict?
+	PredictedAddr uint32       // Where did we predict?
+	TargetSource  TargetSource // What predicted a JALR's target
 
 	// Memory prediction (from L1D predictor)
 	PredictedMemAddr uint32
 	MemPredictor     PredictorID
 	HasMemPrediction bool
+
+	// Load address speculation (see addrspec.go)
+	AddrSpeculated  bool   // Issued at PredictedMemAddr before its base was ready
+	AddrVerified    bool   // Base arrived and the address was checked
+	AddrReplayed    bool   // Address was wrong: reissued at the real one
+	SpecResult      uint32 // Value the speculative access returned
+	SpecResultValid bool   // ...before the replay (dependents may have used it)
+
+	// Load value prediction (see valuepred.go)
+	ValuePredicted    bool           // Dependents were woken with PredictedValue
+	PredictedValue    uint32         // Value supplied at dispatch
+	ValueComponent    ValueComponent // Which component supplied it
+	ValueMispredicted bool           // The load's result differs
+
+	// Trap raised at fetch or execute, taken at commit (see trap.go)
+	Fault     uint8  // Cause (CauseNone = no trap)
+	FaultAddr uint32 // Faulting virtual address
+
+	Priv uint8 // Mode this instruction runs in (PrivUser or PrivMachine)
+}
+
+// EffectiveAddr computes a load/store address from its base register
+func (e *WindowEntry) EffectiveAddr(base uint32) uint32 {
+	return Add32(base, uint32(e.Imm))
+}
+
+// Misaligned reports an address that is not a multiple of the access size
+//
+// Accesses must be naturally aligned so they never straddle a cache line
+// (or a page); issue raises a misaligned trap instead (see trap.go).
+func (e *WindowEntry) Misaligned(addr uint32) bool {
+	return addr&(uint32(e.MemSize)-1) != 0
 }
 
 // RAT (Register Alias Table) implements INNOVATION #37
@@ -4570,11 +5142,16 @@ type WindowEntry struct {
 // Maps architectural registers to physical registers using bitmaps
 type RAT struct {
 	bitmaps [NumArchRegs]uint64 // 32 bitmaps, one per architectural register
+	latest  [NumArchRegs]uint8  // Youngest mapping (InvalidTag = committed value)
 }
 
 // NewRAT creates an initialized RAT
 func NewRAT() *RAT {
-	return &RAT{}
+	rat := &RAT{}
+	for i := range rat.latest {
+		rat.latest[i] = InvalidTag
+	}
+	return rat
 }
 
 // Lookup returns physical register holding an architectural register
@@ -4582,33 +5159,23 @@ func NewRAT() *RAT {
 // ALGORITHM:
 //
 //	STEP 1: Check if register is r0 (always zero, never renamed)
-//	STEP 2: Get bitmap for this architectural register
-//	STEP 3: Find highest set bit (most recent mapping)
-//	STEP 4: Return physical register number
-//
-// WHY HIGHEST BIT: Multiple mappings might exist during speculation
+//	STEP 2: Return the youngest mapping
 //
-//	Highest bit = most recent = correct one
+// WHY NOT THE HIGHEST BIT: Multiple mappings exist during speculation,
+// and the free list hands out the LOWEST free register, so a younger
+// mapping often has a smaller number than an older one:
 //
-// EXAMPLE: r5 mapped to physical registers 9 and 33
+//	r5 → p35 (older), then p33 (younger): bitmap has bits 33 and 35
+//	Highest bit: 35 ❌ (stale value)   latest: 33 ✅
 //
-//	Bitmap: 0x0000_0000_0002_0200 (bits 9 and 33 set)
-//	Highest bit: 33
-//	Return: 33 (most recent mapping) ✅
+// The bitmap still records every live mapping (debugger view, Free).
 func (rat *RAT) Lookup(archReg uint8) uint8 {
 	// r0 is special: always zero, never renamed
 	if archReg == 0 || archReg >= NumArchRegs {
 		return InvalidTag
 	}
 
-	bitmap := rat.bitmaps[archReg]
-	if bitmap == 0 {
-		return InvalidTag // No mapping exists
-	}
-
-	// Find highest set bit (most recent mapping)
-	// LeadingZeros counts from left, we want position from right
-	return uint8(63 - bits.LeadingZeros64(bitmap))
+	return rat.latest[archReg]
 }
 
 // Allocate creates a new mapping (INNOVATION #37)
@@ -4628,6 +5195,7 @@ func (rat *RAT) Allocate(archReg, physReg uint8) {
 
 	// Set bit for this physical register
 	rat.bitmaps[archReg] |= (1 << physReg)
+	rat.latest[archReg] = physReg
 }
 
 // Free removes a mapping when instruction commits
@@ -4643,6 +5211,11 @@ func (rat *RAT) Free(archReg, physReg uint8) {
 
 	// Clear bit for this physical register
 	rat.bitmaps[archReg] &^= (1 << physReg)
+
+	// Youngest mapping committed: the value now lives in the regFile
+	if rat.latest[archReg] == physReg {
+		rat.latest[archReg] = InvalidTag
+	}
 }
 
 // FreeList tracks available physical registers (INNOVATION #38)
@@ -4789,10 +5362,33 @@ type Window struct {
 	physRegFile  [NumPhysRegs]uint32 // Physical register values
 	physRegReady [NumPhysRegs]bool   // Which registers have valid data
 
+	// Optional pipeline tracer (nil when tracing is off)
+	tracer *PipeTracer
+
+	// Debugger attached: stores wait for the gate (see Core.gateStore)
+	gateStores bool
+
+	// A system call is in flight: nothing younger dispatches until it
+	// commits (see syscall.go)
+	serialized bool
+
+	// Privilege mode of the instructions being dispatched. It changes
+	// only at commit (trap, eret), which flushes everything younger, so
+	// it is also the architectural mode (see trap.go)
+	priv uint8
+
+	// Loads may issue at a predicted address (see addrspec.go)
+	addrSpec bool
+
 	// Statistics
-	dispatched uint64
-	issued     uint64
-	committed  uint64
+	dispatched   uint64
+	issued       uint64
+	committed    uint64
+	flushes      uint64 // Recoveries (mispredicts, traps, refetches)
+	squashed     uint64 // Entries discarded by flushes
+	occupancySum uint64 // Sum of per-cycle occupancy (for averages)
+	fullCycles   uint64 // Cycles where dispatch was blocked
+	samples      uint64 // Cycles sampled
 }
 
 // NewWindow creates an initialized instruction window
@@ -4800,6 +5396,7 @@ func NewWindow() *Window {
 	w := &Window{
 		rat:      NewRAT(),
 		freeList: NewFreeList(),
+		priv:     PrivMachine, // Reset mode
 	}
 
 	// Architectural registers start ready (initialized to zero)
@@ -4835,8 +5432,8 @@ func (w *Window) CanDispatch() bool {
 //
 //	Note which ingredients are available
 func (w *Window) Dispatch(inst Instruction) (windowID int, ok bool) {
-	// STEP 1: Check capacity
-	if !w.CanDispatch() {
+	// STEP 1: Check capacity (and wait out a system call)
+	if !w.CanDispatch() || w.serialized {
 		return -1, false
 	}
 
@@ -4858,7 +5455,7 @@ func (w *Window) Dispatch(inst Instruction) (windowID int, ok bool) {
 	src2Ready := inst.Rs2 == 0 || physRs2 == InvalidTag || w.physRegReady[physRs2]
 
 	// Special case: I-format instructions use immediate for rs2
-	if inst.UsesImm && !inst.IsBranch && inst.Opcode != OpSW && inst.Opcode != OpSC {
+	if inst.UsesImm && !inst.IsBranch && !inst.IsStore {
 		src2Ready = true
 		physRs2 = InvalidTag
 	}
@@ -4875,12 +5472,21 @@ func (w *Window) Dispatch(inst Instruction) (windowID int, ok bool) {
 		PhysRs1:   physRs1,
 		PhysRs2:   physRs2,
 		Imm:       inst.Imm,
+		Seq:       inst.Seq,
 		Src1Ready: src1Ready,
 		Src2Ready: src2Ready,
 		Valid:     true,
 		IsLoad:    inst.IsLoad,
 		IsStore:   inst.IsStore,
+		MemSize:   inst.MemSize,
+		MemSigned: inst.MemSigned,
 		IsBranch:  inst.IsBranch,
+		Priv:      w.priv,
+	}
+	if inst.Fault != CauseNone {
+		// The fetch faulted: the entry is a nop that traps at commit
+		entry.Fault = inst.Fault
+		entry.FaultAddr = inst.PC
 	}
 
 	// STEP 6: Update RAT with new mapping
@@ -4889,10 +5495,17 @@ func (w *Window) Dispatch(inst Instruction) (windowID int, ok bool) {
 		w.physRegReady[physRd] = false // Result not ready yet
 	}
 
+	if w.tracer != nil {
+		w.tracer.Dispatch(entry, w.tail)
+	}
+
 	windowID = w.tail
 	w.tail = (w.tail + 1) % WindowSize
 	w.count++
 	w.dispatched++
+	if inst.Opcode == OpSYSTEM {
+		w.serialized = true
+	}
 
 	return windowID, true
 }
@@ -4936,7 +5549,7 @@ func (w *Window) Wakeup(physReg uint8, value uint32) {
 	for i := 0; i < WindowSize; i++ {
 		entry := &w.entries[i]
 
-		if !entry.Valid || entry.Issued {
+		if !entry.Valid || entry.Issued && !entry.awaitsBase() {
 			continue
 		}
 
@@ -4986,8 +5599,22 @@ func (w *Window) SelectReady() []int {
 		idx := (w.head + i) % WindowSize
 		entry := &w.entries[idx]
 
-		// Check if ready
-		if !entry.Valid || entry.Issued || !entry.Src1Ready || !entry.Src2Ready {
+		// Check if ready (a load may go without its base, see addrspec.go)
+		if !entry.Valid || entry.Issued || !entry.Src2Ready ||
+			!entry.Src1Ready && !w.canSpeculateAddr(entry) {
+			continue
+		}
+		if entry.IsStore && w.gateStores && !entry.GateOK {
+			continue // Issuing writes the L1D: the debugger decides first
+		}
+
+		// Memory ordering: a store writes the L1D when it issues, so it
+		// waits until it is the oldest instruction (never on a wrong
+		// path); a load waits for older stores that may overlap it
+		if entry.IsStore && i != 0 {
+			continue
+		}
+		if entry.IsLoad && w.olderStoreConflict(i, entry) {
 			continue
 		}
 
@@ -5004,7 +5631,7 @@ func (w *Window) SelectReady() []int {
 				divCount++
 				canIssue = true
 			}
-		case OpLW, OpSW, OpLR, OpSC:
+		case OpLW, OpSW, OpLR, OpSC, OpLDN, OpSTN:
 			if lsuCount < NumLSUs {
 				lsuCount++
 				canIssue = true
@@ -5024,11 +5651,52 @@ func (w *Window) SelectReady() []int {
 	return ready
 }
 
+// olderStoreConflict reports whether a load (n entries after the head)
+// must wait for an older store
+//
+// ALGORITHM:
+//
+//	FOR each older store that has not written the L1D yet:
+//	  Address unknown (base not ready) → conflict (be conservative)
+//	  Bytes overlap the load's bytes   → conflict (load would read stale data)
+//
+// MINECRAFT ANALOGY: Don't take from a chest while a hopper that fills
+//
+//	it is still on its way
+func (w *Window) olderStoreConflict(n int, load *WindowEntry) bool {
+	addr := load.PredictedMemAddr // Base not ready: the address it would use
+	if load.Src1Ready {
+		addr = load.EffectiveAddr(w.ReadReg(load.Rs1, load.PhysRs1))
+	}
+
+	for i := 0; i < n; i++ {
+		st := &w.entries[(w.head+i)%WindowSize]
+		if !st.Valid || !st.IsStore || st.Executed {
+			continue
+		}
+		if !st.Src1Ready {
+			return true
+		}
+		stAddr := st.MemAddr
+		if !st.MemAddrValid {
+			stAddr = st.EffectiveAddr(w.ReadReg(st.Rs1, st.PhysRs1))
+		}
+		if addr < stAddr+uint32(st.MemSize) && stAddr < addr+uint32(load.MemSize) {
+			return true
+		}
+	}
+	return false
+}
+
 // MarkIssued marks instruction as sent to execution
 func (w *Window) MarkIssued(windowID int) {
 	if windowID >= 0 && windowID < WindowSize {
 		w.entries[windowID].Issued = true
 		w.issued++
+
+		if w.tracer != nil {
+			w.tracer.Issue(w.entries[windowID].Seq)
+		}
 	}
 }
 
@@ -5054,6 +5722,15 @@ func (w *Window) Complete(windowID int, result uint32) {
 	entry.ResultValid = true
 	entry.Executed = true
 
+	// A predicted load checks its own value (see valuepred.go)
+	if entry.ValuePredicted {
+		entry.ValueMispredicted = result != entry.PredictedValue
+	}
+
+	if w.tracer != nil {
+		w.tracer.Complete(entry.Seq)
+	}
+
 	// INNOVATION #55: Wakeup dependent instructions
 	if entry.PhysRd != InvalidTag {
 		w.Wakeup(entry.PhysRd, result)
@@ -5115,8 +5792,8 @@ func (w *Window) Commit() *WindowEntry {
 
 	entry := &w.entries[w.head]
 
-	// Can only commit if executed
-	if !entry.Valid || !entry.Executed {
+	// Can only commit if executed (at an address known to be right)
+	if !entry.Valid || !entry.Executed || entry.AddrSpeculated && !entry.AddrVerified {
 		return nil
 	}
 
@@ -5129,10 +5806,14 @@ func (w *Window) Commit() *WindowEntry {
 	if entry.PhysRd != InvalidTag {
 		w.rat.Free(entry.Rd, entry.PhysRd)
 		w.freeList.Free(entry.PhysRd)
+		w.retarget(entry.PhysRd)
 	}
 
 	// Save entry info before clearing
 	committed := *entry
+	if committed.Opcode == OpSYSTEM {
+		w.serialized = false
+	}
 
 	// Clear entry
 	entry.Valid = false
@@ -5142,9 +5823,35 @@ func (w *Window) Commit() *WindowEntry {
 	w.count--
 	w.committed++
 
+	if w.tracer != nil {
+		w.tracer.Retire(&committed)
+	}
+
 	return &committed
 }
 
+// retarget points waiting readers of a freed physical register at the
+// architectural register instead
+//
+// The register may be reallocated and rewritten before a reader issues.
+// The committed value stays in the regFile until the next writer of that
+// architectural register commits, and that writer is younger than every
+// reader, so the regFile is the right place to read from.
+func (w *Window) retarget(physReg uint8) {
+	for i := 0; i < WindowSize; i++ {
+		entry := &w.entries[i]
+		if !entry.Valid || entry.Issued && !entry.awaitsBase() {
+			continue
+		}
+		if entry.PhysRs1 == physReg {
+			entry.PhysRs1 = InvalidTag
+		}
+		if entry.PhysRs2 == physReg {
+			entry.PhysRs2 = InvalidTag
+		}
+	}
+}
+
 // Flush clears all entries (INNOVATION #48: mispredict recovery)
 //
 // ALGORITHM:
@@ -5164,12 +5871,18 @@ func (w *Window) Commit() *WindowEntry {
 //	Works correctly for nested mispredictions
 //	Fast enough (mispredictions are rare)
 func (w *Window) Flush() {
+	w.flushes++
+	w.squashed += uint64(w.count)
+
 	// STEP 1: Free all allocated physical registers
 	for i := 0; i < WindowSize; i++ {
 		entry := &w.entries[i]
 		if entry.Valid && entry.PhysRd != InvalidTag {
 			w.freeList.Free(entry.PhysRd)
 		}
+		if entry.Valid && w.tracer != nil {
+			w.tracer.Squash(entry.Seq)
+		}
 		entry.Valid = false
 	}
 
@@ -5177,6 +5890,7 @@ func (w *Window) Flush() {
 	w.head = 0
 	w.tail = 0
 	w.count = 0
+	w.serialized = false
 
 	// STEP 4: Reset RAT (INNOVATION #48)
 	w.rat = NewRAT()
@@ -5187,6 +5901,23 @@ func (w *Window) GetCount() int {
 	return w.count
 }
 
+// Head returns the oldest in-flight instruction (nil if window is empty)
+func (w *Window) Head() *WindowEntry {
+	if w.count == 0 {
+		return nil
+	}
+	return &w.entries[w.head]
+}
+
+// sampleOccupancy records this cycle's fill level for statistics
+func (w *Window) sampleOccupancy() {
+	w.samples++
+	w.occupancySum += uint64(w.count)
+	if !w.CanDispatch() {
+		w.fullCycles++
+	}
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // EXECUTION UNITS (INNOVATIONS #56-58)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -5371,6 +6102,12 @@ func (m *Multiplier) IsBusy() bool {
 	return m.busy
 }
 
+// Cancel drops a result nobody will collect (its instruction was squashed)
+func (m *Multiplier) Cancel() {
+	m.busy = false
+	m.completed = false
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // THE COMPLETE CPU (INTEGRATION OF ALL INNOVATIONS)
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -5447,6 +6184,62 @@ type Core struct {
 	// Main memory (simplified - in reality this is DRAM)
 	memory []byte
 
+	// CPI stack accounting (see cpistack.go)
+	cpi         CPIStack    // Commit slots by cause, whole run
+	cpiRegions  []CPIRegion // Commit slots by cause, per code region
+	recovering  bool        // Window refilling after a mispredict flush
+	fetchMissed bool        // Last fetch stage missed in the L1I
+
+	// Architectural PC: address of the next instruction to commit
+	archPC uint32
+
+	// Debugger hook (see debugger.go): called with the head before each
+	// commit (before issue for a store, see gateStore); returning false
+	// holds the head for this cycle
+	commitGate func(head *WindowEntry) bool
+
+	// Pipeline tracing (see pipetrace.go)
+	fetchSeq uint64      // Sequence number of the next fetched instruction
+	tracer   *PipeTracer // nil when tracing is off
+
+	// Names for addresses (see symbols.go); nil when none were loaded
+	symbols *SymbolTable
+
+	// Calling convention checker (see abi.go); nil when off
+	abiCheck *ABIChecker
+
+	// System calls (see syscall.go)
+	console  io.Writer // Receives SysWrite output; nil discards it
+	halted   bool      // SysExit committed: Cycle does nothing
+	exitCode uint32
+
+	// Virtual memory and traps (see mmu.go, trap.go)
+	mmu        *MMU    // ITLB + DTLB + page walker (off until CSRPtbr enables it)
+	csr        csrFile // Trap CSRs
+	fetchFault bool    // Fetch stopped at a faulting address until redirected
+	traps      uint64  // Traps taken
+
+	// Memory-mapped devices (see bus.go)
+	bus *Bus
+
+	// Load address speculation (see addrspec.go)
+	addrSpec addrSpecState
+
+	// Load value prediction (see valuepred.go)
+	valuePred *ValuePredictor
+
+	// Committed loads for offline predictor evaluation (see addrtrace.go)
+	traceLoads bool
+	loadTrace  []LoadRecord
+
+	// Committed conditional branches, likewise (see branchtrace.go)
+	traceBranches bool
+	branchTrace   []BranchRecord
+
+	// Conditional branch directions at fetch (see specdirection.go); nil
+	// leaves them to the 4-bit counters
+	direction SpeculativeDirectionPredictor
+
 	// Statistics
 	cycles            uint64
 	instructions      uint64
@@ -5454,6 +6247,13 @@ type Core struct {
 	branchMispredicts uint64
 	loads             uint64
 	stores            uint64
+
+	// Functional unit statistics (INNOVATIONS #56-58)
+	aluOps        uint64 // Single-cycle ALU operations issued
+	branchOps     uint64 // Branches and jumps resolved in the ALUs
+	mulOps        uint64 // Multiplies issued
+	divOps        uint64 // Divides and remainders issued
+	divBusyCycles uint64 // Cycles the divider spent iterating
 }
 
 // NewCore creates an initialized SUPRAX-32 processor
@@ -5470,6 +6270,7 @@ func NewCore(memorySize int) *Core {
 		icache:         NewL1ICache(),
 		dcache:         NewL1DCache(),
 		branchPred:     NewBranchPredictor(),
+		valuePred:      NewValuePredictor(),
 		window:         NewWindow(),
 		multiplier:     &Multiplier{},
 		divider:        &Divider{},
@@ -5478,6 +6279,13 @@ func NewCore(memorySize int) *Core {
 		memory:         make([]byte, memorySize),
 	}
 
+	// Demand misses and write-backs go to main memory
+	c.dcache.memory = c.memory
+
+	// Page walks read through the L1D
+	c.mmu = NewMMU(c.dcache)
+	c.bus = NewBus()
+
 	// Initialize LSUs (INNOVATION #69: 2 independent units)
 	for i := range c.lsus {
 		c.lsus[i] = NewLSU(c.dcache)
@@ -5505,6 +6313,43 @@ func (c *Core) LoadProgram(program []uint32, startAddr uint32) {
 	}
 
 	c.pc = startAddr
+	c.archPC = startAddr
+}
+
+// LoadImage loads a linked image (link.go) at its intended addresses
+//
+// ALGORITHM:
+//
+//	FOR each segment:
+//	  Copy its initialised bytes, zero the rest (bss)
+//	Install the image's symbols (see symbols.go)
+//	Build argc/argv (no envp) at the top of memory, set sp
+//	Set PC to the entry point
+func (c *Core) LoadImage(img *Image, argv ...string) error {
+	top := uint64(0)
+	for _, seg := range img.Segments {
+		end := uint64(seg.Addr) + uint64(seg.Size)
+		if end > uint64(len(c.memory)) {
+			return fmt.Errorf("%s at 0x%08x+0x%x does not fit in %d bytes of memory",
+				seg.Name, seg.Addr, seg.Size, len(c.memory))
+		}
+		mem := c.memory[seg.Addr:end]
+		n := copy(mem, seg.Data)
+		clear(mem[n:])
+		top = max(top, end)
+	}
+
+	// Same entry state as LoadELF, so crt0 works with either loader
+	sp, err := c.initStack(argv, nil, top)
+	if err != nil {
+		return err
+	}
+
+	c.SetSymbols(img.SymbolTable())
+	c.window.regFile[RegSP] = sp
+	c.pc = img.Entry
+	c.archPC = img.Entry
+	return nil
 }
 
 // ReadMemWord reads a 32-bit word from memory
@@ -5542,7 +6387,15 @@ func (c *Core) WriteMemWord(addr uint32, data uint32) {
 //
 // MINECRAFT ANALOGY: All 7 crafting stations work simultaneously
 func (c *Core) Cycle() {
+	if c.halted {
+		return
+	}
 	c.cycles++
+	c.window.sampleOccupancy()
+
+	if c.tracer != nil {
+		c.tracer.Cycle(c.cycles)
+	}
 
 	// ═══════════════════════════════════════════════════════════════════════
 	// STAGE 1: COMMIT (INNOVATION #45, #47, #48)
@@ -5556,13 +6409,68 @@ func (c *Core) Cycle() {
 	// INNOVATION #47: Program-order commit (precise exceptions)
 	// INNOVATION #48: Branch mispredict recovery (flush on wrong prediction)
 
+	committedBefore := c.instructions
 	for i := 0; i < CommitWidth; i++ {
+		if head := c.window.Head(); c.commitGate != nil && head != nil &&
+			head.Executed && !head.GateOK && !c.commitGate(head) {
+			break // Held by the debugger
+		}
+
+		// Precise trap: everything older has committed (see trap.go)
+		if head := c.window.Head(); head != nil && head.Executed && head.Fault != CauseNone {
+			c.trap(head)
+			c.recovering = true
+			c.accountLostSlots(CommitWidth - i)
+			return
+		}
+
 		committed := c.window.Commit()
 		if committed == nil {
 			break // No more ready to commit
 		}
 
 		c.instructions++
+		c.retireSlot(committed.PC)
+		if c.abiCheck != nil {
+			c.abiCheck.retire(committed, &c.window.regFile, c.cycles)
+		}
+		if committed.Opcode == OpSYSTEM {
+			next, redirect := c.system(committed)
+			if c.halted {
+				return
+			}
+			if redirect {
+				// Control state changed: refetch under it (see trap.go)
+				c.archPC = next
+				c.redirect(next)
+				c.recovering = true
+				c.accountLostSlots(CommitWidth - (i + 1))
+				return
+			}
+		}
+
+		// Track the architectural PC (what a debugger sees)
+		c.archPC = committed.PC + 4
+		if committed.BranchTaken {
+			c.archPC = committed.BranchTarget
+		}
+
+		// A load whose dependents may have used a wrong value: a replayed
+		// first value (see addrspec.go) or a mispredicted one (see
+		// valuepred.go). Refetch everything younger.
+		if committed.IsLoad {
+			c.traceLoad(committed)
+			refetch := c.commitLoad(committed)
+			if c.commitValue(committed) {
+				refetch = true
+			}
+			if refetch {
+				c.redirect(committed.PC + 4)
+				c.recovering = true
+				c.accountLostSlots(CommitWidth - (i + 1))
+				return
+			}
+		}
 
 		// Check branches for misprediction (INNOVATION #48)
 		if committed.IsBranch || committed.Opcode == OpJAL || committed.Opcode == OpJALR {
@@ -5570,6 +6478,12 @@ func (c *Core) Cycle() {
 
 			actualTaken := committed.BranchTaken
 			actualTarget := committed.BranchTarget
+			if committed.IsBranch {
+				c.valuePred.commitBranch(actualTaken)
+				c.traceBranch(committed)
+			}
+			c.branchPred.indirect.commit(committed)
+			c.commitDirection(committed)
 
 			// Compare prediction to reality
 			if actualTaken != committed.Predicted ||
@@ -5578,16 +6492,11 @@ func (c *Core) Cycle() {
 				// MISPREDICT! (INNOVATION #48: Recovery)
 				c.branchMispredicts++
 
-				// Flush all speculative work
-				c.window.Flush()
-				c.fetchBuffer = c.fetchBuffer[:0]
-				c.icache.Flush()
-
-				// Restart from correct path
+				// Flush all speculative work, restart from correct path
 				if actualTaken {
-					c.pc = actualTarget
+					c.redirect(actualTarget)
 				} else {
-					c.pc = committed.PC + 4
+					c.redirect(committed.PC + 4)
 				}
 
 				// Update branch predictor (learn from mistake)
@@ -5596,6 +6505,10 @@ func (c *Core) Cycle() {
 				// Notify L1I about branch resolution (INNOVATION #23)
 				c.icache.NotifyBranchResolved(committed.PC, actualTaken, actualTarget)
 
+				// Remaining commit slots are lost to the recovery
+				c.recovering = true
+				c.accountLostSlots(CommitWidth - (i + 1))
+
 				return // Restart pipeline
 			}
 
@@ -5612,6 +6525,9 @@ func (c *Core) Cycle() {
 		}
 	}
 
+	// Attribute the slots we could not fill (see cpistack.go)
+	c.accountLostSlots(CommitWidth - int(c.instructions-committedBefore))
+
 	// ═══════════════════════════════════════════════════════════════════════
 	// STAGE 2: COMPLETE (INNOVATION #55: Result forwarding)
 	// ═══════════════════════════════════════════════════════════════════════
@@ -5638,6 +6554,9 @@ func (c *Core) Cycle() {
 			c.window.Complete(winID, data)
 		}
 	}
+	if winID, data, ok := c.bus.result(); ok {
+		c.window.Complete(winID, data)
+	}
 
 	// ═══════════════════════════════════════════════════════════════════════
 	// STAGE 3: EXECUTE (INNOVATION #73: Variable latency)
@@ -5647,10 +6566,15 @@ func (c *Core) Cycle() {
 	// Divider: Newton-Raphson iterations (INNOVATION #13-15)
 	// LSUs: Cache access or DRAM wait (INNOVATION #70, #73)
 
+	if c.divider.Busy {
+		c.divBusyCycles++
+	}
 	c.divider.Tick()
 	for _, lsu := range c.lsus {
 		lsu.Tick()
 	}
+	c.mmu.tick() // Page walker (see mmu.go)
+	c.bus.tick() // Devices and the uncached access (see bus.go)
 
 	// ═══════════════════════════════════════════════════════════════════════
 	// STAGE 4: ISSUE (INNOVATION #43: 6-wide issue)
@@ -5664,10 +6588,21 @@ func (c *Core) Cycle() {
 	//   Select: Age-based priority (oldest first)
 	//   Issue: Up to 6 per cycle
 
+	c.gateStore()
+	c.verifyAddrSpec() // Loads issued at a predicted address (see addrspec.go)
+
 	readyList := c.window.SelectReady() // INNOVATION #42: Age-based
 	lsuIdx := 0                         // Track which LSU to use
 
-	for _, winID := range readyList {
+	// A load selected without its base goes at its prediction even if an
+	// ALU below wakes the base this cycle: the older-store check covered
+	// only the predicted address
+	var atPrediction [IssueWidth]bool
+	for k, winID := range readyList {
+		atPrediction[k] = !c.window.entries[winID].Src1Ready
+	}
+
+	for k, winID := range readyList {
 		entry := c.window.GetEntry(winID)
 		if entry == nil {
 			continue
@@ -5703,57 +6638,152 @@ func (c *Core) Cycle() {
 
 		case OpDIV:
 			// INNOVATION #58: 4-cycle divide
-			if !c.divider.Busy {
+			// A result finished this cycle is collected next cycle;
+			// starting a new division now would overwrite it.
+			if !c.divider.Busy && !c.divider.Done {
 				c.divider.StartDivision(op1, op2, winID, false)
 				issued = true
 			}
 
 		case OpREM:
 			// INNOVATION #58: 4-cycle remainder
-			if !c.divider.Busy {
+			if !c.divider.Busy && !c.divider.Done {
 				c.divider.StartDivision(op1, op2, winID, true)
 				issued = true
 			}
 
-		case OpLW, OpLR:
+		case OpLW, OpLR, OpLDN:
 			// INNOVATION #69-73: Load operation
 			if lsuIdx < NumLSUs && !c.lsus[lsuIdx].IsBusy() {
 				// INNOVATION #7: Carry-select adder for address
-				addr := Add32(op1, uint32(entry.Imm))
+				addr := entry.EffectiveAddr(op1)
+				paddr := addr
+				speculate := atPrediction[k]
+				if speculate {
+					// Base not ready: go at the predicted address, which
+					// is physical (see addrspec.go); never guess a device
+					// or an address the load could not use
+					addr, paddr = entry.PredictedMemAddr, entry.PredictedMemAddr
+					if c.bus.decode(paddr) != nil || entry.Misaligned(addr) {
+						break
+					}
+					entry.AddrSpeculated = true
+				} else if entry.Misaligned(addr) {
+					c.fault(winID, CauseLoadMisaligned, addr)
+					issued = true
+					break
+				} else {
+					// DTLB (see mmu.go): wait out a walk, or fault at commit
+					var cause uint8
+					var ok bool
+					paddr, cause, ok = c.mmu.translate(addr, accessLoad, entry.Priv)
+					if !ok {
+						break
+					}
+					if cause != CauseNone {
+						c.fault(winID, cause, addr)
+						issued = true
+						break
+					}
+				}
+				entry.MemAddr = addr
+				entry.MemAddrValid = true
+
+				// Device register: uncached, and only once nothing older
+				// can squash it (see bus.go)
+				if dev := c.bus.decode(paddr); dev != nil {
+					if winID != c.window.head || c.bus.busy() {
+						break
+					}
+					c.bus.load(dev, winID, paddr, entry.MemSize, entry.MemSigned)
+					issued = true
+					c.loads++
+					break
+				}
 
 				c.lsus[lsuIdx].Issue(MemoryOperation{
 					PC:       entry.PC,
-					Addr:     addr,
+					Addr:     paddr,
 					Rd:       entry.Rd,
 					WindowID: winID,
 					IsStore:  false,
 					IsLR:     entry.Opcode == OpLR, // INNOVATION #71
+					Size:     entry.MemSize,
+					Signed:   entry.MemSigned,
+					NoTrain:  speculate,
 				})
 				lsuIdx++
 				issued = true
 				c.loads++
 			}
 
-		case OpSW, OpSC:
+		case OpSW, OpSC, OpSTN:
 			// INNOVATION #69-73: Store operation
 			if lsuIdx < NumLSUs && !c.lsus[lsuIdx].IsBusy() {
-				addr := Add32(op1, uint32(entry.Imm))
+				addr := entry.EffectiveAddr(op1)
 				storeData := c.window.ReadReg(entry.Rs2, entry.PhysRs2)
+				entry.MemAddr = addr
+				entry.MemAddrValid = true
+				entry.StoreData = storeData
+
+				// A faulting store never reaches the LSU, so memory is
+				// untouched when the trap is taken
+				if entry.Misaligned(addr) {
+					c.fault(winID, CauseStoreMisaligned, addr)
+					issued = true
+					break
+				}
+				paddr, cause, ok := c.mmu.translate(addr, accessStore, entry.Priv)
+				if !ok {
+					break
+				}
+				if cause != CauseNone {
+					c.fault(winID, cause, addr)
+					issued = true
+					break
+				}
+
+				// Device register: stores already issue only at the
+				// head, so this is non-speculative (see bus.go)
+				if dev := c.bus.decode(paddr); dev != nil {
+					if c.bus.busy() {
+						break
+					}
+					if entry.Opcode == OpSC {
+						c.bus.fail(winID, 1) // No reservation on a device
+					} else {
+						c.bus.store(dev, winID, paddr, entry.MemSize, storeData)
+					}
+					issued = true
+					c.stores++
+					break
+				}
 
 				c.lsus[lsuIdx].Issue(MemoryOperation{
 					PC:       entry.PC,
-					Addr:     addr,
+					Addr:     paddr,
 					Data:     storeData,
 					Rd:       entry.Rd,
 					WindowID: winID,
 					IsStore:  true,
 					IsAtomic: entry.Opcode == OpSC, // INNOVATION #71
+					Size:     entry.MemSize,
 				})
 				lsuIdx++
 				issued = true
 				c.stores++
 			}
 
+		case OpSYSTEM:
+			// Acts at commit; the privilege check is made here so a
+			// forbidden one traps precisely instead (see trap.go)
+			if cause := systemCheck(entry); cause != CauseNone {
+				c.fault(winID, cause, 0)
+			} else {
+				c.window.Complete(winID, 0)
+			}
+			issued = true
+
 		case OpBEQ, OpBNE, OpBLT, OpBGE:
 			// Branch evaluation
 			taken := EvaluateBranch(entry.Opcode, op1, op2)
@@ -5768,9 +6798,6 @@ func (c *Core) Cycle() {
 			result := entry.PC + 4 // Return address
 			target := uint32(int32(entry.PC) + entry.Imm)
 
-			// INNOVATION #31: Push return address to RSB
-			c.branchPred.PushRSB(result)
-
 			entry.BranchTaken = true
 			entry.BranchTarget = target
 			c.window.Complete(winID, result)
@@ -5795,6 +6822,19 @@ func (c *Core) Cycle() {
 
 		if issued {
 			c.window.MarkIssued(winID)
+
+			switch {
+			case entry.Opcode == OpMUL || entry.Opcode == OpMULH:
+				c.mulOps++
+			case entry.Opcode == OpDIV || entry.Opcode == OpREM:
+				c.divOps++
+			case entry.IsLoad || entry.IsStore:
+				// Counted by the LSUs
+			case entry.IsBranch || entry.Opcode == OpJAL || entry.Opcode == OpJALR:
+				c.branchOps++
+			default:
+				c.aluOps++
+			}
 		}
 	}
 
@@ -5822,19 +6862,11 @@ func (c *Core) Cycle() {
 
 		entry := c.window.GetEntry(winID)
 		if entry != nil {
-			// Store branch predictions
+			// Branch predictions were made at fetch (INNOVATION #29-32)
 			if inst.IsBranch || inst.IsJump {
-				// INNOVATION #29-32: Branch prediction
-				predicted, _ := c.branchPred.Predict(inst.PC)
-				predTarget := c.branchPred.PredictTarget(inst.PC, inst)
-				entry.Predicted = predicted
-				entry.PredictedAddr = predTarget
-			}
-
-			// For jumps, always predict taken
-			if inst.IsJump {
-				entry.Predicted = true
-				entry.PredictedAddr = c.branchPred.PredictTarget(inst.PC, inst)
+				entry.Predicted = inst.Predicted
+				entry.PredictedAddr = inst.PredictedAddr
+				entry.TargetSource = inst.TargetSource
 			}
 
 			// Query L1D predictor for loads (INNOVATION #59)
@@ -5846,12 +6878,17 @@ func (c *Core) Cycle() {
 					entry.HasMemPrediction = true
 
 					// INNOVATION #67-68: Queue prefetch with deduplication
-					c.dcache.prefetchQueue.Enqueue(predAddr, predictor)
+					c.dcache.queuePrefetch(inst.PC, predAddr, predictor)
 				}
 			}
+
+			// Load values and the history they are predicted under
+			// (see valuepred.go)
+			c.predictValue(entry)
 		}
 
 		dispatched++
+		c.recovering = false // Correct-path work has reached the window
 	}
 
 	// ═══════════════════════════════════════════════════════════════════════
@@ -5863,14 +6900,34 @@ func (c *Core) Cycle() {
 	// Predict branches (INNOVATION #29-33)
 	// Fill fetch buffer
 
-	if len(c.fetchBuffer) < c.fetchBufferMax {
+	c.fetchMissed = false
+	if len(c.fetchBuffer) < c.fetchBufferMax && !c.fetchFault {
 		for i := 0; i < DispatchWidth && len(c.fetchBuffer) < c.fetchBufferMax; i++ {
+			// ITLB (see mmu.go): wait out a walk, or send the fault down
+			// the pipe and stop fetching until a redirect
+			fetchAddr, cause, ok := c.mmu.translate(c.pc, accessFetch, c.window.priv)
+			if !ok {
+				break
+			}
+			if cause != CauseNone {
+				inst := Instruction{PC: c.pc, Seq: c.fetchSeq, Fault: cause}
+				c.fetchSeq++
+				c.fetchBuffer = append(c.fetchBuffer, inst)
+				if c.tracer != nil {
+					c.tracer.Fetch(inst)
+				}
+				c.fetchFault = true
+				break
+			}
+
 			// INNOVATION #21-28: Quad-buffered L1I with smart prefetch
-			word, hit := c.icache.Read(c.pc)
+			word, hit := c.icache.Read(fetchAddr)
 
 			if !hit {
+				c.fetchMissed = true
+
 				// Cache miss - fetch from memory
-				lineAddr := c.pc &^ (CacheLineSize - 1)
+				lineAddr := fetchAddr &^ (CacheLineSize - 1)
 				lineData := make([]byte, CacheLineSize)
 
 				// In real hardware, this triggers DRAM access
@@ -5884,7 +6941,7 @@ func (c *Core) Cycle() {
 				c.icache.Fill(lineAddr, lineData)
 
 				// Try again
-				word, hit = c.icache.Read(c.pc)
+				word, hit = c.icache.Read(fetchAddr)
 				if !hit {
 					break // Still missing, wait
 				}
@@ -5892,23 +6949,44 @@ func (c *Core) Cycle() {
 
 			// INNOVATION #5: Single-cycle decode
 			inst := DecodeInstruction(word, c.pc)
-			c.fetchBuffer = append(c.fetchBuffer, inst)
+			inst.Seq = c.fetchSeq
+			c.fetchSeq++
 
 			// Update PC based on prediction
 			if inst.IsBranch || inst.IsJump {
-				// INNOVATION #29-33: Predict branch/jump target
-				predTarget := c.branchPred.PredictTarget(c.pc, inst)
+				// INNOVATION #29-33: Predict branch/jump target, once.
+				// Commit compares against exactly this prediction, so
+				// asking again later could let a wrong path retire.
+				taken, conf := c.branchPred.Predict(inst.PC)
+				predTarget, source := c.branchPred.PredictTarget(c.pc, inst)
+				taken, predTarget = c.predictDirection(inst, taken, predTarget)
+				inst.Predicted = taken || inst.IsJump
+				inst.PredictedAddr = predTarget
+				inst.TargetSource = source
+				c.branchPred.indirect.speculate(inst, inst.Predicted, predTarget)
+
+				// INNOVATION #31: Calls push in fetch order, direct
+				// (JAL) and through a pointer (JALR) alike
+				if (inst.Opcode == OpJAL || inst.Opcode == OpJALR) && inst.Rd == 1 {
+					c.branchPred.PushRSB(inst.PC + 4)
+				}
 				c.pc = predTarget
 
 				// INNOVATION #22, #32: Confidence-based prefetch
-				_, conf := c.branchPred.Predict(inst.PC)
 				// Convert 4-bit confidence (0-15) to float32 (0.0-1.0)
 				confFloat := float32(conf) / 15.0
-				c.icache.TriggerBranchTargetPrefetch(predTarget, confFloat)
+				if target, ok := c.mmu.probeFetch(predTarget, c.window.priv); ok {
+					c.icache.TriggerBranchTargetPrefetch(target, confFloat)
+				}
 			} else {
 				// Sequential execution
 				c.pc += 4
 			}
+
+			c.fetchBuffer = append(c.fetchBuffer, inst)
+			if c.tracer != nil {
+				c.tracer.Fetch(inst)
+			}
 		}
 	}
 
@@ -5923,8 +7001,10 @@ func (c *Core) Cycle() {
 	//   We rely on intelligent prefetching instead
 	//   Saves 530M transistors! 🎯
 
-	// L1I prefetch (INNOVATION #22, #27)
-	if prefetchAddr, valid := c.icache.GetPrefetchAddr(); valid {
+	// L1I prefetch (INNOVATION #22, #27), or a plugged-in prefetcher
+	if c.icache.port != nil {
+		c.prefetchL1I()
+	} else if prefetchAddr, valid := c.icache.GetPrefetchAddr(); valid {
 		lineAddr := prefetchAddr &^ (CacheLineSize - 1)
 		lineData := make([]byte, CacheLineSize)
 
@@ -5937,22 +7017,14 @@ func (c *Core) Cycle() {
 		c.icache.Fill(lineAddr, lineData)
 	}
 
-	// L1D prefetch (INNOVATION #59, #67)
-	if prefetchAddr, valid := c.dcache.GetNextPrefetch(); valid {
+	// L1D prefetch (INNOVATION #59, #67), or a plugged-in prefetcher
+	c.dcache.throttleTick(c.cycles)
+	if prefetchAddr, predictor, valid := c.nextL1DPrefetch(); valid {
 		// Check if already in cache
-		setIdx := c.dcache.getSetIndex(prefetchAddr)
-		tag := c.dcache.getTag(prefetchAddr)
-		inCache := false
-
-		for way := 0; way < L1Associativity; way++ {
-			if c.dcache.sets[setIdx][way].Valid && c.dcache.sets[setIdx][way].Tag == tag {
-				inCache = true
-				break
-			}
-		}
-
-		// Fetch if not in cache
-		if !inCache {
+		if _, _, inCache := c.dcache.Probe(prefetchAddr); inCache {
+			c.dcache.prefetchQueue.Discard(prefetchAddr)
+		} else {
+			// Fetch if not in cache
 			lineAddr := prefetchAddr &^ (CacheLineSize - 1)
 			lineData := make([]byte, CacheLineSize)
 
@@ -5962,21 +7034,83 @@ func (c *Core) Cycle() {
 				}
 			}
 
-			c.dcache.Fill(lineAddr, lineData)
+			c.dcache.fillPrefetch(lineAddr, lineData, predictor)
 		}
 	}
 }
 
+// redirect throws away all speculative work and restarts fetch at pc
+//
+// USED BY: Mispredict recovery, traps, and system instructions that
+// change how the next instruction is fetched
+func (c *Core) redirect(pc uint32) {
+	c.window.Flush()
+	c.squashUnits()
+	c.valuePred.squash()
+	c.branchPred.indirect.squash()
+	if c.direction != nil {
+		c.direction.Flush()
+	}
+	if c.tracer != nil {
+		for _, inst := range c.fetchBuffer {
+			c.tracer.Squash(inst.Seq)
+		}
+	}
+	c.fetchBuffer = c.fetchBuffer[:0]
+	c.icache.Flush()
+	c.fetchFault = false
+	c.pc = pc
+}
+
+// squashUnits cancels work in the execution units after a window flush
+//
+// The flush frees every window slot, so a result still in flight would
+// land in whatever instruction reuses its slot.
+func (c *Core) squashUnits() {
+	c.multiplier.Cancel()
+	c.divider.Cancel()
+	for _, lsu := range c.lsus {
+		lsu.Cancel()
+	}
+	c.bus.flush()
+}
+
+// gateStore asks the debugger whether the head store may commit
+//
+// A store writes the L1D when it issues, not when it commits. Asked at
+// commit, the gate could only stop on a store whose value is already in
+// memory, and squashing younger work (SetPC) would leave their stores
+// applied. So with a debugger attached, a store waits until it is the
+// head and its operands are ready, then asks the gate; SelectReady holds
+// it until the gate agrees. A stop therefore leaves memory exactly as
+// the committed instructions left it.
+func (c *Core) gateStore() {
+	c.window.gateStores = c.commitGate != nil
+
+	head := c.window.Head()
+	if c.commitGate == nil || head == nil || !head.IsStore || head.Issued || head.GateOK ||
+		!head.Src1Ready || !head.Src2Ready {
+		return
+	}
+
+	// Watchpoints need the address
+	op1 := c.window.ReadReg(head.Rs1, head.PhysRs1)
+	head.MemAddr = head.EffectiveAddr(op1)
+	head.MemAddrValid = true
+
+	head.GateOK = c.commitGate(head)
+}
+
 // Run executes for the specified number of cycles
 //
 // ALGORITHM:
 //
-//	FOR each cycle until limit:
+//	FOR each cycle until limit (or until the program exits):
 //	  Execute one cycle
 //
 // USED BY: Benchmark and test programs
 func (c *Core) Run(maxCycles uint64) {
-	for c.cycles < maxCycles {
+	for c.cycles < maxCycles && !c.halted {
 		c.Cycle()
 	}
 }
@@ -6108,6 +7242,33 @@ func EncodeBFormat(opcode, rs1, rs2 uint8, imm int32) uint32 {
 		(uint32(imm) & 0x1FFFF)
 }
 
+// EncodeSFormat creates a word store (OpSW)
+//
+// S-FORMAT: [opcode:5][rs2:5][rs1:5][immediate:17]
+// Stores rs2 to memory[rs1 + imm]
+func EncodeSFormat(opcode, rs1, rs2 uint8, imm int32) uint32 {
+	return EncodeBFormat(opcode, rs1, rs2, imm)
+}
+
+// EncodeSC creates a store-conditional (OpSC)
+//
+// Layout: [opcode:5][rd:5][rs1:5][rs2:5][immediate:12]
+func EncodeSC(rd, rs1, rs2 uint8, imm int32) uint32 {
+	return EncodeRFormat(OpSC, rd, rs1, rs2) | (uint32(imm) & 0xFFF)
+}
+
+// EncodeNFormat creates a narrow load/store (OpLDN, OpSTN)
+//
+// N-FORMAT: [opcode:5][reg:5][rs1:5][funct:2][immediate:15]
+// reg is rd for loads and the data register (rs2) for stores
+func EncodeNFormat(opcode, reg, rs1, funct uint8, imm int32) uint32 {
+	return (uint32(opcode) << 27) |
+		(uint32(reg) << 22) |
+		(uint32(rs1) << 17) |
+		(uint32(funct&0x3) << 15) |
+		(uint32(imm) & 0x7FFF)
+}
+
 // CreateSimpleProgram creates a test program
 //
 // Simple program that exercises all components:
@@ -6133,7 +7294,7 @@ func CreateSimpleProgram() []uint32 {
 		EncodeRFormat(OpDIV, 6, 2, 1), // r6 = r2 / r1 (2)
 
 		// Load/Store (INNOVATION #69)
-		EncodeIFormat(OpSW, 0, 1, 0x2000), // Store r1 to [0x2000]
+		EncodeSFormat(OpSW, 0, 1, 0x2000), // Store r1 to [0x2000]
 		EncodeIFormat(OpLW, 7, 0, 0x2000), // Load r7 from [0x2000]
 
 		// Branch (INNOVATION #29-33)
@@ -6312,7 +7473,7 @@ func CreateAtomicTest() []uint32 {
 		// Setup: counter at 0x5000
 		EncodeIFormat(OpADDI, 1, 0, 0x5000), // r1 = counter address
 		EncodeIFormat(OpADDI, 2, 0, 0),      // r2 = 0 (initial value)
-		EncodeIFormat(OpSW, 0, 2, 0x5000),   // store 0 to counter
+		EncodeSFormat(OpSW, 0, 2, 0x5000),   // store 0 to counter
 
 		// Atomic increment loop (10 iterations)
 		EncodeIFormat(OpADDI, 3, 0, 0),  // r3 = 0 (iteration counter)
@@ -6322,7 +7483,7 @@ func CreateAtomicTest() []uint32 {
 		// Retry:
 		EncodeIFormat(OpLR, 5, 1, 0),    // r5 = load reserved [counter]
 		EncodeIFormat(OpADDI, 5, 5, 1),  // r5++ (increment)
-		EncodeIFormat(OpSC, 6, 1, 5),    // store conditional, r6 = success
+		EncodeSC(6, 1, 5, 0),            // store conditional, r6 = success
 		EncodeBFormat(OpBNE, 6, 0, -12), // if failed (r6!=0), retry
 
 		// Success:
@@ -6338,6 +7499,35 @@ func CreateAtomicTest() []uint32 {
 	return program
 }
 
+// CreateByteCopyProgram copies a NUL-terminated string byte by byte
+//
+// TESTS: Narrow loads/stores (OpLDN, OpSTN) and byte-stride prediction
+//
+// CODE:
+//
+//	src = 0x4000; dst = 0x6000
+//	do { c = *src++; *dst++ = c; } while (c != 0)
+//
+// Memory starts zeroed, so the string is empty unless the caller fills
+// 0x4000; the loop runs once per byte including the terminator.
+func CreateByteCopyProgram() []uint32 {
+	program := []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000), // r1 = src
+		EncodeIFormat(OpADDI, 2, 0, 0x6000), // r2 = dst
+
+		// Loop: (PC = 0x1008)
+		EncodeNFormat(OpLDN, 3, 1, FnByteU, 0), // r3 = *src (lbu)
+		EncodeNFormat(OpSTN, 3, 2, FnByte, 0),  // *dst = r3 (sb)
+		EncodeIFormat(OpADDI, 1, 1, 1),         // src++
+		EncodeIFormat(OpADDI, 2, 2, 1),         // dst++
+		EncodeBFormat(OpBNE, 3, 0, -16),        // if c != 0, loop
+
+		// End
+		EncodeIFormat(OpADDI, 7, 0, 42), // r7 = 42 (done marker)
+	}
+	return program
+}
+
 // CreateOutOfOrderTest tests out-of-order execution
 //
 // TESTS: INNOVATIONS #34-58 (Complete OOO engine)
@@ -6452,7 +7642,7 @@ func CreateComprehensiveBenchmark() []uint32 {
 		EncodeIFormat(OpADDI, 27, 0, 0x5000), // r27 = counter address
 		EncodeIFormat(OpLR, 28, 27, 0),       // r28 = load reserved
 		EncodeIFormat(OpADDI, 28, 28, 1),     // r28++
-		EncodeIFormat(OpSC, 29, 27, 28),      // store conditional
+		EncodeSC(29, 27, 28, 0),              // store conditional
 
 		// ═══════════════════════════════════════════════════════════════
 		// SECTION 8: End marker
@@ -6478,10 +7668,14 @@ func RunBenchmark(name string, program []uint32, cycles uint64) string {
 ╚═══════════════════════════════════════════════════════════════════════════╝
 
 %s
-`, name, core.GetStats())
+%s
+`, name, core.GetStats(), core.GetCPIStack())
 }
 
 // CompareWithIntel provides a detailed comparison with Intel
+//
+// The Intel figures are fixed estimates, not measurements. For
+// self-checking workloads with measured IPC, see RunSuite (benchmarks.go).
 func CompareWithIntel(ourIPC float64) string {
 	intelIPC := 4.3
 	intelTransistors := 26000.0 // Million
@@ -6728,6 +7922,9 @@ func ExampleBenchmarkSuite() {
 	fmt.Println("\n" + RunBenchmark("Atomic Operations Test",
 		CreateAtomicTest(), 5000))
 
+	fmt.Println("\n" + RunBenchmark("Byte Copy (Narrow Loads/Stores)",
+		CreateByteCopyProgram(), 5000))
+
 	fmt.Println("\n" + RunBenchmark("Out-of-Order Test",
 		CreateOutOfOrderTest(), 1000))
 
@@ -0,0 +1,353 @@
+package suprax32
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// APPLICATION BINARY INTERFACE
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY AN ABI: Hardware already assumes part of a convention. The RSB pushes
+//
+//	on "jal r1" and pops on "jalr r0, 0(r1)", and L1ICache.NotifyReturn
+//	watches for the same return. Code that links with other code must
+//	agree on the rest: where arguments go and which registers survive
+//	a call.
+//
+// REGISTERS:
+//
+//	Reg      Name    Role                          Preserved across calls
+//	r0       zero    hard-wired zero               -
+//	r1       ra      return address (link)         no
+//	r2       sp      stack pointer                 yes
+//	r3       fp      frame pointer                 yes
+//	r4-r11   a0-a7   arguments, a0 = return value  no
+//	r12-r19  t0-t7   temporaries                   no
+//	r20-r29  s0-s9   saved registers               yes
+//	r30-r31  t8-t9   temporaries                   no
+//
+// CALLS:
+//
+//	call:    jal r1, func  (or jalr r1, 0(rs) through a pointer)
+//	return:  jalr r0, 0(r1)  — any other form defeats the RSB
+//	Arguments are 32-bit words in a0-a7, extended to their type.
+//	sp is StackAlign-aligned at every call.
+//
+// STACK FRAME (see Frame):
+//
+//	        ┌──────────────────┐ ← caller's sp = fp
+//	fp-4    │ saved ra         │
+//	fp-8    │ saved fp         │
+//	fp-12   │ saved s-regs     │ (Frame.Saved, in order)
+//	        │ locals           │ (Frame.Locals bytes)
+//	        ├──────────────────┤ ← sp (16-byte aligned)
+//	        │ outgoing scratch │
+//	        └──────────────────┘
+//
+// CHECKING: Core.SetABICheck makes the simulator verify the
+//
+//	"preserved" column at run time (see ABIChecker).
+//
+// MINECRAFT ANALOGY: Server rules. Anyone can build, but you put borrowed
+//
+//	tools back in the chest you took them from.
+
+// Register roles
+const (
+	RegZero = 0  // Hard-wired zero
+	RegRA   = 1  // Return address (the RSB's link register)
+	RegSP   = 2  // Stack pointer
+	RegFP   = 3  // Frame pointer
+	RegA0   = 4  // First argument and return value (a0-a7 = r4-r11)
+	RegT0   = 12 // First temporary (t0-t7 = r12-r19, t8-t9 = r30-r31)
+	RegS0   = 20 // First saved register (s0-s9 = r20-r29)
+
+	NumArgRegs   = 8  // a0-a7
+	NumSavedRegs = 10 // s0-s9
+)
+
+// StackAlign is the stack pointer alignment at calls and at program entry
+const StackAlign = 16
+
+// abiRegNames are the ABI names of r0-r31
+var abiRegNames = [NumArchRegs]string{
+	"zero", "ra", "sp", "fp", "a0", "a1", "a2", "a3",
+	"a4", "a5", "a6", "a7", "t0", "t1", "t2", "t3",
+	"t4", "t5", "t6", "t7", "s0", "s1", "s2", "s3",
+	"s4", "s5", "s6", "s7", "s8", "s9", "t8", "t9",
+}
+
+// ABIRegName returns a register's ABI name ("sp", "a0", ...)
+func ABIRegName(r uint8) string {
+	if int(r) >= NumArchRegs {
+		return "r" + strconv.Itoa(int(r))
+	}
+	return abiRegNames[r]
+}
+
+// IsCalleeSaved reports whether a call must preserve the register
+func IsCalleeSaved(r uint8) bool {
+	return r == RegSP || r == RegFP || (r >= RegS0 && r < RegS0+NumSavedRegs)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// STACK FRAMES
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// Frame describes a function's stack frame for EmitPrologue/EmitEpilogue
+type Frame struct {
+	Locals uint32  // Bytes of locals below the saved registers
+	Saved  []uint8 // Callee-saved registers the function uses (besides fp)
+}
+
+// Size returns the frame size, rounded up to StackAlign
+func (f Frame) Size() uint32 {
+	return alignUp(8+4*uint32(len(f.Saved))+f.Locals, StackAlign)
+}
+
+// SavedOffset returns the fp-relative slot of Saved[i]
+func (f Frame) SavedOffset(i int) int32 {
+	return -12 - 4*int32(i)
+}
+
+// LocalsOffset returns the fp-relative address of the lowest local byte
+func (f Frame) LocalsOffset() int32 {
+	return -8 - 4*int32(len(f.Saved)) - int32(f.Locals)
+}
+
+// check validates the saved-register list
+func (f Frame) check() error {
+	for _, r := range f.Saved {
+		if !IsCalleeSaved(r) || r == RegSP || r == RegFP {
+			return fmt.Errorf("%s is not a saved register (s0-s9)", ABIRegName(r))
+		}
+	}
+	if f.Size() >= 1<<31 {
+		return fmt.Errorf("frame of %d bytes is too large", f.Locals)
+	}
+	return nil
+}
+
+// EmitPrologue emits a function entry that builds the frame
+//
+// ALGORITHM:
+//
+//	STEP 1: sp -= Size (through t9 when Size does not fit an immediate)
+//	STEP 2: Store ra, fp and the saved registers below the old sp
+//	STEP 3: fp = old sp
+//
+// Nothing is stored below sp, so an interrupted prologue loses nothing.
+func (o *Object) EmitPrologue(f Frame) {
+	size := int32(f.Size())
+	base, top := uint8(RegSP), size // Stores go to top+off(base), off < 0
+	if size < 1<<16 {
+		o.Emit(EncodeIFormat(OpADDI, RegSP, RegSP, -size))
+	} else {
+		// t9 = old sp; stores and fp use it instead of sp + size
+		const t9 = 31
+		emitLoadImm(o, t9, uint32(size))
+		o.Emit(EncodeRFormat(OpSUB, RegSP, RegSP, t9))
+		o.Emit(EncodeRFormat(OpADD, t9, RegSP, t9))
+		base, top = t9, 0
+	}
+
+	o.Emit(EncodeSFormat(OpSW, base, RegRA, top-4))
+	o.Emit(EncodeSFormat(OpSW, base, RegFP, top-8))
+	for i, r := range f.Saved {
+		o.Emit(EncodeSFormat(OpSW, base, r, top+f.SavedOffset(i)))
+	}
+	o.Emit(EncodeIFormat(OpADDI, RegFP, base, top))
+}
+
+// EmitEpilogue emits the matching return sequence
+//
+// ALGORITHM:
+//
+//	STEP 1: Reload the saved registers and ra from fp
+//	STEP 2: t9 = fp; fp = saved fp, read while its slot is still above sp
+//	STEP 3: sp = t9 (the old sp), then return
+//
+// Everything is found from fp, so sp may be anywhere below it. Like the
+// prologue, nothing is read below sp: a trap handler pushing onto this
+// stack between two steps cannot overwrite a slot still to be loaded.
+func (o *Object) EmitEpilogue(f Frame) {
+	const t9 = 31
+	for i, r := range f.Saved {
+		o.Emit(EncodeIFormat(OpLW, r, RegFP, f.SavedOffset(i)))
+	}
+	o.Emit(EncodeIFormat(OpLW, RegRA, RegFP, -4))
+	o.Emit(EncodeIFormat(OpADDI, t9, RegFP, 0))
+	o.Emit(EncodeIFormat(OpLW, RegFP, t9, -8))
+	o.Emit(EncodeIFormat(OpADDI, RegSP, t9, 0))
+	o.Emit(EncodeIFormat(OpJALR, RegZero, RegRA, 0))
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// RUNTIME CHECKER
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// Watches committed instructions (the architectural view, never wrong-path
+// work) and keeps a shadow call stack:
+//
+//	Call commits   (jal/jalr with rd = ra):
+//	  sp not StackAlign-aligned → ABIStackMisaligned
+//	  Push {return address, callee, sp, fp, s0-s9}
+//	Return commits (jalr r0, 0(ra)):
+//	  Target matches a shadow frame → pop to it and compare:
+//	    sp differs            → ABIStackImbalance
+//	    fp or s0-s9 differ    → ABICalleeSavedClobber (one per register)
+//	  No match → not a return we can check (longjmp, hand-made stack)
+//
+// Tail calls (j func) need no special case: the callee returns to our
+// caller, which matches our caller's frame.
+
+// ABIViolationKind classifies an ABI violation
+type ABIViolationKind uint8
+
+const (
+	ABICalleeSavedClobber ABIViolationKind = iota // fp or s0-s9 changed across a call
+	ABIStackImbalance                             // sp changed across a call
+	ABIStackMisaligned                            // sp not aligned at a call
+)
+
+func (k ABIViolationKind) String() string {
+	switch k {
+	case ABICalleeSavedClobber:
+		return "callee-saved register clobbered"
+	case ABIStackImbalance:
+		return "stack pointer imbalance"
+	default:
+		return "misaligned stack at call"
+	}
+}
+
+// ABIViolation is one broken rule
+type ABIViolation struct {
+	Kind   ABIViolationKind
+	PC     uint32 // The call (misaligned) or return (others)
+	Callee uint32 // Called function's entry point
+	Reg    uint8  // Register involved
+	Want   uint32 // Value at the call (clobber, imbalance)
+	Got    uint32 // Value after the return, or the misaligned sp
+	Cycle  uint64
+}
+
+// Format renders the violation, naming code through st (may be nil)
+func (v ABIViolation) Format(st *SymbolTable) string {
+	where := func(addr uint32) string {
+		if name := st.Format(addr); name != "" {
+			return fmt.Sprintf("0x%08x <%s>", addr, name)
+		}
+		return fmt.Sprintf("0x%08x", addr)
+	}
+	switch v.Kind {
+	case ABIStackMisaligned:
+		return fmt.Sprintf("%s: call at %s to %s with sp=0x%08x", v.Kind, where(v.PC), where(v.Callee), v.Got)
+	default:
+		return fmt.Sprintf("%s: %s changed 0x%08x → 0x%08x across call to %s (return at %s)",
+			v.Kind, ABIRegName(v.Reg), v.Want, v.Got, where(v.Callee), where(v.PC))
+	}
+}
+
+func (v ABIViolation) String() string { return v.Format(nil) }
+
+const (
+	abiMaxDepth      = 4096 // Shadow frames kept (oldest dropped beyond)
+	abiMaxViolations = 256  // Violations kept (all are counted)
+)
+
+// abiFrame is one shadow call stack entry
+type abiFrame struct {
+	ret    uint32
+	callee uint32
+	saved  [NumArchRegs]uint32 // Only callee-saved registers are meaningful
+}
+
+// ABIChecker verifies the calling convention on committed instructions
+type ABIChecker struct {
+	stack      []abiFrame
+	violations []ABIViolation
+	total      uint64 // Violations seen, including ones not kept
+	calls      uint64
+	returns    uint64 // Returns matched to a call and checked
+}
+
+// retire examines one committed instruction against the architectural
+// registers it just updated
+func (a *ABIChecker) retire(e *WindowEntry, regs *[NumArchRegs]uint32, cycle uint64) {
+	switch {
+	case (e.Opcode == OpJAL || e.Opcode == OpJALR) && e.Rd == RegRA:
+		a.calls++
+		if regs[RegSP]%StackAlign != 0 {
+			a.report(ABIViolation{Kind: ABIStackMisaligned, PC: e.PC, Callee: e.BranchTarget,
+				Reg: RegSP, Got: regs[RegSP], Cycle: cycle})
+		}
+		if len(a.stack) == abiMaxDepth {
+			a.stack = append(a.stack[:0], a.stack[1:]...)
+		}
+		a.stack = append(a.stack, abiFrame{ret: e.PC + 4, callee: e.BranchTarget, saved: *regs})
+
+	case e.Opcode == OpJALR && e.Rd == RegZero && e.Rs1 == RegRA && e.Imm == 0:
+		for i := len(a.stack) - 1; i >= 0; i-- {
+			if a.stack[i].ret != e.BranchTarget {
+				continue
+			}
+			a.returns++
+			f := &a.stack[i]
+			for r := uint8(0); r < NumArchRegs; r++ {
+				if !IsCalleeSaved(r) || f.saved[r] == regs[r] {
+					continue
+				}
+				kind := ABICalleeSavedClobber
+				if r == RegSP {
+					kind = ABIStackImbalance
+				}
+				a.report(ABIViolation{Kind: kind, PC: e.PC, Callee: f.callee, Reg: r,
+					Want: f.saved[r], Got: regs[r], Cycle: cycle})
+			}
+			a.stack = a.stack[:i]
+			break
+		}
+	}
+}
+
+func (a *ABIChecker) report(v ABIViolation) {
+	a.total++
+	if len(a.violations) < abiMaxViolations {
+		a.violations = append(a.violations, v)
+	}
+}
+
+// Violations returns the recorded violations, oldest first
+func (a *ABIChecker) Violations() []ABIViolation {
+	if a == nil {
+		return nil
+	}
+	return append([]ABIViolation(nil), a.violations...)
+}
+
+// Stats returns the calls seen, returns checked and violations counted
+func (a *ABIChecker) Stats() (calls, returns, violations uint64) {
+	if a == nil {
+		return 0, 0, 0
+	}
+	return a.calls, a.returns, a.total
+}
+
+// SetABICheck turns the run-time calling convention checker on or off
+//
+// Turning it on starts a fresh shadow stack, so enable it before the
+// program's first call (checking starts with the calls it sees).
+func (c *Core) SetABICheck(on bool) {
+	c.abiCheck = nil
+	if on {
+		c.abiCheck = &ABIChecker{}
+	}
+}
+
+// ABICheck returns the checker (nil when checking is off)
+func (c *Core) ABICheck() *ABIChecker {
+	return c.abiCheck
+}
@@ -0,0 +1,232 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Calling Convention - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The ABI checker is only trusted if it stays quiet on correct code and names each kind of
+// violation when one is provoked, so every test runs hand-written functions that break exactly
+// one rule. The frame sequences the assembler and compiler share are checked the same way: a
+// frame built and torn down by EmitPrologue/EmitEpilogue must return every register intact and
+// never read below sp, where a trap handler may push.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. FRAME TESTS
+//    Prologue/epilogue pairs restore the caller's registers and stay above sp
+//
+// 2. CHECKER TESTS
+//    Clobbered callee-saved registers, unbalanced and misaligned stacks
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runABI runs an assembly program with the ABI checker on and returns its violations
+func runABI(t *testing.T, src string) (*Core, []ABIViolation) {
+	t.Helper()
+	core, _ := runAsm(t, src, func(c *Core, _ *pageTables, _ *Image) { c.SetABICheck(true) })
+	return core, core.ABICheck().Violations()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. FRAME TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestABI_FramesPreserveCallerRegisters(t *testing.T) {
+	// WHAT: Functions using prologue/epilogue with saved registers, locals and a frame too
+	//       large for an immediate return with sp, fp and s0-s9 unchanged
+	// WHY: Every compiled function is built from these two sequences
+	// HARDWARE: N/A (calling convention)
+	// CATEGORY: [INTEGRATION]
+
+	core, violations := runABI(t, `
+	.text
+	.globl _start
+_start:
+	li    fp, 0x1234
+	li    s0, 10
+	li    s1, 11
+	call  small
+	call  big
+	add   a0, a0, s0           # 7 + 2 + 10 + 11 = 30 if nothing was clobbered
+	add   a0, a0, s1
+	system 0
+
+small:
+	prologue 12, s0, s1
+	li    s0, 100
+	li    s1, 101
+	sw    s0, -20(fp)          # Lowest local
+	li    a0, 7
+	epilogue
+
+big:
+	prologue 70000, s0
+	li    s0, 200
+	li    t0, -70012           # Lowest local, beyond an immediate's reach
+	add   t0, fp, t0
+	sw    s0, 0(t0)
+	addi  a0, a0, 2
+	epilogue
+`)
+	for _, v := range violations {
+		t.Errorf("ABI violation: %s", v.Format(core.Symbols()))
+	}
+	if status, _ := core.Exited(); status != 30 {
+		t.Errorf("exit status %d, expected 30", int32(status))
+	}
+	if calls, returns, _ := core.ABICheck().Stats(); calls != 2 || returns != 2 {
+		t.Errorf("checker saw %d calls and %d returns, expected 2 and 2", calls, returns)
+	}
+}
+
+func TestABI_EpilogueNeverReadsBelowSP(t *testing.T) {
+	// WHAT: Interpreting EmitEpilogue's instructions, every load addresses a slot at or
+	//       above sp at the moment it runs, and the caller's fp, sp and ra come back
+	// WHY: A trap handler pushing onto the interrupted stack overwrites anything below
+	//      sp; the epilogue used to restore sp first and then load fp from -8(sp)
+	// HARDWARE: N/A (calling convention)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	for _, f := range []Frame{{}, {Locals: 40, Saved: []uint8{RegS0, RegS0 + 3}}, {Locals: 70000}} {
+		const callerSP, callerFP, callerRA = 0x80000, 0x1234, 0x5678
+		var regs [NumArchRegs]uint32
+		mem := map[uint32]uint32{}
+
+		// The frame as the prologue left it, sp pushed further down by the body
+		fp := uint32(callerSP)
+		mem[fp-4], mem[fp-8] = callerRA, callerFP
+		for i, r := range f.Saved {
+			mem[fp+uint32(f.SavedOffset(i))] = 0x5000 + uint32(r)
+		}
+		regs[RegFP], regs[RegSP] = fp, fp-f.Size()-32
+
+		o := NewObject("epilogue")
+		o.EmitEpilogue(f)
+		for i := 0; i < len(o.Text); i += 4 {
+			word := uint32(o.Text[i]) | uint32(o.Text[i+1])<<8 | uint32(o.Text[i+2])<<16 | uint32(o.Text[i+3])<<24
+			inst := DecodeInstruction(word, uint32(i))
+			addr := regs[inst.Rs1] + uint32(inst.Imm)
+			switch inst.Opcode {
+			case OpLW:
+				if addr < regs[RegSP] {
+					t.Errorf("frame %+v: lw %s at +%d reads 0x%x, below sp 0x%x",
+						f, ABIRegName(inst.Rd), i, addr, regs[RegSP])
+				}
+				regs[inst.Rd] = mem[addr]
+			case OpADDI:
+				regs[inst.Rd] = addr
+			case OpJALR:
+			default:
+				t.Fatalf("frame %+v: unexpected opcode %d in the epilogue", f, inst.Opcode)
+			}
+		}
+
+		if regs[RegSP] != callerSP || regs[RegFP] != callerFP || regs[RegRA] != callerRA {
+			t.Errorf("frame %+v: sp, fp, ra = 0x%x, 0x%x, 0x%x, expected 0x%x, 0x%x, 0x%x",
+				f, regs[RegSP], regs[RegFP], regs[RegRA], callerSP, callerFP, callerRA)
+		}
+		for _, r := range f.Saved {
+			if regs[r] != 0x5000+uint32(r) {
+				t.Errorf("frame %+v: %s = 0x%x, expected its saved value", f, ABIRegName(r), regs[r])
+			}
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. CHECKER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestABI_CheckerReportsEachViolation(t *testing.T) {
+	// WHAT: A function overwriting s2, one returning with sp 16 bytes lower, and a call
+	//       made with sp 4 bytes off alignment are each reported once, with the right
+	//       kind, register, values and function; a correct call is not reported
+	// WHY: A checker that reports nothing is indistinguishable from one that checks
+	//      nothing
+	// HARDWARE: Commit-time shadow call stack
+	// CATEGORY: [INTEGRATION]
+
+	core, violations := runABI(t, `
+	.text
+	.globl _start, clean, clobber, leak, misaligned
+_start:
+	li    s2, 5
+	call  clean
+	call  clobber
+	call  leak
+	addi  sp, sp, 16           # Undo the leak so only one call sees it
+	addi  sp, sp, -4
+misaligned:
+	call  clean
+	addi  sp, sp, 4
+	li    a0, 0
+	system 0
+
+clean:
+	prologue 0, s0
+	li    s0, 1
+	epilogue
+
+clobber:
+	li    s2, 99
+	ret
+
+leak:
+	addi  sp, sp, -16
+	ret
+`)
+	if frames := core.ABICheck().stack; len(frames) != 0 {
+		t.Errorf("%d shadow frames left after all calls returned", len(frames))
+	}
+	st := core.Symbols()
+	addr := func(name string) uint32 { return symbol(t, core, name) }
+
+	if len(violations) != 3 {
+		for _, v := range violations {
+			t.Logf("  %s", v.Format(st))
+		}
+		t.Fatalf("%d violations, expected 3", len(violations))
+	}
+
+	clob := violations[0]
+	if clob.Kind != ABICalleeSavedClobber || clob.Reg != RegS0+2 || clob.Want != 5 || clob.Got != 99 ||
+		clob.Callee != addr("clobber") {
+		t.Errorf("clobber reported as %+v", clob)
+	}
+
+	imb := violations[1]
+	if imb.Kind != ABIStackImbalance || imb.Reg != RegSP || imb.Want-imb.Got != 16 || imb.Callee != addr("leak") {
+		t.Errorf("leak reported as %+v", imb)
+	}
+
+	mis := violations[2]
+	if mis.Kind != ABIStackMisaligned || mis.PC != addr("misaligned") || mis.Got != imb.Want-4 ||
+		mis.Callee != addr("clean") {
+		t.Errorf("misaligned call reported as %+v", mis)
+	}
+
+	for _, c := range []struct {
+		v    ABIViolation
+		want string
+	}{
+		{clob, fmt.Sprintf("callee-saved register clobbered: s2 changed 0x00000005 → 0x00000063 across call to 0x%08x <clobber>", addr("clobber"))},
+		{imb, "stack pointer imbalance: sp changed"},
+		{mis, "misaligned stack at call: call at"},
+	} {
+		if got := c.v.Format(st); !strings.HasPrefix(got, c.want) {
+			t.Errorf("Format = %q, expected it to start with %q", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// LOAD ADDRESS SPECULATION
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: At dispatch the L1D predictor (INNOVATION #59) already guesses the
+//
+//	address of every load, but the guess only feeds the prefetch queue.
+//	A load whose base register comes from a slow producer (another
+//	load, a divide) still waits for it, and so does everything behind
+//	it. Pointer chasing and indexed walks serialize on exactly that.
+//
+// THE MODE (SetAddrSpeculation):
+//
+//	STEP 1: Issue. A load (LW, LB/LH) with a prediction may issue while
+//	        its base register is not ready, at PredictedMemAddr. Its
+//	        value wakes dependents like any other load result.
+//	STEP 2: Verify. When the base arrives, compute the real address.
+//	        Equal → the load is done; train the predictor now.
+//	STEP 3: Replay. Different → cancel the access if it is still in an
+//	        LSU, forget the value, and issue the load again at the real
+//	        address. Dependents that have not issued wait for the new
+//	        value; those that have may hold a wrong one.
+//	STEP 4: Commit. A replayed load commits its correct value. If the
+//	        first value was already out (the speculative access had
+//	        completed) and differs from the real one, everything younger
+//	        is flushed and refetched, like a branch mispredict.
+//
+//	Only the load itself replays: the Window has no partial squash
+//	(INNOVATION #48), so a wrong value that escaped costs a full refetch
+//	of the younger work. A wrong address that returned the right value
+//	(or returned nothing yet) costs only the replay.
+//
+// LIMITS:
+//
+//	Predictions are physical addresses (the predictor trains in the
+//	L1D), so speculation is off while the MMU translates, and therefore
+//	in user mode (which cannot touch memory untranslated, see trap.go).
+//	A predicted address on a device is never used (see bus.go). LR and
+//	stores never speculate. A speculative access does not train the
+//	predictor: a wrong guess must not confirm itself.
+//
+// STATISTICS (per PredictorID, over committed loads):
+//
+//	Coverage   speculated loads / loads
+//	Accuracy   verified loads / speculated loads
+//	Recovery   replays, flushes and the instructions the flushes squashed
+//
+// MINECRAFT ANALOGY: Running to the chest where the item usually is
+//
+//	before the villager tells you which chest it is in. Usually you win
+//	a few seconds; when you guessed wrong you walk back, and if you had
+//	already started crafting with what you grabbed, you start over.
+
+// addrSpecCounters are one predictor's outcomes
+type addrSpecCounters struct {
+	speculated uint64 // Committed loads that issued at its prediction
+	correct    uint64 // ...whose address was right
+	replays    uint64 // ...that were reissued at the real address
+	flushes    uint64 // Replays that refetched the younger work
+	squashed   uint64 // Instructions those refetches discarded
+}
+
+// addrSpecState is the core's address speculation mode and statistics
+type addrSpecState struct {
+	on    bool
+	loads uint64 // Committed loads
+
+	perPredictor [5]addrSpecCounters // Indexed by PredictorID-1
+}
+
+// SetAddrSpeculation turns load address speculation on or off
+func (c *Core) SetAddrSpeculation(on bool) {
+	c.addrSpec.on = on
+}
+
+// canSpeculateAddr reports whether a load may issue before its base is
+// ready (STEP 1)
+func (w *Window) canSpeculateAddr(e *WindowEntry) bool {
+	return w.addrSpec && e.HasMemPrediction && e.Priv == PrivMachine &&
+		(e.Opcode == OpLW || e.Opcode == OpLDN) &&
+		e.PredictedMemAddr&uint32(e.MemSize-1) == 0 // Never straddles a line
+}
+
+// awaitsBase reports whether an issued load still needs its base register
+// to check its predicted address (it keeps listening for wakeups)
+func (e *WindowEntry) awaitsBase() bool {
+	return e.AddrSpeculated && !e.AddrVerified
+}
+
+// verifyAddrSpec checks speculated loads whose base has arrived (STEPS 2-3)
+func (c *Core) verifyAddrSpec() {
+	w := c.window
+	w.addrSpec = c.addrSpec.on && !c.mmu.Enabled()
+
+	for i := 0; i < w.count; i++ {
+		winID := (w.head + i) % WindowSize
+		e := &w.entries[winID]
+		if !e.Valid || !e.AddrSpeculated || e.AddrVerified || !e.Src1Ready {
+			continue
+		}
+		e.AddrVerified = true
+
+		addr := e.EffectiveAddr(w.ReadReg(e.Rs1, e.PhysRs1))
+		if addr == e.PredictedMemAddr {
+			c.dcache.predictor.RecordLoad(e.PC, addr)
+			continue
+		}
+
+		// STEP 3: Replay at the real address
+		e.AddrReplayed = true
+		e.SpecResult, e.SpecResultValid = e.Result, e.Executed
+		for _, lsu := range c.lsus {
+			lsu.cancelWindow(winID)
+		}
+		e.Issued, e.Executed, e.ResultValid = false, false, false
+		e.MemAddr = addr
+		if e.PhysRd != InvalidTag {
+			w.unready(e.PhysRd)
+		}
+	}
+}
+
+// commitLoad scores a committing load and reports whether the younger
+// work must be refetched (STEP 4)
+func (c *Core) commitLoad(e *WindowEntry) (refetch bool) {
+	c.addrSpec.loads++
+	if !e.AddrSpeculated {
+		return false
+	}
+
+	s := &c.addrSpec.perPredictor[e.MemPredictor-1]
+	s.speculated++
+	if !e.AddrReplayed {
+		s.correct++
+		return false
+	}
+	s.replays++
+	if !e.SpecResultValid || e.SpecResult == e.Result {
+		return false
+	}
+	s.flushes++
+	s.squashed += uint64(c.window.GetCount())
+	return true
+}
+
+// unready takes back a physical register's value: readers that have not
+// issued wait for it again
+func (w *Window) unready(physReg uint8) {
+	w.physRegReady[physReg] = false
+	for i := range w.entries {
+		e := &w.entries[i]
+		if !e.Valid || e.Issued && !e.awaitsBase() {
+			continue
+		}
+		if e.PhysRs1 == physReg {
+			e.Src1Ready = false
+		}
+		if e.PhysRs2 == physReg {
+			e.Src2Ready = false
+		}
+	}
+}
+
+// cancelWindow abandons the LSU's operation (or undelivered result) for
+// one window entry
+func (lsu *LSU) cancelWindow(winID int) {
+	if lsu.busy && lsu.op.WindowID == winID {
+		lsu.busy = false
+		lsu.waitDRAM = false
+	}
+	if lsu.resultValid && lsu.resultWinID == winID {
+		lsu.resultValid = false
+	}
+}
+
+// readUntrained is ReadSize without training the predictor (a load at a
+// predicted address, STEP 1)
+func (c *L1DCache) readUntrained(addr uint32, size int) (data uint32, hit bool) {
+	c.accesses++
+	data, hit = c.peekSize(addr, size)
+	if !hit {
+		c.demandMiss(addr) // See prefetch.go
+		return 0, false
+	}
+	c.hits++
+	line, _, _ := c.Probe(addr)
+	c.demandHit(line)
+	return data, true
+}
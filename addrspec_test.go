@@ -0,0 +1,200 @@
+package suprax32
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Load Address Speculation - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Speculating on an address may only ever change how fast a program runs. Every test checks
+// the answer first: a wrong guess that leaked a value, or a load that skipped an older store
+// because the store check looked at the guessed address, shows up as a wrong result long
+// before it shows up in the statistics.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. CORRECTNESS TESTS
+//    Standard kernels and a mispredicting walk compute the same answers with speculation on
+//
+// 2. STATISTICS TESTS
+//    Off by default, counters consistent per predictor
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// chaseSource walks a table whose links mostly step by one and sometimes jump, so the
+// stride predictor learns the walk and is then wrong at every jump. Each link is loaded
+// from the previous one: the load's base is always the slowest thing in the loop.
+const chaseSource = `
+int link[256];
+int value[256];
+
+int main() {
+	int i, j, sum;
+	for (i = 0; i < 256; i++) {
+		link[i] = (i + 1) & 255;
+		if (i % 17 == 16)
+			link[i] = (i * 7) & 255;
+		value[i] = i * 3 + 1;
+	}
+	sum = 0;
+	j = 0;
+	for (i = 0; i < 3000; i++) {
+		sum = sum + value[j];
+		j = link[j];
+	}
+	return sum & 0xffff;
+}
+`
+
+// chaseExpected is chaseSource's exit status, computed natively
+func chaseExpected() uint32 {
+	var link, value [256]int32
+	for i := int32(0); i < 256; i++ {
+		link[i] = (i + 1) & 255
+		if i%17 == 16 {
+			link[i] = (i * 7) & 255
+		}
+		value[i] = i*3 + 1
+	}
+	sum, j := int32(0), int32(0)
+	for i := 0; i < 3000; i++ {
+		sum += value[j]
+		j = link[j]
+	}
+	return uint32(sum & 0xffff)
+}
+
+// runChase runs chaseSource with address speculation on or off
+func runChase(t *testing.T, on bool) (*Core, uint32) {
+	t.Helper()
+	img, err := BuildC("chase.c", chaseSource)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	core.SetAddrSpeculation(on)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	core.Run(2_000_000)
+	status, ok := core.Exited()
+	if !ok {
+		t.Fatalf("program did not exit (pc 0x%x)", core.pc)
+	}
+	return core, status
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. CORRECTNESS TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestAddrSpec_KernelsPass(t *testing.T) {
+	// WHAT: Every standard kernel passes its self-check with address speculation on, and
+	//       speculation actually happened
+	// WHY: The kernels mix stack reloads, pointer walks and stores right before loads - the
+	//      cases where a replay or the older-store check gets it wrong
+	// HARDWARE: canSpeculateAddr at select, verifyAddrSpec replay, commitLoad refetch
+	// CATEGORY: [INTEGRATION]
+
+	if testing.Short() {
+		t.Skip("runs every kernel on the cycle-level core")
+	}
+	for _, k := range StandardKernels() {
+		t.Run(k.Name, func(t *testing.T) {
+			var core *Core
+			r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+				core = c
+				c.SetAddrSpeculation(true)
+			}})
+			if r.Err != nil || !r.Passed {
+				t.Fatalf("err %v, status %d, output %q", r.Err, r.Status, r.Output)
+			}
+			if s := core.Snapshot().AddrSpec; s.Speculated == 0 {
+				t.Errorf("no load speculated out of %d", s.Loads)
+			}
+		})
+	}
+}
+
+func TestAddrSpec_MispredictedWalk(t *testing.T) {
+	// WHAT: A walk that defeats the stride predictor every 17 links computes the same sum
+	//       with speculation on as off, and the wrong guesses were replayed
+	// WHY: A replay must deliver the value at the real address, and a wrong value that
+	//      already reached dependents must be refetched away
+	// HARDWARE: verifyAddrSpec (replay), commitLoad (refetch when the first value differs)
+	// CATEGORY: [INTEGRATION]
+
+	want := chaseExpected()
+	_, off := runChase(t, false)
+	core, on := runChase(t, true)
+	if off != want || on != want {
+		t.Fatalf("exit status off %d, on %d, expected %d", off, on, want)
+	}
+
+	s := core.Snapshot().AddrSpec
+	if s.Speculated == 0 || s.Replays == 0 {
+		t.Errorf("speculated %d, replayed %d: the walk did not exercise replay", s.Speculated, s.Replays)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. STATISTICS TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestAddrSpec_OffByDefault(t *testing.T) {
+	// WHAT: A new core does not speculate: the mode reports disabled and counts only loads
+	// WHY: Existing results and performance baselines must not move unless asked
+	// CATEGORY: [UNIT]
+
+	core, _ := runChase(t, false)
+	s := core.Snapshot().AddrSpec
+	if s.Enabled || s.Speculated != 0 || s.Replays != 0 {
+		t.Errorf("enabled %v, speculated %d, replays %d", s.Enabled, s.Speculated, s.Replays)
+	}
+	if s.Loads == 0 {
+		t.Error("committed loads not counted")
+	}
+}
+
+func TestAddrSpec_StatsConsistent(t *testing.T) {
+	// WHAT: Each speculated load is either correct or replayed, flushes are a subset of
+	//       replays, and the per-predictor rows add up to the totals
+	// WHY: Coverage and accuracy per PredictorID are what the mode is evaluated by
+	// CATEGORY: [UNIT]
+
+	core, _ := runChase(t, true)
+	s := core.Snapshot().AddrSpec
+	if !s.Enabled {
+		t.Error("mode reports disabled")
+	}
+	if s.Correct+s.Replays != s.Speculated || s.Flushes > s.Replays || s.Speculated > s.Loads {
+		t.Errorf("loads %d, speculated %d, correct %d, replays %d, flushes %d",
+			s.Loads, s.Speculated, s.Correct, s.Replays, s.Flushes)
+	}
+	var speculated, flushes uint64
+	for _, p := range s.Predictors {
+		speculated += p.Speculated
+		flushes += p.Flushes
+		if p.Accuracy < 0 || p.Accuracy > 1 {
+			t.Errorf("%s accuracy %.3f", p.Name, p.Accuracy)
+		}
+	}
+	if speculated != s.Speculated || flushes != s.Flushes {
+		t.Errorf("predictor rows sum to %d speculated, %d flushes; totals %d, %d",
+			speculated, flushes, s.Speculated, s.Flushes)
+	}
+	if s.Accuracy != float64(s.Correct)/float64(s.Speculated) {
+		t.Errorf("accuracy %.3f, expected correct/speculated", s.Accuracy)
+	}
+}
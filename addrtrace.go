@@ -0,0 +1,329 @@
+package suprax32
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// OFFLINE ADDRESS PREDICTOR EVALUATION
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY OFFLINE:
+//
+// The L1D predictor (INNOVATION #59) sees nothing but a stream of
+// (PC, address) pairs. Running the whole Core to tune it spends almost
+// all of its time on fetch, rename, the window and the caches, none of
+// which the predictor looks at. Capture the stream once, then replay it
+// through as many predictor variants as you like.
+//
+// THE PIPELINE:
+//
+//	Core.SetLoadTrace(true)       every committed load, in program order
+//	WriteLoadTrace / ReadLoadTrace
+//	ReplayLoadTrace(name, p, tr)  for each load: PredictAddr, then
+//	                              RecordLoad with the real address
+//	FormatAddrTrace(results)      one table for all predictors
+//
+// Committed loads only: wrong-path loads never reach the trace, and the
+// order is program order rather than the order the core trained in. The
+// replayed numbers are therefore what the predictor could do with perfect
+// training timing - an upper bound on what the core sees.
+//
+// FILE FORMATS (ReadLoadTrace tells them apart by the magic):
+//
+//	Binary: "SXLT", then 8 bytes per load: PC, address (little-endian)
+//	Text:   one load per line, "<pc> <addr>" in hex (0x optional);
+//	        blank lines and lines starting with # are skipped
+//
+// THE ENSEMBLE:
+//
+// Any AddrPredictor can be replayed. For an *L1DPredictor the harness
+// also looks inside: what each specialist offered, whom the meta-predictor
+// (INNOVATION #65) chose, and a confusion table of its choice against an
+// oracle that picks a specialist that was right:
+//
+//	Confusion[selected][oracle]
+//	  diagonal           the choice was right (or nobody was, and none chosen)
+//	  [x][y], x≠y        chose x, but y had the address
+//	  [none][y]          y had the address, the selector offered nothing
+//	  [x][none]          nobody had it: no choice could have helped
+//
+// The oracle prefers the selected specialist when it was right, then the
+// lowest PredictorID.
+//
+// MINECRAFT ANALOGY: Recording a replay of your run and trying new
+//
+//	villager advice against it in spectator mode, instead of replaying
+//	the whole world every time.
+
+// LoadTraceMagic starts a binary load trace
+const LoadTraceMagic = "SXLT"
+
+// LoadRecord is one committed load
+type LoadRecord struct {
+	PC   uint32
+	Addr uint32 // Virtual address of the access
+}
+
+// AddrPredictor predicts load addresses from the load's PC
+type AddrPredictor interface {
+	PredictAddr(pc uint32) (addr uint32, valid bool)
+	RecordLoad(pc uint32, addr uint32)
+}
+
+// PredictAddr makes the ensemble an AddrPredictor
+func (p *L1DPredictor) PredictAddr(pc uint32) (addr uint32, valid bool) {
+	addr, _, valid = p.Predict(pc)
+	return
+}
+
+// SetLoadTrace starts (or stops) recording committed loads
+//
+// Starting discards any earlier recording.
+func (c *Core) SetLoadTrace(on bool) {
+	c.traceLoads = on
+	if on {
+		c.loadTrace = nil
+	}
+}
+
+// LoadTrace returns the loads recorded since SetLoadTrace(true)
+func (c *Core) LoadTrace() []LoadRecord {
+	return c.loadTrace
+}
+
+// traceLoad records one committed load
+func (c *Core) traceLoad(e *WindowEntry) {
+	if c.traceLoads {
+		c.loadTrace = append(c.loadTrace, LoadRecord{PC: e.PC, Addr: e.MemAddr})
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// TRACE FILES
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// WriteLoadTrace writes a trace in the binary format
+func WriteLoadTrace(w io.Writer, trace []LoadRecord) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(LoadTraceMagic)
+	var rec [8]byte
+	for _, ld := range trace {
+		binary.LittleEndian.PutUint32(rec[0:], ld.PC)
+		binary.LittleEndian.PutUint32(rec[4:], ld.Addr)
+		bw.Write(rec[:])
+	}
+	return bw.Flush()
+}
+
+// WriteLoadTraceText writes a trace in the text format
+func WriteLoadTraceText(w io.Writer, trace []LoadRecord) error {
+	bw := bufio.NewWriter(w)
+	for _, ld := range trace {
+		fmt.Fprintf(bw, "%08x %08x\n", ld.PC, ld.Addr)
+	}
+	return bw.Flush()
+}
+
+// ReadLoadTrace reads a trace in either format
+func ReadLoadTrace(r io.Reader) ([]LoadRecord, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(LoadTraceMagic)); string(magic) == LoadTraceMagic {
+		br.Discard(len(LoadTraceMagic))
+		return readBinaryTrace(br)
+	}
+	return readTextTrace(br)
+}
+
+// readBinaryTrace reads the records after the magic
+func readBinaryTrace(r io.Reader) ([]LoadRecord, error) {
+	var trace []LoadRecord
+	var rec [8]byte
+	for {
+		_, err := io.ReadFull(r, rec[:])
+		switch err {
+		case nil:
+		case io.EOF:
+			return trace, nil
+		case io.ErrUnexpectedEOF:
+			return nil, fmt.Errorf("load trace: truncated record after %d loads", len(trace))
+		default:
+			return nil, fmt.Errorf("load trace: %w", err)
+		}
+		trace = append(trace, LoadRecord{
+			PC:   binary.LittleEndian.Uint32(rec[0:]),
+			Addr: binary.LittleEndian.Uint32(rec[4:]),
+		})
+	}
+}
+
+// readTextTrace reads "<pc> <addr>" lines
+func readTextTrace(r io.Reader) ([]LoadRecord, error) {
+	var trace []LoadRecord
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(string(line))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("load trace: line %d: want \"<pc> <addr>\", got %q", n, line)
+		}
+		pc, err := parseTraceHex(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("load trace: line %d: pc: %w", n, err)
+		}
+		addr, err := parseTraceHex(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("load trace: line %d: addr: %w", n, err)
+		}
+		trace = append(trace, LoadRecord{PC: pc, Addr: addr})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("load trace: %w", err)
+	}
+	return trace, nil
+}
+
+// parseTraceHex parses a 32-bit hex number with an optional 0x
+func parseTraceHex(s string) (uint32, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	v, err := strconv.ParseUint(s, 16, 32)
+	return uint32(v), err
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// REPLAY
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// AddrTraceResult is one predictor's score on a trace
+//
+// The embedded L1DPredictorStats carries the totals; its Predictors rows
+// and Confusion are only filled for an *L1DPredictor.
+type AddrTraceResult struct {
+	Name string `json:"name"`
+	L1DPredictorStats
+
+	// Confusion[selected][oracle], indexed by PredictorID (0 = none)
+	Confusion [6][6]uint64 `json:"confusion"`
+}
+
+// ReplayLoadTrace runs every load of a trace through one predictor
+//
+// ALGORITHM (per load, in trace order):
+//
+//	STEP 1: PredictAddr(pc) and score it against the real address
+//	STEP 2: For the ensemble, score every specialist and the choice
+//	STEP 3: RecordLoad(pc, addr) trains the predictor
+func ReplayLoadTrace(name string, p AddrPredictor, trace []LoadRecord) AddrTraceResult {
+	r := AddrTraceResult{Name: name}
+	ens, _ := p.(*L1DPredictor)
+	if ens != nil {
+		r.Predictors = make([]SubPredictorStats, len(ens.subOffered))
+		for i := range r.Predictors {
+			r.Predictors[i].Name = PredictorID(i + 1).String()
+			r.Predictors[i].Enabled = !ens.disabled[i]
+		}
+	}
+
+	for _, ld := range trace {
+		// STEP 1
+		addr, valid := p.PredictAddr(ld.PC)
+		r.Loads++
+		if valid {
+			r.Predictions++
+			if addr == ld.Addr {
+				r.Correct++
+			}
+		}
+
+		// STEP 2
+		if ens != nil {
+			r.scoreEnsemble(ens, ld.Addr)
+		}
+
+		// STEP 3
+		p.RecordLoad(ld.PC, ld.Addr)
+	}
+
+	r.L1DPredictorStats.derive()
+	return r
+}
+
+// scoreEnsemble scores the specialists and the meta-predictor's choice
+// for the prediction just made
+func (r *AddrTraceResult) scoreEnsemble(ens *L1DPredictor, addr uint32) {
+	oracle := PredictorNone
+	for i := range ens.lastSubValid {
+		if !ens.lastSubValid[i] {
+			continue
+		}
+		sub := &r.Predictors[i]
+		sub.Offered++
+		if ens.lastSubAddr[i] == addr {
+			sub.Correct++
+			if oracle == PredictorNone {
+				oracle = PredictorID(i + 1)
+			}
+		}
+	}
+
+	selected := PredictorNone
+	if ens.hasPrediction {
+		selected = ens.lastPredictor
+		sub := &r.Predictors[selected-1]
+		sub.Selected++
+		if ens.lastPredAddr == addr {
+			sub.SelectedCorrect++
+			oracle = selected
+		}
+	}
+	r.Confusion[selected][oracle]++
+}
+
+// FormatAddrTrace renders replay results as tables
+func FormatAddrTrace(results []AddrTraceResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-16s %10s %10s %9s %9s\n", "PREDICTOR", "LOADS", "PREDICTED", "COVERAGE", "ACCURACY")
+	for _, r := range results {
+		fmt.Fprintf(&b, "%-16s %10d %10d %9.3f %9.3f\n", r.Name, r.Loads, r.Predictions, r.Coverage, r.Accuracy)
+	}
+
+	for _, r := range results {
+		if len(r.Predictors) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s: specialists\n", r.Name)
+		fmt.Fprintf(&b, "  %-13s %9s %9s %9s %9s %9s\n", "", "OFFERED", "COVERAGE", "ACCURACY", "CHOSEN", "CHOSEN-OK")
+		for _, sub := range r.Predictors {
+			name := sub.Name
+			if !sub.Enabled {
+				name += " (off)"
+			}
+			fmt.Fprintf(&b, "  %-13s %9d %9.3f %9.3f %8.1f%% %9.3f\n", name, sub.Offered, sub.Coverage, sub.Accuracy,
+				100*ratio(sub.Selected, r.Predictions), sub.SelectedAccuracy)
+		}
+
+		fmt.Fprintf(&b, "\n%s: confusion (rows: chosen, columns: a specialist that was right)\n", r.Name)
+		fmt.Fprintf(&b, "  %-10s", "")
+		for oracle := range r.Confusion[0] {
+			fmt.Fprintf(&b, " %9s", PredictorID(oracle))
+		}
+		b.WriteString("\n")
+		for selected, row := range r.Confusion {
+			fmt.Fprintf(&b, "  %-10s", PredictorID(selected))
+			for _, n := range row {
+				fmt.Fprintf(&b, " %9d", n)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,267 @@
+package suprax32
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Offline Address Predictor Evaluation - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The harness is only worth using if a trace means the same thing wherever it came from, and
+// if its numbers can be trusted. The file tests round-trip both formats; the replay tests use
+// synthetic traces whose right answers are known by construction; the capture test checks a
+// recorded trace against the program that produced it.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. FILE TESTS
+//    Binary and text round trips, malformed input
+//
+// 2. REPLAY TESTS
+//    Ensemble rows and confusion table, any AddrPredictor
+//
+// 3. CAPTURE TESTS
+//    Committed loads recorded from a Core run
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// stridedTrace is one load walking an array: only the stride specialist follows it
+func stridedTrace(n int) []LoadRecord {
+	trace := make([]LoadRecord, n)
+	for i := range trace {
+		trace[i] = LoadRecord{PC: 0x1000, Addr: 0x20000 + uint32(4*i)}
+	}
+	return trace
+}
+
+// lastAddrPredictor predicts that a load repeats its last address
+type lastAddrPredictor struct {
+	last map[uint32]uint32
+}
+
+func (p *lastAddrPredictor) PredictAddr(pc uint32) (uint32, bool) {
+	addr, ok := p.last[pc]
+	return addr, ok
+}
+
+func (p *lastAddrPredictor) RecordLoad(pc uint32, addr uint32) {
+	p.last[pc] = addr
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. FILE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestAddrTrace_FileRoundTrip(t *testing.T) {
+	// WHAT: A trace written in either format reads back identical, and ReadLoadTrace tells
+	//       the formats apart by itself
+	// WHY: Captured and imported traces must replay the same way
+	// CATEGORY: [UNIT]
+
+	trace := []LoadRecord{{PC: 0x1000, Addr: 0x20000}, {PC: 0xfffffffc, Addr: 0}, {PC: 0x1004, Addr: 0xdeadbeef}}
+	writers := map[string]func(*bytes.Buffer) error{
+		"binary": func(b *bytes.Buffer) error { return WriteLoadTrace(b, trace) },
+		"text":   func(b *bytes.Buffer) error { return WriteLoadTraceText(b, trace) },
+	}
+	for name, write := range writers {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			t.Fatalf("%s: write: %v", name, err)
+		}
+		got, err := ReadLoadTrace(&buf)
+		if err != nil {
+			t.Fatalf("%s: read: %v", name, err)
+		}
+		if len(got) != len(trace) {
+			t.Fatalf("%s: read %d loads, wrote %d", name, len(got), len(trace))
+		}
+		for i := range trace {
+			if got[i] != trace[i] {
+				t.Errorf("%s: load %d = %+v, wrote %+v", name, i, got[i], trace[i])
+			}
+		}
+	}
+}
+
+func TestAddrTrace_TextImport(t *testing.T) {
+	// WHAT: Hand-written text traces accept comments, blank lines and 0x prefixes, and a
+	//       malformed line or a truncated binary record is an error naming the problem
+	// WHY: Imported traces come from other tools; a silently skipped line skews every number
+	// CATEGORY: [UNIT]
+
+	got, err := ReadLoadTrace(strings.NewReader("# pc addr\n\n0x1000 0x2000\n  1004\t2004  \n"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := []LoadRecord{{PC: 0x1000, Addr: 0x2000}, {PC: 0x1004, Addr: 0x2004}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("read %+v, expected %+v", got, want)
+	}
+
+	bad := map[string]string{
+		"one field":  "1000 2000\n1004\n",
+		"not hex":    "1000 20zz\n",
+		"too wide":   "100000000 0\n",
+		"truncated":  LoadTraceMagic + "\x00\x10\x00\x00\x00\x20",
+		"three cols": "1000 2000 4\n",
+	}
+	for name, src := range bad {
+		if _, err := ReadLoadTrace(strings.NewReader(src)); err == nil {
+			t.Errorf("%s: no error", name)
+		} else if !strings.HasPrefix(err.Error(), "load trace: ") {
+			t.Errorf("%s: error %q", name, err)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. REPLAY TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestAddrTrace_EnsembleOnStride(t *testing.T) {
+	// WHAT: On a strided walk the ensemble predicts nearly every load, stride is right
+	//       whenever it offers, the meta-predictor picks it, and the confusion table accounts
+	//       for every load exactly once
+	// WHY: The specialist rows and the confusion table are what tuning is done from
+	// HARDWARE: L1DPredictor.Predict/RecordLoad, MetaPredictor.SelectBest
+	// CATEGORY: [UNIT]
+
+	const n = 2000
+	r := ReplayLoadTrace("ensemble", NewL1DPredictor(), stridedTrace(n))
+
+	if r.Loads != n || r.Coverage < 0.95 || r.Accuracy < 0.99 {
+		t.Errorf("loads %d, coverage %.3f, accuracy %.3f", r.Loads, r.Coverage, r.Accuracy)
+	}
+	stride := r.Predictors[PredictorStride-1]
+	if stride.Offered < n*9/10 || stride.Correct != stride.Offered {
+		t.Errorf("stride offered %d, correct %d", stride.Offered, stride.Correct)
+	}
+
+	var total, selected, diagonal uint64
+	for s, row := range r.Confusion {
+		for o, count := range row {
+			total += count
+			if s != int(PredictorNone) {
+				selected += count
+			}
+			if s == o {
+				diagonal += count
+			}
+		}
+	}
+	if total != r.Loads || selected != r.Predictions {
+		t.Errorf("confusion holds %d loads, %d choices; expected %d, %d", total, selected, r.Loads, r.Predictions)
+	}
+	if diagonal < n*95/100 {
+		t.Errorf("only %d of %d choices on the diagonal", diagonal, n)
+	}
+	if stride.Selected < r.Predictions*9/10 {
+		t.Errorf("stride chosen %d of %d times", stride.Selected, r.Predictions)
+	}
+}
+
+func TestAddrTrace_AnyPredictor(t *testing.T) {
+	// WHAT: A predictor outside the ensemble is scored on the same trace, with no specialist
+	//       rows, and the report lists both
+	// WHY: Candidate predictors are compared against the ensemble on identical input
+	// CATEGORY: [UNIT]
+
+	// Five loads of one global, five of a strided walk: last-address gets the global only
+	trace := append(stridedTrace(5), make([]LoadRecord, 5)...)
+	for i := 5; i < 10; i++ {
+		trace[i] = LoadRecord{PC: 0x2000, Addr: 0x8000}
+	}
+	r := ReplayLoadTrace("last", &lastAddrPredictor{last: map[uint32]uint32{}}, trace)
+	if r.Loads != 10 || r.Predictions != 8 || r.Correct != 4 || len(r.Predictors) != 0 {
+		t.Errorf("loads %d, predicted %d, correct %d, rows %d; expected 10, 8, 4, 0",
+			r.Loads, r.Predictions, r.Correct, len(r.Predictors))
+	}
+
+	report := FormatAddrTrace([]AddrTraceResult{r, ReplayLoadTrace("ensemble", NewL1DPredictor(), trace)})
+	for _, want := range []string{"last", "ensemble: specialists", "ensemble: confusion", "context"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestAddrTrace_DisabledSpecialist(t *testing.T) {
+	// WHAT: With stride switched off the replay shows it off and offering nothing, and no
+	//       other specialist covers the walk: every load lands in confusion[none][none]
+	// WHY: Replaying with one specialist off measures its marginal worth offline
+	// HARDWARE: L1DPredictor.disabled (see prefetch.go)
+	// CATEGORY: [UNIT]
+
+	const n = 1000
+	p := NewL1DPredictor()
+	p.disabled[PredictorStride-1] = true
+	r := ReplayLoadTrace("no stride", p, stridedTrace(n))
+
+	stride := r.Predictors[PredictorStride-1]
+	if stride.Enabled || stride.Offered != 0 || stride.Selected != 0 {
+		t.Errorf("stride enabled %v, offered %d, chosen %d", stride.Enabled, stride.Offered, stride.Selected)
+	}
+	if r.Confusion[PredictorNone][PredictorNone] != n {
+		t.Errorf("%d of %d loads uncovered; another specialist followed the walk", r.Confusion[PredictorNone][PredictorNone], n)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. CAPTURE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestAddrTrace_CaptureFromCore(t *testing.T) {
+	// WHAT: A recorded trace holds one record per committed load, including the walk's
+	//       one-line strides, and replays through the ensemble; nothing is recorded unless
+	//       asked
+	// WHY: Core runs are where realistic traces come from
+	// HARDWARE: traceLoad at commit
+	// CATEGORY: [INTEGRATION]
+
+	core := runStrideWalk(t, PredictorNone)
+	if core.LoadTrace() != nil {
+		t.Error("loads recorded without SetLoadTrace")
+	}
+
+	img, err := BuildC("walk.c", strideWalkSource)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	core = NewCore(1 << 20)
+	core.SetConsole(&bytes.Buffer{})
+	core.SetLoadTrace(true)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	core.Run(5_000_000)
+	trace := core.LoadTrace()
+
+	if loads := core.Snapshot().AddrSpec.Loads; uint64(len(trace)) != loads {
+		t.Errorf("recorded %d loads, committed %d", len(trace), loads)
+	}
+	lineStrides := 0
+	last := map[uint32]uint32{}
+	for _, ld := range trace {
+		if prev, ok := last[ld.PC]; ok && ld.Addr-prev == CacheLineSize {
+			lineStrides++
+		}
+		last[ld.PC] = ld.Addr
+	}
+	if lineStrides < 65536/16-1 {
+		t.Errorf("%d one-line strides from the same PC; the walk makes %d", lineStrides, 65536/16-1)
+	}
+
+	r := ReplayLoadTrace("ensemble", NewL1DPredictor(), trace)
+	if r.Loads != uint64(len(trace)) || r.Correct == 0 {
+		t.Errorf("replayed %d loads, %d correct", r.Loads, r.Correct)
+	}
+}
@@ -0,0 +1,740 @@
+package suprax32
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// ASSEMBLER
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// Turns assembly text into a relocatable object (object.go). The syntax is
+// the disassembler's (disasm.go), so listings can be pasted back in.
+//
+// SYNTAX:
+//
+//	label:                       # Comments start with '#'
+//	    add   r3, r1, r2          R-format
+//	    addi  r1, r0, -10         I-format (andi, ori, xori too)
+//	    lui   r4, %hi(table)      upper 17 bits of a symbol
+//	    ori   r4, r4, %lo(table)  lower 15 bits of a symbol
+//	    lw    r5, 16(r2)          loads: lw lr lb lbu lh lhu
+//	    sw    r6, 16(r2)          stores: sw sb sh (data register first)
+//	    sc    r7, r6, 0(r2)       rd = 0 on success
+//	    beq   r1, r2, loop        branches to labels (beq bne blt bge)
+//	    jal   r1, func            jalr r0, 0(r1)
+//	    system 1                  system call 1 (0-4095)
+//	    csrr  a0, epc             read a CSR (by name or number, see trap.go)
+//	    csrw  tvec, a0            write a CSR
+//	    eret                      return from a trap
+//	    tlbflush                  drop every TLB entry (see mmu.go)
+//
+// Registers are r0-r31 or their ABI names (zero ra sp fp a0-a7 t0-t9 s0-s9,
+// see abi.go).
+//
+// PSEUDO-INSTRUCTIONS:
+//
+//	li rd, imm        addi, or lui + ori for values beyond 17 bits
+//	la rd, sym[+off]  lui + ori with relocations
+//	mv rd, rs         addi rd, rs, 0
+//	not rd, rs        xori rd, rs, -1
+//	neg rd, rs        sub rd, r0, rs
+//	nop               add r0, r0, r0
+//	j label           jal r0, label
+//	call sym          jal r1, sym   (r1 is the link register the RSB expects)
+//	ret               jalr r0, 0(r1)
+//	jr rs             jalr r0, 0(rs)
+//	beqz/bnez rs, L   beq/bne rs, r0, L
+//	bgt/ble a, b, L   blt/bge b, a, L
+//	prologue n[, s0, ...]  build a frame with n bytes of locals, saving ra,
+//	                  fp and the listed s-registers (Object.EmitPrologue)
+//	epilogue          undo the last prologue and return
+//
+// DIRECTIVES:
+//
+//	.text .data .bss           switch section
+//	.globl sym[, sym]          make symbols visible to other objects
+//	.word v[, v]               32-bit values or sym[+off] (absolute)
+//	.half v  .byte v           16/8-bit values
+//	.ascii "s"  .asciz "s"     strings (.asciz/.string add a NUL)
+//	.space n  .zero n          n zero bytes (or n bytes of bss)
+//	.align n                   pad to an n-byte boundary
+//	.type .size .file          accepted and ignored
+//
+// Every label reference becomes a relocation, so the linker resolves local
+// and global symbols alike.
+//
+// MINECRAFT ANALOGY: Writing recipe names on the cards instead of
+//
+//	punching the holes by hand.
+
+// regNames maps register operand spellings (rN and ABI names) to numbers
+var regNames = func() map[string]uint8 {
+	m := make(map[string]uint8, 2*NumArchRegs)
+	for i := 0; i < NumArchRegs; i++ {
+		m["r"+strconv.Itoa(i)] = uint8(i)
+		m[ABIRegName(uint8(i))] = uint8(i)
+	}
+	return m
+}()
+
+// asmMnemonics maps real instruction mnemonics to opcodes
+var asmMnemonics = func() map[string]uint8 {
+	m := make(map[string]uint8)
+	for op, name := range opcodeNames {
+		if name != "" && name != "ldn" && name != "stn" {
+			m[name] = uint8(op)
+		}
+	}
+	return m
+}()
+
+// narrowMnemonics maps lb/lbu/lh/lhu/sb/sh to opcode and funct
+var narrowMnemonics = map[string][2]uint8{
+	"lb": {OpLDN, FnByte}, "lbu": {OpLDN, FnByteU},
+	"lh": {OpLDN, FnHalf}, "lhu": {OpLDN, FnHalfU},
+	"sb": {OpSTN, FnByte}, "sh": {OpSTN, FnHalf},
+}
+
+// assembler holds the state of one Assemble call
+type assembler struct {
+	obj     *Object
+	sec     SectionKind
+	globals []string
+	line    int
+	frame   *Frame // Last prologue, for epilogue
+}
+
+// Assemble translates assembly source into a relocatable object
+func Assemble(name, src string) (*Object, error) {
+	a := &assembler{obj: NewObject(name), sec: SecText}
+	for i, text := range strings.Split(src, "\n") {
+		a.line = i + 1
+		if err := a.statement(text); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, a.line, err)
+		}
+	}
+	for _, g := range a.globals {
+		if sym, ok := a.obj.Lookup(g); ok && sym.Section != SecUndef {
+			sym.Global = true
+		}
+	}
+	return a.obj, nil
+}
+
+// statement assembles one line: labels, then a directive or instruction
+func (a *assembler) statement(text string) error {
+	text = strings.TrimSpace(stripComment(text))
+	for {
+		i := strings.IndexByte(text, ':')
+		if i < 0 || !isIdent(text[:i]) {
+			break
+		}
+		if err := a.label(text[:i]); err != nil {
+			return err
+		}
+		text = strings.TrimSpace(text[i+1:])
+	}
+	if text == "" {
+		return nil
+	}
+
+	mnemonic, rest := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		mnemonic, rest = text[:i], strings.TrimSpace(text[i+1:])
+	}
+	mnemonic = strings.ToLower(mnemonic)
+	if strings.HasPrefix(mnemonic, ".") {
+		return a.directive(mnemonic, rest)
+	}
+	if a.sec != SecText {
+		return fmt.Errorf("instruction %q outside .text", mnemonic)
+	}
+	return a.instruction(mnemonic, splitOperands(rest))
+}
+
+// label defines a symbol at the current position of the current section
+func (a *assembler) label(name string) error {
+	var off uint32
+	switch a.sec {
+	case SecText:
+		off = uint32(len(a.obj.Text))
+	case SecData:
+		off = uint32(len(a.obj.Data))
+	default:
+		off = a.obj.BSSSize
+	}
+	return a.obj.Define(name, a.sec, off, false)
+}
+
+// emitBytes appends raw bytes to the current section
+func (a *assembler) emitBytes(b []byte) error {
+	switch a.sec {
+	case SecText:
+		a.obj.Text = append(a.obj.Text, b...)
+	case SecData:
+		a.obj.Data = append(a.obj.Data, b...)
+	default:
+		for _, v := range b {
+			if v != 0 {
+				return fmt.Errorf("initialised data in .bss")
+			}
+		}
+		a.obj.BSSSize += uint32(len(b))
+	}
+	return nil
+}
+
+// offset returns the current position in the current section
+func (a *assembler) offset() uint32 {
+	return a.obj.sectionSize(a.sec)
+}
+
+// directive handles assembler directives
+func (a *assembler) directive(name, rest string) error {
+	args := splitOperands(rest)
+	switch name {
+	case ".text":
+		a.sec = SecText
+	case ".data", ".rodata":
+		a.sec = SecData
+	case ".bss":
+		a.sec = SecBSS
+	case ".section":
+		switch {
+		case len(args) > 0 && strings.HasPrefix(args[0], ".text"):
+			a.sec = SecText
+		case len(args) > 0 && strings.HasPrefix(args[0], ".bss"):
+			a.sec = SecBSS
+		case len(args) > 0:
+			a.sec = SecData
+		}
+	case ".globl", ".global":
+		a.globals = append(a.globals, args...)
+	case ".type", ".size", ".file", ".local":
+		// Accepted for compatibility with other assemblers' output
+	case ".word", ".half", ".byte":
+		size := map[string]int{".word": 4, ".half": 2, ".byte": 1}[name]
+		for _, arg := range args {
+			if err := a.dataValue(arg, size); err != nil {
+				return err
+			}
+		}
+	case ".ascii", ".asciz", ".string":
+		s, err := strconv.Unquote(rest)
+		if err != nil {
+			return fmt.Errorf("bad string %s", rest)
+		}
+		if name != ".ascii" {
+			s += "\x00"
+		}
+		return a.emitBytes([]byte(s))
+	case ".space", ".zero":
+		n, err := parseImm(rest)
+		if err != nil || n < 0 {
+			return fmt.Errorf("bad size %q", rest)
+		}
+		return a.emitBytes(make([]byte, n))
+	case ".align", ".balign", ".p2align":
+		n, err := parseImm(rest)
+		if name == ".p2align" {
+			n = 1 << n
+		}
+		if err != nil || n <= 0 || n&(n-1) != 0 {
+			return fmt.Errorf("bad alignment %q", rest)
+		}
+		pad := alignUp(a.offset(), uint32(n)) - a.offset()
+		return a.emitBytes(make([]byte, pad))
+	default:
+		return fmt.Errorf("unknown directive %s", name)
+	}
+	return nil
+}
+
+// dataValue emits one .word/.half/.byte value (numbers, or sym[+off] for words)
+func (a *assembler) dataValue(arg string, size int) error {
+	if v, err := parseImm(arg); err == nil {
+		b := binary.LittleEndian.AppendUint32(nil, uint32(v))
+		return a.emitBytes(b[:size])
+	}
+	sym, addend, ok := parseSymRef(arg)
+	if !ok || size != 4 || a.sec == SecBSS {
+		return fmt.Errorf("bad value %q", arg)
+	}
+	a.obj.reloc(a.sec, a.offset(), RelocAbs32, sym, addend)
+	return a.emitBytes(make([]byte, 4))
+}
+
+// instruction assembles one (pseudo-)instruction
+func (a *assembler) instruction(m string, ops []string) error {
+	o := a.obj
+	p := &operands{ops: ops}
+
+	if nm, ok := narrowMnemonics[m]; ok {
+		reg := p.reg()
+		off, base := p.mem()
+		if err := p.done(2); err != nil {
+			return err
+		}
+		if off < -(1<<14) || off >= 1<<14 {
+			return fmt.Errorf("offset %d out of range for %s", off, m)
+		}
+		o.Emit(EncodeNFormat(nm[0], reg, base, nm[1], int32(off)))
+		return nil
+	}
+
+	switch m {
+	// ───────────────────────────── pseudo-instructions ─────────────────────
+	case "nop":
+		o.Emit(EncodeRFormat(OpADD, 0, 0, 0))
+		return p.done(0)
+	case "mv":
+		rd, rs := p.reg(), p.reg()
+		o.Emit(EncodeIFormat(OpADDI, rd, rs, 0))
+		return p.done(2)
+	case "not":
+		rd, rs := p.reg(), p.reg()
+		o.Emit(EncodeIFormat(OpXORI, rd, rs, -1))
+		return p.done(2)
+	case "neg":
+		rd, rs := p.reg(), p.reg()
+		o.Emit(EncodeRFormat(OpSUB, rd, 0, rs))
+		return p.done(2)
+	case "li":
+		rd, v := p.reg(), p.imm()
+		if err := p.done(2); err != nil {
+			return err
+		}
+		if v < -(1<<31) || v >= 1<<32 {
+			return fmt.Errorf("li value %d does not fit in 32 bits", v)
+		}
+		emitLoadImm(o, rd, uint32(v))
+		return nil
+	case "la":
+		rd := p.reg()
+		sym, addend := p.sym()
+		if err := p.done(2); err != nil {
+			return err
+		}
+		o.EmitLoadAddr(rd, sym, addend)
+		return nil
+	case "j":
+		sym, addend := p.sym()
+		a.jal(0, sym, addend)
+		return p.done(1)
+	case "call":
+		sym, addend := p.sym()
+		a.jal(1, sym, addend)
+		return p.done(1)
+	case "ret":
+		o.Emit(EncodeIFormat(OpJALR, 0, 1, 0))
+		return p.done(0)
+	case "jr":
+		rs := p.reg()
+		o.Emit(EncodeIFormat(OpJALR, 0, rs, 0))
+		return p.done(1)
+	case "beqz", "bnez":
+		rs := p.reg()
+		sym, addend := p.sym()
+		a.branch(map[string]uint8{"beqz": OpBEQ, "bnez": OpBNE}[m], rs, 0, sym, addend)
+		return p.done(2)
+	case "bgt", "ble":
+		rs1, rs2 := p.reg(), p.reg()
+		sym, addend := p.sym()
+		a.branch(map[string]uint8{"bgt": OpBLT, "ble": OpBGE}[m], rs2, rs1, sym, addend)
+		return p.done(3)
+	case "prologue":
+		f := &Frame{}
+		locals := p.imm()
+		for p.i < len(ops) {
+			f.Saved = append(f.Saved, p.reg())
+		}
+		if err := p.done(len(ops)); err != nil {
+			return err
+		}
+		if locals < 0 || locals >= 1<<30 {
+			return fmt.Errorf("bad frame size %d", locals)
+		}
+		f.Locals = uint32(locals)
+		if err := f.check(); err != nil {
+			return err
+		}
+		o.EmitPrologue(*f)
+		a.frame = f
+		return nil
+	case "epilogue":
+		if a.frame == nil {
+			return fmt.Errorf("epilogue without a prologue")
+		}
+		o.EmitEpilogue(*a.frame)
+		return p.done(0)
+
+	// ───────────────────────────── system functions (trap.go) ──────────────
+	case "csrr":
+		rd, csr := p.reg(), p.csr()
+		o.Emit(EncodeSystem(SysFnCSRRead, rd, 0, csr))
+		return p.done(2)
+	case "csrw":
+		csr, rs := p.csr(), p.reg()
+		o.Emit(EncodeSystem(SysFnCSRWrite, 0, rs, csr))
+		return p.done(2)
+	case "eret":
+		o.Emit(EncodeSystem(SysFnTrapRet, 0, 0, 0))
+		return p.done(0)
+	case "tlbflush":
+		o.Emit(EncodeSystem(SysFnTLBFlush, 0, 0, 0))
+		return p.done(0)
+	}
+
+	op, ok := asmMnemonics[m]
+	if !ok {
+		return fmt.Errorf("unknown instruction %q", m)
+	}
+
+	switch {
+	case op < OpLDN:
+		rd, rs1, rs2 := p.reg(), p.reg(), p.reg()
+		o.Emit(EncodeRFormat(op, rd, rs1, rs2))
+		return p.done(3)
+
+	case op >= OpBEQ && op <= OpBGE:
+		rs1, rs2 := p.reg(), p.reg()
+		sym, addend := p.sym()
+		a.branch(op, rs1, rs2, sym, addend)
+		return p.done(3)
+
+	case op == OpLW || op == OpLR:
+		rd := p.reg()
+		off, base := p.mem()
+		o.Emit(EncodeIFormat(op, rd, base, int32(off)))
+		return p.doneImm(2, off)
+
+	case op == OpSW:
+		rs2 := p.reg()
+		off, base := p.mem()
+		o.Emit(EncodeSFormat(op, base, rs2, int32(off)))
+		return p.doneImm(2, off)
+
+	case op == OpSC:
+		rd, rs2 := p.reg(), p.reg()
+		off, base := p.mem()
+		if err := p.done(3); err != nil {
+			return err
+		}
+		if off < -(1<<11) || off >= 1<<11 {
+			return fmt.Errorf("offset %d out of range for sc", off)
+		}
+		o.Emit(EncodeSC(rd, base, rs2, int32(off)))
+		return nil
+
+	case op == OpJAL:
+		rd := uint8(1)
+		if len(ops) == 2 {
+			rd = p.reg()
+		}
+		sym, addend := p.sym()
+		a.jal(rd, sym, addend)
+		return p.done(len(ops))
+
+	case op == OpJALR:
+		rd := p.reg()
+		off, base := p.mem()
+		o.Emit(EncodeIFormat(op, rd, base, int32(off)))
+		return p.doneImm(2, off)
+
+	case op == OpLUI:
+		rd := p.reg()
+		if sym, addend, ok := p.reloc("%hi"); ok {
+			a.relocated(EncodeIFormat(op, rd, 0, 0), RelocHi17, sym, addend)
+			return p.done(2)
+		}
+		// The upper 17 bits are unsigned, as the disassembler prints them
+		v := p.imm()
+		if err := p.done(2); err != nil {
+			return err
+		}
+		if v < -(1<<16) || v >= 1<<17 {
+			return fmt.Errorf("lui value %d out of range (17 bits)", v)
+		}
+		o.Emit(EncodeIFormat(op, rd, 0, int32(v)))
+		return nil
+
+	case op == OpSYSTEM:
+		v := p.imm()
+		if err := p.done(1); err != nil {
+			return err
+		}
+		if v < 0 || v > 0xFFF {
+			return fmt.Errorf("system call %d out of range (0-4095)", v)
+		}
+		o.Emit(EncodeSystem(SysFnCall, 0, 0, uint32(v)))
+		return nil
+
+	default:
+		// I-format ALU: addi andi ori xori
+		rd, rs1 := p.reg(), p.reg()
+		if sym, addend, ok := p.reloc("%lo"); ok && op == OpORI {
+			a.relocated(EncodeIFormat(op, rd, rs1, 0), RelocLo15, sym, addend)
+			return p.done(3)
+		}
+		v := p.imm()
+		o.Emit(EncodeIFormat(op, rd, rs1, int32(v)))
+		return p.doneImm(3, v)
+	}
+}
+
+// emitLoadImm loads a 32-bit constant in one or two instructions
+func emitLoadImm(o *Object, rd uint8, v uint32) {
+	if s := int32(v); s >= -(1<<16) && s < 1<<16 {
+		o.Emit(EncodeIFormat(OpADDI, rd, 0, s))
+		return
+	}
+	o.Emit(EncodeIFormat(OpLUI, rd, 0, int32(v>>15)))
+	if lo := v & 0x7FFF; lo != 0 {
+		o.Emit(EncodeIFormat(OpORI, rd, rd, int32(lo)))
+	}
+}
+
+// branch emits a conditional branch with a relocation to its target
+func (a *assembler) branch(op, rs1, rs2 uint8, sym string, addend int32) {
+	a.relocated(EncodeBFormat(op, rs1, rs2, 0), RelocBranch17, sym, addend)
+}
+
+// jal emits a jump-and-link with a relocation to its target
+func (a *assembler) jal(rd uint8, sym string, addend int32) {
+	a.relocated(EncodeIFormat(OpJAL, rd, 0, 0), RelocJAL17, sym, addend)
+}
+
+// relocated emits an instruction word and its relocation
+func (a *assembler) relocated(word uint32, typ RelocType, sym string, addend int32) {
+	off := a.obj.Emit(word)
+	a.obj.reloc(SecText, off, typ, sym, addend)
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Operand parsing
+// ───────────────────────────────────────────────────────────────────────────────
+
+// operands consumes an instruction's operands left to right; the first
+// problem is remembered and reported by done
+type operands struct {
+	ops []string
+	i   int
+	err error
+}
+
+func (p *operands) next() string {
+	if p.i >= len(p.ops) {
+		if p.err == nil {
+			p.err = fmt.Errorf("missing operand")
+		}
+		return ""
+	}
+	p.i++
+	return p.ops[p.i-1]
+}
+
+func (p *operands) fail(format string, args ...any) {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
+// reg parses a register name
+func (p *operands) reg() uint8 {
+	s := p.next()
+	r, ok := regNames[strings.ToLower(s)]
+	if !ok && s != "" {
+		p.fail("bad register %q", s)
+	}
+	return r
+}
+
+// imm parses an integer or character constant
+func (p *operands) imm() int64 {
+	s := p.next()
+	v, err := parseImm(s)
+	if err != nil && s != "" {
+		p.fail("bad immediate %q", s)
+	}
+	return v
+}
+
+// csr parses a CSR name (see trap.go) or number
+func (p *operands) csr() uint32 {
+	s := p.next()
+	if n, ok := csrNames[strings.ToLower(s)]; ok {
+		return n
+	}
+	v, err := parseImm(s)
+	if (err != nil || v < 0 || v > 0xFFF) && s != "" {
+		p.fail("bad CSR %q", s)
+	}
+	return uint32(v) & 0xFFF
+}
+
+// mem parses "off(reg)" or "(reg)"
+func (p *operands) mem() (int64, uint8) {
+	s := p.next()
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		if s != "" {
+			p.fail("bad memory operand %q", s)
+		}
+		return 0, 0
+	}
+	var off int64
+	if open > 0 {
+		v, err := parseImm(strings.TrimSpace(s[:open]))
+		if err != nil {
+			p.fail("bad offset in %q", s)
+		}
+		off = v
+	}
+	r, ok := regNames[strings.ToLower(strings.TrimSpace(s[open+1:len(s)-1]))]
+	if !ok {
+		p.fail("bad base register in %q", s)
+	}
+	return off, r
+}
+
+// sym parses a label reference "name", "name+4" or "name-4"
+func (p *operands) sym() (string, int32) {
+	s := p.next()
+	sym, addend, ok := parseSymRef(s)
+	if !ok && s != "" {
+		p.fail("bad label %q", s)
+	}
+	return sym, addend
+}
+
+// reloc parses "%hi(sym+off)" / "%lo(sym+off)" if the next operand is one
+func (p *operands) reloc(kind string) (string, int32, bool) {
+	if p.i >= len(p.ops) || !strings.HasPrefix(p.ops[p.i], kind+"(") || !strings.HasSuffix(p.ops[p.i], ")") {
+		return "", 0, false
+	}
+	s := p.next()
+	sym, addend, ok := parseSymRef(s[len(kind)+1 : len(s)-1])
+	if !ok {
+		p.fail("bad relocation %q", s)
+	}
+	return sym, addend, true
+}
+
+// done checks the operand count and reports the first parse error
+func (p *operands) done(n int) error {
+	if p.err != nil {
+		return p.err
+	}
+	if len(p.ops) != n {
+		return fmt.Errorf("expected %d operands, got %d", n, len(p.ops))
+	}
+	return nil
+}
+
+// doneImm is done plus a check that the immediate fits in 17 signed bits
+func (p *operands) doneImm(n int, v int64) error {
+	if err := p.done(n); err != nil {
+		return err
+	}
+	if v < -(1<<16) || v >= 1<<16 {
+		return fmt.Errorf("immediate %d out of range (17 bits)", v)
+	}
+	return nil
+}
+
+// parseImm parses decimal, hex (0x), octal (0) or 'c' constants
+func parseImm(s string) (int64, error) {
+	if len(s) >= 3 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		r, _, tail, err := strconv.UnquoteChar(s[1:len(s)-1], '\'')
+		if err != nil || tail != "" {
+			return 0, fmt.Errorf("bad character %s", s)
+		}
+		return int64(r), nil
+	}
+	return strconv.ParseInt(s, 0, 64)
+}
+
+// parseSymRef splits "name", "name+off" or "name-off"
+func parseSymRef(s string) (string, int32, bool) {
+	name, off := s, int64(0)
+	if i := strings.IndexAny(s, "+-"); i > 0 {
+		v, err := strconv.ParseInt(s[i:], 0, 32)
+		if err != nil {
+			return "", 0, false
+		}
+		name, off = s[:i], v
+	}
+	if !isIdent(name) {
+		return "", 0, false
+	}
+	return name, int32(off), true
+}
+
+// isIdent reports whether s is a symbol name: [A-Za-z_.$][A-Za-z0-9_.$]*
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		letter := c == '_' || c == '.' || c == '$' || (c|0x20 >= 'a' && c|0x20 <= 'z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitOperands splits on commas outside quotes and parentheses
+func splitOperands(s string) []string {
+	var ops []string
+	depth, quote, start := 0, byte(0), 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			ops = append(ops, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" || len(ops) > 0 {
+		ops = append(ops, rest)
+	}
+	return ops
+}
+
+// stripComment removes a '#' comment that is not inside a string
+func stripComment(s string) string {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
@@ -0,0 +1,1095 @@
+package suprax32
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// BENCHMARK SUITE
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: The Create* programs are hand-built loops, and CompareWithIntel
+//
+//	measures them against a fixed number. Numbers that mean something
+//	outside this project need known workloads that check their answers.
+//
+// THE KERNELS (C, built with BuildC against the runtime library):
+//
+//	dhrystone      Dhrystone 2.1, checked against Weicker's final values
+//	coremark       CoreMark-style list/matrix/state machine/CRC-16; every
+//	               iteration must reproduce a fixed CRC
+//	sort           quicksort and heapsort must agree and stay ordered
+//	hash           every inserted key found, every other key missed
+//	crc32          table-driven CRC-32 against the bitwise definition
+//	pointer-chase  a single random cycle through a 128 KB footprint
+//	spmv           CSR sparse product against coordinate order
+//
+// Each kernel takes ITERATIONS from the runner, prints one summary line
+// and exits 0 only when its self-check passes.
+//
+// MEASUREMENT: Cycles and instructions cover the whole program, from
+//
+//	_start to SysExit, including setup and the self-check.
+//
+// MINECRAFT ANALOGY: Timing a speedrun of a real map instead of a
+//
+//	flat test world you built to make your redstone look fast.
+
+// Kernel is one benchmark program
+type Kernel struct {
+	Name        string
+	Description string
+	Source      string // C; ITERATIONS is defined by the runner
+	Iterations  int    // Default iteration count
+}
+
+// SuiteOptions controls RunKernel and RunSuite
+type SuiteOptions struct {
+	Iterations int           // 0 = each kernel's default
+	MaxCycles  uint64        // 0 = 50M; a kernel still running fails
+	MemorySize int           // 0 = 1MB
+	Configure  func(c *Core) // Optional: adjust the core before the run
+}
+
+// KernelResult is one kernel's outcome
+type KernelResult struct {
+	Name         string
+	Iterations   int
+	Cycles       uint64
+	Instructions uint64
+	IPC          float64
+	Passed       bool   // Exited with status 0
+	Status       int32  // Exit status (valid when the program exited)
+	Output       string // Console output
+	Err          error  // Build or load failure, or no exit within MaxCycles
+	Stats        *Stats // Final snapshot (nil if the kernel never ran)
+}
+
+// StandardKernels returns the benchmark suite
+func StandardKernels() []Kernel {
+	return []Kernel{
+		{Name: "dhrystone", Description: "Dhrystone 2.1: calls, records, string copies and compares", Source: kernelDhrystoneSource, Iterations: 1000},
+		{Name: "coremark", Description: "CoreMark-style list, matrix, state machine and CRC-16", Source: kernelCoremarkSource, Iterations: 5},
+		{Name: "sort", Description: "Quicksort and heapsort of 200 integers", Source: kernelSortSource, Iterations: 8},
+		{Name: "hash", Description: "Open-addressing hash table with FNV-1a keys", Source: kernelHashSource, Iterations: 3},
+		{Name: "crc32", Description: "Table-driven CRC-32 over 512 bytes (Embench-style)", Source: kernelCrc32Source, Iterations: 40},
+		{Name: "pointer-chase", Description: "Random single-cycle walk over 128 KB, one node per line", Source: kernelChaseSource, Iterations: 4},
+		{Name: "spmv", Description: "CSR sparse matrix-vector product, 720 nonzeros", Source: kernelSpmvSource, Iterations: 10},
+	}
+}
+
+// RunKernel builds and runs one kernel on a fresh core
+func RunKernel(k Kernel, opts SuiteOptions) KernelResult {
+	r := KernelResult{Name: k.Name, Iterations: k.Iterations}
+	if opts.Iterations > 0 {
+		r.Iterations = opts.Iterations
+	}
+	maxCycles := opts.MaxCycles
+	if maxCycles == 0 {
+		maxCycles = 50_000_000
+	}
+	memSize := opts.MemorySize
+	if memSize == 0 {
+		memSize = 1 << 20
+	}
+
+	src := fmt.Sprintf("#define ITERATIONS %d\n%s", r.Iterations, k.Source)
+	img, err := BuildC(k.Name+".c", src)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	core := NewCore(memSize)
+	var console strings.Builder
+	core.SetConsole(&console)
+	if opts.Configure != nil {
+		opts.Configure(core)
+	}
+	if err := core.LoadImage(img); err != nil {
+		r.Err = err
+		return r
+	}
+	core.Run(maxCycles)
+
+	status, exited := core.Exited()
+	r.Cycles, r.Instructions, r.IPC = core.cycles, core.instructions, core.GetIPC()
+	r.Stats = core.Snapshot()
+	r.Output = console.String()
+	r.Status = int32(status)
+	r.Passed = exited && status == 0
+	if !exited {
+		r.Err = fmt.Errorf("%s: no exit within %d cycles", k.Name, maxCycles)
+	}
+	return r
+}
+
+// RunSuite runs every standard kernel
+func RunSuite(opts SuiteOptions) []KernelResult {
+	var results []KernelResult
+	for _, k := range StandardKernels() {
+		results = append(results, RunKernel(k, opts))
+	}
+	return results
+}
+
+// FormatSuite renders results as a table with a geometric-mean IPC
+func FormatSuite(results []KernelResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-14s %6s %12s %12s %6s  %s\n", "KERNEL", "ITERS", "CYCLES", "INSTRUCTIONS", "IPC", "RESULT")
+
+	logSum, n := 0.0, 0
+	for _, r := range results {
+		result := "PASS"
+		switch {
+		case r.Err != nil:
+			result = "FAIL: " + r.Err.Error()
+		case !r.Passed:
+			result = fmt.Sprintf("FAIL: exit status %d", r.Status)
+		}
+		fmt.Fprintf(&b, "%-14s %6d %12d %12d %6.3f  %s\n", r.Name, r.Iterations, r.Cycles, r.Instructions, r.IPC, result)
+		if r.IPC > 0 {
+			logSum += math.Log(r.IPC)
+			n++
+		}
+	}
+	if n > 0 {
+		fmt.Fprintf(&b, "%-14s %6s %12s %12s %6.3f\n", "geomean", "", "", "", math.Exp(logSum/float64(n)))
+	}
+	return b.String()
+}
+
+// kernelDhrystoneSource is the dhrystone kernel
+const kernelDhrystoneSource = `/* Dhrystone 2.1 (Weicker), integer benchmark of calls, records and strings */
+#include <stdio.h>
+#include <string.h>
+
+typedef enum { Ident_1, Ident_2, Ident_3, Ident_4, Ident_5 } Enumeration;
+typedef int One_Thirty;
+typedef int One_Fifty;
+typedef char Capital_Letter;
+typedef int Boolean;
+typedef char Str_30[31];
+typedef int Arr_1_Dim[50];
+typedef int Arr_2_Dim[50][50];
+
+typedef struct record {
+	struct record *Ptr_Comp;
+	Enumeration Discr;
+	Enumeration Enum_Comp;
+	int Int_Comp;
+	Str_30 Str_Comp;
+} Rec_Type, *Rec_Pointer;
+
+Rec_Type Glob_Rec, Next_Glob_Rec;
+Rec_Pointer Ptr_Glob, Next_Ptr_Glob;
+int Int_Glob;
+Boolean Bool_Glob;
+char Ch_1_Glob, Ch_2_Glob;
+Arr_1_Dim Arr_1_Glob;
+Arr_2_Dim Arr_2_Glob;
+
+void Proc_3(Rec_Pointer *Ptr_Ref_Par);
+void Proc_6(Enumeration Enum_Val_Par, Enumeration *Enum_Ref_Par);
+void Proc_7(One_Fifty Int_1_Par_Val, One_Fifty Int_2_Par_Val, One_Fifty *Int_Par_Ref);
+Boolean Func_3(Enumeration Enum_Par_Val);
+
+void Proc_1(Rec_Pointer Ptr_Val_Par) {
+	Rec_Pointer Next_Record = Ptr_Val_Par->Ptr_Comp;
+
+	*Ptr_Val_Par->Ptr_Comp = *Ptr_Glob;
+	Ptr_Val_Par->Int_Comp = 5;
+	Next_Record->Int_Comp = Ptr_Val_Par->Int_Comp;
+	Next_Record->Ptr_Comp = Ptr_Val_Par->Ptr_Comp;
+	Proc_3(&Next_Record->Ptr_Comp);
+	if (Next_Record->Discr == Ident_1) {
+		Next_Record->Int_Comp = 6;
+		Proc_6(Ptr_Val_Par->Enum_Comp, &Next_Record->Enum_Comp);
+		Next_Record->Ptr_Comp = Ptr_Glob->Ptr_Comp;
+		Proc_7(Next_Record->Int_Comp, 10, &Next_Record->Int_Comp);
+	} else {
+		*Ptr_Val_Par = *Ptr_Val_Par->Ptr_Comp;
+	}
+}
+
+void Proc_2(One_Fifty *Int_Par_Ref) {
+	One_Fifty Int_Loc = *Int_Par_Ref + 10;
+	Enumeration Enum_Loc = Ident_2;
+
+	do
+		if (Ch_1_Glob == 'A') {
+			Int_Loc -= 1;
+			*Int_Par_Ref = Int_Loc - Int_Glob;
+			Enum_Loc = Ident_1;
+		}
+	while (Enum_Loc != Ident_1);
+}
+
+void Proc_3(Rec_Pointer *Ptr_Ref_Par) {
+	if (Ptr_Glob != 0)
+		*Ptr_Ref_Par = Ptr_Glob->Ptr_Comp;
+	Proc_7(10, Int_Glob, &Ptr_Glob->Int_Comp);
+}
+
+void Proc_4(void) {
+	Boolean Bool_Loc = Ch_1_Glob == 'A';
+	Bool_Glob = Bool_Loc | Bool_Glob;
+	Ch_2_Glob = 'B';
+}
+
+void Proc_5(void) {
+	Ch_1_Glob = 'A';
+	Bool_Glob = 0;
+}
+
+void Proc_6(Enumeration Enum_Val_Par, Enumeration *Enum_Ref_Par) {
+	*Enum_Ref_Par = Enum_Val_Par;
+	if (!Func_3(Enum_Val_Par))
+		*Enum_Ref_Par = Ident_4;
+	switch (Enum_Val_Par) {
+	case Ident_1:
+		*Enum_Ref_Par = Ident_1;
+		break;
+	case Ident_2:
+		if (Int_Glob > 100)
+			*Enum_Ref_Par = Ident_1;
+		else
+			*Enum_Ref_Par = Ident_4;
+		break;
+	case Ident_3:
+		*Enum_Ref_Par = Ident_2;
+		break;
+	case Ident_4:
+		break;
+	case Ident_5:
+		*Enum_Ref_Par = Ident_3;
+		break;
+	}
+}
+
+void Proc_7(One_Fifty Int_1_Par_Val, One_Fifty Int_2_Par_Val, One_Fifty *Int_Par_Ref) {
+	One_Fifty Int_Loc = Int_1_Par_Val + 2;
+	*Int_Par_Ref = Int_2_Par_Val + Int_Loc;
+}
+
+void Proc_8(Arr_1_Dim Arr_1_Par_Ref, Arr_2_Dim Arr_2_Par_Ref, int Int_1_Par_Val, int Int_2_Par_Val) {
+	One_Fifty Int_Index;
+	One_Fifty Int_Loc = Int_1_Par_Val + 5;
+
+	Arr_1_Par_Ref[Int_Loc] = Int_2_Par_Val;
+	Arr_1_Par_Ref[Int_Loc + 1] = Arr_1_Par_Ref[Int_Loc];
+	Arr_1_Par_Ref[Int_Loc + 30] = Int_Loc;
+	for (Int_Index = Int_Loc; Int_Index <= Int_Loc + 1; ++Int_Index)
+		Arr_2_Par_Ref[Int_Loc][Int_Index] = Int_Loc;
+	Arr_2_Par_Ref[Int_Loc][Int_Loc - 1] += 1;
+	Arr_2_Par_Ref[Int_Loc + 20][Int_Loc] = Arr_1_Par_Ref[Int_Loc];
+	Int_Glob = 5;
+}
+
+Enumeration Func_1(Capital_Letter Ch_1_Par_Val, Capital_Letter Ch_2_Par_Val) {
+	Capital_Letter Ch_1_Loc = Ch_1_Par_Val;
+	Capital_Letter Ch_2_Loc = Ch_1_Loc;
+
+	if (Ch_2_Loc != Ch_2_Par_Val)
+		return Ident_1;
+	Ch_1_Glob = Ch_1_Loc;
+	return Ident_2;
+}
+
+Boolean Func_2(Str_30 Str_1_Par_Ref, Str_30 Str_2_Par_Ref) {
+	One_Thirty Int_Loc = 2;
+	Capital_Letter Ch_Loc = 'A';
+
+	while (Int_Loc <= 2)
+		if (Func_1(Str_1_Par_Ref[Int_Loc], Str_2_Par_Ref[Int_Loc + 1]) == Ident_1) {
+			Ch_Loc = 'A';
+			Int_Loc += 1;
+		}
+	if (Ch_Loc >= 'W' && Ch_Loc < 'Z')
+		Int_Loc = 7;
+	if (Ch_Loc == 'R')
+		return 1;
+	if (strcmp(Str_1_Par_Ref, Str_2_Par_Ref) > 0) {
+		Int_Loc += 7;
+		Int_Glob = Int_Loc;
+		return 1;
+	}
+	return 0;
+}
+
+Boolean Func_3(Enumeration Enum_Par_Val) {
+	return Enum_Par_Val == Ident_3;
+}
+
+int main(void) {
+	One_Fifty Int_1_Loc, Int_2_Loc, Int_3_Loc;
+	char Ch_Index;
+	Enumeration Enum_Loc;
+	Str_30 Str_1_Loc, Str_2_Loc;
+	int Run_Index, errors = 0;
+
+	Next_Ptr_Glob = &Next_Glob_Rec;
+	Ptr_Glob = &Glob_Rec;
+	Ptr_Glob->Ptr_Comp = Next_Ptr_Glob;
+	Ptr_Glob->Discr = Ident_1;
+	Ptr_Glob->Enum_Comp = Ident_3;
+	Ptr_Glob->Int_Comp = 40;
+	strcpy(Ptr_Glob->Str_Comp, "DHRYSTONE PROGRAM, SOME STRING");
+	strcpy(Str_1_Loc, "DHRYSTONE PROGRAM, 1'ST STRING");
+	Arr_2_Glob[8][7] = 10;
+
+	for (Run_Index = 1; Run_Index <= ITERATIONS; ++Run_Index) {
+		Proc_5();
+		Proc_4();
+		Int_1_Loc = 2;
+		Int_2_Loc = 3;
+		strcpy(Str_2_Loc, "DHRYSTONE PROGRAM, 2'ND STRING");
+		Enum_Loc = Ident_2;
+		Bool_Glob = !Func_2(Str_1_Loc, Str_2_Loc);
+		while (Int_1_Loc < Int_2_Loc) {
+			Int_3_Loc = 5 * Int_1_Loc - Int_2_Loc;
+			Proc_7(Int_1_Loc, Int_2_Loc, &Int_3_Loc);
+			Int_1_Loc += 1;
+		}
+		Proc_8(Arr_1_Glob, Arr_2_Glob, Int_1_Loc, Int_3_Loc);
+		Proc_1(Ptr_Glob);
+		for (Ch_Index = 'A'; Ch_Index <= Ch_2_Glob; ++Ch_Index) {
+			if (Enum_Loc == Func_1(Ch_Index, 'C')) {
+				Proc_6(Ident_1, &Enum_Loc);
+				strcpy(Str_2_Loc, "DHRYSTONE PROGRAM, 3'RD STRING");
+				Int_2_Loc = Run_Index;
+				Int_Glob = Run_Index;
+			}
+		}
+		Int_2_Loc = Int_2_Loc * Int_1_Loc;
+		Int_1_Loc = Int_2_Loc / Int_3_Loc;
+		Int_2_Loc = 7 * (Int_2_Loc - Int_3_Loc) - Int_1_Loc;
+		Proc_2(&Int_1_Loc);
+	}
+
+	/* The final values Weicker's reference output says "should be" */
+	errors += Int_Glob != 5;
+	errors += Bool_Glob != 1;
+	errors += Ch_1_Glob != 'A';
+	errors += Ch_2_Glob != 'B';
+	errors += Arr_1_Glob[8] != 7;
+	errors += Arr_2_Glob[8][7] != ITERATIONS + 10;
+	errors += Ptr_Glob->Discr != 0 || Ptr_Glob->Enum_Comp != 2 || Ptr_Glob->Int_Comp != 17;
+	errors += strcmp(Ptr_Glob->Str_Comp, "DHRYSTONE PROGRAM, SOME STRING") != 0;
+	errors += Next_Ptr_Glob->Discr != 0 || Next_Ptr_Glob->Enum_Comp != 1 || Next_Ptr_Glob->Int_Comp != 18;
+	errors += Int_1_Loc != 5 || Int_2_Loc != 13 || Int_3_Loc != 7 || Enum_Loc != 1;
+	errors += strcmp(Str_1_Loc, "DHRYSTONE PROGRAM, 1'ST STRING") != 0;
+	errors += strcmp(Str_2_Loc, "DHRYSTONE PROGRAM, 2'ND STRING") != 0;
+
+	printf("dhrystone: %d runs, %d errors\n", ITERATIONS, errors);
+	return errors;
+}
+`
+
+// kernelCoremarkSource is the coremark kernel
+const kernelCoremarkSource = `/* CoreMark-style workload: linked list, matrix, state machine and CRC.
+   Every iteration redoes the same work from the same seeds, so every
+   iteration must produce the same CRC, and that CRC is fixed. */
+#include <stdio.h>
+#include <string.h>
+
+#define LIST_NODES 32
+#define MATRIX_N 6
+#define STATE_BYTES 120
+#define EXPECTED_CRC 0xa038 /* Same seeds, same work: a fixed CRC */
+
+/* ─── CRC-16 (bitwise, as CoreMark computes it) ─── */
+
+unsigned short crcu8(unsigned char data, unsigned short crc) {
+	for (int i = 0; i < 8; i++) {
+		int x16 = (data & 1) ^ (crc & 1);
+		data >>= 1;
+		if (x16 == 1)
+			crc ^= 0x4002;
+		crc >>= 1;
+		if (x16)
+			crc |= 0x8000;
+		else
+			crc &= 0x7fff;
+	}
+	return crc;
+}
+
+unsigned short crcu16(unsigned short v, unsigned short crc) {
+	crc = crcu8(v, crc);
+	return crcu8(v >> 8, crc);
+}
+
+unsigned short crcu32(unsigned v, unsigned short crc) {
+	crc = crcu16(v, crc);
+	return crcu16(v >> 16, crc);
+}
+
+/* ─── Linked list: find, reverse, merge sort ─── */
+
+typedef struct node {
+	struct node *next;
+	short value;
+	short idx;
+} node;
+
+node nodes[LIST_NODES];
+
+int node_cmp(node *a, node *b, int by_idx) {
+	if (!by_idx && a->value != b->value)
+		return a->value - b->value;
+	return a->idx - b->idx;
+}
+
+/* Simon Tatham's iterative merge sort for singly linked lists */
+node *list_sort(node *list, int by_idx) {
+	for (int insize = 1;; insize *= 2) {
+		node *p = list, *tail = 0;
+		int merges = 0;
+		list = 0;
+		while (p) {
+			node *q = p;
+			int psize = 0, qsize = insize;
+			merges++;
+			for (int i = 0; i < insize && q; i++) {
+				psize++;
+				q = q->next;
+			}
+			while (psize > 0 || (qsize > 0 && q)) {
+				node *e;
+				if (psize == 0) {
+					e = q;
+					q = q->next;
+					qsize--;
+				} else if (qsize == 0 || !q || node_cmp(p, q, by_idx) <= 0) {
+					e = p;
+					p = p->next;
+					psize--;
+				} else {
+					e = q;
+					q = q->next;
+					qsize--;
+				}
+				if (tail)
+					tail->next = e;
+				else
+					list = e;
+				tail = e;
+			}
+			p = q;
+		}
+		tail->next = 0;
+		if (merges <= 1)
+			return list;
+	}
+}
+
+node *list_reverse(node *list) {
+	node *prev = 0;
+	while (list) {
+		node *next = list->next;
+		list->next = prev;
+		prev = list;
+		list = next;
+	}
+	return prev;
+}
+
+/* Position of the first node with this value, or -1 */
+int list_find(node *list, int value) {
+	for (int pos = 0; list; list = list->next, pos++)
+		if (list->value == value)
+			return pos;
+	return -1;
+}
+
+unsigned short bench_list(unsigned seed, unsigned short crc) {
+	node *list = 0;
+	for (int i = LIST_NODES - 1; i >= 0; i--) {
+		seed = seed * 1103515245 + 12345;
+		nodes[i].value = (seed >> 16) & 0xff;
+		nodes[i].idx = i;
+		nodes[i].next = list;
+		list = &nodes[i];
+	}
+
+	for (int v = 0; v < 256; v += 37)
+		crc = crcu16(list_find(list, v), crc);
+	list = list_reverse(list);
+	crc = crcu16(list->idx, crc);
+
+	list = list_sort(list, 0);
+	for (node *n = list; n; n = n->next)
+		crc = crcu16(n->value, crc);
+	list = list_sort(list, 1);
+	for (node *n = list; n; n = n->next)
+		crc = crcu16(n->idx, crc);
+	return crc;
+}
+
+/* ─── Matrix: constant add/multiply, vector and matrix products ─── */
+
+short mat_a[MATRIX_N][MATRIX_N], mat_b[MATRIX_N][MATRIX_N];
+int mat_c[MATRIX_N][MATRIX_N];
+
+unsigned short matrix_sum(unsigned short crc) {
+	int sum = 0;
+	for (int i = 0; i < MATRIX_N; i++)
+		for (int j = 0; j < MATRIX_N; j++)
+			sum += mat_c[i][j];
+	return crcu32(sum, crc);
+}
+
+int bit_extract(int x, int from, int bits) {
+	return (x >> from) & ((1 << bits) - 1);
+}
+
+unsigned short bench_matrix(unsigned seed, unsigned short crc) {
+	int n = MATRIX_N;
+	for (int i = 0; i < n; i++)
+		for (int j = 0; j < n; j++) {
+			seed = seed * 1103515245 + 12345;
+			mat_a[i][j] = (seed >> 16) % 97 - 48;
+			mat_b[i][j] = (seed >> 8) % 61 - 30;
+		}
+
+	for (int i = 0; i < n; i++)
+		for (int j = 0; j < n; j++)
+			mat_a[i][j] += 7;
+
+	for (int i = 0; i < n; i++)
+		for (int j = 0; j < n; j++)
+			mat_c[i][j] = mat_a[i][j] * -13;
+	crc = matrix_sum(crc);
+
+	for (int i = 0; i < n; i++) {
+		int sum = 0;
+		for (int j = 0; j < n; j++)
+			sum += mat_a[i][j] * mat_b[j][0];
+		mat_c[i][0] = sum;
+	}
+	crc = matrix_sum(crc);
+
+	for (int i = 0; i < n; i++)
+		for (int j = 0; j < n; j++) {
+			int sum = 0;
+			for (int k = 0; k < n; k++)
+				sum += mat_a[i][k] * mat_b[k][j];
+			mat_c[i][j] = sum;
+		}
+	crc = matrix_sum(crc);
+
+	for (int i = 0; i < n; i++)
+		for (int j = 0; j < n; j++) {
+			int sum = 0;
+			for (int k = 0; k < n; k++) {
+				int t = mat_a[i][k] * mat_b[k][j];
+				sum += bit_extract(t, 2, 4) * bit_extract(t, 5, 7);
+			}
+			mat_c[i][j] = sum;
+		}
+	return matrix_sum(crc);
+}
+
+/* ─── State machine: classify comma-separated numbers ─── */
+
+enum { S_START, S_INVALID, S_S1, S_INT, S_FLOAT, S_S2, S_EXPONENT, S_SCIENTIFIC, NUM_STATES };
+
+char state_input[STATE_BYTES + 1];
+const char *state_words[] = {
+	"5012", "1234", "-874", "+122", "35.54", ".1234", "-110.700", "+0.64",
+	"5.500e+3", "-.123e-2", "-87e+832", "+0.6e-12", "T0.3e-1F", "-T.T++Tq", "1T3.4e4z", "34.0e-T^",
+};
+
+int is_digit(int c) {
+	return c >= '0' && c <= '9';
+}
+
+int state_transition(const char **in, int *transitions) {
+	const char *s = *in;
+	int state = S_START;
+	for (; *s && state != S_INVALID; s++) {
+		int c = *s;
+		if (c == ',') {
+			s++;
+			break;
+		}
+		switch (state) {
+		case S_START:
+			if (is_digit(c))
+				state = S_INT;
+			else if (c == '+' || c == '-')
+				state = S_S1;
+			else if (c == '.')
+				state = S_FLOAT;
+			else
+				state = S_INVALID;
+			transitions[S_START]++;
+			break;
+		case S_S1:
+			if (is_digit(c))
+				state = S_INT;
+			else if (c == '.')
+				state = S_FLOAT;
+			else
+				state = S_INVALID;
+			transitions[S_S1]++;
+			break;
+		case S_INT:
+			if (c == '.')
+				state = S_FLOAT;
+			else if (!is_digit(c))
+				state = S_INVALID;
+			if (state != S_INT)
+				transitions[S_INT]++;
+			break;
+		case S_FLOAT:
+			if (c == 'E' || c == 'e')
+				state = S_S2;
+			else if (!is_digit(c))
+				state = S_INVALID;
+			if (state != S_FLOAT)
+				transitions[S_FLOAT]++;
+			break;
+		case S_S2:
+			state = c == '+' || c == '-' ? S_EXPONENT : S_INVALID;
+			transitions[S_S2]++;
+			break;
+		case S_EXPONENT:
+			state = is_digit(c) ? S_SCIENTIFIC : S_INVALID;
+			transitions[S_EXPONENT]++;
+			break;
+		case S_SCIENTIFIC:
+			if (!is_digit(c)) {
+				state = S_INVALID;
+				transitions[S_SCIENTIFIC]++;
+			}
+			break;
+		}
+	}
+	*in = s;
+	return state;
+}
+
+unsigned short bench_state(unsigned seed, unsigned short crc) {
+	int finals[NUM_STATES], transitions[NUM_STATES];
+	int len = 0;
+	while (1) {
+		seed = seed * 1103515245 + 12345;
+		const char *w = state_words[(seed >> 16) & 15];
+		int wl = strlen(w);
+		if (len + wl + 1 > STATE_BYTES)
+			break;
+		memcpy(state_input + len, w, wl);
+		len += wl;
+		state_input[len++] = ',';
+	}
+	state_input[len] = 0;
+
+	for (int pass = 0; pass < 2; pass++) {
+		memset(finals, 0, sizeof(finals));
+		memset(transitions, 0, sizeof(transitions));
+		const char *p = state_input;
+		while (*p)
+			finals[state_transition(&p, transitions)]++;
+		for (int i = 0; i < NUM_STATES; i++) {
+			crc = crcu32(finals[i], crc);
+			crc = crcu32(transitions[i], crc);
+		}
+		/* Second pass: corrupt every seventh byte, as CoreMark does */
+		for (int i = 0; i < len; i += 7)
+			state_input[i] ^= 1;
+	}
+	return crc;
+}
+
+int main(void) {
+	unsigned short first = 0;
+	for (int it = 0; it < ITERATIONS; it++) {
+		unsigned short crc = 0;
+		crc = bench_list(0x3415, crc);
+		crc = bench_matrix(0x66, crc);
+		crc = bench_state(0xB0B, crc);
+		if (it == 0)
+			first = crc;
+		else if (crc != first)
+			return 2;
+	}
+	printf("coremark: %d iterations, crc 0x%04x\n", ITERATIONS, first);
+	return first != EXPECTED_CRC;
+}
+`
+
+// kernelSortSource is the sort kernel
+const kernelSortSource = `/* Sorting: quicksort and heapsort of the same pseudo-random array.
+   Both results must be ordered, agree element for element, and keep
+   the input's sum and xor. */
+#include <stdio.h>
+#include <string.h>
+
+#define N 200
+
+int input[N], qs[N], hs[N];
+
+void swap(int *a, int *b) {
+	int t = *a;
+	*a = *b;
+	*b = t;
+}
+
+void quicksort(int *a, int lo, int hi) {
+	while (hi - lo > 8) {
+		int mid = lo + (hi - lo) / 2;
+		if (a[mid] < a[lo])
+			swap(&a[mid], &a[lo]);
+		if (a[hi] < a[lo])
+			swap(&a[hi], &a[lo]);
+		if (a[hi] < a[mid])
+			swap(&a[hi], &a[mid]);
+		int pivot = a[mid], i = lo, j = hi;
+		while (i <= j) {
+			while (a[i] < pivot)
+				i++;
+			while (a[j] > pivot)
+				j--;
+			if (i <= j) {
+				swap(&a[i], &a[j]);
+				i++;
+				j--;
+			}
+		}
+		/* Recurse into the smaller half, loop on the larger */
+		if (j - lo < hi - i) {
+			quicksort(a, lo, j);
+			lo = i;
+		} else {
+			quicksort(a, i, hi);
+			hi = j;
+		}
+	}
+	for (int i = lo + 1; i <= hi; i++) {
+		int v = a[i], j = i - 1;
+		while (j >= lo && a[j] > v) {
+			a[j + 1] = a[j];
+			j--;
+		}
+		a[j + 1] = v;
+	}
+}
+
+void sift_down(int *a, int root, int n) {
+	while (2 * root + 1 < n) {
+		int child = 2 * root + 1;
+		if (child + 1 < n && a[child] < a[child + 1])
+			child++;
+		if (a[root] >= a[child])
+			return;
+		swap(&a[root], &a[child]);
+		root = child;
+	}
+}
+
+void heapsort(int *a, int n) {
+	for (int i = n / 2 - 1; i >= 0; i--)
+		sift_down(a, i, n);
+	for (int end = n - 1; end > 0; end--) {
+		swap(&a[0], &a[end]);
+		sift_down(a, 0, end);
+	}
+}
+
+int main(void) {
+	unsigned seed = 12345;
+	int errors = 0;
+	for (int it = 0; it < ITERATIONS; it++) {
+		int sum = 0, xor = 0;
+		for (int i = 0; i < N; i++) {
+			seed = seed * 1664525 + 1013904223;
+			input[i] = (int)seed >> 12;
+			sum += input[i];
+			xor ^= input[i];
+		}
+		memcpy(qs, input, sizeof(input));
+		memcpy(hs, input, sizeof(input));
+		quicksort(qs, 0, N - 1);
+		heapsort(hs, N);
+
+		int qsum = 0, qxor = 0;
+		for (int i = 0; i < N; i++) {
+			if (i > 0 && qs[i - 1] > qs[i])
+				errors++;
+			if (qs[i] != hs[i])
+				errors++;
+			qsum += qs[i];
+			qxor ^= qs[i];
+		}
+		errors += qsum != sum || qxor != xor;
+	}
+	printf("sort: %d x %d elements, %d errors\n", ITERATIONS, N, errors);
+	return errors != 0;
+}
+`
+
+// kernelHashSource is the hash kernel
+const kernelHashSource = `/* Hashing: an open-addressing table with FNV-1a string keys and a
+   multiplicative integer hash. Every inserted key must be found with
+   its value and every other key must miss. */
+#include <stdio.h>
+#include <string.h>
+
+#define SLOTS 256
+#define KEYS 100
+
+typedef struct {
+	unsigned key;
+	int value;
+	int used;
+} slot;
+
+slot table[SLOTS];
+
+unsigned hash_int(unsigned x) {
+	x ^= x >> 16;
+	x *= 0x45d9f3b;
+	x ^= x >> 16;
+	return x;
+}
+
+unsigned fnv1a(const char *s, int n) {
+	unsigned h = 2166136261u;
+	for (int i = 0; i < n; i++) {
+		h ^= (unsigned char)s[i];
+		h *= 16777619;
+	}
+	return h;
+}
+
+void insert(unsigned key, int value) {
+	unsigned i = hash_int(key) & (SLOTS - 1);
+	while (table[i].used && table[i].key != key)
+		i = (i + 1) & (SLOTS - 1);
+	table[i].key = key;
+	table[i].value = value;
+	table[i].used = 1;
+}
+
+int lookup(unsigned key, int *value) {
+	unsigned i = hash_int(key) & (SLOTS - 1);
+	while (table[i].used) {
+		if (table[i].key == key) {
+			*value = table[i].value;
+			return 1;
+		}
+		i = (i + 1) & (SLOTS - 1);
+	}
+	return 0;
+}
+
+/* Key i: the FNV-1a hash of "key-<i>"; even i are inserted */
+unsigned make_key(int i) {
+	char buf[16];
+	int n = sprintf(buf, "key-%d", i);
+	return fnv1a(buf, n);
+}
+
+int main(void) {
+	int errors = 0;
+	/* Published FNV-1a test vectors */
+	errors += fnv1a("", 0) != 0x811c9dc5u;
+	errors += fnv1a("a", 1) != 0xe40c292cu;
+	errors += fnv1a("foobar", 6) != 0xbf9cf968u;
+
+	for (int it = 0; it < ITERATIONS; it++) {
+		memset(table, 0, sizeof(table));
+		for (int i = 0; i < 2 * KEYS; i += 2)
+			insert(make_key(i), i * 3 + it);
+		int found = 0;
+		for (int i = 0; i < 2 * KEYS; i++) {
+			int v;
+			if (lookup(make_key(i), &v)) {
+				found++;
+				errors += i % 2 != 0 || v != i * 3 + it;
+			} else {
+				errors += i % 2 == 0;
+			}
+		}
+		errors += found != KEYS;
+	}
+	printf("hash: %d x %d keys, %d errors\n", ITERATIONS, KEYS, errors);
+	return errors != 0;
+}
+`
+
+// kernelCrc32Source is the crc32 kernel
+const kernelCrc32Source = `/* CRC-32 (IEEE 802.3), Embench-style: a table-driven CRC over a
+   pseudo-random buffer, checked against the bitwise definition and
+   the standard check value of "123456789". */
+#include <stdio.h>
+#include <string.h>
+
+#define BYTES 512
+
+unsigned table[256];
+unsigned char buf[BYTES];
+
+unsigned crc32_bitwise(const unsigned char *p, int n) {
+	unsigned crc = 0xFFFFFFFF;
+	for (int i = 0; i < n; i++) {
+		crc ^= p[i];
+		for (int k = 0; k < 8; k++)
+			crc = crc & 1 ? (crc >> 1) ^ 0xEDB88320 : crc >> 1;
+	}
+	return ~crc;
+}
+
+unsigned crc32_table(const unsigned char *p, int n) {
+	unsigned crc = 0xFFFFFFFF;
+	for (int i = 0; i < n; i++)
+		crc = table[(crc ^ p[i]) & 0xFF] ^ (crc >> 8);
+	return ~crc;
+}
+
+int main(void) {
+	int errors = 0;
+	for (unsigned i = 0; i < 256; i++) {
+		unsigned c = i;
+		for (int k = 0; k < 8; k++)
+			c = c & 1 ? (c >> 1) ^ 0xEDB88320 : c >> 1;
+		table[i] = c;
+	}
+	errors += crc32_table((const unsigned char *)"123456789", 9) != 0xCBF43926;
+
+	unsigned seed = 1;
+	for (int i = 0; i < BYTES; i++) {
+		seed = seed * 1103515245 + 12345;
+		buf[i] = seed >> 16;
+	}
+	unsigned crc = 0;
+	for (int it = 0; it < ITERATIONS; it++) {
+		crc = crc32_table(buf, BYTES);
+		buf[it % BYTES] ^= crc; /* Each iteration hashes different data */
+	}
+	/* The last buffer through the slow, obviously correct definition */
+	buf[(ITERATIONS - 1) % BYTES] ^= crc;
+	errors += crc32_bitwise(buf, BYTES) != crc;
+
+	printf("crc32: %d x %d bytes, crc 0x%08x, %d errors\n", ITERATIONS, BYTES, crc, errors);
+	return errors != 0;
+}
+`
+
+// kernelChaseSource is the pointer-chase kernel
+const kernelChaseSource = `/* Pointer chasing: a single random cycle through nodes one cache line
+   apart, larger than the L1D. The chase must return to the start after
+   exactly NODES steps. */
+#include <stdio.h>
+
+#define NODES 2048
+
+typedef struct chase_node {
+	struct chase_node *next;
+	int pad[15]; /* One node per 64-byte line */
+} chase_node;
+
+chase_node nodes[NODES];
+int order[NODES];
+
+int main(void) {
+	unsigned seed = 7;
+	int errors = 0;
+
+	/* Sattolo's algorithm: a random permutation with a single cycle */
+	for (int i = 0; i < NODES; i++)
+		order[i] = i;
+	for (int i = NODES - 1; i > 0; i--) {
+		seed = seed * 1664525 + 1013904223;
+		int j = (seed >> 8) % i;
+		int t = order[i];
+		order[i] = order[j];
+		order[j] = t;
+	}
+	for (int i = 0; i < NODES; i++)
+		nodes[i].next = &nodes[order[i]];
+
+	chase_node *p = &nodes[0];
+	for (int it = 0; it < ITERATIONS; it++) {
+		for (int step = 1; step <= NODES; step++) {
+			p = p->next;
+			if (p == &nodes[0] && step != NODES)
+				errors++;
+		}
+		errors += p != &nodes[0];
+	}
+	printf("pointer-chase: %d x %d nodes, %d errors\n", ITERATIONS, NODES, errors);
+	return errors != 0;
+}
+`
+
+// kernelSpmvSource is the spmv kernel
+const kernelSpmvSource = `/* Sparse matrix-vector product in CSR form, checked against the same
+   matrix walked as coordinate triplets in a different order. */
+#include <stdio.h>
+
+#define ROWS 120
+#define PER_ROW 6
+#define NNZ (ROWS * PER_ROW)
+
+int row_start[ROWS + 1], col[NNZ], val[NNZ];
+int coo_row[NNZ];
+int x[ROWS], y_csr[ROWS], y_coo[ROWS];
+
+void spmv_csr(void) {
+	for (int r = 0; r < ROWS; r++) {
+		int sum = 0;
+		for (int k = row_start[r]; k < row_start[r + 1]; k++)
+			sum += val[k] * x[col[k]];
+		y_csr[r] = sum;
+	}
+}
+
+void spmv_coo_reversed(void) {
+	for (int r = 0; r < ROWS; r++)
+		y_coo[r] = 0;
+	for (int k = NNZ - 1; k >= 0; k--)
+		y_coo[coo_row[k]] += val[k] * x[col[k]];
+}
+
+int main(void) {
+	unsigned seed = 99;
+	int errors = 0;
+
+	/* Banded structure plus scattered entries, like a mesh with long links */
+	for (int r = 0; r < ROWS; r++) {
+		row_start[r] = r * PER_ROW;
+		for (int k = 0; k < PER_ROW; k++) {
+			int i = r * PER_ROW + k;
+			seed = seed * 1664525 + 1013904223;
+			if (k < 3)
+				col[i] = (r + k + ROWS - 1) % ROWS;
+			else
+				col[i] = (seed >> 10) % ROWS;
+			val[i] = (int)(seed >> 20) % 19 - 9;
+			coo_row[i] = r;
+		}
+	}
+	row_start[ROWS] = NNZ;
+	for (int i = 0; i < ROWS; i++)
+		x[i] = i % 13 - 6;
+
+	int checksum = 0;
+	for (int it = 0; it < ITERATIONS; it++) {
+		spmv_csr();
+		spmv_coo_reversed();
+		for (int r = 0; r < ROWS; r++) {
+			errors += y_csr[r] != y_coo[r];
+			checksum += y_csr[r];
+		}
+		/* Feed the result back so iterations differ */
+		for (int r = 0; r < ROWS; r++)
+			x[r] = y_csr[r] % 7;
+	}
+	printf("spmv: %d x %d nonzeros, checksum %d, %d errors\n", ITERATIONS, NNZ, checksum, errors);
+	return errors != 0;
+}
+`
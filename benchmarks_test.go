@@ -0,0 +1,105 @@
+package suprax32
+
+import (
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Benchmark Suite - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A benchmark number is only worth reporting if the program computed the right answer, so
+// the kernels check themselves and these tests check that the checks pass on the real core
+// and that the runner reports failures instead of hiding them.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. KERNEL TESTS
+//    Every standard kernel passes its self-check
+//
+// 2. RUNNER TESTS
+//    Failing and non-terminating programs, table format
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. KERNEL TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSuite_KernelsPass(t *testing.T) {
+	// WHAT: Each kernel builds, runs to SysExit and passes its self-check (one iteration)
+	// WHY: A kernel that fails on the core points at the compiler, the runtime or the core
+	// CATEGORY: [INTEGRATION]
+
+	if testing.Short() {
+		t.Skip("runs every kernel on the cycle-level core")
+	}
+	for _, k := range StandardKernels() {
+		t.Run(k.Name, func(t *testing.T) {
+			r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000})
+			if r.Err != nil || !r.Passed {
+				t.Fatalf("err %v, status %d, output %q", r.Err, r.Status, r.Output)
+			}
+			if !strings.HasPrefix(r.Output, k.Name+":") {
+				t.Errorf("output %q does not start with the kernel name", r.Output)
+			}
+			if r.Instructions == 0 || r.IPC <= 0 || r.Cycles < r.Instructions/CommitWidth {
+				t.Errorf("implausible counts: %d cycles, %d instructions, IPC %.3f", r.Cycles, r.Instructions, r.IPC)
+			}
+		})
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. RUNNER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSuite_ReportsFailures(t *testing.T) {
+	// WHAT: A non-zero exit status, a build error and a program that never exits all fail
+	// WHY: The runner must never turn a broken kernel into a PASS row
+	// CATEGORY: [UNIT]
+
+	failing := RunKernel(Kernel{Name: "failing", Source: "int main() { return ITERATIONS + 2; }", Iterations: 1}, SuiteOptions{})
+	if failing.Passed || failing.Err != nil || failing.Status != 3 {
+		t.Errorf("failing kernel: passed %v, err %v, status %d", failing.Passed, failing.Err, failing.Status)
+	}
+
+	broken := RunKernel(Kernel{Name: "broken", Source: "int main() { return undeclared; }"}, SuiteOptions{})
+	if broken.Passed || broken.Err == nil {
+		t.Errorf("broken kernel: passed %v, err %v", broken.Passed, broken.Err)
+	}
+
+	spinning := RunKernel(Kernel{Name: "spinning", Source: "int main() { for (;;); }"}, SuiteOptions{MaxCycles: 20_000})
+	if spinning.Passed || spinning.Err == nil || spinning.Cycles != 20_000 {
+		t.Errorf("spinning kernel: passed %v, err %v, %d cycles", spinning.Passed, spinning.Err, spinning.Cycles)
+	}
+
+	table := FormatSuite([]KernelResult{failing, broken, spinning})
+	if strings.Contains(table, "PASS") || strings.Count(table, "FAIL") != 3 {
+		t.Errorf("table should show three failures:\n%s", table)
+	}
+}
+
+func TestSuite_FormatGeomean(t *testing.T) {
+	// WHAT: One row per result and a geometric mean over the IPCs
+	// CATEGORY: [UNIT]
+
+	table := FormatSuite([]KernelResult{
+		{Name: "a", Iterations: 1, Cycles: 100, Instructions: 100, IPC: 1, Passed: true},
+		{Name: "b", Iterations: 1, Cycles: 100, Instructions: 400, IPC: 4, Passed: true},
+	})
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, two rows and geomean:\n%s", table)
+	}
+	if !strings.HasPrefix(lines[3], "geomean") || !strings.Contains(lines[3], "2.000") {
+		t.Errorf("geomean row %q, expected 2.000", lines[3])
+	}
+}
@@ -0,0 +1,135 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// OFFLINE BRANCH DIRECTION PREDICTOR EVALUATION
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY OFFLINE:
+//
+// Choosing a direction predictor (4-bit counters here, TAGE or a neural
+// design in proto/tage) is a question about one stream: which way each
+// conditional branch went, in program order. Capture it once from a real
+// run, then replay it through every candidate - each one sees exactly the
+// same branches, which no two Core runs would guarantee.
+//
+// THE PIPELINE (same shape as addrtrace.go):
+//
+//	Core.SetBranchTrace(true)          every committed conditional branch
+//	ReplayBranchTrace(name, p, tr)     for each branch: PredictDirection,
+//	                                   then RecordBranch with the outcome
+//	FormatBranchTrace(results)         one table for all predictors
+//
+// Committed conditional branches only: JAL/JALR are unconditional (their
+// targets are indirect.go's business) and wrong-path branches never
+// commit. Each branch is predicted and trained before the next, so the
+// replayed accuracy is what the predictor could do with perfect training
+// timing - an upper bound on what the core sees, and the same bound for
+// every candidate.
+//
+// MINECRAFT ANALOGY: Filming one run through the parkour course and
+//
+//	letting every player call the jumps from the same footage.
+
+// BranchRecord is one committed conditional branch
+type BranchRecord struct {
+	PC    uint32
+	Taken bool
+}
+
+// DirectionPredictor predicts conditional branch directions from the PC
+type DirectionPredictor interface {
+	PredictDirection(pc uint32) (taken bool)
+	RecordBranch(pc uint32, taken bool)
+}
+
+// PredictDirection makes the core's predictor a DirectionPredictor
+func (bp *BranchPredictor) PredictDirection(pc uint32) bool {
+	taken, _ := bp.Predict(pc)
+	return taken
+}
+
+// RecordBranch trains the core's predictor with a resolved branch
+func (bp *BranchPredictor) RecordBranch(pc uint32, taken bool) {
+	bp.Update(pc, taken)
+}
+
+// SetBranchTrace starts (or stops) recording committed conditional branches
+//
+// Starting discards any earlier recording.
+func (c *Core) SetBranchTrace(on bool) {
+	c.traceBranches = on
+	if on {
+		c.branchTrace = nil
+	}
+}
+
+// BranchTrace returns the branches recorded since SetBranchTrace(true)
+func (c *Core) BranchTrace() []BranchRecord {
+	return c.branchTrace
+}
+
+// traceBranch records one committed conditional branch
+func (c *Core) traceBranch(e *WindowEntry) {
+	if c.traceBranches {
+		c.branchTrace = append(c.branchTrace, BranchRecord{PC: e.PC, Taken: e.BranchTaken})
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// REPLAY
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// BranchTraceResult is one predictor's score on a trace
+type BranchTraceResult struct {
+	Name        string  `json:"name"`
+	Branches    uint64  `json:"branches"`
+	Taken       uint64  `json:"taken"`
+	Mispredicts uint64  `json:"mispredicts"`
+	Accuracy    float64 `json:"accuracy"`
+	PerKilo     float64 `json:"per_kilo"` // Mispredicts per 1000 branches
+}
+
+// ReplayBranchTrace runs every branch of a trace through one predictor
+//
+// ALGORITHM (per branch, in trace order):
+//
+//	STEP 1: PredictDirection(pc) and score it against the outcome
+//	STEP 2: RecordBranch(pc, taken) trains the predictor
+func ReplayBranchTrace(name string, p DirectionPredictor, trace []BranchRecord) BranchTraceResult {
+	r := BranchTraceResult{Name: name}
+	for _, br := range trace {
+		// STEP 1
+		r.Branches++
+		if br.Taken {
+			r.Taken++
+		}
+		if p.PredictDirection(br.PC) != br.Taken {
+			r.Mispredicts++
+		}
+
+		// STEP 2
+		p.RecordBranch(br.PC, br.Taken)
+	}
+
+	if r.Branches > 0 {
+		r.Accuracy = 1 - ratio(r.Mispredicts, r.Branches)
+	}
+	r.PerKilo = ratio(r.Mispredicts*1000, r.Branches)
+	return r
+}
+
+// FormatBranchTrace renders replay results as a table
+func FormatBranchTrace(results []BranchTraceResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-20s %10s %9s %11s %9s %9s\n", "PREDICTOR", "BRANCHES", "TAKEN", "MISPREDICTS", "ACCURACY", "PER-1K")
+	for _, r := range results {
+		fmt.Fprintf(&b, "%-20s %10d %8.1f%% %11d %9.4f %9.1f\n", r.Name, r.Branches,
+			100*ratio(r.Taken, r.Branches), r.Mispredicts, r.Accuracy, r.PerKilo)
+	}
+	return b.String()
+}
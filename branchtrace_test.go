@@ -0,0 +1,272 @@
+package suprax32
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"suprax/proto/tage"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Offline Branch Direction Predictor Evaluation - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The harness exists to compare the core's 4-bit counters against the TAGE and neural
+// reference models in proto/tage on identical streams. The replay tests use synthetic
+// traces whose mispredictions are known by construction; the capture test checks a
+// recorded trace against the program that produced it; the comparison runs every
+// standard kernel's branches through all four predictors.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. REPLAY TESTS
+//    Scoring, any DirectionPredictor, table format
+//
+// 2. CAPTURE TESTS
+//    Committed conditional branches recorded from a Core run
+//
+// 3. COMPARISON TESTS
+//    Core BranchPredictor vs TAGE vs perceptron vs hashed perceptron on kernel traces
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// protoDirection adapts a proto/tage predictor (context 0) to DirectionPredictor
+type protoDirection struct {
+	p    tage.DirectionPredictor
+	last bool
+}
+
+func (d *protoDirection) PredictDirection(pc uint32) bool {
+	d.last, _ = d.p.Predict(uint64(pc), 0)
+	return d.last
+}
+
+func (d *protoDirection) RecordBranch(pc uint32, taken bool) {
+	if taken == d.last {
+		d.p.Update(uint64(pc), 0, taken)
+	} else {
+		d.p.OnMispredict(uint64(pc), 0, taken)
+	}
+}
+
+// directionCandidates returns the predictors under comparison, freshly reset
+func directionCandidates() []struct {
+	name string
+	p    DirectionPredictor
+} {
+	return []struct {
+		name string
+		p    DirectionPredictor
+	}{
+		{"core (4-bit)", NewBranchPredictor()},
+		{"tage", &protoDirection{p: tage.NewTAGEPredictor()}},
+		{"perceptron", &protoDirection{p: tage.NewPerceptronPredictor(tage.DefaultPerceptronConfig())}},
+		{"hashed perceptron", &protoDirection{p: tage.NewHashedPerceptronPredictor(tage.DefaultHashedPerceptronConfig())}},
+	}
+}
+
+// loopBranchTrace is one loop branch taken `trip-1` times, then not, `trips` times over
+func loopBranchTrace(trips, trip int) []BranchRecord {
+	var trace []BranchRecord
+	for i := 0; i < trips; i++ {
+		for j := 1; j <= trip; j++ {
+			trace = append(trace, BranchRecord{PC: 0x1000, Taken: j < trip})
+		}
+	}
+	return trace
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. REPLAY TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestBranchTrace_ReplayScoresCounters(t *testing.T) {
+	// WHAT: A loop of trip 4 through the 4-bit counters mispredicts exactly once per trip
+	//       (the exit), and the totals add up
+	// WHY: Hysteresis keeps the counter taken across an exit; the count is known exactly
+	// HARDWARE: BranchPredictor 4-bit counters (INNOVATION #29)
+	// CATEGORY: [UNIT]
+
+	const trips = 100
+	trace := loopBranchTrace(trips, 4)
+	r := ReplayBranchTrace("core", NewBranchPredictor(), trace)
+
+	if r.Branches != uint64(len(trace)) || r.Taken != 3*trips {
+		t.Errorf("%d branches, %d taken; expected %d and %d", r.Branches, r.Taken, len(trace), 3*trips)
+	}
+	if r.Mispredicts != trips {
+		t.Errorf("%d mispredicts, expected one per trip (%d)", r.Mispredicts, trips)
+	}
+	if r.Accuracy != 0.75 || r.PerKilo != 250 {
+		t.Errorf("accuracy %.3f, per-1K %.1f; expected 0.750 and 250", r.Accuracy, r.PerKilo)
+	}
+}
+
+func TestBranchTrace_ReplayAnyPredictor(t *testing.T) {
+	// WHAT: The proto/tage predictors replay through the adapter; TAGE learns the trip-4
+	//       loop exactly and two neighbouring branches with opposite directions separately
+	// WHY: The comparison is only meaningful if the models tell raw SUPRAX PCs apart:
+	//      they once hashed only PC bits 12 and up, which every branch of a small
+	//      program shares
+	// HARDWARE: proto/tage PC hash (hashPC)
+	// CATEGORY: [UNIT]
+
+	loop := loopBranchTrace(400, 4)
+	all := ReplayBranchTrace("tage", &protoDirection{p: tage.NewTAGEPredictor()}, loop)
+	firstHalf := ReplayBranchTrace("tage", &protoDirection{p: tage.NewTAGEPredictor()}, loop[:len(loop)/2])
+	if all.Mispredicts != firstHalf.Mispredicts {
+		t.Errorf("TAGE kept mispredicting the loop: %d mispredicts in the first half, %d overall",
+			firstHalf.Mispredicts, all.Mispredicts)
+	}
+
+	var neighbours []BranchRecord
+	for i := 0; i < 200; i++ {
+		neighbours = append(neighbours, BranchRecord{PC: 0x2000, Taken: true}, BranchRecord{PC: 0x2004, Taken: false})
+	}
+	for _, c := range directionCandidates() {
+		r := ReplayBranchTrace(c.name, c.p, neighbours)
+		if r.Mispredicts > 10 {
+			t.Errorf("%s: %d mispredicts on two fixed-direction branches", c.name, r.Mispredicts)
+		}
+	}
+}
+
+func TestBranchTrace_FormatTable(t *testing.T) {
+	// WHAT: The table has a header and one row per predictor, in order
+	// WHY: The comparison is read by people
+	// CATEGORY: [UNIT]
+
+	trace := loopBranchTrace(10, 4)
+	out := FormatBranchTrace([]BranchTraceResult{
+		ReplayBranchTrace("first", NewBranchPredictor(), trace),
+		ReplayBranchTrace("second", NewBranchPredictor(), trace),
+	})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "PREDICTOR") ||
+		!strings.HasPrefix(lines[1], "first") || !strings.HasPrefix(lines[2], "second") {
+		t.Errorf("table:\n%s", out)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. CAPTURE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestBranchTrace_CaptureFromCore(t *testing.T) {
+	// WHAT: A recorded trace holds a branch once per walk iteration, no more
+	//       records than resolved branches, and nothing unless asked
+	// WHY: Core runs are where realistic traces come from
+	// HARDWARE: traceBranch at commit
+	// CATEGORY: [INTEGRATION]
+
+	img, err := BuildC("walk.c", strideWalkSource)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	if runStrideWalk(t, PredictorNone).BranchTrace() != nil {
+		t.Error("branches recorded without SetBranchTrace")
+	}
+
+	core := NewCore(1 << 20)
+	core.SetConsole(&bytes.Buffer{})
+	core.SetBranchTrace(true)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	core.Run(5_000_000)
+	trace := core.BranchTrace()
+	if resolved := core.Snapshot().Branch.Resolved; uint64(len(trace)) > resolved {
+		t.Errorf("recorded %d conditional branches, only %d branches resolved", len(trace), resolved)
+	}
+	perPC := map[uint32]int{}
+	for _, br := range trace {
+		perPC[br.PC]++
+	}
+	const iterations = 65536 / 16
+	found := false
+	for _, n := range perPC {
+		found = found || n == iterations || n == iterations+1
+	}
+	if !found {
+		t.Errorf("no branch recorded once per walk iteration (%d) among %d PCs", iterations, len(perPC))
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. COMPARISON TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Every standard kernel (one iteration) is run once on the core with branch tracing on;
+// its trace is replayed through each candidate. The per-kernel tables and the totals are
+// logged - run with -v to read them.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestBranchTrace_CompareDirectionPredictors(t *testing.T) {
+	// WHAT: Each candidate sees every branch of every kernel; TAGE and both neural
+	//       predictors each mispredict less in total than the core's 4-bit counters
+	// WHY: The evidence for (or against) replacing the counters with TAGE
+	// HARDWARE: BranchPredictor vs proto/tage at roughly equal storage
+	// CATEGORY: [INTEGRATION]
+
+	if testing.Short() {
+		t.Skip("runs every kernel on the cycle-level core")
+	}
+
+	totals := map[string]*BranchTraceResult{}
+	var order []string
+	for _, k := range StandardKernels() {
+		var core *Core
+		r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+			c.SetBranchTrace(true)
+			core = c
+		}})
+		if r.Err != nil || !r.Passed {
+			t.Fatalf("%s: err %v, status %d", k.Name, r.Err, r.Status)
+		}
+		trace := core.BranchTrace()
+
+		var results []BranchTraceResult
+		for _, c := range directionCandidates() {
+			res := ReplayBranchTrace(c.name, c.p, trace)
+			if res.Branches != uint64(len(trace)) {
+				t.Errorf("%s/%s: replayed %d of %d branches", k.Name, c.name, res.Branches, len(trace))
+			}
+			results = append(results, res)
+
+			total := totals[c.name]
+			if total == nil {
+				total = &BranchTraceResult{Name: c.name}
+				totals[c.name] = total
+				order = append(order, c.name)
+			}
+			total.Branches += res.Branches
+			total.Taken += res.Taken
+			total.Mispredicts += res.Mispredicts
+		}
+		t.Logf("%s:\n%s", k.Name, FormatBranchTrace(results))
+	}
+
+	var summary []BranchTraceResult
+	for _, name := range order {
+		total := *totals[name]
+		total.Accuracy = 1 - ratio(total.Mispredicts, total.Branches)
+		total.PerKilo = ratio(total.Mispredicts*1000, total.Branches)
+		summary = append(summary, total)
+	}
+	t.Logf("all kernels:\n%s", FormatBranchTrace(summary))
+
+	counters := totals["core (4-bit)"].Mispredicts
+	for _, name := range order[1:] {
+		if totals[name].Mispredicts >= counters {
+			t.Errorf("%s: %d mispredicts, the 4-bit counters have %d", name, totals[name].Mispredicts, counters)
+		}
+	}
+}
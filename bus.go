@@ -0,0 +1,264 @@
+package suprax32
+
+import "fmt"
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// SYSTEM BUS AND MEMORY-MAPPED I/O
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: Every load and store reaches Core.memory through the L1D, so a
+//
+//	program can compute but cannot talk to anything. Devices need
+//	addresses of their own whose reads and writes do something.
+//
+// THE ADDRESS MAP: Physical addresses below len(Core.memory) are RAM.
+//
+//	Devices are mapped above it, each on its own range (MapDevice):
+//
+//	0x0000_0000  RAM (cacheable)
+//	...
+//	0xF000_0000  UART          (UARTBase, see devices.go)
+//	0xF000_1000  Timer         (TimerBase)
+//	0xF000_2000  Block device  (BlockBase)
+//	0xF100_0000  Framebuffer   (FramebufferBase)
+//
+//	The bases are conventions, not wiring: MapDevice accepts any range
+//	that misses RAM and every other device.
+//
+// UNCACHEABLE ACCESSES: A device register is not memory. Reading a UART
+//
+//	consumes a byte; writing one prints it. So a load or store whose
+//	physical address decodes to a device:
+//
+//	STEP 1: Translates as usual (the MMU may map device pages)
+//	STEP 2: Waits until it is the oldest instruction in the window, so
+//	        it is no longer speculative and nothing older can fault
+//	STEP 3: Bypasses the L1D and the LSUs: the bus performs it
+//	STEP 4: Completes after BusLatency cycles, then commits
+//
+//	A wrong-path access never reaches a device, and a mispredicted
+//	branch cannot replay one. Only one access is on the bus at a time.
+//	LR is a plain load; SC always fails (devices keep no reservation).
+//
+// DEVICES: Anything with Read, Write and Tick. Every device ticks once per
+//
+//	core cycle, whether or not it is being accessed, so timers count
+//	and transfers finish in the background.
+//
+// MINECRAFT ANALOGY: Redstone. Most blocks just sit in a chest (RAM), but
+//
+//	a lever or a lamp does something the moment you touch it - and you
+//	had better not touch it "just to see".
+
+// BusLatency is the cycles a device access takes (no cache in between)
+const BusLatency = 10
+
+// Device is a peripheral on the system bus
+//
+// Offsets are relative to the device's base. Registers are 32-bit:
+// narrower accesses reach the register at offset &^ 3 with the value in
+// the low bits, unless the device says otherwise.
+type Device interface {
+	Read(offset uint32, size int) uint32
+	Write(offset uint32, size int, value uint32)
+	Tick()
+}
+
+// busRegion is one device's address range
+type busRegion struct {
+	name string
+	base uint32
+	size uint32
+	dev  Device
+
+	// Statistics
+	reads  uint64
+	writes uint64
+}
+
+// busAccess is the access in flight on the bus
+type busAccess struct {
+	active    bool
+	winID     int
+	data      uint32 // Load result (or SC failure flag)
+	cyclesRem int
+}
+
+// Bus decodes physical addresses to devices
+type Bus struct {
+	regions []*busRegion
+	access  busAccess
+
+	// Statistics
+	busyCycles uint64 // Cycles an access was in flight
+}
+
+// NewBus creates a bus with no devices
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Map adds a device on [base, base+size)
+func (b *Bus) Map(name string, base, size uint32, dev Device) error {
+	if size == 0 || base+size-1 < base {
+		return fmt.Errorf("device %s: bad range 0x%x+0x%x", name, base, size)
+	}
+	for _, r := range b.regions {
+		if base < r.base+r.size && r.base < base+size {
+			return fmt.Errorf("device %s at 0x%x overlaps %s at 0x%x", name, base, r.name, r.base)
+		}
+		if r.name == name {
+			return fmt.Errorf("device %s mapped twice", name)
+		}
+	}
+	b.regions = append(b.regions, &busRegion{name: name, base: base, size: size, dev: dev})
+	return nil
+}
+
+// decode returns the device region holding a physical address, or nil
+func (b *Bus) decode(addr uint32) *busRegion {
+	for _, r := range b.regions {
+		if addr-r.base < r.size {
+			return r
+		}
+	}
+	return nil
+}
+
+// busy reports whether an access is in flight
+func (b *Bus) busy() bool {
+	return b.access.active
+}
+
+// load starts a device read (STEP 3)
+func (b *Bus) load(r *busRegion, winID int, addr uint32, size uint8, signed bool) {
+	data := r.dev.Read(addr-r.base, int(size))
+	if size < 4 {
+		shift := 32 - 8*uint(size)
+		data <<= shift
+		if signed {
+			data = uint32(int32(data) >> shift)
+		} else {
+			data >>= shift
+		}
+	}
+	r.reads++
+	b.access = busAccess{active: true, winID: winID, data: data, cyclesRem: BusLatency}
+}
+
+// store starts a device write (STEP 3)
+func (b *Bus) store(r *busRegion, winID int, addr uint32, size uint8, data uint32) {
+	if size < 4 {
+		data &= 1<<(8*uint(size)) - 1
+	}
+	r.dev.Write(addr-r.base, int(size), data)
+	r.writes++
+	b.access = busAccess{active: true, winID: winID, cyclesRem: BusLatency}
+}
+
+// fail occupies the bus for an access that does nothing but return data
+// (SC to a device)
+func (b *Bus) fail(winID int, data uint32) {
+	b.access = busAccess{active: true, winID: winID, data: data, cyclesRem: BusLatency}
+}
+
+// tick advances every device and the access in flight
+func (b *Bus) tick() {
+	for _, r := range b.regions {
+		r.dev.Tick()
+	}
+	if b.access.active && b.access.cyclesRem > 0 {
+		b.access.cyclesRem--
+		b.busyCycles++
+	}
+}
+
+// result returns a finished access (STEP 4), once
+func (b *Bus) result() (winID int, data uint32, ok bool) {
+	if !b.access.active || b.access.cyclesRem > 0 {
+		return 0, 0, false
+	}
+	b.access.active = false
+	return b.access.winID, b.access.data, true
+}
+
+// flush drops the access in flight (its instruction was squashed)
+func (b *Bus) flush() {
+	b.access.active = false
+}
+
+// debugUnit is the widest aligned access (4, 2 or 1 bytes) at addr that
+// fits in n bytes
+func debugUnit(addr uint32, n int) int {
+	switch {
+	case addr&3 == 0 && n >= 4:
+		return 4
+	case addr&1 == 0 && n >= 2:
+		return 2
+	}
+	return 1
+}
+
+// debugRegion returns the device holding all of [addr, addr+n), or nil
+func (b *Bus) debugRegion(addr uint32, n int) *busRegion {
+	r := b.decode(addr)
+	if r == nil || uint64(addr-r.base)+uint64(n) > uint64(r.size) {
+		return nil
+	}
+	return r
+}
+
+// debugRead reads device bytes for a debugger (ok = false unless one
+// device holds the whole range)
+//
+// The range is read in the widest aligned units, as the program's own
+// lw/lh/lb would, and with the same side effects: reading a UART's data
+// register consumes a byte. Nothing is counted in the statistics and the
+// access in flight is left alone.
+func (b *Bus) debugRead(addr uint32, n int) ([]byte, bool) {
+	r := b.debugRegion(addr, n)
+	if r == nil {
+		return nil, false
+	}
+	data := make([]byte, 0, n)
+	for n > 0 {
+		size := debugUnit(addr, n)
+		v := r.dev.Read(addr-r.base, size)
+		for i := 0; i < size; i++ {
+			data = append(data, byte(v>>(8*i)))
+		}
+		addr += uint32(size)
+		n -= size
+	}
+	return data, true
+}
+
+// debugWrite writes device bytes for a debugger (see debugRead)
+func (b *Bus) debugWrite(addr uint32, data []byte) bool {
+	r := b.debugRegion(addr, len(data))
+	if r == nil {
+		return false
+	}
+	for len(data) > 0 {
+		size := debugUnit(addr, len(data))
+		var v uint32
+		for i := 0; i < size; i++ {
+			v |= uint32(data[i]) << (8 * i)
+		}
+		r.dev.Write(addr-r.base, size, v)
+		addr += uint32(size)
+		data = data[size:]
+	}
+	return true
+}
+
+// MapDevice puts a device on the bus at [base, base+size)
+//
+// The range must not overlap RAM or another device. Accesses to it are
+// uncached and non-speculative (see above).
+func (c *Core) MapDevice(name string, base, size uint32, dev Device) error {
+	if uint64(base) < uint64(len(c.memory)) {
+		return fmt.Errorf("device %s at 0x%x overlaps RAM (0x%x bytes)", name, base, len(c.memory))
+	}
+	return c.bus.Map(name, base, size, dev)
+}
@@ -0,0 +1,460 @@
+package suprax32
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX System Bus and Devices - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A device is observed from both sides: the program pokes its registers with ordinary loads
+// and stores, and the test checks what the host saw (bytes on a stream, a file, a PNG). The
+// one property unique to MMIO - that a device is touched exactly as often as the program
+// says, never by a wrong path - is tested with a register whose read has a side effect.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. BUS TESTS
+//    Address map checks, non-speculative access, statistics
+//
+// 2. DEVICE TESTS
+//    UART, timer, block device and framebuffer driven by programs
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// mapDevice maps a device for a test, failing it on error
+func mapDevice(t *testing.T, c *Core, name string, base, size uint32, dev Device) {
+	t.Helper()
+	if err := c.MapDevice(name, base, size, dev); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. BUS TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestBus_MapRejectsOverlaps(t *testing.T) {
+	// WHAT: MapDevice refuses ranges on RAM, on another device, empty or wrapping ranges,
+	//       and a name used twice
+	// WHY: An overlapping map would silently send accesses to the wrong place
+	// CATEGORY: [UNIT]
+
+	c := NewCore(1 << 20)
+	mapDevice(t, c, "timer", TimerBase, DeviceRegionSize, NewTimer())
+
+	bad := []struct {
+		name       string
+		base, size uint32
+	}{
+		{"ram", 0x80000, DeviceRegionSize},
+		{"overlap", TimerBase + 0x800, DeviceRegionSize},
+		{"empty", 0xF0100000, 0},
+		{"wrap", 0xFFFFF000, 0x2000},
+		{"timer", 0xF0200000, DeviceRegionSize},
+	}
+	for _, b := range bad {
+		if err := c.MapDevice(b.name, b.base, b.size, NewTimer()); err == nil {
+			t.Errorf("%s at 0x%x+0x%x mapped", b.name, b.base, b.size)
+		}
+	}
+	mapDevice(t, c, "adjacent", TimerBase+DeviceRegionSize, DeviceRegionSize, NewTimer())
+}
+
+func TestBus_WrongPathNeverReadsDevice(t *testing.T) {
+	// WHAT: A UART read that only appears on a mispredicted path consumes no input byte
+	// WHY: Reading DATA pops the receive buffer - a speculative read would lose input
+	// HARDWARE: Device loads wait until they are the window head
+	// CATEGORY: [INTEGRATION]
+
+	uart := NewUART(strings.NewReader("AB"), nil)
+	core, _ := runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(UARTBase)+`
+wait:
+	lw    t2, 4(t1)           # STATUS
+	andi  t2, t2, 1
+	beqz  t2, wait
+	la    t2, slow
+	lw    t3, 0(t2)           # Misses the L1D: the branch resolves late
+	beqz  t3, steal           # Never taken
+	lw    a0, 0(t1)           # Must be 'A'
+	system 0
+steal:
+	lw    a1, 0(t1)           # Wrong path only
+	j     steal
+
+	.data
+	.align 64
+slow:
+	.word 1
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "uart", UARTBase, DeviceRegionSize, uart)
+	})
+
+	if status, _ := core.Exited(); status != 'A' {
+		t.Errorf("read %q, expected 'A': a wrong-path read consumed input", rune(status))
+	}
+}
+
+func TestBus_Stats(t *testing.T) {
+	// WHAT: Each device reports its own reads and writes; the bus counts busy cycles
+	// WHY: Device traffic is slow and serializing, so it must be visible in the stats
+	// CATEGORY: [UNIT]
+
+	core, _ := runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(TimerBase)+`
+	li    t2, 5
+	sw    t2, 4(t1)
+	sw    t2, 8(t1)
+	lw    t3, 0(t1)
+	li    a0, 0
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "timer", TimerBase, DeviceRegionSize, NewTimer())
+	})
+
+	s := core.Snapshot().Bus
+	if len(s.Devices) != 1 || s.Devices[0].Name != "timer" || s.Devices[0].Base != TimerBase {
+		t.Fatalf("devices %+v", s.Devices)
+	}
+	if d := s.Devices[0]; d.Reads != 1 || d.Writes != 2 {
+		t.Errorf("timer reads %d writes %d, expected 1 and 2", d.Reads, d.Writes)
+	}
+	if s.BusyCycles != 3*BusLatency {
+		t.Errorf("bus busy %d cycles, expected %d", s.BusyCycles, 3*BusLatency)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. DEVICE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestDevices_UARTEcho(t *testing.T) {
+	// WHAT: A program echoes UART input to UART output in upper case until the input closes
+	// WHY: The UART is the console of a program that does not use system calls
+	// HARDWARE: Byte loads and stores to device registers, polled STATUS
+	// CATEGORY: [INTEGRATION]
+
+	var out bytes.Buffer
+	runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(UARTBase)+`
+loop:
+	lw    t2, 4(t1)           # STATUS
+	andi  t3, t2, 1           # RX ready
+	bnez  t3, echo
+	andi  t3, t2, 4           # RX closed
+	beqz  t3, loop
+	li    a0, 0
+	system 0
+echo:
+	lbu   t2, 0(t1)
+	li    t3, 97
+	blt   t2, t3, send
+	addi  t2, t2, -32
+send:
+	sb    t2, 0(t1)
+	j     loop
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "uart", UARTBase, DeviceRegionSize, NewUART(strings.NewReader("hello, uart"), &out))
+	})
+
+	if out.String() != "HELLO, UART" {
+		t.Errorf("echoed %q", out.String())
+	}
+}
+
+// watchedReader is a host stream that records being read and never runs dry
+type watchedReader struct {
+	reads atomic.Int32
+}
+
+func (r *watchedReader) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+func TestDevices_UARTReadsHostLazily(t *testing.T) {
+	// WHAT: A program that only sends on the UART never causes a read of the host input;
+	//       the first read of a UART register starts reading it
+	// WHY: The simulator command wires the UART to stdin. Reading stdin up front took
+	//      keystrokes away from anything else on the terminal even when the program
+	//      never used its UART
+	// HARDWARE: N/A (host side of the UART)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	in := &watchedReader{}
+	var out bytes.Buffer
+	uart := NewUART(in, &out)
+	defer uart.Close()
+	runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(UARTBase)+`
+	li    t2, 111
+	sb    t2, 0(t1)
+	li    t2, 107
+	sb    t2, 0(t1)
+	li    a0, 0
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "uart", UARTBase, DeviceRegionSize, uart)
+	})
+	if out.String() != "ok" {
+		t.Errorf("sent %q, expected \"ok\"", out.String())
+	}
+	time.Sleep(10 * time.Millisecond) // Give a wrongly started reader time to show up
+	if n := in.reads.Load(); n != 0 {
+		t.Fatalf("host input read %d times by a program that never reads the UART", n)
+	}
+
+	uart.Read(UARTStatus, 4)
+	deadline := time.Now().Add(time.Second)
+	for uart.Read(UARTStatus, 4)&UARTRxReady == 0 && time.Now().Before(deadline) {
+		uart.Tick()
+	}
+	if got := uart.Read(UARTData, 4); got != 'x' {
+		t.Errorf("DATA = %#x after reading STATUS, expected the host's 'x'", got)
+	}
+}
+
+func TestDevices_UARTCloseStopsReader(t *testing.T) {
+	// WHAT: Close ends the reader goroutine even while it waits for the program to take
+	//       a byte, and a UART closed before it is read never starts one
+	// WHY: The goroutine would otherwise outlive the simulation, holding the host stream
+	// HARDWARE: N/A (host side of the UART)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	before := runtime.NumGoroutine()
+
+	// An endless stream fills the receive buffer, so the reader blocks sending to it
+	uart := NewUART(&watchedReader{}, nil)
+	uart.Read(UARTStatus, 4)
+	uart.Close()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after Close, expected %d", n, before)
+	}
+
+	in := &watchedReader{}
+	closed := NewUART(in, nil)
+	closed.Close()
+	closed.Read(UARTStatus, 4)
+	time.Sleep(10 * time.Millisecond)
+	if n := in.reads.Load(); n != 0 {
+		t.Errorf("closed UART read its host input %d times", n)
+	}
+}
+
+func TestDevices_TimerPeriodic(t *testing.T) {
+	// WHAT: A periodic timer expires every RELOAD cycles; a one-shot timer stops
+	// WHY: Programs use it to measure and pace time without system calls
+	// CATEGORY: [UNIT]
+
+	tm := NewTimer()
+	tm.Write(TimerCount, 4, 10)
+	tm.Write(TimerReload, 4, 10)
+	tm.Write(TimerCtrl, 4, TimerEnable)
+	for i := 0; i < 35; i++ {
+		tm.Tick()
+	}
+	if n := tm.Read(TimerExpired, 4); n != 3 {
+		t.Errorf("periodic timer expired %d times in 35 cycles, expected 3", n)
+	}
+	if tm.Read(TimerStatus, 4) != TimerFired || tm.Read(TimerTime, 4) != 35 {
+		t.Errorf("status %d time %d", tm.Read(TimerStatus, 4), tm.Read(TimerTime, 4))
+	}
+	tm.Write(TimerStatus, 4, TimerFired)
+	if tm.Read(TimerStatus, 4) != 0 {
+		t.Error("STATUS not cleared by writing 1")
+	}
+
+	tm.Write(TimerReload, 4, 0)
+	for i := 0; i < 40; i++ {
+		tm.Tick()
+	}
+	if n := tm.Read(TimerExpired, 4); n != 4 || tm.Read(TimerCtrl, 4) != 0 {
+		t.Errorf("one-shot: expired %d, ctrl %d", n, tm.Read(TimerCtrl, 4))
+	}
+}
+
+func TestDevices_TimerPolledByProgram(t *testing.T) {
+	// WHAT: A program arms a one-shot timer and polls until it fires; TIME has advanced
+	//       by at least the count
+	// WHY: The timer ticks with the core, so a program can wait a known number of cycles
+	// CATEGORY: [INTEGRATION]
+
+	core, _ := runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(TimerBase)+`
+	lw    s0, 0(t1)           # TIME before
+	li    t2, 500
+	sw    t2, 4(t1)           # COUNT
+	li    t2, 1
+	sw    t2, 12(t1)          # CTRL = enable
+wait:
+	lw    t2, 16(t1)          # STATUS
+	beqz  t2, wait
+	lw    a0, 0(t1)           # TIME after
+	sub   a0, a0, s0
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "timer", TimerBase, DeviceRegionSize, NewTimer())
+	})
+
+	if elapsed, _ := core.Exited(); elapsed < 500 || elapsed > 1000 {
+		t.Errorf("%d cycles between TIME reads around a 500-cycle wait", elapsed)
+	}
+}
+
+func TestDevices_BlockDeviceFile(t *testing.T) {
+	// WHAT: A program reads sector 1 of a host file, increments its first word, and writes
+	//       the sector to sector 2; an out-of-range sector sets the error bit
+	// WHY: The block device is storage that outlives the simulation
+	// HARDWARE: Commands complete after BlockLatency cycles in Tick (STATUS busy until then)
+	// CATEGORY: [INTEGRATION]
+
+	path := filepath.Join(t.TempDir(), "disk.img")
+	disk := make([]byte, 4*SectorSize)
+	disk[SectorSize] = 41
+	copy(disk[SectorSize+4:], "sector one")
+	if err := os.WriteFile(path, disk, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dev, err := OpenBlockDevice(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	core, _ := runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(BlockBase)+`
+	li    t2, 1
+	sw    t2, 0(t1)           # SECTOR = 1
+	sw    t2, 4(t1)           # CMD = read
+	jal   ra, wait
+	lw    t3, 0x200(t1)
+	addi  t3, t3, 1
+	sw    t3, 0x200(t1)
+	li    t2, 2
+	sw    t2, 0(t1)           # SECTOR = 2
+	sw    t2, 4(t1)           # CMD = write
+	jal   ra, wait
+	mv    s0, t2              # Status after a good command
+	li    t2, 9
+	sw    t2, 0(t1)           # SECTOR = 9 (past the end)
+	li    t2, 1
+	sw    t2, 4(t1)
+	jal   ra, wait
+	lw    a1, 12(t1)          # SECTORS
+	mv    a0, t2
+	system 0
+
+wait:
+	lw    t2, 8(t1)           # STATUS
+	andi  t4, t2, 1
+	bnez  t4, wait
+	ret
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "disk", BlockBase, DeviceRegionSize, dev)
+	})
+
+	if status, _ := core.Exited(); status != BlockError {
+		t.Errorf("STATUS after an out-of-range read = %d, expected %d", status, BlockError)
+	}
+	regs := &core.window.regFile
+	if regs[RegS0] != 0 {
+		t.Errorf("STATUS after a good write = %d", regs[RegS0])
+	}
+	if regs[RegA0+1] != 4 {
+		t.Errorf("SECTORS = %d, expected 4", regs[RegA0+1])
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[2*SectorSize] != 42 || string(got[2*SectorSize+4:2*SectorSize+14]) != "sector one" {
+		t.Errorf("sector 2 = % x...", got[2*SectorSize:2*SectorSize+16])
+	}
+}
+
+func TestDevices_FramebufferPNG(t *testing.T) {
+	// WHAT: Pixels stored by a program (word and byte stores) appear in the PNG dump
+	// WHY: The framebuffer is checked by looking at the picture
+	// CATEGORY: [INTEGRATION]
+
+	fb := NewFramebuffer(8, 4)
+	runAsm(t, `
+	.text
+_start:
+	li    t1, `+fmt.Sprint(FramebufferBase)+`
+	li    t2, 0xFF0000        # Red at (0,0)
+	sw    t2, 0(t1)
+	li    t2, 0x00FF00        # Green at (7,3)
+	sw    t2, 124(t1)
+	li    t2, 0x80            # Blue byte at (1,2)
+	sb    t2, 68(t1)
+	li    a0, 0
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {
+		mapDevice(t, c, "fb", FramebufferBase, fb.Size(), fb)
+	})
+
+	var buf bytes.Buffer
+	if err := fb.WritePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 8 || b.Dy() != 4 {
+		t.Fatalf("image is %v", b)
+	}
+	checks := []struct {
+		x, y    int
+		r, g, b uint32
+	}{
+		{0, 0, 0xFF, 0, 0},
+		{7, 3, 0, 0xFF, 0},
+		{1, 2, 0, 0, 0x80},
+		{3, 1, 0, 0, 0},
+	}
+	for _, c := range checks {
+		r, g, b, _ := img.At(c.x, c.y).RGBA()
+		if r>>8 != c.r || g>>8 != c.g || b>>8 != c.b {
+			t.Errorf("pixel (%d,%d) = %02x%02x%02x, expected %02x%02x%02x", c.x, c.y, r>>8, g>>8, b>>8, c.r, c.g, c.b)
+		}
+	}
+}
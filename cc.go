@@ -0,0 +1,2336 @@
+package suprax32
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// C COMPILER (SUBSET) — FRONT END
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY A COMPILER: Hand-coded loops like CreateComprehensiveBenchmark say
+//
+//	little about real workloads: real code calls functions, walks
+//	structs through pointers, recurses and spills to the stack.
+//
+// THE PIPELINE:
+//
+//	C source ──lex──▶ tokens ──parse──▶ typed AST ──ccgen.go──▶ assembly
+//	         ──asm.go──▶ Object ──link.go──▶ Image ──▶ Core.LoadImage
+//
+// THE SUBSET:
+//
+//	Types:       void, char, short, int, long (32-bit), unsigned variants,
+//	             pointers, arrays, structs, unions, enums, typedef,
+//	             function pointers
+//	Statements:  if/else, while, do/while, for, switch/case/default,
+//	             break, continue, return, blocks with declarations
+//	Expressions: every C operator except floating point, including
+//	             compound assignment, ++/--, ?:, comma, sizeof, casts
+//	Functions:   up to 8 parameters, recursion, variadic functions
+//	             (va_list, va_start, va_arg, va_end are built in)
+//	Globals:     constant initializers, strings, addresses of globals
+//	Preprocessor: object-like #define / #undef; #include reads the
+//	             runtime's headers (runtime.go) and ignores any other;
+//	             #pragma lines are ignored
+//
+// NOT SUPPORTED: floating point, struct arguments/return values by value,
+//
+//	bit-fields, goto, function-like macros, #if.
+//
+// MINECRAFT ANALOGY: A crafting guide book. You write "I want a piston"
+//
+//	and it works out the planks, cobblestone and redstone for you.
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Lexer
+// ───────────────────────────────────────────────────────────────────────────────
+
+type tokKind uint8
+
+const (
+	tkEOF tokKind = iota
+	tkIdent
+	tkKeyword
+	tkNum
+	tkStr
+	tkPunct
+)
+
+type token struct {
+	kind     tokKind
+	text     string
+	val      int64  // tkNum
+	unsigned bool   // tkNum with a u suffix (or too big for int)
+	str      []byte // tkStr contents (without the NUL)
+	line     int
+}
+
+var ccKeywords = map[string]bool{
+	"void": true, "char": true, "short": true, "int": true, "long": true,
+	"signed": true, "unsigned": true, "struct": true, "union": true, "enum": true,
+	"typedef": true, "static": true, "extern": true, "const": true, "volatile": true,
+	"register": true, "inline": true, "auto": true, "sizeof": true, "return": true,
+	"if": true, "else": true, "while": true, "do": true, "for": true, "break": true,
+	"continue": true, "switch": true, "case": true, "default": true,
+	"_Bool": true, "va_list": true, "va_start": true, "va_arg": true, "va_end": true,
+}
+
+// Longest first so "<<=" wins over "<<" and "<"
+var ccPuncts = []string{
+	"<<=", ">>=", "...", "->", "++", "--", "<<", ">>", "<=", ">=", "==", "!=",
+	"&&", "||", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+}
+
+// ccError is a compile error at a source line
+type ccError struct {
+	file string
+	line int
+	msg  string
+}
+
+func (e *ccError) Error() string { return fmt.Sprintf("%s:%d: %s", e.file, e.line, e.msg) }
+
+// lexer turns source text into tokens, expanding object-like macros
+type lexer struct {
+	file     string
+	src      string
+	pos      int
+	line     int
+	macros   map[string][]token
+	included map[string]bool // Built-in headers already read (shared)
+	toks     []token
+}
+
+func (lx *lexer) errorf(format string, args ...any) {
+	panic(&ccError{lx.file, lx.line, fmt.Sprintf(format, args...)})
+}
+
+// tokenize lexes the whole file
+func tokenize(file, src string) []token {
+	lx := &lexer{file: file, src: src, line: 1, macros: make(map[string][]token),
+		included: make(map[string]bool)}
+	lx.run()
+	lx.toks = append(lx.toks, token{kind: tkEOF, line: lx.line})
+	return lx.toks
+}
+
+// run lexes lx.src from the current position to the end
+func (lx *lexer) run() {
+	atLineStart := true
+	for {
+		lx.skipSpace()
+		if lx.pos >= len(lx.src) {
+			break
+		}
+		if lx.src[lx.pos] == '\n' {
+			lx.pos++
+			lx.line++
+			atLineStart = true
+			continue
+		}
+		if atLineStart && lx.src[lx.pos] == '#' {
+			lx.directive()
+			continue
+		}
+		atLineStart = false
+		lx.expand(lx.next(), nil)
+	}
+}
+
+// include lexes a built-in header (see ccHeaders in runtime.go) in place
+//
+// Its tokens and macros join the including file's, each token carrying
+// the line of the #include. A header is read at most once.
+func (lx *lexer) include(name string) {
+	src, ok := ccHeaders[name]
+	if !ok || lx.included[name] {
+		return // Other headers are ignored, as before
+	}
+	lx.included[name] = true
+
+	inc := &lexer{file: name, src: src, line: 1, macros: lx.macros, included: lx.included}
+	inc.run()
+	for _, t := range inc.toks {
+		t.line = lx.line
+		lx.toks = append(lx.toks, t)
+	}
+}
+
+// skipSpace skips blanks and comments (not newlines)
+func (lx *lexer) skipSpace() {
+	for lx.pos < len(lx.src) {
+		c := lx.src[lx.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			lx.pos++
+		case c == '\\' && lx.pos+1 < len(lx.src) && lx.src[lx.pos+1] == '\n':
+			lx.pos += 2
+			lx.line++
+		case strings.HasPrefix(lx.src[lx.pos:], "//"):
+			for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+				lx.pos++
+			}
+		case strings.HasPrefix(lx.src[lx.pos:], "/*"):
+			end := strings.Index(lx.src[lx.pos+2:], "*/")
+			if end < 0 {
+				lx.errorf("unterminated comment")
+			}
+			lx.line += strings.Count(lx.src[lx.pos:lx.pos+2+end], "\n")
+			lx.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// expand appends a token, replacing macro names by their bodies
+func (lx *lexer) expand(t token, active map[string]bool) {
+	body, ok := lx.macros[t.text]
+	if t.kind != tkIdent || !ok || active[t.text] {
+		lx.toks = append(lx.toks, t)
+		return
+	}
+	inner := map[string]bool{t.text: true}
+	for name := range active {
+		inner[name] = true
+	}
+	for _, bt := range body {
+		bt.line = t.line
+		lx.expand(bt, inner)
+	}
+}
+
+// directive handles a preprocessor line
+func (lx *lexer) directive() {
+	end := strings.IndexByte(lx.src[lx.pos:], '\n')
+	if end < 0 {
+		end = len(lx.src) - lx.pos
+	}
+	line := lx.src[lx.pos+1 : lx.pos+end]
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		lx.pos += end
+		return
+	}
+
+	switch fields[0] {
+	case "include":
+		if len(fields) > 1 && len(fields[1]) > 2 {
+			lx.include(fields[1][1 : len(fields[1])-1]) // <name> or "name"
+		}
+		lx.pos += end
+	case "pragma":
+		lx.pos += end
+	case "undef":
+		if len(fields) > 1 {
+			delete(lx.macros, fields[1])
+		}
+		lx.pos += end
+	case "define":
+		// Lex the rest of the line as the macro body
+		lx.pos++
+		lx.skipSpace()
+		lx.pos += len("define")
+		lx.skipSpace()
+		name := lx.next()
+		if name.kind != tkIdent && name.kind != tkKeyword {
+			lx.errorf("macro name expected")
+		}
+		if lx.pos < len(lx.src) && lx.src[lx.pos] == '(' {
+			lx.errorf("function-like macros are not supported")
+		}
+		var body []token
+		for {
+			lx.skipSpace()
+			if lx.pos >= len(lx.src) || lx.src[lx.pos] == '\n' {
+				break
+			}
+			body = append(body, lx.next())
+		}
+		lx.macros[name.text] = body
+	default:
+		lx.errorf("unsupported preprocessor directive #%s", fields[0])
+	}
+}
+
+// next lexes one token at lx.pos
+func (lx *lexer) next() token {
+	s := lx.src[lx.pos:]
+	c := s[0]
+	t := token{line: lx.line}
+
+	switch {
+	case isCIdentStart(c):
+		n := 1
+		for n < len(s) && (isCIdentStart(s[n]) || isDigit(s[n])) {
+			n++
+		}
+		t.text = s[:n]
+		t.kind = tkIdent
+		if ccKeywords[t.text] {
+			t.kind = tkKeyword
+		}
+		lx.pos += n
+
+	case isDigit(c):
+		n := 0
+		for n < len(s) && (isCIdentStart(s[n]) || isDigit(s[n])) {
+			n++
+		}
+		t.text, t.kind = s[:n], tkNum
+		digits := strings.TrimRight(strings.ToLower(t.text), "ul")
+		suffix := strings.ToLower(t.text[len(digits):])
+		base := 10
+		switch {
+		case strings.HasPrefix(digits, "0x"):
+			base, digits = 16, digits[2:]
+		case strings.HasPrefix(digits, "0b"):
+			base, digits = 2, digits[2:]
+		case len(digits) > 1 && digits[0] == '0':
+			base, digits = 8, digits[1:]
+		}
+		v, err := strconv.ParseUint(digits, base, 32)
+		if err != nil {
+			lx.errorf("bad number %q", t.text)
+		}
+		t.val = int64(v)
+		t.unsigned = strings.Contains(suffix, "u") || (base == 10 && v > 0x7FFFFFFF) || v > 0xFFFFFFFF>>1
+		lx.pos += n
+
+	case c == '\'':
+		r, n := lx.charLit(s[1:])
+		if n+1 >= len(s) || s[1+n] != '\'' {
+			lx.errorf("bad character constant")
+		}
+		t.text, t.kind, t.val = s[:n+2], tkNum, int64(int8(r))
+		lx.pos += n + 2
+
+	case c == '"':
+		t.kind = tkStr
+		i := 1
+		for {
+			if i >= len(s) || s[i] == '\n' {
+				lx.errorf("unterminated string")
+			}
+			if s[i] == '"' {
+				break
+			}
+			r, n := lx.charLit(s[i:])
+			t.str = append(t.str, r)
+			i += n
+		}
+		t.text = s[:i+1]
+		lx.pos += i + 1
+
+	default:
+		t.kind = tkPunct
+		t.text = s[:1]
+		for _, p := range ccPuncts {
+			if strings.HasPrefix(s, p) {
+				t.text = p
+				break
+			}
+		}
+		if !strings.ContainsAny(t.text[:1], "+-*/%&|^~!<>=?:;,.()[]{}") {
+			lx.errorf("unexpected character %q", c)
+		}
+		lx.pos += len(t.text)
+	}
+	return t
+}
+
+// charLit decodes one (possibly escaped) character, returning its length
+func (lx *lexer) charLit(s string) (byte, int) {
+	if s == "" {
+		lx.errorf("unexpected end of input")
+	}
+	if s[0] != '\\' {
+		return s[0], 1
+	}
+	if len(s) < 2 {
+		lx.errorf("bad escape")
+	}
+	switch s[1] {
+	case 'n':
+		return '\n', 2
+	case 't':
+		return '\t', 2
+	case 'r':
+		return '\r', 2
+	case 'a':
+		return 7, 2
+	case 'b':
+		return 8, 2
+	case 'f':
+		return 12, 2
+	case 'v':
+		return 11, 2
+	case 'e':
+		return 27, 2
+	case 'x':
+		n := 2
+		v := 0
+		for n < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[n]) >= 0 {
+			d, _ := strconv.ParseUint(s[n:n+1], 16, 8)
+			v = v*16 + int(d)
+			n++
+		}
+		return byte(v), n
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		n := 1
+		v := 0
+		for n < len(s) && n < 4 && s[n] >= '0' && s[n] <= '7' {
+			v = v*8 + int(s[n]-'0')
+			n++
+		}
+		return byte(v), n
+	default:
+		return s[1], 2
+	}
+}
+
+func isCIdentStart(c byte) bool { return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isDigit(c byte) bool       { return c >= '0' && c <= '9' }
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Types
+// ───────────────────────────────────────────────────────────────────────────────
+
+type ctKind uint8
+
+const (
+	tyVoid ctKind = iota
+	tyChar
+	tyShort
+	tyInt
+	tyPtr
+	tyArray
+	tyStruct
+	tyFunc
+)
+
+// ctype is a C type
+type ctype struct {
+	kind     ctKind
+	unsigned bool
+	size     int
+	align    int
+
+	base   *ctype // Pointer target, array element
+	length int    // Array length (-1 = not yet known)
+
+	members  []*member // Struct/union members
+	complete bool      // Struct/union body seen
+
+	ret      *ctype   // Function return type
+	params   []*ctype // Function parameter types
+	variadic bool     // Function takes "..."
+	oldStyle bool     // Declared with () — any arguments accepted
+}
+
+// member is a struct/union field
+type member struct {
+	name   string
+	ty     *ctype
+	offset int
+}
+
+var (
+	tyVoidT   = &ctype{kind: tyVoid, size: 1, align: 1}
+	tyCharT   = &ctype{kind: tyChar, size: 1, align: 1}
+	tyUCharT  = &ctype{kind: tyChar, size: 1, align: 1, unsigned: true}
+	tyShortT  = &ctype{kind: tyShort, size: 2, align: 2}
+	tyUShortT = &ctype{kind: tyShort, size: 2, align: 2, unsigned: true}
+	tyIntT    = &ctype{kind: tyInt, size: 4, align: 4}
+	tyUIntT   = &ctype{kind: tyInt, size: 4, align: 4, unsigned: true}
+)
+
+func pointerTo(base *ctype) *ctype {
+	return &ctype{kind: tyPtr, size: 4, align: 4, base: base, unsigned: true}
+}
+
+func arrayOf(base *ctype, n int) *ctype {
+	return &ctype{kind: tyArray, size: base.size * max(n, 0), align: base.align, base: base, length: n}
+}
+
+func (t *ctype) isInteger() bool { return t.kind == tyChar || t.kind == tyShort || t.kind == tyInt }
+
+// isScalar reports whether values of the type fit in a register
+func (t *ctype) isScalar() bool { return t.isInteger() || t.kind == tyPtr }
+
+// hasBase reports whether the type is a pointer or array
+func (t *ctype) hasBase() bool { return t.base != nil }
+
+// String renders a type for error messages
+func (t *ctype) String() string {
+	switch t.kind {
+	case tyVoid:
+		return "void"
+	case tyPtr:
+		return t.base.String() + "*"
+	case tyArray:
+		return fmt.Sprintf("%s[%d]", t.base, t.length)
+	case tyStruct:
+		return "struct"
+	case tyFunc:
+		return "function returning " + t.ret.String()
+	}
+	name := map[ctKind]string{tyChar: "char", tyShort: "short", tyInt: "int"}[t.kind]
+	if t.unsigned {
+		return "unsigned " + name
+	}
+	return name
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// AST
+// ───────────────────────────────────────────────────────────────────────────────
+
+type ndKind uint8
+
+const (
+	ndNum ndKind = iota
+	ndVar
+	ndAdd
+	ndSub
+	ndMul
+	ndDiv
+	ndMod
+	ndBitAnd
+	ndBitOr
+	ndBitXor
+	ndShl
+	ndShr
+	ndEq
+	ndNe
+	ndLt
+	ndLe
+	ndLogAnd
+	ndLogOr
+	ndNot
+	ndBitNot
+	ndNeg
+	ndAssign
+	ndCond
+	ndComma
+	ndAddr
+	ndDeref
+	ndMember
+	ndCall
+	ndCast
+	ndMemZero // Zero a local variable (initializers)
+	ndVaStart
+
+	// Statements
+	ndReturn
+	ndIf
+	ndFor // Also while
+	ndDo
+	ndSwitch
+	ndCase
+	ndBlock
+	ndBreak
+	ndContinue
+	ndExprStmt
+)
+
+// node is an AST node (expression or statement)
+type node struct {
+	kind ndKind
+	ty   *ctype
+	line int
+
+	lhs, rhs *node
+	val      int64 // ndNum, ndCase value
+	v        *cvar // ndVar
+	mem      *member
+
+	// ndCall
+	fnName string // Direct call target ("" = through lhs)
+	fnTy   *ctype
+	args   []*node
+
+	// Statements
+	cond, then, els, init, inc *node
+	body                       []*node
+	brk, cont                  string // Labels for break/continue
+	cases                      []*node
+	dflt                       *node
+	label                      string // ndCase
+}
+
+// cvar is a variable or function
+type cvar struct {
+	name   string
+	ty     *ctype
+	local  bool
+	offset int    // Local: fp-relative offset (negative)
+	label  string // Global: assembly symbol
+	static bool
+
+	// Globals
+	init     []byte
+	initRels []initReloc
+	hasInit  bool
+	defined  bool // Functions: body seen
+
+	// Functions
+	params []*cvar
+	locals []*cvar
+	body   *node
+	frame  int
+	vaArea int // Offset of the register save area (variadic functions)
+}
+
+// initReloc is "word at off = address of label + addend" in a global
+type initReloc struct {
+	off    int
+	label  string
+	addend int64
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Parser
+// ───────────────────────────────────────────────────────────────────────────────
+
+// scope holds one block's names
+type scope struct {
+	vars     map[string]*cvar
+	typedefs map[string]*ctype
+	enums    map[string]int64
+	tags     map[string]*ctype
+}
+
+func newScope() *scope {
+	return &scope{
+		vars:     make(map[string]*cvar),
+		typedefs: make(map[string]*ctype),
+		enums:    make(map[string]int64),
+		tags:     make(map[string]*ctype),
+	}
+}
+
+// parser builds the AST and symbol tables for one translation unit
+type parser struct {
+	file    string
+	toks    []token
+	pos     int
+	scopes  []*scope
+	globals []*cvar
+	fn      *cvar // Function being parsed
+	labels  int
+	strings int
+
+	brk, cont string // Innermost break/continue targets
+	sw        *node  // Innermost switch
+}
+
+func (p *parser) errorf(format string, args ...any) {
+	panic(&ccError{p.file, p.tok().line, fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) tok() *token { return &p.toks[p.pos] }
+func (p *parser) peek(s string) bool {
+	t := p.tok()
+	return (t.kind == tkPunct || t.kind == tkKeyword) && t.text == s
+}
+
+func (p *parser) peekAt(n int, s string) bool {
+	if p.pos+n >= len(p.toks) {
+		return false
+	}
+	t := &p.toks[p.pos+n]
+	return (t.kind == tkPunct || t.kind == tkKeyword) && t.text == s
+}
+
+func (p *parser) consume(s string) bool {
+	if p.peek(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) {
+	if !p.consume(s) {
+		p.errorf("expected %q, got %q", s, p.tok().text)
+	}
+}
+
+func (p *parser) ident() string {
+	t := p.tok()
+	if t.kind != tkIdent {
+		p.errorf("expected an identifier, got %q", t.text)
+	}
+	p.pos++
+	return t.text
+}
+
+func (p *parser) newLabel() string {
+	p.labels++
+	return fmt.Sprintf(".L%d", p.labels)
+}
+
+func (p *parser) enterScope() { p.scopes = append(p.scopes, newScope()) }
+func (p *parser) leaveScope() { p.scopes = p.scopes[:len(p.scopes)-1] }
+func (p *parser) inner() *scope {
+	return p.scopes[len(p.scopes)-1]
+}
+
+// lookup finds a variable, typedef or enum constant, innermost first
+func (p *parser) lookup(name string) (v *cvar, td *ctype, enumVal int64, kind int) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		s := p.scopes[i]
+		if v, ok := s.vars[name]; ok {
+			return v, nil, 0, 1
+		}
+		if td, ok := s.typedefs[name]; ok {
+			return nil, td, 0, 2
+		}
+		if ev, ok := s.enums[name]; ok {
+			return nil, nil, ev, 3
+		}
+	}
+	return nil, nil, 0, 0
+}
+
+func (p *parser) lookupTag(name string) *ctype {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if t, ok := p.scopes[i].tags[name]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// parseC parses a translation unit
+func parseC(file, src string) (globals []*cvar, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ce, ok := r.(*ccError)
+			if !ok {
+				panic(r)
+			}
+			err = ce
+		}
+	}()
+
+	p := &parser{file: file, toks: tokenize(file, src)}
+	p.enterScope()
+	p.inner().typedefs["va_list"] = pointerTo(tyCharT)
+	for p.tok().kind != tkEOF {
+		p.topLevel()
+	}
+	return p.globals, nil
+}
+
+// declAttr carries storage-class specifiers
+type declAttr struct {
+	typedef, static, extern bool
+}
+
+// isTypeName reports whether the current token starts a type
+func (p *parser) isTypeName() bool {
+	t := p.tok()
+	if t.kind == tkKeyword {
+		switch t.text {
+		case "void", "char", "short", "int", "long", "signed", "unsigned", "struct", "union",
+			"enum", "typedef", "static", "extern", "const", "volatile", "register", "inline",
+			"auto", "_Bool", "va_list":
+			return true
+		}
+		return false
+	}
+	if t.kind == tkIdent {
+		_, td, _, kind := p.lookup(t.text)
+		return kind == 2 && td != nil
+	}
+	return false
+}
+
+// declspec parses type specifiers and storage classes
+func (p *parser) declspec(attr *declAttr) *ctype {
+	var ty *ctype
+	unsigned, signed, sawInt := false, false, 0
+	short := false
+
+	for p.isTypeName() {
+		t := p.tok()
+		switch t.text {
+		case "typedef", "static", "extern":
+			if attr == nil {
+				p.errorf("storage class %q not allowed here", t.text)
+			}
+			switch t.text {
+			case "typedef":
+				attr.typedef = true
+			case "static":
+				attr.static = true
+			case "extern":
+				attr.extern = true
+			}
+			p.pos++
+			continue
+		case "const", "volatile", "register", "inline", "auto":
+			p.pos++
+			continue
+		case "unsigned":
+			unsigned = true
+			p.pos++
+			continue
+		case "signed":
+			signed = true
+			p.pos++
+			continue
+		case "short":
+			short = true
+			sawInt++
+			p.pos++
+			continue
+		case "int", "long":
+			sawInt++
+			p.pos++
+			continue
+		}
+
+		if ty != nil {
+			break
+		}
+		switch t.text {
+		case "void":
+			ty = tyVoidT
+			p.pos++
+		case "char", "_Bool":
+			ty = tyCharT
+			if t.text == "_Bool" {
+				ty = tyUCharT
+			}
+			p.pos++
+		case "struct", "union":
+			p.pos++
+			ty = p.structDecl(t.text == "union")
+		case "enum":
+			p.pos++
+			ty = p.enumDecl()
+		case "va_list":
+			ty = pointerTo(tyCharT)
+			p.pos++
+		default:
+			_, td, _, _ := p.lookup(t.text)
+			ty = td
+			p.pos++
+		}
+	}
+
+	switch {
+	case ty == nil && short:
+		ty = tyShortT
+	case ty == nil:
+		if sawInt == 0 && !unsigned && !signed && attr == nil {
+			p.errorf("type expected")
+		}
+		ty = tyIntT
+	}
+	if unsigned && ty.isInteger() {
+		switch ty.kind {
+		case tyChar:
+			ty = tyUCharT
+		case tyShort:
+			ty = tyUShortT
+		default:
+			ty = tyUIntT
+		}
+	}
+	_ = signed
+	return ty
+}
+
+// structDecl parses "struct tag { members }" or a reference to a tag
+func (p *parser) structDecl(union bool) *ctype {
+	tag := ""
+	if p.tok().kind == tkIdent {
+		tag = p.ident()
+	}
+	if tag != "" && !p.peek("{") {
+		if t := p.lookupTag(tag); t != nil {
+			return t
+		}
+		t := &ctype{kind: tyStruct, align: 1}
+		p.inner().tags[tag] = t
+		return t
+	}
+
+	// Definition: reuse a forward declaration from this scope
+	ty := &ctype{kind: tyStruct, align: 1}
+	if tag != "" {
+		if t, ok := p.inner().tags[tag]; ok && !t.complete {
+			ty = t
+		}
+		p.inner().tags[tag] = ty
+	}
+
+	p.expect("{")
+	offset := 0
+	for !p.consume("}") {
+		base := p.declspec(nil)
+		for first := true; !p.consume(";"); first = false {
+			if !first {
+				p.expect(",")
+			}
+			mty, name := p.declarator(base)
+			if mty.kind == tyArray && mty.length < 0 {
+				p.errorf("flexible array members are not supported")
+			}
+			if union {
+				ty.members = append(ty.members, &member{name: name, ty: mty})
+				offset = max(offset, mty.size)
+			} else {
+				offset = int(alignUp(uint32(offset), uint32(mty.align)))
+				ty.members = append(ty.members, &member{name: name, ty: mty, offset: offset})
+				offset += mty.size
+			}
+			ty.align = max(ty.align, mty.align)
+		}
+	}
+	ty.size = int(alignUp(uint32(offset), uint32(ty.align)))
+	ty.complete = true
+	return ty
+}
+
+// enumDecl parses "enum tag { A, B = 3 }"; enums are ints
+func (p *parser) enumDecl() *ctype {
+	if p.tok().kind == tkIdent {
+		p.ident()
+	}
+	if !p.consume("{") {
+		return tyIntT
+	}
+	val := int64(0)
+	for !p.consume("}") {
+		name := p.ident()
+		if p.consume("=") {
+			val = p.constExpr()
+		}
+		p.inner().enums[name] = val
+		val++
+		if !p.consume(",") {
+			p.expect("}")
+			break
+		}
+	}
+	return tyIntT
+}
+
+// declarator parses pointers, a name (optional) and array/function suffixes
+//
+// Nested declarators such as "int (*fp)(int)" are handled by skipping
+// the parenthesised part, parsing the suffix, then re-parsing the inner
+// part with the suffixed type as its base.
+func (p *parser) declarator(ty *ctype) (*ctype, string) {
+	for p.consume("*") {
+		ty = pointerTo(ty)
+		for p.consume("const") || p.consume("volatile") {
+		}
+	}
+
+	if p.peek("(") && !p.startsParams() {
+		start := p.pos + 1
+		p.pos = start
+		p.declarator(tyIntT) // Skip the inner declarator
+		p.expect(")")
+		ty = p.typeSuffix(ty)
+		end := p.pos
+		p.pos = start
+		inner, name := p.declarator(ty)
+		p.expect(")")
+		p.pos = end
+		return inner, name
+	}
+
+	name := ""
+	if p.tok().kind == tkIdent {
+		name = p.ident()
+	}
+	return p.typeSuffix(ty), name
+}
+
+// startsParams reports whether "(" begins a parameter list rather than a
+// nested declarator (abstract function types such as "int (int)")
+func (p *parser) startsParams() bool {
+	if p.peekAt(1, ")") {
+		return true
+	}
+	save := p.pos
+	p.pos++
+	isType := p.isTypeName()
+	p.pos = save
+	return isType
+}
+
+// typeSuffix parses array dimensions and function parameter lists
+func (p *parser) typeSuffix(ty *ctype) *ctype {
+	if p.consume("[") {
+		n := -1
+		if !p.peek("]") {
+			n = int(p.constExpr())
+		}
+		p.expect("]")
+		ty = p.typeSuffix(ty)
+		return arrayOf(ty, n)
+	}
+	if p.consume("(") {
+		fn := &ctype{kind: tyFunc, ret: ty, size: 1, align: 1}
+		if p.consume(")") {
+			fn.oldStyle = true
+			return fn
+		}
+		if p.peek("void") && p.peekAt(1, ")") {
+			p.pos += 2
+			return fn
+		}
+		for {
+			if p.consume("...") {
+				fn.variadic = true
+				p.expect(")")
+				break
+			}
+			pty, _ := p.declarator(p.declspec(nil))
+			fn.params = append(fn.params, decay(pty))
+			if p.consume(")") {
+				break
+			}
+			p.expect(",")
+		}
+		return fn
+	}
+	return ty
+}
+
+// decay turns array and function parameter types into pointers
+func decay(t *ctype) *ctype {
+	switch t.kind {
+	case tyArray:
+		return pointerTo(t.base)
+	case tyFunc:
+		return pointerTo(t)
+	}
+	return t
+}
+
+// paramNames re-parses a function declarator's parameter names
+//
+// declarator() builds the type; names are collected separately so the
+// body can declare them as locals.
+func (p *parser) paramNames(start int) []string {
+	save := p.pos
+	p.pos = start
+	var names []string
+	if p.consume("(") && !p.peek(")") && !(p.peek("void") && p.peekAt(1, ")")) {
+		for {
+			if p.consume("...") {
+				break
+			}
+			_, name := p.declarator(p.declspec(nil))
+			names = append(names, name)
+			if p.peek(")") {
+				break
+			}
+			p.expect(",")
+		}
+	}
+	p.pos = save
+	return names
+}
+
+// topLevel parses a function definition or global declaration
+func (p *parser) topLevel() {
+	if p.consume(";") {
+		return
+	}
+	attr := &declAttr{}
+	base := p.declspec(attr)
+	if p.consume(";") {
+		return // struct/enum declaration only
+	}
+
+	for first := true; ; first = false {
+		if !first {
+			p.expect(",")
+		}
+		declStart := p.pos
+		ty, name := p.declarator(base)
+		if name == "" {
+			p.errorf("declaration needs a name")
+		}
+
+		if attr.typedef {
+			p.inner().typedefs[name] = ty
+		} else if ty.kind == tyFunc {
+			fn := p.declareFunc(name, ty, attr)
+			if p.peek("{") {
+				if !first {
+					p.errorf("function definition in a declaration list")
+				}
+				p.funcBody(fn, p.paramNames(p.findParams(declStart)))
+				return
+			}
+		} else {
+			p.globalVar(name, ty, attr)
+		}
+
+		if p.consume(";") {
+			return
+		}
+	}
+}
+
+// findParams locates the "(" of the outermost function declarator
+func (p *parser) findParams(declStart int) int {
+	depth := 0
+	for i := declStart; i < p.pos; i++ {
+		t := p.toks[i]
+		if t.kind != tkPunct {
+			continue
+		}
+		switch t.text {
+		case "(":
+			if depth == 0 && i > declStart && p.toks[i-1].kind == tkIdent {
+				return i
+			}
+			depth++
+		case ")":
+			depth--
+		}
+	}
+	p.errorf("cannot find parameter list")
+	return 0
+}
+
+// declareFunc records a function declaration (or returns the earlier one)
+func (p *parser) declareFunc(name string, ty *ctype, attr *declAttr) *cvar {
+	if v, _, _, kind := p.lookup(name); kind == 1 && v.ty.kind == tyFunc {
+		if !ty.oldStyle {
+			v.ty = ty
+		}
+		return v
+	}
+	fn := &cvar{name: name, ty: ty, label: name, static: attr.static}
+	p.scopes[0].vars[name] = fn
+	p.globals = append(p.globals, fn)
+	return fn
+}
+
+// funcBody parses a function definition
+func (p *parser) funcBody(fn *cvar, names []string) {
+	if fn.defined {
+		p.errorf("redefinition of %s", fn.name)
+	}
+	fn.defined = true
+	ty := fn.ty
+	if len(ty.params) > 8 {
+		p.errorf("%s: more than 8 parameters", fn.name)
+	}
+	p.fn = fn
+	p.enterScope()
+
+	// Variadic functions spill all 8 argument registers side by side so
+	// va_arg can walk past the named parameters
+	if ty.variadic {
+		fn.vaArea = p.allocLocal(32, 4)
+	}
+	for i, pty := range ty.params {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		v := &cvar{name: name, ty: pty, local: true}
+		if ty.variadic {
+			v.offset = fn.vaArea + 4*i
+		} else {
+			v.offset = p.allocLocal(pty.size, pty.align)
+		}
+		if name != "" {
+			p.inner().vars[name] = v
+		}
+		fn.params = append(fn.params, v)
+	}
+
+	fn.body = p.compound()
+	p.leaveScope()
+	p.fn = nil
+}
+
+// allocLocal reserves frame space and returns its fp-relative offset
+//
+// The frame's top 8 bytes hold the saved ra and fp (see ccgen.go).
+func (p *parser) allocLocal(size, align int) int {
+	fn := p.fn
+	fn.frame = int(alignUp(uint32(fn.frame+size), uint32(max(align, 1))))
+	return -(8 + fn.frame)
+}
+
+// globalVar declares a global variable with an optional initializer
+func (p *parser) globalVar(name string, ty *ctype, attr *declAttr) {
+	v, _, _, kind := p.lookup(name)
+	if kind != 1 || v.ty.kind == tyFunc {
+		v = &cvar{name: name, ty: ty, label: name, static: attr.static}
+		p.scopes[0].vars[name] = v
+		p.globals = append(p.globals, v)
+	} else if v.ty.kind == tyArray && v.ty.length < 0 {
+		v.ty = ty
+	}
+	if !attr.extern {
+		v.defined = true
+	}
+	if p.consume("=") {
+		p.globalInit(v)
+		v.defined = true
+	}
+	if v.defined && v.ty.kind == tyArray && v.ty.length < 0 {
+		p.errorf("array %s needs a size", name)
+	}
+}
+
+// staticLocal declares a function-scope static as a uniquely named global
+func (p *parser) staticLocal(name string, ty *ctype) {
+	p.strings++
+	v := &cvar{name: name, ty: ty, label: fmt.Sprintf("%s.%s.%d", p.fn.name, name, p.strings), static: true, defined: true}
+	p.inner().vars[name] = v
+	p.globals = append(p.globals, v)
+	if p.consume("=") {
+		p.globalInit(v)
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Initializers
+// ───────────────────────────────────────────────────────────────────────────────
+
+// globalInit evaluates a constant initializer into v.init/v.initRels
+func (p *parser) globalInit(v *cvar) {
+	if v.ty.kind == tyArray && v.ty.length < 0 {
+		n := p.countInit(v.ty)
+		v.ty = arrayOf(v.ty.base, n)
+	}
+	v.init = make([]byte, v.ty.size)
+	v.hasInit = true
+	p.initData(v, v.ty, 0)
+}
+
+// countInit finds the length of an unsized array from its initializer
+func (p *parser) countInit(ty *ctype) int {
+	save := p.pos
+	defer func() { p.pos = save }()
+	if t := p.tok(); t.kind == tkStr && ty.base.kind == tyChar {
+		return len(t.str) + 1
+	}
+	p.expect("{")
+	n := 0
+	for !p.consume("}") {
+		p.skipInit()
+		n++
+		if !p.consume(",") {
+			p.expect("}")
+			break
+		}
+	}
+	return n
+}
+
+// skipInit skips one initializer (braced or an assignment expression)
+func (p *parser) skipInit() {
+	if p.consume("{") {
+		for depth := 1; depth > 0; p.pos++ {
+			switch {
+			case p.peek("{"):
+				depth++
+			case p.peek("}"):
+				depth--
+			case p.tok().kind == tkEOF:
+				p.errorf("unterminated initializer")
+			}
+		}
+		return
+	}
+	p.assign()
+}
+
+// initData fills global bytes for a value of type ty at offset off
+func (p *parser) initData(v *cvar, ty *ctype, off int) {
+	switch {
+	case ty.kind == tyArray && ty.base.kind == tyChar && p.tok().kind == tkStr:
+		copy(v.init[off:off+ty.size], p.tok().str)
+		p.pos++
+	case ty.kind == tyArray:
+		p.expect("{")
+		for i := 0; !p.consume("}"); i++ {
+			if i < ty.length {
+				p.initData(v, ty.base, off+i*ty.base.size)
+			} else {
+				p.skipInit()
+			}
+			if !p.consume(",") {
+				p.expect("}")
+				break
+			}
+		}
+	case ty.kind == tyStruct:
+		p.expect("{")
+		for i := 0; !p.consume("}"); i++ {
+			if i < len(ty.members) {
+				m := ty.members[i]
+				p.initData(v, m.ty, off+m.offset)
+			} else {
+				p.skipInit()
+			}
+			if !p.consume(",") {
+				p.expect("}")
+				break
+			}
+		}
+	default:
+		braced := p.consume("{")
+		val, label := p.evalReloc(p.assign())
+		if braced {
+			p.consume(",")
+			p.expect("}")
+		}
+		if label != "" {
+			if ty.size != 4 {
+				p.errorf("address in a %d-byte initializer", ty.size)
+			}
+			v.initRels = append(v.initRels, initReloc{off: off, label: label, addend: val})
+			return
+		}
+		for i := 0; i < ty.size; i++ {
+			v.init[off+i] = byte(val >> (8 * i))
+		}
+	}
+}
+
+// localInit turns "T x = init" into assignments
+func (p *parser) localInit(v *cvar) *node {
+	line := p.tok().line
+	if v.ty.kind == tyArray || v.ty.kind == tyStruct {
+		stmts := []*node{{kind: ndMemZero, v: v, line: line}}
+		stmts = append(stmts, p.localInitAt(v, v.ty, 0)...)
+		return &node{kind: ndBlock, body: stmts, line: line}
+	}
+	lhs := &node{kind: ndVar, v: v, ty: v.ty, line: line}
+	return p.exprStmt(p.newAssign(lhs, p.assign()))
+}
+
+// localInitAt builds element assignments for an aggregate initializer
+func (p *parser) localInitAt(v *cvar, ty *ctype, off int) []*node {
+	elem := func(t *ctype, o int) *node {
+		addr := p.newAdd(&node{kind: ndCast, ty: pointerTo(tyCharT), lhs: p.addrOf(&node{kind: ndVar, v: v, ty: v.ty}), line: p.tok().line},
+			p.num(int64(o)))
+		return p.deref(&node{kind: ndCast, ty: pointerTo(t), lhs: addr, line: addr.line})
+	}
+
+	switch {
+	case ty.kind == tyArray && ty.base.kind == tyChar && p.tok().kind == tkStr:
+		var out []*node
+		s := p.tok().str
+		p.pos++
+		for i := 0; i < len(s) && i < ty.length; i++ {
+			out = append(out, p.exprStmt(p.newAssign(elem(ty.base, off+i), p.num(int64(int8(s[i]))))))
+		}
+		return out
+	case ty.kind == tyArray || ty.kind == tyStruct:
+		p.expect("{")
+		var out []*node
+		for i := 0; !p.consume("}"); i++ {
+			switch {
+			case ty.kind == tyArray && i < ty.length:
+				out = append(out, p.localInitAt(v, ty.base, off+i*ty.base.size)...)
+			case ty.kind == tyStruct && i < len(ty.members):
+				m := ty.members[i]
+				out = append(out, p.localInitAt(v, m.ty, off+m.offset)...)
+			default:
+				p.skipInit()
+			}
+			if !p.consume(",") {
+				p.expect("}")
+				break
+			}
+		}
+		return out
+	default:
+		braced := p.consume("{")
+		val := p.assign()
+		if braced {
+			p.consume(",")
+			p.expect("}")
+		}
+		return []*node{p.exprStmt(p.newAssign(elem(ty, off), val))}
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Statements
+// ───────────────────────────────────────────────────────────────────────────────
+
+// compound parses "{ ... }" as a block with its own scope
+func (p *parser) compound() *node {
+	line := p.tok().line
+	p.expect("{")
+	p.enterScope()
+	var body []*node
+	for !p.consume("}") {
+		if p.isTypeName() && !p.peekAt(1, ":") {
+			body = append(body, p.declaration()...)
+		} else {
+			body = append(body, p.stmt())
+		}
+	}
+	p.leaveScope()
+	return &node{kind: ndBlock, body: body, line: line}
+}
+
+// declaration parses local declarations (and their initializers)
+func (p *parser) declaration() []*node {
+	attr := &declAttr{}
+	base := p.declspec(attr)
+	var out []*node
+	for first := true; !p.consume(";"); first = false {
+		if !first {
+			p.expect(",")
+		}
+		ty, name := p.declarator(base)
+		switch {
+		case name == "":
+			p.errorf("declaration needs a name")
+		case attr.typedef:
+			p.inner().typedefs[name] = ty
+			continue
+		case ty.kind == tyFunc:
+			p.declareFunc(name, ty, attr)
+			continue
+		case attr.extern:
+			p.globalVar(name, ty, attr)
+			continue
+		case attr.static:
+			p.staticLocal(name, ty)
+			continue
+		case ty.kind == tyVoid:
+			p.errorf("variable %s declared void", name)
+		}
+
+		if ty.kind == tyArray && ty.length < 0 {
+			if !p.peek("=") {
+				p.errorf("array %s needs a size", name)
+			}
+			p.pos++
+			ty = arrayOf(ty.base, p.countInit(ty))
+			p.pos--
+		}
+		v := &cvar{name: name, ty: ty, local: true}
+		v.offset = p.allocLocal(ty.size, ty.align)
+		p.inner().vars[name] = v
+		p.fn.locals = append(p.fn.locals, v)
+		if p.consume("=") {
+			out = append(out, p.localInit(v))
+		}
+	}
+	return out
+}
+
+func (p *parser) exprStmt(e *node) *node {
+	return &node{kind: ndExprStmt, lhs: e, line: e.line}
+}
+
+// stmt parses one statement
+func (p *parser) stmt() *node {
+	line := p.tok().line
+	switch {
+	case p.consume("return"):
+		n := &node{kind: ndReturn, line: line}
+		if !p.consume(";") {
+			e := p.expr()
+			p.expect(";")
+			if p.fn.ty.ret.kind == tyStruct {
+				p.errorf("returning structs by value is not supported")
+			}
+			n.lhs = p.cast(e, p.fn.ty.ret)
+		}
+		return n
+
+	case p.consume("if"):
+		n := &node{kind: ndIf, line: line}
+		p.expect("(")
+		n.cond = p.expr()
+		p.expect(")")
+		n.then = p.stmt()
+		if p.consume("else") {
+			n.els = p.stmt()
+		}
+		return n
+
+	case p.consume("while"):
+		n := &node{kind: ndFor, line: line}
+		p.expect("(")
+		n.cond = p.expr()
+		p.expect(")")
+		n.body = []*node{p.loopBody(n)}
+		return n
+
+	case p.consume("do"):
+		n := &node{kind: ndDo, line: line}
+		n.body = []*node{p.loopBody(n)}
+		p.expect("while")
+		p.expect("(")
+		n.cond = p.expr()
+		p.expect(")")
+		p.expect(";")
+		return n
+
+	case p.consume("for"):
+		n := &node{kind: ndFor, line: line}
+		p.expect("(")
+		p.enterScope()
+		if p.isTypeName() {
+			n.init = &node{kind: ndBlock, body: p.declaration(), line: line}
+		} else if !p.consume(";") {
+			n.init = p.exprStmt(p.expr())
+			p.expect(";")
+		}
+		if !p.consume(";") {
+			n.cond = p.expr()
+			p.expect(";")
+		}
+		if !p.consume(")") {
+			n.inc = p.expr()
+			p.expect(")")
+		}
+		n.body = []*node{p.loopBody(n)}
+		p.leaveScope()
+		return n
+
+	case p.consume("switch"):
+		n := &node{kind: ndSwitch, line: line}
+		p.expect("(")
+		n.cond = p.expr()
+		p.expect(")")
+		if !n.cond.ty.isInteger() {
+			p.errorf("switch on a non-integer")
+		}
+		outerSw, outerBrk := p.sw, p.brk
+		p.sw, p.brk = n, p.newLabel()
+		n.brk = p.brk
+		n.then = p.stmt()
+		p.sw, p.brk = outerSw, outerBrk
+		return n
+
+	case p.peek("case") || p.peek("default"):
+		if p.sw == nil {
+			p.errorf("%s outside switch", p.tok().text)
+		}
+		n := &node{kind: ndCase, label: p.newLabel(), line: line}
+		if p.consume("case") {
+			n.val = p.constExpr()
+			p.sw.cases = append(p.sw.cases, n)
+		} else {
+			p.pos++
+			p.sw.dflt = n
+		}
+		p.expect(":")
+		if p.peek("}") {
+			return n
+		}
+		n.lhs = p.stmt()
+		return n
+
+	case p.consume("break"):
+		if p.brk == "" {
+			p.errorf("break outside a loop or switch")
+		}
+		p.expect(";")
+		return &node{kind: ndBreak, brk: p.brk, line: line}
+
+	case p.consume("continue"):
+		if p.cont == "" {
+			p.errorf("continue outside a loop")
+		}
+		p.expect(";")
+		return &node{kind: ndContinue, cont: p.cont, line: line}
+
+	case p.peek("{"):
+		return p.compound()
+
+	case p.consume(";"):
+		return &node{kind: ndBlock, line: line}
+	}
+
+	e := p.expr()
+	p.expect(";")
+	return p.exprStmt(e)
+}
+
+// loopBody parses a loop body with fresh break/continue labels
+func (p *parser) loopBody(loop *node) *node {
+	outerBrk, outerCont := p.brk, p.cont
+	loop.brk, loop.cont = p.newLabel(), p.newLabel()
+	p.brk, p.cont = loop.brk, loop.cont
+	body := p.stmt()
+	p.brk, p.cont = outerBrk, outerCont
+	return body
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Expressions (lowest to highest precedence)
+// ───────────────────────────────────────────────────────────────────────────────
+
+func (p *parser) num(v int64) *node {
+	return &node{kind: ndNum, val: v, ty: tyIntT, line: p.tok().line}
+}
+
+func (p *parser) expr() *node {
+	n := p.assign()
+	for p.peek(",") {
+		line := p.tok().line
+		p.pos++
+		rhs := p.assign()
+		n = &node{kind: ndComma, lhs: n, rhs: rhs, ty: rhs.ty, line: line}
+	}
+	return n
+}
+
+var compoundOps = map[string]ndKind{
+	"+=": ndAdd, "-=": ndSub, "*=": ndMul, "/=": ndDiv, "%=": ndMod,
+	"&=": ndBitAnd, "|=": ndBitOr, "^=": ndBitXor, "<<=": ndShl, ">>=": ndShr,
+}
+
+func (p *parser) assign() *node {
+	n := p.conditional()
+	if p.consume("=") {
+		return p.newAssign(n, p.assign())
+	}
+	if op, ok := compoundOps[p.tok().text]; ok && p.tok().kind == tkPunct {
+		p.pos++
+		return p.compoundAssign(n, op, p.assign())
+	}
+	return n
+}
+
+// compoundAssign lowers "A op= B" to "tmp = &A, *tmp = *tmp op B"
+func (p *parser) compoundAssign(lhs *node, op ndKind, rhs *node) *node {
+	p.checkLvalue(lhs)
+	tmp := p.tempVar(pointerTo(lhs.ty))
+	line := lhs.line
+	setTmp := p.newAssign(&node{kind: ndVar, v: tmp, ty: tmp.ty, line: line}, p.addrOf(lhs))
+	target := func() *node {
+		return p.deref(&node{kind: ndVar, v: tmp, ty: tmp.ty, line: line})
+	}
+	value := p.binary(op, target(), rhs)
+	return &node{kind: ndComma, lhs: setTmp, rhs: p.newAssign(target(), value), ty: lhs.ty, line: line}
+}
+
+// tempVar allocates an anonymous local
+func (p *parser) tempVar(ty *ctype) *cvar {
+	if p.fn == nil {
+		p.errorf("expression not allowed outside a function")
+	}
+	v := &cvar{ty: ty, local: true}
+	v.offset = p.allocLocal(ty.size, ty.align)
+	p.fn.locals = append(p.fn.locals, v)
+	return v
+}
+
+func (p *parser) conditional() *node {
+	cond := p.logOr()
+	if !p.peek("?") {
+		return cond
+	}
+	line := p.tok().line
+	p.pos++
+	then := p.expr()
+	p.expect(":")
+	els := p.conditional()
+
+	n := &node{kind: ndCond, cond: cond, then: then, els: els, line: line}
+	switch {
+	case then.ty.kind == tyVoid || els.ty.kind == tyVoid:
+		n.ty = tyVoidT
+	case then.ty.hasBase() && then.ty.kind != tyFunc:
+		n.ty = pointerTo(then.ty.base)
+	case els.ty.hasBase():
+		n.ty = pointerTo(els.ty.base)
+	case then.ty.kind == tyStruct:
+		p.errorf("struct values in ?: are not supported")
+	default:
+		n.ty = arithType(then.ty, els.ty)
+		n.then, n.els = p.cast(then, n.ty), p.cast(els, n.ty)
+	}
+	return n
+}
+
+func (p *parser) logOr() *node {
+	n := p.logAnd()
+	for p.peek("||") {
+		line := p.tok().line
+		p.pos++
+		n = &node{kind: ndLogOr, lhs: n, rhs: p.logAnd(), ty: tyIntT, line: line}
+	}
+	return n
+}
+
+func (p *parser) logAnd() *node {
+	n := p.bitOr()
+	for p.peek("&&") {
+		line := p.tok().line
+		p.pos++
+		n = &node{kind: ndLogAnd, lhs: n, rhs: p.bitOr(), ty: tyIntT, line: line}
+	}
+	return n
+}
+
+func (p *parser) bitOr() *node {
+	n := p.bitXor()
+	for p.consume("|") {
+		n = p.binary(ndBitOr, n, p.bitXor())
+	}
+	return n
+}
+
+func (p *parser) bitXor() *node {
+	n := p.bitAnd()
+	for p.consume("^") {
+		n = p.binary(ndBitXor, n, p.bitAnd())
+	}
+	return n
+}
+
+func (p *parser) bitAnd() *node {
+	n := p.equality()
+	for p.consume("&") {
+		n = p.binary(ndBitAnd, n, p.equality())
+	}
+	return n
+}
+
+func (p *parser) equality() *node {
+	n := p.relational()
+	for {
+		switch {
+		case p.consume("=="):
+			n = p.binary(ndEq, n, p.relational())
+		case p.consume("!="):
+			n = p.binary(ndNe, n, p.relational())
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) relational() *node {
+	n := p.shift()
+	for {
+		switch {
+		case p.consume("<"):
+			n = p.binary(ndLt, n, p.shift())
+		case p.consume("<="):
+			n = p.binary(ndLe, n, p.shift())
+		case p.consume(">"):
+			n = p.binary(ndLt, p.shift(), n)
+		case p.consume(">="):
+			n = p.binary(ndLe, p.shift(), n)
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) shift() *node {
+	n := p.additive()
+	for {
+		switch {
+		case p.consume("<<"):
+			n = p.binary(ndShl, n, p.additive())
+		case p.consume(">>"):
+			n = p.binary(ndShr, n, p.additive())
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) additive() *node {
+	n := p.multiplicative()
+	for {
+		switch {
+		case p.consume("+"):
+			n = p.newAdd(n, p.multiplicative())
+		case p.consume("-"):
+			n = p.newSub(n, p.multiplicative())
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) multiplicative() *node {
+	n := p.castExpr()
+	for {
+		switch {
+		case p.consume("*"):
+			n = p.binary(ndMul, n, p.castExpr())
+		case p.consume("/"):
+			n = p.binary(ndDiv, n, p.castExpr())
+		case p.consume("%"):
+			n = p.binary(ndMod, n, p.castExpr())
+		default:
+			return n
+		}
+	}
+}
+
+// isCastStart reports "(" followed by a type name
+func (p *parser) isCastStart() bool {
+	if !p.peek("(") {
+		return false
+	}
+	p.pos++
+	ok := p.isTypeName()
+	p.pos--
+	return ok
+}
+
+func (p *parser) castExpr() *node {
+	if p.isCastStart() {
+		p.pos++
+		ty := p.typeName()
+		p.expect(")")
+		return p.cast(p.castExpr(), ty)
+	}
+	return p.unary()
+}
+
+// typeName parses an abstract type ("int *", "struct s [4]")
+func (p *parser) typeName() *ctype {
+	ty, _ := p.declarator(p.declspec(nil))
+	return ty
+}
+
+func (p *parser) unary() *node {
+	line := p.tok().line
+	switch {
+	case p.consume("+"):
+		return p.arith(p.castExpr())
+	case p.consume("-"):
+		e := p.arith(p.castExpr())
+		return &node{kind: ndNeg, lhs: e, ty: e.ty, line: line}
+	case p.consume("!"):
+		return &node{kind: ndNot, lhs: p.castExpr(), ty: tyIntT, line: line}
+	case p.consume("~"):
+		e := p.arith(p.castExpr())
+		return &node{kind: ndBitNot, lhs: e, ty: e.ty, line: line}
+	case p.consume("&"):
+		return p.addrOf(p.castExpr())
+	case p.consume("*"):
+		return p.deref(p.castExpr())
+	case p.consume("++"):
+		return p.compoundAssign(p.unary(), ndAdd, p.num(1))
+	case p.consume("--"):
+		return p.compoundAssign(p.unary(), ndSub, p.num(1))
+	case p.consume("sizeof"):
+		if p.isCastStart() {
+			p.pos++
+			ty := p.typeName()
+			p.expect(")")
+			return &node{kind: ndNum, val: int64(ty.size), ty: tyUIntT, line: line}
+		}
+		e := p.unary()
+		return &node{kind: ndNum, val: int64(e.ty.size), ty: tyUIntT, line: line}
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() *node {
+	n := p.primary()
+	for {
+		line := p.tok().line
+		switch {
+		case p.consume("("):
+			n = p.call(n)
+		case p.consume("["):
+			idx := p.expr()
+			p.expect("]")
+			n = p.deref(p.newAdd(n, idx))
+		case p.consume("."):
+			n = p.memberOf(n, p.ident())
+		case p.consume("->"):
+			n = p.memberOf(p.deref(n), p.ident())
+		case p.consume("++"):
+			n = p.postIncDec(n, 1, line)
+		case p.consume("--"):
+			n = p.postIncDec(n, -1, line)
+		default:
+			return n
+		}
+	}
+}
+
+// postIncDec lowers "A++" to "(typeof A)((A += 1) - 1)"
+func (p *parser) postIncDec(n *node, delta int64, line int) *node {
+	inc := p.compoundAssign(n, ndAdd, p.num(delta))
+	return p.cast(p.newAdd(inc, p.num(-delta)), n.ty)
+}
+
+func (p *parser) memberOf(n *node, name string) *node {
+	if n.ty.kind != tyStruct {
+		p.errorf("member access on a non-struct")
+	}
+	for _, m := range n.ty.members {
+		if m.name == name {
+			return &node{kind: ndMember, lhs: n, mem: m, ty: m.ty, line: n.line}
+		}
+	}
+	p.errorf("no member named %q", name)
+	return nil
+}
+
+// call parses arguments and builds a call node
+func (p *parser) call(fn *node) *node {
+	line := fn.line
+	fnTy := fn.ty
+	if fnTy.kind == tyPtr && fnTy.base.kind == tyFunc {
+		fnTy = fnTy.base
+	}
+	if fnTy.kind != tyFunc {
+		p.errorf("called object is not a function")
+	}
+
+	var args []*node
+	for !p.consume(")") {
+		if len(args) > 0 {
+			p.expect(",")
+		}
+		arg := p.assign()
+		i := len(args)
+		switch {
+		case i < len(fnTy.params):
+			if arg.ty.kind == tyStruct {
+				p.errorf("passing structs by value is not supported")
+			}
+			arg = p.cast(arg, fnTy.params[i])
+		case !fnTy.variadic && !fnTy.oldStyle:
+			p.errorf("too many arguments")
+		default:
+			// Default argument promotions: arrays and functions decay too
+			if arg.ty.kind == tyArray || arg.ty.kind == tyFunc {
+				arg = p.cast(arg, decay(arg.ty))
+			}
+			arg = p.arith(arg)
+		}
+		args = append(args, arg)
+	}
+	if len(args) < len(fnTy.params) {
+		p.errorf("too few arguments")
+	}
+	if len(args) > 8 {
+		p.errorf("more than 8 arguments")
+	}
+
+	n := &node{kind: ndCall, fnTy: fnTy, args: args, ty: fnTy.ret, line: line}
+	if fn.kind == ndVar && fn.v.ty.kind == tyFunc {
+		n.fnName = fn.v.label
+	} else {
+		n.lhs = fn
+	}
+	return n
+}
+
+func (p *parser) primary() *node {
+	t := p.tok()
+	line := t.line
+
+	switch {
+	case p.consume("("):
+		n := p.expr()
+		p.expect(")")
+		return n
+
+	case t.kind == tkNum:
+		p.pos++
+		ty := tyIntT
+		if t.unsigned {
+			ty = tyUIntT
+		}
+		return &node{kind: ndNum, val: t.val, ty: ty, line: line}
+
+	case t.kind == tkStr:
+		// Adjacent literals concatenate
+		var s []byte
+		for p.tok().kind == tkStr {
+			s = append(s, p.tok().str...)
+			p.pos++
+		}
+		return p.stringLiteral(s, line)
+
+	case p.consume("va_start"):
+		p.expect("(")
+		ap := p.assign()
+		p.expect(",")
+		p.assign()
+		p.expect(")")
+		if p.fn == nil || !p.fn.ty.variadic {
+			p.errorf("va_start outside a variadic function")
+		}
+		return &node{kind: ndVaStart, lhs: ap, ty: tyVoidT, line: line}
+
+	case p.consume("va_arg"):
+		// va_arg(ap, T) → *(T *)((ap += 4) - 4)
+		p.expect("(")
+		ap := p.assign()
+		p.expect(",")
+		ty := p.typeName()
+		p.expect(")")
+		next := p.newAdd(p.compoundAssign(ap, ndAdd, p.num(4)), p.num(-4))
+		return p.deref(p.cast(next, pointerTo(ty)))
+
+	case p.consume("va_end"):
+		p.expect("(")
+		p.assign()
+		p.expect(")")
+		return &node{kind: ndNum, ty: tyVoidT, line: line}
+
+	case t.kind == tkIdent:
+		p.pos++
+		v, _, ev, kind := p.lookup(t.text)
+		switch kind {
+		case 1:
+			return &node{kind: ndVar, v: v, ty: v.ty, line: line}
+		case 3:
+			return &node{kind: ndNum, val: ev, ty: tyIntT, line: line}
+		}
+		if p.peek("(") {
+			// Implicit declaration: int name()
+			fty := &ctype{kind: tyFunc, ret: tyIntT, size: 1, align: 1, oldStyle: true}
+			fn := p.declareFunc(t.text, fty, &declAttr{})
+			return &node{kind: ndVar, v: fn, ty: fn.ty, line: line}
+		}
+		p.pos--
+		p.errorf("undefined variable %q", t.text)
+	}
+	p.errorf("expression expected, got %q", t.text)
+	return nil
+}
+
+// stringLiteral makes an anonymous char array global
+func (p *parser) stringLiteral(s []byte, line int) *node {
+	p.strings++
+	ty := arrayOf(tyCharT, len(s)+1)
+	v := &cvar{ty: ty, label: fmt.Sprintf(".L.str.%d", p.strings), static: true, defined: true, hasInit: true}
+	v.init = append(append([]byte(nil), s...), 0)
+	p.globals = append(p.globals, v)
+	return &node{kind: ndVar, v: v, ty: ty, line: line}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Typing helpers
+// ───────────────────────────────────────────────────────────────────────────────
+
+// arithType is the usual arithmetic conversion for two integer types
+func arithType(a, b *ctype) *ctype {
+	if (a.kind == tyInt && a.unsigned) || (b.kind == tyInt && b.unsigned) || a.kind == tyPtr || b.kind == tyPtr {
+		return tyUIntT
+	}
+	return tyIntT
+}
+
+// arith applies integer promotion (char/short → int)
+func (p *parser) arith(n *node) *node {
+	if !n.ty.isInteger() {
+		if n.ty.kind == tyPtr {
+			return n
+		}
+		p.errorf("invalid operand of type %s", n.ty)
+	}
+	if n.ty.size < 4 {
+		return p.cast(n, tyIntT)
+	}
+	return n
+}
+
+// cast converts an expression to a type
+func (p *parser) cast(n *node, ty *ctype) *node {
+	if ty.kind == tyVoid {
+		return &node{kind: ndCast, lhs: n, ty: ty, line: n.line}
+	}
+	if n.ty.kind == tyStruct || ty.kind == tyStruct {
+		if n.ty == ty {
+			return n
+		}
+		p.errorf("cannot convert %s to %s", n.ty, ty)
+	}
+	if n.ty.kind == tyVoid {
+		p.errorf("void value used")
+	}
+	return &node{kind: ndCast, lhs: n, ty: ty, line: n.line}
+}
+
+// binary builds an arithmetic/comparison node with usual conversions
+func (p *parser) binary(kind ndKind, lhs, rhs *node) *node {
+	line := lhs.line
+	switch kind {
+	case ndAdd:
+		return p.newAdd(lhs, rhs)
+	case ndSub:
+		return p.newSub(lhs, rhs)
+	case ndEq, ndNe, ndLt, ndLe:
+		if lhs.ty.hasBase() || rhs.ty.hasBase() {
+			// Pointer comparisons are unsigned
+			return &node{kind: kind, lhs: p.cast(lhs, tyUIntT), rhs: p.cast(rhs, tyUIntT), ty: tyIntT, line: line}
+		}
+		lhs, rhs = p.arith(lhs), p.arith(rhs)
+		ty := arithType(lhs.ty, rhs.ty)
+		return &node{kind: kind, lhs: p.cast(lhs, ty), rhs: p.cast(rhs, ty), ty: tyIntT, line: line}
+	case ndShl, ndShr:
+		lhs = p.arith(lhs)
+		return &node{kind: kind, lhs: lhs, rhs: p.arith(rhs), ty: lhs.ty, line: line}
+	}
+	lhs, rhs = p.arith(lhs), p.arith(rhs)
+	ty := arithType(lhs.ty, rhs.ty)
+	return &node{kind: kind, lhs: p.cast(lhs, ty), rhs: p.cast(rhs, ty), ty: ty, line: line}
+}
+
+// newAdd handles int+int, ptr+int and int+ptr (scaled)
+func (p *parser) newAdd(lhs, rhs *node) *node {
+	line := lhs.line
+	if lhs.ty.isInteger() && rhs.ty.isInteger() {
+		lhs, rhs = p.arith(lhs), p.arith(rhs)
+		ty := arithType(lhs.ty, rhs.ty)
+		return &node{kind: ndAdd, lhs: p.cast(lhs, ty), rhs: p.cast(rhs, ty), ty: ty, line: line}
+	}
+	if lhs.ty.isInteger() {
+		lhs, rhs = rhs, lhs
+	}
+	if !lhs.ty.hasBase() || !rhs.ty.isInteger() {
+		p.errorf("invalid operands to +")
+	}
+	scaled := p.binary(ndMul, p.cast(rhs, tyIntT), p.num(int64(lhs.ty.base.size)))
+	return &node{kind: ndAdd, lhs: lhs, rhs: scaled, ty: pointerTo(lhs.ty.base), line: line}
+}
+
+// newSub handles int-int, ptr-int and ptr-ptr (element count)
+func (p *parser) newSub(lhs, rhs *node) *node {
+	line := lhs.line
+	if lhs.ty.isInteger() && rhs.ty.isInteger() {
+		lhs, rhs = p.arith(lhs), p.arith(rhs)
+		ty := arithType(lhs.ty, rhs.ty)
+		return &node{kind: ndSub, lhs: p.cast(lhs, ty), rhs: p.cast(rhs, ty), ty: ty, line: line}
+	}
+	if lhs.ty.hasBase() && rhs.ty.isInteger() {
+		scaled := p.binary(ndMul, p.cast(rhs, tyIntT), p.num(int64(lhs.ty.base.size)))
+		return &node{kind: ndSub, lhs: lhs, rhs: scaled, ty: pointerTo(lhs.ty.base), line: line}
+	}
+	if lhs.ty.hasBase() && rhs.ty.hasBase() {
+		diff := &node{kind: ndSub, lhs: lhs, rhs: rhs, ty: tyIntT, line: line}
+		return &node{kind: ndDiv, lhs: diff, rhs: p.num(int64(lhs.ty.base.size)), ty: tyIntT, line: line}
+	}
+	p.errorf("invalid operands to -")
+	return nil
+}
+
+func (p *parser) newAssign(lhs, rhs *node) *node {
+	p.checkLvalue(lhs)
+	if lhs.ty.kind == tyArray {
+		p.errorf("assignment to an array")
+	}
+	if lhs.ty.kind != tyStruct {
+		rhs = p.cast(rhs, lhs.ty)
+	} else if rhs.ty != lhs.ty {
+		p.errorf("incompatible struct assignment")
+	}
+	return &node{kind: ndAssign, lhs: lhs, rhs: rhs, ty: lhs.ty, line: lhs.line}
+}
+
+func (p *parser) checkLvalue(n *node) {
+	switch n.kind {
+	case ndVar, ndDeref, ndMember:
+		if n.kind == ndVar && n.v.ty.kind == tyFunc {
+			p.errorf("function used as a value")
+		}
+		return
+	}
+	p.errorf("not an lvalue")
+}
+
+func (p *parser) addrOf(n *node) *node {
+	if n.kind == ndVar && n.v.ty.kind == tyFunc {
+		return &node{kind: ndAddr, lhs: n, ty: pointerTo(n.ty), line: n.line}
+	}
+	p.checkLvalue(n)
+	ty := pointerTo(n.ty)
+	if n.ty.kind == tyArray {
+		ty = pointerTo(n.ty.base)
+	}
+	return &node{kind: ndAddr, lhs: n, ty: ty, line: n.line}
+}
+
+func (p *parser) deref(n *node) *node {
+	switch {
+	case n.ty.kind == tyFunc:
+		return n
+	case n.ty.kind == tyPtr && n.ty.base.kind == tyFunc:
+		return n
+	case !n.ty.hasBase():
+		p.errorf("dereferencing a non-pointer")
+	case n.ty.base.kind == tyVoid:
+		p.errorf("dereferencing a void pointer")
+	}
+	return &node{kind: ndDeref, lhs: n, ty: n.ty.base, line: n.line}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Constant evaluation
+// ───────────────────────────────────────────────────────────────────────────────
+
+func (p *parser) constExpr() int64 {
+	val, label := p.evalReloc(p.conditional())
+	if label != "" {
+		p.errorf("constant expression expected")
+	}
+	return val
+}
+
+// evalReloc evaluates a constant, possibly "address of label + offset"
+func (p *parser) evalReloc(n *node) (int64, string) {
+	bin := func(f func(a, b int64) int64) (int64, string) {
+		a, la := p.evalReloc(n.lhs)
+		b, lb := p.evalReloc(n.rhs)
+		if lb != "" || (la != "" && n.kind != ndAdd && n.kind != ndSub) {
+			p.errorf("not a constant expression")
+		}
+		return f(a, b), la
+	}
+	unsigned := n.ty != nil && n.ty.unsigned
+	switch n.kind {
+	case ndNum:
+		return n.val, ""
+	case ndAdd:
+		return bin(func(a, b int64) int64 { return int64(int32(a + b)) })
+	case ndSub:
+		return bin(func(a, b int64) int64 { return int64(int32(a - b)) })
+	case ndMul:
+		return bin(func(a, b int64) int64 { return int64(int32(a * b)) })
+	case ndDiv, ndMod:
+		return bin(func(a, b int64) int64 {
+			if b == 0 {
+				p.errorf("division by zero in a constant")
+			}
+			if unsigned {
+				a, b = int64(uint32(a)), int64(uint32(b))
+			}
+			if n.kind == ndDiv {
+				return int64(int32(a / b))
+			}
+			return int64(int32(a % b))
+		})
+	case ndBitAnd:
+		return bin(func(a, b int64) int64 { return a & b })
+	case ndBitOr:
+		return bin(func(a, b int64) int64 { return a | b })
+	case ndBitXor:
+		return bin(func(a, b int64) int64 { return a ^ b })
+	case ndShl:
+		return bin(func(a, b int64) int64 { return int64(int32(a << uint(b&31))) })
+	case ndShr:
+		return bin(func(a, b int64) int64 {
+			if unsigned {
+				return int64(uint32(a) >> uint(b&31))
+			}
+			return int64(int32(a) >> uint(b&31))
+		})
+	case ndEq, ndNe, ndLt, ndLe:
+		a, _ := p.evalReloc(n.lhs)
+		b, _ := p.evalReloc(n.rhs)
+		if n.lhs.ty.unsigned {
+			a, b = int64(uint32(a)), int64(uint32(b))
+		} else {
+			a, b = int64(int32(a)), int64(int32(b))
+		}
+		r := map[ndKind]bool{ndEq: a == b, ndNe: a != b, ndLt: a < b, ndLe: a <= b}[n.kind]
+		if r {
+			return 1, ""
+		}
+		return 0, ""
+	case ndLogAnd, ndLogOr:
+		a, _ := p.evalReloc(n.lhs)
+		b, _ := p.evalReloc(n.rhs)
+		if (n.kind == ndLogAnd && a != 0 && b != 0) || (n.kind == ndLogOr && (a != 0 || b != 0)) {
+			return 1, ""
+		}
+		return 0, ""
+	case ndNot:
+		a, _ := p.evalReloc(n.lhs)
+		if a == 0 {
+			return 1, ""
+		}
+		return 0, ""
+	case ndNeg:
+		a, _ := p.evalReloc(n.lhs)
+		return int64(int32(-a)), ""
+	case ndBitNot:
+		a, _ := p.evalReloc(n.lhs)
+		return ^a, ""
+	case ndCond:
+		c, _ := p.evalReloc(n.cond)
+		if c != 0 {
+			return p.evalReloc(n.then)
+		}
+		return p.evalReloc(n.els)
+	case ndCast:
+		v, label := p.evalReloc(n.lhs)
+		if label != "" || !n.ty.isInteger() {
+			return v, label
+		}
+		switch {
+		case n.ty.size == 1 && n.ty.unsigned:
+			return int64(uint8(v)), ""
+		case n.ty.size == 1:
+			return int64(int8(v)), ""
+		case n.ty.size == 2 && n.ty.unsigned:
+			return int64(uint16(v)), ""
+		case n.ty.size == 2:
+			return int64(int16(v)), ""
+		case n.ty.unsigned:
+			return int64(uint32(v)), ""
+		}
+		return int64(int32(v)), ""
+	case ndAddr:
+		return p.evalAddr(n.lhs)
+	case ndVar:
+		if !n.v.local && (n.ty.kind == tyArray || n.ty.kind == tyFunc) {
+			return 0, n.v.label
+		}
+	case ndDeref, ndMember:
+		if n.ty.kind == tyArray {
+			return p.evalAddr(n)
+		}
+	}
+	p.errorf("not a constant expression")
+	return 0, ""
+}
+
+// evalAddr evaluates the address of a global lvalue
+func (p *parser) evalAddr(n *node) (int64, string) {
+	switch n.kind {
+	case ndVar:
+		if n.v.local {
+			break
+		}
+		return 0, n.v.label
+	case ndDeref:
+		return p.evalReloc(n.lhs)
+	case ndMember:
+		v, label := p.evalAddr(n.lhs)
+		return v + int64(n.mem.offset), label
+	}
+	p.errorf("not a constant address")
+	return 0, ""
+}
@@ -0,0 +1,265 @@
+package suprax32
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX C Compiler - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A compiler is only right if the code it emits computes the right answer. Each test
+// compiles a small C program, links it behind a bare start stub, runs it on the
+// out-of-order core and checks the value main returns. Programs are built so that a
+// wrong offset, a lost register or a bad branch changes the result.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. LANGUAGE TESTS
+//    Structs, pointers, recursion, switch, function pointers
+//
+// 2. ERROR TESTS
+//    Diagnostics carry the file and line
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ccStart calls main with a 16-byte aligned stack and stores its return value
+const ccStart = `
+	.text
+	.globl _start
+_start:
+	li    r2, 0x80000
+	call  main
+	la    r5, ccResult
+	sw    r4, 0(r5)
+	li    r5, 1
+	la    r6, ccDone
+	sw    r5, 0(r6)
+halt:
+	j     halt
+
+	.data
+	.globl ccResult, ccDone
+ccResult: .word 0
+ccDone:   .word 0
+`
+
+// runCompiled compiles src, runs main on a core and returns main's result
+func runCompiled(t *testing.T, src string) int32 {
+	t.Helper()
+	obj, err := CompileObject("test.c", src)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	start, err := Assemble("start.s", ccStart)
+	if err != nil {
+		t.Fatalf("assemble start: %v", err)
+	}
+	img, err := Link([]*Object{start, obj}, nil)
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	core := NewCore(1 << 20)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	done := img.Symbols["ccDone"]
+	for cycles := uint64(2000); cycles <= 400000; cycles += 2000 {
+		core.Run(cycles)
+		if binary.LittleEndian.Uint32(core.ReadMem(done, 4)) == 1 {
+			return int32(binary.LittleEndian.Uint32(core.ReadMem(img.Symbols["ccResult"], 4)))
+		}
+	}
+	t.Fatalf("main did not return within 400000 cycles (pc 0x%x)", core.archPC)
+	return 0
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. LANGUAGE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestCC_Structs(t *testing.T) {
+	// WHAT: Struct members of mixed widths, nested structs, arrays of structs and
+	//       member access through pointers all read back what was written
+	// WHY: Member offsets and alignment are computed by the compiler alone; one wrong
+	//      offset overlaps two members
+	// HARDWARE: Narrow and word loads/stores (INNOVATION #69)
+	// CATEGORY: [INTEGRATION]
+
+	got := runCompiled(t, `
+struct point { char tag; short x; int y; };
+struct box { struct point lo, hi; int area; };
+
+int fill(struct box *b) {
+	b->area = (b->hi.x - b->lo.x) * (b->hi.y - b->lo.y);
+	return b->area;
+}
+
+int main() {
+	struct box boxes[3];
+	int i, sum = 0;
+	for (i = 0; i < 3; i++) {
+		boxes[i].lo.tag = 'a' + i;
+		boxes[i].lo.x = -i;
+		boxes[i].lo.y = i;
+		boxes[i].hi.x = 10 + i;
+		boxes[i].hi.y = 20 + i;
+		sum += fill(&boxes[i]);
+	}
+	return sum * 1000 + boxes[2].lo.tag + sizeof(struct point);
+}
+`)
+	// Areas: 10*20, 12*20, 14*20 = 720; 'c' = 99; sizeof(struct point) = 8
+	if want := int32(720*1000 + 99 + 8); got != want {
+		t.Errorf("main() = %d, expected %d", got, want)
+	}
+}
+
+func TestCC_Pointers(t *testing.T) {
+	// WHAT: Pointer arithmetic scales by the pointee size, pointers to pointers write
+	//       through, and char pointers walk a string
+	// WHY: Scaling is a per-type multiply the compiler inserts; a missing one only
+	//      shows up past the first element
+	// HARDWARE: Load/store address generation
+	// CATEGORY: [INTEGRATION]
+
+	got := runCompiled(t, `
+int table[5] = {1, 2, 3, 4, 5};
+char *msg = "hello";
+
+void swap(int *a, int *b) { int t = *a; *a = *b; *b = t; }
+void set(int **pp, int *target) { *pp = target; }
+
+int main() {
+	int *p = table + 1;
+	int *q;
+	char *s = msg;
+	int n = 0;
+	swap(p, &table[4]);   /* table = 1 5 3 4 2 */
+	set(&q, p + 2);       /* q = &table[3] */
+	*q += 10;             /* table = 1 5 3 14 2 */
+	while (*s++) n++;
+	return table[1] * 10000 + table[3] * 100 + table[4] * 10 + n + (q - table);
+}
+`)
+	// 5*10000 + 14*100 + 2*10 + 5 + 3
+	if want := int32(51428); got != want {
+		t.Errorf("main() = %d, expected %d", got, want)
+	}
+}
+
+func TestCC_Recursion(t *testing.T) {
+	// WHAT: Recursive functions with live temporaries across calls return the right values
+	// WHY: Every call must save the return address, frame pointer and the expression
+	//      temporaries still in use; recursion loses any one of them immediately
+	// HARDWARE: JAL/JALR call and return through the RSB (INNOVATION #31)
+	// CATEGORY: [INTEGRATION]
+
+	got := runCompiled(t, `
+int fib(int n) { return n < 2 ? n : fib(n - 1) + fib(n - 2); }
+int ack(int m, int n) {
+	if (m == 0) return n + 1;
+	if (n == 0) return ack(m - 1, 1);
+	return ack(m - 1, ack(m, n - 1));
+}
+int main() { return fib(12) * 100 + ack(2, 3); }
+`)
+	// fib(12) = 144, ack(2, 3) = 9
+	if want := int32(14409); got != want {
+		t.Errorf("main() = %d, expected %d", got, want)
+	}
+}
+
+func TestCC_Switch(t *testing.T) {
+	// WHAT: Switch dispatches to matching cases, falls through without break, and takes
+	//       default otherwise, including negative case values
+	// WHY: Case labels, fallthrough and break targets are all compiler-generated branches
+	// HARDWARE: Conditional branches (INNOVATION #29)
+	// CATEGORY: [INTEGRATION]
+
+	got := runCompiled(t, `
+int classify(int v) {
+	int r = 0;
+	switch (v) {
+	case -1: r = 7; break;
+	case 0:
+	case 1: r += 1;
+	case 2: r += 10; break;
+	default: r = 100;
+	}
+	return r;
+}
+int main() {
+	return classify(-1) + classify(0) * 10 + classify(2) * 1000 + classify(5) * 10000;
+}
+`)
+	// 7 + 11*10 + 10*1000 + 100*10000
+	if want := int32(1010117); got != want {
+		t.Errorf("main() = %d, expected %d", got, want)
+	}
+}
+
+func TestCC_FunctionPointers(t *testing.T) {
+	// WHAT: Calls through function pointers held in variables, arrays and struct members
+	// WHY: An indirect call is a jalr through a register other than r1; the return
+	//      address must still land in r1 for the callee's ret
+	// HARDWARE: JALR (INNOVATION #33: no BTB, indirect calls mispredict)
+	// CATEGORY: [INTEGRATION]
+
+	got := runCompiled(t, `
+int add(int a, int b) { return a + b; }
+int sub(int a, int b) { return a - b; }
+int mul(int a, int b) { return a * b; }
+
+struct op { int (*fn)(int, int); int arg; };
+
+int apply(int (*f)(int, int), int a, int b) { return f(a, b); }
+
+int main() {
+	int (*ops[3])(int, int) = {add, sub, mul};
+	struct op o;
+	int i, acc = 1;
+	for (i = 0; i < 3; i++)
+		acc = ops[i](acc, i + 2);   /* (1+2)=3, (3-3)=0, (0*4)=0 */
+	o.fn = add;
+	o.arg = 40;
+	return acc + o.fn(o.arg, 2) + apply(mul, 6, 7) * 100;
+}
+`)
+	if want := int32(42 + 4200); got != want {
+		t.Errorf("main() = %d, expected %d", got, want)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. ERROR TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestCC_ErrorsNameFileAndLine(t *testing.T) {
+	// WHAT: Undeclared identifiers and syntax errors are reported with file:line
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	cases := []struct{ src, want string }{
+		{"int main() {\n\treturn x;\n}\n", "bad.c:2"},
+		{"int main() {\n\tint a = ;\n}\n", "bad.c:2"},
+	}
+	for _, c := range cases {
+		_, err := Compile("bad.c", c.src)
+		if err == nil {
+			t.Errorf("%q compiled, expected an error", c.src)
+			continue
+		}
+		if got := err.Error(); len(got) < len(c.want) || got[:len(c.want)] != c.want {
+			t.Errorf("error %q, expected it to start with %q", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,731 @@
+package suprax32
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// C COMPILER (SUBSET) — CODE GENERATOR
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// Walks the typed AST from cc.go and writes assembly for asm.go.
+//
+// CALLING CONVENTION: The ABI in abi.go. Arguments are words in a0-a7
+//
+//	(chars and shorts already extended to their type), at most 8;
+//	structs are passed by pointer only. Frames come from the assembler's
+//	prologue/epilogue with no saved registers, since the compiler keeps
+//	values only in temporaries, which calls do not preserve anyway.
+//
+// STACK FRAME (grows down):
+//
+//	        ┌──────────────────┐ ← caller's sp = fp
+//	fp-4    │ saved ra         │
+//	fp-8    │ saved fp         │
+//	        │ locals, spilled  │ (variadic functions: a0-a7 side by side
+//	        │ parameters       │  so va_arg can walk them)
+//	        ├──────────────────┤ ← sp (fp - frame size, 16-byte aligned)
+//	        │ saved temporaries│ (pushed around calls)
+//	        └──────────────────┘
+//
+// EXPRESSIONS: A register stack over the ten temporaries. Every
+//
+//	expression pushes its value; binary operators pop two and push one.
+//	Live temporaries are stored below sp around each call. a6/a7 serve
+//	as scratch inside single expansions, since argument registers
+//	are only loaded immediately before a jal.
+//
+// MINECRAFT ANALOGY: The crafting table itself. The guide book said what to
+//
+//	make; this is where items actually move between slots.
+
+// ccTemps are the expression stack registers (t0-t9), bottom first
+var ccTemps = []uint8{12, 13, 14, 15, 16, 17, 18, 19, 30, 31}
+
+const (
+	ccArg0     = RegA0
+	ccScratch1 = RegA0 + 6 // a6, free outside call setup
+	ccScratch2 = RegA0 + 7 // a7
+)
+
+// Compile translates C source into SUPRAX-32 assembly
+func Compile(name, src string) (asm string, err error) {
+	globals, err := parseC(name, src)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ce, ok := r.(*ccError)
+			if !ok {
+				panic(r)
+			}
+			err = ce
+		}
+	}()
+
+	g := &codegen{file: name}
+	fmt.Fprintf(&g.out, "\t.file %q\n", name)
+	for _, v := range globals {
+		if v.ty.kind == tyFunc && v.defined {
+			g.function(v)
+		}
+	}
+	for _, v := range globals {
+		if v.ty.kind != tyFunc && v.defined {
+			g.data(v)
+		}
+	}
+	return g.out.String(), nil
+}
+
+// CompileObject compiles C source and assembles the result
+func CompileObject(name, src string) (*Object, error) {
+	asm, err := Compile(name, src)
+	if err != nil {
+		return nil, err
+	}
+	return Assemble(name+".s", asm)
+}
+
+// codegen holds the state of one Compile call
+type codegen struct {
+	file   string
+	out    strings.Builder
+	fn     *cvar
+	depth  int // Temporaries in use
+	labels int
+	line   int // Source line of the node being compiled (for errors)
+}
+
+func (g *codegen) errorf(format string, args ...any) {
+	panic(&ccError{g.file, g.line, fmt.Sprintf(format, args...)})
+}
+
+// emit writes one instruction line
+func (g *codegen) emit(format string, args ...any) {
+	g.out.WriteByte('\t')
+	fmt.Fprintf(&g.out, format, args...)
+	g.out.WriteByte('\n')
+}
+
+func (g *codegen) label(l string) {
+	g.out.WriteString(l + ":\n")
+}
+
+func (g *codegen) newLabel() string {
+	g.labels++
+	return fmt.Sprintf(".Lcg%d", g.labels)
+}
+
+// push claims the next temporary and returns its name
+func (g *codegen) push() string {
+	if g.depth == len(ccTemps) {
+		g.errorf("expression too complex (more than %d temporaries)", len(ccTemps))
+	}
+	g.depth++
+	return g.top()
+}
+
+func (g *codegen) pop() { g.depth-- }
+
+// top and below name the top two temporaries
+func (g *codegen) top() string   { return reg(ccTemps[g.depth-1]) }
+func (g *codegen) below() string { return reg(ccTemps[g.depth-2]) }
+
+func reg(r uint8) string { return ABIRegName(r) }
+
+// fits17 reports whether v fits a signed 17-bit immediate
+func fits17(v int64) bool { return v >= -(1<<16) && v < 1<<16 }
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Functions
+// ───────────────────────────────────────────────────────────────────────────────
+
+// function emits one function definition
+//
+// ALGORITHM:
+//
+//	STEP 1: Prologue: allocate the frame, save ra and fp, fp = old sp
+//	        (prologue pseudo-instruction, see Object.EmitPrologue)
+//	STEP 2: Spill parameters from a0-a7 to their frame slots
+//	STEP 3: Body
+//	STEP 4: Epilogue (every return jumps here): restore ra, sp and fp
+func (g *codegen) function(fn *cvar) {
+	g.fn, g.depth = fn, 0
+	// Locals are addressed as fp+offset, so offsets must fit an immediate
+	if !fits17(int64(8 + fn.frame)) {
+		g.line = fn.body.line
+		g.errorf("%s: %d bytes of locals is too many", fn.name, fn.frame)
+	}
+
+	g.out.WriteString("\n\t.text\n")
+	if !fn.static {
+		g.emit(".globl %s", fn.label)
+	}
+	g.label(fn.label)
+
+	// STEP 1: Prologue (no saved registers: see the header)
+	g.emit("prologue %d", fn.frame)
+
+	// STEP 2: Parameters
+	if fn.ty.variadic {
+		for i := 0; i < 8; i++ {
+			g.emit("sw %s, %d(fp)", reg(uint8(ccArg0+i)), fn.vaArea+4*i)
+		}
+	} else {
+		for i, p := range fn.params {
+			g.store(p.ty, reg(uint8(ccArg0+i)), "fp", p.offset)
+		}
+	}
+
+	// STEP 3: Body
+	g.stmt(fn.body)
+	if g.depth != 0 {
+		panic(fmt.Sprintf("ccgen: %s: %d temporaries left on the stack", fn.name, g.depth))
+	}
+
+	// STEP 4: Epilogue
+	g.label(".Lret_" + fn.label)
+	g.emit("epilogue")
+	g.fn = nil
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Statements
+// ───────────────────────────────────────────────────────────────────────────────
+
+func (g *codegen) stmt(n *node) {
+	g.line = n.line
+	switch n.kind {
+	case ndReturn:
+		if n.lhs != nil {
+			g.expr(n.lhs)
+			g.emit("mv %s, %s", reg(ccArg0), g.top())
+			g.pop()
+		}
+		g.emit("j .Lret_%s", g.fn.label)
+
+	case ndIf:
+		els, end := g.newLabel(), g.newLabel()
+		g.cond(n.cond, els)
+		g.stmt(n.then)
+		if n.els != nil {
+			g.emit("j %s", end)
+			g.label(els)
+			g.stmt(n.els)
+		} else {
+			g.label(els)
+		}
+		g.label(end)
+
+	case ndFor:
+		// while loops are for loops without init and inc
+		begin := g.newLabel()
+		if n.init != nil {
+			g.stmt(n.init)
+		}
+		g.label(begin)
+		if n.cond != nil {
+			g.cond(n.cond, n.brk)
+		}
+		g.stmts(n.body)
+		g.label(n.cont)
+		if n.inc != nil {
+			g.discard(n.inc)
+		}
+		g.emit("j %s", begin)
+		g.label(n.brk)
+
+	case ndDo:
+		begin := g.newLabel()
+		g.label(begin)
+		g.stmts(n.body)
+		g.label(n.cont)
+		g.expr(n.cond)
+		g.emit("bnez %s, %s", g.top(), begin)
+		g.pop()
+		g.label(n.brk)
+
+	case ndSwitch:
+		// Compare chain: small switches are the common case and a jump
+		// table would need an indirect branch the predictor rarely gets
+		g.expr(n.cond)
+		v := g.top()
+		for _, c := range n.cases {
+			if c.val == 0 {
+				g.emit("beqz %s, %s", v, c.label)
+				continue
+			}
+			g.emit("li %s, %d", reg(ccScratch1), int32(c.val))
+			g.emit("beq %s, %s, %s", v, reg(ccScratch1), c.label)
+		}
+		g.pop()
+		if n.dflt != nil {
+			g.emit("j %s", n.dflt.label)
+		} else {
+			g.emit("j %s", n.brk)
+		}
+		g.stmt(n.then)
+		g.label(n.brk)
+
+	case ndCase:
+		g.label(n.label)
+		if n.lhs != nil {
+			g.stmt(n.lhs)
+		}
+
+	case ndBlock:
+		g.stmts(n.body)
+
+	case ndBreak:
+		g.emit("j %s", n.brk)
+
+	case ndContinue:
+		g.emit("j %s", n.cont)
+
+	case ndExprStmt:
+		g.discard(n.lhs)
+
+	case ndMemZero:
+		g.memZero(n.v)
+
+	default:
+		g.errorf("unexpected statement")
+	}
+}
+
+func (g *codegen) stmts(list []*node) {
+	for _, s := range list {
+		g.stmt(s)
+	}
+}
+
+// discard evaluates an expression for its side effects
+func (g *codegen) discard(n *node) {
+	g.expr(n)
+	g.pop()
+}
+
+// cond evaluates a condition and branches to target if it is false
+func (g *codegen) cond(n *node, target string) {
+	g.expr(n)
+	g.emit("beqz %s, %s", g.top(), target)
+	g.pop()
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Expressions
+// ───────────────────────────────────────────────────────────────────────────────
+
+// constVal returns the value of an integer constant, looking through
+// conversions that do not change it
+func constVal(n *node) (int64, bool) {
+	for n.kind == ndCast && n.ty.size == 4 && n.lhs.ty.isInteger() {
+		n = n.lhs
+	}
+	if n.kind != ndNum {
+		return 0, false
+	}
+	return int64(int32(n.val)), true
+}
+
+// aggregate reports whether values of the type are represented by address
+func aggregate(t *ctype) bool {
+	return t.kind == tyArray || t.kind == tyStruct || t.kind == tyFunc
+}
+
+// addr pushes the address of an lvalue
+func (g *codegen) addr(n *node) {
+	g.line = n.line
+	switch n.kind {
+	case ndVar:
+		r := g.push()
+		if n.v.local {
+			g.emit("addi %s, fp, %d", r, n.v.offset)
+		} else {
+			g.emit("la %s, %s", r, n.v.label)
+		}
+	case ndDeref:
+		g.expr(n.lhs)
+	case ndMember:
+		g.addr(n.lhs)
+		if n.mem.offset != 0 {
+			g.emit("addi %s, %s, %d", g.top(), g.top(), n.mem.offset)
+		}
+	default:
+		if n.ty.kind == tyStruct {
+			g.expr(n) // Struct values are addresses already
+			return
+		}
+		g.errorf("not an lvalue")
+	}
+}
+
+// expr pushes the value of an expression (void expressions push junk)
+func (g *codegen) expr(n *node) {
+	g.line = n.line
+	switch n.kind {
+	case ndNum:
+		g.emit("li %s, %d", g.push(), int32(n.val))
+
+	case ndVar, ndDeref, ndMember:
+		g.addr(n)
+		if !aggregate(n.ty) {
+			g.load(n.ty, g.top(), g.top(), 0)
+		}
+
+	case ndAddr:
+		g.addr(n.lhs)
+
+	case ndAssign:
+		g.expr(n.rhs)
+		g.addr(n.lhs)
+		if n.ty.kind == tyStruct {
+			g.copy(g.top(), g.below(), n.ty)
+		} else {
+			g.store(n.ty, g.below(), g.top(), 0)
+		}
+		g.pop()
+
+	case ndCast:
+		g.expr(n.lhs)
+		g.convert(n.lhs.ty, n.ty)
+
+	case ndComma:
+		g.discard(n.lhs)
+		g.expr(n.rhs)
+
+	case ndCond:
+		els, end := g.newLabel(), g.newLabel()
+		g.cond(n.cond, els)
+		g.expr(n.then)
+		g.pop()
+		g.emit("j %s", end)
+		g.label(els)
+		g.expr(n.els)
+		g.label(end)
+
+	case ndLogAnd, ndLogOr:
+		// Both operands branch to the same short-circuit label; the
+		// result register is the same whichever way we get there
+		short, end := g.newLabel(), g.newLabel()
+		skip := "beqz"
+		if n.kind == ndLogOr {
+			skip = "bnez"
+		}
+		g.expr(n.lhs)
+		g.emit("%s %s, %s", skip, g.top(), short)
+		g.pop()
+		g.expr(n.rhs)
+		r := g.top()
+		g.emit("%s %s, %s", skip, r, short)
+		g.emit("li %s, %d", r, map[bool]int{true: 1, false: 0}[n.kind == ndLogAnd])
+		g.emit("j %s", end)
+		g.label(short)
+		g.emit("li %s, %d", r, map[bool]int{true: 0, false: 1}[n.kind == ndLogAnd])
+		g.label(end)
+
+	case ndNot:
+		g.expr(n.lhs)
+		g.emit("sltu %s, zero, %s", g.top(), g.top())
+		g.emit("xori %s, %s, 1", g.top(), g.top())
+
+	case ndBitNot:
+		g.expr(n.lhs)
+		g.emit("not %s, %s", g.top(), g.top())
+
+	case ndNeg:
+		g.expr(n.lhs)
+		g.emit("neg %s, %s", g.top(), g.top())
+
+	case ndCall:
+		g.call(n)
+
+	case ndVaStart:
+		g.addr(n.lhs)
+		g.emit("addi %s, fp, %d", reg(ccScratch1), g.fn.vaArea+4*len(g.fn.params))
+		g.emit("sw %s, 0(%s)", reg(ccScratch1), g.top())
+
+	default:
+		g.binary(n)
+	}
+}
+
+// binary handles arithmetic, bitwise, shift and comparison operators
+func (g *codegen) binary(n *node) {
+	// Immediate forms for the common "x op constant" case
+	if v, ok := constVal(n.rhs); ok {
+		if n.kind == ndSub {
+			v = -v
+		}
+		op := map[ndKind]string{ndAdd: "addi", ndSub: "addi", ndBitAnd: "andi", ndBitOr: "ori", ndBitXor: "xori"}[n.kind]
+		if op != "" && fits17(v) {
+			g.expr(n.lhs)
+			g.emit("%s %s, %s, %d", op, g.top(), g.top(), v)
+			return
+		}
+	}
+
+	g.expr(n.lhs)
+	g.expr(n.rhs)
+	a, b := g.below(), g.top()
+	unsigned := n.lhs.ty.unsigned
+	switch n.kind {
+	case ndAdd, ndSub, ndMul, ndBitAnd, ndBitOr, ndBitXor, ndShl:
+		op := map[ndKind]string{ndAdd: "add", ndSub: "sub", ndMul: "mul",
+			ndBitAnd: "and", ndBitOr: "or", ndBitXor: "xor", ndShl: "sll"}[n.kind]
+		g.emit("%s %s, %s, %s", op, a, a, b)
+	case ndShr:
+		op := "sra"
+		if unsigned {
+			op = "srl"
+		}
+		g.emit("%s %s, %s, %s", op, a, a, b)
+	case ndDiv, ndMod:
+		g.divide(n.kind == ndDiv, unsigned || n.ty.unsigned, a, b)
+	case ndEq, ndNe:
+		g.emit("xor %s, %s, %s", a, a, b)
+		g.emit("sltu %s, zero, %s", a, a)
+		if n.kind == ndEq {
+			g.emit("xori %s, %s, 1", a, a)
+		}
+	case ndLt, ndLe:
+		op := "slt"
+		if unsigned {
+			op = "sltu"
+		}
+		if n.kind == ndLt {
+			g.emit("%s %s, %s, %s", op, a, a, b)
+		} else {
+			// a <= b is !(b < a)
+			g.emit("%s %s, %s, %s", op, a, b, a)
+			g.emit("xori %s, %s, 1", a, a)
+		}
+	default:
+		g.errorf("unexpected expression")
+	}
+	g.pop()
+}
+
+// divide emits a = a / b or a = a % b
+//
+// DIV and REM are unsigned. Signed operations divide the magnitudes and
+// fix the sign afterwards: the quotient is negative when the operand
+// signs differ, the remainder takes the dividend's sign (C truncation).
+func (g *codegen) divide(quotient, unsigned bool, a, b string) {
+	op := "rem"
+	if quotient {
+		op = "div"
+	}
+	if unsigned {
+		g.emit("%s %s, %s, %s", op, a, a, b)
+		return
+	}
+	s1, s2 := reg(ccScratch1), reg(ccScratch2)
+	g.emit("li %s, 31", s2)
+	g.emit("sra %s, %s, %s", s1, a, s2) // s1 = a < 0 ? -1 : 0
+	g.emit("sra %s, %s, %s", s2, b, s2) // s2 = b < 0 ? -1 : 0
+	g.emit("xor %s, %s, %s", a, a, s1)
+	g.emit("sub %s, %s, %s", a, a, s1) // a = |a|
+	g.emit("xor %s, %s, %s", b, b, s2)
+	g.emit("sub %s, %s, %s", b, b, s2) // b = |b|
+	g.emit("%s %s, %s, %s", op, a, a, b)
+	if quotient {
+		g.emit("xor %s, %s, %s", s1, s1, s2)
+	}
+	g.emit("xor %s, %s, %s", a, a, s1)
+	g.emit("sub %s, %s, %s", a, a, s1)
+}
+
+// convert changes the top value from one type to another
+//
+// Registers always hold values extended according to their type, so only
+// narrowing to char/short needs code.
+func (g *codegen) convert(from, to *ctype) {
+	if to.kind == tyVoid || !to.isInteger() || to.size == 4 {
+		return
+	}
+	if from.isInteger() && from.size <= to.size && (from.unsigned == to.unsigned || from.size < to.size && from.unsigned) {
+		return
+	}
+	r := g.top()
+	if to.unsigned {
+		g.emit("andi %s, %s, %d", r, r, 1<<(8*to.size)-1)
+		return
+	}
+	s := reg(ccScratch1)
+	g.emit("li %s, %d", s, 32-8*to.size)
+	g.emit("sll %s, %s, %s", r, r, s)
+	g.emit("sra %s, %s, %s", r, r, s)
+}
+
+// call emits a function call
+//
+// ALGORITHM:
+//
+//	STEP 1: Store the live temporaries below sp (16-byte aligned block)
+//	STEP 2: Evaluate the arguments (and the callee, for calls through
+//	        a pointer) from an empty register stack
+//	STEP 3: Move them to a0-a7 and jal (or jalr through the pointer)
+//	STEP 4: Reload the temporaries, push a0 as the result
+func (g *codegen) call(n *node) {
+	// STEP 1: Save live temporaries
+	live := g.depth
+	save := int(alignUp(uint32(4*live), StackAlign))
+	if live > 0 {
+		g.emit("addi sp, sp, %d", -save)
+		for i := 0; i < live; i++ {
+			g.emit("sw %s, %d(sp)", reg(ccTemps[i]), 4*i)
+		}
+	}
+	g.depth = 0
+
+	// STEP 2: Arguments, then the callee
+	for _, arg := range n.args {
+		g.expr(arg)
+	}
+	if n.fnName == "" {
+		g.expr(n.lhs)
+	}
+
+	// STEP 3: Call
+	for i := range n.args {
+		g.emit("mv %s, %s", reg(uint8(ccArg0+i)), reg(ccTemps[i]))
+	}
+	if n.fnName == "" {
+		g.emit("jalr ra, 0(%s)", g.top())
+	} else {
+		g.emit("call %s", n.fnName)
+	}
+
+	// STEP 4: Restore and push the result
+	g.depth = live
+	if live > 0 {
+		for i := 0; i < live; i++ {
+			g.emit("lw %s, %d(sp)", reg(ccTemps[i]), 4*i)
+		}
+		g.emit("addi sp, sp, %d", save)
+	}
+	g.emit("mv %s, %s", g.push(), reg(ccArg0))
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Memory
+// ───────────────────────────────────────────────────────────────────────────────
+
+// load reads a value of type ty from off(base) into dst
+func (g *codegen) load(ty *ctype, dst, base string, off int) {
+	op := "lw"
+	switch {
+	case ty.size == 1 && ty.unsigned:
+		op = "lbu"
+	case ty.size == 1:
+		op = "lb"
+	case ty.size == 2 && ty.unsigned:
+		op = "lhu"
+	case ty.size == 2:
+		op = "lh"
+	}
+	g.emit("%s %s, %d(%s)", op, dst, off, base)
+}
+
+// store writes src as a value of type ty to off(base)
+func (g *codegen) store(ty *ctype, src, base string, off int) {
+	op := map[int]string{1: "sb", 2: "sh", 4: "sw"}[ty.size]
+	if op == "" || !ty.isScalar() {
+		g.errorf("cannot store a value of type %s", ty)
+	}
+	g.emit("%s %s, %d(%s)", op, src, off, base)
+}
+
+// copy copies a struct from src to dst (both addresses)
+func (g *codegen) copy(dst, src string, ty *ctype) {
+	s := reg(ccScratch1)
+	step, ld, st := 1, "lbu", "sb"
+	if ty.align >= 4 && ty.size%4 == 0 {
+		step, ld, st = 4, "lw", "sw"
+	}
+	for off := 0; off < ty.size; off += step {
+		g.emit("%s %s, %d(%s)", ld, s, off, src)
+		g.emit("%s %s, %d(%s)", st, s, off, dst)
+	}
+}
+
+// memZero zeroes a local variable before its initializer runs
+func (g *codegen) memZero(v *cvar) {
+	step, st := 1, "sb"
+	if v.ty.align >= 4 && v.ty.size%4 == 0 {
+		step, st = 4, "sw"
+	}
+	if v.ty.size <= 16*step {
+		for off := 0; off < v.ty.size; off += step {
+			g.emit("%s zero, %d(fp)", st, v.offset+off)
+		}
+		return
+	}
+	loop, p, end := g.newLabel(), reg(ccScratch1), reg(ccScratch2)
+	g.emit("addi %s, fp, %d", p, v.offset)
+	g.emit("addi %s, fp, %d", end, v.offset+v.ty.size)
+	g.label(loop)
+	g.emit("%s zero, 0(%s)", st, p)
+	g.emit("addi %s, %s, %d", p, p, step)
+	g.emit("bne %s, %s, %s", p, end, loop)
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Data
+// ───────────────────────────────────────────────────────────────────────────────
+
+// data emits a global variable or string literal
+func (g *codegen) data(v *cvar) {
+	if !v.hasInit {
+		g.out.WriteString("\n\t.bss\n")
+		g.emit(".align %d", max(v.ty.align, 1))
+		if !v.static {
+			g.emit(".globl %s", v.label)
+		}
+		g.label(v.label)
+		g.emit(".space %d", max(v.ty.size, 1))
+		return
+	}
+
+	g.out.WriteString("\n\t.data\n")
+	g.emit(".align %d", max(v.ty.align, 1))
+	if !v.static {
+		g.emit(".globl %s", v.label)
+	}
+	g.label(v.label)
+	if v.ty.kind == tyArray && v.ty.base.kind == tyChar && len(v.initRels) == 0 {
+		g.emit(".ascii %s", strconv.Quote(string(v.init)))
+		return
+	}
+
+	rels := make(map[int]initReloc, len(v.initRels))
+	for _, r := range v.initRels {
+		rels[r.off] = r
+	}
+	var bytes []string
+	flush := func() {
+		if len(bytes) > 0 {
+			g.emit(".byte %s", strings.Join(bytes, ", "))
+			bytes = bytes[:0]
+		}
+	}
+	for off := 0; off < len(v.init); {
+		if r, ok := rels[off]; ok {
+			flush()
+			g.emit(".word %s%+d", r.label, r.addend)
+			off += 4
+			continue
+		}
+		bytes = append(bytes, strconv.Itoa(int(v.init[off])))
+		if len(bytes) == 16 {
+			flush()
+		}
+		off++
+	}
+	flush()
+}
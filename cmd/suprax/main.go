@@ -0,0 +1,218 @@
+// Command suprax runs a program on the SUPRAX-32 out-of-order core.
+//
+// ═══════════════════════════════════════════════════════════════════════════════
+// SIMULATOR COMMAND
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// USAGE:
+//
+//	suprax [flags] program [args...]
+//
+// The program is picked by extension:
+//
+//	.c    compiled and linked with the runtime (BuildC)
+//	.s    assembled and linked with the runtime (LinkProgram)
+//	.sxo  object file linked with the runtime
+//	else  SUPRAX-32 ELF executable (LoadELF)
+//
+// In every case argv[0] is the program path and args follow it.
+//
+// MODES:
+//
+//	default   run until the program exits or -cycles elapse; the exit
+//	          status becomes the command's
+//	-debug    interactive debugger on stdin/stdout (Debugger.REPL)
+//	-gdb ADDR wait for gdb on a TCP address (GDBStub)
+//
+// The program's console output (SysWrite) goes to stdout in every mode.
+//
+// DEVICES (see devices.go), at their conventional addresses:
+//
+//	UART         stdout, and stdin once the program reads a UART register
+//	             (no input under -debug, where the debugger owns stdin)
+//	timer        always
+//	block device -disk FILE (sectors are FILE's 512-byte blocks)
+//	framebuffer  -fb WxH screen, saved as PNG by -fb-png FILE at the end
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"suprax"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run parses the flags, runs the program and returns the exit status
+func run() int {
+	cycles := flag.Uint64("cycles", 100_000_000, "stop after this many cycles")
+	memory := flag.Int("mem", 1<<20, "memory size in bytes")
+	debug := flag.Bool("debug", false, "start the interactive debugger")
+	gdb := flag.String("gdb", "", "serve gdb on this TCP address (e.g. localhost:1234)")
+	stats := flag.Bool("stats", false, "print core statistics when the run ends")
+	disk := flag.String("disk", "", "back the block device with this file")
+	fbSize := flag.String("fb", "320x240", "framebuffer size in pixels")
+	fbPNG := flag.String("fb-png", "", "write the framebuffer to this PNG file when the run ends")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: suprax [flags] program.{c,s,sxo,elf} [args...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		return 2
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(*fbSize, "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+		fmt.Fprintf(os.Stderr, "suprax: bad -fb %q, want WIDTHxHEIGHT\n", *fbSize)
+		return 2
+	}
+
+	core := suprax32.NewCore(*memory)
+	core.SetConsole(os.Stdout)
+	if err := load(core, flag.Arg(0), flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "suprax: %v\n", err)
+		return 1
+	}
+
+	// Devices
+	var stdin io.Reader = os.Stdin
+	if *debug {
+		stdin = nil
+	}
+	uart := suprax32.NewUART(stdin, os.Stdout)
+	defer uart.Close()
+	fb := suprax32.NewFramebuffer(width, height)
+	type mapping struct {
+		name       string
+		base, size uint32
+		dev        suprax32.Device
+	}
+	devices := []mapping{
+		{"uart", suprax32.UARTBase, suprax32.DeviceRegionSize, uart},
+		{"timer", suprax32.TimerBase, suprax32.DeviceRegionSize, suprax32.NewTimer()},
+		{"framebuffer", suprax32.FramebufferBase, fb.Size(), fb},
+	}
+	if *disk != "" {
+		block, err := suprax32.OpenBlockDevice(*disk)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "suprax: %v\n", err)
+			return 1
+		}
+		defer block.Close()
+		devices = append(devices, mapping{"block", suprax32.BlockBase, suprax32.DeviceRegionSize, block})
+	}
+	for _, d := range devices {
+		if err := core.MapDevice(d.name, d.base, d.size, d.dev); err != nil {
+			fmt.Fprintf(os.Stderr, "suprax: %v\n", err)
+			return 1
+		}
+	}
+
+	switch {
+	case *debug:
+		if err := suprax32.NewDebugger(core).REPL(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "suprax: %v\n", err)
+			return 1
+		}
+
+	case *gdb != "":
+		fmt.Fprintf(os.Stderr, "suprax: waiting for gdb on %s\n", *gdb)
+		stub := suprax32.NewGDBStub(suprax32.NewDebugger(core))
+		if err := stub.ListenAndServe("tcp", *gdb); err != nil {
+			fmt.Fprintf(os.Stderr, "suprax: %v\n", err)
+			return 1
+		}
+
+	default:
+		core.Run(*cycles)
+	}
+
+	if *stats {
+		fmt.Fprint(os.Stderr, core.GetStats())
+	}
+	if *fbPNG != "" {
+		if err := writePNG(fb, *fbPNG); err != nil {
+			fmt.Fprintf(os.Stderr, "suprax: %v\n", err)
+			return 1
+		}
+	}
+	if status, ok := core.Exited(); ok {
+		return int(int32(status))
+	}
+	if !*debug && *gdb == "" {
+		fmt.Fprintf(os.Stderr, "suprax: program still running after %d cycles\n", *cycles)
+		return 1
+	}
+	return 0
+}
+
+// writePNG saves the framebuffer's screen
+func writePNG(fb *suprax32.Framebuffer, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fb.WritePNG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load builds or reads the program and loads it into the core
+func load(core *suprax32.Core, path string, argv []string) error {
+	var img *suprax32.Image
+	switch filepath.Ext(path) {
+	case ".c":
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if img, err = suprax32.BuildC(path, string(src)); err != nil {
+			return err
+		}
+
+	case ".s":
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		obj, err := suprax32.Assemble(path, string(src))
+		if err != nil {
+			return err
+		}
+		if img, err = suprax32.LinkProgram([]*suprax32.Object{obj}, nil); err != nil {
+			return err
+		}
+
+	case ".sxo":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		obj, err := suprax32.ReadObject(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if img, err = suprax32.LinkProgram([]*suprax32.Object{obj}, nil); err != nil {
+			return err
+		}
+
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return core.LoadELF(f, argv, nil)
+	}
+	return core.LoadImage(img, argv...)
+}
@@ -0,0 +1,318 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// CPI STACK (TOP-DOWN COMMIT SLOT ACCOUNTING)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE QUESTION: Why didn't this cycle retire CommitWidth instructions?
+//
+// THE MODEL: Every cycle offers CommitWidth (4) commit slots.
+//
+//	Filled slot → Retiring
+//	Empty slot  → blamed on ONE cause, chosen by looking at what is
+//	              blocking the oldest instruction (the window head)
+//
+// Summed over a run, the slots add up exactly:
+//
+//	Σ slots = cycles × CommitWidth
+//
+// Dividing by (CommitWidth × instructions) turns each bucket into a CPI
+// component, and the components sum to the measured CPI:
+//
+//	CPI = 1/CommitWidth (Retiring) + FetchStarved + ... + ExecLatency
+//
+// ALGORITHM (per cycle, after the commit stage):
+//
+//	STEP 1: Each committed instruction fills one Retiring slot
+//	STEP 2: If the window is EMPTY, the front end is to blame:
+//	          refilling after a mispredict → BranchRecovery
+//	          last fetch missed the L1I    → ICacheMiss
+//	          otherwise                    → FetchStarved
+//	STEP 3: Otherwise look at the head entry:
+//	          load/store waiting on DRAM   → LoadMiss
+//	          DIV/REM blocked or iterating → DivBusy
+//	          MUL/MULH blocked on the unit → MulBusy
+//	          load/store with no free LSU  → LSUHazard
+//	          window cannot accept more    → WindowFull
+//	          otherwise                    → ExecLatency
+//
+// WHY BLAME THE HEAD:
+//
+//	Commit is in order. Whatever holds the head holds every slot
+//	behind it, so the head's reason IS the reason the slots were lost.
+//
+// REGIONS:
+//
+//	AddCPIRegion("inner_loop", 0x1030, 0x1040) gives a separate stack for
+//	a PC range. Retiring slots go to the committed instruction's PC, lost
+//	slots to the head's PC (or the fetch PC when the window is empty).
+//	AddCPIFunctionRegions adds a region per function from Core.Symbols().
+//
+// MINECRAFT ANALOGY: The furnace output chest can take 4 items per tick.
+//
+//	When it takes fewer, look at the first item in the queue and write
+//	down why it isn't ready: no fuel delivered, still smelting, etc.
+
+// SlotCause identifies why a commit slot was (or wasn't) used
+type SlotCause uint8
+
+const (
+	SlotRetiring       SlotCause = iota // Slot used by a committing instruction
+	SlotFetchStarved                    // Window empty, front end delivered nothing
+	SlotICacheMiss                      // Window empty after an L1I miss
+	SlotBranchRecovery                  // Window refilling after a mispredict flush
+	SlotWindowFull                      // Head stalled with the window full
+	SlotLoadMiss                        // Head is a memory op waiting for DRAM
+	SlotDivBusy                         // Head is a divide waiting for the divider
+	SlotMulBusy                         // Head is a multiply waiting for the multiplier
+	SlotLSUHazard                       // Head is a memory op with no free LSU
+	SlotExecLatency                     // Head issued or about to issue, not done yet
+	NumSlotCauses
+)
+
+var slotCauseNames = [NumSlotCauses]string{
+	"retiring", "fetch_starved", "icache_miss", "branch_recovery", "window_full",
+	"load_miss", "div_busy", "mul_busy", "lsu_hazard", "exec_latency",
+}
+
+// String returns the short name of a slot cause
+func (sc SlotCause) String() string {
+	if sc >= NumSlotCauses {
+		return "unknown"
+	}
+	return slotCauseNames[sc]
+}
+
+// CPIStack counts commit slots by cause
+type CPIStack struct {
+	Retiring       uint64 `json:"retiring"`
+	FetchStarved   uint64 `json:"fetch_starved"`
+	ICacheMiss     uint64 `json:"icache_miss"`
+	BranchRecovery uint64 `json:"branch_recovery"`
+	WindowFull     uint64 `json:"window_full"`
+	LoadMiss       uint64 `json:"load_miss"`
+	DivBusy        uint64 `json:"div_busy"`
+	MulBusy        uint64 `json:"mul_busy"`
+	LSUHazard      uint64 `json:"lsu_hazard"`
+	ExecLatency    uint64 `json:"exec_latency"`
+
+	CPI float64 `json:"cpi"` // Total slots / CommitWidth / retired instructions
+}
+
+// CPIRegion is a CPI stack restricted to a PC range [Start, End)
+type CPIRegion struct {
+	Name  string   `json:"name" stat:"key"`
+	Start uint32   `json:"start"`
+	End   uint32   `json:"end"`
+	Stack CPIStack `json:"stack"`
+}
+
+// slot returns the counter for a cause
+func (s *CPIStack) slot(cause SlotCause) *uint64 {
+	switch cause {
+	case SlotRetiring:
+		return &s.Retiring
+	case SlotFetchStarved:
+		return &s.FetchStarved
+	case SlotICacheMiss:
+		return &s.ICacheMiss
+	case SlotBranchRecovery:
+		return &s.BranchRecovery
+	case SlotWindowFull:
+		return &s.WindowFull
+	case SlotLoadMiss:
+		return &s.LoadMiss
+	case SlotDivBusy:
+		return &s.DivBusy
+	case SlotMulBusy:
+		return &s.MulBusy
+	case SlotLSUHazard:
+		return &s.LSUHazard
+	default:
+		return &s.ExecLatency
+	}
+}
+
+// Slots returns the number of slots attributed to a cause
+func (s *CPIStack) Slots(cause SlotCause) uint64 {
+	return *s.slot(cause)
+}
+
+// Total returns the number of slots accounted (cycles × CommitWidth)
+func (s *CPIStack) Total() uint64 {
+	var total uint64
+	for cause := SlotCause(0); cause < NumSlotCauses; cause++ {
+		total += s.Slots(cause)
+	}
+	return total
+}
+
+// Component returns one cause's contribution to CPI
+func (s *CPIStack) Component(cause SlotCause) float64 {
+	return ratio(s.Slots(cause), s.Retiring*CommitWidth)
+}
+
+// derive recomputes the total CPI from the slot counters
+func (s *CPIStack) derive() {
+	s.CPI = ratio(s.Total(), s.Retiring*CommitWidth)
+}
+
+// AddCPIRegion starts a separate CPI stack for PCs in [start, end)
+//
+// Regions may overlap; a slot counts toward every region containing its PC.
+func (c *Core) AddCPIRegion(name string, start, end uint32) {
+	c.cpiRegions = append(c.cpiRegions, CPIRegion{Name: name, Start: start, End: end})
+}
+
+// AddCPIFunctionRegions adds one region per function in the loaded symbol
+// table (see symbols.go), giving a per-function CPI profile
+func (c *Core) AddCPIFunctionRegions() {
+	for _, fn := range c.symbols.Functions() {
+		if fn.Size != 0 {
+			c.AddCPIRegion(fn.Name, fn.Addr, fn.Addr+fn.Size)
+		}
+	}
+}
+
+// CPIStack returns the whole-run stack with its CPI filled in
+func (c *Core) CPIStack() CPIStack {
+	s := c.cpi
+	s.derive()
+	return s
+}
+
+// CPIRegions returns a copy of the per-region stacks
+func (c *Core) CPIRegions() []CPIRegion {
+	regions := append([]CPIRegion(nil), c.cpiRegions...)
+	for i := range regions {
+		regions[i].Stack.derive()
+	}
+	return regions
+}
+
+// addSlots credits n slots of one cause to the run and to matching regions
+func (c *Core) addSlots(cause SlotCause, pc uint32, n uint64) {
+	*c.cpi.slot(cause) += n
+	for i := range c.cpiRegions {
+		r := &c.cpiRegions[i]
+		if pc >= r.Start && pc < r.End {
+			*r.Stack.slot(cause) += n
+		}
+	}
+}
+
+// retireSlot records one committed instruction (STEP 1)
+func (c *Core) retireSlot(pc uint32) {
+	c.addSlots(SlotRetiring, pc, 1)
+}
+
+// accountLostSlots blames the unused commit slots of this cycle (STEPS 2-3)
+func (c *Core) accountLostSlots(lost int) {
+	if lost <= 0 {
+		return
+	}
+	cause, pc := c.stallCause()
+	c.addSlots(cause, pc, uint64(lost))
+}
+
+// stallCause decides what is holding back commit and at which PC
+func (c *Core) stallCause() (SlotCause, uint32) {
+	w := c.window
+
+	// STEP 2: Empty window → front end
+	if w.count == 0 {
+		switch {
+		case c.recovering:
+			return SlotBranchRecovery, c.pc
+		case c.fetchMissed:
+			return SlotICacheMiss, c.pc
+		default:
+			return SlotFetchStarved, c.pc
+		}
+	}
+
+	// STEP 3: Blame the head
+	head := &w.entries[w.head]
+	isMem := head.IsLoad || head.IsStore
+
+	switch {
+	case isMem && head.Issued && c.lsuWaitingDRAM(w.head):
+		return SlotLoadMiss, head.PC
+
+	case head.Opcode == OpDIV || head.Opcode == OpREM:
+		if head.Issued || c.divider.Busy {
+			return SlotDivBusy, head.PC
+		}
+
+	case head.Opcode == OpMUL || head.Opcode == OpMULH:
+		if !head.Issued && c.multiplier.IsBusy() {
+			return SlotMulBusy, head.PC
+		}
+
+	case isMem && !head.Issued && c.allLSUsBusy():
+		return SlotLSUHazard, head.PC
+	}
+
+	if !w.CanDispatch() {
+		return SlotWindowFull, head.PC
+	}
+	return SlotExecLatency, head.PC
+}
+
+// lsuWaitingDRAM reports whether the LSU holding windowID is on a miss
+func (c *Core) lsuWaitingDRAM(windowID int) bool {
+	for _, lsu := range c.lsus {
+		if lsu.busy && lsu.waitDRAM && lsu.op.WindowID == windowID {
+			return true
+		}
+	}
+	return false
+}
+
+// allLSUsBusy reports a structural hazard on the load/store units
+func (c *Core) allLSUsBusy() bool {
+	for _, lsu := range c.lsus {
+		if !lsu.IsBusy() {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCPIStack renders the whole-run stack and any regions as text
+func (c *Core) GetCPIStack() string {
+	var b strings.Builder
+
+	b.WriteString(`
+╔═══════════════════════════════════════════════════════════════════════════╗
+║                    SUPRAX-32 CPI STACK (TOP-DOWN)                         ║
+╚═══════════════════════════════════════════════════════════════════════════╝
+`)
+	writeCPIStack(&b, "WHOLE RUN", c.CPIStack())
+	for _, r := range c.CPIRegions() {
+		writeCPIStack(&b, fmt.Sprintf("REGION %s [0x%x, 0x%x)", r.Name, r.Start, r.End), r.Stack)
+	}
+	return b.String()
+}
+
+// writeCPIStack renders one stack as a table with proportional bars
+func writeCPIStack(b *strings.Builder, title string, s CPIStack) {
+	total := s.Total()
+
+	fmt.Fprintf(b, "\n%s:\n", title)
+	fmt.Fprintf(b, "  CPI:                 %.3f (%d retired, %d slots)\n",
+		s.CPI, s.Retiring, total)
+
+	for cause := SlotCause(0); cause < NumSlotCauses; cause++ {
+		slots := s.Slots(cause)
+		share := ratio(slots, total)
+		bar := strings.Repeat("█", int(share*40+0.5))
+		fmt.Fprintf(b, "  %-16s %6.3f  %5.1f%%  %s\n",
+			cause.String()+":", s.Component(cause), share*100, bar)
+	}
+}
@@ -0,0 +1,193 @@
+package suprax32
+
+import "testing"
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX CPI Stack - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A CPI stack is only useful if the slots add up and each one lands in the right bucket.
+// The sum is checked over a mixed run. Each stall cause then gets the smallest program
+// that produces it, with a region drawn around the stalling instruction, and the slots
+// lost there must go to that cause and to no other named stall.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. ACCOUNTING TESTS
+//    Every cycle accounts for exactly CommitWidth slots
+//
+// 2. ATTRIBUTION TESTS
+//    Load misses, a busy divider and branch recovery each charged to their own cause
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runCPI runs a program at 0x1000 with one region [start, end) and returns the
+// whole-run and region stacks
+func runCPI(program []uint32, cycles uint64, start, end uint32) (*Core, CPIStack, CPIStack) {
+	core := NewCore(1 << 20)
+	core.LoadProgram(program, 0x1000)
+	core.AddCPIRegion("probe", start, end)
+	core.Run(cycles)
+	return core, core.CPIStack(), core.CPIRegions()[0].Stack
+}
+
+// checkChargedTo checks that cause holds at least minShare of the stack's lost slots
+// and that no other named stall (one with a specific hardware reason) got any
+func checkChargedTo(t *testing.T, s CPIStack, cause SlotCause, minShare float64) {
+	t.Helper()
+	lost := s.Total() - s.Retiring
+	if got := s.Slots(cause); got == 0 || ratio(got, lost) < minShare {
+		t.Errorf("%s has %d of %d lost slots, expected at least %.0f%%", cause, got, lost, minShare*100)
+	}
+	for _, other := range []SlotCause{SlotBranchRecovery, SlotLoadMiss, SlotDivBusy, SlotMulBusy, SlotLSUHazard} {
+		if other != cause && s.Slots(other) != 0 {
+			t.Errorf("%d slots charged to %s, expected them under %s", s.Slots(other), other, cause)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. ACCOUNTING TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestCPIStack_SlotsSumToCyclesTimesWidth(t *testing.T) {
+	// WHAT: Σ slots = cycles × CommitWidth over a run that misses, divides, multiplies
+	//       and mispredicts, and the CPI derived from the slots equals cycles / retired
+	// WHY: A cycle that blames nothing, or blames twice, makes the components stop
+	//      summing to the measured CPI and every percentage in the report wrong
+	// HARDWARE: N/A (accounting over the commit stage)
+	// CATEGORY: [INTEGRATION]
+
+	program := []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000),
+		EncodeIFormat(OpADDI, 8, 0, 1000),
+		EncodeIFormat(OpADDI, 9, 0, 3),
+		EncodeIFormat(OpLW, 3, 1, 0),    // 0x100C: cold miss
+		EncodeRFormat(OpDIV, 4, 8, 9),   // 0x1010
+		EncodeRFormat(OpMUL, 5, 4, 9),   // 0x1014
+		EncodeBFormat(OpBEQ, 5, 0, 8),   // 0x1018: predicted taken, falls through
+		EncodeIFormat(OpADDI, 6, 6, 1),  // 0x101C
+		EncodeIFormat(OpADDI, 9, 9, 1),  // 0x1020
+		EncodeIFormat(OpADDI, 1, 1, 64), // 0x1024: next line
+		EncodeBFormat(OpBNE, 9, 0, -32), // 0x1028: back to the load
+	}
+	for _, cycles := range []uint64{1, 37, 500, 3000} {
+		core, whole, region := runCPI(program, cycles, 0x100C, 0x1010)
+		if want := core.cycles * CommitWidth; whole.Total() != want {
+			t.Errorf("after %d cycles: %d slots accounted, expected %d", cycles, whole.Total(), want)
+		}
+		if whole.Retiring != core.instructions {
+			t.Errorf("after %d cycles: %d retiring slots, expected one per committed instruction (%d)",
+				cycles, whole.Retiring, core.instructions)
+		}
+		if region.Total() > whole.Total() {
+			t.Errorf("after %d cycles: region holds %d slots, more than the run's %d", cycles, region.Total(), whole.Total())
+		}
+		if core.instructions > 0 {
+			if want := float64(core.cycles) / float64(core.instructions); whole.CPI < want-1e-9 || whole.CPI > want+1e-9 {
+				t.Errorf("after %d cycles: stack CPI %.6f, expected cycles/instructions = %.6f", cycles, whole.CPI, want)
+			}
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. ATTRIBUTION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestCPIStack_LoadMissChargedToLoadMiss(t *testing.T) {
+	// WHAT: The slots lost while a load to a cold line waits for DRAM are charged to
+	//       load_miss at the load's PC
+	// WHY: A miss stalls commit for the whole DRAM latency; charged anywhere else it
+	//      looks like slow arithmetic
+	// HARDWARE: LSU waitDRAM flag (INNOVATION #69)
+	// CATEGORY: [INTEGRATION]
+
+	_, whole, load := runCPI([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000),
+		EncodeIFormat(OpLW, 3, 1, 0),  // 0x1004: cold line
+		EncodeRFormat(OpADD, 4, 3, 3), // 0x1008
+		EncodeIFormat(OpJAL, 0, 0, 0), // 0x100C: spin
+	}, 400, 0x1004, 0x1008)
+
+	checkChargedTo(t, load, SlotLoadMiss, 0.9)
+	if load.LoadMiss != whole.LoadMiss {
+		t.Errorf("%d load_miss slots at the load, %d in the run, expected all of them at the load",
+			load.LoadMiss, whole.LoadMiss)
+	}
+}
+
+func TestCPIStack_BusyDividerChargedToDivBusy(t *testing.T) {
+	// WHAT: The slots lost behind a chain of dependent divides are charged to div_busy
+	// WHY: The divider is iterative and unpipelined; code that divides in a loop needs to
+	//      see that cost separately from ordinary latency
+	// HARDWARE: Iterative divider (INNOVATION #58)
+	// CATEGORY: [INTEGRATION]
+
+	_, whole, divs := runCPI([]uint32{
+		EncodeIFormat(OpADDI, 8, 0, 1000),
+		EncodeIFormat(OpADDI, 9, 0, 3),
+		EncodeRFormat(OpDIV, 3, 8, 9), // 0x1008
+		EncodeRFormat(OpDIV, 3, 3, 9), // 0x100C
+		EncodeRFormat(OpDIV, 3, 3, 9), // 0x1010
+		EncodeIFormat(OpJAL, 0, 0, 0), // 0x1014: spin
+	}, 400, 0x1008, 0x1014)
+
+	checkChargedTo(t, divs, SlotDivBusy, 0.8)
+	if divs.DivBusy != whole.DivBusy {
+		t.Errorf("%d div_busy slots at the divides, %d in the run, expected all of them at the divides",
+			divs.DivBusy, whole.DivBusy)
+	}
+}
+
+func TestCPIStack_MispredictChargedToBranchRecovery(t *testing.T) {
+	// WHAT: A mispredicted branch charges the slots spent refilling the window to
+	//       branch_recovery; the same program with the branch predicted right charges none
+	// WHY: After a flush the window is empty, which without the recovery flag reads as
+	//      an idle front end (fetch_starved) and hides the cost of the mispredict
+	// HARDWARE: Redirect and refill after a mispredict (INNOVATION #29)
+	// CATEGORY: [INTEGRATION]
+
+	program := func(op uint8) []uint32 {
+		return []uint32{
+			EncodeIFormat(OpADDI, 3, 0, 1),
+			EncodeBFormat(op, 3, 0, 12),    // 0x1004: predicted taken
+			EncodeIFormat(OpADDI, 4, 0, 1), // 0x1008
+			EncodeIFormat(OpADDI, 5, 0, 1), // 0x100C
+			EncodeIFormat(OpADDI, 6, 0, 1), // 0x1010
+			EncodeIFormat(OpJAL, 0, 0, 0),  // 0x1014: spin
+		}
+	}
+
+	core, whole, _ := runCPI(program(OpBEQ), 400, 0x1000, 0x1018)
+	if n := core.Snapshot().Branch.Mispredicts; n != 1 {
+		t.Fatalf("%d mispredicts, expected the BEQ's one", n)
+	}
+	if whole.BranchRecovery == 0 {
+		t.Error("no branch_recovery slots after the mispredict")
+	}
+	for _, other := range []SlotCause{SlotLoadMiss, SlotDivBusy, SlotMulBusy, SlotLSUHazard} {
+		if whole.Slots(other) != 0 {
+			t.Errorf("%d slots charged to %s, expected none in a program without that stall", whole.Slots(other), other)
+		}
+	}
+	missFetchStarved := whole.FetchStarved
+
+	core, whole, _ = runCPI(program(OpBNE), 400, 0x1000, 0x1018)
+	if n := core.Snapshot().Branch.Mispredicts; n != 0 {
+		t.Fatalf("%d mispredicts, expected the taken BNE to be predicted", n)
+	}
+	if whole.BranchRecovery != 0 {
+		t.Errorf("%d branch_recovery slots without a mispredict, expected 0", whole.BranchRecovery)
+	}
+	if missFetchStarved != whole.FetchStarved {
+		t.Errorf("fetch_starved %d with the mispredict, %d without, expected the refill to stay out of it",
+			missFetchStarved, whole.FetchStarved)
+	}
+}
@@ -0,0 +1,836 @@
+package suprax32
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// DEBUGGER
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY A DEBUGGER:
+//
+// Programs run on an out-of-order core: at any moment dozens of
+// instructions are in flight, some on the wrong path. A debugger must show
+// the ARCHITECTURAL view (what a program sees) while still letting us peek
+// at the machinery (window, RAT, physical registers, caches).
+//
+// THE KEY IDEA: Stop at the commit boundary
+//
+//	Everything before the window head has committed → architectural state
+//	Everything from the head onward is speculative  → not yet visible
+//
+// The core calls our commit gate with the head before each commit. By
+// refusing to commit, we freeze the architectural state exactly at an
+// instruction boundary, even though the pipeline keeps running.
+//
+//	Breakpoint:  head.PC is a breakpoint   → hold it, stop BEFORE it runs
+//	Step N:      allow N commits           → hold the next one
+//	Watchpoint:  head touches a watched    → let it commit, stop AFTER it
+//	             address
+//
+// A store writes the L1D when it issues, so the gate is asked about a
+// store before it issues (Core.gateStore); stores then run one at a time
+// from the head. When a run stops, no instruction at or after the stop
+// has changed memory.
+//
+// MEMORY VIEW:
+//
+//	The L1D is write-back, so the latest value of an address may live in a
+//	dirty line. ReadMem/WriteMem look through the L1D (and keep the L1I
+//	coherent on writes) so inspection matches what the program would load.
+//	Addresses are the program's: with paging on, Debugger.ReadMem/WriteMem
+//	translate each page through the page table (Core.Translate), as
+//	breakpoints and watchpoints already compare virtual addresses.
+//
+// USAGE:
+//
+//	dbg := NewDebugger(core)
+//	dbg.Break(0x1030)
+//	ev := dbg.Continue(100000)          // Stops at 0x1030
+//	fmt.Println(ev, dbg.Registers())
+//	dbg.StepInstructions(1)
+//
+// Or interactively: dbg.REPL(os.Stdin, os.Stdout), or from gdb (gdbstub.go).
+// The simulator command wraps both: suprax -debug prog.c, suprax -gdb :1234 prog.c
+// (cmd/suprax).
+// With symbols loaded (Core.LoadELF / LoadImage), addresses may be given
+// as symbol names and listings show function names.
+//
+// MINECRAFT ANALOGY: Pausing the game tick by tick with F3 open
+//
+//	The world keeps its queued block updates; we only look at what has
+//	actually happened so far.
+
+// StopReason explains why execution stopped
+type StopReason uint8
+
+const (
+	StopNone       StopReason = iota
+	StopBreakpoint            // Head reached a PC breakpoint (not executed yet)
+	StopWatchpoint            // A committed access touched a watched address
+	StopStep                  // Requested number of instructions committed
+	StopCycles                // Requested number of cycles elapsed
+	StopExited                // The program called SysExit (see syscall.go)
+)
+
+// WatchKind selects which accesses trigger a watchpoint
+type WatchKind uint8
+
+const (
+	WatchWrite  WatchKind = 1 << iota // Stores and store-conditionals
+	WatchRead                         // Loads and load-reserved
+	WatchAccess = WatchRead | WatchWrite
+)
+
+// StopEvent describes where and why the debugger stopped
+type StopEvent struct {
+	Reason StopReason
+	PC     uint32 // Architectural PC (next instruction to commit)
+	Addr   uint32 // Watchpoint: address accessed
+	Status uint32 // Exited: the program's exit status
+	Cycle  uint64
+}
+
+// String renders the event like a debugger status line
+func (ev StopEvent) String() string {
+	switch ev.Reason {
+	case StopBreakpoint:
+		return fmt.Sprintf("breakpoint at 0x%08x (cycle %d)", ev.PC, ev.Cycle)
+	case StopWatchpoint:
+		return fmt.Sprintf("watchpoint 0x%08x hit, pc 0x%08x (cycle %d)", ev.Addr, ev.PC, ev.Cycle)
+	case StopStep:
+		return fmt.Sprintf("stepped to 0x%08x (cycle %d)", ev.PC, ev.Cycle)
+	case StopCycles:
+		return fmt.Sprintf("ran to cycle %d, pc 0x%08x", ev.Cycle, ev.PC)
+	case StopExited:
+		return fmt.Sprintf("exited with status %d (cycle %d)", int32(ev.Status), ev.Cycle)
+	default:
+		return fmt.Sprintf("stopped at 0x%08x (cycle %d)", ev.PC, ev.Cycle)
+	}
+}
+
+// watchpoint watches [Addr, Addr+Size)
+type watchpoint struct {
+	Addr uint32
+	Size uint32
+	Kind WatchKind
+}
+
+// Debugger controls and inspects a Core
+type Debugger struct {
+	core *Core
+
+	breakpoints map[uint32]bool
+	watchpoints []watchpoint
+
+	// Per-run state
+	stop        *StopEvent // Set by the gate when the run must stop
+	stopPending *StopEvent // Watchpoint: stop after this commit
+	pendingAt   uint64     // Commit count when stopPending was set
+	stepBudget  int        // Commits left before a step stop (-1 = unlimited)
+	skipPC      uint32     // Breakpoint to step over when resuming
+	skipValid   bool
+}
+
+// NewDebugger attaches a debugger to the core
+func NewDebugger(core *Core) *Debugger {
+	d := &Debugger{
+		core:        core,
+		breakpoints: make(map[uint32]bool),
+		stepBudget:  -1,
+	}
+	core.commitGate = d.gate
+	return d
+}
+
+// Detach removes the debugger's commit hook from the core
+func (d *Debugger) Detach() {
+	d.core.commitGate = nil
+}
+
+// Core returns the core under control
+func (d *Debugger) Core() *Core {
+	return d.core
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Breakpoints and watchpoints
+// ───────────────────────────────────────────────────────────────────────────────
+
+// Break sets a breakpoint on a PC
+func (d *Debugger) Break(pc uint32) {
+	d.breakpoints[pc] = true
+}
+
+// ClearBreak removes a breakpoint (returns false if none was set)
+func (d *Debugger) ClearBreak(pc uint32) bool {
+	if !d.breakpoints[pc] {
+		return false
+	}
+	delete(d.breakpoints, pc)
+	return true
+}
+
+// Breakpoints returns all breakpoint PCs in ascending order
+func (d *Debugger) Breakpoints() []uint32 {
+	pcs := make([]uint32, 0, len(d.breakpoints))
+	for pc := range d.breakpoints {
+		pcs = append(pcs, pc)
+	}
+	sort.Slice(pcs, func(i, j int) bool { return pcs[i] < pcs[j] })
+	return pcs
+}
+
+// Watch sets a watchpoint on [addr, addr+size)
+func (d *Debugger) Watch(addr, size uint32, kind WatchKind) {
+	if size == 0 {
+		size = 4
+	}
+	d.ClearWatch(addr)
+	d.watchpoints = append(d.watchpoints, watchpoint{Addr: addr, Size: size, Kind: kind})
+}
+
+// ClearWatch removes the watchpoint starting at addr
+func (d *Debugger) ClearWatch(addr uint32) bool {
+	for i, wp := range d.watchpoints {
+		if wp.Addr == addr {
+			d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// watchHit checks a committing memory access against the watchpoints
+func (d *Debugger) watchHit(entry *WindowEntry) (uint32, bool) {
+	if !entry.MemAddrValid || (!entry.IsLoad && !entry.IsStore) {
+		return 0, false
+	}
+
+	kind := WatchRead
+	if entry.IsStore {
+		kind = WatchWrite
+	}
+
+	lo, hi := entry.MemAddr, entry.MemAddr+uint32(entry.MemSize)
+	for _, wp := range d.watchpoints {
+		if wp.Kind&kind != 0 && lo < wp.Addr+wp.Size && wp.Addr < hi {
+			return entry.MemAddr, true
+		}
+	}
+	return 0, false
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Execution control
+// ───────────────────────────────────────────────────────────────────────────────
+
+// gate is the core's commit hook (see Core.commitGate)
+//
+// ALGORITHM:
+//
+//	STEP 1: Already stopping this cycle → hold everything
+//	STEP 2: Head is on a breakpoint (and not the one we resume from) → hold
+//	STEP 3: Step budget exhausted → hold
+//	STEP 4: Head touches a watched address → commit it, then hold
+func (d *Debugger) gate(head *WindowEntry) bool {
+	// STEP 1
+	if d.stop != nil {
+		return false
+	}
+	if d.stopPending != nil {
+		d.stop = d.stopPending
+		d.stopPending = nil
+		return false
+	}
+
+	// STEP 2
+	if d.breakpoints[head.PC] {
+		if d.skipValid && d.skipPC == head.PC {
+			d.skipValid = false
+		} else {
+			d.stop = &StopEvent{Reason: StopBreakpoint, PC: head.PC}
+			return false
+		}
+	}
+	d.skipValid = false
+
+	// STEP 3
+	if d.stepBudget == 0 {
+		d.stop = &StopEvent{Reason: StopStep, PC: head.PC}
+		return false
+	}
+	if d.stepBudget > 0 {
+		d.stepBudget--
+	}
+
+	// STEP 4
+	if addr, hit := d.watchHit(head); hit {
+		d.stopPending = &StopEvent{Reason: StopWatchpoint, Addr: addr}
+		d.pendingAt = d.core.instructions
+	}
+
+	return true
+}
+
+// run cycles the core until the gate stops it or maxCycles elapse
+func (d *Debugger) run(maxCycles uint64, budget int) StopEvent {
+	d.stop = nil
+	d.stopPending = nil
+	d.stepBudget = budget
+
+	// Resuming from a breakpoint: let that instruction commit once
+	d.skipPC = d.core.archPC
+	d.skipValid = d.breakpoints[d.skipPC]
+
+	for i := uint64(0); i < maxCycles; i++ {
+		d.core.Cycle()
+
+		if status, exited := d.core.Exited(); exited {
+			d.stepBudget = -1
+			return StopEvent{Reason: StopExited, PC: d.core.archPC, Status: status, Cycle: d.core.cycles}
+		}
+
+		// A store is let through before it issues: wait for its commit
+		if d.stop == nil && d.stopPending != nil && d.core.instructions != d.pendingAt {
+			d.stop = d.stopPending // Watched access was the last commit
+			d.stopPending = nil
+		}
+		// The same goes for a store that used up the step budget
+		if head := d.core.window.Head(); d.stop == nil && d.stepBudget == 0 &&
+			(head == nil || !head.IsStore || !head.GateOK) {
+			d.stop = &StopEvent{Reason: StopStep}
+		}
+		if d.stop != nil {
+			ev := *d.stop
+			ev.PC = d.core.archPC
+			ev.Cycle = d.core.cycles
+			d.stop = nil
+			d.stepBudget = -1
+			return ev
+		}
+	}
+
+	d.stepBudget = -1
+	return StopEvent{Reason: StopCycles, PC: d.core.archPC, Cycle: d.core.cycles}
+}
+
+// Continue runs until a breakpoint or watchpoint, at most maxCycles
+func (d *Debugger) Continue(maxCycles uint64) StopEvent {
+	return d.run(maxCycles, -1)
+}
+
+// StepCycles runs n cycles (stopping early on a breakpoint or watchpoint)
+func (d *Debugger) StepCycles(n uint64) StopEvent {
+	return d.run(n, -1)
+}
+
+// StepInstructions commits exactly n instructions (within maxCycles)
+func (d *Debugger) StepInstructions(n int, maxCycles uint64) StopEvent {
+	if n <= 0 {
+		return StopEvent{Reason: StopStep, PC: d.core.archPC, Cycle: d.core.cycles}
+	}
+	return d.run(maxCycles, n)
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Inspection
+// ───────────────────────────────────────────────────────────────────────────────
+
+// WindowSlot is a window entry together with its slot number
+type WindowSlot struct {
+	Index int
+	Entry WindowEntry
+}
+
+// PC returns the architectural PC (next instruction to commit)
+func (d *Debugger) PC() uint32 {
+	return d.core.archPC
+}
+
+// Cycle returns the current cycle count
+func (d *Debugger) Cycle() uint64 {
+	return d.core.cycles
+}
+
+// Registers returns the architectural register file
+func (d *Debugger) Registers() [NumArchRegs]uint32 {
+	regs := d.core.window.regFile
+	regs[0] = 0
+	return regs
+}
+
+// SetRegister writes an architectural register (r0 is ignored)
+//
+// Only meaningful when nothing in flight has renamed the register; the
+// debugger uses it while stopped at a flushed boundary.
+func (d *Debugger) SetRegister(reg uint8, value uint32) {
+	if reg == 0 || reg >= NumArchRegs {
+		return
+	}
+	d.core.window.regFile[reg] = value
+}
+
+// SetPC redirects execution: squashes all in-flight work and refetches
+func (d *Debugger) SetPC(pc uint32) {
+	c := d.core
+	c.redirect(pc)
+	c.archPC = pc
+}
+
+// PhysRegs returns the physical register file and its ready bits
+func (d *Debugger) PhysRegs() (values [NumPhysRegs]uint32, ready [NumPhysRegs]bool) {
+	return d.core.window.physRegFile, d.core.window.physRegReady
+}
+
+// WindowEntries returns in-flight instructions from oldest to youngest
+func (d *Debugger) WindowEntries() []WindowSlot {
+	w := d.core.window
+	slots := make([]WindowSlot, 0, w.count)
+	for i := 0; i < w.count; i++ {
+		idx := (w.head + i) % WindowSize
+		slots = append(slots, WindowSlot{Index: idx, Entry: w.entries[idx]})
+	}
+	return slots
+}
+
+// RATBitmaps returns the rename bitmaps, one per architectural register
+func (d *Debugger) RATBitmaps() [NumArchRegs]uint64 {
+	return d.core.window.rat.bitmaps
+}
+
+// RSB returns the return stack contents, bottom first
+func (d *Debugger) RSB() []uint32 {
+	bp := d.core.branchPred
+	return append([]uint32(nil), bp.rsb[:bp.rsbTop]...)
+}
+
+// DCacheLine returns a copy of the L1D line holding addr
+func (d *Debugger) DCacheLine(addr uint32) (line CacheLine, way int, ok bool) {
+	l, way, ok := d.core.dcache.Probe(addr)
+	if !ok {
+		return CacheLine{}, -1, false
+	}
+	return *l, way, true
+}
+
+// ICacheLine returns a copy of the L1I line holding addr
+func (d *Debugger) ICacheLine(addr uint32) (line CacheLine, buffer, way int, ok bool) {
+	l, buffer, way, ok := d.core.icache.Probe(addr)
+	if !ok {
+		return CacheLine{}, -1, -1, false
+	}
+	return *l, buffer, way, true
+}
+
+// ReadMem reads memory as the program would see it
+//
+// Bytes in a dirty L1D line come from the cache; everything else comes
+// from main memory. Out-of-range bytes read as zero.
+func (c *Core) ReadMem(addr uint32, n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		a := addr + uint32(i)
+		if line, _, ok := c.dcache.Probe(a); ok && line.Dirty {
+			data[i] = line.Data[a&(CacheLineSize-1)]
+		} else if int(a) < len(c.memory) {
+			data[i] = c.memory[a]
+		}
+	}
+	return data
+}
+
+// WriteMem writes memory and every cached copy of it
+//
+// Main memory, any L1D line (dirty or clean) and any L1I line are all
+// updated so the write is visible to both loads and instruction fetch
+// (needed for software breakpoints and code patching).
+func (c *Core) WriteMem(addr uint32, data []byte) {
+	for i, b := range data {
+		a := addr + uint32(i)
+		if int(a) < len(c.memory) {
+			c.memory[a] = b
+		}
+		if line, _, ok := c.dcache.Probe(a); ok {
+			line.Data[a&(CacheLineSize-1)] = b
+		}
+		if line, _, _, ok := c.icache.Probe(a); ok {
+			line.Data[a&(CacheLineSize-1)] = b
+		}
+	}
+}
+
+// ArchPC returns the address of the next instruction to commit
+func (c *Core) ArchPC() uint32 {
+	return c.archPC
+}
+
+// ReadMem reads memory at a virtual address through the cache hierarchy
+// (see Core.ReadMem; ok = false if a page of the range is unmapped)
+func (d *Debugger) ReadMem(addr uint32, n int) ([]byte, bool) {
+	pieces, ok := d.core.translateRange(addr, n)
+	if !ok {
+		return nil, false
+	}
+	data := make([]byte, 0, n)
+	for _, p := range pieces {
+		data = append(data, d.core.ReadMem(p.pa, p.n)...)
+	}
+	return data, true
+}
+
+// WriteMem writes memory at a virtual address and every cached copy of it
+// (see Core.WriteMem; nothing is written if a page of the range is unmapped)
+func (d *Debugger) WriteMem(addr uint32, data []byte) bool {
+	pieces, ok := d.core.translateRange(addr, len(data))
+	if !ok {
+		return false
+	}
+	for _, p := range pieces {
+		d.core.WriteMem(p.pa, data[:p.n])
+		data = data[p.n:]
+	}
+	return true
+}
+
+// ReadWord reads a little-endian 32-bit word at a virtual address (see
+// ReadMem; an unmapped word reads as zero)
+func (d *Debugger) ReadWord(addr uint32) uint32 {
+	b, ok := d.ReadMem(addr, 4)
+	if !ok {
+		return 0
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// REPL
+// ───────────────────────────────────────────────────────────────────────────────
+
+const debuggerHelp = `commands:
+  break <addr|symbol>     b      set breakpoint          delete <addr>     remove it
+  watch <addr> [r|w|rw]   w      set watchpoint          unwatch <addr>    remove it
+  continue [cycles]       c      run to next stop        info              list break/watchpoints
+  step [n]                s      commit n instructions   cycle [n]         run n cycles
+  regs                    r      architectural regs      pregs             physical regs
+  window                         in-flight entries       rat               rename bitmaps
+  rsb                            return stack            pc                current pc
+  dcache <addr>                  L1D line                icache <addr>     L1I line
+  mem <addr> [words]      x      memory (via L1D)        disas [addr] [n]  disassemble
+  stats                          core statistics         quit              q
+`
+
+// defaultRunCycles bounds continue/step when no limit is given
+const defaultRunCycles = 1000000
+
+// REPL runs an interactive command loop until quit or end of input
+func (d *Debugger) REPL(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprintf(out, "SUPRAX-32 debugger, pc 0x%08x. Type 'help' for commands.\n", d.PC())
+
+	for {
+		fmt.Fprint(out, "(suprax) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		quit, err := d.Exec(scanner.Text(), out)
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// Exec runs one debugger command, writing its output to out
+func (d *Debugger) Exec(line string, out io.Writer) (quit bool, err error) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "help", "h", "?":
+		fmt.Fprint(out, debuggerHelp)
+
+	case "quit", "q", "exit":
+		return true, nil
+
+	case "break", "b":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		d.Break(addr)
+		fmt.Fprintf(out, "breakpoint at 0x%08x\n", addr)
+
+	case "delete", "d":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		if !d.ClearBreak(addr) {
+			return false, fmt.Errorf("no breakpoint at 0x%08x", addr)
+		}
+
+	case "watch", "w":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		kind := WatchWrite
+		if len(args) > 1 {
+			switch args[1] {
+			case "r":
+				kind = WatchRead
+			case "w":
+				kind = WatchWrite
+			case "rw", "a":
+				kind = WatchAccess
+			default:
+				return false, fmt.Errorf("watch kind must be r, w or rw")
+			}
+		}
+		d.Watch(addr, 4, kind)
+		fmt.Fprintf(out, "watchpoint at 0x%08x\n", addr)
+
+	case "unwatch":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		if !d.ClearWatch(addr) {
+			return false, fmt.Errorf("no watchpoint at 0x%08x", addr)
+		}
+
+	case "info":
+		for _, pc := range d.Breakpoints() {
+			fmt.Fprintf(out, "break 0x%08x\n", pc)
+		}
+		for _, wp := range d.watchpoints {
+			fmt.Fprintf(out, "watch 0x%08x+%d kind=%d\n", wp.Addr, wp.Size, wp.Kind)
+		}
+
+	case "continue", "c":
+		limit, err := argUint(args, 0, defaultRunCycles)
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintln(out, d.Continue(limit))
+
+	case "step", "s":
+		n, err := argUint(args, 0, 1)
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintln(out, d.StepInstructions(int(n), defaultRunCycles))
+
+	case "cycle":
+		n, err := argUint(args, 0, 1)
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintln(out, d.StepCycles(n))
+
+	case "pc":
+		fmt.Fprintf(out, "pc 0x%08x%s  fetch 0x%08x  cycle %d\n", d.PC(), d.symbolSuffix(d.PC()), d.core.pc, d.Cycle())
+
+	case "regs", "r":
+		regs := d.Registers()
+		for i := 0; i < NumArchRegs; i += 4 {
+			fmt.Fprintf(out, "r%-2d %08x  r%-2d %08x  r%-2d %08x  r%-2d %08x\n",
+				i, regs[i], i+1, regs[i+1], i+2, regs[i+2], i+3, regs[i+3])
+		}
+
+	case "pregs":
+		values, ready := d.PhysRegs()
+		for i := 0; i < NumPhysRegs; i++ {
+			mark := " "
+			if !ready[i] {
+				mark = "*" // Result still pending
+			}
+			fmt.Fprintf(out, "p%-2d %08x%s", i, values[i], mark)
+			if i%4 == 3 {
+				fmt.Fprintln(out)
+			} else {
+				fmt.Fprint(out, "  ")
+			}
+		}
+		fmt.Fprintln(out, "(* = not ready)")
+
+	case "window":
+		for _, slot := range d.WindowEntries() {
+			e := &slot.Entry
+			state := "waiting"
+			switch {
+			case e.Executed:
+				state = "done"
+			case e.Issued:
+				state = "issued"
+			}
+			inst := Instruction{Opcode: e.Opcode, Rd: e.Rd, Rs1: e.Rs1, Rs2: e.Rs2,
+				Imm: e.Imm, PC: e.PC, IsBranch: e.IsBranch, IsLoad: e.IsLoad, IsStore: e.IsStore,
+				MemSize: e.MemSize, MemSigned: e.MemSigned}
+			fmt.Fprintf(out, "[%2d] %08x  %-28s %-7s %s <- %s,%s\n", slot.Index, e.PC,
+				inst.String(), state, physRegName(e.PhysRd), physRegName(e.PhysRs1), physRegName(e.PhysRs2))
+		}
+
+	case "rat":
+		bitmaps := d.RATBitmaps()
+		for reg, bitmap := range bitmaps {
+			if bitmap != 0 {
+				fmt.Fprintf(out, "r%-2d %010x -> %s\n", reg, bitmap,
+					physRegName(d.core.window.rat.Lookup(uint8(reg))))
+			}
+		}
+
+	case "rsb":
+		rsb := d.RSB()
+		for i := len(rsb) - 1; i >= 0; i-- {
+			fmt.Fprintf(out, "%2d: 0x%08x\n", i, rsb[i])
+		}
+
+	case "dcache":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		line, way, ok := d.DCacheLine(addr)
+		if !ok {
+			fmt.Fprintf(out, "0x%08x not in L1D\n", addr)
+			break
+		}
+		fmt.Fprintf(out, "L1D set %d way %d dirty=%v\n", d.core.dcache.getSetIndex(addr), way, line.Dirty)
+		dumpLine(out, addr&^(CacheLineSize-1), line.Data[:])
+
+	case "icache":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		line, buffer, way, ok := d.ICacheLine(addr)
+		if !ok {
+			fmt.Fprintf(out, "0x%08x not in L1I\n", addr)
+			break
+		}
+		fmt.Fprintf(out, "L1I buffer %d set %d way %d\n", buffer, d.core.icache.getSetIndex(addr), way)
+		dumpLine(out, addr&^(CacheLineSize-1), line.Data[:])
+
+	case "mem", "x":
+		addr, err := d.argAddr(args, 0)
+		if err != nil {
+			return false, err
+		}
+		words, err := argUint(args, 1, 8)
+		if err != nil {
+			return false, err
+		}
+		for i := uint32(0); i < uint32(words); i++ {
+			if i%4 == 0 {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "%08x:", addr+i*4)
+			}
+			if _, ok := d.ReadMem(addr+i*4, 4); !ok {
+				fmt.Fprint(out, " ????????") // Unmapped
+				continue
+			}
+			fmt.Fprintf(out, " %08x", d.ReadWord(addr+i*4))
+		}
+		fmt.Fprintln(out)
+
+	case "disas":
+		addr := d.PC()
+		if len(args) > 0 {
+			if addr, err = d.argAddr(args, 0); err != nil {
+				return false, err
+			}
+		}
+		n, err := argUint(args, 1, 8)
+		if err != nil {
+			return false, err
+		}
+		for i := uint32(0); i < uint32(n); i++ {
+			pc := addr + i*4
+			marker := "  "
+			if pc == d.PC() {
+				marker = "=>"
+			} else if d.breakpoints[pc] {
+				marker = " *"
+			}
+			if sym, off, ok := d.core.symbols.Lookup(pc); ok && off == 0 {
+				fmt.Fprintf(out, "%s:\n", sym.Name)
+			}
+			inst := DecodeInstruction(d.ReadWord(pc), pc)
+			fmt.Fprintf(out, "%s %08x: %s\n", marker, pc, inst.Format(d.core.symbols))
+		}
+
+	case "stats":
+		fmt.Fprint(out, d.core.GetStats())
+
+	default:
+		return false, fmt.Errorf("unknown command %q (try 'help')", cmd)
+	}
+
+	return false, nil
+}
+
+// symbolSuffix returns " <main+0x8>" for an address inside a known symbol
+func (d *Debugger) symbolSuffix(addr uint32) string {
+	if name := d.core.symbols.Format(addr); name != "" {
+		return " <" + name + ">"
+	}
+	return ""
+}
+
+// dumpLine prints a cache line as 16 words
+func dumpLine(out io.Writer, base uint32, data []byte) {
+	for i := 0; i < len(data); i += 4 {
+		if i%16 == 0 {
+			fmt.Fprintf(out, "%08x:", base+uint32(i))
+		}
+		word := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		fmt.Fprintf(out, " %08x", word)
+		if i%16 == 12 {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+// argAddr parses a required address argument (a symbol name, hex with
+// 0x, or decimal)
+func (d *Debugger) argAddr(args []string, i int) (uint32, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("missing address")
+	}
+	if addr, ok := d.core.symbols.Addr(args[i]); ok {
+		return addr, nil
+	}
+	v, err := strconv.ParseUint(args[i], 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad address %q", args[i])
+	}
+	return uint32(v), nil
+}
+
+// argUint parses an optional count argument
+func argUint(args []string, i int, def uint64) (uint64, error) {
+	if i >= len(args) {
+		return def, nil
+	}
+	v, err := strconv.ParseUint(args[i], 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q", args[i])
+	}
+	return v, nil
+}
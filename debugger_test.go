@@ -0,0 +1,450 @@
+package suprax32
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Debugger - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The debugger promises an in-order view of an out-of-order core: when it stops, every
+// instruction before the reported PC has committed and none after it has. Each test stops
+// the core somewhere awkward and checks both sides of that line — the committed count,
+// the architectural registers and memory.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. STEP TESTS
+//    Instruction steps retire exactly the requested count, stores included
+//
+// 2. BREAKPOINT AND WATCHPOINT TESTS
+//    Breakpoints stop before the instruction commits, watchpoints after
+//
+// 3. REPL TESTS
+//    Command parsing, arguments, symbols and errors
+//
+// 4. MEMORY VIEW TESTS
+//    Virtual addresses with paging on: page-crossing ranges, unmapped pages
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// debugProgram loads a program at 0x1000 and attaches a debugger
+func debugProgram(program []uint32) *Debugger {
+	core := NewCore(1 << 20)
+	core.LoadProgram(program, 0x1000)
+	return NewDebugger(core)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. STEP TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestDebugger_StepEndingOnStoreCommitsIt(t *testing.T) {
+	// WHAT: A step whose last instruction is a store stops after the store commits, with
+	//       the store's bytes in memory and the PC on the next instruction
+	// WHY: Stores are let through the gate before they issue, so the step budget runs
+	//      out while the store is still in flight; stopping then reported a step one
+	//      instruction short
+	// HARDWARE: N/A (tooling over the commit gate)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	dbg := debugProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000),
+		EncodeIFormat(OpADDI, 2, 0, 7),
+		EncodeSFormat(OpSW, 1, 2, 0),
+		EncodeIFormat(OpADDI, 3, 0, 1),
+	})
+
+	ev := dbg.StepInstructions(3, 1000)
+	if ev.Reason != StopStep || ev.PC != 0x100C {
+		t.Fatalf("stopped with %v, expected a step stop at 0x100c", ev)
+	}
+	if n := dbg.Core().instructions; n != 3 {
+		t.Errorf("%d instructions committed, expected 3", n)
+	}
+	if got := dbg.Core().ReadMem(0x4000, 1)[0]; got != 7 {
+		t.Errorf("memory at 0x4000 = %d, expected the store's 7", got)
+	}
+	if r3 := dbg.Registers()[3]; r3 != 0 {
+		t.Errorf("r3 = %d, expected the instruction after the step not to commit", r3)
+	}
+}
+
+func TestDebugger_StepRetiresRequestedCount(t *testing.T) {
+	// WHAT: Steps of 1, 2 and 5 instructions through a divide, a mispredicted branch and
+	//       a loop each commit exactly that many instructions and stop on the next one
+	// WHY: The core commits up to CommitWidth per cycle and runs far ahead of commit; a
+	//      step that counts cycles or fetched instructions overshoots
+	// HARDWARE: N/A (tooling over the commit gate)
+	// CATEGORY: [INTEGRATION]
+
+	dbg := debugProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 100), // 0x1000
+		EncodeIFormat(OpADDI, 2, 0, 3),   // 0x1004
+		EncodeRFormat(OpDIV, 3, 1, 2),    // 0x1008: r3 = 33
+		EncodeBFormat(OpBEQ, 3, 0, 8),    // 0x100C: predicted taken, falls through
+		EncodeIFormat(OpADDI, 4, 4, 1),   // 0x1010: loop body
+		EncodeIFormat(OpADDI, 2, 2, -1),  // 0x1014
+		EncodeBFormat(OpBNE, 2, 0, -8),   // 0x1018: three iterations
+		EncodeIFormat(OpADDI, 5, 0, 9),   // 0x101C
+		EncodeIFormat(OpJAL, 0, 0, 0),    // 0x1020: spin
+	})
+
+	// Commit order: 1000 1004 1008 100C, then (1010 1014 1018) x3, then 101C 1020...
+	order := []uint32{0x1000, 0x1004, 0x1008, 0x100C}
+	for i := 0; i < 3; i++ {
+		order = append(order, 0x1010, 0x1014, 0x1018)
+	}
+	order = append(order, 0x101C, 0x1020, 0x1020)
+
+	committed := 0
+	for _, n := range []int{1, 2, 1, 5, 1, 2, 1, 1} {
+		ev := dbg.StepInstructions(n, 1000)
+		committed += n
+		if ev.Reason != StopStep {
+			t.Fatalf("step %d stopped with %v, expected a step stop", n, ev)
+		}
+		if got := dbg.Core().instructions; got != uint64(committed) {
+			t.Fatalf("after stepping %d: %d committed, expected %d", n, got, committed)
+		}
+		if ev.PC != order[committed] || dbg.PC() != order[committed] {
+			t.Fatalf("after %d commits: pc 0x%x, expected 0x%x", committed, ev.PC, order[committed])
+		}
+	}
+	regs := dbg.Registers()
+	if regs[3] != 33 || regs[4] != 3 || regs[5] != 9 {
+		t.Errorf("r3 r4 r5 = %d %d %d, expected 33 3 9", regs[3], regs[4], regs[5])
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. BREAKPOINT AND WATCHPOINT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestDebugger_BreakpointStopsBeforeCommit(t *testing.T) {
+	// WHAT: Continue stops with the breakpoint instruction at the head, uncommitted, with
+	//       everything older committed; continuing again runs it and stops on its next visit
+	// WHY: The pipeline has usually executed the instruction already; only the commit
+	//      boundary makes "before it runs" true for the architectural state
+	// HARDWARE: N/A (tooling over the commit gate)
+	// CATEGORY: [INTEGRATION]
+
+	dbg := debugProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 3),  // 0x1000
+		EncodeIFormat(OpADDI, 2, 2, 10), // 0x1004: loop, breakpoint
+		EncodeIFormat(OpADDI, 1, 1, -1), // 0x1008
+		EncodeBFormat(OpBNE, 1, 0, -8),  // 0x100C
+		EncodeIFormat(OpJAL, 0, 0, 0),   // 0x1010: spin
+	})
+	dbg.Break(0x1004)
+
+	for visit := 0; visit < 3; visit++ {
+		ev := dbg.Continue(1000)
+		if ev.Reason != StopBreakpoint || ev.PC != 0x1004 {
+			t.Fatalf("visit %d: stopped with %v, expected the breakpoint at 0x1004", visit, ev)
+		}
+		if n := dbg.Core().instructions; n != uint64(1+3*visit) {
+			t.Errorf("visit %d: %d instructions committed, expected %d", visit, n, 1+3*visit)
+		}
+		if r2 := dbg.Registers()[2]; r2 != uint32(10*visit) {
+			t.Errorf("visit %d: r2 = %d, expected %d (breakpoint instruction not yet run)", visit, r2, 10*visit)
+		}
+	}
+
+	if !dbg.ClearBreak(0x1004) || dbg.ClearBreak(0x1004) {
+		t.Error("ClearBreak should remove the breakpoint exactly once")
+	}
+	if ev := dbg.Continue(500); ev.Reason != StopCycles {
+		t.Errorf("stopped with %v after clearing the breakpoint, expected to run out of cycles", ev)
+	}
+	if r2 := dbg.Registers()[2]; r2 != 30 {
+		t.Errorf("r2 = %d, expected 30 after the loop finished", r2)
+	}
+}
+
+func TestDebugger_WatchpointStopsAfterCommit(t *testing.T) {
+	// WHAT: A write watchpoint stops right after the store commits: its bytes are in
+	//       memory and the store behind it, held back before issue, has not written yet.
+	//       A read watchpoint stops after the load with its value in the register
+	// WHY: A store writes the L1D when it issues, so the gate sees stores before issue
+	//      and the stop must wait for the commit; stopping at the gate reports a store
+	//      whose bytes are not yet in memory, and letting the next store issue writes
+	//      memory past the stop
+	// HARDWARE: N/A (tooling over the commit gate)
+	// CATEGORY: [INTEGRATION]
+
+	dbg := debugProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000), // 0x1000
+		EncodeIFormat(OpADDI, 8, 0, 700),    // 0x1004
+		EncodeIFormat(OpADDI, 9, 0, 100),    // 0x1008
+		EncodeRFormat(OpDIV, 2, 8, 9),       // 0x100C: r2 = 7, slow
+		EncodeSFormat(OpSW, 1, 2, 0),        // 0x1010: watched, waits for the divide
+		EncodeSFormat(OpSW, 1, 9, 4),        // 0x1014: must not write before the stop
+		EncodeIFormat(OpLW, 5, 1, 8),        // 0x1018: watched for reads
+		EncodeIFormat(OpADDI, 6, 0, 1),      // 0x101C
+		EncodeIFormat(OpJAL, 0, 0, 0),       // 0x1020: spin
+	})
+	dbg.Core().WriteMem(0x4008, []byte{42, 0, 0, 0})
+	dbg.Watch(0x4000, 4, WatchWrite)
+	dbg.Watch(0x4008, 4, WatchRead)
+
+	ev := dbg.Continue(1000)
+	if ev.Reason != StopWatchpoint || ev.Addr != 0x4000 || ev.PC != 0x1014 {
+		t.Fatalf("stopped with %v, expected the 0x4000 watchpoint with pc 0x1014", ev)
+	}
+	if got := dbg.ReadWord(0x4000); got != 7 {
+		t.Errorf("0x4000 = %d, expected the watched store's 7", got)
+	}
+	if got := dbg.ReadWord(0x4004); got != 0 {
+		t.Errorf("0x4004 = %d, expected the next store to be held", got)
+	}
+	if n := dbg.Core().instructions; n != 5 {
+		t.Errorf("%d instructions committed, expected 5", n)
+	}
+
+	ev = dbg.Continue(1000)
+	if ev.Reason != StopWatchpoint || ev.Addr != 0x4008 || ev.PC != 0x101C {
+		t.Fatalf("stopped with %v, expected the 0x4008 watchpoint with pc 0x101c", ev)
+	}
+	regs := dbg.Registers()
+	if regs[5] != 42 || regs[6] != 0 {
+		t.Errorf("r5 r6 = %d %d, expected the load's 42 and nothing after it", regs[5], regs[6])
+	}
+	if got := dbg.ReadWord(0x4004); got != 100 {
+		t.Errorf("0x4004 = %d, expected 100 once the store committed", got)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. REPL TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestDebugger_ExecParsesCommands(t *testing.T) {
+	// WHAT: Commands with hex, decimal and symbol arguments do what they say; bad or
+	//       missing arguments and unknown commands return errors and change nothing
+	// WHY: The REPL is the debugger's main interface; a misparsed address silently sets a
+	//      breakpoint nobody will reach
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	obj, err := Assemble("t.s", `
+	.text
+	.globl _start, loop
+_start:
+	li   r5, 0
+loop:
+	addi r5, r5, 1
+	j    loop
+`)
+	if err != nil {
+		t.Fatalf("assemble: %v", err)
+	}
+	img, err := Link([]*Object{obj}, nil)
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	core := NewCore(1 << 20)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	dbg := NewDebugger(core)
+	loop := img.Symbols["loop"]
+
+	exec := func(line string) (string, error) {
+		var out strings.Builder
+		quit, err := dbg.Exec(line, &out)
+		if quit {
+			t.Errorf("%q quit the REPL", line)
+		}
+		return out.String(), err
+	}
+
+	// Arguments: symbol, hex and decimal all name the same address
+	for _, arg := range []string{"loop", "0x" + strconv.FormatUint(uint64(loop), 16), strconv.FormatUint(uint64(loop), 10)} {
+		if _, err := exec("b " + arg); err != nil {
+			t.Errorf("b %s: %v", arg, err)
+		}
+		if bps := dbg.Breakpoints(); len(bps) != 1 || bps[0] != loop {
+			t.Errorf("b %s set %x, expected only 0x%x", arg, bps, loop)
+		}
+	}
+	if out, err := exec("continue 1000"); err != nil || !strings.Contains(out, "breakpoint at") {
+		t.Errorf("continue: %q, %v; expected a breakpoint stop", out, err)
+	}
+	if _, err := exec("delete loop"); err != nil || len(dbg.Breakpoints()) != 0 {
+		t.Errorf("delete loop: %v, breakpoints %x", err, dbg.Breakpoints())
+	}
+	if out, _ := exec("s 3"); !strings.HasPrefix(out, "stepped to") {
+		t.Errorf("s 3: %q, expected a step stop", out)
+	}
+	if n := core.instructions; n != 4 {
+		t.Errorf("%d committed after the breakpoint and 3 steps, expected 4", n)
+	}
+	if out, _ := exec("regs"); !strings.Contains(out, "r5  00000002") {
+		t.Errorf("regs does not show r5 = 2:\n%s", out)
+	}
+	if _, err := exec("watch 0x4000 rw"); err != nil || len(dbg.watchpoints) != 1 || dbg.watchpoints[0].Kind != WatchAccess {
+		t.Errorf("watch 0x4000 rw: %v, watchpoints %+v", err, dbg.watchpoints)
+	}
+	if _, err := exec("unwatch 0x4000"); err != nil || len(dbg.watchpoints) != 0 {
+		t.Errorf("unwatch 0x4000: %v, watchpoints %+v", err, dbg.watchpoints)
+	}
+
+	// Errors
+	errs := []struct{ line, want string }{
+		{"break", "missing address"},
+		{"break nowhere", "bad address"},
+		{"watch 0x4000 x", "watch kind"},
+		{"step many", "bad number"},
+		{"delete 0x2000", "no breakpoint"},
+		{"frobnicate", "unknown command"},
+	}
+	for _, e := range errs {
+		if _, err := exec(e.line); err == nil || !strings.Contains(err.Error(), e.want) {
+			t.Errorf("%q returned %v, expected an error containing %q", e.line, err, e.want)
+		}
+	}
+	if n := core.instructions; n != 4 {
+		t.Errorf("failed commands ran the core: %d committed, expected 4", n)
+	}
+}
+
+func TestDebugger_REPLRunsScript(t *testing.T) {
+	// WHAT: The REPL reads commands line by line, reports errors without stopping, and
+	//       returns on quit without reading further
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	dbg := debugProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 5),
+		EncodeIFormat(OpADDI, 2, 0, 6),
+		EncodeIFormat(OpJAL, 0, 0, 0),
+	})
+	var out strings.Builder
+	script := "\nbogus\nstep 2\npc\nquit\nstep 1\n"
+	if err := dbg.REPL(strings.NewReader(script), &out); err != nil {
+		t.Fatalf("REPL: %v", err)
+	}
+
+	text := out.String()
+	for _, want := range []string{
+		`error: unknown command "bogus"`,
+		"stepped to 0x00001008",
+		"pc 0x00001008",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("REPL output missing %q:\n%s", want, text)
+		}
+	}
+	if n := dbg.Core().instructions; n != 2 {
+		t.Errorf("%d committed, expected 2 (nothing after quit)", n)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 4. MEMORY VIEW TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// pagedDebugger runs a program that turns paging on and stores through virtual pages, and
+// stops it at spin with the MMU still on
+//
+//	0x400000 → 0x20000  0x401000 → 0x30000  0x402000 unmapped  (RAM, read/write)
+//	0x500000 → TimerBase                                       (device)
+func pagedDebugger(t *testing.T) (*Debugger, *Timer) {
+	t.Helper()
+	obj, err := Assemble("paged.s", `
+	.text
+	.globl _start, spin
+_start:
+	li    t0, `+fmt.Sprint(testPTBR)+`
+	csrw  ptbr, t0
+	li    t1, 0x400000
+	li    t2, 0x11223344
+	sw    t2, 0(t1)
+	li    t2, 0x55667788
+	sw    t2, 4092(t1)
+spin:
+	j     spin
+`)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	img, err := Link([]*Object{obj}, nil)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	core := NewCore(1 << 20)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	timer := NewTimer()
+	mapDevice(t, core, "timer", TimerBase, DeviceRegionSize, timer)
+	pt := newPageTables(core)
+	pt.identity(0x1000, 0x2000, PTERead|PTEExec)
+	pt.mapPage(0x400000, 0x20000, PTERead|PTEWrite)
+	pt.mapPage(0x401000, 0x30000, PTERead|PTEWrite)
+	pt.mapPage(0x500000, TimerBase, PTERead|PTEWrite)
+
+	dbg := NewDebugger(core)
+	dbg.Break(symbol(t, core, "spin"))
+	if ev := dbg.Continue(100_000); ev.Reason != StopBreakpoint {
+		t.Fatalf("stopped with %v, expected the breakpoint at spin", ev)
+	}
+	return dbg, timer
+}
+
+func TestDebugger_MemoryIsVirtualWithPaging(t *testing.T) {
+	// WHAT: With paging on, ReadMem/WriteMem/ReadWord and the x command use the program's
+	//       addresses: a range crossing pages is split at the boundary, and a range
+	//       touching an unmapped page fails whole
+	// WHY: Breakpoints and watchpoints take virtual addresses; memory access used the
+	//      same numbers as physical ones and showed another part of RAM (or nothing)
+	// HARDWARE: N/A (tooling over Core.Translate)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	dbg, _ := pagedDebugger(t)
+	core := dbg.Core()
+
+	if got := dbg.ReadWord(0x400000); got != 0x11223344 {
+		t.Errorf("ReadWord(0x400000) = %#x, expected the program's store", got)
+	}
+	if got, ok := dbg.ReadMem(0x400ffc, 8); !ok || !bytes.Equal(got, []byte{0x88, 0x77, 0x66, 0x55, 0, 0, 0, 0}) {
+		t.Errorf("ReadMem across the page boundary = % x, %v", got, ok)
+	}
+
+	if !dbg.WriteMem(0x400ffe, []byte{1, 2, 3, 4}) {
+		t.Fatal("WriteMem across two mapped pages failed")
+	}
+	if lo, hi := core.ReadMem(0x20ffe, 2), core.ReadMem(0x30000, 2); !bytes.Equal(lo, []byte{1, 2}) || !bytes.Equal(hi, []byte{3, 4}) {
+		t.Errorf("physical bytes % x | % x, expected 01 02 | 03 04", lo, hi)
+	}
+
+	if _, ok := dbg.ReadMem(0x401ffe, 4); ok {
+		t.Error("ReadMem into the unmapped page succeeded")
+	}
+	if dbg.WriteMem(0x401ffe, []byte{9, 9, 9, 9}) {
+		t.Error("WriteMem into the unmapped page succeeded")
+	}
+	if got := core.ReadMem(0x30ffe, 2); !bytes.Equal(got, []byte{0, 0}) {
+		t.Errorf("failed WriteMem changed the mapped half: % x", got)
+	}
+
+	var out strings.Builder
+	if _, err := dbg.Exec("x 0x401ff8 4", &out); err != nil {
+		t.Fatalf("x: %v", err)
+	}
+	if want := "00401ff8: 00000000 00000000 ???????? ????????\n"; out.String() != want {
+		t.Errorf("x across into the unmapped page printed %q, expected %q", out.String(), want)
+	}
+}
@@ -0,0 +1,493 @@
+package suprax32
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// BUILT-IN DEVICES
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: A bus is only useful with something on it. These four cover what a
+//
+//	small system needs: a console, a clock, storage and a screen.
+//
+// REGISTER MAPS (offsets from the device base, 32-bit registers):
+//
+//	UART (UARTBase)
+//	  0x00 DATA     write: send the low byte   read: next received byte
+//	  0x04 STATUS   bit0 RX ready, bit1 TX ready (always), bit2 RX closed
+//
+//	Timer (TimerBase)
+//	  0x00 TIME     cycles since reset (read-only)
+//	  0x04 COUNT    counts down once per cycle while enabled
+//	  0x08 RELOAD   COUNT restarts here when it expires (0 = one-shot)
+//	  0x0C CTRL     bit0 enable
+//	  0x10 STATUS   bit0 expired (write 1 to clear)
+//	  0x14 EXPIRED  number of expiries (read-only)
+//
+//	Block device (BlockBase), 512-byte sectors
+//	  0x000 SECTOR   sector for the next command
+//	  0x004 CMD      write BlockCmdRead (sector → buffer) or
+//	                 BlockCmdWrite (buffer → sector)
+//	  0x008 STATUS   bit0 busy, bit1 error (last command)
+//	  0x00C SECTORS  sectors on the device (read-only)
+//	  0x200 BUFFER   512 bytes, any access size
+//
+//	Framebuffer (FramebufferBase)
+//	  0x0 + 4*(y*width+x)  pixel 0x00RRGGBB, any access size
+//
+// INTERRUPTS: None yet. Software polls STATUS registers.
+//
+// MINECRAFT ANALOGY: A sign (UART), a daylight sensor (timer), an ender
+//
+//	chest (block device) and a map wall (framebuffer).
+
+// Conventional device addresses (see bus.go)
+const (
+	UARTBase        = 0xF0000000
+	TimerBase       = 0xF0001000
+	BlockBase       = 0xF0002000
+	FramebufferBase = 0xF1000000
+
+	DeviceRegionSize = 0x1000 // UART, timer and block device each take a page
+)
+
+// ───────────────────────────────────────────────────────────────────────────────
+// UART
+// ───────────────────────────────────────────────────────────────────────────────
+
+// UART registers
+const (
+	UARTData   = 0x00
+	UARTStatus = 0x04
+
+	UARTRxReady  = 1 << 0
+	UARTTxReady  = 1 << 1
+	UARTRxClosed = 1 << 2
+)
+
+// UART is a serial port wired to host streams
+//
+// Input is read by a goroutine, so a host stream that blocks (stdin)
+// never stalls the simulation: bytes show up in STATUS when they arrive.
+// The goroutine starts the first time a UART register is read, so a
+// program that never looks at its UART leaves the host stream alone.
+type UART struct {
+	in   io.Reader
+	out  io.Writer
+	rx   chan byte     // Filled by the reader goroutine; closed at EOF
+	stop chan struct{} // Closed by Close
+
+	started bool
+	stopped bool
+
+	rxByte   byte
+	rxValid  bool
+	rxClosed bool
+}
+
+// NewUART creates a UART reading in and writing out (either may be nil)
+func NewUART(in io.Reader, out io.Writer) *UART {
+	return &UART{
+		in: in, out: out, rx: make(chan byte, 256), stop: make(chan struct{}),
+		rxClosed: in == nil,
+	}
+}
+
+// startReader starts the goroutine that feeds rx from the host stream
+func (u *UART) startReader() {
+	u.started = true
+	if u.in == nil || u.stopped {
+		return
+	}
+	in, rx, stop := u.in, u.rx, u.stop
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			for _, b := range buf[:n] {
+				select {
+				case rx <- b:
+				case <-stop:
+					return
+				}
+			}
+			if err != nil {
+				close(rx)
+				return
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+	}()
+}
+
+// Close stops the reader goroutine (the host streams stay open)
+//
+// A host read already in progress cannot be interrupted: the goroutine
+// drops whatever it returns and exits.
+func (u *UART) Close() error {
+	if !u.stopped {
+		u.stopped = true
+		close(u.stop)
+	}
+	return nil
+}
+
+// NewStdioUART creates a UART on the host's stdin and stdout
+func NewStdioUART() *UART {
+	return NewUART(os.Stdin, os.Stdout)
+}
+
+// Read implements Device
+func (u *UART) Read(offset uint32, size int) uint32 {
+	if !u.started {
+		u.startReader()
+	}
+	switch offset &^ 3 {
+	case UARTData:
+		if !u.rxValid {
+			return 0
+		}
+		u.rxValid = false
+		return uint32(u.rxByte)
+	case UARTStatus:
+		status := uint32(UARTTxReady)
+		if u.rxValid {
+			status |= UARTRxReady
+		} else if u.rxClosed {
+			status |= UARTRxClosed
+		}
+		return status
+	}
+	return 0
+}
+
+// Write implements Device
+func (u *UART) Write(offset uint32, size int, value uint32) {
+	if offset&^3 == UARTData && u.out != nil {
+		u.out.Write([]byte{byte(value)})
+	}
+}
+
+// Tick implements Device: latch the next received byte
+func (u *UART) Tick() {
+	if u.rxValid || u.rxClosed {
+		return
+	}
+	select {
+	case b, ok := <-u.rx:
+		if ok {
+			u.rxByte, u.rxValid = b, true
+		} else {
+			u.rxClosed = true
+		}
+	default:
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// TIMER
+// ───────────────────────────────────────────────────────────────────────────────
+
+// Timer registers
+const (
+	TimerTime    = 0x00
+	TimerCount   = 0x04
+	TimerReload  = 0x08
+	TimerCtrl    = 0x0C
+	TimerStatus  = 0x10
+	TimerExpired = 0x14
+
+	TimerEnable = 1 << 0 // CTRL
+	TimerFired  = 1 << 0 // STATUS
+)
+
+// Timer is a free-running cycle counter plus a programmable down-counter
+type Timer struct {
+	time    uint32
+	count   uint32
+	reload  uint32
+	enabled bool
+	fired   bool
+	expired uint32
+}
+
+// NewTimer creates a stopped timer
+func NewTimer() *Timer {
+	return &Timer{}
+}
+
+// Read implements Device
+func (t *Timer) Read(offset uint32, size int) uint32 {
+	switch offset &^ 3 {
+	case TimerTime:
+		return t.time
+	case TimerCount:
+		return t.count
+	case TimerReload:
+		return t.reload
+	case TimerCtrl:
+		if t.enabled {
+			return TimerEnable
+		}
+	case TimerStatus:
+		if t.fired {
+			return TimerFired
+		}
+	case TimerExpired:
+		return t.expired
+	}
+	return 0
+}
+
+// Write implements Device
+func (t *Timer) Write(offset uint32, size int, value uint32) {
+	switch offset &^ 3 {
+	case TimerCount:
+		t.count = value
+	case TimerReload:
+		t.reload = value
+	case TimerCtrl:
+		t.enabled = value&TimerEnable != 0
+	case TimerStatus:
+		if value&TimerFired != 0 {
+			t.fired = false
+		}
+	}
+}
+
+// Tick implements Device
+//
+// ALGORITHM:
+//
+//	TIME++
+//	IF enabled and COUNT > 0: COUNT--
+//	  Reached 0 → set STATUS.expired, EXPIRED++, then
+//	              COUNT = RELOAD (periodic) or disable (one-shot)
+func (t *Timer) Tick() {
+	t.time++
+	if !t.enabled || t.count == 0 {
+		return
+	}
+	t.count--
+	if t.count == 0 {
+		t.fired = true
+		t.expired++
+		t.count = t.reload
+		t.enabled = t.reload != 0
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// BLOCK DEVICE
+// ───────────────────────────────────────────────────────────────────────────────
+
+// Block device registers and commands
+const (
+	BlockSector  = 0x000
+	BlockCmd     = 0x004
+	BlockStatus  = 0x008
+	BlockSectors = 0x00C
+	BlockBuffer  = 0x200
+
+	BlockCmdRead  = 1 // Sector → buffer
+	BlockCmdWrite = 2 // Buffer → sector
+
+	BlockBusy  = 1 << 0 // STATUS
+	BlockError = 1 << 1
+
+	SectorSize   = 512
+	BlockLatency = 200 // Cycles per command
+)
+
+// BlockStore is the host side of a block device (an *os.File will do)
+type BlockStore interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// BlockDevice is a sector-addressed disk backed by a host file
+type BlockDevice struct {
+	store   BlockStore
+	sectors uint32
+
+	sector    uint32
+	buffer    [SectorSize]byte
+	cmd       uint32 // Command in progress (0 = idle)
+	cyclesRem int
+	err       bool
+}
+
+// NewBlockDevice creates a block device over size bytes of store
+func NewBlockDevice(store BlockStore, size int64) *BlockDevice {
+	return &BlockDevice{store: store, sectors: uint32(size / SectorSize)}
+}
+
+// OpenBlockDevice creates a block device backed by a host file
+//
+// The caller closes the file (Close) when the simulation is over.
+func OpenBlockDevice(path string) (*BlockDevice, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return NewBlockDevice(f, info.Size()), nil
+}
+
+// Close closes the backing store if it can be closed
+func (d *BlockDevice) Close() error {
+	if c, ok := d.store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Read implements Device
+func (d *BlockDevice) Read(offset uint32, size int) uint32 {
+	if offset >= BlockBuffer && offset < BlockBuffer+SectorSize {
+		return readBytes(d.buffer[:], offset-BlockBuffer, size)
+	}
+	switch offset &^ 3 {
+	case BlockSector:
+		return d.sector
+	case BlockStatus:
+		var status uint32
+		if d.cmd != 0 {
+			status |= BlockBusy
+		}
+		if d.err {
+			status |= BlockError
+		}
+		return status
+	case BlockSectors:
+		return d.sectors
+	}
+	return 0
+}
+
+// Write implements Device
+func (d *BlockDevice) Write(offset uint32, size int, value uint32) {
+	if offset >= BlockBuffer && offset < BlockBuffer+SectorSize {
+		writeBytes(d.buffer[:], offset-BlockBuffer, size, value)
+		return
+	}
+	switch offset &^ 3 {
+	case BlockSector:
+		d.sector = value
+	case BlockCmd:
+		if d.cmd == 0 && (value == BlockCmdRead || value == BlockCmdWrite) {
+			d.cmd = value
+			d.cyclesRem = BlockLatency
+		}
+	}
+}
+
+// Tick implements Device: finish a command after BlockLatency cycles
+func (d *BlockDevice) Tick() {
+	if d.cmd == 0 {
+		return
+	}
+	if d.cyclesRem--; d.cyclesRem > 0 {
+		return
+	}
+
+	d.err = d.sector >= d.sectors
+	if !d.err {
+		pos := int64(d.sector) * SectorSize
+		var err error
+		if d.cmd == BlockCmdRead {
+			_, err = d.store.ReadAt(d.buffer[:], pos)
+		} else {
+			_, err = d.store.WriteAt(d.buffer[:], pos)
+		}
+		d.err = err != nil
+	}
+	d.cmd = 0
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// FRAMEBUFFER
+// ───────────────────────────────────────────────────────────────────────────────
+
+// Framebuffer is a width × height screen of 0x00RRGGBB pixels
+type Framebuffer struct {
+	width, height int
+	pixels        []byte // Little-endian words, row-major
+}
+
+// NewFramebuffer creates a black screen
+func NewFramebuffer(width, height int) *Framebuffer {
+	return &Framebuffer{width: width, height: height, pixels: make([]byte, 4*width*height)}
+}
+
+// Size returns the bytes of address space the framebuffer needs
+// (rounded up to whole pages, for MapDevice)
+func (f *Framebuffer) Size() uint32 {
+	return alignUp(uint32(len(f.pixels)), PageSize)
+}
+
+// Read implements Device
+func (f *Framebuffer) Read(offset uint32, size int) uint32 {
+	return readBytes(f.pixels, offset, size)
+}
+
+// Write implements Device
+func (f *Framebuffer) Write(offset uint32, size int, value uint32) {
+	writeBytes(f.pixels, offset, size, value)
+}
+
+// Tick implements Device (a framebuffer has nothing to do on its own)
+func (f *Framebuffer) Tick() {}
+
+// Image returns the current screen contents
+func (f *Framebuffer) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.width, f.height))
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			p := f.pixels[4*(y*f.width+x):]
+			img.SetRGBA(x, y, color.RGBA{R: p[2], G: p[1], B: p[0], A: 0xFF})
+		}
+	}
+	return img
+}
+
+// WritePNG writes the current screen contents as a PNG
+func (f *Framebuffer) WritePNG(w io.Writer) error {
+	return png.Encode(w, f.Image())
+}
+
+// readBytes reads a little-endian value from a device's byte array
+// (out of range bytes read as 0)
+func readBytes(mem []byte, offset uint32, size int) uint32 {
+	var v uint32
+	for i := 0; i < size; i++ {
+		if off := uint64(offset) + uint64(i); off < uint64(len(mem)) {
+			v |= uint32(mem[off]) << (8 * i)
+		}
+	}
+	return v
+}
+
+// writeBytes writes a little-endian value into a device's byte array
+// (out of range bytes are dropped)
+func writeBytes(mem []byte, offset uint32, size int, value uint32) {
+	for i := 0; i < size; i++ {
+		if off := uint64(offset) + uint64(i); off < uint64(len(mem)) {
+			mem[off] = byte(value >> (8 * i))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package suprax32
+
+import "fmt"
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// DISASSEMBLER
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// Turns decoded instructions back into assembly text for traces, the
+// debugger and error messages.
+//
+// SYNTAX:
+//
+//	R-format:  add   r3, r1, r2
+//	I-format:  addi  r1, r0, 10
+//	Loads:     lw    r5, 16(r2)          (also lb, lbu, lh, lhu)
+//	Stores:    sw    r6, 16(r2)          (rs2 is the data register; also sb, sh)
+//	Branches:  beq   r1, r2, 0x1040      (absolute target, PC-relative in the encoding)
+//	Jumps:     jal   r1, 0x1100
+//	           jalr  r0, 0(r1)
+//	Upper:     lui   r4, 0x12
+//	System:    system 1, csrr r4, epc, csrw tvec, r4, eret, tlbflush
+//
+// With a symbol table (Format), branch and jump targets are named:
+//
+//	jal   r1, 0x1100 <strlen>
+//
+// Opcodes without a defined instruction print as "illegal 0x0e".
+
+// opcodeNames maps every 5-bit opcode to its mnemonic ("" = undefined)
+var opcodeNames = [32]string{
+	OpADD: "add", OpSUB: "sub", OpAND: "and", OpOR: "or", OpXOR: "xor",
+	OpSLL: "sll", OpSRL: "srl", OpSRA: "sra",
+	OpMUL: "mul", OpMULH: "mulh", OpDIV: "div", OpREM: "rem",
+	OpSLT: "slt", OpSLTU: "sltu",
+	OpLDN: "ldn", OpSTN: "stn", // Spelled by width in String (lb, sh, ...)
+	OpADDI: "addi", OpLW: "lw", OpSW: "sw",
+	OpBEQ: "beq", OpBNE: "bne", OpBLT: "blt", OpBGE: "bge",
+	OpJAL: "jal", OpJALR: "jalr", OpLUI: "lui",
+	OpANDI: "andi", OpORI: "ori", OpXORI: "xori",
+	OpLR: "lr", OpSC: "sc",
+	OpSYSTEM: "system",
+}
+
+// OpcodeName returns the mnemonic for an opcode ("" if undefined)
+func OpcodeName(op uint8) string {
+	if int(op) >= len(opcodeNames) {
+		return ""
+	}
+	return opcodeNames[op]
+}
+
+// String renders the instruction as assembly text
+func (inst Instruction) String() string {
+	name := OpcodeName(inst.Opcode)
+	if name == "" {
+		return fmt.Sprintf("illegal 0x%02x", inst.Opcode)
+	}
+	if inst.Opcode == OpLDN || inst.Opcode == OpSTN {
+		name = narrowName(inst)
+	}
+
+	switch {
+	case inst.Opcode < OpLDN:
+		return fmt.Sprintf("%-6s r%d, r%d, r%d", name, inst.Rd, inst.Rs1, inst.Rs2)
+
+	case inst.IsBranch:
+		target := uint32(int32(inst.PC) + inst.Imm)
+		return fmt.Sprintf("%-6s r%d, r%d, 0x%x", name, inst.Rs1, inst.Rs2, target)
+
+	case inst.IsLoad:
+		return fmt.Sprintf("%-6s r%d, %d(r%d)", name, inst.Rd, inst.Imm, inst.Rs1)
+
+	case inst.Opcode == OpSW || inst.Opcode == OpSTN:
+		return fmt.Sprintf("%-6s r%d, %d(r%d)", name, inst.Rs2, inst.Imm, inst.Rs1)
+
+	case inst.Opcode == OpSC:
+		return fmt.Sprintf("%-6s r%d, r%d, %d(r%d)", name, inst.Rd, inst.Rs2, inst.Imm, inst.Rs1)
+
+	case inst.Opcode == OpJAL:
+		target := uint32(int32(inst.PC) + inst.Imm)
+		return fmt.Sprintf("%-6s r%d, 0x%x", name, inst.Rd, target)
+
+	case inst.Opcode == OpJALR:
+		return fmt.Sprintf("%-6s r%d, %d(r%d)", name, inst.Rd, inst.Imm, inst.Rs1)
+
+	case inst.Opcode == OpLUI:
+		return fmt.Sprintf("%-6s r%d, 0x%x", name, inst.Rd, uint32(inst.Imm)&0x1FFFF)
+
+	case inst.Opcode == OpSYSTEM:
+		return systemString(inst)
+
+	default:
+		return fmt.Sprintf("%-6s r%d, r%d, %d", name, inst.Rd, inst.Rs1, inst.Imm)
+	}
+}
+
+// Format renders the instruction like String, naming branch and jump
+// targets from a symbol table: "jal r1, 0x1100 <strlen>"
+func (inst Instruction) Format(st *SymbolTable) string {
+	text := inst.String()
+	if inst.IsBranch || inst.Opcode == OpJAL {
+		if name := st.Format(uint32(int32(inst.PC) + inst.Imm)); name != "" {
+			text += " <" + name + ">"
+		}
+	}
+	return text
+}
+
+// narrowName spells an N-format access: l/s, b/h, and u for zero-extend
+func narrowName(inst Instruction) string {
+	name := "s"
+	if inst.IsLoad {
+		name = "l"
+	}
+	if inst.MemSize == 2 {
+		name += "h"
+	} else {
+		name += "b"
+	}
+	if inst.IsLoad && !inst.MemSigned {
+		name += "u"
+	}
+	return name
+}
+
+// Disassemble decodes one instruction word and renders it as assembly
+func Disassemble(word uint32, pc uint32) string {
+	return DecodeInstruction(word, pc).String()
+}
+
+// DisassembleProgram renders a program as one "address: word  text" line
+// per instruction, starting at startAddr
+func DisassembleProgram(program []uint32, startAddr uint32) []string {
+	lines := make([]string, len(program))
+	for i, word := range program {
+		pc := startAddr + uint32(i*4)
+		lines[i] = fmt.Sprintf("%08x: %08x  %s", pc, word, Disassemble(word, pc))
+	}
+	return lines
+}
+
+// systemString renders a system instruction by function (see trap.go)
+func systemString(inst Instruction) string {
+	funct, arg := systemFunct(inst.Imm)
+	switch funct {
+	case SysFnCall:
+		return fmt.Sprintf("%-6s %d", "system", arg)
+	case SysFnCSRRead:
+		return fmt.Sprintf("%-6s r%d, %s", "csrr", inst.Rd, csrName(arg))
+	case SysFnCSRWrite:
+		return fmt.Sprintf("%-6s %s, r%d", "csrw", csrName(arg), inst.Rs1)
+	case SysFnTrapRet:
+		return "eret"
+	case SysFnTLBFlush:
+		return "tlbflush"
+	}
+	return fmt.Sprintf("illegal system 0x%x", uint32(inst.Imm)&0x1FFFF)
+}
+
+// csrName returns a CSR's assembler name, or its number
+func csrName(csr uint32) string {
+	for name, n := range csrNames {
+		if n == csr {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%03x", csr)
+}
@@ -0,0 +1,100 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Disassembler - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Listings are read by people chasing a bug, so each format must print the fields the
+// hardware actually uses: branch and jump targets as absolute addresses, signed offsets as
+// signed, LUI's 17 bits as the unsigned value it shifts in, and narrow accesses under the
+// width they move rather than as some neighbouring instruction.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. FORMAT TESTS
+//    One instruction of each format, fields at their extremes
+//
+// 2. LISTING TESTS
+//    DisassembleProgram addresses and words
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. FORMAT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestDisasm_Formats(t *testing.T) {
+	// WHAT: Each format renders its operands in assembler order, with PC-relative targets
+	//       turned into absolute addresses and immediates sign-extended as decode does
+	// WHY: A listing that shows the wrong register or target sends the reader after a bug
+	//      that is not there
+	// HARDWARE: N/A (tooling over DecodeInstruction)
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	const pc = 0x1000
+	for _, c := range []struct {
+		word uint32
+		want string
+	}{
+		{EncodeRFormat(OpADD, 3, 1, 2), "add    r3, r1, r2"},
+		{EncodeRFormat(OpSLTU, 31, 0, 31), "sltu   r31, r0, r31"},
+		{EncodeIFormat(OpADDI, 1, 0, -65536), "addi   r1, r0, -65536"},
+		{EncodeIFormat(OpXORI, 31, 31, 65535), "xori   r31, r31, 65535"},
+		{EncodeIFormat(OpLW, 5, 2, 16), "lw     r5, 16(r2)"},
+		{EncodeIFormat(OpLW, 5, 2, -4), "lw     r5, -4(r2)"},
+		{EncodeBFormat(OpBEQ, 1, 2, 0x40), "beq    r1, r2, 0x1040"},
+		{EncodeBFormat(OpBLT, 3, 4, -0x1000), "blt    r3, r4, 0x0"},
+		{EncodeBFormat(OpBNE, 31, 0, -65536), "bne    r31, r0, 0xffff1000"},
+		{EncodeIFormat(OpJAL, 1, 0, 0x100), "jal    r1, 0x1100"},
+		{EncodeIFormat(OpJALR, 0, 1, 0), "jalr   r0, 0(r1)"},
+		{EncodeIFormat(OpLUI, 4, 0, 0x12), "lui    r4, 0x12"},
+		{EncodeIFormat(OpLUI, 4, 0, 0x10000), "lui    r4, 0x10000"}, // Upper half of 0x80000000
+		{EncodeIFormat(OpSYSTEM, 0, 0, 1), "system 1"},
+		{EncodeNFormat(OpLDN, 5, 2, FnByte, -1), "lb     r5, -1(r2)"},
+		{EncodeNFormat(OpLDN, 5, 2, FnHalfU, 2), "lhu    r5, 2(r2)"},
+		{EncodeNFormat(OpSTN, 6, 2, FnHalf, 16), "sh     r6, 16(r2)"},
+	} {
+		if got := Disassemble(c.word, pc); got != c.want {
+			t.Errorf("Disassemble(%#08x) = %q, expected %q", c.word, got, c.want)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. LISTING TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestDisasm_ProgramListing(t *testing.T) {
+	// WHAT: Each listing line carries its address, the raw word and the text, with branch
+	//       targets computed from that line's own address
+	// WHY: Traces and the debugger match listings to PCs by these columns
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	prog := []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 3),
+		EncodeBFormat(OpBNE, 1, 0, -4),
+		EncodeIFormat(OpJAL, 0, 0, 0),
+	}
+	want := []string{
+		fmt.Sprintf("00002000: %08x  addi   r1, r0, 3", prog[0]),
+		fmt.Sprintf("00002004: %08x  bne    r1, r0, 0x2000", prog[1]),
+		fmt.Sprintf("00002008: %08x  jal    r0, 0x2008", prog[2]),
+	}
+	got := DisassembleProgram(prog, 0x2000)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("listing:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
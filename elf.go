@@ -0,0 +1,339 @@
+package suprax32
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// ELF32 EXECUTABLES
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY ELF: LoadProgram takes one flat blob at one address. Real programs
+//
+//	have separate code and data at fixed addresses, a bss that only
+//	exists in memory, an entry point that is not the first word, and
+//	symbols we want to see in traces. ELF carries all of that and every
+//	binutils-style tool can inspect it.
+//
+// WHAT WE USE:
+//
+//	ELFCLASS32, little-endian, ET_EXEC, e_machine = EM_SUPRAX
+//	PT_LOAD segments      → copied to p_vaddr, p_memsz - p_filesz zeroed
+//	e_entry               → initial PC
+//	.symtab / .strtab     → Core.Symbols() (STT_FUNC, STT_OBJECT, SHN_ABS)
+//
+// INITIAL STACK (Core.LoadELF), growing down from the top of memory:
+//
+//	top → argv and envp strings (NUL-terminated)
+//	      ...padding to 16 bytes...
+//	      NULL
+//	      envp[envc-1] ... envp[0]
+//	      NULL
+//	      argv[argc-1] ... argv[0]
+//	sp  → argc                     (sp is 16-byte aligned, held in RegSP)
+//
+// MINECRAFT ANALOGY: A structure file instead of a list of block placements
+//
+//	It knows where each part goes, which chests start empty, and where
+//	the player spawns.
+
+// EM_SUPRAX is the ELF machine number for SUPRAX-32 executables
+//
+// Not assigned by the ELF registry; 0x5358 is "SX" and outside the
+// range used by real architectures.
+const EM_SUPRAX elf.Machine = 0x5358
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// WRITER
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// WriteELF writes the image as an ELF32 executable
+//
+// ALGORITHM:
+//
+//	STEP 1: ELF header, then one PT_LOAD per segment
+//	STEP 2: Segment contents (bss segments have no file bytes)
+//	STEP 3: .symtab, .strtab and .shstrtab
+//	STEP 4: Section headers (one per segment, then the three tables)
+func (img *Image) WriteELF(w io.Writer) error {
+	const (
+		ehsize    = 52
+		phentsize = 32
+		shentsize = 40
+		symsize   = 16
+	)
+	var body bytes.Buffer
+	le := binary.LittleEndian
+	phoff := uint32(ehsize)
+	off := phoff + uint32(len(img.Segments))*phentsize
+
+	// STEP 1-2: Program headers and segment contents
+	progs := make([]elf.Prog32, len(img.Segments))
+	shdrs := []elf.Section32{{}} // Index 0 is the null section
+	shstr := []byte{0}
+	name := func(s string) uint32 {
+		idx := uint32(len(shstr))
+		shstr = append(append(shstr, s...), 0)
+		return idx
+	}
+	for i, seg := range img.Segments {
+		flags, shFlags := elf.PF_R|elf.PF_W, elf.SHF_ALLOC|elf.SHF_WRITE
+		shType := elf.SHT_PROGBITS
+		if seg.Name == SecText.String() {
+			flags, shFlags = elf.PF_R|elf.PF_X, elf.SHF_ALLOC|elf.SHF_EXECINSTR
+		}
+		if len(seg.Data) == 0 {
+			shType = elf.SHT_NOBITS
+		}
+		progs[i] = elf.Prog32{
+			Type: uint32(elf.PT_LOAD), Off: off, Vaddr: seg.Addr, Paddr: seg.Addr,
+			Filesz: uint32(len(seg.Data)), Memsz: seg.Size, Flags: uint32(flags), Align: 4,
+		}
+		shdrs = append(shdrs, elf.Section32{
+			Name: name(seg.Name), Type: uint32(shType), Flags: uint32(shFlags),
+			Addr: seg.Addr, Off: off, Size: seg.Size, Addralign: 4,
+		})
+		body.Write(seg.Data)
+		off += uint32(len(seg.Data))
+	}
+
+	// STEP 3: Symbol and string tables
+	strtab := []byte{0}
+	symtab := make([]byte, symsize) // Entry 0 is the null symbol
+	for _, s := range img.SymbolTable().Symbols() {
+		shndx, typ := uint16(elf.SHN_ABS), elf.STT_NOTYPE
+		if s.Kind != SymAbs {
+			for i := range img.Segments {
+				if img.segmentAt(s.Addr) == &img.Segments[i] {
+					shndx = uint16(i + 1)
+				}
+			}
+			typ = elf.STT_OBJECT
+			if s.Kind == SymFunc {
+				typ = elf.STT_FUNC
+			}
+		}
+		symtab = le.AppendUint32(symtab, uint32(len(strtab)))
+		symtab = le.AppendUint32(symtab, s.Addr)
+		symtab = le.AppendUint32(symtab, s.Size)
+		symtab = append(symtab, elf.ST_INFO(elf.STB_GLOBAL, typ), 0)
+		symtab = le.AppendUint16(symtab, shndx)
+		strtab = append(append(strtab, s.Name...), 0)
+	}
+
+	symIdx := uint32(len(shdrs))
+	shdrs = append(shdrs, elf.Section32{
+		Name: name(".symtab"), Type: uint32(elf.SHT_SYMTAB), Off: off, Size: uint32(len(symtab)),
+		Link: symIdx + 1, Info: 1, Addralign: 4, Entsize: symsize,
+	})
+	body.Write(symtab)
+	off += uint32(len(symtab))
+
+	shdrs = append(shdrs, elf.Section32{
+		Name: name(".strtab"), Type: uint32(elf.SHT_STRTAB), Off: off, Size: uint32(len(strtab)), Addralign: 1,
+	})
+	body.Write(strtab)
+	off += uint32(len(strtab))
+
+	shstrIdx := uint16(len(shdrs))
+	shdrs = append(shdrs, elf.Section32{
+		Name: name(".shstrtab"), Type: uint32(elf.SHT_STRTAB), Off: off, Addralign: 1,
+	})
+	shdrs[shstrIdx].Size = uint32(len(shstr))
+	body.Write(shstr)
+	off += uint32(len(shstr))
+
+	// STEP 4: Section headers go last, word-aligned
+	for off%4 != 0 {
+		body.WriteByte(0)
+		off++
+	}
+
+	hdr := elf.Header32{
+		Type: uint16(elf.ET_EXEC), Machine: uint16(EM_SUPRAX), Version: uint32(elf.EV_CURRENT),
+		Entry: img.Entry, Phoff: phoff, Shoff: off,
+		Ehsize: ehsize, Phentsize: phentsize, Phnum: uint16(len(progs)),
+		Shentsize: shentsize, Shnum: uint16(len(shdrs)), Shstrndx: shstrIdx,
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS32)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	for _, v := range []any{hdr, progs} {
+		if err := binary.Write(w, le, v); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(body.Bytes()); err != nil {
+		return err
+	}
+	return binary.Write(w, le, shdrs)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// LOADER
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// LoadELF loads a SUPRAX-32 ELF executable and prepares it to run
+//
+// ALGORITHM:
+//
+//	STEP 1: Check class, byte order, type and machine
+//	STEP 2: Check every PT_LOAD segment fits in memory without overlapping
+//	        another, and read its file bytes
+//	STEP 3: Read .symtab for Core.Symbols() (absent symbols are fine)
+//	STEP 4: Copy each segment to its virtual address, zero bss
+//	STEP 5: Build argc/argv/envp at the top of memory, set RegSP
+//	STEP 6: PC = e_entry
+//
+// Everything is read and checked before memory is written, so a malformed
+// file leaves the core as it was.
+func (c *Core) LoadELF(r io.ReaderAt, argv, envp []string) error {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// STEP 1: Is this ours?
+	switch {
+	case f.Class != elf.ELFCLASS32:
+		return fmt.Errorf("elf: %v, want ELFCLASS32", f.Class)
+	case f.Data != elf.ELFDATA2LSB:
+		return fmt.Errorf("elf: %v, want little-endian", f.Data)
+	case f.Type != elf.ET_EXEC:
+		return fmt.Errorf("elf: %v, want ET_EXEC", f.Type)
+	case f.Machine != EM_SUPRAX:
+		return fmt.Errorf("elf: machine %#x, want EM_SUPRAX (%#x)", uint16(f.Machine), uint16(EM_SUPRAX))
+	}
+
+	// STEP 2: Check the layout and read the segments
+	var loads []*elf.Prog
+	var layout []Segment
+	var contents [][]byte
+	for _, p := range f.Progs {
+		if p.Type != elf.PT_LOAD {
+			continue
+		}
+		switch {
+		case p.Filesz > p.Memsz:
+			return fmt.Errorf("elf: segment at 0x%08x has 0x%x file bytes but only 0x%x in memory",
+				p.Vaddr, p.Filesz, p.Memsz)
+		case p.Vaddr+p.Memsz > uint64(len(c.memory)):
+			return fmt.Errorf("elf: segment at 0x%08x+0x%x does not fit in %d bytes of memory",
+				p.Vaddr, p.Memsz, len(c.memory))
+		case p.Memsz == 0:
+			continue // Nothing to map, and nothing to overlap
+		}
+		data := make([]byte, p.Filesz) // Bounded by memory size above
+		if _, err := io.ReadFull(p.Open(), data); err != nil {
+			return fmt.Errorf("elf: reading segment at 0x%08x: %w", p.Vaddr, err)
+		}
+		loads = append(loads, p)
+		contents = append(contents, data)
+		layout = append(layout, Segment{
+			Name: fmt.Sprintf("segment %d", len(layout)), Addr: uint32(p.Vaddr), Size: uint32(p.Memsz),
+		})
+	}
+	if err := checkOverlap(layout); err != nil {
+		return fmt.Errorf("elf: %w", err)
+	}
+
+	// STEP 3: Symbols for the disassembler, tracer and profiler
+	syms, err := f.Symbols()
+	if err != nil && !errors.Is(err, elf.ErrNoSymbols) {
+		return fmt.Errorf("elf: %w", err)
+	}
+	var entries []SymbolEntry
+	for _, s := range syms {
+		e := SymbolEntry{Name: s.Name, Addr: uint32(s.Value), Size: uint32(s.Size)}
+		switch {
+		case s.Name == "" || s.Section == elf.SHN_UNDEF:
+			continue
+		case s.Section == elf.SHN_ABS:
+			e.Kind = SymAbs
+		case elf.ST_TYPE(s.Info) == elf.STT_FUNC:
+			e.Kind = SymFunc
+		case elf.ST_TYPE(s.Info) == elf.STT_OBJECT, elf.ST_TYPE(s.Info) == elf.STT_NOTYPE:
+			e.Kind = SymObject
+			if sec := int(s.Section); sec < len(f.Sections) && f.Sections[sec].Flags&elf.SHF_EXECINSTR != 0 {
+				e.Kind = SymFunc // Assembly labels in code are untyped
+			}
+		default:
+			continue // Sections, files, TLS
+		}
+		entries = append(entries, e)
+	}
+
+	// STEP 4: Map the segments
+	top := uint64(0)
+	for i, p := range loads {
+		end := p.Vaddr + p.Memsz
+		mem := c.memory[p.Vaddr:end]
+		clear(mem[copy(mem, contents[i]):])
+		top = max(top, end)
+	}
+
+	// STEP 5: Stack with argc, argv and envp
+	sp, err := c.initStack(argv, envp, top)
+	if err != nil {
+		return fmt.Errorf("elf: %w", err)
+	}
+
+	c.SetSymbols(NewSymbolTable(entries))
+	c.window.regFile[RegSP] = sp
+
+	// STEP 6: Entry point
+	c.pc = uint32(f.Entry)
+	c.archPC = uint32(f.Entry)
+	return nil
+}
+
+// initStack writes the initial stack below the top of memory
+//
+// Returns the stack pointer (pointing at argc). The whole block must lie
+// above imageEnd so it does not overwrite loaded segments.
+func (c *Core) initStack(argv, envp []string, imageEnd uint64) (uint32, error) {
+	le := binary.LittleEndian
+
+	// Size the block first: strings, pointer vectors, alignment slack
+	need := uint64(4 * (len(argv) + len(envp) + 3))
+	for _, s := range append(append([]string(nil), argv...), envp...) {
+		need += uint64(len(s) + 1)
+	}
+	top := uint64(len(c.memory)) &^ (StackAlign - 1)
+	if top < imageEnd || top-imageEnd < need+StackAlign {
+		return 0, fmt.Errorf("no room for the initial stack above 0x%08x", imageEnd)
+	}
+
+	// Strings first (highest addresses), remembering where each landed
+	addr := top
+	place := func(strs []string) []uint32 {
+		ptrs := make([]uint32, len(strs))
+		for i, s := range strs {
+			addr -= uint64(len(s) + 1)
+			ptrs[i] = uint32(addr)
+			copy(c.memory[addr:], s)
+			c.memory[addr+uint64(len(s))] = 0
+		}
+		return ptrs
+	}
+	envPtrs := place(envp)
+	argPtrs := place(argv)
+
+	// Then argc, argv[], NULL, envp[], NULL with sp 16-byte aligned
+	words := []uint32{uint32(len(argv))}
+	words = append(append(words, argPtrs...), 0)
+	words = append(append(words, envPtrs...), 0)
+	addr = (addr - uint64(len(words)*4)) &^ (StackAlign - 1)
+	for i, word := range words {
+		le.PutUint32(c.memory[addr+uint64(i*4):], word)
+	}
+	return uint32(addr), nil
+}
@@ -0,0 +1,301 @@
+package suprax32
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX ELF Loader - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// An ELF file is input from outside the simulator, and every field in it can lie. Each test
+// writes a good executable with WriteELF, changes one field, and checks that LoadELF says no
+// with an error instead of panicking or half-loading: memory and the PC must be exactly as
+// they were. The good file must load byte for byte, zero its bss over stale memory, and give
+// the disassembler, pipeline tracer and CPI profiler the same names the linker produced.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. ROUND TRIP TESTS
+//    Segments, entry point and bss through WriteELF and LoadELF
+//
+// 2. REJECTION TESTS
+//    Foreign headers, offsets past the end of the file, bad segment layouts
+//
+// 3. SYMBOL TESTS
+//    Names in the disassembler, pipeline trace and CPI regions
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ELF32 field offsets in WriteELF's output, for corrupting one field at a time
+const (
+	elfClass   = 4  // e_ident[EI_CLASS]
+	elfData    = 5  // e_ident[EI_DATA]
+	elfType    = 16 // e_type
+	elfMachine = 18 // e_machine
+	elfPhoff   = 28 // e_phoff
+	elfShoff   = 32 // e_shoff
+	elfPhdr    = 52 // First program header (32 bytes each)
+	elfShent   = 40 // Section header size
+
+	phOffset = 4  // p_offset
+	phVaddr  = 8  // p_vaddr
+	phFilesz = 16 // p_filesz
+	phMemsz  = 20 // p_memsz
+	shOffset = 16 // sh_offset
+)
+
+// elfProgram is linked to: .text at 0x1000 (call helper, spin, helper), .data with one word
+// and a 64-byte .bss
+//
+//	_start 0x1000  spin 0x1004  helper 0x1008  table (.data)  buf (.bss)
+const elfProgram = `
+	.text
+	.globl _start, spin, helper, table, buf
+_start:
+	call helper
+spin:
+	j    spin
+helper:
+	la   t0, table
+	lw   a0, 0(t0)
+	la   t0, buf
+	lw   a1, 60(t0)
+	ret
+	.data
+table:
+	.word 0x12345678
+	.bss
+buf:
+	.space 64
+`
+
+// elfFile links elfProgram and writes it as ELF
+func elfFile(t *testing.T) (*Image, []byte) {
+	t.Helper()
+	obj, err := Assemble("elf.s", elfProgram)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	img, err := Link([]*Object{obj}, nil)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := img.WriteELF(&buf); err != nil {
+		t.Fatalf("WriteELF: %v", err)
+	}
+	return img, buf.Bytes()
+}
+
+// elfSection returns the file offset of the named section's header
+func elfSection(t *testing.T, file []byte, name string) int {
+	t.Helper()
+	le := binary.LittleEndian
+	shoff := int(le.Uint32(file[elfShoff:]))
+	shnum := int(le.Uint16(file[48:]))
+	shstr := shoff + int(le.Uint16(file[50:]))*elfShent
+	names := file[le.Uint32(file[shstr+shOffset:]):]
+	for i := 0; i < shnum; i++ {
+		at := shoff + i*elfShent
+		n := names[le.Uint32(file[at:]):]
+		if string(n[:bytes.IndexByte(n, 0)]) == name {
+			return at
+		}
+	}
+	t.Fatalf("no section %s", name)
+	return 0
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. ROUND TRIP TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestELF_RoundTrip(t *testing.T) {
+	// WHAT: Every segment lands at its address with its bytes, bss is zeroed over stale
+	//       memory, the PC starts at the entry point, and the program reads both
+	// WHY: WriteELF and LoadELF are each other's only test partner; an off-by-one in a
+	//      file offset or a bss left dirty shows up as wrong data, not as an error
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	img, file := elfFile(t)
+	core := NewCore(1 << 20)
+	buf := img.Symbols["buf"]
+	for a := buf; a < buf+64; a++ {
+		core.memory[a] = 0xA5 // Stale contents the loader must clear
+	}
+	if err := core.LoadELF(bytes.NewReader(file), nil, nil); err != nil {
+		t.Fatalf("LoadELF: %v", err)
+	}
+
+	for _, seg := range img.Segments {
+		want := append(append([]byte(nil), seg.Data...), make([]byte, int(seg.Size)-len(seg.Data))...)
+		if got := core.ReadMem(seg.Addr, int(seg.Size)); !bytes.Equal(got, want) {
+			t.Errorf("%s at 0x%x = % x, expected % x", seg.Name, seg.Addr, got, want)
+		}
+	}
+	if core.pc != img.Entry || core.archPC != img.Entry {
+		t.Errorf("PC = 0x%x (arch 0x%x), expected the entry point 0x%x", core.pc, core.archPC, img.Entry)
+	}
+
+	dbg := NewDebugger(core)
+	dbg.Break(img.Symbols["spin"])
+	if ev := dbg.Continue(100_000); ev.Reason != StopBreakpoint {
+		t.Fatalf("stopped with %v, expected helper to return to spin", ev)
+	}
+	if regs := dbg.Registers(); regs[RegA0] != 0x12345678 || regs[RegA0+1] != 0 {
+		t.Errorf("a0 = %#x, a1 = %#x, expected .data's word and a zero from .bss", regs[RegA0], regs[RegA0+1])
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. REJECTION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestELF_RejectsMalformedFiles(t *testing.T) {
+	// WHAT: A file with one bad field fails to load with an error, and memory and the PC
+	//       are left exactly as they were: foreign class, byte order, type or machine;
+	//       header, segment or symbol table offsets past the end; more file bytes than
+	//       memory bytes; segments that overlap or run off the end of memory
+	// WHY: The loader indexes memory with numbers from the file. Before the layout checks
+	//      an overlapping segment silently overwrote another, and any check done after
+	//      copying leaves a half-loaded core behind the error
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	_, good := elfFile(t)
+	le := binary.LittleEndian
+	text, data := elfPhdr, elfPhdr+32 // .text and .data program headers
+
+	for _, c := range []struct {
+		name  string
+		patch func(f []byte)
+		want  string // Error substring ("" = any error)
+	}{
+		{"ELFCLASS64", func(f []byte) { f[elfClass] = 2 }, ""},
+		{"big-endian", func(f []byte) { f[elfData] = 2 }, ""},
+		{"ET_DYN", func(f []byte) { le.PutUint16(f[elfType:], 3) }, "ET_EXEC"},
+		{"EM_RISCV", func(f []byte) { le.PutUint16(f[elfMachine:], 243) }, "EM_SUPRAX"},
+		{"phdrs past EOF", func(f []byte) { le.PutUint32(f[elfPhoff:], uint32(len(f))) }, ""},
+		{"shdrs past EOF", func(f []byte) { le.PutUint32(f[elfShoff:], uint32(len(f))) }, ""},
+		{"segment past EOF", func(f []byte) { le.PutUint32(f[data+phOffset:], uint32(len(f))-2) }, "reading segment"},
+		{"symtab past EOF", func(f []byte) {
+			le.PutUint32(f[elfSection(t, f, ".symtab")+shOffset:], uint32(len(f)))
+		}, ""},
+		{"filesz > memsz", func(f []byte) { le.PutUint32(f[text+phMemsz:], le.Uint32(f[text+phFilesz:])-4) }, "file bytes"},
+		{"overlapping segments", func(f []byte) { le.PutUint32(f[data+phVaddr:], 0x1004) }, "overlaps"},
+		{"past end of memory", func(f []byte) { le.PutUint32(f[data+phVaddr:], 1<<20-2) }, "does not fit"},
+		{"past 4GB", func(f []byte) { le.PutUint32(f[data+phVaddr:], 0xFFFFFFF0) }, "does not fit"},
+		{"huge memsz", func(f []byte) { le.PutUint32(f[data+phMemsz:], 0xFFFFFFFF) }, "does not fit"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			file := append([]byte(nil), good...)
+			c.patch(file)
+
+			core := NewCore(1 << 20)
+			for a := range core.memory {
+				core.memory[a] = byte(a) // Anything the loader writes shows up
+			}
+			core.pc, core.archPC = 0x4321, 0x4321
+			before := append([]byte(nil), core.memory...)
+
+			err := core.LoadELF(bytes.NewReader(file), []string{"prog"}, nil)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("LoadELF: %v, expected an error containing %q", err, c.want)
+			}
+			if !bytes.Equal(core.memory, before) {
+				t.Errorf("memory changed by a failed load (%v)", err)
+			}
+			if core.pc != 0x4321 || core.archPC != 0x4321 {
+				t.Errorf("PC moved to 0x%x by a failed load", core.pc)
+			}
+		})
+	}
+
+	// The unpatched file still loads, so each case above failed on its own field
+	if err := NewCore(1<<20).LoadELF(bytes.NewReader(good), nil, nil); err != nil {
+		t.Fatalf("unmodified file: %v", err)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. SYMBOL TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestELF_SymbolsReachTools(t *testing.T) {
+	// WHAT: After LoadELF, the disassembler names call targets, the pipeline trace labels
+	//       instructions with their function, and AddCPIFunctionRegions makes one region
+	//       per function with the linker's bounds
+	// WHY: Symbols are the reason to load ELF rather than a flat blob; a symbol table that
+	//      loads but has the wrong kinds or sizes leaves all three tools showing addresses
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	img, file := elfFile(t)
+	core := NewCore(1 << 20)
+	if err := core.LoadELF(bytes.NewReader(file), nil, nil); err != nil {
+		t.Fatalf("LoadELF: %v", err)
+	}
+	st := core.Symbols()
+	helper := img.Symbols["helper"]
+
+	// Disassembler: the call names its target
+	call := DecodeInstruction(core.ReadMemWord(img.Entry), img.Entry)
+	if got := call.Format(st); !strings.HasSuffix(got, "<helper>") {
+		t.Errorf("call disassembles as %q, expected a <helper> target", got)
+	}
+	if got := st.Format(helper + 8); got != "helper+0x8" {
+		t.Errorf("Format(helper+8) = %q, expected helper+0x8", got)
+	}
+	if got := st.Format(img.Symbols["table"]); got != "table" {
+		t.Errorf("Format(table) = %q, expected the .data object", got)
+	}
+
+	// Pipeline trace and CPI regions from the same run
+	core.AddCPIFunctionRegions()
+	var log bytes.Buffer
+	tracer := NewPipeTracer(&log)
+	core.SetTracer(tracer)
+	core.Run(500)
+	if err := tracer.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	for _, want := range []string{"00001000 <_start>: ", "00001004 <spin>: ", "00001008 <helper>: ", "0000100c <helper+0x4>: "} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("trace has no %q label", want)
+		}
+	}
+
+	regions := map[string]CPIRegion{}
+	for _, r := range core.CPIRegions() {
+		regions[r.Name] = r
+	}
+	text := img.Segments[0]
+	for _, want := range []CPIRegion{
+		{Name: "_start", Start: 0x1000, End: 0x1004},
+		{Name: "spin", Start: 0x1004, End: helper},
+		{Name: "helper", Start: helper, End: text.Addr + text.Size},
+	} {
+		r, ok := regions[want.Name]
+		if !ok || r.Start != want.Start || r.End != want.End {
+			t.Errorf("region %s = [0x%x, 0x%x) (present %v), expected [0x%x, 0x%x)", want.Name, r.Start, r.End, ok, want.Start, want.End)
+			continue
+		}
+		if r.Stack.Retiring == 0 {
+			t.Errorf("region %s retired nothing", want.Name)
+		}
+	}
+	if _, ok := regions["table"]; ok {
+		t.Error("the .data object table became a CPI region")
+	}
+}
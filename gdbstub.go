@@ -0,0 +1,681 @@
+package suprax32
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// GDB REMOTE STUB
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY A GDB STUB:
+//
+// Everyone already knows gdb. Rather than teach a new command set, we speak
+// gdb's Remote Serial Protocol (RSP) and let gdb drive the Debugger.
+//
+// THE PROTOCOL:
+//
+//	Packet:     $<payload>#<checksum>   checksum = sum of payload bytes mod 256
+//	Ack:        + (good) or - (resend), until QStartNoAckMode
+//	Interrupt:  a bare 0x03 byte (Ctrl-C) while the target runs
+//
+// WHAT WE SERVE:
+//
+//	?                     last stop reason
+//	g / G                 read / write all registers (r0-r31, pc)
+//	p n / P n=v           read / write one register
+//	m addr,len            read memory   (through the L1D, see Core.ReadMem)
+//	M addr,len:hex        write memory  (memory + L1D + L1I, see Core.WriteMem)
+//	                      Addresses are virtual when paging is on (each page
+//	                      translated, see Core.Translate). A range on a device
+//	                      goes through the bus, with the device's side effects
+//	                      (see Bus.debugRead)
+//	c [addr] / s [addr]   continue / step one committed instruction
+//	Z0/z0, Z1/z1          breakpoints   (Debugger.Break at the commit boundary)
+//	Z2-Z4 / z2-z4         watchpoints   (write, read, access)
+//	qXfer:features:read   target description (gdbTargetXML)
+//	D / k                 detach / kill (ends the session)
+//
+// Anything else gets the empty reply, which tells gdb "not supported".
+//
+// REGISTER ORDER (the 'g' packet and target.xml):
+//
+//	0-31  r0-r31   32-bit, little-endian hex
+//	32    pc       architectural PC (next instruction to commit)
+//
+// BREAKPOINTS ARE NOT PATCHED INTO MEMORY:
+//
+//	The ISA has no trap instruction to patch in, and the Debugger already
+//	stops at the commit boundary before a breakpoint PC commits. So Z0 is
+//	just Debugger.Break; memory reads never show breakpoint bytes.
+//
+// STATE CHANGES WHILE STOPPED:
+//
+//	Younger instructions may already have read a register or a memory word
+//	that gdb now overwrites. After every write we squash the window and
+//	refetch from the architectural PC (Debugger.SetPC), so the new value is
+//	what the rest of the program sees. Stores never run ahead of a stop
+//	(Core.gateStore), so nothing younger has touched memory.
+//
+// USAGE:
+//
+//	stub := NewGDBStub(NewDebugger(core))
+//	stub.ListenAndServe("tcp", "localhost:1234")   // gdb: target remote :1234
+//
+// Or over a pipe (gdb: target remote | suprax-sim --gdb-stdio):
+//
+//	stub.Serve(struct{ io.Reader; io.Writer }{os.Stdin, os.Stdout})
+//
+// MINECRAFT ANALOGY: A server console that speaks the standard RCON protocol
+//
+//	Any RCON client can pause, inspect and edit the world; the server
+//	doesn't care which client it is.
+
+// GDB signal numbers used in stop replies
+const (
+	gdbSigInt  = 2  // SIGINT: interrupted by Ctrl-C
+	gdbSigTrap = 5  // SIGTRAP: breakpoint, watchpoint or step
+	gdbPCReg   = 32 // Register number of the pc in the 'g' packet
+	gdbNumRegs = 33
+
+	// gdbRunChunk is how many cycles continue runs between interrupt checks
+	gdbRunChunk = 10000
+)
+
+// gdbEvent is one item read from the connection
+type gdbEvent struct {
+	packet    string
+	interrupt bool  // Bare 0x03 byte
+	bad       bool  // Checksum mismatch: ask for a resend
+	err       error // Connection closed or failed
+}
+
+// GDBStub serves the GDB remote protocol for one Debugger
+type GDBStub struct {
+	dbg *Debugger
+
+	// Per-session state
+	w        io.Writer
+	events   chan gdbEvent
+	done     chan struct{}
+	queued   []gdbEvent // Packets that arrived while the target was running
+	noAck    bool       // QStartNoAckMode negotiated
+	swbreak  bool       // gdb accepts "swbreak" in stop replies
+	lastStop string     // Reply to '?'
+}
+
+// NewGDBStub creates a stub that controls the core through dbg
+func NewGDBStub(dbg *Debugger) *GDBStub {
+	return &GDBStub{dbg: dbg}
+}
+
+// ListenAndServe accepts gdb connections one at a time until Accept fails
+func (s *GDBStub) ListenAndServe(network, addr string) error {
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		err = s.Serve(conn)
+		conn.Close()
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+}
+
+// Serve runs one gdb session on rw until detach, kill or end of input
+func (s *GDBStub) Serve(rw io.ReadWriter) error {
+	s.w = rw
+	s.events = make(chan gdbEvent, 16)
+	s.done = make(chan struct{})
+	s.queued = nil
+	s.noAck = false
+	s.swbreak = false
+	s.lastStop = fmt.Sprintf("S%02x", gdbSigTrap)
+	defer close(s.done)
+
+	go s.readLoop(bufio.NewReader(rw))
+
+	for {
+		ev := s.next()
+		switch {
+		case ev.err != nil:
+			return ev.err
+		case ev.interrupt:
+			continue // Already stopped
+		case ev.bad:
+			if err := s.writeRaw("-"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !s.noAck {
+			if err := s.writeRaw("+"); err != nil {
+				return err
+			}
+		}
+
+		reply, quit := s.handle(ev.packet)
+		if ev.packet == "k" {
+			return nil // Kill expects no reply
+		}
+		if err := s.send(reply); err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// next returns the oldest queued event, or waits for one
+func (s *GDBStub) next() gdbEvent {
+	if len(s.queued) > 0 {
+		ev := s.queued[0]
+		s.queued = s.queued[1:]
+		return ev
+	}
+	return <-s.events
+}
+
+// readLoop splits the byte stream into events
+//
+// Runs in its own goroutine so Ctrl-C can be seen while the core runs.
+func (s *GDBStub) readLoop(r *bufio.Reader) {
+	emit := func(ev gdbEvent) bool {
+		select {
+		case s.events <- ev:
+			return true
+		case <-s.done:
+			return false
+		}
+	}
+
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			emit(gdbEvent{err: err})
+			return
+		}
+
+		switch b {
+		case 0x03:
+			if !emit(gdbEvent{interrupt: true}) {
+				return
+			}
+		case '$':
+			payload, err := r.ReadString('#')
+			if err != nil {
+				emit(gdbEvent{err: err})
+				return
+			}
+			payload = payload[:len(payload)-1]
+
+			sum := make([]byte, 2)
+			if _, err := io.ReadFull(r, sum); err != nil {
+				emit(gdbEvent{err: err})
+				return
+			}
+			want, err := strconv.ParseUint(string(sum), 16, 8)
+			ok := err == nil && uint8(want) == gdbChecksum(payload)
+			if !emit(gdbEvent{packet: gdbUnescape(payload), bad: !ok}) {
+				return
+			}
+		default:
+			// '+' / '-' acks and line noise: we never resend, so ignore
+		}
+	}
+}
+
+// interrupted reports whether gdb sent Ctrl-C, queueing anything else
+func (s *GDBStub) interrupted() (bool, error) {
+	for {
+		select {
+		case ev := <-s.events:
+			if ev.err != nil {
+				return false, ev.err
+			}
+			if ev.interrupt {
+				return true, nil
+			}
+			s.queued = append(s.queued, ev)
+		default:
+			return false, nil
+		}
+	}
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Packet dispatch
+// ───────────────────────────────────────────────────────────────────────────────
+
+// handle executes one packet and returns the reply payload
+func (s *GDBStub) handle(pkt string) (reply string, quit bool) {
+	if pkt == "" {
+		return "", false
+	}
+
+	d := s.dbg
+	cmd, args := pkt[0], pkt[1:]
+	switch cmd {
+	case '?':
+		return s.lastStop, false
+
+	case 'g':
+		var sb strings.Builder
+		for reg := 0; reg < gdbNumRegs; reg++ {
+			sb.WriteString(gdbHexWord(s.readReg(reg)))
+		}
+		return sb.String(), false
+
+	case 'G':
+		if len(args) < gdbNumRegs*8 {
+			return "E01", false
+		}
+		for reg := 0; reg < gdbNumRegs; reg++ {
+			v, ok := gdbParseWord(args[reg*8 : reg*8+8])
+			if !ok {
+				return "E01", false
+			}
+			s.writeReg(reg, v)
+		}
+		d.SetPC(d.PC()) // Younger work may have read the old values
+		return "OK", false
+
+	case 'p':
+		reg, err := strconv.ParseUint(args, 16, 32)
+		if err != nil || reg >= gdbNumRegs {
+			return "E01", false
+		}
+		return gdbHexWord(s.readReg(int(reg))), false
+
+	case 'P':
+		regStr, valStr, found := strings.Cut(args, "=")
+		reg, err := strconv.ParseUint(regStr, 16, 32)
+		v, ok := gdbParseWord(valStr)
+		if !found || err != nil || reg >= gdbNumRegs || !ok {
+			return "E01", false
+		}
+		s.writeReg(int(reg), v)
+		d.SetPC(d.PC())
+		return "OK", false
+
+	case 'm':
+		addr, n, ok := gdbParseAddrLen(args)
+		if !ok {
+			return "E01", false
+		}
+		data, ok := s.readMem(addr, n)
+		if !ok {
+			return "E01", false
+		}
+		return hex.EncodeToString(data), false
+
+	case 'M':
+		head, data, found := strings.Cut(args, ":")
+		addr, n, ok := gdbParseAddrLen(head)
+		raw, err := hex.DecodeString(data)
+		if !found || !ok || err != nil || uint32(len(raw)) != n || !s.writeMem(addr, raw) {
+			return "E01", false
+		}
+		d.SetPC(d.PC()) // Younger loads may have read the old bytes
+		return "OK", false
+
+	case 'c', 's':
+		if args != "" {
+			addr, err := strconv.ParseUint(args, 16, 32)
+			if err != nil {
+				return "E01", false
+			}
+			d.SetPC(uint32(addr))
+		}
+		if cmd == 's' {
+			return s.stopReply(d.StepInstructions(1, defaultRunCycles), false), false
+		}
+		return s.resume(), false
+
+	case 'Z', 'z':
+		return s.breakpoint(cmd == 'Z', args), false
+
+	case 'H', 'T':
+		return "OK", false // One thread: every thread ID is ours
+
+	case 'q':
+		return s.query(args), false
+
+	case 'Q':
+		if args == "StartNoAckMode" {
+			s.noAck = true
+			return "OK", false
+		}
+		return "", false
+
+	case 'D':
+		return "OK", true // The Debugger stays attached for the next session
+
+	case 'k':
+		return "", true
+	}
+
+	return "", false
+}
+
+// resume continues until a stop or Ctrl-C
+func (s *GDBStub) resume() string {
+	for {
+		ev := s.dbg.Continue(gdbRunChunk)
+		if ev.Reason != StopCycles {
+			return s.stopReply(ev, false)
+		}
+		if interrupt, err := s.interrupted(); interrupt || err != nil {
+			return s.stopReply(ev, true)
+		}
+	}
+}
+
+// stopReply builds a T packet: signal, stop reason and the pc (or a W
+// packet with the exit status once the program has exited)
+func (s *GDBStub) stopReply(ev StopEvent, interrupt bool) string {
+	if ev.Reason == StopExited {
+		s.lastStop = fmt.Sprintf("W%02x", uint8(ev.Status))
+		return s.lastStop
+	}
+
+	sig := gdbSigTrap
+	if interrupt {
+		sig = gdbSigInt
+	}
+
+	reason := ""
+	switch ev.Reason {
+	case StopBreakpoint:
+		if s.swbreak {
+			reason = "swbreak:;"
+		}
+	case StopWatchpoint:
+		reason = fmt.Sprintf("%s:%x;", s.watchKindName(ev.Addr), ev.Addr)
+	}
+
+	s.lastStop = fmt.Sprintf("T%02x%s%02x:%s;", sig, reason, gdbPCReg, gdbHexWord(ev.PC))
+	return s.lastStop
+}
+
+// watchKindName names the watchpoint covering addr as gdb expects
+func (s *GDBStub) watchKindName(addr uint32) string {
+	for _, wp := range s.dbg.watchpoints {
+		if addr < wp.Addr+wp.Size && wp.Addr < addr+4 {
+			switch wp.Kind {
+			case WatchRead:
+				return "rwatch"
+			case WatchAccess:
+				return "awatch"
+			}
+			return "watch"
+		}
+	}
+	return "watch"
+}
+
+// breakpoint handles Z/z type,addr,kind
+func (s *GDBStub) breakpoint(insert bool, args string) string {
+	fields := strings.Split(args, ",")
+	if len(fields) < 3 {
+		return "E01"
+	}
+	addr, err1 := strconv.ParseUint(fields[1], 16, 32)
+	size, err2 := strconv.ParseUint(fields[2], 16, 32)
+	if err1 != nil || err2 != nil {
+		return "E01"
+	}
+
+	d := s.dbg
+	var kind WatchKind
+	switch fields[0] {
+	case "0", "1": // Software and hardware breakpoints are the same to us
+		if insert {
+			d.Break(uint32(addr))
+		} else {
+			d.ClearBreak(uint32(addr))
+		}
+		return "OK"
+	case "2":
+		kind = WatchWrite
+	case "3":
+		kind = WatchRead
+	case "4":
+		kind = WatchAccess
+	default:
+		return ""
+	}
+
+	if insert {
+		d.Watch(uint32(addr), uint32(size), kind)
+	} else {
+		d.ClearWatch(uint32(addr))
+	}
+	return "OK"
+}
+
+// query handles q packets
+func (s *GDBStub) query(args string) string {
+	switch {
+	case strings.HasPrefix(args, "Supported"):
+		s.swbreak = strings.Contains(args, "swbreak+")
+		return "PacketSize=4000;qXfer:features:read+;swbreak+;hwbreak+;QStartNoAckMode+"
+
+	case strings.HasPrefix(args, "Xfer:features:read:target.xml:"):
+		off, n, ok := gdbParseAddrLen(strings.TrimPrefix(args, "Xfer:features:read:target.xml:"))
+		if !ok {
+			return "E01"
+		}
+		xml := gdbTargetXML()
+		if int(off) >= len(xml) {
+			return "l"
+		}
+		end := int(off) + int(n)
+		if end >= len(xml) {
+			return "l" + xml[off:]
+		}
+		return "m" + xml[off:end]
+
+	case args == "Attached":
+		return "1" // Detaching leaves the core alone rather than killing it
+
+	case args == "C":
+		return "QC1"
+
+	case args == "fThreadInfo":
+		return "m1"
+
+	case args == "sThreadInfo":
+		return "l"
+	}
+	return ""
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Registers and memory
+// ───────────────────────────────────────────────────────────────────────────────
+
+// readReg reads a register by gdb number
+func (s *GDBStub) readReg(reg int) uint32 {
+	if reg == gdbPCReg {
+		return s.dbg.PC()
+	}
+	return s.dbg.Registers()[reg]
+}
+
+// writeReg writes a register by gdb number (the caller resyncs the window)
+func (s *GDBStub) writeReg(reg int, v uint32) {
+	if reg == gdbPCReg {
+		s.dbg.core.archPC = v
+		return
+	}
+	s.dbg.SetRegister(uint8(reg), v)
+}
+
+// inMemory reports whether the physical range [pa, pa+n) lies inside main memory
+func (s *GDBStub) inMemory(pa uint32, n int) bool {
+	return uint64(pa)+uint64(n) <= uint64(len(s.dbg.core.memory))
+}
+
+// readMem reads the virtual range [addr, addr+n), each page from RAM or
+// from one device (ok = false if a page is unmapped or backed by neither)
+func (s *GDBStub) readMem(addr, n uint32) ([]byte, bool) {
+	c := s.dbg.core
+	pieces, ok := c.translateRange(addr, int(n))
+	if !ok {
+		return nil, false
+	}
+	data := make([]byte, 0, n)
+	for _, p := range pieces {
+		if s.inMemory(p.pa, p.n) {
+			data = append(data, c.ReadMem(p.pa, p.n)...)
+			continue
+		}
+		b, ok := c.bus.debugRead(p.pa, p.n)
+		if !ok {
+			return nil, false
+		}
+		data = append(data, b...)
+	}
+	return data, true
+}
+
+// writeMem writes data at the virtual address addr, each page to RAM or to
+// one device (nothing is written unless every page is backed)
+func (s *GDBStub) writeMem(addr uint32, data []byte) bool {
+	c := s.dbg.core
+	pieces, ok := c.translateRange(addr, len(data))
+	if !ok {
+		return false
+	}
+	for _, p := range pieces {
+		if !s.inMemory(p.pa, p.n) && c.bus.debugRegion(p.pa, p.n) == nil {
+			return false
+		}
+	}
+	for _, p := range pieces {
+		if s.inMemory(p.pa, p.n) {
+			c.WriteMem(p.pa, data[:p.n])
+		} else {
+			c.bus.debugWrite(p.pa, data[:p.n])
+		}
+		data = data[p.n:]
+	}
+	return true
+}
+
+// gdbTargetXML describes the SUPRAX-32 register set to gdb
+func gdbTargetXML() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0"?>` + "\n")
+	sb.WriteString(`<!DOCTYPE target SYSTEM "gdb-target.dtd">` + "\n")
+	sb.WriteString(`<target version="1.0">` + "\n")
+	sb.WriteString(`  <feature name="org.suprax.cpu">` + "\n")
+	for reg := 0; reg < NumArchRegs; reg++ {
+		fmt.Fprintf(&sb, `    <reg name="r%d" bitsize="32" type="uint32" regnum="%d"/>`+"\n", reg, reg)
+	}
+	fmt.Fprintf(&sb, `    <reg name="pc" bitsize="32" type="code_ptr" regnum="%d"/>`+"\n", gdbPCReg)
+	sb.WriteString("  </feature>\n")
+	sb.WriteString("</target>\n")
+	return sb.String()
+}
+
+// ───────────────────────────────────────────────────────────────────────────────
+// Wire format
+// ───────────────────────────────────────────────────────────────────────────────
+
+// send writes one packet with its checksum
+func (s *GDBStub) send(payload string) error {
+	payload = gdbEscape(payload)
+	return s.writeRaw(fmt.Sprintf("$%s#%02x", payload, gdbChecksum(payload)))
+}
+
+// writeRaw writes bytes to the connection unframed
+func (s *GDBStub) writeRaw(data string) error {
+	_, err := io.WriteString(s.w, data)
+	return err
+}
+
+// gdbChecksum sums the payload bytes mod 256
+func gdbChecksum(payload string) uint8 {
+	var sum uint8
+	for i := 0; i < len(payload); i++ {
+		sum += payload[i]
+	}
+	return sum
+}
+
+// gdbEscape escapes the bytes RSP reserves ($ # } *)
+func gdbEscape(payload string) string {
+	if !strings.ContainsAny(payload, "$#}*") {
+		return payload
+	}
+	var sb strings.Builder
+	for i := 0; i < len(payload); i++ {
+		b := payload[i]
+		if b == '$' || b == '#' || b == '}' || b == '*' {
+			sb.WriteByte('}')
+			b ^= 0x20
+		}
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
+
+// gdbUnescape reverses gdbEscape on incoming packets
+func gdbUnescape(payload string) string {
+	if !strings.Contains(payload, "}") {
+		return payload
+	}
+	var sb strings.Builder
+	for i := 0; i < len(payload); i++ {
+		b := payload[i]
+		if b == '}' && i+1 < len(payload) {
+			i++
+			b = payload[i] ^ 0x20
+		}
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
+
+// gdbHexWord encodes a register value in target (little-endian) byte order
+func gdbHexWord(v uint32) string {
+	return hex.EncodeToString([]byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)})
+}
+
+// gdbParseWord decodes a little-endian register value
+func gdbParseWord(s string) (uint32, bool) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return 0, false
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, true
+}
+
+// gdbParseAddrLen parses "addr,len" (both hex)
+func gdbParseAddrLen(s string) (addr, n uint32, ok bool) {
+	a, l, found := strings.Cut(s, ",")
+	av, err1 := strconv.ParseUint(a, 16, 32)
+	lv, err2 := strconv.ParseUint(l, 16, 32)
+	if !found || err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return uint32(av), uint32(lv), true
+}
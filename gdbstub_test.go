@@ -0,0 +1,450 @@
+package suprax32
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX GDB Remote Stub - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// gdb is unforgiving: a wrong checksum, a missing ack or a register in the wrong byte order
+// and the session silently goes wrong. Each test plays gdb over a net.Pipe - framing every
+// packet, checking every checksum and ack the stub sends - and checks both the replies and
+// the core behind them.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. WIRE FORMAT TESTS
+//    Checksums, acks and resends, no-ack mode, escaping
+//
+// 2. REGISTER AND MEMORY TESTS
+//    g/G/p/P, m/M on RAM and on a device, with paging off and on
+//
+// 3. EXECUTION CONTROL TESTS
+//    Z/z breakpoints and watchpoints, Ctrl-C, target.xml, detach
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// gdbClient plays gdb on one end of a pipe
+type gdbClient struct {
+	t     *testing.T
+	conn  net.Conn
+	r     *bufio.Reader
+	noAck bool
+	done  chan error // Serve's result
+}
+
+// startGDB loads a program at 0x1000 and serves a session to a new client
+func startGDB(t *testing.T, program []uint32) (*gdbClient, *Debugger) {
+	t.Helper()
+	core := NewCore(1 << 20)
+	core.LoadProgram(program, 0x1000)
+	dbg := NewDebugger(core)
+	return serveGDB(t, dbg), dbg
+}
+
+// serveGDB serves a session on an existing debugger to a new client
+func serveGDB(t *testing.T, dbg *Debugger) *gdbClient {
+	t.Helper()
+	server, client := net.Pipe()
+	g := &gdbClient{t: t, conn: client, r: bufio.NewReader(client), done: make(chan error, 1)}
+	go func() {
+		g.done <- NewGDBStub(dbg).Serve(server)
+		server.Close()
+	}()
+	client.SetDeadline(time.Now().Add(30 * time.Second))
+	t.Cleanup(func() { client.Close() })
+	return g
+}
+
+// raw writes bytes unframed
+func (g *gdbClient) raw(data string) {
+	g.t.Helper()
+	if _, err := io.WriteString(g.conn, data); err != nil {
+		g.t.Fatalf("write %q: %v", data, err)
+	}
+}
+
+// expectAck reads one ack byte and checks it
+func (g *gdbClient) expectAck(want byte) {
+	g.t.Helper()
+	b, err := g.r.ReadByte()
+	if err != nil {
+		g.t.Fatalf("reading ack: %v", err)
+	}
+	if b != want {
+		g.t.Fatalf("ack %q, expected %q", b, want)
+	}
+}
+
+// send frames one packet and waits for the stub's ack
+func (g *gdbClient) send(payload string) {
+	g.t.Helper()
+	g.raw(fmt.Sprintf("$%s#%02x", payload, gdbChecksum(payload)))
+	if !g.noAck {
+		g.expectAck('+')
+	}
+}
+
+// recv reads one packet, checks its checksum, acks it and returns the
+// unescaped payload
+func (g *gdbClient) recv() string {
+	g.t.Helper()
+	b, err := g.r.ReadByte()
+	if err != nil {
+		g.t.Fatalf("reading packet: %v", err)
+	}
+	if b != '$' {
+		g.t.Fatalf("packet starts with %q, expected '$'", b)
+	}
+	payload, err := g.r.ReadString('#')
+	if err != nil {
+		g.t.Fatalf("reading packet: %v", err)
+	}
+	payload = payload[:len(payload)-1]
+	sum := make([]byte, 2)
+	if _, err := io.ReadFull(g.r, sum); err != nil {
+		g.t.Fatalf("reading checksum: %v", err)
+	}
+	if want := fmt.Sprintf("%02x", gdbChecksum(payload)); string(sum) != want {
+		g.t.Fatalf("packet %q has checksum %s, expected %s", payload, sum, want)
+	}
+	if !g.noAck {
+		g.raw("+")
+	}
+	return gdbUnescape(payload)
+}
+
+// cmd sends a packet and returns the reply
+func (g *gdbClient) cmd(payload string) string {
+	g.t.Helper()
+	g.send(payload)
+	return g.recv()
+}
+
+// expect sends a packet and checks the reply
+func (g *gdbClient) expect(payload, want string) {
+	g.t.Helper()
+	if got := g.cmd(payload); got != want {
+		g.t.Errorf("%q replied %q, expected %q", payload, got, want)
+	}
+}
+
+// detach ends the session and checks Serve returned cleanly
+func (g *gdbClient) detach() {
+	g.t.Helper()
+	g.send("D")
+	g.noAck = true // The stub hangs up after replying: nobody reads an ack
+	if reply := g.recv(); reply != "OK" {
+		g.t.Errorf("D replied %q, expected OK", reply)
+	}
+	if err := <-g.done; err != nil {
+		g.t.Errorf("Serve returned %v after detach, expected nil", err)
+	}
+}
+
+// gdbSpin is a program that counts in r5 forever
+var gdbSpin = []uint32{
+	EncodeIFormat(OpADDI, 5, 0, 0), // 0x1000
+	EncodeIFormat(OpADDI, 5, 5, 1), // 0x1004
+	EncodeIFormat(OpJAL, 0, 0, -4), // 0x1008
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. WIRE FORMAT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestGDB_ChecksumsAndAcks(t *testing.T) {
+	// WHAT: A packet with a bad checksum is answered with '-' and no reply; the resend is
+	//       acked and answered. After QStartNoAckMode neither side acks
+	// WHY: gdb resends on '-' and waits forever for a reply to a packet that was acked;
+	//      an ack in no-ack mode reads as the start of garbage
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	g, _ := startGDB(t, gdbSpin)
+
+	g.raw("$?#00")
+	g.expectAck('-')
+	g.expect("?", "S05")
+
+	g.expect("QStartNoAckMode", "OK")
+	g.noAck = true
+	g.expect("?", "S05")
+	g.expect("qC", "QC1")
+	g.expect("vMustReplyEmpty", "")
+	g.detach()
+}
+
+func TestGDB_EscapeRoundTrip(t *testing.T) {
+	// WHAT: The reserved bytes $ # } * are escaped as } and the byte XOR 0x20, and
+	//       unescaping restores them
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	for _, s := range []string{"", "plain", "$", "a#b}c*d", "}}", "$#}*$#}*"} {
+		escaped := gdbEscape(s)
+		if strings.ContainsAny(escaped, "$#*") {
+			t.Errorf("gdbEscape(%q) = %q still holds a reserved byte", s, escaped)
+		}
+		if got := gdbUnescape(escaped); got != s {
+			t.Errorf("gdbUnescape(gdbEscape(%q)) = %q", s, got)
+		}
+	}
+	if got := gdbEscape("a#b"); got != "a}\x03b" {
+		t.Errorf("gdbEscape(\"a#b\") = %q, expected \"a}\\x03b\"", got)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. REGISTER AND MEMORY TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestGDB_Registers(t *testing.T) {
+	// WHAT: g returns r0-r31 and the pc as little-endian words; G, P and p write and read
+	//       them back; a register written at a breakpoint is the one the program then uses
+	// WHY: Younger instructions may already have read the old value; without a resync
+	//      the program keeps computing with it
+	// HARDWARE: N/A (tooling over Debugger.SetPC)
+	// CATEGORY: [INTEGRATION]
+
+	g, dbg := startGDB(t, []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 5), // 0x1000
+		EncodeRFormat(OpADD, 3, 1, 2),  // 0x1004: r3 = r1 + r2
+		EncodeIFormat(OpJAL, 0, 0, 0),  // 0x1008: spin
+	})
+
+	g.expect("Z0,1004,4", "OK")
+	g.expect("c", "T0520:04100000;")
+
+	regs := g.cmd("g")
+	if len(regs) != gdbNumRegs*8 {
+		t.Fatalf("g returned %d hex digits, expected %d", len(regs), gdbNumRegs*8)
+	}
+	if r1 := regs[8:16]; r1 != "05000000" {
+		t.Errorf("g: r1 = %s, expected 05000000", r1)
+	}
+	if pc := regs[gdbPCReg*8:]; pc != "04100000" {
+		t.Errorf("g: pc = %s, expected 04100000", pc)
+	}
+
+	// G: rewrite r7 through the whole block
+	block := regs[:7*8] + "efbeadde" + regs[8*8:]
+	g.expect("G"+block, "OK")
+	g.expect("p7", "efbeadde")
+	g.expect("G1234", "E01")
+
+	// P: r2 = 10 before the add commits
+	g.expect("P2=0a000000", "OK")
+	g.expect("p2", "0a000000")
+	g.expect("p21", "E01") // 0x21 = 33: one past the pc
+	g.expect("s", "T0520:08100000;")
+	if r3 := dbg.Registers()[3]; r3 != 15 {
+		t.Errorf("r3 = %d after the step, expected 5 + the written 10", r3)
+	}
+	g.detach()
+}
+
+func TestGDB_Memory(t *testing.T) {
+	// WHAT: m reads what the program stored (still in a dirty L1D line); M writes memory
+	//       a later load sees; ranges past RAM fail; a device range goes through the bus
+	// WHY: The latest value of a word may only exist in the L1D, and gdb's x/ and set
+	//      commands are how device registers get inspected and poked
+	// HARDWARE: N/A (tooling over Core.ReadMem/WriteMem and the bus)
+	// CATEGORY: [INTEGRATION]
+
+	g, dbg := startGDB(t, []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000), // 0x1000
+		EncodeIFormat(OpADDI, 2, 0, 0x123),  // 0x1004
+		EncodeSFormat(OpSW, 1, 2, 0),        // 0x1008
+		EncodeIFormat(OpLW, 3, 1, 4),        // 0x100C: breakpoint, reads what M wrote
+		EncodeIFormat(OpJAL, 0, 0, 0),       // 0x1010: spin
+	})
+	timer := NewTimer()
+	mapDevice(t, dbg.Core(), "timer", TimerBase, DeviceRegionSize, timer)
+
+	g.expect("Z0,100c,4", "OK")
+	g.expect("c", "T0520:0c100000;")
+	g.expect("m4000,4", "23010000")
+	g.expect("M4004,4:78563412", "OK")
+	g.expect("m4004,4", "78563412")
+	g.expect("s", "T0520:10100000;")
+	if r3 := dbg.Registers()[3]; r3 != 0x12345678 {
+		t.Errorf("r3 = %#x, expected the load to see the M write", r3)
+	}
+
+	// Out of range and malformed
+	memTop := strconv.FormatUint(1<<20-2, 16)
+	g.expect("m"+memTop+",4", "E01")
+	g.expect("m4000", "E01")
+	g.expect("M4000,4:12", "E01")
+
+	// Device: RELOAD written and read back through the bus, TIME counts cycles
+	reload := strconv.FormatUint(uint64(TimerBase+TimerReload), 16)
+	g.expect("M"+reload+",4:2a000000", "OK")
+	if timer.reload != 42 {
+		t.Errorf("timer RELOAD = %d, expected the M write's 42", timer.reload)
+	}
+	g.expect("m"+reload+",4", "2a000000")
+	tm := strconv.FormatUint(uint64(TimerBase+TimerTime), 16)
+	if got, want := g.cmd("m"+tm+",4"), gdbHexWord(timer.time); got != want {
+		t.Errorf("timer TIME read %s, expected %s", got, want)
+	}
+	g.expect("m"+strconv.FormatUint(uint64(TimerBase+DeviceRegionSize-2), 16)+",4", "E01")
+	g.detach()
+}
+
+func TestGDB_MemoryWithPaging(t *testing.T) {
+	// WHAT: With paging on, m/M take virtual addresses: RAM pages map to their frames, a
+	//       range crossing pages is split, a device page reaches the device, and a range
+	//       touching an unmapped page fails without writing anything
+	// WHY: gdb only knows the program's addresses; physical indexing read another part
+	//      of RAM, or failed outright for addresses past the end of it
+	// HARDWARE: N/A (tooling over Core.Translate and the bus)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	dbg, timer := pagedDebugger(t)
+	core := dbg.Core()
+	g := serveGDB(t, dbg)
+
+	g.expect("m400000,4", "44332211")
+	g.expect("m400ffc,8", "8877665500000000")
+	g.expect("M400ffe,4:01020304", "OK")
+	if lo, hi := core.ReadMem(0x20ffe, 2), core.ReadMem(0x30000, 2); lo[0] != 1 || lo[1] != 2 || hi[0] != 3 || hi[1] != 4 {
+		t.Errorf("physical bytes % x | % x, expected 01 02 | 03 04", lo, hi)
+	}
+
+	g.expect("m401ffe,4", "E01")
+	g.expect("M401ffe,4:09090909", "E01")
+	if got := core.ReadMem(0x30ffe, 2); got[0] != 0 || got[1] != 0 {
+		t.Errorf("failed M changed the mapped half: % x", got)
+	}
+
+	reload := strconv.FormatUint(uint64(0x500000+TimerReload), 16)
+	g.expect("M"+reload+",4:2a000000", "OK")
+	if timer.reload != 42 {
+		t.Errorf("timer RELOAD = %d, expected the M write's 42 through the page table", timer.reload)
+	}
+	g.expect("m"+reload+",4", "2a000000")
+	g.detach()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. EXECUTION CONTROL TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestGDB_BreakpointsAndWatchpoints(t *testing.T) {
+	// WHAT: Z0 stops before the instruction commits and z0 removes it; Z2 reports the
+	//       watched address and stops after the store; swbreak is reported only once gdb
+	//       said it understands it; unknown Z types are unsupported
+	// WHY: gdb decides how to step off a stop from the reason in the T packet
+	// HARDWARE: N/A (tooling over Debugger.Break/Watch)
+	// CATEGORY: [INTEGRATION]
+
+	g, dbg := startGDB(t, []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000), // 0x1000
+		EncodeIFormat(OpADDI, 2, 2, 1),      // 0x1004: loop
+		EncodeSFormat(OpSW, 1, 2, 0),        // 0x1008
+		EncodeIFormat(OpJAL, 0, 0, -8),      // 0x100C
+	})
+
+	if reply := g.cmd("qSupported:swbreak+;hwbreak+"); !strings.Contains(reply, "swbreak+") {
+		t.Errorf("qSupported reply %q does not offer swbreak", reply)
+	}
+	g.expect("Z0,1004,4", "OK")
+	g.expect("c", "T05swbreak:;20:04100000;")
+	g.expect("c", "T05swbreak:;20:04100000;")
+	g.expect("z0,1004,4", "OK")
+
+	g.expect("Z2,4000,4", "OK")
+	g.expect("c", "T05watch:4000;20:0c100000;")
+	if got := dbg.ReadWord(0x4000); got != 2 {
+		t.Errorf("0x4000 = %d at the watchpoint, expected the second pass's 2", got)
+	}
+	g.expect("z2,4000,4", "OK")
+	g.expect("Z9,4000,4", "")
+	g.expect("Z0,zz,4", "E01")
+	g.detach()
+}
+
+func TestGDB_TargetDescription(t *testing.T) {
+	// WHAT: qXfer:features:read:target.xml read in small chunks reassembles to the whole
+	//       description, ending with an 'l' chunk, and names 32 registers plus the pc
+	// WHY: gdb reads the register layout from this; a dropped chunk shifts every register
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	g, _ := startGDB(t, gdbSpin)
+
+	var xml strings.Builder
+	for off := 0; ; off += 0x40 {
+		reply := g.cmd(fmt.Sprintf("qXfer:features:read:target.xml:%x,40", off))
+		if reply == "" || (reply[0] != 'm' && reply[0] != 'l') {
+			t.Fatalf("chunk at %#x: %q, expected an m or l reply", off, reply)
+		}
+		xml.WriteString(reply[1:])
+		if reply[0] == 'l' {
+			break
+		}
+		if len(reply) != 0x41 {
+			t.Fatalf("chunk at %#x is %d bytes, expected 0x40", off, len(reply)-1)
+		}
+	}
+	if xml.String() != gdbTargetXML() {
+		t.Errorf("reassembled target.xml differs:\n%s", xml.String())
+	}
+	if n := strings.Count(xml.String(), "<reg "); n != gdbNumRegs {
+		t.Errorf("target.xml has %d registers, expected %d", n, gdbNumRegs)
+	}
+	g.expect("qXfer:features:read:target.xml:nope", "E01")
+	g.detach()
+}
+
+func TestGDB_CtrlCInterruptsContinue(t *testing.T) {
+	// WHAT: A bare 0x03 while the target runs stops it with SIGINT (T02) at a commit
+	//       boundary; '?' then repeats that stop and the session carries on
+	// WHY: Continue on a program with no breakpoint never returns on its own; the
+	//      interrupt is the only way back to the prompt
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	g, dbg := startGDB(t, gdbSpin)
+
+	g.send("c")
+	g.raw("\x03")
+	stop := g.recv()
+	if !strings.HasPrefix(stop, "T0220:") {
+		t.Fatalf("stop reply %q, expected T02 with the pc", stop)
+	}
+	if pc := stop[len("T0220:") : len("T0220:")+8]; pc != gdbHexWord(dbg.PC()) {
+		t.Errorf("stop reply pc %s, expected the architectural pc %s", pc, gdbHexWord(dbg.PC()))
+	}
+	if dbg.Cycle() < gdbRunChunk {
+		t.Errorf("stopped at cycle %d, expected the target to have run", dbg.Cycle())
+	}
+	g.expect("?", stop)
+
+	// The session is still usable: a step commits exactly one instruction
+	committed := dbg.Core().instructions
+	if reply := g.cmd("s"); !strings.HasPrefix(reply, "T0520:") {
+		t.Errorf("step after the interrupt replied %q, expected T05", reply)
+	}
+	if n := dbg.Core().instructions; n != committed+1 {
+		t.Errorf("step committed %d instructions, expected 1", n-committed)
+	}
+	g.detach()
+}
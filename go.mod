@@ -1,3 +1,9 @@
-module main
+module suprax
 
 go 1.25.4
+
+// proto/ holds the TAGE and perceptron reference models; only tests import
+// them (branchtrace_test.go and specdirection_test.go).
+require suprax/proto v0.0.0
+
+replace suprax/proto => ./proto
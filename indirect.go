@@ -0,0 +1,398 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// INDIRECT TARGET PREDICTION: BTB + ITTAGE
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: INNOVATION #33 dropped the BTB because fetch decodes every word it
+//
+//	reads, so a direct target is computed, not predicted, and returns
+//	have the RSB. That leaves every other JALR - a call through a
+//	function pointer, a jump through a table - predicted as pc+4, which
+//	is wrong every single time.
+//
+// THE TWO PREDICTORS (consulted at fetch, trained at commit):
+//
+//	BTB     512 entries, 4-way, tagged by PC. Holds the last target of
+//	        every indirect jump and return. Right for a call site that
+//	        always goes the same way; also catches returns the RSB has
+//	        lost (underflow after deep recursion).
+//
+//	ITTAGE  4 tagged tables of 256 entries, indexed by PC and the
+//	        newest 4, 12, 28 and 64 bits of global history. Right for
+//	        a call site whose target follows from how we got there:
+//	        the same virtual call inside a loop over mixed objects.
+//	        The longest matching table provides the target; a fresh
+//	        entry (confidence 0) defers to the next longest.
+//
+//	  fetch:  ITTAGE hit?  ──yes──► its target
+//	            │no
+//	          BTB hit?     ──yes──► last target
+//	            │no
+//	          pc + 4                (as before)
+//
+// THE HISTORY (global, 64 bits):
+//
+//	Conditional branch  shifts in its direction (1 bit)
+//	Indirect jump       shifts in 3 bits hashed from its target, so the
+//	                    path through earlier dispatches counts too
+//
+//	Fetch extends a speculative copy with its predictions; commit
+//	extends the committed copy with outcomes. Everything younger than a
+//	flush is thrown away, so a flush copies committed over speculative.
+//	A jump that commits was fetched down the right path, so the history
+//	it trains with at commit is the one it was predicted with.
+//
+// TRAINING (commit, every JALR):
+//
+//	BTB     records the target
+//	ITTAGE  provider: confidence up when right, down (then retarget)
+//	        when wrong; useful up when it was right and the next
+//	        longest would not have been
+//	        mispredicted: claim an entry with no usefulness in a longer
+//	        table; if there is none, age every candidate instead
+//
+// COST:
+//
+//	BTB:    512 × 54 bits (tag, target, valid)        = 3.4KB
+//	ITTAGE: 4 × 256 × 44 bits (tag, target, counters) = 5.5KB
+//	~430K transistors at 6T per bit, against the 98K INNOVATION #33
+//	saved: indirect-heavy code is where that 0.15 IPC went.
+//
+// MINECRAFT ANALOGY: A signpost at every crossroads that remembers where
+//
+//	you went last time (BTB), plus a villager who remembers which way
+//	you went after taking a particular route there (ITTAGE).
+
+const (
+	BTBEntries      = 512 // Total BTB entries
+	BTBWays         = 4   // BTB associativity
+	ITTAGETableSize = 256 // Entries per ITTAGE table
+)
+
+// ittageHistoryLengths are the history bits each ITTAGE table indexes with
+var ittageHistoryLengths = [...]uint{4, 12, 28, 64}
+
+// TargetSource names what predicted a JALR's target at fetch
+type TargetSource uint8
+
+const (
+	TargetNone   TargetSource = iota // Nothing: predicted pc+4
+	TargetRSB                        // Return stack buffer (INNOVATION #31)
+	TargetBTB                        // BTB, last target
+	TargetTagged                     // ITTAGE table 0; table t is TargetTagged+t
+)
+
+// String returns the short name of a target source
+func (s TargetSource) String() string {
+	switch {
+	case s == TargetRSB:
+		return "rsb"
+	case s == TargetBTB:
+		return "btb"
+	case s >= TargetTagged && int(s-TargetTagged) < len(ittageHistoryLengths):
+		return "t" + string(rune('1'+s-TargetTagged))
+	default:
+		return "none"
+	}
+}
+
+// isReturn reports whether a JALR is a return (JALR x0, ra, 0)
+func isReturn(inst Instruction) bool {
+	return inst.Opcode == OpJALR && inst.Rs1 == 1 && inst.Imm == 0
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// BRANCH TARGET BUFFER
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// BTBEntry holds the last target of one jump
+type BTBEntry struct {
+	Tag     uint32 // PC above the set index
+	Target  uint32 // Last target
+	Valid   bool
+	lastUse uint64 // For LRU replacement
+}
+
+// BTB is a tagged, set-associative branch target buffer
+type BTB struct {
+	sets  [BTBEntries / BTBWays][BTBWays]BTBEntry
+	clock uint64
+
+	// Statistics
+	lookups uint64
+	hits    uint64
+}
+
+// locate returns the set and tag for a PC
+func (b *BTB) locate(pc uint32) (set *[BTBWays]BTBEntry, tag uint32) {
+	idx := (pc >> 2) % uint32(len(b.sets))
+	return &b.sets[idx], pc >> 2 / uint32(len(b.sets))
+}
+
+// Lookup returns the last target recorded for pc
+func (b *BTB) Lookup(pc uint32) (target uint32, hit bool) {
+	b.lookups++
+	set, tag := b.locate(pc)
+	for w := range set {
+		if set[w].Valid && set[w].Tag == tag {
+			b.clock++
+			set[w].lastUse = b.clock
+			b.hits++
+			return set[w].Target, true
+		}
+	}
+	return 0, false
+}
+
+// Update records pc's target, replacing the least recently used way
+func (b *BTB) Update(pc, target uint32) {
+	set, tag := b.locate(pc)
+	victim := 0
+	for w := range set {
+		if set[w].Valid && set[w].Tag == tag {
+			victim = w
+			break
+		}
+		if !set[w].Valid || set[victim].Valid && set[w].lastUse < set[victim].lastUse {
+			victim = w
+		}
+	}
+	b.clock++
+	set[victim] = BTBEntry{Tag: tag, Target: target, Valid: true, lastUse: b.clock}
+}
+
+// validEntries counts the entries in use
+func (b *BTB) validEntries() int {
+	n := 0
+	for i := range b.sets {
+		for w := range b.sets[i] {
+			if b.sets[i][w].Valid {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// ITTAGE
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// ITTAGEEntry is one target seen after one history
+type ITTAGEEntry struct {
+	Tag        uint16 // PC and history tag (9 bits)
+	Target     uint32
+	Confidence uint8 // 0-3: how often the target repeated
+	Useful     uint8 // 0-3: right where the next longest table was not
+	Valid      bool
+}
+
+// ITTAGE predicts indirect targets from global history
+type ITTAGE struct {
+	tables [len(ittageHistoryLengths)][ITTAGETableSize]ITTAGEEntry
+
+	// Statistics
+	allocations   uint64 // Entries claimed after a mispredict
+	allocFailures uint64 // Mispredicts that found nothing to claim
+}
+
+// slot returns table t's entry and tag for a PC under a history
+func (it *ITTAGE) slot(t int, pc uint32, history uint64) (*ITTAGEEntry, uint16) {
+	length := ittageHistoryLengths[t]
+	idx := ((pc >> 2) ^ foldHistory(history, length, 8)) & (ITTAGETableSize - 1)
+	tag := uint16(((pc >> 10) ^ (pc >> 2) ^ foldHistory(history, length, 9)<<1) & 0x1FF)
+	return &it.tables[t][idx], tag
+}
+
+// match returns the longest matching table shorter than limit (-1 = none)
+func (it *ITTAGE) match(pc uint32, history uint64, limit int) (t int, entry *ITTAGEEntry) {
+	for t := limit - 1; t >= 0; t-- {
+		entry, tag := it.slot(t, pc, history)
+		if entry.Valid && entry.Tag == tag {
+			return t, entry
+		}
+	}
+	return -1, nil
+}
+
+// Predict returns the target of the longest match, or of the next
+// longest while the longest is still unconfirmed
+func (it *ITTAGE) Predict(pc uint32, history uint64) (target uint32, table int, valid bool) {
+	t, entry := it.match(pc, history, len(it.tables))
+	if entry == nil {
+		return 0, -1, false
+	}
+	if entry.Confidence == 0 {
+		if alt, altEntry := it.match(pc, history, t); altEntry != nil {
+			return altEntry.Target, alt, true
+		}
+	}
+	return entry.Target, t, true
+}
+
+// Update trains on a committed jump's target
+//
+// ALGORITHM:
+//
+//	STEP 1: The provider learns the target (confidence up on a repeat;
+//	        down, then retarget, on a change). Its usefulness follows
+//	        whether it beat the next longest match.
+//	STEP 2: If the fetched prediction was wrong, claim an entry in a
+//	        longer table with no usefulness to lose; if every candidate
+//	        is still useful, each loses one step instead.
+func (it *ITTAGE) Update(pc uint32, history uint64, target uint32, mispredicted bool) {
+	// STEP 1
+	t, entry := it.match(pc, history, len(it.tables))
+	if entry != nil {
+		_, alt := it.match(pc, history, t)
+		altRight := alt != nil && alt.Target == target
+		if entry.Target == target {
+			if entry.Confidence < 3 {
+				entry.Confidence++
+			}
+			if !altRight && entry.Useful < 3 {
+				entry.Useful++
+			}
+		} else {
+			if altRight && entry.Useful > 0 {
+				entry.Useful--
+			}
+			if entry.Confidence > 0 {
+				entry.Confidence--
+			} else {
+				entry.Target = target
+			}
+		}
+	}
+	if !mispredicted {
+		return
+	}
+
+	// STEP 2
+	for u := t + 1; u < len(it.tables); u++ {
+		candidate, tag := it.slot(u, pc, history)
+		if !candidate.Valid || candidate.Useful == 0 {
+			*candidate = ITTAGEEntry{Tag: tag, Target: target, Valid: true}
+			it.allocations++
+			return
+		}
+	}
+	for u := t + 1; u < len(it.tables); u++ {
+		candidate, _ := it.slot(u, pc, history)
+		candidate.Useful--
+	}
+	it.allocFailures++
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// FRONT-END INTEGRATION
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// targetCounters are one source's outcomes over committed jumps
+type targetCounters struct {
+	predicted uint64 // Jumps whose target it supplied
+	correct   uint64 // ...and the jump went there
+}
+
+// IndirectPredictor holds the BTB, ITTAGE and their history
+type IndirectPredictor struct {
+	on bool
+
+	btb    BTB
+	ittage ITTAGE
+
+	specHistory   uint64 // Extended at fetch with predictions
+	commitHistory uint64 // Extended at commit with outcomes
+
+	// Statistics
+	jumps             uint64 // Committed indirect jumps and calls
+	mispredicts       uint64 // ...that went somewhere else
+	returns           uint64 // Committed returns
+	returnMispredicts uint64 // ...that went somewhere else
+	perSource         [int(TargetTagged) + len(ittageHistoryLengths)]targetCounters
+}
+
+// SetIndirectPrediction turns the BTB and ITTAGE on (the default) or off;
+// off predicts every indirect jump as pc+4, as INNOVATION #33 did
+func (c *Core) SetIndirectPrediction(on bool) {
+	c.branchPred.indirect.on = on
+}
+
+// predictJALR predicts a JALR's target at fetch
+func (ip *IndirectPredictor) predictJALR(bp *BranchPredictor, pc uint32, inst Instruction) (uint32, TargetSource) {
+	if isReturn(inst) {
+		if addr, valid := bp.PopRSB(); valid {
+			return addr, TargetRSB
+		}
+	}
+	if !ip.on {
+		return pc + 4, TargetNone
+	}
+	if !isReturn(inst) {
+		if target, t, ok := ip.ittage.Predict(pc, ip.specHistory); ok {
+			return target, TargetTagged + TargetSource(t)
+		}
+	}
+	if target, ok := ip.btb.Lookup(pc); ok {
+		return target, TargetBTB
+	}
+	return pc + 4, TargetNone
+}
+
+// extendHistory returns history extended by one control transfer
+func extendHistory(history uint64, inst Instruction, taken bool, target uint32) uint64 {
+	switch {
+	case inst.IsBranch:
+		history <<= 1
+		if taken {
+			history |= 1
+		}
+	case inst.Opcode == OpJALR && !isReturn(inst):
+		history = history<<3 | uint64((target>>2^target>>5^target>>8)&7)
+	}
+	return history
+}
+
+// speculate extends the speculative history with a fetch-time prediction
+func (ip *IndirectPredictor) speculate(inst Instruction, taken bool, target uint32) {
+	ip.specHistory = extendHistory(ip.specHistory, inst, taken, target)
+}
+
+// commit trains on a committed control transfer and extends the
+// committed history
+func (ip *IndirectPredictor) commit(e *WindowEntry) {
+	inst := Instruction{Opcode: e.Opcode, Rs1: e.Rs1, Imm: e.Imm, IsBranch: e.IsBranch}
+	if e.Opcode == OpJALR {
+		wrong := e.PredictedAddr != e.BranchTarget
+		src := &ip.perSource[e.TargetSource]
+		src.predicted++
+		if !wrong {
+			src.correct++
+		}
+		if isReturn(inst) {
+			ip.returns++
+			if wrong {
+				ip.returnMispredicts++
+			}
+		} else {
+			ip.jumps++
+			if wrong {
+				ip.mispredicts++
+			}
+		}
+
+		if ip.on {
+			if !isReturn(inst) {
+				ip.ittage.Update(e.PC, ip.commitHistory, e.BranchTarget, wrong)
+			}
+			ip.btb.Update(e.PC, e.BranchTarget)
+		}
+	}
+	ip.commitHistory = extendHistory(ip.commitHistory, inst, e.BranchTaken, e.BranchTarget)
+}
+
+// squash returns the speculative history to the committed one (flush)
+func (ip *IndirectPredictor) squash() {
+	ip.specHistory = ip.commitHistory
+}
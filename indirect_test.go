@@ -0,0 +1,238 @@
+package suprax32
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Indirect Target Prediction - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Each structure is checked on the behaviour it exists for: the BTB on a site that always goes
+// one way, ITTAGE on a site whose target follows from the path to it, and the two histories on
+// the one property everything depends on - after a flush, fetch predicts with exactly the
+// history commit will train with. A C program with function-pointer calls then checks that the
+// front end uses all of it and the program computes the same thing either way.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. STRUCTURE TESTS
+//    BTB tags and replacement, ITTAGE learning, history repair
+//
+// 2. PROGRAM TESTS
+//    Function-pointer dispatch with and without the predictors
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// dispatchSource calls through function pointers three ways per iteration
+const dispatchSource = `
+int add1(int x) { return x + 1; }
+int dbl(int x) { return x * 2; }
+int neg(int x) { return 0 - x; }
+int sq(int x) { return x * x; }
+int (*ops[4])(int) = { add1, dbl, neg, sq };
+int data[64];
+
+int main(void) {
+	int i, acc = 0, seed = 7;
+	for (i = 0; i < 64; i++) {
+		seed = seed * 1103515245 + 12345;
+		data[i] = (seed >> 16) & 1;
+	}
+	for (i = 0; i < 3000; i++) {
+		int (*f)(int);
+		acc += ops[i % 3](i);     /* cycles through three targets */
+		if (data[i & 63])
+			f = sq;
+		else
+			f = add1;
+		acc += f(i);              /* follows the branch before it */
+		acc += ops[1](acc & 255); /* always the same target */
+	}
+	return acc & 127;
+}
+`
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. STRUCTURE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestIndirect_BTB(t *testing.T) {
+	// WHAT: The BTB returns a PC's last target, never another PC's from the same set, and a
+	//       full set replaces its least recently used way
+	// WHY: A tag mismatch returned as a hit sends fetch somewhere arbitrary
+	// HARDWARE: BTB.Lookup, BTB.Update
+	// CATEGORY: [UNIT]
+
+	var b BTB
+	sets := uint32(len(b.sets))
+	pc := func(i uint32) uint32 { return 0x1000 + i*sets*4 } // All in one set
+
+	if _, hit := b.Lookup(pc(0)); hit {
+		t.Fatal("hit in an empty BTB")
+	}
+	for i := uint32(0); i < BTBWays; i++ {
+		b.Update(pc(i), 0x8000+i)
+	}
+	b.Update(pc(0), 0x9000) // Retarget: same way, now most recent
+	b.Lookup(pc(2))
+	b.Update(pc(BTBWays), 0xA000) // Evicts pc(1), the least recent
+
+	for i, want := range map[uint32]uint32{0: 0x9000, 2: 0x8002, 3: 0x8003, BTBWays: 0xA000} {
+		if got, hit := b.Lookup(pc(i)); !hit || got != want {
+			t.Errorf("pc %d: target 0x%x hit %v, expected 0x%x", i, got, hit, want)
+		}
+	}
+	if _, hit := b.Lookup(pc(1)); hit {
+		t.Error("least recently used entry survived")
+	}
+	if n := b.validEntries(); n != BTBWays {
+		t.Errorf("%d valid entries, expected %d", n, BTBWays)
+	}
+}
+
+func TestIndirect_ITTAGELearnsFromHistory(t *testing.T) {
+	// WHAT: One jump whose target is set by the last two history bits: after a few rounds
+	//       ITTAGE predicts all four targets, while a history-free last-target guess (the BTB)
+	//       would be wrong nearly every time
+	// WHY: Telling these apart is the reason ITTAGE exists next to the BTB
+	// HARDWARE: ITTAGE.Predict, ITTAGE.Update
+	// CATEGORY: [UNIT]
+
+	var it ITTAGE
+	const pc = 0x4000
+	wrong := 0
+	for round := 0; round < 50; round++ {
+		for h := uint64(0); h < 4; h++ {
+			history := h | 0xA50 // Same older history, two distinguishing bits
+			want := 0x8000 + uint32(h)*0x100
+			got, _, ok := it.Predict(pc, history)
+			miss := !ok || got != want
+			if round >= 10 && miss {
+				wrong++
+			}
+			it.Update(pc, history, want, miss)
+		}
+	}
+	if wrong != 0 {
+		t.Errorf("%d mispredictions after training", wrong)
+	}
+	if it.allocations == 0 || it.allocations > 16 {
+		t.Errorf("%d allocations for four contexts", it.allocations)
+	}
+}
+
+func TestIndirect_HistoryRepair(t *testing.T) {
+	// WHAT: Fetch extends the speculative history with predictions, commit extends the
+	//       committed one with outcomes, and a flush puts the committed one back; returns and
+	//       direct jumps leave the history alone
+	// WHY: A predictor trained on one history and queried with another learns nothing
+	// HARDWARE: IndirectPredictor.speculate, commit, squash
+	// CATEGORY: [UNIT]
+
+	ip := IndirectPredictor{on: true}
+	branch := Instruction{Opcode: OpBEQ, IsBranch: true}
+	jump := Instruction{Opcode: OpJALR, Rs1: 5}
+	ret := Instruction{Opcode: OpJALR, Rs1: 1}
+	call := Instruction{Opcode: OpJAL, Rd: 1, IsJump: true}
+
+	ip.speculate(branch, true, 0)
+	ip.speculate(jump, true, 0x1234)
+	ip.speculate(ret, true, 0x5678)
+	ip.speculate(call, true, 0x9000)
+	want := extendHistory(1, jump, true, 0x1234)
+	if ip.specHistory != want || want>>3 != 1 {
+		t.Fatalf("speculative history 0x%x, expected 0x%x", ip.specHistory, want)
+	}
+
+	// The branch commits not taken (a mispredict): younger work is flushed
+	ip.commit(&WindowEntry{Opcode: OpBEQ, IsBranch: true})
+	ip.squash()
+	if ip.specHistory != 0 || ip.commitHistory != 0 {
+		t.Errorf("after the flush: speculative 0x%x, committed 0x%x; expected 0, 0", ip.specHistory, ip.commitHistory)
+	}
+
+	// A committed JALR trains the BTB and is scored under its source
+	ip.commit(&WindowEntry{PC: 0x40, Opcode: OpJALR, Rs1: 5, BranchTaken: true, BranchTarget: 0x1234,
+		PredictedAddr: 0x44, TargetSource: TargetNone})
+	if target, hit := ip.btb.Lookup(0x40); !hit || target != 0x1234 {
+		t.Errorf("BTB holds 0x%x (%v) after commit", target, hit)
+	}
+	if ip.jumps != 1 || ip.mispredicts != 1 || ip.perSource[TargetNone].predicted != 1 {
+		t.Errorf("jumps %d, mispredicts %d, none predicted %d", ip.jumps, ip.mispredicts, ip.perSource[TargetNone].predicted)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. PROGRAM TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestIndirect_FunctionPointerDispatch(t *testing.T) {
+	// WHAT: With the BTB and ITTAGE, the dispatch program's 9000 indirect calls are nearly all
+	//       predicted, every source pulls its weight, the run is faster, and the program exits
+	//       with the same status; off, every indirect call is predicted pc+4 as before. Returns
+	//       from calls through a pointer find their address on the RSB either way.
+	// WHY: Function pointers and virtual calls were mispredicted every time
+	// HARDWARE: PredictTarget, fetch, commit
+	// CATEGORY: [INTEGRATION]
+
+	img, err := BuildC("dispatch.c", dispatchSource)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	run := func(on bool) (*Stats, uint32) {
+		core := NewCore(1 << 20)
+		core.SetConsole(&bytes.Buffer{})
+		core.SetIndirectPrediction(on)
+		if err := core.LoadImage(img); err != nil {
+			t.Fatalf("LoadImage: %v", err)
+		}
+		core.Run(5_000_000)
+		status, ok := core.Exited()
+		if !ok {
+			t.Fatalf("predictors %v: no exit", on)
+		}
+		return core.Snapshot(), status
+	}
+	off, offStatus := run(false)
+	on, onStatus := run(true)
+
+	if onStatus != offStatus {
+		t.Errorf("exit status %d with the predictors, %d without", onStatus, offStatus)
+	}
+	if off.Indirect.Jumps != 9000 || off.Indirect.Mispredicts != 9000 || off.Indirect.BTBLookups != 0 {
+		t.Errorf("off: %d jumps, %d mispredicted, %d BTB lookups", off.Indirect.Jumps, off.Indirect.Mispredicts, off.Indirect.BTBLookups)
+	}
+	if on.Indirect.Jumps != 9000 || on.Indirect.Accuracy < 0.99 {
+		t.Errorf("on: %d jumps at accuracy %.4f", on.Indirect.Jumps, on.Indirect.Accuracy)
+	}
+	for _, s := range []*Stats{off, on} {
+		if s.Indirect.ReturnAccuracy < 0.99 {
+			t.Errorf("enabled %v: return accuracy %.4f", s.Indirect.Enabled, s.Indirect.ReturnAccuracy)
+		}
+	}
+
+	var btb, tagged uint64
+	for _, src := range on.Indirect.Sources {
+		switch {
+		case src.Name == "btb":
+			btb = src.Correct
+		case src.Name[0] == 't':
+			tagged += src.Correct
+		}
+	}
+	if btb < 1000 || tagged < 5000 {
+		t.Errorf("correct targets from the BTB %d, from ITTAGE %d", btb, tagged)
+	}
+	if on.Core.Cycles >= off.Core.Cycles || on.Branch.Mispredicts*2 > off.Branch.Mispredicts {
+		t.Errorf("%d cycles and %d mispredicts with the predictors, %d and %d without",
+			on.Core.Cycles, on.Branch.Mispredicts, off.Core.Cycles, off.Branch.Mispredicts)
+	}
+}
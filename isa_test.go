@@ -0,0 +1,294 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Instruction Encoding - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// An encoding is a contract between the assembler and the decoder: every field an Encode*
+// helper packs must come back out of DecodeInstruction unchanged, at the extremes of its
+// range, with the convenience flags the pipeline keys off. The disassembler is the third
+// party to the contract, so its spelling is checked here too.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. N-FORMAT TESTS
+//    LB/LBU/LH/LHU/SB/SH field round trips, flags, disassembly
+//
+// 2. STORE FORMAT TESTS
+//    SW (S-format) and SC (three registers) round trips and disassembly
+//
+// 3. DISASSEMBLER ROUND-TRIP TESTS
+//    Every format disassembles to text the assembler turns back into the same word
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. N-FORMAT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestISA_NFormatRoundTrip(t *testing.T) {
+	// WHAT: Every narrow load and store decodes back to its registers, 15-bit offset,
+	//       access size and extension, for the extreme offsets and registers
+	// WHY: The funct field borrows two immediate bits; an off-by-one in either field
+	//      silently changes the address or the width
+	// HARDWARE: N-format decode (INNOVATION #5)
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	cases := []struct {
+		opcode, funct uint8
+		load          bool
+		size          uint8
+		signed        bool
+	}{
+		{OpLDN, FnByte, true, 1, true},
+		{OpLDN, FnByteU, true, 1, false},
+		{OpLDN, FnHalf, true, 2, true},
+		{OpLDN, FnHalfU, true, 2, false},
+		{OpSTN, FnByte, false, 1, false},
+		{OpSTN, FnHalf, false, 2, false},
+	}
+	for _, c := range cases {
+		for _, imm := range []int32{0, 1, -1, 16383, -16384} {
+			for _, regs := range [][2]uint8{{1, 2}, {31, 0}, {0, 31}} {
+				reg, rs1 := regs[0], regs[1]
+				inst := DecodeInstruction(EncodeNFormat(c.opcode, reg, rs1, c.funct, imm), 0x1000)
+
+				if inst.Opcode != c.opcode || inst.Rs1 != rs1 || inst.Imm != imm {
+					t.Errorf("op %#x funct %d reg %d rs1 %d imm %d: decoded opcode %#x rs1 %d imm %d",
+						c.opcode, c.funct, reg, rs1, imm, inst.Opcode, inst.Rs1, inst.Imm)
+				}
+				if inst.IsLoad != c.load || inst.IsStore == c.load || !inst.UsesImm {
+					t.Errorf("op %#x funct %d: IsLoad %v IsStore %v UsesImm %v",
+						c.opcode, c.funct, inst.IsLoad, inst.IsStore, inst.UsesImm)
+				}
+				if inst.MemSize != c.size || inst.MemSigned != c.signed {
+					t.Errorf("op %#x funct %d: size %d signed %v, expected %d %v",
+						c.opcode, c.funct, inst.MemSize, inst.MemSigned, c.size, c.signed)
+				}
+				if c.load && (inst.Rd != reg || inst.Rs2 != 0) {
+					t.Errorf("load: rd %d rs2 %d, expected rd %d", inst.Rd, inst.Rs2, reg)
+				}
+				if !c.load && (inst.Rs2 != reg || inst.Rd != 0) {
+					t.Errorf("store: rs2 %d rd %d, expected rs2 %d and no destination", inst.Rs2, inst.Rd, reg)
+				}
+			}
+		}
+	}
+}
+
+func TestISA_WordAccessSize(t *testing.T) {
+	// WHAT: LW, SW, LR and SC decode as 4-byte accesses
+	// WHY: The LSU and L1D take their width from MemSize for every access, not just
+	//      the narrow ones
+	// HARDWARE: Decode flags (INNOVATION #6)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	for _, op := range []uint8{OpLW, OpSW, OpLR, OpSC} {
+		inst := DecodeInstruction(EncodeIFormat(op, 1, 2, 8), 0x1000)
+		if inst.MemSize != 4 || inst.MemSigned {
+			t.Errorf("op %#x: size %d signed %v, expected a 4-byte access", op, inst.MemSize, inst.MemSigned)
+		}
+	}
+}
+
+func TestISA_NFormatDisassembly(t *testing.T) {
+	// WHAT: Narrow accesses disassemble by width and extension, stores naming the data register
+	// WHY: Traces and the debugger show this text; "ldn"/"stn" would hide the width
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	cases := []struct {
+		word uint32
+		want string
+	}{
+		{EncodeNFormat(OpLDN, 3, 2, FnByte, -4), "lb     r3, -4(r2)"},
+		{EncodeNFormat(OpLDN, 3, 2, FnByteU, 5), "lbu    r3, 5(r2)"},
+		{EncodeNFormat(OpLDN, 3, 2, FnHalf, 6), "lh     r3, 6(r2)"},
+		{EncodeNFormat(OpLDN, 3, 2, FnHalfU, 0), "lhu    r3, 0(r2)"},
+		{EncodeNFormat(OpSTN, 7, 2, FnByte, 1), "sb     r7, 1(r2)"},
+		{EncodeNFormat(OpSTN, 7, 2, FnHalf, 2), "sh     r7, 2(r2)"},
+	}
+	for _, c := range cases {
+		if got := Disassemble(c.word, 0x1000); got != c.want {
+			t.Errorf("Disassemble(%#08x) = %q, expected %q", c.word, got, c.want)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. STORE FORMAT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestISA_SFormatRoundTrip(t *testing.T) {
+	// WHAT: SW decodes back to its base register, data register and full 17-bit offset,
+	//       with no destination register
+	// WHY: SW used to take its data register from bits [16:12] of the offset, so any
+	//      offset of 4096 or more stored a different register than the one written
+	// HARDWARE: S-format decode (INNOVATION #4)
+	// CATEGORY: [UNIT] [REGRESSION] [BOUNDARY]
+
+	for _, imm := range []int32{0, 4, -4, 0x2000, 65535, -65536} {
+		for _, regs := range [][2]uint8{{1, 2}, {0, 31}, {31, 0}} {
+			rs1, rs2 := regs[0], regs[1]
+			inst := DecodeInstruction(EncodeSFormat(OpSW, rs1, rs2, imm), 0x1000)
+			if inst.Rs1 != rs1 || inst.Rs2 != rs2 || inst.Imm != imm || inst.Rd != 0 {
+				t.Errorf("sw r%d, %d(r%d): decoded rs2 %d rs1 %d imm %d rd %d",
+					rs2, imm, rs1, inst.Rs2, inst.Rs1, inst.Imm, inst.Rd)
+			}
+			if !inst.IsStore || inst.IsLoad || inst.MemSize != 4 {
+				t.Errorf("sw: IsStore %v IsLoad %v size %d", inst.IsStore, inst.IsLoad, inst.MemSize)
+			}
+		}
+	}
+}
+
+func TestISA_SCRoundTrip(t *testing.T) {
+	// WHAT: SC decodes back to its success register, base, data register and 12-bit offset
+	// WHY: SC needs three registers; with only two fields its data register overlapped
+	//      the offset, exactly like the old SW
+	// HARDWARE: Atomic decode (INNOVATION #71)
+	// CATEGORY: [UNIT] [REGRESSION] [BOUNDARY]
+
+	for _, imm := range []int32{0, 4, -4, 2047, -2048} {
+		for _, regs := range [][3]uint8{{6, 1, 5}, {31, 0, 31}, {0, 31, 0}} {
+			rd, rs1, rs2 := regs[0], regs[1], regs[2]
+			inst := DecodeInstruction(EncodeSC(rd, rs1, rs2, imm), 0x1000)
+			if inst.Rd != rd || inst.Rs1 != rs1 || inst.Rs2 != rs2 || inst.Imm != imm {
+				t.Errorf("sc r%d, r%d, %d(r%d): decoded rd %d rs2 %d rs1 %d imm %d",
+					rd, rs2, imm, rs1, inst.Rd, inst.Rs2, inst.Rs1, inst.Imm)
+			}
+			if !inst.IsStore || inst.MemSize != 4 {
+				t.Errorf("sc: IsStore %v size %d", inst.IsStore, inst.MemSize)
+			}
+		}
+	}
+}
+
+func TestISA_StoreDisassembly(t *testing.T) {
+	// WHAT: SW and SC disassemble with the data register and offset they encode
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	cases := []struct {
+		word uint32
+		want string
+	}{
+		{EncodeSFormat(OpSW, 2, 7, 0x2000), "sw     r7, 8192(r2)"},
+		{EncodeSFormat(OpSW, 29, 1, -8), "sw     r1, -8(r29)"},
+		{EncodeSC(6, 1, 5, 0), "sc     r6, r5, 0(r1)"},
+		{EncodeSC(6, 1, 5, -12), "sc     r6, r5, -12(r1)"},
+	}
+	for _, c := range cases {
+		if got := Disassemble(c.word, 0x1000); got != c.want {
+			t.Errorf("Disassemble(%#08x) = %q, expected %q", c.word, got, c.want)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. DISASSEMBLER ROUND-TRIP TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// roundTripProgram has at least one word of every format, with fields at their extremes
+func roundTripProgram() []uint32 {
+	var prog []uint32
+	for op := uint8(OpADD); op <= OpSLTU; op++ {
+		prog = append(prog, EncodeRFormat(op, 3, 4, 5), EncodeRFormat(op, 31, 0, 31))
+	}
+	for _, n := range [][2]uint8{{OpLDN, FnByte}, {OpLDN, FnByteU}, {OpLDN, FnHalf}, {OpLDN, FnHalfU},
+		{OpSTN, FnByte}, {OpSTN, FnHalf}} {
+		prog = append(prog, EncodeNFormat(n[0], 7, 2, n[1], -16384), EncodeNFormat(n[0], 31, 31, n[1], 16383))
+	}
+	for _, op := range []uint8{OpADDI, OpANDI, OpORI, OpXORI, OpLW, OpLR, OpJALR} {
+		prog = append(prog, EncodeIFormat(op, 1, 2, -65536), EncodeIFormat(op, 31, 0, 65535))
+	}
+	prog = append(prog,
+		EncodeIFormat(OpLUI, 4, 0, 0x1234),
+		EncodeIFormat(OpLUI, 4, 0, 0x10000), // Upper half of 0x80000000
+		EncodeIFormat(OpLUI, 4, 0, 0x1FFFF),
+		EncodeSFormat(OpSW, 2, 6, -65536),
+		EncodeSFormat(OpSW, 31, 0, 65535),
+		EncodeSC(7, 2, 6, -2048),
+		EncodeSC(0, 31, 31, 2047),
+		EncodeSystem(SysFnCall, 0, 0, 0),
+		EncodeSystem(SysFnCall, 0, 0, 0xFFF),
+		EncodeSystem(SysFnCSRRead, 5, 0, CSRCause),
+		EncodeSystem(SysFnCSRWrite, 0, 6, CSRTvec),
+		EncodeSystem(SysFnTrapRet, 0, 0, 0),
+		EncodeSystem(SysFnTLBFlush, 0, 0, 0),
+	)
+	// Branches and jumps to the first word, the last word and themselves
+	n := int32(len(prog))
+	for i, op := range []uint8{OpBEQ, OpBNE, OpBLT, OpBGE} {
+		at := n + int32(i)*3
+		prog = append(prog,
+			EncodeBFormat(op, 1, 2, -4*at),
+			EncodeBFormat(op, 31, 0, 0),
+			EncodeBFormat(op, 0, 31, 4*(n+14-at-2)))
+	}
+	at := n + 12
+	prog = append(prog, EncodeIFormat(OpJAL, 1, 0, -4*at), EncodeIFormat(OpJAL, 0, 0, 4))
+	return prog
+}
+
+func TestISA_DisassemblyReassembles(t *testing.T) {
+	// WHAT: A listing of every instruction format assembles back to the identical words
+	// WHY: The assembler promises to accept the disassembler's syntax, so listings and
+	//      debugger output can be pasted back in; a field printed in a form the assembler
+	//      reads differently (or rejects) breaks that promise
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	prog := roundTripProgram()
+	const base = 0x1000
+
+	// Branch and jump targets print as addresses; give every word a label to land on
+	var src strings.Builder
+	src.WriteString("\t.text\n")
+	for i, word := range prog {
+		pc := uint32(base + 4*i)
+		text := Disassemble(word, pc)
+		inst := DecodeInstruction(word, pc)
+		if inst.IsBranch || inst.Opcode == OpJAL {
+			target := fmt.Sprintf("0x%x", uint32(int32(pc)+inst.Imm))
+			if !strings.HasSuffix(text, target) {
+				t.Fatalf("%q does not end in its target %s", text, target)
+			}
+			text = strings.TrimSuffix(text, target) + fmt.Sprintf("L%x", uint32(int32(pc)+inst.Imm))
+		}
+		fmt.Fprintf(&src, "L%x:\t%s\n", pc, text)
+	}
+	fmt.Fprintf(&src, "L%x:\n", base+4*len(prog))
+
+	obj, err := Assemble("listing.s", src.String())
+	if err != nil {
+		t.Fatalf("listing does not assemble: %v", err)
+	}
+	img, err := Link([]*Object{obj}, nil)
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	text := img.Segments[0]
+	if text.Addr != base || len(text.Data) != 4*len(prog) {
+		t.Fatalf(".text at %#x with %d bytes, expected %#x with %d", text.Addr, len(text.Data), base, 4*len(prog))
+	}
+	for i, want := range prog {
+		got := uint32(text.Data[4*i]) | uint32(text.Data[4*i+1])<<8 |
+			uint32(text.Data[4*i+2])<<16 | uint32(text.Data[4*i+3])<<24
+		if got != want {
+			t.Errorf("%q: reassembled to %#08x, expected %#08x", Disassemble(want, uint32(base+4*i)), got, want)
+		}
+	}
+}
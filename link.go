@@ -0,0 +1,328 @@
+package suprax32
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// LINKER
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// Combines relocatable objects (object.go) into an executable Image that
+// Core.LoadImage places at its intended addresses.
+//
+// ALGORITHM:
+//
+//	STEP 1: Layout. Walk the script's sections in order. Each section
+//	        starts at its script address (or right after the previous
+//	        one, aligned) and holds every object's contribution back to
+//	        back, each aligned to 4 bytes.
+//	STEP 2: Symbols. Global symbols go into one table (defining the
+//	        same global twice is an error). Local symbols are only seen
+//	        by their own object. The linker adds __<sec>_start and
+//	        __<sec>_end for each section (e.g. __bss_start).
+//	STEP 3: Relocate. Patch every relocation with its symbol's final
+//	        address, checking that PC-relative offsets fit in 17 bits.
+//	STEP 4: Entry point = the ENTRY symbol (default _start, or the
+//	        start of .text when no _start exists).
+//
+// LINKER SCRIPT:
+//
+//	# comment
+//	ENTRY(_start)
+//	.text 0x1000
+//	.data 0x4000 ALIGN 64
+//	.bss
+//
+//	A section without an address follows the previous one. Sections the
+//	script leaves out are placed after the listed ones in the order
+//	.text, .data, .bss.
+//
+// MINECRAFT ANALOGY: Several players build parts of a base in creative
+//
+//	mode, then one person pastes them into the world side by side and
+//	reconnects the redstone between them.
+
+// ScriptSection places one output section
+type ScriptSection struct {
+	Kind  SectionKind
+	Addr  uint32 // Start address (used when HasAddr)
+	Align uint32 // Start alignment (power of two, 0 = 4)
+	// HasAddr is false when the section follows the previous one
+	HasAddr bool
+}
+
+// LinkerScript controls section placement and the entry point
+type LinkerScript struct {
+	Entry    string // Entry symbol ("" = _start, falling back to .text)
+	Sections []ScriptSection
+}
+
+// DefaultLinkerScript puts .text at the core's reset PC with data after it
+func DefaultLinkerScript() *LinkerScript {
+	return &LinkerScript{
+		Sections: []ScriptSection{
+			{Kind: SecText, Addr: 0x1000, HasAddr: true},
+			{Kind: SecData},
+			{Kind: SecBSS},
+		},
+	}
+}
+
+// ParseLinkerScript parses the text form described above
+func ParseLinkerScript(src string) (*LinkerScript, error) {
+	ls := &LinkerScript{}
+	for lineNo, line := range strings.Split(src, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(fields[0], "ENTRY(") && strings.HasSuffix(fields[0], ")") && len(fields) == 1 {
+			ls.Entry = strings.TrimSuffix(strings.TrimPrefix(fields[0], "ENTRY("), ")")
+			continue
+		}
+
+		sec, err := parseScriptSection(fields)
+		if err != nil {
+			return nil, fmt.Errorf("linker script line %d: %v", lineNo+1, err)
+		}
+		for _, s := range ls.Sections {
+			if s.Kind == sec.Kind {
+				return nil, fmt.Errorf("linker script line %d: %s placed twice", lineNo+1, sec.Kind)
+			}
+		}
+		ls.Sections = append(ls.Sections, sec)
+	}
+	return ls, nil
+}
+
+// parseScriptSection parses ".name [addr] [ALIGN n]"
+func parseScriptSection(fields []string) (ScriptSection, error) {
+	sec := ScriptSection{Kind: SecUndef}
+	for k := SectionKind(0); k < NumSections; k++ {
+		if fields[0] == k.String() {
+			sec.Kind = k
+		}
+	}
+	if sec.Kind == SecUndef {
+		return sec, fmt.Errorf("unknown directive %q", fields[0])
+	}
+
+	rest := fields[1:]
+	if len(rest) > 0 && rest[0] != "ALIGN" {
+		addr, err := strconv.ParseUint(rest[0], 0, 32)
+		if err != nil {
+			return sec, fmt.Errorf("bad address %q", rest[0])
+		}
+		sec.Addr, sec.HasAddr = uint32(addr), true
+		rest = rest[1:]
+	}
+	if len(rest) == 2 && rest[0] == "ALIGN" {
+		align, err := strconv.ParseUint(rest[1], 0, 32)
+		if err != nil || align == 0 || align&(align-1) != 0 {
+			return sec, fmt.Errorf("bad alignment %q", rest[1])
+		}
+		sec.Align = uint32(align)
+		rest = nil
+	}
+	if len(rest) != 0 {
+		return sec, fmt.Errorf("unexpected %q", strings.Join(rest, " "))
+	}
+	return sec, nil
+}
+
+// Segment is a contiguous range of the loaded image
+type Segment struct {
+	Name string
+	Addr uint32
+	Data []byte // Initialised bytes
+	Size uint32 // Size in memory (≥ len(Data), the rest is zero)
+}
+
+// Image is a linked program ready to load
+type Image struct {
+	Entry    uint32
+	Segments []Segment
+	Symbols  map[string]uint32 // Global and linker-defined symbols
+}
+
+// Link combines objects into an image (nil script = DefaultLinkerScript)
+func Link(objs []*Object, script *LinkerScript) (*Image, error) {
+	if script == nil {
+		script = DefaultLinkerScript()
+	}
+
+	// Place sections the script leaves out after the listed ones
+	order := append([]ScriptSection(nil), script.Sections...)
+	for k := SectionKind(0); k < NumSections; k++ {
+		listed := false
+		for _, s := range order {
+			listed = listed || s.Kind == k
+		}
+		if !listed {
+			order = append(order, ScriptSection{Kind: k})
+		}
+	}
+
+	// STEP 1: Layout
+	img := &Image{Symbols: make(map[string]uint32)}
+	base := make([][NumSections]uint32, len(objs)) // base[obj][sec]
+	var start [NumSections]uint32
+	addr := uint32(0)
+	for _, s := range order {
+		if s.HasAddr {
+			addr = s.Addr
+		}
+		align := s.Align
+		if align == 0 {
+			align = 4
+		}
+		addr = alignUp(addr, align)
+		start[s.Kind] = addr
+
+		seg := Segment{Name: s.Kind.String(), Addr: addr}
+		for i, o := range objs {
+			off := alignUp(seg.Size, 4)
+			base[i][s.Kind] = addr + off
+			if s.Kind != SecBSS {
+				seg.Data = append(seg.Data, make([]byte, off-seg.Size)...)
+				seg.Data = append(seg.Data, o.section(s.Kind)...)
+			}
+			seg.Size = off + o.sectionSize(s.Kind)
+		}
+		if uint64(addr)+uint64(seg.Size) > 1<<32 {
+			return nil, fmt.Errorf("%s at 0x%08x overflows the address space", seg.Name, addr)
+		}
+
+		name := strings.TrimPrefix(seg.Name, ".")
+		img.Symbols["__"+name+"_start"] = addr
+		img.Symbols["__"+name+"_end"] = addr + seg.Size
+		if seg.Size > 0 {
+			img.Segments = append(img.Segments, seg)
+		}
+		addr += seg.Size
+	}
+	if err := checkOverlap(img.Segments); err != nil {
+		return nil, err
+	}
+
+	// STEP 2: Symbols
+	for i, o := range objs {
+		for _, sym := range o.Symbols {
+			if sym.Section == SecUndef || !sym.Global {
+				continue
+			}
+			if sym.Section >= NumSections {
+				return nil, fmt.Errorf("%s: symbol %q in unknown section %d", o.Name, sym.Name, sym.Section)
+			}
+			if _, dup := img.Symbols[sym.Name]; dup {
+				return nil, fmt.Errorf("%s: multiple definition of %q", o.Name, sym.Name)
+			}
+			img.Symbols[sym.Name] = base[i][sym.Section] + sym.Value
+		}
+	}
+
+	// STEP 3: Relocate
+	segOf := make(map[SectionKind]*Segment)
+	for i := range img.Segments {
+		for k := SectionKind(0); k < NumSections; k++ {
+			if img.Segments[i].Name == k.String() {
+				segOf[k] = &img.Segments[i]
+			}
+		}
+	}
+	for i, o := range objs {
+		for _, r := range o.Relocs {
+			s, ok := resolve(o, base[i], img.Symbols, r.Symbol)
+			if !ok {
+				return nil, fmt.Errorf("%s: undefined reference to %q", o.Name, r.Symbol)
+			}
+			if r.Section != SecText && r.Section != SecData {
+				return nil, fmt.Errorf("%s: relocation in %s", o.Name, r.Section)
+			}
+			if uint64(r.Offset)+4 > uint64(len(o.section(r.Section))) {
+				return nil, fmt.Errorf("%s: relocation at %s+0x%x is outside the section", o.Name, r.Section, r.Offset)
+			}
+			p := base[i][r.Section] + r.Offset
+			seg := segOf[r.Section]
+			at := seg.Data[p-seg.Addr:]
+			word, err := relocate(binary.LittleEndian.Uint32(at), r, s, p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", o.Name, err)
+			}
+			binary.LittleEndian.PutUint32(at, word)
+		}
+	}
+
+	// STEP 4: Entry point
+	entry := script.Entry
+	if entry == "" {
+		entry = "_start"
+	}
+	if e, ok := img.Symbols[entry]; ok {
+		img.Entry = e
+	} else if script.Entry != "" {
+		return nil, fmt.Errorf("entry symbol %q is not defined", entry)
+	} else {
+		img.Entry = start[SecText]
+	}
+	return img, nil
+}
+
+// resolve finds a symbol's address: own definitions first, then globals
+func resolve(o *Object, base [NumSections]uint32, globals map[string]uint32, name string) (uint32, bool) {
+	if sym, ok := o.Lookup(name); ok && sym.Section < NumSections {
+		return base[sym.Section] + sym.Value, true
+	}
+	addr, ok := globals[name]
+	return addr, ok
+}
+
+// relocate patches one word
+//
+// v = S + A; PC-relative types store v - P and must fit in 17 signed bits.
+func relocate(word uint32, r Reloc, s, p uint32) (uint32, error) {
+	v := s + uint32(r.Addend)
+	switch r.Type {
+	case RelocBranch17, RelocJAL17:
+		d := int32(v - p)
+		if d < -(1<<16) || d >= 1<<16 {
+			return 0, fmt.Errorf("%s to %q at 0x%08x is out of range (%d bytes)", r.Type, r.Symbol, p, d)
+		}
+		return word&^0x1FFFF | uint32(d)&0x1FFFF, nil
+	case RelocHi17:
+		return word&^0x1FFFF | v>>15, nil
+	case RelocLo15:
+		return word&^0x1FFFF | v&0x7FFF, nil
+	case RelocAbs32:
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown relocation type %d", r.Type)
+}
+
+// checkOverlap rejects images whose segments share addresses
+func checkOverlap(segs []Segment) error {
+	sorted := append([]Segment(nil), segs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Addr < sorted[j].Addr })
+	for i := 1; i < len(sorted); i++ {
+		prev := sorted[i-1]
+		if uint64(prev.Addr)+uint64(prev.Size) > uint64(sorted[i].Addr) {
+			return fmt.Errorf("%s (0x%08x+0x%x) overlaps %s at 0x%08x",
+				prev.Name, prev.Addr, prev.Size, sorted[i].Name, sorted[i].Addr)
+		}
+	}
+	return nil
+}
+
+// alignUp rounds v up to a power-of-two boundary
+func alignUp(v, align uint32) uint32 {
+	return (v + align - 1) &^ (align - 1)
+}
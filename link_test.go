@@ -0,0 +1,137 @@
+package suprax32
+
+import (
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Linker - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A relocation that does not fit must fail the link; a silently truncated offset sends a
+// branch to a random instruction at run time. Each test places a target exactly at the edge
+// of a field's range, then one step past it, and checks which side links.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. RELOCATION RANGE TESTS
+//    PC-relative fields at and past their limits, forward and backward
+//
+// 2. RELOCATION ERROR TESTS
+//    Undefined symbols, offsets outside the section
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// branchObject emits a branch (or jal) to a label gap bytes away: forward if gap > 0,
+// backward otherwise
+func branchObject(jal bool, gap int32) *Object {
+	o := NewObject("far.s")
+	emit := func() {
+		if jal {
+			o.EmitJAL(0, "far")
+		} else {
+			o.EmitBranch(OpBEQ, 0, 0, "far")
+		}
+	}
+	pad := func(words int32) {
+		for i := int32(0); i < words; i++ {
+			o.Emit(EncodeIFormat(OpADDI, 0, 0, 0))
+		}
+	}
+	o.Label("_start", true)
+	if gap > 0 {
+		emit()
+		pad(gap/4 - 1)
+		o.Label("far", false)
+	} else {
+		o.Label("far", false)
+		pad(-gap / 4)
+		emit()
+	}
+	o.Emit(EncodeIFormat(OpJAL, 0, 0, 0))
+	return o
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. RELOCATION RANGE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestLink_PCRelativeRange(t *testing.T) {
+	// WHAT: Branch and JAL offsets link up to +65532 and down to -65536 bytes and fail
+	//       one word beyond, naming the relocation and the offset; the linked offsets
+	//       decode back to the target
+	// WHY: The fields hold 17 signed bits; an offset outside wraps to the other end of
+	//      the range if it is not caught
+	// HARDWARE: B-format and JAL immediates
+	// CATEGORY: [UNIT]
+
+	for _, jal := range []bool{false, true} {
+		name := map[bool]string{false: "branch17", true: "jal17"}[jal]
+		for _, c := range []struct {
+			gap int32
+			ok  bool
+		}{
+			{65532, true}, {65536, false},
+			{-65536, true}, {-65540, false},
+		} {
+			img, err := Link([]*Object{branchObject(jal, c.gap)}, nil)
+			if !c.ok {
+				if err == nil || !strings.Contains(err.Error(), name) || !strings.Contains(err.Error(), "out of range") {
+					t.Errorf("%s over %d bytes: %v, expected an out-of-range error", name, c.gap, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s over %d bytes: %v", name, c.gap, err)
+				continue
+			}
+
+			// The patched instruction lands on the label
+			text := img.Segments[0]
+			at := img.Symbols["_start"]
+			if c.gap < 0 {
+				at = img.Symbols["_start"] + uint32(-c.gap)
+			}
+			off := at - text.Addr
+			word := uint32(text.Data[off]) | uint32(text.Data[off+1])<<8 | uint32(text.Data[off+2])<<16 | uint32(text.Data[off+3])<<24
+			inst := DecodeInstruction(word, at)
+			if target := uint32(int32(at) + inst.Imm); target != at+uint32(c.gap) {
+				t.Errorf("%s over %d bytes lands at 0x%x, expected 0x%x", name, c.gap, target, at+uint32(c.gap))
+			}
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. RELOCATION ERROR TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestLink_RelocationErrors(t *testing.T) {
+	// WHAT: A reference to an undefined symbol and a relocation past the end of its
+	//       section both fail the link with the object's name
+	// WHY: Either would otherwise patch a word that is not there (or with address 0)
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	undef := NewObject("undef.s")
+	undef.Label("_start", true)
+	undef.EmitJAL(RegRA, "nowhere")
+	if _, err := Link([]*Object{undef}, nil); err == nil || !strings.Contains(err.Error(), `undef.s: undefined reference to "nowhere"`) {
+		t.Errorf("undefined symbol: %v", err)
+	}
+
+	outside := NewObject("outside.s")
+	outside.Label("_start", true)
+	outside.Emit(EncodeIFormat(OpJAL, 0, 0, 0))
+	outside.Relocs = append(outside.Relocs, Reloc{Section: SecText, Offset: 2, Type: RelocAbs32, Symbol: "_start"})
+	if _, err := Link([]*Object{outside}, nil); err == nil || !strings.Contains(err.Error(), "outside the section") {
+		t.Errorf("relocation past the end: %v", err)
+	}
+}
@@ -0,0 +1,522 @@
+package suprax32
+
+import "testing"
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Load/Store Path - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A load or store crosses three pieces of hardware: the LSU (timing, extension), the L1D
+// (which bytes of which line) and, through the window, the rest of the core. The unit tests
+// drive the LSU and L1D directly with a line installed by hand, so a failure names the
+// piece that is wrong; the core tests then run real programs through all of them.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. L1D TESTS
+//    Narrow reads and merging writes within a resident line
+//
+// 2. LSU TESTS
+//    Sign and zero extension of narrow loads
+//
+// 3. MISS AND EVICTION TESTS
+//    Demand fills, write-allocate, write-back of dirty victims
+//
+// 4. CORE TESTS
+//    Result hand-off, narrow loads and stores through the whole pipeline
+//
+// 5. PREFETCH QUEUE TESTS
+//    Predictions within one line collapse into a single line request
+//
+// 6. MEMORY ORDERING TESTS
+//    Loads wait for overlapping older stores; stores never write from a wrong path
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// testLineAddr is where the unit tests install their line
+const testLineAddr = 0x4000
+
+// filledL1D returns an L1D holding one clean line at testLineAddr with bytes 0x80, 0x81, ...
+func filledL1D() *L1DCache {
+	dc := NewL1DCache()
+	data := make([]byte, CacheLineSize)
+	for i := range data {
+		data[i] = byte(0x80 + i)
+	}
+	dc.Fill(testLineAddr, data)
+	return dc
+}
+
+// lsuLoad runs one load through an LSU in front of dc and returns the result
+func lsuLoad(t *testing.T, dc *L1DCache, addr uint32, size uint8, signed bool) uint32 {
+	t.Helper()
+	return lsuRun(t, dc, MemoryOperation{Addr: addr, Size: size, Signed: signed})
+}
+
+// lsuRun runs one operation through an LSU in front of dc, allowing for a miss
+func lsuRun(t *testing.T, dc *L1DCache, op MemoryOperation) uint32 {
+	t.Helper()
+	lsu := NewLSU(dc)
+	lsu.Issue(op)
+	for i := 0; i < L1Latency+DRAMLatency+1; i++ {
+		lsu.Tick()
+		if data, _, _, ok := lsu.GetResult(); ok {
+			return data
+		}
+	}
+	t.Fatalf("%d-byte access at %#x never completed", op.Size, op.Addr)
+	return 0
+}
+
+// backedL1D returns an empty L1D in front of a memory whose byte i is byte(i)
+func backedL1D() (*L1DCache, []byte) {
+	mem := make([]byte, 1<<20)
+	for i := range mem {
+		mem[i] = byte(i)
+	}
+	dc := NewL1DCache()
+	dc.memory = mem
+	return dc, mem
+}
+
+// setConflict returns the nth address after addr that maps to the same L1D set
+func setConflict(addr uint32, n int) uint32 {
+	return addr + uint32(n*L1DNumSets*CacheLineSize)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. L1D TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMemory_NarrowReadsAssembleLittleEndian(t *testing.T) {
+	// WHAT: ReadSize returns 1, 2 or 4 bytes from the line, little-endian and zero-extended
+	// WHY: Extension is the LSU's job; the L1D must hand it exactly the bytes asked for
+	// HARDWARE: L1D read byte-select mux
+	// CATEGORY: [UNIT]
+
+	dc := filledL1D()
+	cases := []struct {
+		addr uint32
+		size int
+		want uint32
+	}{
+		{testLineAddr + 0, 1, 0x80},
+		{testLineAddr + 5, 1, 0x85},
+		{testLineAddr + 2, 2, 0x8382},
+		{testLineAddr + 62, 2, 0xBFBE},
+		{testLineAddr + 4, 4, 0x87868584},
+	}
+	for _, c := range cases {
+		data, hit := dc.ReadSize(0x1000, c.addr, c.size)
+		if !hit || data != c.want {
+			t.Errorf("ReadSize(%#x, %d) = %#x (hit %v), expected %#x", c.addr, c.size, data, hit, c.want)
+		}
+	}
+}
+
+func TestMemory_NarrowStoresMergeIntoLine(t *testing.T) {
+	// WHAT: SB and SH change only their own bytes of a cached line and mark it dirty
+	// WHY: A narrow store that wrote a whole word would clobber its neighbours, and a
+	//      line left clean would never be written back
+	// HARDWARE: L1D write byte enables
+	// CATEGORY: [UNIT]
+
+	dc := filledL1D()
+	if !dc.WriteSize(testLineAddr+1, 0xAABBCCDD, 1) {
+		t.Fatal("SB missed the installed line")
+	}
+	if !dc.WriteSize(testLineAddr+6, 0x11223344, 2) {
+		t.Fatal("SH missed the installed line")
+	}
+
+	line, _, ok := dc.Probe(testLineAddr)
+	if !ok {
+		t.Fatal("line lost")
+	}
+	want := [8]byte{0x80, 0xDD, 0x82, 0x83, 0x84, 0x85, 0x44, 0x33}
+	for i, b := range want {
+		if line.Data[i] != b {
+			t.Errorf("byte %d = %#x, expected %#x", i, line.Data[i], b)
+		}
+	}
+	if line.Data[8] != 0x88 {
+		t.Errorf("byte 8 = %#x, the byte after the halfword changed", line.Data[8])
+	}
+	if !line.Dirty {
+		t.Error("line not dirty after a narrow store")
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. LSU TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMemory_NarrowLoadsExtend(t *testing.T) {
+	// WHAT: LB and LH sign-extend, LBU and LHU zero-extend, on values with the top bit
+	//       set and clear
+	// WHY: Extension is the only difference between the four loads
+	// HARDWARE: LSU result sign-extension stage
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	dc := filledL1D()
+	dc.WriteSize(testLineAddr+8, 0x7F, 1)
+	dc.WriteSize(testLineAddr+10, 0x7FFF, 2)
+
+	cases := []struct {
+		name   string
+		addr   uint32
+		size   uint8
+		signed bool
+		want   uint32
+	}{
+		{"lb negative", testLineAddr, 1, true, 0xFFFFFF80},
+		{"lbu negative", testLineAddr, 1, false, 0x00000080},
+		{"lb positive", testLineAddr + 8, 1, true, 0x0000007F},
+		{"lbu positive", testLineAddr + 8, 1, false, 0x0000007F},
+		{"lh negative", testLineAddr + 2, 2, true, 0xFFFF8382},
+		{"lhu negative", testLineAddr + 2, 2, false, 0x00008382},
+		{"lh positive", testLineAddr + 10, 2, true, 0x00007FFF},
+		{"lhu positive", testLineAddr + 10, 2, false, 0x00007FFF},
+		{"lw", testLineAddr + 4, 4, false, 0x87868584},
+	}
+	for _, c := range cases {
+		if got := lsuLoad(t, dc, c.addr, c.size, c.signed); got != c.want {
+			t.Errorf("%s: %#08x, expected %#08x", c.name, got, c.want)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. MISS AND EVICTION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMemory_LoadMissFillsFromMemory(t *testing.T) {
+	// WHAT: A load that misses waits out DRAM latency, installs the line, and returns
+	//       memory's bytes; the next load to the line hits
+	// WHY: Without a demand fill a missing load retries forever unless a prefetch
+	//      happens to bring its line in
+	// HARDWARE: L1D refill path (INNOVATION #73)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	dc, _ := backedL1D()
+	if got := lsuLoad(t, dc, 0x8006, 2, false); got != 0x0706 {
+		t.Errorf("lhu after a miss = %#x, expected 0x0706", got)
+	}
+	if _, _, ok := dc.Probe(0x8000); !ok {
+		t.Fatal("miss did not install the line")
+	}
+	if data, hit := dc.ReadSize(0x1000, 0x803C, 4); !hit || data != 0x3F3E3D3C {
+		t.Errorf("rest of the line = %#x (hit %v), expected 0x3f3e3d3c", data, hit)
+	}
+}
+
+func TestMemory_StoreMissWriteAllocates(t *testing.T) {
+	// WHAT: A store that misses fetches its line and merges into it; the rest of the
+	//       line keeps memory's bytes and memory is untouched until write-back
+	// WHY: A missing store used to be dropped, losing the write
+	// HARDWARE: L1D write-allocate
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	dc, mem := backedL1D()
+	lsuRun(t, dc, MemoryOperation{Addr: 0x9005, Data: 0xAA, Size: 1, IsStore: true})
+
+	line, _, ok := dc.Probe(0x9000)
+	if !ok {
+		t.Fatal("store miss did not allocate the line")
+	}
+	if line.Data[5] != 0xAA || line.Data[4] != 0x04 || line.Data[6] != 0x06 || !line.Dirty {
+		t.Errorf("line bytes 4-6 = % x (dirty %v), expected 04 aa 06 and dirty",
+			line.Data[4:7], line.Dirty)
+	}
+	if mem[0x9005] != 0x05 {
+		t.Error("write-back cache wrote memory before eviction")
+	}
+}
+
+func TestMemory_DirtyVictimWrittenBack(t *testing.T) {
+	// WHAT: Evicting a dirty line copies it to memory; evicting a clean line does not
+	// WHY: The L1D is write-back: a dirty victim is the only copy of its stores
+	// HARDWARE: L1D victim write-back (INNOVATION #19 LRU picks the victim)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	dc, mem := backedL1D()
+	lsuRun(t, dc, MemoryOperation{Addr: 0xA004, Data: 0xDEADBEEF, Size: 4, IsStore: true})
+
+	clean := make([]byte, CacheLineSize)
+	for i := range clean {
+		clean[i] = 0xEE
+	}
+	dc.Fill(0xB000, clean) // Different set: stays resident, never evicted
+
+	// Fill the rest of the set, then make the dirty line the victim: findVictim
+	// replaces the way updateLRU recorded last once no way is free
+	for n := 1; n < L1Associativity; n++ {
+		dc.Fill(setConflict(0xA000, n), make([]byte, CacheLineSize))
+	}
+	dc.ReadSize(0x1000, 0xA000, 4)
+	dc.Fill(setConflict(0xA000, L1Associativity), make([]byte, CacheLineSize))
+
+	if _, _, ok := dc.Probe(0xA000); ok {
+		t.Fatal("dirty line still resident")
+	}
+	if got := mem[0xA004:0xA008]; got[0] != 0xEF || got[1] != 0xBE || got[2] != 0xAD || got[3] != 0xDE {
+		t.Errorf("memory after eviction = % x, expected ef be ad de", got)
+	}
+	if mem[0xA003] != 0x03 || mem[0xA008] != 0x08 {
+		t.Error("write-back changed bytes the store did not touch")
+	}
+
+	// The line filled last is the next victim, and it is clean: memory keeps its bytes
+	last := setConflict(0xA000, L1Associativity)
+	dc.Fill(setConflict(0xA000, L1Associativity+1), clean)
+	if _, _, ok := dc.Probe(last); ok {
+		t.Fatal("clean line still resident")
+	}
+	if mem[last] != byte(last) || mem[last+1] != byte(last+1) {
+		t.Error("clean victim written back")
+	}
+	if dc.dirtyEvictions != 1 {
+		t.Errorf("%d dirty evictions, expected 1", dc.dirtyEvictions)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 4. CORE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMemory_UncollectedResultSurvivesIssue(t *testing.T) {
+	// WHAT: A load result the complete stage has not taken yet is still there after the
+	//       LSU accepts its next operation
+	// WHY: The execute stage runs before issue, so an LSU that finishes in a cycle can be
+	//      handed new work before its result is collected; clearing the result on Issue
+	//      lost the load, and its instruction never completed
+	// HARDWARE: LSU result register vs. operation register
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	dc := filledL1D()
+	lsu := NewLSU(dc)
+	lsu.Issue(MemoryOperation{Addr: testLineAddr, Size: 1, WindowID: 3})
+	lsu.Tick()
+	if !lsu.Issue(MemoryOperation{Addr: testLineAddr + 1, Size: 1, WindowID: 4}) {
+		t.Fatal("LSU still busy after its load hit")
+	}
+
+	data, _, winID, ok := lsu.GetResult()
+	if !ok || winID != 3 || data != 0x80 {
+		t.Fatalf("first result = %#x for window %d (valid %v), expected 0x80 for window 3", data, winID, ok)
+	}
+	lsu.Tick()
+	if data, _, winID, ok = lsu.GetResult(); !ok || winID != 4 || data != 0x81 {
+		t.Errorf("second result = %#x for window %d (valid %v), expected 0x81 for window 4", data, winID, ok)
+	}
+}
+
+func TestMemory_NarrowAccessesThroughCore(t *testing.T) {
+	// WHAT: A program using all four narrow loads and both narrow stores commits the
+	//       extended values and leaves memory with exactly the stored bytes
+	// WHY: End to end: decode, window, LSU, L1D refill and the commit of each result
+	// HARDWARE: Whole load/store path
+	// CATEGORY: [INTEGRATION]
+
+	core := NewCore(1 << 20)
+	core.WriteMemWord(0x4000, 0x80017F80)
+	core.LoadProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0x4000),
+		EncodeNFormat(OpLDN, 2, 1, FnByte, 0),     // r2 = lb  0x80   → 0xffffff80
+		EncodeNFormat(OpLDN, 3, 1, FnByteU, 0),    // r3 = lbu 0x80   → 0x00000080
+		EncodeNFormat(OpLDN, 4, 1, FnHalf, 2),     // r4 = lh  0x8001 → 0xffff8001
+		EncodeNFormat(OpLDN, 5, 1, FnHalfU, 2),    // r5 = lhu 0x8001 → 0x00008001
+		EncodeNFormat(OpSTN, 4, 1, FnHalf, 0x100), // 0x4100 = 01 80
+		EncodeNFormat(OpSTN, 2, 1, FnByte, 0x102), // 0x4102 = 80 (0x4103 untouched)
+		EncodeIFormat(OpADDI, 7, 0, 42),
+	}, 0x1000)
+	core.Run(2000)
+
+	regs := NewDebugger(core).Registers()
+	want := map[int]uint32{2: 0xFFFFFF80, 3: 0x80, 4: 0xFFFF8001, 5: 0x8001, 7: 42}
+	for r, v := range want {
+		if regs[r] != v {
+			t.Errorf("r%d = %#08x, expected %#08x", r, regs[r], v)
+		}
+	}
+	if got := core.ReadMem(0x4100, 4); got[0] != 0x01 || got[1] != 0x80 || got[2] != 0x80 || got[3] != 0x00 {
+		t.Errorf("memory at 0x4100 = % x, expected 01 80 80 00", got)
+	}
+}
+
+func TestMemory_MisalignedAccessesTrap(t *testing.T) {
+	// WHAT: Halfword and word loads and stores at addresses their size does not divide
+	//       trap with the misaligned cause and the address in badaddr, write nothing, and
+	//       resume after the handler; bytes and aligned accesses at the same addresses work
+	// WHY: Halfwords used to drop bit 0 and silently access the wrong bytes, and a
+	//      misaligned word at the end of a line indexed past it
+	// HARDWARE: Alignment check at issue, precise trap at commit (INNOVATION #47)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	core, _ := runAsm(t, `
+	.text
+_start:
+	la    t0, handler
+	csrw  tvec, t0
+	li    s0, 0               # Causes, one per nibble
+	li    s1, 0x4100          # Bad addresses, one word each
+	li    t1, 0x4000
+	li    t2, 0x1234
+	sw    t2, 0(t1)           # 34 12 00 00
+	li    a1, -1
+	li    a2, -1
+	li    a3, -1
+	lh    a1, 1(t1)           # Load misaligned
+	lhu   a2, 3(t1)           # Load misaligned
+	lw    a3, 2(t1)           # Load misaligned
+	sh    t2, 5(t1)           # Store misaligned
+	sw    t2, 6(t1)           # Store misaligned
+	lh    a4, 0(t1)           # Aligned
+	lb    a5, 1(t1)           # Bytes go anywhere
+	sb    t2, 7(t1)
+	mv    a0, s0
+	system 0
+
+handler:
+	csrr  t5, cause
+	li    t6, 4
+	sll   s0, s0, t6
+	or    s0, s0, t5
+	csrr  t5, badaddr
+	sw    t5, 0(s1)
+	addi  s1, s1, 4
+	csrr  t5, epc
+	addi  t5, t5, 4
+	csrw  epc, t5
+	eret
+`, func(c *Core, pt *pageTables, img *Image) {})
+
+	if status, _ := core.Exited(); status != 0x66677 {
+		t.Errorf("trap causes 0x%x, expected 0x66677 (load x3, store x2)", status)
+	}
+	dbg := NewDebugger(core)
+	for i, want := range []uint32{0x4001, 0x4003, 0x4002, 0x4005, 0x4006} {
+		if got := dbg.ReadWord(0x4100 + 4*uint32(i)); got != want {
+			t.Errorf("trap %d: badaddr 0x%x, expected 0x%x", i, got, want)
+		}
+	}
+	regs := dbg.Registers()
+	for r, want := range map[int]uint32{5: 0xFFFFFFFF, 6: 0xFFFFFFFF, 7: 0xFFFFFFFF, 8: 0x1234, 9: 0x12} {
+		if regs[r] != want {
+			t.Errorf("%s = %#x, expected %#x", ABIRegName(uint8(r)), regs[r], want)
+		}
+	}
+	if got := core.ReadMem(0x4000, 8); string(got) != "\x34\x12\x00\x00\x00\x00\x00\x34" {
+		t.Errorf("memory at 0x4000 = % x, expected 34 12 00 00 00 00 00 34", got)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 5. PREFETCH QUEUE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMemory_PrefetchesWithinALineCollapse(t *testing.T) {
+	// WHAT: Byte-stride predictions into one line queue a single request for the line
+	//       address, and completing that line drains the queue
+	// WHY: Unaligned addresses filled the queue with one entry per byte, and Complete
+	//      (called with the line address the fill reports) never matched them, so the
+	//      queue stayed full and dropped every later prediction
+	// HARDWARE: Prefetch queue (INNOVATION #67)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	var pq PrefetchQueue
+	for i := uint32(0); i < 4; i++ {
+		pq.Enqueue(testLineAddr+1+i, PredictorStride)
+	}
+	if pq.count != 1 {
+		t.Fatalf("queued %d requests for one line, expected 1", pq.count)
+	}
+
+	addr, _, ok := pq.Dequeue()
+	if !ok || addr != testLineAddr {
+		t.Fatalf("Dequeue = %#x, %v, expected line address %#x", addr, ok, testLineAddr)
+	}
+	pq.Complete(testLineAddr)
+	if pq.count != 0 {
+		t.Errorf("%d requests left after the line completed, expected none", pq.count)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 6. MEMORY ORDERING TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMemory_LoadWaitsForOlderStore(t *testing.T) {
+	// WHAT: Loads after a store to the same bytes read the stored value, when the store's
+	//       data or its base register is still being computed by the divider
+	// WHY: There is no store buffer to forward from, so a load that issues before an
+	//      older overlapping store reads the stale line
+	// HARDWARE: Window.olderStoreConflict at issue select
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	core := NewCore(1 << 20)
+	core.WriteMemWord(0xC000, 0xAAAAAAAA)
+	core.WriteMemWord(0xC008, 0xAAAAAAAA)
+	core.LoadProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0xC000),
+		EncodeIFormat(OpLW, 10, 1, 0),        // Bring the line in
+		EncodeIFormat(OpADDI, 9, 10, 0x1003), // r9 = 0xAAAABAAD
+		EncodeIFormat(OpADDI, 8, 0, 3),
+		EncodeRFormat(OpDIV, 2, 9, 8),          // r2 = 0x38E3938F, four cycles late
+		EncodeSFormat(OpSW, 1, 2, 0),           // [0xC000] = r2, data late
+		EncodeIFormat(OpLW, 3, 1, 0),           // r3 = r2
+		EncodeNFormat(OpLDN, 4, 1, FnByteU, 1), // r4 = 0x93 (partial overlap)
+		EncodeRFormat(OpDIV, 5, 1, 8),          // r5 = 0x4000
+		EncodeIFormat(OpADDI, 5, 5, 0x8000),    // r5 = 0xC000, base late
+		EncodeSFormat(OpSW, 5, 8, 8),           // [0xC008] = 3
+		EncodeIFormat(OpLW, 6, 1, 8),           // r6 = 3
+		EncodeIFormat(OpADDI, 7, 0, 42),
+	}, 0x1000)
+	core.Run(1000) // Stop before fetch runs off the end into the data
+
+	regs := NewDebugger(core).Registers()
+	want := map[int]uint32{3: 0x38E3938F, 4: 0x93, 6: 3, 7: 42}
+	for r, v := range want {
+		if regs[r] != v {
+			t.Errorf("r%d = %#x, expected %#x", r, regs[r], v)
+		}
+	}
+}
+
+func TestMemory_WrongPathStoreNeverWritten(t *testing.T) {
+	// WHAT: A store at the predicted target of a branch that is not taken leaves memory
+	//       untouched
+	// WHY: Stores write the L1D when they issue; issuing one before the branch ahead of
+	//      it resolved let a squashed store change memory
+	// HARDWARE: Stores issue only from the window head
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	core := NewCore(1 << 20)
+	core.LoadProgram([]uint32{
+		EncodeIFormat(OpADDI, 1, 0, 0xC000),
+		EncodeIFormat(OpLW, 10, 1, 0), // Bring the line in (r10 = 0)
+		EncodeIFormat(OpADDI, 2, 10, 0x55),
+		EncodeIFormat(OpADDI, 8, 10, 1000),
+		EncodeIFormat(OpADDI, 9, 0, 7),
+		EncodeRFormat(OpDIV, 3, 8, 9),  // r3 = 142, resolves the branch late
+		EncodeBFormat(OpBEQ, 3, 0, 12), // Predicted taken (counters start at 8), falls through
+		EncodeIFormat(OpADDI, 7, 0, 42),
+		EncodeIFormat(OpJAL, 0, 0, 8), // Skip the store
+		EncodeSFormat(OpSW, 1, 2, 0),  // Wrong path only
+		EncodeIFormat(OpADDI, 6, 0, 1),
+	}, 0x1000)
+	core.Run(1000)
+
+	if regs := NewDebugger(core).Registers(); regs[7] != 42 || regs[6] != 1 {
+		t.Fatalf("r7 = %d, r6 = %d: program did not finish", regs[7], regs[6])
+	}
+	if got := core.ReadMem(0xC000, 4); got[0] != 0 {
+		t.Errorf("memory at 0xC000 = % x, expected the wrong-path store to be discarded", got)
+	}
+}
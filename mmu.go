@@ -0,0 +1,533 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// VIRTUAL MEMORY: PAGE TABLES, TLBs AND THE PAGE WALKER
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: Core.memory is addressed directly, so every program sees every
+//
+//	byte. Process isolation, demand paging and relocation all need a
+//	level of indirection between the address a program uses (virtual)
+//	and the byte it reaches (physical).
+//
+// THE PAGE TABLE (two levels, 4KB pages):
+//
+//	 31        22 21        12 11          0
+//	┌────────────┬────────────┬─────────────┐
+//	│   VPN[1]   │   VPN[0]   │   offset    │   virtual address
+//	└────────────┴────────────┴─────────────┘
+//
+//	PTBR (CSRPtbr) = [enable:1][unused:11][root PPN:20]
+//	Root table  = 1024 PTEs at PPN<<12, indexed by VPN[1]
+//	Leaf tables = 1024 PTEs each, indexed by VPN[0]
+//
+//	PTE = [PPN:20][unused:7][U][X][W][R][V]
+//
+//	V=0          → page fault
+//	R=W=X=0      → pointer to the next-level table at PPN<<12
+//	any of R/W/X → leaf. A leaf in the root table maps a 4MB megapage
+//	               (its PPN must be 4MB aligned)
+//	W without R  → reserved, page fault
+//	U            → user mode may use the page (machine mode may use
+//	               every page; see trap.go)
+//
+// TRANSLATION (translate):
+//
+//	STEP 1: MMU off (PTBR enable bit clear) → physical = virtual in
+//	        machine mode, page fault in user mode
+//	STEP 2: Look up the ITLB (fetch) or DTLB (loads and stores)
+//	STEP 3: Hit → check R/W/X (and U in user mode) for the access,
+//	        build the physical address
+//	STEP 4: Miss → start the page walker and retry each cycle until the
+//	        walk has filled the TLB (or failed)
+//
+// THE WALKER (tick): One walk at a time, shared by both TLBs. Each PTE
+//
+//	is read through the L1D like a load: a hit costs L1Latency, a miss
+//	installs the line and costs DRAMLatency. Page tables therefore
+//	compete with data for the cache, and a hot page table stays cheap.
+//	A failed walk is remembered for its page, and the next access to
+//	that page takes the fault.
+//
+// FAULTS: A faulting access does not touch memory. The instruction is
+//
+//	marked with the cause and completes; the trap is taken when it
+//	reaches commit (see trap.go), so a fault on a wrong path is
+//	squashed like any other wrong-path work.
+//
+// SIMPLIFICATIONS:
+//
+//	The caches are physically addressed and the TLB lookup is free
+//	(real designs overlap it with the L1 index). There are no accessed
+//	or dirty bits and no address-space IDs: software flushes the TLBs
+//	(tlbflush, or any write to CSRPtbr) after changing a mapping.
+//
+// MINECRAFT ANALOGY: Nether portals. The coordinates you walk to are not
+//
+//	the coordinates you arrive at. The TLB is the list of portals you
+//	remember; the walker is looking the link up in the atlas.
+
+// Page geometry
+const (
+	PageShift     = 12
+	PageSize      = 1 << PageShift // 4KB
+	MegaPageShift = 22
+	MegaPageSize  = 1 << MegaPageShift // 4MB (a leaf in the root table)
+	PTEsPerTable  = 1024
+)
+
+// Page table entry bits
+const (
+	PTEValid = 1 << 0 // Entry in use
+	PTERead  = 1 << 1 // Loads allowed
+	PTEWrite = 1 << 2 // Stores allowed
+	PTEExec  = 1 << 3 // Instruction fetch allowed
+	PTEUser  = 1 << 4 // User mode allowed
+
+	pteLeafBits = PTERead | PTEWrite | PTEExec
+	pteFlagBits = pteLeafBits | PTEUser
+)
+
+// PTBREnable turns translation on (the rest of CSRPtbr is the root PPN)
+const PTBREnable = 1 << 31
+
+// TLB sizes (fully associative, LRU replacement)
+const (
+	ITLBEntries = 16
+	DTLBEntries = 32
+)
+
+// accessKind says which permission an access needs
+type accessKind uint8
+
+const (
+	accessFetch accessKind = iota // Needs PTEExec, uses the ITLB
+	accessLoad                    // Needs PTERead, uses the DTLB
+	accessStore                   // Needs PTEWrite, uses the DTLB
+)
+
+// tlbEntry caches one translation
+type tlbEntry struct {
+	valid   bool
+	mega    bool   // 4MB megapage (vpn/ppn count 4MB units)
+	vpn     uint32 // Virtual page number
+	ppn     uint32 // Physical page number (4KB units, as in the PTE)
+	flags   uint32 // PTE permission bits
+	lastUse uint64 // For LRU
+}
+
+// physical returns the physical address of va through this entry
+func (e *tlbEntry) physical(va uint32) uint32 {
+	if e.mega {
+		return e.ppn<<PageShift | va&(MegaPageSize-1)
+	}
+	return e.ppn<<PageShift | va&(PageSize-1)
+}
+
+// TLB is a fully associative translation cache
+type TLB struct {
+	entries []tlbEntry
+	clock   uint64 // Lookup counter, for LRU
+
+	// Statistics
+	hits    uint64 // Lookups that found a translation
+	misses  uint64 // Lookups that started a walk (retries are not counted)
+	flushes uint64
+}
+
+// newTLB creates an empty TLB with n entries
+func newTLB(n int) *TLB {
+	return &TLB{entries: make([]tlbEntry, n)}
+}
+
+// probe finds the entry translating va (no LRU or statistics update)
+func (t *TLB) probe(va uint32) *tlbEntry {
+	for i := range t.entries {
+		e := &t.entries[i]
+		if !e.valid {
+			continue
+		}
+		if e.mega && e.vpn == va>>MegaPageShift || !e.mega && e.vpn == va>>PageShift {
+			return e
+		}
+	}
+	return nil
+}
+
+// lookup finds the entry translating va and marks it recently used
+func (t *TLB) lookup(va uint32) *tlbEntry {
+	e := t.probe(va)
+	if e != nil {
+		t.clock++
+		e.lastUse = t.clock
+		t.hits++
+	}
+	return e
+}
+
+// insert adds a translation, replacing an invalid or the LRU entry
+func (t *TLB) insert(e tlbEntry) {
+	victim := &t.entries[0]
+	for i := range t.entries {
+		cand := &t.entries[i]
+		if !cand.valid {
+			victim = cand
+			break
+		}
+		if cand.lastUse < victim.lastUse {
+			victim = cand
+		}
+	}
+	t.clock++
+	e.valid = true
+	e.lastUse = t.clock
+	*victim = e
+}
+
+// flush drops every entry
+func (t *TLB) flush() {
+	clear(t.entries)
+	t.flushes++
+}
+
+// validEntries counts entries in use
+func (t *TLB) validEntries() int {
+	n := 0
+	for i := range t.entries {
+		if t.entries[i].valid {
+			n++
+		}
+	}
+	return n
+}
+
+// pageWalk is the state of the hardware page-table walker
+type pageWalk struct {
+	active    bool
+	va        uint32
+	fetch     bool   // Fills the ITLB (else the DTLB)
+	level     int    // 1 = root table, 0 = leaf table
+	table     uint32 // Physical address of the table being read
+	cyclesRem int
+	waitDRAM  bool // The PTE's line missed the L1D
+}
+
+// MMU translates virtual addresses for fetch and the LSUs
+type MMU struct {
+	ptbr   uint32    // Page table base register (CSRPtbr)
+	itlb   *TLB      // In front of the L1I
+	dtlb   *TLB      // In front of the L1D
+	dcache *L1DCache // The walker reads PTEs through it
+
+	walk pageWalk
+
+	// The last failed walk: the next access to this page faults
+	faultValid bool
+	faultPage  uint32
+
+	// Statistics
+	walks      uint64 // Walks started
+	walkCycles uint64 // Cycles the walker was busy
+	pteReads   uint64 // PTEs read
+	pteMisses  uint64 // PTE reads that missed the L1D
+	pageFaults uint64 // Accesses that faulted
+}
+
+// NewMMU creates a disabled MMU whose walker reads through dcache
+func NewMMU(dcache *L1DCache) *MMU {
+	return &MMU{
+		itlb:   newTLB(ITLBEntries),
+		dtlb:   newTLB(DTLBEntries),
+		dcache: dcache,
+	}
+}
+
+// Enabled reports whether addresses are being translated
+func (m *MMU) Enabled() bool {
+	return m.ptbr&PTBREnable != 0
+}
+
+// root returns the physical address of the root page table
+func (m *MMU) root() uint32 {
+	return (m.ptbr & 0xFFFFF) << PageShift
+}
+
+// setPTBR installs a new page table (and forgets the old translations)
+func (m *MMU) setPTBR(v uint32) {
+	m.ptbr = v
+	m.Flush()
+}
+
+// Flush drops every cached translation and abandons a walk in progress
+func (m *MMU) Flush() {
+	m.itlb.flush()
+	m.dtlb.flush()
+	m.walk.active = false
+	m.faultValid = false
+}
+
+// translate maps va for one access (ALGORITHM above)
+//
+// RETURNS:
+//
+//	ok = false:           TLB miss, try again next cycle
+//	cause != CauseNone:   the access faults
+//	otherwise:            pa is the physical address
+func (m *MMU) translate(va uint32, kind accessKind, priv uint8) (pa uint32, cause uint8, ok bool) {
+	// STEP 1: MMU off
+	if !m.Enabled() {
+		if priv == PrivUser {
+			m.pageFaults++
+			return 0, faultCause(kind), true
+		}
+		return va, CauseNone, true
+	}
+
+	// STEP 2-3: TLB hit
+	tlb := m.dtlb
+	if kind == accessFetch {
+		tlb = m.itlb
+	}
+	if e := tlb.lookup(va); e != nil {
+		if !permits(e.flags, kind, priv) {
+			m.pageFaults++
+			return 0, faultCause(kind), true
+		}
+		return e.physical(va), CauseNone, true
+	}
+
+	// STEP 4: Miss. A walk for this page already failed: fault
+	if m.faultValid && m.faultPage == va>>PageShift {
+		m.faultValid = false
+		m.pageFaults++
+		return 0, faultCause(kind), true
+	}
+	if !m.walk.active {
+		m.walk = pageWalk{
+			active:    true,
+			va:        va,
+			fetch:     kind == accessFetch,
+			level:     1,
+			table:     m.root(),
+			cyclesRem: L1Latency,
+		}
+		m.walks++
+		tlb.misses++
+	}
+	return 0, CauseNone, false
+}
+
+// probeFetch translates a fetch address from the ITLB alone (no walk,
+// no statistics), for prefetch hints
+func (m *MMU) probeFetch(va uint32, priv uint8) (pa uint32, ok bool) {
+	if !m.Enabled() {
+		return va, priv != PrivUser
+	}
+	e := m.itlb.probe(va)
+	if e == nil || !permits(e.flags, accessFetch, priv) {
+		return 0, false
+	}
+	return e.physical(va), true
+}
+
+// permits reports whether a PTE's flags allow an access
+func permits(flags uint32, kind accessKind, priv uint8) bool {
+	if priv == PrivUser && flags&PTEUser == 0 {
+		return false
+	}
+	switch kind {
+	case accessFetch:
+		return flags&PTEExec != 0
+	case accessLoad:
+		return flags&PTERead != 0
+	default:
+		return flags&PTEWrite != 0
+	}
+}
+
+// faultCause is the trap cause for a faulting access
+func faultCause(kind accessKind) uint8 {
+	switch kind {
+	case accessFetch:
+		return CauseFetchPageFault
+	case accessLoad:
+		return CauseLoadPageFault
+	default:
+		return CauseStorePageFault
+	}
+}
+
+// tick advances the page walker by one cycle
+//
+// ALGORITHM:
+//
+//	STEP 1: Count down the current PTE read
+//	STEP 2: Read the PTE through the L1D (miss → fill, wait DRAMLatency)
+//	STEP 3: Invalid PTE → remember the fault, done
+//	STEP 4: Pointer → descend to the leaf table
+//	STEP 5: Leaf → fill the TLB, done
+func (m *MMU) tick() {
+	w := &m.walk
+	if !w.active {
+		return
+	}
+	m.walkCycles++
+
+	// STEP 1: Count down
+	w.cyclesRem--
+	if w.cyclesRem > 0 {
+		return
+	}
+
+	// STEP 2: Read the PTE
+	index := w.va >> MegaPageShift
+	if w.level == 0 {
+		index = w.va >> PageShift & (PTEsPerTable - 1)
+	}
+	pteAddr := w.table + 4*index
+	if uint64(pteAddr)+4 > uint64(len(m.dcache.memory)) {
+		m.walkFailed()
+		return
+	}
+	if w.waitDRAM {
+		m.dcache.fetchLine(pteAddr)
+		w.waitDRAM = false
+	}
+	pte, hit := m.dcache.peekSize(pteAddr, 4)
+	if !hit {
+		w.cyclesRem = DRAMLatency
+		w.waitDRAM = true
+		m.pteMisses++
+		return
+	}
+	m.pteReads++
+
+	ppn := pte >> PageShift
+	switch {
+	case pte&PTEValid == 0 || pte&(PTERead|PTEWrite) == PTEWrite:
+		// STEP 3: Not mapped (or reserved encoding)
+		m.walkFailed()
+
+	case pte&pteLeafBits == 0:
+		// STEP 4: Pointer to the leaf table
+		if w.level == 0 {
+			m.walkFailed()
+			return
+		}
+		w.level = 0
+		w.table = ppn << PageShift
+		w.cyclesRem = L1Latency
+
+	default:
+		// STEP 5: Leaf
+		e := tlbEntry{vpn: w.va >> PageShift, ppn: ppn, flags: pte & pteFlagBits}
+		if w.level == 1 {
+			if ppn&(PTEsPerTable-1) != 0 {
+				m.walkFailed() // Misaligned megapage
+				return
+			}
+			e.mega = true
+			e.vpn = w.va >> MegaPageShift
+		}
+		if w.fetch {
+			m.itlb.insert(e)
+		} else {
+			m.dtlb.insert(e)
+		}
+		w.active = false
+	}
+}
+
+// walkFailed ends the walk and remembers the page as unmapped
+func (m *MMU) walkFailed() {
+	m.faultValid = true
+	m.faultPage = m.walk.va >> PageShift
+	m.walk.active = false
+}
+
+// Translate maps a virtual address through the current page table
+//
+// This is a functional walk for tools and system calls: it reads the
+// PTEs as the program sees memory (Core.ReadMem) and leaves the TLBs,
+// the caches and the statistics alone. With the MMU off the address is
+// returned unchanged.
+func (c *Core) Translate(va uint32) (pa uint32, flags uint32, ok bool) {
+	m := c.mmu
+	if !m.Enabled() {
+		return va, pteFlagBits, true
+	}
+
+	table := m.root()
+	for level := 1; level >= 0; level-- {
+		index := va >> MegaPageShift
+		if level == 0 {
+			index = va >> PageShift & (PTEsPerTable - 1)
+		}
+		pteAddr := table + 4*index
+		if uint64(pteAddr)+4 > uint64(len(c.memory)) {
+			return 0, 0, false
+		}
+		b := c.ReadMem(pteAddr, 4)
+		pte := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+		ppn := pte >> PageShift
+
+		switch {
+		case pte&PTEValid == 0 || pte&(PTERead|PTEWrite) == PTEWrite:
+			return 0, 0, false
+		case pte&pteLeafBits == 0:
+			table = ppn << PageShift
+		case level == 1:
+			if ppn&(PTEsPerTable-1) != 0 {
+				return 0, 0, false
+			}
+			return ppn<<PageShift | va&(MegaPageSize-1), pte & pteFlagBits, true
+		default:
+			return ppn<<PageShift | va&(PageSize-1), pte & pteFlagBits, true
+		}
+	}
+	return 0, 0, false
+}
+
+// readVirtual reads n bytes at a virtual address the program may load
+// from (ok = false if any page is unmapped or not readable)
+func (c *Core) readVirtual(va uint32, n int) ([]byte, bool) {
+	data := make([]byte, 0, n)
+	for n > 0 {
+		pa, flags, ok := c.Translate(va)
+		if !ok || flags&PTERead == 0 {
+			return nil, false
+		}
+		chunk := min(n, PageSize-int(va&(PageSize-1)))
+		data = append(data, c.ReadMem(pa, chunk)...)
+		va += uint32(chunk)
+		n -= chunk
+	}
+	return data, true
+}
+
+// physRange is the physical part of a virtual range that lies in one page
+type physRange struct {
+	pa uint32
+	n  int
+}
+
+// translateRange splits [va, va+n) into its physical pieces, one per page,
+// for the debugger (ok = false if any page is unmapped)
+//
+// Unlike readVirtual the permission bits are not checked: a debugger reads
+// execute-only code and patches read-only pages. With the MMU off the
+// pieces are the range itself.
+func (c *Core) translateRange(va uint32, n int) ([]physRange, bool) {
+	var pieces []physRange
+	for n > 0 {
+		pa, _, ok := c.Translate(va)
+		if !ok {
+			return nil, false
+		}
+		chunk := min(n, PageSize-int(va&(PageSize-1)))
+		pieces = append(pieces, physRange{pa: pa, n: chunk})
+		va += uint32(chunk)
+		n -= chunk
+	}
+	return pieces, true
+}
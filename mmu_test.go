@@ -0,0 +1,400 @@
+package suprax32
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Virtual Memory - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Translation is invisible when it works, so every test makes it visible: data lands at a
+// physical address other than the one the program used, a fault reaches a handler with the
+// right epc/cause/badaddr, or a retried instruction sees the mapping the handler installed.
+// The page tables are built by the test (like a boot loader would) and switched on by the
+// program itself with csrw, so the CSR path is exercised too.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. TRANSLATION TESTS
+//    Identity-mapped C program, remapped pages, system calls through the page table
+//
+// 2. TRAP TESTS
+//    Precise page faults with retry, fault causes, stores that fault leave memory alone
+//
+// 3. ENCODING TESTS
+//    Assembler and disassembler for the system functions
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// Page tables live high in the 1MB test memory, below the initial stack
+const (
+	testPTRoot = 0xC0000
+	testPTLeaf = 0xC1000 // Leaf tables are allocated from here up
+	testPTBR   = PTBREnable | testPTRoot>>PageShift
+)
+
+// pageTables builds a two-level page table directly in memory
+type pageTables struct {
+	core *Core
+	next uint32 // Next free leaf table
+}
+
+func newPageTables(c *Core) *pageTables {
+	return &pageTables{core: c, next: testPTLeaf}
+}
+
+// mapPage maps one 4KB page, creating its leaf table on first use
+func (pt *pageTables) mapPage(va, pa, flags uint32) {
+	rootPTE := testPTRoot + 4*(va>>MegaPageShift)
+	leaf := pt.core.ReadMemWord(rootPTE) &^ (PageSize - 1)
+	if pt.core.ReadMemWord(rootPTE)&PTEValid == 0 {
+		leaf = pt.next
+		pt.next += PageSize
+		pt.core.WriteMemWord(rootPTE, leaf|PTEValid)
+	}
+	pt.core.WriteMemWord(leaf+4*(va>>PageShift&(PTEsPerTable-1)), pa&^(PageSize-1)|flags|PTEValid)
+}
+
+// identity maps [start, end) to itself with 4KB pages
+func (pt *pageTables) identity(start, end, flags uint32) {
+	for va := start &^ (PageSize - 1); va < end; va += PageSize {
+		pt.mapPage(va, va, flags)
+	}
+}
+
+// runAsm assembles, links and loads a program, builds page tables with setup, and runs it
+func runAsm(t *testing.T, src string, setup func(c *Core, pt *pageTables, img *Image)) (*Core, string) {
+	t.Helper()
+	obj, err := Assemble("test.s", src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	img, err := Link([]*Object{obj}, nil)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	pt := newPageTables(core)
+	setup(core, pt, img)
+	core.Run(200_000)
+	if _, ok := core.Exited(); !ok {
+		t.Fatalf("program did not exit (pc 0x%x, cause %d, epc 0x%x)", core.pc, core.csr.cause, core.csr.epc)
+	}
+	return core, console.String()
+}
+
+// symbol returns the address of a symbol of the loaded program
+func symbol(t *testing.T, c *Core, name string) uint32 {
+	t.Helper()
+	addr, ok := c.Symbols().Addr(name)
+	if !ok {
+		t.Fatalf("no symbol %s", name)
+	}
+	return addr
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. TRANSLATION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMMU_IdentityMappedC(t *testing.T) {
+	// WHAT: A C program (crt0, printf, stack) runs unchanged with every page identity-mapped
+	// WHY: Turning translation on must not change what a program computes
+	// HARDWARE: Every fetch goes through the ITLB, every load/store through the DTLB
+	// CATEGORY: [INTEGRATION]
+
+	paging, err := Assemble("paging.s", `
+	.globl paging_on
+paging_on:
+	csrw  ptbr, a0
+	ret
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := CompileObject("test.c", `
+#include <stdio.h>
+void paging_on(unsigned ptbr);
+int table[300];
+int main() {
+	paging_on(`+fmt.Sprintf("0x%x", testPTBR)+`);
+	int sum = 0;
+	for (int i = 0; i < 300; i++) table[i] = i * 3;
+	for (int i = 0; i < 300; i++) sum += table[i];
+	printf("sum=%d\n", sum);
+	return 0;
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := LinkProgram([]*Object{paging, prog}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatal(err)
+	}
+	pt := newPageTables(core)
+	pt.identity(0, testPTRoot, PTERead|PTEWrite|PTEExec)
+	pt.identity(0xF0000, 1<<20, PTERead|PTEWrite) // Stack
+	core.Run(2_000_000)
+
+	if _, ok := core.Exited(); !ok || console.String() != "sum=134550\n" {
+		t.Fatalf("exited=%v output %q", ok, console.String())
+	}
+	s := core.Snapshot().MMU
+	if !s.Enabled || s.Walks == 0 || s.ITLB.Hits == 0 || s.DTLB.Hits == 0 || s.PTEReads < s.Walks {
+		t.Errorf("implausible MMU stats: %+v", s)
+	}
+	if core.traps != 0 {
+		t.Errorf("%d traps in a fully mapped program", core.traps)
+	}
+	if s.ITLB.Misses+s.DTLB.Misses != s.Walks {
+		t.Errorf("walks %d != ITLB misses %d + DTLB misses %d", s.Walks, s.ITLB.Misses, s.DTLB.Misses)
+	}
+}
+
+func TestMMU_RemappedPages(t *testing.T) {
+	// WHAT: Loads, stores and SysWrite at a virtual address reach a different physical page
+	// WHY: Relocation is the point of translation; system calls must see the program's view
+	// HARDWARE: DTLB fill from a leaf table, Core.Translate for the system call buffer
+	// CATEGORY: [INTEGRATION]
+
+	core, out := runAsm(t, `
+	.text
+_start:
+	li    t0, `+fmt.Sprint(testPTBR)+`
+	csrw  ptbr, t0
+	li    t1, 0x400000        # Virtual page → physical 0x20000
+	lw    t2, 0(t1)           # 'hi!\n' placed there by the test
+	li    t3, 0x12345678
+	sw    t3, 16(t1)
+	sw    t2, 20(t1)
+	li    a0, 1
+	mv    a1, t1
+	li    a2, 4
+	system 1
+	li    a0, 0
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {
+		pt.identity(0x1000, 0x2000, PTERead|PTEExec)
+		pt.mapPage(0x400000, 0x20000, PTERead|PTEWrite)
+		copy(c.memory[0x20000:], "hi!\n")
+	})
+
+	if out != "hi!\n" {
+		t.Errorf("SysWrite through the page table printed %q", out)
+	}
+	if got := c32(core.ReadMem(0x20010, 4)); got != 0x12345678 {
+		t.Errorf("physical 0x20010 = 0x%x, expected the store", got)
+	}
+	if got := c32(core.ReadMem(0x20014, 4)); got != c32([]byte("hi!\n")) {
+		t.Errorf("physical 0x20014 = 0x%x, expected the loaded word", got)
+	}
+	if pa, _, ok := core.Translate(0x400abc); !ok || pa != 0x20abc {
+		t.Errorf("Translate(0x400abc) = 0x%x, %v", pa, ok)
+	}
+	if _, _, ok := core.Translate(0x401000); ok {
+		t.Error("Translate of an unmapped page succeeded")
+	}
+}
+
+// c32 reads a little-endian word
+func c32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. TRAP TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMMU_PageFaultIsPreciseAndRetried(t *testing.T) {
+	// WHAT: A load from an unmapped page traps with epc/cause/badaddr set; the handler maps
+	//       the page and erets; the load is retried and succeeds
+	// WHY: Demand paging needs the fault to be restartable
+	// HARDWARE: Fault recorded at issue, trap taken at commit, walker re-reads the PTE the
+	//           handler stored (through the L1D)
+	// CATEGORY: [INTEGRATION]
+
+	core, _ := runAsm(t, `
+	.text
+	.globl faulting, record
+_start:
+	la    t0, handler
+	csrw  tvec, t0
+	li    t0, `+fmt.Sprint(testPTBR)+`
+	csrw  ptbr, t0
+	li    s0, 1
+	li    t1, 0x400000
+faulting:
+	lw    a0, 8(t1)           # Unmapped until the handler runs
+	li    s0, 2               # Younger: must not have committed when the handler runs
+	addi  a0, a0, 1
+	system 0
+
+handler:
+	la    t4, record
+	csrr  t5, epc
+	sw    t5, 0(t4)
+	csrr  t5, cause
+	sw    t5, 4(t4)
+	csrr  t5, badaddr
+	sw    t5, 8(t4)
+	sw    s0, 12(t4)
+	lw    t5, 16(t4)          # Trap count
+	addi  t5, t5, 1
+	sw    t5, 16(t4)
+	li    t5, `+fmt.Sprint(0xC2000+4*0)+`  # Leaf table entry for 0x400000
+	li    t6, `+fmt.Sprint(0x30000|PTERead|PTEValid)+`
+	sw    t6, 0(t5)
+	tlbflush
+	eret
+
+	.data
+record:
+	.word 0, 0, 0, 0, 0
+`, func(c *Core, pt *pageTables, img *Image) {
+		pt.identity(0x1000, 0x3000, PTERead|PTEWrite|PTEExec)
+		pt.identity(testPTRoot, testPTLeaf+3*PageSize, PTERead|PTEWrite) // Tables (0xC2000 is 0x400000's)
+		pt.mapPage(0x7FF000, 0x31000, PTERead)                           // Creates leaf table 0xC2000
+		c.WriteMemWord(0x30008, 41)
+	})
+
+	record := symbol(t, core, "record")
+	faulting := symbol(t, core, "faulting")
+	got := core.ReadMem(record, 20)
+	if epc := c32(got[0:]); epc != faulting {
+		t.Errorf("epc = 0x%x, expected the faulting load at 0x%x", epc, faulting)
+	}
+	if cause := c32(got[4:]); cause != CauseLoadPageFault {
+		t.Errorf("cause = %d, expected %d", cause, CauseLoadPageFault)
+	}
+	if bad := c32(got[8:]); bad != 0x400008 {
+		t.Errorf("badaddr = 0x%x, expected 0x400008", bad)
+	}
+	if s0 := c32(got[12:]); s0 != 1 {
+		t.Errorf("s0 = %d in the handler: a younger instruction committed before the trap", s0)
+	}
+	if n := c32(got[16:]); n != 1 {
+		t.Errorf("%d traps, expected 1", n)
+	}
+	if status, _ := core.Exited(); status != 42 {
+		t.Errorf("exit status %d, expected the retried load + 1 = 42", status)
+	}
+}
+
+func TestMMU_FaultCauses(t *testing.T) {
+	// WHAT: Fetch, load and store faults report their own cause and address; a faulting
+	//       store leaves memory untouched
+	// WHY: The handler decides what to do from cause and badaddr
+	// HARDWARE: Permission checks on TLB hits, unmapped pages on walks, fetch faults carried
+	//           down the pipe as trapping nops
+	// CATEGORY: [UNIT]
+
+	cases := []struct {
+		name  string
+		code  string
+		cause uint32
+		bad   uint32
+	}{
+		{"load unmapped", "lw a1, 4(t1)", CauseLoadPageFault, 0x500004},
+		{"store read-only", "sw t1, 12(t2)", CauseStorePageFault, 0x600000 + 12},
+		{"fetch unmapped", "jalr r0, 0(t1)", CauseFetchPageFault, 0x500000},
+		{"fetch no-exec", "jalr r0, 0(t2)", CauseFetchPageFault, 0x600000},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, _ := runAsm(t, `
+	.text
+	.globl faulting
+_start:
+	la    t0, handler
+	csrw  tvec, t0
+	li    t0, `+fmt.Sprint(testPTBR)+`
+	csrw  ptbr, t0
+	li    t1, 0x500000        # Unmapped
+	li    t2, 0x600000        # Read-only
+faulting:
+	`+tc.code+`
+	li    a0, 99
+	system 0
+
+handler:
+	csrr  a0, cause
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {
+				pt.identity(0x1000, 0x2000, PTERead|PTEExec)
+				pt.mapPage(0x600000, 0x40000, PTERead)
+				c.WriteMemWord(0x4000c, 0xdeadbeef)
+			})
+
+			if status, _ := core.Exited(); status != tc.cause {
+				t.Errorf("exit status (cause) %d, expected %d", status, tc.cause)
+			}
+			if core.csr.badAddr != tc.bad {
+				t.Errorf("badaddr 0x%x, expected 0x%x", core.csr.badAddr, tc.bad)
+			}
+			wantEPC := symbol(t, core, "faulting")
+			if tc.cause == CauseFetchPageFault {
+				wantEPC = tc.bad
+			}
+			if core.csr.epc != wantEPC {
+				t.Errorf("epc 0x%x, expected 0x%x", core.csr.epc, wantEPC)
+			}
+			if got := c32(core.ReadMem(0x4000c, 4)); got != 0xdeadbeef {
+				t.Errorf("read-only page written: 0x%x", got)
+			}
+		})
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. ENCODING TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestMMU_SystemFunctionsRoundTrip(t *testing.T) {
+	// WHAT: csrr/csrw/eret/tlbflush/system disassemble to what was assembled
+	// WHY: Traces and the debugger show these instructions
+	// CATEGORY: [UNIT]
+
+	lines := []string{"system 1", "csrr   r4, epc", "csrw   ptbr, r5", "eret", "tlbflush", "csrr   r6, 0x7c0"}
+	src := ""
+	for _, l := range lines {
+		src += l + "\n"
+	}
+	obj, err := Assemble("t.s", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range lines {
+		word := c32(obj.Text[4*i:])
+		if got := DecodeInstruction(word, 0).String(); got != want {
+			t.Errorf("%q disassembles as %q", want, got)
+		}
+	}
+
+	for _, bad := range []string{"system 4096", "system -1", "csrr r1, nosuch", "csrw 0x1000, r1"} {
+		if _, err := Assemble("t.s", bad); err == nil {
+			t.Errorf("%q assembled", bad)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package suprax32
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// RELOCATABLE OBJECT FORMAT (.sxo)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE PROBLEM: Hand-assembled programs hard-code every address.
+//
+//	A branch offset, a JAL target or a LUI/ORI address pair is only
+//	known once everything has been placed in memory. Two separately
+//	written pieces of code cannot call each other.
+//
+// THE SOLUTION: Emit code at section offsets and leave holes.
+//
+//	Each object carries three sections (text, data, bss), a symbol
+//	table naming offsets within them, and relocations saying "patch
+//	this instruction once symbol X has an address". The linker
+//	(link.go) places the sections and fills the holes.
+//
+// RELOCATION TYPES:
+//
+//	RelocBranch17: B-format imm[16:0] = S + A - P   (BEQ/BNE/BLT/BGE)
+//	RelocJAL17:    I-format imm[16:0] = S + A - P   (JAL)
+//	RelocHi17:     LUI      imm[16:0] = (S + A) >> 15
+//	RelocLo15:     ORI      imm[16:0] = (S + A) & 0x7FFF
+//	RelocAbs32:    data word          = S + A       (pointers, jump tables)
+//
+//	S = symbol address, A = addend, P = address of the patched word
+//
+// WHY HI17/LO15: LUI shifts its 17-bit immediate left by 15, so the
+//
+//	pair covers all 32 bits. The low part is at most 0x7FFF, which
+//	keeps bit 16 of the ORI immediate clear (no sign extension).
+//
+// FILE LAYOUT (little-endian, strings are u16 length + bytes):
+//
+//	"SXO1"
+//	name
+//	text: u32 length, bytes        data: u32 length, bytes
+//	bss:  u32 size
+//	u32 symbol count, then per symbol:    name, u8 section, u8 global, u32 value
+//	u32 relocation count, then per reloc: u8 section, u8 type, u32 offset,
+//	                                      i32 addend, symbol name
+//
+// MINECRAFT ANALOGY: A schematic with "chest goes here" markers.
+//
+//	The builder decides where the schematic is pasted, then fills in
+//	the markers with real coordinates.
+
+// SectionKind identifies one of an object's sections
+type SectionKind uint8
+
+const (
+	SecText SectionKind = iota // Instructions
+	SecData                    // Initialised data
+	SecBSS                     // Zero-initialised data (size only)
+	NumSections
+	SecUndef SectionKind = 0xFF // Symbol referenced but not defined here
+)
+
+var sectionNames = [NumSections]string{".text", ".data", ".bss"}
+
+// String returns the section name (".text", ".data", ".bss")
+func (k SectionKind) String() string {
+	if k >= NumSections {
+		return "*UND*"
+	}
+	return sectionNames[k]
+}
+
+// RelocType selects how a relocation patches its word
+type RelocType uint8
+
+const (
+	RelocBranch17 RelocType = iota // B-format PC-relative offset
+	RelocJAL17                     // JAL PC-relative offset
+	RelocHi17                      // LUI upper 17 bits of an address
+	RelocLo15                      // ORI lower 15 bits of an address
+	RelocAbs32                     // Whole 32-bit address in data
+	numRelocTypes
+)
+
+var relocTypeNames = [numRelocTypes]string{"branch17", "jal17", "hi17", "lo15", "abs32"}
+
+// String returns the short name of a relocation type
+func (t RelocType) String() string {
+	if t >= numRelocTypes {
+		return "unknown"
+	}
+	return relocTypeNames[t]
+}
+
+// Symbol names an offset within a section
+type Symbol struct {
+	Name    string
+	Section SectionKind // SecUndef for external references
+	Value   uint32      // Offset within the section
+	Global  bool        // Visible to other objects
+}
+
+// Reloc asks the linker to patch the word at Offset in Section
+type Reloc struct {
+	Section SectionKind // Section holding the word (text or data)
+	Offset  uint32      // Byte offset of the word in that section
+	Type    RelocType
+	Symbol  string // Symbol whose address is S
+	Addend  int32  // A
+}
+
+// Object is one separately compiled/assembled unit
+type Object struct {
+	Name    string
+	Text    []byte // Instruction words, little-endian
+	Data    []byte
+	BSSSize uint32
+	Symbols []Symbol
+	Relocs  []Reloc
+}
+
+// NewObject creates an empty object
+func NewObject(name string) *Object {
+	return &Object{Name: name}
+}
+
+// Lookup returns the symbol with the given name
+func (o *Object) Lookup(name string) (*Symbol, bool) {
+	for i := range o.Symbols {
+		if o.Symbols[i].Name == name {
+			return &o.Symbols[i], true
+		}
+	}
+	return nil, false
+}
+
+// Define adds a symbol at an offset in a section
+//
+// A forward reference (added as undefined by a relocation helper) is
+// turned into a definition; defining the same name twice is an error.
+func (o *Object) Define(name string, sec SectionKind, value uint32, global bool) error {
+	if sym, ok := o.Lookup(name); ok {
+		if sym.Section != SecUndef {
+			return fmt.Errorf("%s: symbol %q defined twice", o.Name, name)
+		}
+		sym.Section, sym.Value, sym.Global = sec, value, global
+		return nil
+	}
+	o.Symbols = append(o.Symbols, Symbol{Name: name, Section: sec, Value: value, Global: global})
+	return nil
+}
+
+// Label defines a symbol at the current end of the text section
+func (o *Object) Label(name string, global bool) error {
+	return o.Define(name, SecText, uint32(len(o.Text)), global)
+}
+
+// Emit appends one instruction word and returns its text offset
+func (o *Object) Emit(word uint32) uint32 {
+	off := uint32(len(o.Text))
+	o.Text = binary.LittleEndian.AppendUint32(o.Text, word)
+	return off
+}
+
+// EmitBranch emits a conditional branch to a symbol
+func (o *Object) EmitBranch(opcode, rs1, rs2 uint8, target string) {
+	off := o.Emit(EncodeBFormat(opcode, rs1, rs2, 0))
+	o.reloc(SecText, off, RelocBranch17, target, 0)
+}
+
+// EmitJAL emits a jump-and-link to a symbol
+func (o *Object) EmitJAL(rd uint8, target string) {
+	off := o.Emit(EncodeIFormat(OpJAL, rd, 0, 0))
+	o.reloc(SecText, off, RelocJAL17, target, 0)
+}
+
+// EmitLoadAddr emits "lui rd, %hi(sym+addend); ori rd, rd, %lo(sym+addend)"
+func (o *Object) EmitLoadAddr(rd uint8, sym string, addend int32) {
+	hi := o.Emit(EncodeIFormat(OpLUI, rd, 0, 0))
+	lo := o.Emit(EncodeIFormat(OpORI, rd, rd, 0))
+	o.reloc(SecText, hi, RelocHi17, sym, addend)
+	o.reloc(SecText, lo, RelocLo15, sym, addend)
+}
+
+// DataLabel defines a symbol at the current end of the data section
+func (o *Object) DataLabel(name string, global bool) error {
+	return o.Define(name, SecData, uint32(len(o.Data)), global)
+}
+
+// EmitData appends raw bytes to the data section and returns their offset
+func (o *Object) EmitData(b ...byte) uint32 {
+	off := uint32(len(o.Data))
+	o.Data = append(o.Data, b...)
+	return off
+}
+
+// EmitDataWord appends a 32-bit word to the data section
+func (o *Object) EmitDataWord(word uint32) uint32 {
+	o.alignData(4)
+	off := uint32(len(o.Data))
+	o.Data = binary.LittleEndian.AppendUint32(o.Data, word)
+	return off
+}
+
+// EmitDataAddr appends a word that the linker fills with sym+addend
+func (o *Object) EmitDataAddr(sym string, addend int32) uint32 {
+	off := o.EmitDataWord(0)
+	o.reloc(SecData, off, RelocAbs32, sym, addend)
+	return off
+}
+
+// Reserve defines a zero-initialised bss symbol of size bytes
+func (o *Object) Reserve(name string, size, align uint32, global bool) error {
+	if align > 1 {
+		o.BSSSize = alignUp(o.BSSSize, align)
+	}
+	if err := o.Define(name, SecBSS, o.BSSSize, global); err != nil {
+		return err
+	}
+	o.BSSSize += size
+	return nil
+}
+
+// alignData pads the data section with zeros to a power-of-two boundary
+func (o *Object) alignData(align uint32) {
+	for uint32(len(o.Data))&(align-1) != 0 {
+		o.Data = append(o.Data, 0)
+	}
+}
+
+// reloc records a relocation, adding the target as undefined if unseen
+func (o *Object) reloc(sec SectionKind, off uint32, typ RelocType, sym string, addend int32) {
+	if _, ok := o.Lookup(sym); !ok {
+		o.Symbols = append(o.Symbols, Symbol{Name: sym, Section: SecUndef})
+	}
+	o.Relocs = append(o.Relocs, Reloc{Section: sec, Offset: off, Type: typ, Symbol: sym, Addend: addend})
+}
+
+// section returns the bytes of a loadable section
+func (o *Object) section(k SectionKind) []byte {
+	switch k {
+	case SecText:
+		return o.Text
+	case SecData:
+		return o.Data
+	}
+	return nil
+}
+
+// sectionSize returns the in-memory size of a section
+func (o *Object) sectionSize(k SectionKind) uint32 {
+	if k == SecBSS {
+		return o.BSSSize
+	}
+	return uint32(len(o.section(k)))
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// SERIALISATION
+// ═══════════════════════════════════════════════════════════════════════════════
+
+var objectMagic = [4]byte{'S', 'X', 'O', '1'}
+
+// objWriter accumulates the first write error so callers can chain writes
+type objWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (ow *objWriter) bytes(b []byte) {
+	if ow.err != nil {
+		return
+	}
+	n, err := ow.w.Write(b)
+	ow.n += int64(n)
+	ow.err = err
+}
+
+func (ow *objWriter) u8(v uint8)   { ow.bytes([]byte{v}) }
+func (ow *objWriter) u32(v uint32) { ow.bytes(binary.LittleEndian.AppendUint32(nil, v)) }
+
+// str writes a name with a 16-bit length, refusing names that do not fit
+func (ow *objWriter) str(s string) {
+	if ow.err == nil && len(s) > math.MaxUint16 {
+		ow.err = fmt.Errorf("name %.32q... is %d bytes, more than the %d a name may hold",
+			s, len(s), math.MaxUint16)
+	}
+	ow.bytes(binary.LittleEndian.AppendUint16(nil, uint16(len(s))))
+	ow.bytes([]byte(s))
+}
+
+func (ow *objWriter) blob(b []byte) {
+	ow.u32(uint32(len(b)))
+	ow.bytes(b)
+}
+
+// WriteTo serialises the object in .sxo format
+func (o *Object) WriteTo(w io.Writer) (int64, error) {
+	ow := &objWriter{w: bufio.NewWriter(w)}
+	ow.bytes(objectMagic[:])
+	ow.str(o.Name)
+	ow.blob(o.Text)
+	ow.blob(o.Data)
+	ow.u32(o.BSSSize)
+
+	ow.u32(uint32(len(o.Symbols)))
+	for _, s := range o.Symbols {
+		ow.str(s.Name)
+		ow.u8(uint8(s.Section))
+		global := uint8(0)
+		if s.Global {
+			global = 1
+		}
+		ow.u8(global)
+		ow.u32(s.Value)
+	}
+
+	ow.u32(uint32(len(o.Relocs)))
+	for _, r := range o.Relocs {
+		ow.u8(uint8(r.Section))
+		ow.u8(uint8(r.Type))
+		ow.u32(r.Offset)
+		ow.u32(uint32(r.Addend))
+		ow.str(r.Symbol)
+	}
+
+	if ow.err == nil {
+		ow.err = ow.w.Flush()
+	}
+	return ow.n, ow.err
+}
+
+// objReader mirrors objWriter: the first error sticks
+type objReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (or *objReader) bytes(n int) []byte {
+	if or.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, or.err = io.ReadFull(or.r, b)
+	return b
+}
+
+func (or *objReader) u8() uint8 {
+	b := or.bytes(1)
+	if or.err != nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (or *objReader) u32() uint32 {
+	b := or.bytes(4)
+	if or.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (or *objReader) str() string {
+	b := or.bytes(2)
+	if or.err != nil {
+		return ""
+	}
+	return string(or.bytes(int(binary.LittleEndian.Uint16(b))))
+}
+
+// blob reads a length-prefixed byte slice
+//
+// The length comes from the file, so it is never allocated up front: the
+// slice grows with the bytes actually read, and a length larger than the
+// rest of the input fails at its end instead of allocating gigabytes.
+func (or *objReader) blob() []byte {
+	n := or.u32()
+	if or.err != nil {
+		return nil
+	}
+	if n > maxObjectSection {
+		or.err = fmt.Errorf("section of %d bytes exceeds the %d-byte limit", n, maxObjectSection)
+		return nil
+	}
+	var buf bytes.Buffer
+	got, err := io.Copy(&buf, io.LimitReader(or.r, int64(n)))
+	if err == nil && got < int64(n) {
+		err = fmt.Errorf("section of %d bytes truncated after %d: %w", n, got, io.ErrUnexpectedEOF)
+	}
+	or.err = err
+	return buf.Bytes()
+}
+
+// maxObjectSection bounds section sizes read from untrusted files (a
+// section must also fit the address space alongside the others)
+const maxObjectSection = 1 << 30
+
+// ReadObject parses an object written by Object.WriteTo
+func ReadObject(r io.Reader) (*Object, error) {
+	or := &objReader{r: bufio.NewReader(r)}
+	if magic := or.bytes(4); or.err == nil && [4]byte(magic) != objectMagic {
+		return nil, fmt.Errorf("not a SUPRAX-32 object (magic %q)", magic)
+	}
+
+	o := &Object{Name: or.str()}
+	o.Text = or.blob()
+	o.Data = or.blob()
+	o.BSSSize = or.u32()
+
+	for n := or.u32(); or.err == nil && n > 0; n-- {
+		s := Symbol{Name: or.str()}
+		s.Section = SectionKind(or.u8())
+		s.Global = or.u8() != 0
+		s.Value = or.u32()
+		o.Symbols = append(o.Symbols, s)
+	}
+
+	for n := or.u32(); or.err == nil && n > 0; n-- {
+		r := Reloc{Section: SectionKind(or.u8()), Type: RelocType(or.u8())}
+		r.Offset = or.u32()
+		r.Addend = int32(or.u32())
+		r.Symbol = or.str()
+		o.Relocs = append(o.Relocs, r)
+	}
+
+	if or.err != nil {
+		return nil, fmt.Errorf("reading object: %w", or.err)
+	}
+	return o, nil
+}
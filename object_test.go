@@ -0,0 +1,235 @@
+package suprax32
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Relocatable Objects - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// An object file is a contract between two runs of the toolchain: whatever is written must
+// read back field for field, and link to the same image as the object that was never written.
+// Files also come from outside, so every malformed input - truncated anywhere, a length that
+// lies, a bad magic - must come back as an error, never as a panic or a huge allocation.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. ROUND-TRIP TESTS
+//    Every field survives WriteTo/ReadObject; the read-back object links identically
+//
+// 2. MALFORMED INPUT TESTS
+//    Truncation, lying lengths, bad magic, names too long to write
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// testObjectSource uses every section, symbol kind and relocation type
+const testObjectSource = `
+	.text
+	.globl _start, helper
+_start:
+	la    t0, table
+	lw    a0, 0(t0)
+	beq   a0, zero, done
+	call  helper
+	call  external
+done:
+	j     done
+helper:
+	ret
+
+	.data
+	.globl table
+table:
+	.word 7, helper, counter+4
+msg:
+	.asciz "hi"
+
+	.bss
+	.align 8
+counter:
+	.space 16
+`
+
+// writeObject serialises an object, failing the test on error
+func writeObject(t *testing.T, o *Object) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := o.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+	return buf.Bytes()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. ROUND-TRIP TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestObject_RoundTrip(t *testing.T) {
+	// WHAT: An assembled object with text, data, bss, local/global/undefined symbols and
+	//       all five relocation types reads back equal to what was written
+	// WHY: A field written in one order and read in another only shows up as a wrong
+	//      address after linking
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	obj, err := Assemble("round.s", testObjectSource)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	seen := map[RelocType]bool{}
+	for _, r := range obj.Relocs {
+		seen[r.Type] = true
+	}
+	if len(seen) != int(numRelocTypes) || obj.BSSSize == 0 || len(obj.Data) == 0 {
+		t.Fatalf("test object covers relocations %v, bss %d, data %d; expected all of them", seen, obj.BSSSize, len(obj.Data))
+	}
+
+	back, err := ReadObject(bytes.NewReader(writeObject(t, obj)))
+	if err != nil {
+		t.Fatalf("ReadObject: %v", err)
+	}
+	if !reflect.DeepEqual(back, obj) {
+		t.Errorf("read back\n%+v\nexpected\n%+v", back, obj)
+	}
+
+	// Writing the read-back object gives the same bytes
+	if !bytes.Equal(writeObject(t, back), writeObject(t, obj)) {
+		t.Error("rewriting the read-back object changed its bytes")
+	}
+}
+
+func TestObject_ReadBackLinksIdentically(t *testing.T) {
+	// WHAT: Linking objects read back from .sxo gives the same image as linking the
+	//       in-memory objects
+	// WHY: Separate compilation is the point of the format
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	main, err := Assemble("main.s", testObjectSource)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	ext, err := Assemble("ext.s", "\t.text\n\t.globl external\nexternal:\n\tret\n")
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	want, err := Link([]*Object{main, ext}, nil)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+
+	var read []*Object
+	for _, o := range []*Object{main, ext} {
+		back, err := ReadObject(bytes.NewReader(writeObject(t, o)))
+		if err != nil {
+			t.Fatalf("ReadObject(%s): %v", o.Name, err)
+		}
+		read = append(read, back)
+	}
+	got, err := Link(read, nil)
+	if err != nil {
+		t.Fatalf("Link of read-back objects: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("image from read-back objects differs:\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. MALFORMED INPUT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestObject_TruncatedAnywhereIsAnError(t *testing.T) {
+	// WHAT: Every proper prefix of a valid object is rejected with an error
+	// WHY: A partly written file must not load as a shorter, wrong program
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT]
+
+	obj, err := Assemble("round.s", testObjectSource)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	data := writeObject(t, obj)
+	for n := 0; n < len(data); n++ {
+		if _, err := ReadObject(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("%d-byte prefix of a %d-byte object read without error", n, len(data))
+		}
+	}
+	if _, err := ReadObject(strings.NewReader("ELF\x7f....")); err == nil || !strings.Contains(err.Error(), "magic") {
+		t.Errorf("bad magic: %v, expected a magic error", err)
+	}
+}
+
+func TestObject_SectionLengthBoundedByInput(t *testing.T) {
+	// WHAT: A section length larger than the rest of the file fails as truncated without
+	//       allocating it; one over the limit fails with the limit in the message
+	// WHY: The length is read from the file: trusting it let a 20-byte file allocate a
+	//      gigabyte before noticing the bytes were not there
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	header := func(textLen uint32) []byte {
+		b := append([]byte(nil), objectMagic[:]...)
+		b = binary.LittleEndian.AppendUint16(b, 1)
+		b = append(b, 'x')
+		b = binary.LittleEndian.AppendUint32(b, textLen)
+		return append(b, 1, 2, 3, 4)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	_, err := ReadObject(bytes.NewReader(header(maxObjectSection)))
+	runtime.ReadMemStats(&after)
+	if err == nil || !strings.Contains(err.Error(), "truncated after 4") {
+		t.Errorf("1 GB section in a short file: %v, expected a truncation error", err)
+	}
+	if grew := after.TotalAlloc - before.TotalAlloc; grew > 1<<20 {
+		t.Errorf("reading a short file allocated %d bytes, expected the size of the input", grew)
+	}
+
+	_, err = ReadObject(bytes.NewReader(header(maxObjectSection + 1)))
+	if err == nil || !strings.Contains(err.Error(), "exceeds the 1073741824-byte limit") {
+		t.Errorf("oversized section: %v, expected the limit error", err)
+	}
+}
+
+func TestObject_WriteRejectsLongNames(t *testing.T) {
+	// WHAT: A symbol name of 65536 bytes fails to write; 65535 bytes round-trips
+	// WHY: Names carry a 16-bit length; writing a longer one wrapped the length and
+	//      produced a file that reads back as garbage
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	long := NewObject("long.s")
+	long.Label(strings.Repeat("n", 1<<16), true)
+	if _, err := long.WriteTo(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "65536 bytes") {
+		t.Errorf("65536-byte name: %v, expected an error naming the length", err)
+	}
+
+	fits := NewObject("fits.s")
+	fits.Label(strings.Repeat("n", 1<<16-1), true)
+	back, err := ReadObject(bytes.NewReader(writeObject(t, fits)))
+	if err != nil {
+		t.Fatalf("ReadObject: %v", err)
+	}
+	if len(back.Symbols) != 1 || back.Symbols[0] != fits.Symbols[0] {
+		t.Error("65535-byte name did not round-trip")
+	}
+}
@@ -0,0 +1,218 @@
+package suprax32
+
+import "testing"
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Out-of-Order Pipeline - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The pipeline's bugs live in the hand-offs: a result finished by a unit but not yet taken by
+// the complete stage, a physical register recycled while a reader still names it, work in
+// flight when the window is flushed. Each test builds the smallest program that puts one
+// hand-off under pressure and checks the architectural state that commits.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. RESULT COLLECTION TESTS
+//    A unit's finished result survives until the complete stage takes it
+//
+// 2. RENAMING TESTS
+//    Youngest mapping wins; readers of a recycled register read the committed value
+//
+// 3. SQUASH TESTS
+//    A window flush cancels work in flight in every execution unit
+//
+// 4. BRANCH PREDICTION TESTS
+//    One prediction per fetched branch; calls and returns paired through the RSB
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runProgram runs a program at 0x1000 for the given cycles and returns the committed registers
+func runProgram(t *testing.T, program []uint32, cycles uint64) (*Core, [NumArchRegs]uint32) {
+	t.Helper()
+	core := NewCore(1 << 20)
+	core.LoadProgram(program, 0x1000)
+	core.Run(cycles)
+	return core, NewDebugger(core).Registers()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. RESULT COLLECTION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPipeline_BackToBackDividesAllCommit(t *testing.T) {
+	// WHAT: Three dependent-free divides queued behind one divider all commit
+	// WHY: The execute stage runs before issue, so a division that finishes in a cycle
+	//      still holds its result when issue looks at the divider; starting the next
+	//      one then overwrote it and the first divide never completed
+	// HARDWARE: Divider Done flag gates issue (INNOVATION #58)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	core, regs := runProgram(t, []uint32{
+		EncodeIFormat(OpADDI, 1, 0, 1000),
+		EncodeIFormat(OpADDI, 2, 0, 7),
+		EncodeIFormat(OpADDI, 3, 0, 13),
+		EncodeRFormat(OpDIV, 4, 1, 2),
+		EncodeRFormat(OpDIV, 5, 1, 3),
+		EncodeRFormat(OpREM, 6, 1, 2),
+		EncodeIFormat(OpADDI, 7, 0, 42),
+	}, 500)
+
+	if regs[7] != 42 {
+		t.Fatalf("marker r7 = %d: a divide never completed", regs[7])
+	}
+	if n := core.Snapshot().Core.Instructions; n < 7 {
+		t.Errorf("%d instructions committed, expected 7", n)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. RENAMING TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPipeline_RATLookupReturnsYoungestMapping(t *testing.T) {
+	// WHAT: With two live mappings, Lookup returns the younger even when its physical
+	//       register number is lower, and the committed value once both are freed
+	// WHY: The free list hands out the lowest free register, so "highest bit = newest"
+	//      named the older mapping and younger readers got a stale value
+	// HARDWARE: RAT youngest-mapping register per architectural register (INNOVATION #37)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	rat := NewRAT()
+	rat.Allocate(5, 35)
+	rat.Allocate(5, 33)
+	if got := rat.Lookup(5); got != 33 {
+		t.Fatalf("Lookup(r5) = p%d, expected the younger p33", got)
+	}
+
+	rat.Free(5, 35) // Older writer commits: the younger mapping still stands
+	if got := rat.Lookup(5); got != 33 {
+		t.Errorf("after the older writer commits: p%d, expected p33", got)
+	}
+	rat.Free(5, 33)
+	if got := rat.Lookup(5); got != InvalidTag {
+		t.Errorf("after both commit: p%d, expected the committed value (InvalidTag)", got)
+	}
+}
+
+func TestPipeline_ReaderOfRecycledRegisterReadsCommittedValue(t *testing.T) {
+	// WHAT: A reader still waiting when its producer commits reads the committed value,
+	//       even after the producer's physical register is reallocated and rewritten
+	// WHY: Commit frees the physical register; the next dispatch can take it, and the
+	//      waiting reader then read the new owner's result
+	// HARDWARE: Commit retargets waiting readers to the architectural register file
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	w := NewWindow()
+	dispatch := func(word uint32) int {
+		id, ok := w.Dispatch(DecodeInstruction(word, 0x1000))
+		if !ok {
+			t.Fatal("dispatch failed")
+		}
+		return id
+	}
+
+	producer := dispatch(EncodeIFormat(OpADDI, 5, 0, 11)) // r5 = 11
+	reader := dispatch(EncodeRFormat(OpADD, 6, 5, 0))     // r6 = r5 (not issued)
+	w.Complete(producer, 11)
+	freed := w.GetEntry(producer).PhysRd
+	if w.Commit() == nil {
+		t.Fatal("producer did not commit")
+	}
+
+	recycled := dispatch(EncodeIFormat(OpADDI, 7, 0, 99)) // Takes the freed register
+	if w.GetEntry(recycled).PhysRd != freed {
+		t.Fatalf("free list did not reuse p%d; the test needs that reuse", freed)
+	}
+	w.Complete(recycled, 99)
+
+	r := w.GetEntry(reader)
+	if got := w.ReadReg(r.Rs1, r.PhysRs1); got != 11 {
+		t.Errorf("reader sees r5 = %d, expected the committed 11", got)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. SQUASH TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPipeline_SquashCancelsUnitsInFlight(t *testing.T) {
+	// WHAT: After a flush, the multiplier, divider and LSUs report no result, and a store
+	//       still waiting for its line never reaches the L1D
+	// WHY: A flush frees every window slot; a result still in flight landed in whatever
+	//      instruction reused its slot, and a squashed store wrote memory
+	// HARDWARE: Unit cancel lines driven by the mispredict flush (INNOVATION #48)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	c := NewCore(1 << 20)
+	c.multiplier.Issue(1, 6, 7, false)
+	c.divider.StartDivision(1000, 7, 2, false)
+	c.lsus[0].Issue(MemoryOperation{Addr: 0x8000, Data: 0xAB, Size: 1, IsStore: true, WindowID: 3})
+	c.lsus[1].Issue(MemoryOperation{Addr: 0x9000, Size: 4, WindowID: 4})
+	c.divider.Tick()
+	for _, lsu := range c.lsus {
+		lsu.Tick() // Both miss: waiting on DRAM
+	}
+
+	c.window.Flush()
+	c.squashUnits()
+
+	for i := 0; i < DRAMLatency+L1Latency+1; i++ {
+		c.divider.Tick()
+		for _, lsu := range c.lsus {
+			lsu.Tick()
+		}
+	}
+	if _, _, ok := c.multiplier.GetResult(); ok {
+		t.Error("multiplier result survived the squash")
+	}
+	if _, _, ok := c.divider.GetResult(); ok || c.divider.Busy {
+		t.Error("divider still working after the squash")
+	}
+	for i, lsu := range c.lsus {
+		if _, _, _, ok := lsu.GetResult(); ok || lsu.IsBusy() {
+			t.Errorf("LSU %d still working after the squash", i)
+		}
+	}
+	if _, _, ok := c.dcache.Probe(0x8000); ok || c.ReadMem(0x8000, 1)[0] != 0 {
+		t.Error("squashed store reached the L1D")
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 4. BRANCH PREDICTION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPipeline_ReturnsPredictedFromFetchTimeRSB(t *testing.T) {
+	// WHAT: Two calls to a leaf function return to the right place with no mispredicts,
+	//       and a plain jump (JAL r0) leaves the RSB alone
+	// WHY: The RSB was pushed when a JAL issued, after its return had already been
+	//      fetched, and every return popped twice (at fetch and again at dispatch), so
+	//      commit checked a prediction the fetched path never followed
+	// HARDWARE: Prediction at fetch only; RSB push on JAL r1 (INNOVATION #31)
+	// CATEGORY: [INTEGRATION] [REGRESSION]
+
+	core, regs := runProgram(t, []uint32{
+		EncodeIFormat(OpJAL, 0, 0, 8),  // 0x1000: jump, not a call
+		EncodeIFormat(OpADDI, 7, 0, 1), // 0x1004: skipped
+		EncodeIFormat(OpJAL, 1, 0, 16), // 0x1008: call f
+		EncodeIFormat(OpJAL, 1, 0, 12), // 0x100C: call f
+		EncodeIFormat(OpADDI, 7, 0, 42),
+		EncodeIFormat(OpJAL, 0, 0, 0),  // 0x1014: done, spin
+		EncodeIFormat(OpADDI, 5, 5, 1), // 0x1018: f
+		EncodeIFormat(OpJALR, 0, 1, 0), //          return
+	}, 500)
+
+	if regs[5] != 2 || regs[7] != 42 {
+		t.Fatalf("r5 = %d, r7 = %d, expected 2 calls and r7 = 42", regs[5], regs[7])
+	}
+	if mp := core.Snapshot().Branch.Mispredicts; mp != 0 {
+		t.Errorf("%d mispredicts, expected every jump and return predicted at fetch", mp)
+	}
+}
@@ -0,0 +1,249 @@
+package suprax32
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// PIPELINE TRACER (KONATA FORMAT)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY TRACE:
+//
+// Aggregate statistics say IPC is low; they don't say WHY. A per-instruction
+// lifecycle shows where each instruction waited: stuck in the fetch buffer
+// behind a full window, waiting in the window for an operand, sitting in an
+// LSU on a cache miss, or done but blocked behind an older instruction.
+//
+// THE STAGES WE RECORD:
+//
+//	F   Fetched, waiting in the fetch buffer        (Core fetch stage)
+//	Dp  Dispatched, waiting in the window for issue (Window.Dispatch)
+//	Ex  Issued to an execution unit                 (Window.MarkIssued)
+//	Cm  Result ready, waiting to commit in order    (Window.Complete)
+//
+// Then one of:
+//
+//	Retire  Committed in program order              (Window.Commit)
+//	Flush   Squashed by a mispredict                (Window.Flush / fetch buffer)
+//
+// THE FORMAT:
+//
+// Konata (https://github.com/shioyadan/Konata) reads a tab-separated log:
+//
+//	Kanata  0004            header
+//	C=      <cycle>         absolute start cycle
+//	C       <delta>         advance time
+//	I       <id> <seq> <tid> new instruction
+//	L       <id> <type> <text> label (0 = left pane, 1 = hover)
+//	S / E   <id> <lane> <stage> start / end a stage
+//	R       <id> <rid> <type>  retire (type 0) or flush (type 1)
+//	W       <id> <producer> 0  wakeup dependency arrow
+//
+// TIMING NOTE:
+//
+// Core.Cycle runs commit before complete, so a result produced in cycle N
+// can commit in N+1 at the earliest. We therefore start Cm on the cycle
+// AFTER completion, which also gives single-cycle ALU ops a visible Ex.
+//
+// USAGE:
+//
+//	f, _ := os.Create("run.kanata")
+//	tracer := NewPipeTracer(f)
+//	core.SetTracer(tracer)
+//	core.Run(10000)
+//	tracer.Flush()
+
+// PipeTracer records every instruction's trip through the pipeline
+type PipeTracer struct {
+	w   *bufio.Writer
+	err error // First write error (reported by Flush)
+
+	cycle     uint64 // Current simulation cycle
+	lastCycle uint64 // Cycle of the last emitted event
+	started   bool   // Has the first event (and C=) been written?
+
+	stages  map[uint64]string // In-flight instruction → current stage
+	pending []uint64          // Completed this cycle, enter Cm next cycle
+
+	// Wakeup arrows: which instruction produces each physical register
+	producer      [NumPhysRegs]uint64
+	producerValid [NumPhysRegs]bool
+
+	retired uint64 // Retire ID counter
+
+	symbols *SymbolTable // Function names for labels (nil = addresses only)
+}
+
+// NewPipeTracer creates a tracer writing Konata log lines to w
+func NewPipeTracer(w io.Writer) *PipeTracer {
+	t := &PipeTracer{
+		w:      bufio.NewWriter(w),
+		stages: make(map[uint64]string),
+	}
+	t.printf("Kanata\t0004\n")
+	return t
+}
+
+// SetTracer attaches a pipeline tracer to the core (nil turns tracing off)
+func (c *Core) SetTracer(t *PipeTracer) {
+	c.tracer = t
+	c.window.tracer = t
+	if t != nil {
+		t.symbols = c.symbols
+	}
+}
+
+// Flush writes buffered output and returns the first error seen
+func (t *PipeTracer) Flush() error {
+	if err := t.w.Flush(); err != nil && t.err == nil {
+		t.err = err
+	}
+	return t.err
+}
+
+// printf writes one raw line, remembering the first error
+func (t *PipeTracer) printf(format string, args ...interface{}) {
+	if t.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(t.w, format, args...); err != nil {
+		t.err = err
+	}
+}
+
+// event writes a line stamped with the current cycle
+//
+// Time is only advanced when something happens, so idle stretches cost
+// one "C <delta>" line instead of one per cycle.
+func (t *PipeTracer) event(format string, args ...interface{}) {
+	if !t.started {
+		t.printf("C=\t%d\n", t.cycle)
+		t.started = true
+		t.lastCycle = t.cycle
+	} else if t.cycle != t.lastCycle {
+		t.printf("C\t%d\n", t.cycle-t.lastCycle)
+		t.lastCycle = t.cycle
+	}
+	t.printf(format, args...)
+}
+
+// enter ends the instruction's current stage and starts the next one
+func (t *PipeTracer) enter(seq uint64, stage string) {
+	current, ok := t.stages[seq]
+	if !ok {
+		return // Not traced (fetched before the tracer was attached)
+	}
+	if current == stage {
+		return
+	}
+	t.event("E\t%d\t0\t%s\n", seq, current)
+	t.event("S\t%d\t0\t%s\n", seq, stage)
+	t.stages[seq] = stage
+}
+
+// finish ends the current stage and retires (kind 0) or flushes (kind 1)
+func (t *PipeTracer) finish(seq uint64, kind int) {
+	current, ok := t.stages[seq]
+	if !ok {
+		return
+	}
+	rid := seq
+	if kind == 0 {
+		rid = t.retired
+		t.retired++
+	}
+	t.event("E\t%d\t0\t%s\n", seq, current)
+	t.event("R\t%d\t%d\t%d\n", seq, rid, kind)
+	delete(t.stages, seq)
+}
+
+// Cycle advances the tracer's clock (called at the top of Core.Cycle)
+//
+// Instructions that completed last cycle move to Cm now.
+func (t *PipeTracer) Cycle(cycle uint64) {
+	t.cycle = cycle
+
+	for _, seq := range t.pending {
+		t.enter(seq, "Cm")
+	}
+	t.pending = t.pending[:0]
+}
+
+// Fetch records an instruction entering the fetch buffer
+func (t *PipeTracer) Fetch(inst Instruction) {
+	t.event("I\t%d\t%d\t0\n", inst.Seq, inst.Seq)
+	if name := t.symbols.Format(inst.PC); name != "" {
+		t.event("L\t%d\t0\t%08x <%s>: %s\n", inst.Seq, inst.PC, name, inst.Format(t.symbols))
+	} else {
+		t.event("L\t%d\t0\t%08x: %s\n", inst.Seq, inst.PC, inst.Format(t.symbols))
+	}
+	t.event("S\t%d\t0\tF\n", inst.Seq)
+	t.stages[inst.Seq] = "F"
+}
+
+// Dispatch records an instruction entering the window
+//
+// Also draws wakeup arrows from the producers of any operand that is not
+// ready yet.
+func (t *PipeTracer) Dispatch(entry *WindowEntry, windowID int) {
+	seq := entry.Seq
+	if _, ok := t.stages[seq]; !ok {
+		return
+	}
+
+	t.enter(seq, "Dp")
+	t.event("L\t%d\t1\twindow slot %d, %s <- %s, %s\n", seq, windowID,
+		physRegName(entry.PhysRd), physRegName(entry.PhysRs1), physRegName(entry.PhysRs2))
+
+	if !entry.Src1Ready && entry.PhysRs1 < NumPhysRegs && t.producerValid[entry.PhysRs1] {
+		t.event("W\t%d\t%d\t0\n", seq, t.producer[entry.PhysRs1])
+	}
+	if !entry.Src2Ready && entry.PhysRs2 < NumPhysRegs && t.producerValid[entry.PhysRs2] &&
+		entry.PhysRs2 != entry.PhysRs1 {
+		t.event("W\t%d\t%d\t0\n", seq, t.producer[entry.PhysRs2])
+	}
+
+	if entry.PhysRd < NumPhysRegs {
+		t.producer[entry.PhysRd] = seq
+		t.producerValid[entry.PhysRd] = true
+	}
+}
+
+// Issue records an instruction leaving the window for an execution unit
+func (t *PipeTracer) Issue(seq uint64) {
+	t.enter(seq, "Ex")
+}
+
+// Complete records an instruction's result becoming available
+func (t *PipeTracer) Complete(seq uint64) {
+	if _, ok := t.stages[seq]; ok {
+		t.pending = append(t.pending, seq)
+	}
+}
+
+// Retire records an instruction committing in program order
+func (t *PipeTracer) Retire(entry *WindowEntry) {
+	if _, ok := t.stages[entry.Seq]; !ok {
+		return
+	}
+	if entry.Rd != 0 && entry.ResultValid {
+		t.event("L\t%d\t1\t -> r%d = 0x%08x\n", entry.Seq, entry.Rd, entry.Result)
+	}
+	t.finish(entry.Seq, 0)
+}
+
+// Squash records an instruction discarded by a mispredict
+func (t *PipeTracer) Squash(seq uint64) {
+	t.finish(seq, 1)
+}
+
+// physRegName renders a physical register tag for labels ("-" if none)
+func physRegName(tag uint8) string {
+	if tag == InvalidTag {
+		return "-"
+	}
+	return fmt.Sprintf("p%d", tag)
+}
@@ -0,0 +1,188 @@
+package suprax32
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Pipeline Tracer - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Konata silently drops lines it does not understand, so a malformed trace shows up as a
+// blank or misleading picture rather than an error. These tests parse the log back and
+// check the structure Konata relies on: the header, one I per instruction before anything
+// else about it, balanced S/E pairs per stage, and exactly one R each - type 0 in commit
+// order for retired instructions, type 1 for flushed ones.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. KONATA FORMAT TESTS
+//    Header, instruction lifecycles, retire and flush records
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// konataInst is one instruction's record, rebuilt from a log
+type konataInst struct {
+	pc      uint32   // From the left-pane label
+	stages  []string // Stages in the order they started
+	open    string   // Stage started and not yet ended
+	retired int      // R type (-1 = none yet)
+	rid     int      // Retire ID
+}
+
+// parseKonata rebuilds every instruction from a log, failing on structural errors
+func parseKonata(t *testing.T, log string) map[int]*konataInst {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(log, "\n"), "\n")
+	if lines[0] != "Kanata\t0004" {
+		t.Fatalf("header %q, expected \"Kanata\\t0004\"", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "C=\t") {
+		t.Fatalf("second line %q, expected the absolute start cycle", lines[1])
+	}
+
+	insts := make(map[int]*konataInst)
+	get := func(n int, f []string) *konataInst {
+		id, _ := strconv.Atoi(f[1])
+		inst := insts[id]
+		if inst == nil {
+			t.Fatalf("line %d: %q refers to instruction %d before its I line", n+1, strings.Join(f, "\t"), id)
+		}
+		if inst.retired >= 0 {
+			t.Fatalf("line %d: %q after instruction %d was retired", n+1, strings.Join(f, "\t"), id)
+		}
+		return inst
+	}
+	for n, line := range lines[2:] {
+		n += 2
+		f := strings.Split(line, "\t")
+		switch f[0] {
+		case "C":
+			if d, err := strconv.Atoi(f[1]); err != nil || d <= 0 {
+				t.Fatalf("line %d: bad cycle delta %q", n+1, line)
+			}
+		case "I":
+			id, _ := strconv.Atoi(f[1])
+			if insts[id] != nil {
+				t.Fatalf("line %d: instruction %d introduced twice", n+1, id)
+			}
+			insts[id] = &konataInst{retired: -1}
+		case "L":
+			inst := get(n, f)
+			if f[2] == "0" {
+				pc, err := strconv.ParseUint(f[3][:8], 16, 32)
+				if err != nil {
+					t.Fatalf("line %d: label %q does not start with a PC", n+1, f[3])
+				}
+				inst.pc = uint32(pc)
+			}
+		case "S":
+			inst := get(n, f)
+			if inst.open != "" {
+				t.Fatalf("line %d: stage %s started while %s is open", n+1, f[3], inst.open)
+			}
+			inst.open = f[3]
+			inst.stages = append(inst.stages, f[3])
+		case "E":
+			inst := get(n, f)
+			if inst.open != f[3] {
+				t.Fatalf("line %d: ended stage %s, but %q is open", n+1, f[3], inst.open)
+			}
+			inst.open = ""
+		case "R":
+			inst := get(n, f)
+			if inst.open != "" {
+				t.Fatalf("line %d: retired with stage %s still open", n+1, inst.open)
+			}
+			inst.rid, _ = strconv.Atoi(f[2])
+			inst.retired, _ = strconv.Atoi(f[3])
+		case "W":
+			get(n, f)
+		default:
+			t.Fatalf("line %d: unknown command %q", n+1, line)
+		}
+	}
+	return insts
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. KONATA FORMAT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPipeTrace_KonataLog(t *testing.T) {
+	// WHAT: A run with a mispredicted branch produces a well-formed log: every committed
+	//       instruction goes F → Dp → Ex → Cm and retires with R type 0 in commit order,
+	//       and the wrong-path instruction is flushed with R type 1
+	// WHY: The trace exists to show where instructions waited and which were thrown away;
+	//      a missing stage or an unbalanced S/E pair hides exactly that
+	// HARDWARE: N/A (tooling)
+	// CATEGORY: [INTEGRATION]
+
+	program := []uint32{
+		EncodeIFormat(OpADDI, 8, 0, 1000), // 0x1000
+		EncodeIFormat(OpADDI, 9, 0, 7),    // 0x1004
+		EncodeRFormat(OpDIV, 3, 8, 9),     // 0x1008: r3 = 142, resolves the branch late
+		EncodeBFormat(OpBEQ, 3, 0, 12),    // 0x100C: predicted taken, falls through
+		EncodeIFormat(OpADDI, 7, 0, 42),   // 0x1010
+		EncodeIFormat(OpJAL, 0, 0, 8),     // 0x1014
+		EncodeIFormat(OpADDI, 6, 0, 1),    // 0x1018: wrong path only
+		EncodeIFormat(OpADDI, 5, 0, 5),    // 0x101C
+		EncodeIFormat(OpJAL, 0, 0, 0),     // 0x1020: spin
+	}
+	core := NewCore(1 << 20)
+	core.LoadProgram(program, 0x1000)
+	var log bytes.Buffer
+	tracer := NewPipeTracer(&log)
+	core.SetTracer(tracer)
+	core.Run(200)
+	if err := tracer.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	insts := parseKonata(t, log.String())
+
+	// Retired instructions: full lifecycle, retire IDs in commit order
+	var retired []*konataInst
+	for id := 0; id < len(insts)+1; id++ {
+		if inst := insts[id]; inst != nil && inst.retired == 0 {
+			retired = append(retired, inst)
+		}
+	}
+	wantPCs := []uint32{0x1000, 0x1004, 0x1008, 0x100C, 0x1010, 0x1014, 0x101C, 0x1020}
+	if len(retired) < len(wantPCs) {
+		t.Fatalf("%d instructions retired, expected at least %d", len(retired), len(wantPCs))
+	}
+	for i, pc := range wantPCs {
+		inst := retired[i]
+		if inst.pc != pc || inst.rid != i {
+			t.Errorf("retirement %d: pc %#x rid %d, expected pc %#x rid %d", i, inst.pc, inst.rid, pc, i)
+		}
+		if got := strings.Join(inst.stages, " "); got != "F Dp Ex Cm" {
+			t.Errorf("pc %#x went through %q, expected \"F Dp Ex Cm\"", pc, got)
+		}
+	}
+
+	// The wrong-path instruction: fetched, then flushed, never retired
+	flushed := 0
+	for _, inst := range insts {
+		if inst.pc != 0x1018 {
+			continue
+		}
+		if inst.retired != 1 {
+			t.Errorf("wrong-path instruction ended with R type %d, expected 1 (flush)", inst.retired)
+		}
+		flushed++
+	}
+	if flushed == 0 {
+		t.Error("wrong-path instruction at 0x1018 never appeared in the trace")
+	}
+}
@@ -0,0 +1,110 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// PREFETCH ACCOUNTING
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: The 5-way predictor (INNOVATION #59) fills the prefetch queue, and
+//
+//	the queue fills the L1D. Whether that helps is not the hit rate:
+//	a prefetch can bring in a line nobody reads, arrive after the load
+//	that wanted it, or throw out a line that was about to be used.
+//
+// EVERY LINE REMEMBERS: A prefetch fill sets CacheLine.Prefetched and the
+//
+//	PredictorID whose prediction it was. The first demand access clears
+//	the bit, so each prefetched line is scored exactly once:
+//
+//	Useful          a demand access hit it
+//	Late            a demand access missed while the prefetch for the
+//	                same line was still queued (the miss fetched it)
+//	UnusedEvicted   it was evicted with the bit still set
+//	Polluting       the line it evicted missed on a demand access
+//	                later (the victim is remembered in a small
+//	                pollution filter until then)
+//
+//	Useful / Issued is the accuracy; Late / (Useful + Late) says whether
+//	the prefetches come early enough.
+//
+// MARGINAL CONTRIBUTION (SetL1DPredictorEnabled):
+//
+//	A specialist switched off offers no predictions to the meta-predictor,
+//	so neither prefetches nor address speculation (see addrspec.go) can
+//	use it. Running a workload with each one off in turn measures what
+//	each is worth on its own.
+//
+// MINECRAFT ANALOGY: Laying out materials before the build. Some you use
+//
+//	(useful), some you were already fetching by hand when the helper
+//	brought them (late), some sit there until you clear the chest
+//	(unused), and sometimes the helper cleared out something you needed
+//	to make room (polluting).
+
+// PollutionFilterSize is the number of prefetch victims remembered
+const PollutionFilterSize = 64
+
+// prefetchCounters are one specialist's prefetch outcomes
+type prefetchCounters struct {
+	issued        uint64 // Lines a prefetch brought in
+	useful        uint64 // ...later hit by a demand access
+	late          uint64 // Demand misses on a line still queued for prefetch
+	unusedEvicted uint64 // Prefetched lines evicted unused
+	polluting     uint64 // Prefetch victims that later missed
+}
+
+// prefetchVictim is a line a prefetch evicted
+type prefetchVictim struct {
+	line   uint32      // Line address
+	source PredictorID // PredictorNone = empty slot
+}
+
+// prefetchAccounting scores the L1D's prefetches per specialist
+type prefetchAccounting struct {
+	perSource [6]prefetchCounters // Indexed by PredictorID-1 (6 = plugged-in)
+	victims   [PollutionFilterSize]prefetchVictim
+}
+
+// SetL1DPredictorEnabled switches one L1D predictor specialist on or off
+func (c *Core) SetL1DPredictorEnabled(id PredictorID, on bool) {
+	if id >= PredictorStride && id <= PredictorContext {
+		c.dcache.predictor.disabled[id-1] = !on
+	}
+}
+
+// fillPrefetch installs a line a prefetch brought in
+func (c *L1DCache) fillPrefetch(addr uint32, data []byte, source PredictorID) {
+	c.install(addr, data, source)
+}
+
+// noteEviction scores a line about to be replaced by a fill from source
+func (c *L1DCache) noteEviction(victim *CacheLine, setIdx int, source PredictorID) {
+	acct := &c.prefetchAcct
+	if victim.Prefetched {
+		acct.perSource[victim.PrefetchSource-1].unusedEvicted++
+	}
+	if source != PredictorNone {
+		line := c.lineAddr(victim, setIdx)
+		acct.victims[(line/CacheLineSize)%PollutionFilterSize] = prefetchVictim{line: line, source: source}
+	}
+}
+
+// demandHit scores a demand access that found its line
+func (c *L1DCache) demandHit(line *CacheLine) {
+	if line.Prefetched {
+		c.prefetchAcct.perSource[line.PrefetchSource-1].useful++
+		line.Prefetched = false
+	}
+}
+
+// demandMiss scores a demand access that did not find its line
+func (c *L1DCache) demandMiss(addr uint32) {
+	acct := &c.prefetchAcct
+	line := addr &^ (CacheLineSize - 1)
+	if source, ok := c.prefetchQueue.queued(line); ok {
+		acct.perSource[source-1].late++
+	}
+	if v := &acct.victims[(line/CacheLineSize)%PollutionFilterSize]; v.source != PredictorNone && v.line == line {
+		acct.perSource[v.source-1].polluting++
+		v.source = PredictorNone
+	}
+}
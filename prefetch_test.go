@@ -0,0 +1,214 @@
+package suprax32
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Prefetch Accounting - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The counters are only useful if each prefetched line lands in exactly one bucket, so the
+// unit tests build each outcome by hand on a bare L1D and check that it is scored once and
+// by the right specialist. The program test checks the numbers against a workload whose
+// answer is known: a strided walk that only the stride specialist can prefetch.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. QUEUE TESTS
+//    Requests for cached lines and demand-filled lines leave the queue
+//
+// 2. OUTCOME TESTS
+//    Useful, late, unused-evicted, polluting
+//
+// 3. CONTRIBUTION TESTS
+//    A specialist switched off offers nothing; the stride walk loses its prefetches
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// strideWalkSource touches one word per line of a 256 KB array (four times the L1D)
+const strideWalkSource = `
+int a[65536];
+
+int main() {
+	int i, sum;
+	sum = 0;
+	for (i = 0; i < 65536; i += 16)
+		sum = sum + a[i];
+	return sum;
+}
+`
+
+// runStrideWalk runs strideWalkSource with one specialist switched off (PredictorNone = all on)
+func runStrideWalk(t *testing.T, off PredictorID) *Core {
+	t.Helper()
+	img, err := BuildC("walk.c", strideWalkSource)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	core.SetL1DPredictorEnabled(off, false)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	core.Run(5_000_000)
+	if status, ok := core.Exited(); !ok || status != 0 {
+		t.Fatalf("exit %d, %v", status, ok)
+	}
+	return core
+}
+
+// sameSet returns the address of the n-th line mapping to addr's L1D set
+func sameSet(addr uint32, n int) uint32 {
+	return addr + uint32(n)*L1DNumSets*CacheLineSize
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. QUEUE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPrefetch_QueueNeverJams(t *testing.T) {
+	// WHAT: A request whose line is already cached, and one whose line a demand miss filled
+	//       first, both leave the queue, and the requests behind them are still served
+	// WHY: The head request used to stay InFlight forever when its line was cached, and
+	//      from then on every new prediction was dropped as "queue full"
+	// HARDWARE: Discard, Complete on a Pending entry, retire from the head
+	// CATEGORY: [UNIT]
+
+	var pq PrefetchQueue
+	for i := uint32(0); i < PrefetchQueueSize; i++ {
+		pq.Enqueue(0x1000+i*CacheLineSize, PredictorStride)
+	}
+
+	addr, _, ok := pq.Dequeue()
+	if !ok {
+		t.Fatal("nothing to dequeue")
+	}
+	pq.Discard(addr)                      // Line already cached
+	pq.Complete(0x1000 + CacheLineSize*2) // Demand fill of a request still pending
+
+	if next, _, ok := pq.Dequeue(); !ok || next != 0x1000+CacheLineSize {
+		t.Fatalf("after a discard: dequeued 0x%x, %v", next, ok)
+	}
+	pq.Complete(0x1000 + CacheLineSize)
+	if next, _, ok := pq.Dequeue(); !ok || next != 0x1000+CacheLineSize*3 {
+		t.Errorf("demand-filled request not skipped: dequeued 0x%x, %v", next, ok)
+	}
+	if !pq.Enqueue(0x9000, PredictorDelta) {
+		t.Error("queue still full")
+	}
+	if s := pq.Stats(); s.Redundant != 2 || s.Completed != 1 {
+		t.Errorf("redundant %d, completed %d; expected 2, 1", s.Redundant, s.Completed)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. OUTCOME TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPrefetch_OutcomesScoredOnce(t *testing.T) {
+	// WHAT: Each outcome is built by hand and lands in its specialist's counter, once
+	// WHY: Accuracy and pollution per specialist are the whole point of the accounting
+	// HARDWARE: CacheLine.Prefetched/PrefetchSource, demandHit/demandMiss, pollution filter
+	// CATEGORY: [UNIT]
+
+	line := make([]byte, CacheLineSize)
+	acct := func(c *L1DCache, id PredictorID) prefetchCounters {
+		return c.prefetchAcct.perSource[id-1]
+	}
+
+	t.Run("useful", func(t *testing.T) {
+		c := NewL1DCache()
+		c.fillPrefetch(0x4000, line, PredictorStride)
+		c.ReadSize(0x100, 0x4008, 4)
+		c.ReadSize(0x100, 0x4010, 4)
+		if got := acct(c, PredictorStride); got.issued != 1 || got.useful != 1 {
+			t.Errorf("issued %d, useful %d; expected 1, 1", got.issued, got.useful)
+		}
+	})
+
+	t.Run("late", func(t *testing.T) {
+		c := NewL1DCache()
+		c.prefetchQueue.Enqueue(0x4000, PredictorMarkov)
+		if _, hit := c.ReadSize(0x100, 0x4004, 4); hit {
+			t.Fatal("line cached before any fill")
+		}
+		c.Fill(0x4000, line) // The demand miss gets there first
+		c.ReadSize(0x100, 0x4004, 4)
+		if got := acct(c, PredictorMarkov); got.late != 1 || got.useful != 0 || got.issued != 0 {
+			t.Errorf("late %d, useful %d, issued %d; expected 1, 0, 0", got.late, got.useful, got.issued)
+		}
+		if _, _, ok := c.prefetchQueue.Dequeue(); ok {
+			t.Error("request still queued after the demand fill")
+		}
+	})
+
+	t.Run("unused and polluting", func(t *testing.T) {
+		c := NewL1DCache()
+		for n := 0; n < L1Associativity; n++ {
+			c.Fill(sameSet(0x4000, n), line)
+		}
+		c.fillPrefetch(sameSet(0x4000, L1Associativity), line, PredictorConstant)
+
+		victim := uint32(0)
+		for n := 0; n < L1Associativity; n++ {
+			if _, _, ok := c.Probe(sameSet(0x4000, n)); !ok {
+				victim = sameSet(0x4000, n)
+			}
+		}
+		if victim == 0 {
+			t.Fatal("prefetch evicted nothing")
+		}
+		c.ReadSize(0x100, victim, 4) // Misses: the prefetch polluted
+
+		// Evict the unused prefetched line with demand fills
+		for n := L1Associativity + 1; n < 3*L1Associativity; n++ {
+			c.Fill(sameSet(0x4000, n), line)
+		}
+		got := acct(c, PredictorConstant)
+		if got.polluting != 1 || got.unusedEvicted != 1 || got.useful != 0 {
+			t.Errorf("polluting %d, unused evicted %d, useful %d; expected 1, 1, 0",
+				got.polluting, got.unusedEvicted, got.useful)
+		}
+	})
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. CONTRIBUTION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPrefetch_StrideWalk(t *testing.T) {
+	// WHAT: Walking a 256 KB array a line at a time, the stride specialist's prefetches are
+	//       nearly all useful; with stride switched off it offers nothing, hardly anything
+	//       is prefetched and the walk takes longer
+	// WHY: Switching one specialist off is how its marginal contribution is measured
+	// HARDWARE: SetL1DPredictorEnabled, prefetch accounting per PredictorID
+	// CATEGORY: [INTEGRATION]
+
+	all := runStrideWalk(t, PredictorNone).Snapshot()
+	noStride := runStrideWalk(t, PredictorStride).Snapshot()
+
+	stride := all.L1D.Prefetchers[PredictorStride-1]
+	if stride.Issued < 1000 || stride.Accuracy < 0.9 {
+		t.Errorf("stride issued %d prefetches at accuracy %.3f", stride.Issued, stride.Accuracy)
+	}
+
+	sub := noStride.L1DPredictor.Predictors[PredictorStride-1]
+	if sub.Enabled || sub.Offered != 0 || noStride.L1D.Prefetchers[PredictorStride-1].Issued != 0 {
+		t.Errorf("switched off: enabled %v, offered %d, issued %d",
+			sub.Enabled, sub.Offered, noStride.L1D.Prefetchers[PredictorStride-1].Issued)
+	}
+	if noStride.Core.Cycles <= all.Core.Cycles {
+		t.Errorf("cycles without stride %d, with %d: no contribution measured",
+			noStride.Core.Cycles, all.Core.Cycles)
+	}
+}
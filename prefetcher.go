@@ -0,0 +1,255 @@
+package suprax32
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// PLUGGABLE PREFETCHERS
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: The 5-way predictor (INNOVATION #59) and the L1I coverage scoring
+//
+//	(INNOVATIONS #22, #27) are the only prefetchers, so nothing shows
+//	they beat the designs everyone else ships. A Prefetcher can stand
+//	in for either one for a whole run, and the classic designs in
+//	prefetcher_baselines.go are there to be compared against.
+//
+// THE INTERFACE:
+//
+//	The cache reports every demand access and every fill to Observe:
+//
+//	  PrefetchOnHit   a demand access found its line (Prefetched: the
+//	                  first use of a line a prefetch brought in)
+//	  PrefetchOnMiss  a demand access missed
+//	  PrefetchOnFill  a line was installed (Prefetched: by a prefetch)
+//
+//	and the prefetcher answers with candidate lines through
+//	PrefetchPort.Emit, each with a priority (higher issues first).
+//
+//	L1D events are loads (PC = the load); L1I events are fetches
+//	(PC = Addr = the fetch address).
+//
+// THE PORT (shared by both caches):
+//
+//	Event ──► Prefetcher.Observe ──► Emit ──► degree ──► PrefetchQueue
+//	                                                        │
+//	Prefetch stage ◄── issue interval ◄─────────────────────┘
+//
+//	Degree        most candidates one event may queue; the rest are
+//	              counted as OverDegree and dropped
+//	IssueInterval cycles between two prefetch issues from the queue
+//	PrefetchQueue the same 8-entry deduplicating queue (INNOVATIONS
+//	              #67-68), ordered by priority; a full queue gives a
+//	              lower-priority Pending request's slot to a higher one
+//
+//	The L1D port uses the cache's own queue, so the accounting in
+//	prefetch.go scores the plugged-in prefetcher as PredictorPrefetcher.
+//	With a port plugged in, the 5-way predictor still predicts (address
+//	speculation uses it) but no longer queues prefetches.
+//
+// USAGE:
+//
+//	p, _ := NewPrefetcher("best-offset")
+//	core.SetL1DPrefetcher(p, PrefetchConfig{Degree: 2})
+//	core.SetL1IPrefetcher(NewNextLinePrefetcher(2), PrefetchConfig{})
+//
+// MINECRAFT ANALOGY: Swapping the villager who fetches materials for a
+//
+//	hired hand from another village, with the same chest, the same
+//	number of trips, and a tally of what they brought.
+
+// PrefetchEventKind is what happened at the cache
+type PrefetchEventKind uint8
+
+const (
+	PrefetchOnHit  PrefetchEventKind = iota // Demand access found its line
+	PrefetchOnMiss                          // Demand access missed
+	PrefetchOnFill                          // A line was installed
+)
+
+// PrefetchEvent is one access or fill a Prefetcher observes
+type PrefetchEvent struct {
+	Kind       PrefetchEventKind
+	PC         uint32 // Load PC (L1D), fetch address (L1I), 0 for fills
+	Addr       uint32 // Byte address; line address for fills
+	Prefetched bool   // Hit: first use of a prefetched line. Fill: a prefetch
+}
+
+// trigger reports whether an event should train and trigger a prefetcher
+// that follows the miss stream: a miss, or the first use of a prefetched
+// line (the miss the prefetch removed)
+func (ev PrefetchEvent) trigger() bool {
+	return ev.Kind == PrefetchOnMiss || ev.Kind == PrefetchOnHit && ev.Prefetched
+}
+
+// Prefetcher watches one cache and proposes lines to prefetch
+type Prefetcher interface {
+	Name() string
+	Observe(ev PrefetchEvent, out *PrefetchPort)
+}
+
+// PrefetchConfig holds a port's degree and throttling controls
+type PrefetchConfig struct {
+	Degree        int // Most candidates one event may queue (0 = no limit)
+	IssueInterval int // Cycles between prefetch issues (0 or 1 = every cycle)
+}
+
+// PrefetchPort connects a Prefetcher to a cache's prefetch queue
+type PrefetchPort struct {
+	prefetcher Prefetcher
+	queue      *PrefetchQueue
+	config     PrefetchConfig
+
+	accepted  int    // Candidates queued for the current event
+	nextIssue uint64 // First cycle the next request may issue
+
+	// Statistics
+	events     uint64 // Events observed
+	candidates uint64 // Candidates passed to the queue
+	overDegree uint64 // Candidates beyond Degree
+	throttled  uint64 // Cycles a Pending request waited for IssueInterval
+}
+
+// newPrefetchPort plugs p into a cache with the given queue
+func newPrefetchPort(p Prefetcher, queue *PrefetchQueue, config PrefetchConfig) *PrefetchPort {
+	return &PrefetchPort{prefetcher: p, queue: queue, config: config}
+}
+
+// Emit offers one candidate line; higher priority issues first
+func (p *PrefetchPort) Emit(addr uint32, priority uint8) {
+	if p.config.Degree > 0 && p.accepted >= p.config.Degree {
+		p.overDegree++
+		return
+	}
+	p.accepted++
+	p.candidates++
+	p.queue.EnqueuePriority(addr, PredictorPrefetcher, priority)
+}
+
+// observe hands one event to the prefetcher
+func (p *PrefetchPort) observe(ev PrefetchEvent) {
+	p.events++
+	p.accepted = 0
+	p.prefetcher.Observe(ev, p)
+}
+
+// next returns the request to issue this cycle, if the interval allows one
+func (p *PrefetchPort) next(cycle uint64) (addr uint32, valid bool) {
+	if cycle < p.nextIssue {
+		if p.queue.pending() {
+			p.throttled++
+		}
+		return 0, false
+	}
+	addr, _, valid = p.queue.Dequeue()
+	if valid && p.config.IssueInterval > 1 {
+		p.nextIssue = cycle + uint64(p.config.IssueInterval)
+	}
+	return addr, valid
+}
+
+// SetL1DPrefetcher replaces the 5-way predictor's prefetches with p
+// (nil restores them)
+func (c *Core) SetL1DPrefetcher(p Prefetcher, config PrefetchConfig) {
+	c.dcache.port = nil
+	if p != nil {
+		c.dcache.port = newPrefetchPort(p, &c.dcache.prefetchQueue, config)
+	}
+}
+
+// SetL1IPrefetcher replaces the L1I coverage prefetch with p (nil restores it)
+func (c *Core) SetL1IPrefetcher(p Prefetcher, config PrefetchConfig) {
+	c.icache.port = nil
+	if p != nil {
+		c.icache.port = newPrefetchPort(p, &PrefetchQueue{}, config)
+	}
+}
+
+// observe reports an L1D event to the plugged-in prefetcher
+func (c *L1DCache) observe(kind PrefetchEventKind, pc, addr uint32, prefetched bool) {
+	if c.port != nil {
+		c.port.observe(PrefetchEvent{Kind: kind, PC: pc, Addr: addr, Prefetched: prefetched})
+	}
+}
+
+// observe reports an L1I event to the plugged-in prefetcher
+func (c *L1ICache) observe(kind PrefetchEventKind, addr uint32, prefetched bool) {
+	if c.port != nil {
+		pc := addr
+		if kind == PrefetchOnFill {
+			pc = 0
+		}
+		c.port.observe(PrefetchEvent{Kind: kind, PC: pc, Addr: addr, Prefetched: prefetched})
+	}
+}
+
+// nextL1DPrefetch returns the L1D request to issue this cycle
+func (c *Core) nextL1DPrefetch() (addr uint32, predictor PredictorID, valid bool) {
+	if port := c.dcache.port; port != nil {
+		addr, valid = port.next(c.cycles)
+		return addr, PredictorPrefetcher, valid
+	}
+	return c.dcache.GetNextPrefetch()
+}
+
+// prefetchL1I issues the plugged-in L1I prefetcher's next request
+func (c *Core) prefetchL1I() {
+	port := c.icache.port
+	addr, valid := port.next(c.cycles)
+	if !valid {
+		return
+	}
+	if _, _, _, cached := c.icache.Probe(addr); cached {
+		port.queue.Discard(addr)
+		return
+	}
+	c.icache.install(addr, c.memoryLine(addr), true)
+}
+
+// memoryLine copies the line holding addr out of main memory
+func (c *Core) memoryLine(addr uint32) []byte {
+	lineAddr := addr &^ (CacheLineSize - 1)
+	data := make([]byte, CacheLineSize)
+	for j := range data {
+		if int(lineAddr)+j < len(c.memory) {
+			data[j] = c.memory[lineAddr+uint32(j)]
+		}
+	}
+	return data
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// SELECTION BY NAME
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// prefetcherFactories builds each baseline with its default parameters
+var prefetcherFactories = map[string]func() Prefetcher{
+	"none":        func() Prefetcher { return NoPrefetcher{} },
+	"next-line":   func() Prefetcher { return NewNextLinePrefetcher(1) },
+	"ip-stride":   func() Prefetcher { return NewIPStridePrefetcher(4) },
+	"stream":      func() Prefetcher { return NewStreamPrefetcher(4) },
+	"ghb":         func() Prefetcher { return NewGHBPrefetcher(4) },
+	"best-offset": func() Prefetcher { return NewBestOffsetPrefetcher() },
+	"sms":         func() Prefetcher { return NewSMSPrefetcher() },
+}
+
+// PrefetcherNames lists the names NewPrefetcher accepts
+func PrefetcherNames() []string {
+	names := make([]string, 0, len(prefetcherFactories))
+	for name := range prefetcherFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewPrefetcher builds a baseline prefetcher by name (see PrefetcherNames)
+func NewPrefetcher(name string) (Prefetcher, error) {
+	factory, ok := prefetcherFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prefetcher %q (have %v)", name, PrefetcherNames())
+	}
+	return factory(), nil
+}
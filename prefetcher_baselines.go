@@ -0,0 +1,561 @@
+package suprax32
+
+import "math/bits"
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// BASELINE PREFETCHERS
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// The classic designs, sized like their papers' small configurations,
+// to measure the 5-way predictor and the L1I coverage scoring against.
+//
+//	none         nothing: the no-prefetch floor
+//	next-line    tagged next-N-line (Smith 1982)
+//	ip-stride    per-PC stride table (Baer & Chen 1991)
+//	stream       stream buffers on the miss stream (Jouppi 1990)
+//	ghb          Global History Buffer, PC/DC (Nesbit & Smith 2004)
+//	best-offset  one learned line offset (Michaud 2016)
+//	sms          Spatial Memory Streaming (Somogyi et al. 2006)
+//
+// Every prefetcher works in lines. The miss-stream designs train on
+// PrefetchEvent.trigger: misses plus the first use of a prefetched line,
+// which is the miss a good prefetch hides. Candidates nearer the trigger
+// get higher priority.
+
+// lineShift converts between byte and line addresses
+const lineShift = 6 // log2(CacheLineSize)
+
+// nearFirst ranks a candidate distance lines from its trigger
+func nearFirst(distance int) uint8 {
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance > 255 {
+		return 0
+	}
+	return uint8(255 - distance)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// NONE AND NEXT-LINE
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// NoPrefetcher never prefetches
+type NoPrefetcher struct{}
+
+func (NoPrefetcher) Name() string                         { return "none" }
+func (NoPrefetcher) Observe(PrefetchEvent, *PrefetchPort) {}
+
+// NextLinePrefetcher fetches the lines after every trigger
+//
+// Tagged: the first use of a prefetched line triggers too, so a
+// sequential walk stays degree lines ahead after a single miss.
+type NextLinePrefetcher struct {
+	degree int
+}
+
+// NewNextLinePrefetcher prefetches degree lines after each trigger
+func NewNextLinePrefetcher(degree int) *NextLinePrefetcher {
+	return &NextLinePrefetcher{degree: degree}
+}
+
+func (p *NextLinePrefetcher) Name() string { return "next-line" }
+
+func (p *NextLinePrefetcher) Observe(ev PrefetchEvent, out *PrefetchPort) {
+	if !ev.trigger() {
+		return
+	}
+	line := ev.Addr >> lineShift
+	for k := 1; k <= p.degree; k++ {
+		out.Emit((line+uint32(k))<<lineShift, nearFirst(k))
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// IP-STRIDE
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// ALGORITHM (every demand access, per load PC):
+//
+//	STEP 1: stride = addr - last address of this PC
+//	STEP 2: Same stride as before: confidence up; else down, and at zero
+//	        the new stride replaces the old
+//	STEP 3: Confidence ≥ 2: prefetch addr + k×stride for k = 1..degree
+//	        (strides inside a line step a whole line, same direction)
+
+const ipStrideEntries = 256
+
+type ipStrideEntry struct {
+	tag    uint32
+	last   uint32
+	stride int32
+	conf   uint8 // 0-3
+	valid  bool
+}
+
+// IPStridePrefetcher is a per-PC stride prefetcher
+type IPStridePrefetcher struct {
+	degree int
+	table  [ipStrideEntries]ipStrideEntry
+}
+
+// NewIPStridePrefetcher prefetches degree strides ahead
+func NewIPStridePrefetcher(degree int) *IPStridePrefetcher {
+	return &IPStridePrefetcher{degree: degree}
+}
+
+func (p *IPStridePrefetcher) Name() string { return "ip-stride" }
+
+func (p *IPStridePrefetcher) Observe(ev PrefetchEvent, out *PrefetchPort) {
+	if ev.Kind == PrefetchOnFill {
+		return
+	}
+	e := &p.table[(ev.PC>>2)%ipStrideEntries]
+	tag := ev.PC >> 10
+	if !e.valid || e.tag != tag {
+		*e = ipStrideEntry{tag: tag, last: ev.Addr, valid: true}
+		return
+	}
+
+	// STEP 1
+	stride := int32(ev.Addr - e.last)
+	if stride == 0 {
+		return
+	}
+	e.last = ev.Addr
+
+	// STEP 2
+	switch {
+	case stride == e.stride:
+		if e.conf < 3 {
+			e.conf++
+		}
+	case e.conf > 0:
+		e.conf--
+	default:
+		e.stride = stride
+	}
+
+	// STEP 3
+	if e.conf < 2 {
+		return
+	}
+	step := e.stride
+	if step > -CacheLineSize && step < CacheLineSize {
+		step = CacheLineSize
+		if e.stride < 0 {
+			step = -CacheLineSize
+		}
+	}
+	for k := 1; k <= p.degree; k++ {
+		out.Emit(ev.Addr+uint32(step*int32(k)), nearFirst(k))
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// STREAM BUFFERS
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// ALGORITHM (every trigger):
+//
+//	STEP 1: Find a stream whose last line is within streamWindow lines
+//	STEP 2: Found: its direction is the sign of the step; a step in the
+//	        same direction as the last one confirms the stream and
+//	        prefetches degree lines ahead of the trigger
+//	STEP 3: Not found: the least recently used stream restarts here
+
+const (
+	streamCount  = 8
+	streamWindow = 4 // Lines a stream may step at once
+)
+
+type stream struct {
+	line      uint32 // Last trigger line
+	dir       int32  // +1, -1, or 0 before the second trigger
+	confirmed bool
+	lastUse   uint64
+	valid     bool
+}
+
+// StreamPrefetcher tracks streamCount sequential miss streams
+type StreamPrefetcher struct {
+	degree  int
+	streams [streamCount]stream
+	clock   uint64
+}
+
+// NewStreamPrefetcher runs each confirmed stream degree lines ahead
+func NewStreamPrefetcher(degree int) *StreamPrefetcher {
+	return &StreamPrefetcher{degree: degree}
+}
+
+func (p *StreamPrefetcher) Name() string { return "stream" }
+
+func (p *StreamPrefetcher) Observe(ev PrefetchEvent, out *PrefetchPort) {
+	if !ev.trigger() {
+		return
+	}
+	p.clock++
+	line := ev.Addr >> lineShift
+
+	// STEP 1
+	victim := 0
+	for i := range p.streams {
+		s := &p.streams[i]
+		if !s.valid {
+			victim = i
+			continue
+		}
+		step := int32(line - s.line)
+		if step == 0 || step > streamWindow || step < -streamWindow {
+			if p.streams[victim].valid && s.lastUse < p.streams[victim].lastUse {
+				victim = i
+			}
+			continue
+		}
+
+		// STEP 2
+		dir := int32(1)
+		if step < 0 {
+			dir = -1
+		}
+		s.confirmed = dir == s.dir
+		s.dir, s.line, s.lastUse = dir, line, p.clock
+		if s.confirmed {
+			for k := 1; k <= p.degree; k++ {
+				out.Emit(uint32(int32(line)+dir*int32(k))<<lineShift, nearFirst(k))
+			}
+		}
+		return
+	}
+
+	// STEP 3
+	p.streams[victim] = stream{line: line, lastUse: p.clock, valid: true}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// GLOBAL HISTORY BUFFER (PC/DC)
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE STRUCTURE:
+//
+//	Index table  PC → newest GHB entry of that PC
+//	GHB          FIFO of trigger lines, each linked to the previous
+//	             entry of the same PC (links into overwritten entries
+//	             end the chain)
+//
+// ALGORITHM (every trigger):
+//
+//	STEP 1: Append the line and link it into its PC's chain
+//	STEP 2: Walk the chain: the PC's recent lines, newest first, and the
+//	        deltas between them
+//	STEP 3: Find an earlier occurrence of the newest two deltas
+//	STEP 4: Replay the deltas that followed it from the current line
+
+const (
+	ghbSize      = 256
+	ghbIndexSize = 64
+	ghbHistory   = 16 // Lines walked per trigger
+)
+
+type ghbEntry struct {
+	line uint32
+	prev uint64 // Sequence number + 1 of the PC's previous entry (0 = none)
+}
+
+type ghbIndexEntry struct {
+	tag  uint32
+	last uint64 // Sequence number + 1 of the PC's newest entry (0 = none)
+}
+
+// GHBPrefetcher is a delta-correlating Global History Buffer prefetcher
+type GHBPrefetcher struct {
+	degree int
+	ghb    [ghbSize]ghbEntry
+	index  [ghbIndexSize]ghbIndexEntry
+	seq    uint64 // Entries ever appended
+}
+
+// NewGHBPrefetcher replays up to degree correlated deltas
+func NewGHBPrefetcher(degree int) *GHBPrefetcher {
+	return &GHBPrefetcher{degree: degree}
+}
+
+func (p *GHBPrefetcher) Name() string { return "ghb" }
+
+func (p *GHBPrefetcher) Observe(ev PrefetchEvent, out *PrefetchPort) {
+	if !ev.trigger() {
+		return
+	}
+	line := ev.Addr >> lineShift
+
+	// STEP 1
+	idx := &p.index[(ev.PC>>2)%ghbIndexSize]
+	if tag := ev.PC >> 8; idx.tag != tag {
+		*idx = ghbIndexEntry{tag: tag}
+	}
+	p.ghb[p.seq%ghbSize] = ghbEntry{line: line, prev: idx.last}
+	p.seq++
+	idx.last = p.seq
+
+	// STEP 2: deltas[i] = history[i] - history[i+1], newest first
+	var deltas [ghbHistory]int32
+	n := 0
+	for at := idx.last; n < ghbHistory; n++ {
+		e := p.ghb[(at-1)%ghbSize]
+		if e.prev == 0 || p.seq-(e.prev-1) > ghbSize {
+			break // Chain ends or runs into overwritten entries
+		}
+		deltas[n] = int32(e.line - p.ghb[(e.prev-1)%ghbSize].line)
+		at = e.prev
+	}
+
+	// STEP 3
+	match := 0
+	for j := 1; j+1 < n; j++ {
+		if deltas[j] == deltas[0] && deltas[j+1] == deltas[1] {
+			match = j
+			break
+		}
+	}
+	if match == 0 {
+		return
+	}
+
+	// STEP 4: deltas[match-1] followed the match, deltas[0] is the newest
+	next := int32(line)
+	for k := 0; k < p.degree; k++ {
+		next += deltas[match-1-k%match]
+		out.Emit(uint32(next)<<lineShift, nearFirst(k+1))
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// BEST-OFFSET
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE IDEA: Prefetch X+D for every trigger X, with D learned: offset d
+//
+//	would have been timely for X if X-d was prefetched (or, with
+//	prefetching off, fetched) recently. The recent-requests table (RR)
+//	holds the base lines of recent prefetch fills.
+//
+// ALGORITHM (every trigger X):
+//
+//	STEP 1: Test the next offset d of the list: X-d in RR scores d
+//	STEP 2: An offset reaching boScoreMax, or boRoundMax passes over the
+//	        list, ends the learning phase: the best offset becomes D,
+//	        or prefetching turns off if even it scored ≤ boBadScore
+//	STEP 3: Prefetch X+D
+//
+// On a prefetch fill of line Y, Y-D goes into RR (Y itself while off).
+
+const (
+	boRRSize   = 64
+	boScoreMax = 31
+	boRoundMax = 100
+	boBadScore = 1
+)
+
+// boOffsets are the candidate offsets in lines (2^i × 3^j × 5^k, both ways)
+var boOffsets = [...]int32{1, 2, 3, 4, 5, 6, 8, 9, 10, 12, 15, 16, 18, 20, 24, 25, 27, 30, 32,
+	-1, -2, -3, -4, -6, -8}
+
+// BestOffsetPrefetcher learns one line offset for the whole miss stream
+type BestOffsetPrefetcher struct {
+	offset int32 // D (0 = prefetching off)
+	rr     [boRRSize]uint32
+	scores [len(boOffsets)]int
+	test   int // Offset tested at the next trigger
+	round  int
+}
+
+// NewBestOffsetPrefetcher starts with D = 1 line
+func NewBestOffsetPrefetcher() *BestOffsetPrefetcher {
+	return &BestOffsetPrefetcher{offset: 1}
+}
+
+func (p *BestOffsetPrefetcher) Name() string { return "best-offset" }
+
+// Offset returns the offset in use, in lines (0 = off)
+func (p *BestOffsetPrefetcher) Offset() int32 { return p.offset }
+
+func (p *BestOffsetPrefetcher) Observe(ev PrefetchEvent, out *PrefetchPort) {
+	line := int32(ev.Addr >> lineShift)
+	if ev.Kind == PrefetchOnFill {
+		switch {
+		case ev.Prefetched:
+			p.remember(line - p.offset)
+		case p.offset == 0:
+			p.remember(line)
+		}
+		return
+	}
+	if !ev.trigger() {
+		return
+	}
+
+	// STEP 1-2
+	p.learn(line)
+
+	// STEP 3
+	if p.offset != 0 {
+		out.Emit(uint32(line+p.offset)<<lineShift, nearFirst(1))
+	}
+}
+
+// learn scores one offset against trigger line x
+func (p *BestOffsetPrefetcher) learn(x int32) {
+	if p.recent(x - boOffsets[p.test]) {
+		p.scores[p.test]++
+		if p.scores[p.test] >= boScoreMax {
+			p.choose()
+			return
+		}
+	}
+	p.test++
+	if p.test == len(boOffsets) {
+		p.test = 0
+		p.round++
+		if p.round >= boRoundMax {
+			p.choose()
+		}
+	}
+}
+
+// choose ends a learning phase
+func (p *BestOffsetPrefetcher) choose() {
+	best := 0
+	for i, s := range p.scores {
+		if s > p.scores[best] {
+			best = i
+		}
+	}
+	p.offset = boOffsets[best]
+	if p.scores[best] <= boBadScore {
+		p.offset = 0
+	}
+	p.scores = [len(boOffsets)]int{}
+	p.test, p.round = 0, 0
+}
+
+// rrIndex hashes a line into the recent-requests table
+func rrIndex(line int32) int {
+	return int((uint32(line) ^ uint32(line)>>6) % boRRSize)
+}
+
+func (p *BestOffsetPrefetcher) remember(line int32) {
+	p.rr[rrIndex(line)] = uint32(line) + 1 // 0 = empty
+}
+
+func (p *BestOffsetPrefetcher) recent(line int32) bool {
+	return p.rr[rrIndex(line)] == uint32(line)+1
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// SPATIAL MEMORY STREAMING
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// THE IDEA: Code touches the same lines of a region (a structure, a
+//
+//	stack frame) every time it visits one; the PC and line offset of
+//	the first access predict which.
+//
+// THE STRUCTURE:
+//
+//	AGT  active generations: regions being visited, with the trigger
+//	     PC/offset and the lines touched so far
+//	PHT  pattern history: trigger PC/offset → lines touched last time
+//
+// ALGORITHM (every demand access):
+//
+//	STEP 1: Region already active: record the line, done
+//	STEP 2: Otherwise this is a trigger: the least recently used
+//	        generation ends and its pattern goes to the PHT
+//	STEP 3: Start a generation; prefetch the lines the PHT has for this
+//	        trigger
+//
+// A generation really ends when one of its lines leaves the cache; ending
+// it when it ages out of the AGT is the usual approximation.
+
+const (
+	smsRegionLines = 32 // 2 KB regions
+	smsAGTSize     = 32
+	smsPHTSize     = 256
+)
+
+type smsGeneration struct {
+	region  uint32
+	pc      uint32
+	offset  uint32 // Trigger line within the region
+	pattern uint32 // One bit per line touched
+	lastUse uint64
+	valid   bool
+}
+
+type smsPattern struct {
+	tag     uint32
+	pattern uint32
+	valid   bool
+}
+
+// SMSPrefetcher prefetches regions' remembered spatial footprints
+type SMSPrefetcher struct {
+	agt   [smsAGTSize]smsGeneration
+	pht   [smsPHTSize]smsPattern
+	clock uint64
+}
+
+// NewSMSPrefetcher creates an untrained SMS prefetcher
+func NewSMSPrefetcher() *SMSPrefetcher {
+	return &SMSPrefetcher{}
+}
+
+func (p *SMSPrefetcher) Name() string { return "sms" }
+
+func (p *SMSPrefetcher) Observe(ev PrefetchEvent, out *PrefetchPort) {
+	if ev.Kind == PrefetchOnFill {
+		return
+	}
+	p.clock++
+	line := ev.Addr >> lineShift
+	region, offset := line/smsRegionLines, line%smsRegionLines
+
+	// STEP 1
+	victim := -1
+	for i := range p.agt {
+		g := &p.agt[i]
+		if g.valid && g.region == region {
+			g.pattern |= 1 << offset
+			g.lastUse = p.clock
+			return
+		}
+		if victim < 0 || p.agt[victim].valid && (!g.valid || g.lastUse < p.agt[victim].lastUse) {
+			victim = i
+		}
+	}
+
+	// STEP 2
+	if old := &p.agt[victim]; old.valid && bits.OnesCount32(old.pattern) > 1 {
+		idx, tag := smsKey(old.pc, old.offset)
+		p.pht[idx] = smsPattern{tag: tag, pattern: old.pattern, valid: true}
+	}
+
+	// STEP 3
+	p.agt[victim] = smsGeneration{region: region, pc: ev.PC, offset: offset,
+		pattern: 1 << offset, lastUse: p.clock, valid: true}
+	idx, tag := smsKey(ev.PC, offset)
+	if e := &p.pht[idx]; e.valid && e.tag == tag {
+		for b := uint32(0); b < smsRegionLines; b++ {
+			if b != offset && e.pattern&(1<<b) != 0 {
+				out.Emit((region*smsRegionLines+b)<<lineShift, nearFirst(int(b)-int(offset)))
+			}
+		}
+	}
+}
+
+// smsKey hashes a trigger PC and offset into the PHT
+func smsKey(pc, offset uint32) (idx int, tag uint32) {
+	key := (pc>>2)*smsRegionLines + offset
+	return int(key % smsPHTSize), key / smsPHTSize
+}
@@ -0,0 +1,339 @@
+package suprax32
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Pluggable Prefetchers - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A baseline that is subtly broken makes the 5-way predictor look good for nothing, so each
+// one is fed the access pattern it was designed for and must propose exactly the lines its
+// paper would. The port and queue tests pin down the controls every prefetcher shares; the
+// program tests check that plugging any of them into either cache never changes an answer.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. QUEUE AND PORT TESTS
+//    Priority order, displacement, degree, issue interval
+//
+// 2. BASELINE TESTS
+//    Each design on its own pattern, selection by name
+//
+// 3. PROGRAM TESTS
+//    Results unchanged on both caches, the walk with and without prefetching
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// miss is a demand miss by pc at line (a line number, not an address)
+func miss(pc, line uint32) PrefetchEvent {
+	return PrefetchEvent{Kind: PrefetchOnMiss, PC: pc, Addr: line << lineShift}
+}
+
+// proposals feeds events to p and returns the lines queued after each one
+func proposals(p Prefetcher, events []PrefetchEvent) [][]uint32 {
+	port := newPrefetchPort(p, &PrefetchQueue{}, PrefetchConfig{})
+	out := make([][]uint32, len(events))
+	for i, ev := range events {
+		port.observe(ev)
+		for {
+			addr, _, ok := port.queue.Dequeue()
+			if !ok {
+				break
+			}
+			port.queue.Complete(addr)
+			out[i] = append(out[i], addr>>lineShift)
+		}
+	}
+	return out
+}
+
+// sameLines compares two line lists in order
+func sameLines(got, want []uint32) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. QUEUE AND PORT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPrefetcher_QueuePriority(t *testing.T) {
+	// WHAT: Higher priorities issue first, equal ones in arrival order; a full queue hands a
+	//       lower-priority Pending slot to a higher request and drops one that ranks no higher
+	// WHY: The 5-way predictor queues everything at priority 0 and must keep its FIFO order;
+	//      a baseline's near candidates must not wait behind its far ones
+	// HARDWARE: PrefetchEntry.Priority, EnqueuePriority, Dequeue
+	// CATEGORY: [UNIT]
+
+	var pq PrefetchQueue
+	pq.EnqueuePriority(0x1000, PredictorPrefetcher, 1)
+	pq.EnqueuePriority(0x2000, PredictorPrefetcher, 5)
+	pq.EnqueuePriority(0x3000, PredictorPrefetcher, 5)
+	for _, want := range []uint32{0x2000, 0x3000, 0x1000} {
+		if addr, _, _ := pq.Dequeue(); addr != want {
+			t.Fatalf("dequeued 0x%x, expected 0x%x", addr, want)
+		}
+		pq.Complete(want)
+	}
+
+	for i := uint32(0); i < PrefetchQueueSize; i++ {
+		pq.EnqueuePriority(0x10000+i*CacheLineSize, PredictorPrefetcher, uint8(10+i))
+	}
+	if pq.EnqueuePriority(0x90000, PredictorPrefetcher, 10) {
+		t.Error("a request ranked no higher than any queued one was accepted")
+	}
+	if !pq.EnqueuePriority(0x90000, PredictorPrefetcher, 200) {
+		t.Fatal("a higher-priority request was dropped")
+	}
+	if addr, _, _ := pq.Dequeue(); addr != 0x90000 {
+		t.Errorf("dequeued 0x%x first, expected the high-priority 0x90000", addr)
+	}
+	if _, ok := pq.queued(0x10000); ok {
+		t.Error("the lowest-priority request was not the one displaced")
+	}
+	if s := pq.Stats(); s.Displaced != 1 || s.DropsFull != 1 {
+		t.Errorf("displaced %d, dropped full %d; expected 1, 1", s.Displaced, s.DropsFull)
+	}
+}
+
+func TestPrefetcher_PortControls(t *testing.T) {
+	// WHAT: Degree caps the candidates one event queues; IssueInterval spaces issues out and
+	//       counts the cycles a waiting request was held back
+	// WHY: These are the knobs every baseline is compared at
+	// HARDWARE: PrefetchPort.Emit, PrefetchPort.next
+	// CATEGORY: [UNIT]
+
+	port := newPrefetchPort(NewNextLinePrefetcher(4), &PrefetchQueue{}, PrefetchConfig{Degree: 2, IssueInterval: 3})
+	port.observe(miss(0, 100))
+	port.observe(miss(0, 200))
+
+	var issued []uint64
+	for cycle := uint64(10); cycle < 30; cycle++ {
+		if addr, ok := port.next(cycle); ok {
+			issued = append(issued, cycle)
+			port.queue.Complete(addr)
+		}
+	}
+	if len(issued) != 4 || issued[0] != 10 || issued[1] != 13 || issued[3] != 19 {
+		t.Errorf("issued at cycles %v, expected 4 requests 3 cycles apart from 10", issued)
+	}
+	if s := port.Stats(); s.Events != 2 || s.Candidates != 4 || s.OverDegree != 4 || s.Throttled != 6 {
+		t.Errorf("events %d, candidates %d, over degree %d, throttled %d; expected 2, 4, 4, 6",
+			s.Events, s.Candidates, s.OverDegree, s.Throttled)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. BASELINE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPrefetcher_Baselines(t *testing.T) {
+	// WHAT: Each baseline, trained on its own pattern, proposes the lines its design predicts
+	//       on the last event
+	// WHY: A broken baseline is a meaningless comparison
+	// CATEGORY: [UNIT]
+
+	// SMS: PC 0x400 touches lines 0, 2, 5 of a region; 32 other regions push the generation
+	// out of the AGT; the same trigger in a new region brings back lines 2 and 5
+	sms := []PrefetchEvent{miss(0x400, 0), miss(0x404, 2), miss(0x408, 5)}
+	for r := uint32(1); r <= smsAGTSize; r++ {
+		sms = append(sms, miss(0x800, r*smsRegionLines))
+	}
+	sms = append(sms, miss(0x400, 100*smsRegionLines))
+
+	tests := []struct {
+		name   string
+		p      Prefetcher
+		events []PrefetchEvent
+		want   []uint32
+	}{
+		{"none", NoPrefetcher{}, []PrefetchEvent{miss(0, 7), miss(0, 8)}, nil},
+		{"next-line", NewNextLinePrefetcher(2), []PrefetchEvent{miss(0, 7)}, []uint32{8, 9}},
+		{"next-line tagged", NewNextLinePrefetcher(1),
+			[]PrefetchEvent{{Kind: PrefetchOnHit, Addr: 8 << lineShift, Prefetched: true}}, []uint32{9}},
+		{"next-line ignores plain hits", NewNextLinePrefetcher(1),
+			[]PrefetchEvent{{Kind: PrefetchOnHit, Addr: 8 << lineShift}}, nil},
+		// Strides of 4 lines from one PC: stride learned, then confirmed twice
+		{"ip-stride", NewIPStridePrefetcher(2),
+			[]PrefetchEvent{miss(0x100, 0), miss(0x100, 4), miss(0x100, 8), miss(0x100, 12)}, []uint32{16, 20}},
+		{"ip-stride word steps", NewIPStridePrefetcher(2), []PrefetchEvent{
+			{Kind: PrefetchOnHit, PC: 0x100, Addr: 0x1000}, {Kind: PrefetchOnHit, PC: 0x100, Addr: 0x1004},
+			{Kind: PrefetchOnHit, PC: 0x100, Addr: 0x1008}, {Kind: PrefetchOnHit, PC: 0x100, Addr: 0x100c},
+		}, []uint32{0x1000>>lineShift + 1, 0x1000>>lineShift + 2}},
+		{"stream down", NewStreamPrefetcher(3), []PrefetchEvent{miss(0, 50), miss(0, 49), miss(0, 48)}, []uint32{47, 46, 45}},
+		{"stream unconfirmed", NewStreamPrefetcher(3), []PrefetchEvent{miss(0, 50), miss(0, 51)}, nil},
+		// Deltas +1, +3 repeating from one PC
+		{"ghb", NewGHBPrefetcher(4), []PrefetchEvent{miss(0x200, 0), miss(0x200, 1), miss(0x200, 4),
+			miss(0x200, 5), miss(0x200, 8), miss(0x200, 9), miss(0x200, 12)}, []uint32{13, 16, 17, 20}},
+		{"sms", NewSMSPrefetcher(), sms, []uint32{100*smsRegionLines + 2, 100*smsRegionLines + 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := proposals(tt.p, tt.events)
+			if last := got[len(got)-1]; !sameLines(last, tt.want) {
+				t.Errorf("proposed lines %v, expected %v", last, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefetcher_BestOffsetLearns(t *testing.T) {
+	// WHAT: On a miss stream stepping 3 lines, with prefetch fills reported back, Best-Offset
+	//       settles on a multiple of 3 and prefetches that far ahead; on random misses it turns
+	//       itself off
+	// WHY: The offset is learned from fill timing, the one part a synthetic pattern cannot
+	//      simply spell out
+	// HARDWARE: Recent-requests table, offset scores, learning rounds
+	// CATEGORY: [UNIT]
+
+	p := NewBestOffsetPrefetcher()
+	port := newPrefetchPort(p, &PrefetchQueue{}, PrefetchConfig{})
+	var last []uint32
+	for i := uint32(0); i < 1000; i++ {
+		x := 1000 + 3*i
+		port.observe(miss(0, x))
+		last = last[:0]
+		for {
+			addr, _, ok := port.queue.Dequeue()
+			if !ok {
+				break
+			}
+			port.queue.Complete(addr)
+			port.observe(PrefetchEvent{Kind: PrefetchOnFill, Addr: addr, Prefetched: true})
+			last = append(last, addr>>lineShift)
+		}
+	}
+	if d := p.Offset(); d <= 0 || d%3 != 0 {
+		t.Fatalf("learned offset %d, expected a positive multiple of 3", d)
+	}
+	if want := 1000 + 3*999 + uint32(p.Offset()); len(last) != 1 || last[0] != want {
+		t.Errorf("last proposal %v, expected [%d]", last, want)
+	}
+
+	// Scattered misses: the phase under way ends on the stride's scores, the next one finds
+	// no offset worth keeping and prefetching stops
+	seed := uint32(12345)
+	for i := 0; i < 2*boRoundMax*len(boOffsets); i++ {
+		seed = seed*1103515245 + 12345
+		port.observe(miss(0, seed>>8))
+	}
+	if d := p.Offset(); d != 0 {
+		t.Errorf("offset %d on random misses, expected prefetching off", d)
+	}
+}
+
+func TestPrefetcher_NewByName(t *testing.T) {
+	// WHAT: Every listed name builds a prefetcher reporting that name; an unknown name is an
+	//       error that lists the valid ones
+	// WHY: Runs select their prefetcher by name from the command line and scripts
+	// CATEGORY: [UNIT]
+
+	names := PrefetcherNames()
+	if len(names) != 7 {
+		t.Errorf("%d prefetchers: %v", len(names), names)
+	}
+	for _, name := range names {
+		p, err := NewPrefetcher(name)
+		if err != nil || p.Name() != name {
+			t.Errorf("%s: built %v, err %v", name, p, err)
+		}
+	}
+	if _, err := NewPrefetcher("oracle"); err == nil {
+		t.Error("unknown name accepted")
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. PROGRAM TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPrefetcher_KernelsPass(t *testing.T) {
+	// WHAT: With each baseline on both caches, a code-heavy and a data-heavy kernel still pass
+	// WHY: A prefetch only moves lines into a cache; it must never change what a load returns
+	// HARDWARE: SetL1DPrefetcher, SetL1IPrefetcher, prefetch stage
+	// CATEGORY: [INTEGRATION]
+
+	if testing.Short() {
+		t.Skip("runs two kernels per prefetcher on the cycle-level core")
+	}
+	for _, k := range StandardKernels() {
+		if k.Name != "dhrystone" && k.Name != "pointer-chase" {
+			continue
+		}
+		for _, name := range PrefetcherNames() {
+			t.Run(k.Name+"/"+name, func(t *testing.T) {
+				r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+					d, _ := NewPrefetcher(name)
+					i, _ := NewPrefetcher(name)
+					c.SetL1DPrefetcher(d, PrefetchConfig{})
+					c.SetL1IPrefetcher(i, PrefetchConfig{Degree: 2})
+				}})
+				if r.Err != nil || !r.Passed {
+					t.Fatalf("err %v, status %d, output %q", r.Err, r.Status, r.Output)
+				}
+				if r.Stats.L1D.Port.Name != name || r.Stats.L1I.Port.Name != name || r.Stats.L1D.Port.Events == 0 {
+					t.Errorf("ports %q/%q saw %d L1D events", r.Stats.L1D.Port.Name, r.Stats.L1I.Port.Name, r.Stats.L1D.Port.Events)
+				}
+			})
+		}
+	}
+}
+
+func TestPrefetcher_StrideWalk(t *testing.T) {
+	// WHAT: On the 256 KB walk, next-line prefetching is scored as the plugged-in prefetcher,
+	//       raises the hit rate and saves cycles over no prefetching, and the 5-way predictor
+	//       queues nothing while a prefetcher is plugged in
+	// WHY: The baseline must really stand in for the built-in prefetch
+	// HARDWARE: PredictorPrefetcher accounting (see prefetch.go)
+	// CATEGORY: [INTEGRATION]
+
+	img, err := BuildC("walk.c", strideWalkSource)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	run := func(name string) *Stats {
+		core := NewCore(1 << 20)
+		core.SetConsole(&bytes.Buffer{})
+		p, _ := NewPrefetcher(name)
+		core.SetL1DPrefetcher(p, PrefetchConfig{})
+		if err := core.LoadImage(img); err != nil {
+			t.Fatalf("LoadImage: %v", err)
+		}
+		core.Run(5_000_000)
+		if status, ok := core.Exited(); !ok || status != 0 {
+			t.Fatalf("%s: exit %d, %v", name, status, ok)
+		}
+		return core.Snapshot()
+	}
+
+	none, nextLine := run("none"), run("next-line")
+	plugged := nextLine.L1D.Prefetchers[PredictorPrefetcher-1]
+	if plugged.Name != "prefetcher" || plugged.Issued < 1000 || plugged.Accuracy < 0.9 {
+		t.Errorf("plugged-in row %q: issued %d at accuracy %.3f", plugged.Name, plugged.Issued, plugged.Accuracy)
+	}
+	for _, row := range nextLine.L1D.Prefetchers[:PredictorContext] {
+		if row.Issued != 0 {
+			t.Errorf("%s issued %d prefetches with a prefetcher plugged in", row.Name, row.Issued)
+		}
+	}
+	if nextLine.L1D.HitRate <= none.L1D.HitRate || nextLine.Core.Cycles >= none.Core.Cycles {
+		t.Errorf("next-line hit rate %.3f in %d cycles, none %.3f in %d",
+			nextLine.L1D.HitRate, nextLine.Core.Cycles, none.L1D.HitRate, none.Core.Cycles)
+	}
+}
@@ -1,3 +1,3 @@
-module main
+module suprax/proto
 
 go 1.25.4
@@ -0,0 +1,162 @@
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE Branch Predictor - Statistical Corrector (TAGE-SC-L extension)
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// TAGE is built for branches that are a function of their path. A branch that is merely
+// biased - taken 85% of the time, whatever the path - is its weak spot: every
+// misprediction allocates a fresh entry for the path it happened on, the entries never
+// saturate, and TAGE ends up guessing from weak counters. A statistical corrector sums
+// small signed weights and learns the bias directly, and may override TAGE when TAGE
+// itself is unsure.
+//
+// STRUCTURE (GEHL - GEometric History Length):
+// ────────────────────────────────────────────
+//   Table   Indexed by                           Learns
+//   0       PC, TAGE direction, TAGE confidence  how often TAGE is right for this branch
+//   1       PC ⊕ fold(history[7:0])              short-path bias
+//   2       PC ⊕ fold(history[15:0])             medium-path bias
+//   3       PC ⊕ fold(history[31:0])             longer-path bias
+//
+//   1024 signed 6-bit weights per table. sum = Σ (2w + 1), direction = sum ≥ 0.
+//
+// OVERRIDE:
+// ─────────
+//   TAGE confidence < 2 (base or unsaturated counter)
+//   AND SC direction != TAGE direction
+//   AND |sum| ≥ Threshold
+//
+// A saturated TAGE counter is never overridden: that is TAGE's home ground.
+//
+// TRAINING (every resolved branch, like O-GEHL):
+// ──────────────────────────────────────────────
+//   SC wrong or |sum| < Threshold → every weight moves one step toward the outcome
+//   Threshold adapts: SC wrong bumps a 6-bit counter up, correct-but-unsure bumps it
+//   down; at either end the threshold moves by one. It settles where updates on correct
+//   low-margin predictions balance mispredictions.
+//
+// CONTEXT ISOLATION:
+// ──────────────────
+// The weights are untagged, so the context is hashed into every index instead. That
+// separates contexts statistically but is not the hard isolation of the tagged tables;
+// the corrector can only flip low-confidence predictions, which bounds what one context
+// can do to another.
+//
+// Hardware: ~162K transistors (4 × 1024 × 6-bit SRAM + 4-input adder tree + threshold)
+//
+// SystemVerilog equivalent:
+//   logic signed [5:0] sc_weights [0:3][0:1023];
+//   logic [7:0]        sc_threshold;
+//   logic signed [5:0] sc_threshold_ctr;
+//
+//   always_comb begin
+//     sum = 0;
+//     for (int t = 0; t < 4; t++) sum += 2 * sc_weights[t][idx[t]] + 1;
+//     override = (tage_conf < 2) && ((sum >= 0) != tage_taken) && (abs(sum) >= sc_threshold);
+//   end
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+package tage
+
+const (
+	SCTables               = 4
+	SCIndexBits            = 10
+	SCTableSize            = 1 << SCIndexBits // 1024
+	SCWeightBits           = 6
+	SCWeightMax            = 1<<(SCWeightBits-1) - 1  // 31
+	SCWeightMin            = -1 << (SCWeightBits - 1) // -32
+	SCThresholdInit        = 6
+	SCThresholdCounterBits = 6
+	scThresholdCounterMax  = 1<<(SCThresholdCounterBits-1) - 1  // 31
+	scThresholdCounterMin  = -1 << (SCThresholdCounterBits - 1) // -32
+)
+
+// SCHistoryLengths are the GEHL history lengths (table 0 is the TAGE-indexed bias table).
+var SCHistoryLengths = [SCTables]int{0, 8, 16, 32}
+
+// StatisticalCorrector holds the GEHL weights and the adaptive threshold.
+type StatisticalCorrector struct {
+	Weights      [SCTables][SCTableSize]int8
+	Threshold    int32 // |sum| needed to override TAGE
+	thresholdCtr int8
+}
+
+// SCLookup is the corrector lookup result carried from predict to update.
+type SCLookup struct {
+	Indices   [SCTables]uint32
+	Sum       int32
+	Predicted bool // sum ≥ 0
+	Override  bool // Replaces the TAGE direction
+}
+
+// scIndex hashes one table's index.
+func scIndex(pc uint64, ctx uint8, history uint64, tageTaken bool, tageConf uint8, t int) uint32 {
+	pcBits := hashPC(pc, SCSalt, SCIndexBits)
+	ctxBits := uint32(ctx) << (SCIndexBits - ContextWidth)
+	if t == 0 {
+		return (pcBits<<3 ^ uint32(tageConf)<<1 ^ uint32(takenBit(tageTaken)) ^ ctxBits) & (SCTableSize - 1)
+	}
+	hist := foldHistory(history, nil, SCHistoryLengths[t], SCIndexBits)
+	return (pcBits ^ hist ^ ctxBits ^ uint32(t)*0x155) & (SCTableSize - 1)
+}
+
+// lookup sums the weights for a branch (no state change).
+func (sc *StatisticalCorrector) lookup(pc uint64, ctx uint8, history uint64, tageTaken bool, tageConf uint8) SCLookup {
+	var l SCLookup
+	for t := 0; t < SCTables; t++ {
+		l.Indices[t] = scIndex(pc, ctx, history, tageTaken, tageConf, t)
+		l.Sum += 2*int32(sc.Weights[t][l.Indices[t]]) + 1
+	}
+	l.Predicted = l.Sum >= 0
+	l.Override = tageConf < 2 && l.Predicted != tageTaken && abs32(l.Sum) >= sc.Threshold
+	return l
+}
+
+// train moves the weights and the threshold toward a resolved outcome.
+func (sc *StatisticalCorrector) train(l SCLookup, taken bool) {
+	wrong := l.Predicted != taken
+	unsure := abs32(l.Sum) < sc.Threshold
+
+	if wrong || unsure {
+		for t := 0; t < SCTables; t++ {
+			w := &sc.Weights[t][l.Indices[t]]
+			if taken && *w < SCWeightMax {
+				*w++
+			} else if !taken && *w > SCWeightMin {
+				*w--
+			}
+		}
+	}
+
+	switch {
+	case wrong:
+		sc.thresholdCtr++
+		if sc.thresholdCtr >= scThresholdCounterMax {
+			sc.Threshold++
+			sc.thresholdCtr = 0
+		}
+	case unsure:
+		sc.thresholdCtr--
+		if sc.thresholdCtr <= scThresholdCounterMin {
+			if sc.Threshold > 1 {
+				sc.Threshold--
+			}
+			sc.thresholdCtr = 0
+		}
+	}
+}
+
+// reset clears the weights and restores the initial threshold.
+func (sc *StatisticalCorrector) reset() {
+	*sc = StatisticalCorrector{Threshold: SCThresholdInit}
+}
+
+//go:inline
+func abs32(x int32) int32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,308 @@
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE Branch Predictor - TAGE-SC-L Extensions
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// The base design stops at 8 tables and 64 bits of history (see TAGE SIZING FOR SUPRAX in
+// tage.go). The comparison there is against TAGE-SC-L, which adds three things on top of
+// TAGE. Each is available here behind its own flag so its accuracy and cost can be
+// measured rather than argued:
+//
+//   Flag                 Component                        File             ~Transistors
+//   LongHistoryEnabled   2 tagged tables, 128/256 bits    longhistory.go   346K
+//   SCEnabled            GEHL statistical corrector       corrector.go     162K
+//   LoopEnabled          Loop predictor                   loop.go           25K
+//
+// All three are off by default; with all three off the predictor behaves exactly as
+// before, bit for bit.
+//
+// PREDICTION ORDER:
+// ─────────────────
+//   1. Tables 0-7 (tagePredict)
+//   2. Long tables: a hit is a longer match, so it is the provider         → "TAGE"
+//   3. Statistical corrector: may flip a low-confidence TAGE direction
+//   4. Loop predictor: a confident, trusted entry has the final word
+//
+// TRAINING:
+// ─────────
+// Update() and OnMispredict() say whether the FINAL prediction was right. With extensions
+// the tagged tables may have been right when the final answer was wrong, or the other way
+// round, so train() re-derives each component's own correctness from the lookup metadata
+// and trains each one by that:
+//
+//   Long table hit      → long provider trained; Tables 0-7 trained without allocation
+//   Otherwise           → Tables 0-7 Update or OnMispredict by their own direction
+//   Corrector           → trained on every branch
+//   Loop predictor      → trained on every branch (allocation on a miss the rest got wrong)
+//
+// Lookup metadata comes from the last Predict() of the same PC and context, or from the
+// checkpoint in speculative mode (Commit); failing both it is recomputed from the
+// current history, as Update() already does for the tagged tables.
+//
+// STATISTICS:
+// ───────────
+// Stats() reports, per component: whether it is enabled, how often its direction was the
+// final one, how often that direction replaced a different one, how often those
+// overrides were right, the net mispredictions it removed, and its storage and
+// transistor estimate. TAGEStats.Transistors totals the enabled configuration.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+package tage
+
+import "math/bits"
+
+// Component identifies who supplied a prediction.
+type Component uint8
+
+const (
+	ComponentTAGE        Component = iota // Tables 0-7
+	ComponentLongHistory                  // Long-history tables
+	ComponentCorrector                    // Statistical corrector
+	ComponentLoop                         // Loop predictor
+	numComponents
+)
+
+// String returns the component's name.
+func (c Component) String() string {
+	return [...]string{"tage", "long-history", "corrector", "loop"}[c]
+}
+
+// ExtensionMetadata is everything the extensions looked up for one prediction.
+// Hardware: Carried with PredictionMetadata in the pipeline register
+type ExtensionMetadata struct {
+	Provider        Component // Who supplied the final direction
+	TablesPredicted bool      // Tables 0-7 direction
+	TablesProvider  int       // Tables 0-7 provider table
+	TAGEPredicted   bool      // After the long tables
+	TAGEConfidence  uint8     // Confidence of TAGEPredicted
+	SCPredicted     bool      // After the corrector (before the loop predictor)
+
+	Long LongLookup
+	SC   SCLookup
+	Loop LoopLookup
+}
+
+// componentCounts are one component's running totals.
+type componentCounts struct {
+	used             uint64
+	correct          uint64
+	overrides        uint64
+	overridesCorrect uint64
+}
+
+// ComponentStats reports one extension's contribution and cost.
+type ComponentStats struct {
+	Enabled          bool
+	Used             uint64  // Final predictions it supplied
+	Correct          uint64  // ...that were right
+	Overrides        uint64  // ...that replaced a different direction
+	OverridesCorrect uint64  // ...and were right
+	NetGain          int64   // Mispredictions removed: right overrides - wrong overrides
+	Accuracy         float64 // Correct / Used
+	StorageBits      uint64  // State bits (SRAM + registers)
+	Transistors      uint64  // Estimate (6T per state bit + logic)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TRANSISTOR ESTIMATES
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Same basis as the AREA BUDGET in tage.go: 6 transistors per state bit, plus logic.
+//
+//   Base TAGE       1,340K   (tage.go AREA BUDGET)
+//   Long history      346K   2×1024×24 SRAM + 2K valid bits + 2×8×192 history bits + folders
+//   Corrector         162K   4×1024×6 SRAM + threshold registers + adder tree
+//   Loop               25K   64×40 entries + trust counter + incrementers/comparator
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+const (
+	TAGETransistors   = 1_340_000
+	transistorsPerBit = 6
+
+	longStorageBits = NumLongTables*EntriesPerTable*EntryWidth + // Entries
+		NumLongTables*EntriesPerTable + // Valid bits
+		2*NumContexts*(LongHistoryLength-HistoryWidth) // Architectural + speculative history
+	longLogicTransistors = 20_000 // Index/tag folders, tag compare, winner mux
+
+	scStorageBits        = SCTables*SCTableSize*SCWeightBits + 8 + SCThresholdCounterBits
+	scLogicTransistors   = 15_000 // 4-input adder tree, compare, weight update
+	loopStorageBits      = LoopEntries*LoopEntryWidth + 7
+	loopLogicTransistors = 10_000 // Iteration incrementer, compare, tag match
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// PREDICTION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// extensionsEnabled reports whether any extension is on.
+func (p *TAGEPredictor) extensionsEnabled() bool {
+	return p.LongHistoryEnabled || p.SCEnabled || p.LoopEnabled
+}
+
+// extendPrediction runs the enabled extensions over the tagged tables' prediction and
+// records their metadata in LastPrediction.
+func (p *TAGEPredictor) extendPrediction(pc uint64, ctx uint8, history uint64, long *LongHistory, taken bool, confidence uint8) (bool, uint8) {
+	meta := ExtensionMetadata{
+		Provider:        ComponentTAGE,
+		TablesPredicted: taken,
+		TablesProvider:  p.LastPrediction.ProviderTable,
+	}
+
+	// STEP 1: Long tables - the longest match wins
+	if p.LongHistoryEnabled {
+		meta.Long = p.longLookup(pc, ctx, history, long)
+		if meta.Long.Hit {
+			taken, confidence = meta.Long.Predicted, meta.Long.Confidence
+			meta.Provider = ComponentLongHistory
+		}
+	}
+	meta.TAGEPredicted, meta.TAGEConfidence = taken, confidence
+
+	// STEP 2: Statistical corrector - may flip a low-confidence direction
+	if p.SCEnabled {
+		meta.SC = p.Corrector.lookup(pc, ctx, history, taken, confidence)
+		if meta.SC.Override {
+			taken, confidence = meta.SC.Predicted, 1
+			meta.Provider = ComponentCorrector
+		}
+	}
+	meta.SCPredicted = taken
+
+	// STEP 3: Loop predictor - final word when confident and trusted
+	if p.LoopEnabled {
+		meta.Loop = p.Loop.lookup(pc, ctx)
+		if meta.Loop.Use {
+			taken, confidence = meta.Loop.Predicted, 2
+			meta.Provider = ComponentLoop
+		}
+	}
+
+	p.LastPrediction.Predicted = taken
+	p.LastPrediction.Confidence = confidence
+	p.LastPrediction.Ext = meta
+	return taken, confidence
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TRAINING
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// train updates every component with a resolved outcome (see TRAINING above).
+func (p *TAGEPredictor) train(pc uint64, ctx uint8, taken bool) {
+	// STEP 1: The lookup this outcome belongs to
+	var meta ExtensionMetadata
+	if p.LastPC == pc && p.LastCtx == ctx && p.LastPrediction.ProviderEntry != nil {
+		meta = p.LastPrediction.Ext
+	} else {
+		meta = p.relookup(pc, ctx)
+	}
+	p.countOutcome(&meta, taken)
+
+	// STEP 2: Extensions (before the history shifts)
+	if p.LongHistoryEnabled {
+		p.longTrain(ctx, &meta, taken)
+	}
+	if p.SCEnabled {
+		p.Corrector.train(meta.SC, taken)
+	}
+	if p.LoopEnabled {
+		p.Loop.train(ctx, meta.Loop, meta.SCPredicted, taken)
+	}
+
+	// STEP 3: Tables 0-7 by their own correctness; shifts the history
+	if meta.Long.Hit || meta.TablesPredicted == taken {
+		p.tageUpdate(pc, ctx, taken)
+	} else {
+		p.tageOnMispredict(pc, ctx, taken)
+	}
+}
+
+// relookup recomputes the extension metadata from the current history without
+// disturbing the metadata cache.
+func (p *TAGEPredictor) relookup(pc uint64, ctx uint8) ExtensionMetadata {
+	lastPC, lastCtx, last := p.LastPC, p.LastCtx, p.LastPrediction
+	p.predictWithHistory(pc, ctx, p.History[ctx], &p.LongHistory[ctx])
+	meta := p.LastPrediction.Ext
+	p.LastPC, p.LastCtx, p.LastPrediction = lastPC, lastCtx, last
+	return meta
+}
+
+// countOutcome scores each component that took part in the final prediction.
+func (p *TAGEPredictor) countOutcome(meta *ExtensionMetadata, taken bool) {
+	score := func(c Component, predicted, replaced bool) {
+		n := &p.extCounts[c]
+		n.used++
+		if predicted == taken {
+			n.correct++
+		}
+		if predicted != replaced {
+			n.overrides++
+			if predicted == taken {
+				n.overridesCorrect++
+			}
+		}
+	}
+	if meta.Long.Hit {
+		score(ComponentLongHistory, meta.Long.Predicted, meta.TablesPredicted)
+	}
+	if meta.SC.Override {
+		score(ComponentCorrector, meta.SC.Predicted, meta.TAGEPredicted)
+	}
+	if meta.Loop.Use {
+		score(ComponentLoop, meta.Loop.Predicted, meta.SCPredicted)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// RESET AND STATISTICS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// resetExtensions clears all extension state (not the enable flags).
+func (p *TAGEPredictor) resetExtensions() {
+	for t := range p.LongTables {
+		p.LongTables[t] = TAGETable{HistoryLen: LongHistoryLengths[t]}
+	}
+	p.Loop = LoopPredictor{}
+	p.Corrector.reset()
+	p.extCounts = [numComponents]componentCounts{}
+}
+
+// extensionStats fills in the extension fields of TAGEStats.
+func (p *TAGEPredictor) extensionStats(stats *TAGEStats) {
+	for t := range p.LongTables {
+		for _, w := range p.LongTables[t].ValidBits {
+			stats.LongEntriesUsed[t] += uint32(bits.OnesCount64(w))
+		}
+	}
+
+	component := func(c Component, enabled bool, storageBits, logic uint64) ComponentStats {
+		n := p.extCounts[c]
+		s := ComponentStats{
+			Enabled:          enabled,
+			Used:             n.used,
+			Correct:          n.correct,
+			Overrides:        n.overrides,
+			OverridesCorrect: n.overridesCorrect,
+			NetGain:          2*int64(n.overridesCorrect) - int64(n.overrides),
+			StorageBits:      storageBits,
+			Transistors:      storageBits*transistorsPerBit + logic,
+		}
+		if n.used > 0 {
+			s.Accuracy = float64(n.correct) / float64(n.used)
+		}
+		return s
+	}
+	stats.LongHistory = component(ComponentLongHistory, p.LongHistoryEnabled, longStorageBits, longLogicTransistors)
+	stats.Corrector = component(ComponentCorrector, p.SCEnabled, scStorageBits, scLogicTransistors)
+	stats.Loop = component(ComponentLoop, p.LoopEnabled, loopStorageBits, loopLogicTransistors)
+
+	stats.Transistors = TAGETransistors
+	for _, c := range []ComponentStats{stats.LongHistory, stats.Corrector, stats.Loop} {
+		if c.Enabled {
+			stats.Transistors += c.Transistors
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package tage
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE-SC-L Extensions - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Each extension exists for one kind of branch TAGE handles badly. The suite builds that
+// branch, shows the base predictor missing it and the extension catching it, and checks
+// the per-component statistics agree. Around that:
+//   1. Helpers: the folded history and the long shift register are exact
+//   2. Components: each extension on its target pattern
+//   3. Integration: disabled means untouched, speculative mode still matches in-order,
+//      reset and statistics
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. HELPER TESTS
+//    foldHistory against a bit-by-bit reference, shiftLongHistory carries
+//
+// 2. COMPONENT TESTS
+//    Long history on a distant correlation, corrector on a biased branch,
+//    loop predictor on a fixed trip count
+//
+// 3. INTEGRATION TESTS
+//    Disabled state, speculative equivalence, reset, statistics
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// extPredictor returns a predictor with the chosen extensions enabled
+func extPredictor(long, sc, loop bool) *TAGEPredictor {
+	pred := NewTAGEPredictor()
+	pred.LongHistoryEnabled = long
+	pred.SCEnabled = sc
+	pred.LoopEnabled = loop
+	return pred
+}
+
+// branchAccuracy scores one static branch's predictions, skipping the warmup
+func branchAccuracy(trace []traceBranch, predictions []bool, warmup int, pc uint64) float64 {
+	correct, total := 0, 0
+	for i := warmup; i < len(trace); i++ {
+		if trace[i].pc != pc {
+			continue
+		}
+		total++
+		if predictions[i] == trace[i].taken {
+			correct++
+		}
+	}
+	return float64(correct) / float64(total)
+}
+
+// distantTrace builds iterations of a random branch A, gap always-taken filler branches,
+// then B = A. B depends on a branch gap+1 positions back.
+func distantTrace(iterations, gap int, seed int64) (trace []traceBranch, pcB uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	pcA, pcFill := uint64(1)<<22|0x1000, uint64(2)<<22|0x2000
+	pcB = uint64(3)<<22 | 0x3000
+
+	for i := 0; i < iterations; i++ {
+		a := rng.Intn(2) == 0
+		trace = append(trace, traceBranch{pcA, a})
+		for j := 0; j < gap; j++ {
+			trace = append(trace, traceBranch{pcFill, true})
+		}
+		trace = append(trace, traceBranch{pcB, a})
+	}
+	return trace, pcB
+}
+
+// biasedTrace interleaves random branches from 64 PCs with one branch taken 85% of the
+// time regardless of path - every path TAGE sees for it is new noise.
+func biasedTrace(iterations int, seed int64) (trace []traceBranch, pcBiased uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	pcBiased = uint64(9)<<22 | 0x9000
+	for i := 0; i < iterations; i++ {
+		pc := uint64(rng.Intn(8)+1)<<22 | uint64(rng.Intn(8))<<12
+		trace = append(trace,
+			traceBranch{pc, rng.Intn(2) == 0},
+			traceBranch{pcBiased, rng.Intn(100) < 85})
+	}
+	return trace, pcBiased
+}
+
+// loopTrace builds trips of a loop branch taken `iterations` times, then not taken
+func loopTrace(trips, iterations int) []traceBranch {
+	pc := uint64(7)<<22 | 0x7000
+	trace := make([]traceBranch, 0, trips*(iterations+1))
+	for i := 0; i < trips; i++ {
+		for j := 0; j < iterations; j++ {
+			trace = append(trace, traceBranch{pc, true})
+		}
+		trace = append(trace, traceBranch{pc, false})
+	}
+	return trace
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. HELPER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// The long tables index with a fold of up to 256 history bits spread across two
+// registers. An off-by-one in either would still predict - just worse, and silently.
+//
+// Hardware: 192-bit shift register, folded history CSRs
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestExtensions_FoldHistoryMatchesReference(t *testing.T) {
+	// WHAT: foldHistory equals XOR over i < length of bit i placed at (i mod width)
+	// WHY: The fold is what the hardware CSR holds; the lookup and the model must agree
+	// HARDWARE: folded = rotl(folded, 1) ^ new ^ (out << (L mod W))
+
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		history := rng.Uint64()
+		long := LongHistory{rng.Uint64(), rng.Uint64(), rng.Uint64()}
+		bit := func(i int) uint32 {
+			if i < 64 {
+				return uint32(history>>i) & 1
+			}
+			return uint32(long[(i-64)/64]>>((i-64)%64)) & 1
+		}
+
+		for _, length := range []int{8, 32, 64, 100, 128, 200, 256} {
+			for _, width := range []int{IndexWidth, TagWidth} {
+				var want uint32
+				for i := 0; i < length; i++ {
+					want ^= bit(i) << (i % width)
+				}
+				if got := foldHistory(history, &long, length, width); got != want {
+					t.Fatalf("fold(length %d, width %d) = 0x%X, expected 0x%X", length, width, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestExtensions_LongHistoryShiftCarries(t *testing.T) {
+	// WHAT: History bit 63 moves into LongHistory bit 0, and each word's top bit into the
+	//       next word; bit 255 falls off
+	// WHY: The 256-bit history is one logical register split across two fields
+	// HARDWARE: history[63] → long_history[0], long_history[191] dropped
+
+	pred := NewTAGEPredictor()
+	pred.History[0] = 1 << 63
+	pred.LongHistory[0] = LongHistory{1 << 63, 1 << 63, 1 << 63}
+	pred.Update(0x1000, 0, false)
+
+	want := LongHistory{1, 1, 1}
+	if pred.LongHistory[0] != want {
+		t.Errorf("LongHistory = %X, expected %X", pred.LongHistory[0], want)
+	}
+	if pred.History[0] != 0 {
+		t.Errorf("History = 0x%X, expected 0", pred.History[0])
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. COMPONENT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// One pattern per extension, chosen so the base predictor is measurably wrong on it.
+// Accuracy is scored on the target branch alone after a warmup half.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestExtensions_LongHistoryDistantCorrelation(t *testing.T) {
+	// WHAT: A branch equal to one 100 branches earlier: the base predictor guesses, the
+	//       long tables predict it almost perfectly and are credited in Stats
+	// WHY: This is the correlation the 128/256-bit tables exist for
+	// HARDWARE: L0/L1 tagged tables with folded 128/256-bit history
+
+	trace, pcB := distantTrace(2000, 100, 1)
+	warmup := len(trace) / 2
+
+	base := branchAccuracy(trace, runPipeline(NewTAGEPredictor(), trace, 1, false), warmup, pcB)
+	pred := extPredictor(true, false, false)
+	long := branchAccuracy(trace, runPipeline(pred, trace, 1, false), warmup, pcB)
+	stats := pred.Stats()
+	t.Logf("Distant branch: base %.1f%%, long history %.1f%% (long entries %v, net gain %d)",
+		base*100, long*100, stats.LongEntriesUsed, stats.LongHistory.NetGain)
+
+	if long < 0.95 {
+		t.Errorf("Long history accuracy %.1f%%, expected ≥ 95%%", long*100)
+	}
+	if long < base+0.30 {
+		t.Errorf("Long history %.1f%% not clearly above base %.1f%%", long*100, base*100)
+	}
+	if stats.LongHistory.Used == 0 || stats.LongHistory.NetGain <= 0 {
+		t.Errorf("Long history stats %+v, expected use and a positive net gain", stats.LongHistory)
+	}
+}
+
+func TestExtensions_CorrectorLearnsBias(t *testing.T) {
+	// WHAT: An 85%-taken branch among random ones: the corrector lifts its accuracy and
+	//       its overrides are right more often than wrong
+	// WHY: Path-independent bias is TAGE's weak spot - every path is a fresh weak entry
+	// HARDWARE: GEHL weights + adaptive threshold
+
+	trace, pcBiased := biasedTrace(20000, 2)
+	warmup := len(trace) / 2
+
+	base := branchAccuracy(trace, runPipeline(NewTAGEPredictor(), trace, 1, false), warmup, pcBiased)
+	pred := extPredictor(false, true, false)
+	sc := branchAccuracy(trace, runPipeline(pred, trace, 1, false), warmup, pcBiased)
+	stats := pred.Stats()
+	t.Logf("Biased branch: base %.1f%%, corrector %.1f%% (overrides %d, right %d, threshold %d)",
+		base*100, sc*100, stats.Corrector.Overrides, stats.Corrector.OverridesCorrect, pred.Corrector.Threshold)
+
+	if sc < base+0.01 {
+		t.Errorf("Corrector %.1f%% not above base %.1f%%", sc*100, base*100)
+	}
+	if stats.Corrector.NetGain <= 0 {
+		t.Errorf("Corrector net gain %d, expected positive", stats.Corrector.NetGain)
+	}
+}
+
+func TestExtensions_LoopPredictsExits(t *testing.T) {
+	// WHAT: A loop with 100 iterations per trip: the base predictor misses every exit,
+	//       the loop predictor none after warmup
+	// WHY: A trip count longer than the history is invisible to every tagged table
+	// HARDWARE: Loop table iteration counters
+
+	trace := loopTrace(300, 100)
+	warmup := len(trace) / 2
+
+	base := accuracyAfter(trace, runPipeline(NewTAGEPredictor(), trace, 1, false), warmup)
+	pred := extPredictor(false, false, true)
+	loop := accuracyAfter(trace, runPipeline(pred, trace, 1, false), warmup)
+	stats := pred.Stats()
+	t.Logf("Loop: base %.2f%%, loop predictor %.2f%% (overrides %d, trust %d)",
+		base*100, loop*100, stats.Loop.Overrides, pred.Loop.Trust)
+
+	if loop != 1 {
+		t.Errorf("Loop predictor accuracy %.2f%%, expected 100%%", loop*100)
+	}
+	if base >= loop {
+		t.Errorf("Base %.2f%% already as good as the loop predictor", base*100)
+	}
+	if stats.Loop.NetGain <= 0 || stats.Loop.OverridesCorrect != stats.Loop.Overrides {
+		t.Errorf("Loop stats %+v, expected only correct overrides", stats.Loop)
+	}
+}
+
+func TestExtensions_LoopTrustLimitsPipelinedLoss(t *testing.T) {
+	// WHAT: With 8 branches in flight the non-speculative iteration counts run behind;
+	//       the trust counter keeps the loop predictor from costing accuracy
+	// WHY: PIPELINING in loop.go - the model has no speculative iteration counters
+	// HARDWARE: 7-bit loop_trust gating loop overrides
+
+	trace := loopTrace(300, 100)
+	warmup := len(trace) / 2
+
+	base := accuracyAfter(trace, runPipeline(NewTAGEPredictor(), trace, 8, true), warmup)
+	pred := extPredictor(false, false, true)
+	loop := accuracyAfter(trace, runPipeline(pred, trace, 8, true), warmup)
+	t.Logf("Depth 8: base %.2f%%, loop predictor %.2f%% (trust %d)", base*100, loop*100, pred.Loop.Trust)
+
+	if loop < base-0.005 {
+		t.Errorf("Loop predictor %.2f%% costs accuracy against base %.2f%%", loop*100, base*100)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. INTEGRATION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// The extensions are optional: off, they must leave no trace; on, they must compose with
+// speculative history, Reset, and Stats like the tagged tables do.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestExtensions_DisabledLeavesStateUntouched(t *testing.T) {
+	// WHAT: With every extension off, a full run leaves the extension tables, counters and
+	//       metadata at their initial values
+	// WHY: Off must mean the base predictor, bit for bit
+	// HARDWARE: Clock-gated extension arrays
+
+	pred := NewTAGEPredictor()
+	trace, _ := biasedTrace(5000, 3)
+	runPipeline(pred, trace, 1, false)
+	pred.Predict(0x1000, 0)
+
+	fresh := NewTAGEPredictor()
+	if pred.LongTables != fresh.LongTables || pred.Loop != fresh.Loop || pred.Corrector != fresh.Corrector {
+		t.Error("Extension state changed while disabled")
+	}
+	if pred.extCounts != fresh.extCounts {
+		t.Errorf("Extension counters %+v, expected zero", pred.extCounts)
+	}
+	if pred.LastPrediction.Ext != (ExtensionMetadata{}) {
+		t.Errorf("Extension metadata %+v recorded while disabled", pred.LastPrediction.Ext)
+	}
+}
+
+func TestExtensions_SpeculativeInOrderMatches(t *testing.T) {
+	// WHAT: With all extensions on, depth-1 speculative and non-speculative runs agree
+	//       prediction for prediction and end with identical state
+	// WHY: Checkpoints must carry the long history and extension metadata intact
+	// HARDWARE: Checkpoint register with the 192 extra history bits
+
+	trace, _ := distantTrace(500, 100, 4)
+	bias, _ := biasedTrace(3000, 5)
+	trace = append(trace, bias...)
+	trace = append(trace, loopTrace(50, 20)...)
+
+	base := extPredictor(true, true, true)
+	spec := extPredictor(true, true, true)
+	want := runPipeline(base, trace, 1, false)
+	got := runPipeline(spec, trace, 1, true)
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Prediction %d differs (speculative %v, in-order %v)", i, got[i], want[i])
+		}
+	}
+	if spec.Tables != base.Tables || spec.LongTables != base.LongTables {
+		t.Error("Tagged tables differ")
+	}
+	if spec.Loop != base.Loop || spec.Corrector != base.Corrector {
+		t.Error("Loop predictor or corrector differs")
+	}
+	if spec.LongHistory != base.LongHistory || spec.SpecLongHistory[0] != base.LongHistory[0] {
+		t.Error("Long history differs")
+	}
+	if spec.extCounts != base.extCounts {
+		t.Errorf("Component counts %+v, in-order %+v", spec.extCounts, base.extCounts)
+	}
+}
+
+func TestExtensions_ResetClearsState(t *testing.T) {
+	// WHAT: Reset clears the extension tables, histories and counters but keeps the
+	//       enable flags
+	// WHY: Reset is a cold predictor, not a reconfiguration
+	// HARDWARE: Extension arrays on the reset network; flags are configuration registers
+
+	pred := extPredictor(true, true, true)
+	trace, _ := distantTrace(300, 100, 6)
+	runPipeline(pred, trace, 4, true)
+	pred.Reset()
+
+	fresh := extPredictor(true, true, true)
+	if pred.LongTables != fresh.LongTables || pred.Loop != fresh.Loop || pred.Corrector != fresh.Corrector {
+		t.Error("Extension state survived Reset")
+	}
+	if pred.LongHistory != fresh.LongHistory || pred.SpecLongHistory != fresh.SpecLongHistory {
+		t.Error("Long history survived Reset")
+	}
+	if pred.extCounts != fresh.extCounts {
+		t.Error("Component counts survived Reset")
+	}
+	if !pred.LongHistoryEnabled || !pred.SCEnabled || !pred.LoopEnabled {
+		t.Error("Reset cleared the enable flags")
+	}
+}
+
+func TestExtensions_StatsTransistorBudget(t *testing.T) {
+	// WHAT: Stats reports each component's enable flag and cost, and Transistors totals
+	//       the base plus the enabled components only
+	// WHY: The point of the flags is to weigh accuracy against area
+	// HARDWARE: AREA BUDGET in tage.go, TRANSISTOR ESTIMATES in extensions.go
+
+	stats := NewTAGEPredictor().Stats()
+	if stats.Transistors != TAGETransistors {
+		t.Errorf("Base transistors %d, expected %d", stats.Transistors, TAGETransistors)
+	}
+	if stats.LongHistory.Enabled || stats.Corrector.Enabled || stats.Loop.Enabled {
+		t.Error("Extensions reported enabled by default")
+	}
+
+	for _, cfg := range [][3]bool{{true, false, false}, {false, true, true}, {true, true, true}} {
+		stats := extPredictor(cfg[0], cfg[1], cfg[2]).Stats()
+		want := uint64(TAGETransistors)
+		for _, c := range []ComponentStats{stats.LongHistory, stats.Corrector, stats.Loop} {
+			if c.Transistors < c.StorageBits*transistorsPerBit {
+				t.Errorf("Component %+v: transistors below storage cost", c)
+			}
+			if c.Enabled {
+				want += c.Transistors
+			}
+		}
+		if stats.Transistors != want {
+			t.Errorf("Config %v: transistors %d, expected %d", cfg, stats.Transistors, want)
+		}
+		if stats.LongHistory.Enabled != cfg[0] || stats.Corrector.Enabled != cfg[1] || stats.Loop.Enabled != cfg[2] {
+			t.Errorf("Config %v: enable flags not reported", cfg)
+		}
+		t.Logf("Config %v: %dK transistors", cfg, stats.Transistors/1000)
+	}
+}
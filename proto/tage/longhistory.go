@@ -0,0 +1,220 @@
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE Branch Predictor - Long-History Tables (TAGE-SC-L extension)
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// Tables 1-7 see at most 64 branches back. A branch that depends on something decided
+// 100 branches earlier - a flag set before a long loop, a mode chosen at the top of a big
+// function - looks random to all of them. TAGE-SC-L reaches hundreds of branches back;
+// this extension adds two more tagged tables continuing the geometric series:
+//
+//   Table   History   Index / tag
+//   L0      128 bits  PC ⊕ fold(history[127:0])
+//   L1      256 bits  PC ⊕ fold(history[255:0])
+//
+// A long-table hit is a longer match than any of Tables 1-7, so it is the provider.
+//
+// FOLDED HISTORY:
+// ───────────────
+// A 256-bit history cannot be hashed in one cycle, so hardware keeps it pre-folded: a
+// width-W register where history bit i lands on bit (i mod W). Each new branch updates it
+// in O(1) - rotate in the new bit, XOR out the bit leaving the window:
+//
+//   folded = rotl(folded, 1) ^ new_bit ^ (outgoing_bit << (L mod W))
+//
+// foldHistory computes the same value directly from the bits, which is what the model
+// needs when it rebuilds a lookup from a checkpoint.
+//
+// HISTORY STORAGE:
+// ────────────────
+// History[ctx] stays the newest 64 bits; LongHistory[ctx] holds bits 64-255, fed by the
+// bit shifted out of History[ctx]. Every shift of History - architectural or speculative -
+// shifts LongHistory with it, enabled or not, so turning the tables on mid-run sees a
+// correct history immediately.
+//
+// ALLOCATION:
+// ───────────
+//   Tables 5-7 mispredict (the +1..+3 window runs past Table 7) → allocate L0
+//   L0 mispredicts with a weak counter                            → allocate L1
+//
+// Only the preferred slot is ever written: lookups read nothing else. A useful entry there
+// is kept, and aging clears its useful bit eventually.
+//
+// Hardware: ~346K transistors (2 × 1024 × 24-bit SRAM, 2 index + 2 tag folders,
+//           192 extra history bits per context, architectural + speculative)
+//
+// SystemVerilog equivalent:
+//   logic [191:0] long_history [0:7];       // bits 64-255
+//   tage_table_t  long_tables  [0:1];
+//   logic [9:0]   fold_idx     [0:1][0:7];  // per-context folded CSRs
+//   logic [12:0]  fold_tag     [0:1][0:7];
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+package tage
+
+const (
+	// LongHistoryLength: Total global history bits with the extension.
+	// Hardware: 64-bit History register + 192-bit LongHistory register per context.
+	LongHistoryLength = 256
+
+	// LongHistoryWords: 64-bit words holding history bits 64-255.
+	LongHistoryWords = (LongHistoryLength - HistoryWidth) / 64 // 3
+
+	// NumLongTables: Tagged tables beyond Table 7.
+	NumLongTables = 2
+)
+
+// LongHistoryLengths continues HistoryLengths past 64 bits.
+var LongHistoryLengths = [NumLongTables]int{128, 256}
+
+// LongHistory holds global history bits 64-255 (word 0 bit 0 = history bit 64).
+type LongHistory [LongHistoryWords]uint64
+
+// LongLookup is the long-table lookup result carried from predict to update.
+type LongLookup struct {
+	Hit        bool                  // Some long table matched
+	Table      int                   // Matching table (longest), -1 = none
+	Predicted  bool                  // Its direction
+	Confidence uint8                 // 1 = medium, 2 = saturated counter
+	Indices    [NumLongTables]uint32 // Index in each long table
+	Tags       [NumLongTables]uint16 // Tag for each long table
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// HISTORY HELPERS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// shiftLongHistory moves the bit about to leave history (bit 63) into long's bit 0.
+// Call it before shifting history itself.
+// Hardware: 192-bit shift register chained to history[63]
+func shiftLongHistory(history uint64, long *LongHistory) {
+	carry := history >> 63
+	for w := range long {
+		out := long[w] >> 63
+		long[w] = long[w]<<1 | carry
+		carry = out
+	}
+}
+
+// foldHistory XOR-folds history bits [0, length) into width bits: bit i lands on bit
+// (i mod width). long may be nil when length ≤ 64.
+// Hardware: The folded CSR value (see FOLDED HISTORY above)
+func foldHistory(history uint64, long *LongHistory, length int, width int) uint32 {
+	mask := uint64(1)<<width - 1
+	var folded uint64
+	for w := 0; w*64 < length; w++ {
+		word := history
+		if w > 0 {
+			word = long[w-1]
+		}
+		if rem := length - w*64; rem < 64 {
+			word &= uint64(1)<<rem - 1
+		}
+
+		// Fold the word onto itself, then rotate by where its bit 0 falls (64w mod width)
+		var f uint64
+		for ; word != 0; word >>= width {
+			f ^= word & mask
+		}
+		r := (64 * w) % width
+		folded ^= (f<<r | f>>(width-r)) & mask
+	}
+	return uint32(folded)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// LOOKUP
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Unlike Tables 1-7, the tag includes a history fold: with 256 bits of history, many paths
+// share an index, and a PC-only tag would let them hit each other's entries.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func (p *TAGEPredictor) longLookup(pc uint64, ctx uint8, history uint64, long *LongHistory) LongLookup {
+	l := LongLookup{Table: -1}
+	for t := 0; t < NumLongTables; t++ {
+		length := LongHistoryLengths[t]
+		pcBits := hashPC(pc, NumTables+t, IndexWidth)
+		l.Indices[t] = pcBits ^ foldHistory(history, long, length, IndexWidth)
+		l.Tags[t] = (hashTag(pc) ^ uint16(foldHistory(history, long, length, TagWidth))) & TagMask
+	}
+
+	// Longest match first
+	for t := NumLongTables - 1; t >= 0; t-- {
+		table := &p.LongTables[t]
+		idx := l.Indices[t]
+		if (table.ValidBits[idx>>6]>>(idx&63))&1 == 0 {
+			continue
+		}
+		entry := &table.Entries[idx]
+		if entry.Tag != l.Tags[t] || entry.Context != ctx {
+			continue
+		}
+
+		l.Hit = true
+		l.Table = t
+		l.Predicted = entry.Counter >= TakenThreshold
+		l.Confidence = 1
+		if entry.Counter <= 1 || entry.Counter >= MaxCounter-1 {
+			l.Confidence = 2
+		}
+		break
+	}
+	return l
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TRAINING
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func (p *TAGEPredictor) longTrain(ctx uint8, meta *ExtensionMetadata, taken bool) {
+	l := &meta.Long
+	if l.Hit {
+		entry := &p.LongTables[l.Table].Entries[l.Indices[l.Table]]
+		if entry.Tag != l.Tags[l.Table] || entry.Context != ctx {
+			return // Replaced since the lookup
+		}
+		updateCounterWithHysteresis(entry, taken)
+		entry.Taken = taken
+		if l.Predicted == taken {
+			entry.Useful = true
+			return
+		}
+		entry.Useful = false
+		entry.Age = 0
+		if shouldAllocate(entry.Counter) && l.Table+1 < NumLongTables {
+			p.allocateLong(l.Table+1, l, ctx, taken)
+		}
+		return
+	}
+
+	// The tagged tables missed with nowhere longer left to allocate
+	if meta.TablesPredicted != taken && meta.TablesProvider+3 >= NumTables {
+		p.allocateLong(0, l, ctx, taken)
+	}
+}
+
+// allocateLong writes a new entry at the lookup's preferred slot of long table t.
+func (p *TAGEPredictor) allocateLong(t int, l *LongLookup, ctx uint8, taken bool) {
+	table := &p.LongTables[t]
+	idx := l.Indices[t]
+	valid := (table.ValidBits[idx>>6]>>(idx&63))&1 != 0
+	if valid && table.Entries[idx].Useful {
+		return // Keep a useful entry; aging will release it
+	}
+
+	counter := uint8(NeutralCounter - 1)
+	if taken {
+		counter = NeutralCounter + 1
+	}
+	table.Entries[idx] = TAGEEntry{
+		Tag:     l.Tags[t],
+		Context: ctx,
+		Counter: counter,
+		Taken:   taken,
+	}
+	table.ValidBits[idx>>6] |= 1 << (idx & 63)
+}
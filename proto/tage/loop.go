@@ -0,0 +1,196 @@
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE Branch Predictor - Loop Predictor (TAGE-SC-L extension)
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// A loop branch with a fixed trip count N is taken N times and then falls through. TAGE
+// only sees the exit coming if the previous exit is still in its history - beyond 64
+// iterations (or fewer, with other branches in the body) it mispredicts every exit. A
+// counter per loop sees it trivially: count the iterations, and predict the exit when the
+// count reaches the last trip's.
+//
+// ENTRY (64 entries, direct-mapped, 40 bits each):
+// ──────────────────────────────────────────────────
+//   Tag          10 bits   PC tag
+//   Context       3 bits   Hardware context (isolation, as in the tagged tables)
+//   PastIter     10 bits   Iterations in the last complete trip (exit included)
+//   CurrentIter  10 bits   Iterations so far in this trip
+//   Confidence    2 bits   Consecutive trips with the same count (3 = predict)
+//   Age           3 bits   Replacement protection
+//   Dir           1 bit    Direction of a non-final iteration (usually taken)
+//   Valid         1 bit
+//
+// PREDICTION:
+// ───────────
+//   Hit and Confidence == 3:  CurrentIter + 1 == PastIter ? !Dir : Dir
+//   Used only while Trust ≥ 0 (a 7-bit counter of wins minus losses against the rest of
+//   the predictor), so a predictor that does not fit the program turns itself off.
+//
+// TRAINING (every resolved branch that hit):
+// ──────────────────────────────────────────
+//   Confident and wrong         → free the entry (the loop changed)
+//   CurrentIter++
+//   Outcome != Dir (an exit)    → same count as last trip: Confidence++
+//                                 different: PastIter = CurrentIter, Confidence = 0
+//                                 CurrentIter = 0
+//
+// ALLOCATION:
+// ───────────
+// A branch the rest of the predictor got wrong that missed here takes the slot if its Age
+// is 0 (otherwise Age--). The mispredicted outcome is assumed to be the exit, so Dir is
+// its opposite and counting starts from zero.
+//
+// PIPELINING:
+// ───────────
+// Iteration counts advance at Update/Commit. A front end with several iterations of the
+// same loop in flight would also need speculative iteration counters; the model does not
+// have them, and the trust counter switches the loop predictor off if that costs accuracy.
+//
+// Hardware: ~25K transistors (64 × 40-bit entries + 10-bit incrementers/comparator)
+//
+// SystemVerilog equivalent:
+//   typedef struct packed {
+//     logic [9:0] tag;
+//     logic [2:0] context;
+//     logic [9:0] past_iter, current_iter;
+//     logic [1:0] confidence;
+//     logic [2:0] age;
+//     logic       dir, valid;
+//   } loop_entry_t;
+//
+//   loop_entry_t       loop_table [0:63];
+//   logic signed [6:0] loop_trust;
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+package tage
+
+const (
+	LoopIndexBits     = 6
+	LoopEntries       = 1 << LoopIndexBits // 64
+	LoopTagBits       = 10
+	LoopIterBits      = 10
+	MaxLoopIter       = 1<<LoopIterBits - 1 // 1023
+	LoopConfidenceMax = 3
+	LoopAgeMax        = 7
+	LoopTrustMax      = 63
+	LoopTrustMin      = -64
+
+	// LoopEntryWidth: 10 + 3 + 10 + 10 + 2 + 3 + 1 + 1 = 40 bits
+	LoopEntryWidth = LoopTagBits + ContextWidth + 2*LoopIterBits + 2 + 3 + 1 + 1
+)
+
+// LoopEntry tracks one loop branch's trip count.
+type LoopEntry struct {
+	Tag         uint16
+	Context     uint8
+	PastIter    uint16
+	CurrentIter uint16
+	Confidence  uint8
+	Age         uint8
+	Dir         bool
+	Valid       bool
+}
+
+// LoopPredictor holds the loop table and its trust counter.
+type LoopPredictor struct {
+	Entries [LoopEntries]LoopEntry
+	Trust   int8 // ≥ 0: confident loop predictions are used
+}
+
+// LoopLookup is the loop lookup result carried from predict to update.
+type LoopLookup struct {
+	Hit       bool   // Tag and context matched
+	Index     uint32 // Table slot
+	Tag       uint16 // Tag for the slot
+	Confident bool   // Confidence saturated
+	Predicted bool   // Direction from the iteration count
+	Use       bool   // Confident and trusted: overrides the rest
+}
+
+//go:inline
+func loopIndex(pc uint64) uint32 {
+	return hashPC(pc, LoopIndexSalt, LoopIndexBits)
+}
+
+//go:inline
+func loopTag(pc uint64) uint16 {
+	return uint16(hashPC(pc, LoopTagSalt, LoopTagBits))
+}
+
+// lookup reads the loop entry for pc (no state change).
+func (lp *LoopPredictor) lookup(pc uint64, ctx uint8) LoopLookup {
+	l := LoopLookup{Index: loopIndex(pc), Tag: loopTag(pc)}
+	e := &lp.Entries[l.Index]
+	if !e.Valid || e.Tag != l.Tag || e.Context != ctx {
+		return l
+	}
+
+	l.Hit = true
+	l.Confident = e.Confidence == LoopConfidenceMax
+	l.Predicted = e.Dir
+	if e.CurrentIter+1 == e.PastIter {
+		l.Predicted = !e.Dir // Last iteration: exit
+	}
+	l.Use = l.Confident && lp.Trust >= 0
+	return l
+}
+
+// train advances the loop state with a resolved outcome. before is the prediction the
+// loop predictor would have overridden (TAGE, after the corrector).
+func (lp *LoopPredictor) train(ctx uint8, l LoopLookup, before bool, taken bool) {
+	e := &lp.Entries[l.Index]
+
+	if !l.Hit {
+		// STEP 1: Allocation candidates are branches the rest got wrong
+		if before == taken {
+			return
+		}
+		if e.Valid && e.Age > 0 {
+			e.Age--
+			return
+		}
+		*e = LoopEntry{Tag: l.Tag, Context: ctx, Dir: !taken, Age: LoopAgeMax, Valid: true}
+		return
+	}
+	if !e.Valid || e.Tag != l.Tag || e.Context != ctx {
+		return // Replaced since the lookup
+	}
+
+	// STEP 2: Score a confident prediction
+	if l.Confident {
+		if l.Predicted != before {
+			if l.Predicted == taken && lp.Trust < LoopTrustMax {
+				lp.Trust++
+			} else if l.Predicted != taken && lp.Trust > LoopTrustMin {
+				lp.Trust--
+			}
+		}
+		if l.Predicted != taken {
+			*e = LoopEntry{} // The loop changed
+			return
+		}
+		if before != taken && e.Age < LoopAgeMax {
+			e.Age++
+		}
+	}
+
+	// STEP 3: Count the iteration
+	e.CurrentIter++
+	if e.CurrentIter > MaxLoopIter {
+		*e = LoopEntry{} // Too long to track
+		return
+	}
+	if taken != e.Dir {
+		if e.CurrentIter == e.PastIter {
+			if e.Confidence < LoopConfidenceMax {
+				e.Confidence++
+			}
+		} else {
+			e.PastIter = e.CurrentIter
+			e.Confidence = 0
+		}
+		e.CurrentIter = 0
+	}
+}
@@ -0,0 +1,592 @@
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Branch Predictor Alternatives - Perceptron and Hashed Perceptron
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// TAGE SIZING FOR SUPRAX (tage.go) argues for TAGE. An argument needs the alternatives
+// measured under the same harness, so this file models the other family that wins branch
+// prediction championships: neural predictors. Both implement DirectionPredictor, the
+// method set TAGEPredictor already has, so any stream can be run through all three.
+//
+// PERCEPTRON (Jiménez & Lin, 2001):
+// ─────────────────────────────────
+// One perceptron per PC slot: a bias weight plus one signed weight per history bit.
+//
+//   x_i = +1 if history bit i is taken, -1 if not
+//   y   = w_0 + Σ w_i · x_i             (i = 1..h)
+//   predict taken when y ≥ 0
+//
+//   Train when wrong or |y| ≤ θ:  w_i += x_i · (taken ? +1 : -1), saturating
+//   θ = ⌊1.93·h + 14⌋ (the paper's fit)
+//
+// Strength: cost grows linearly with history, so h can be long. Weakness: it can only
+// learn outcomes that are LINEAR in the history bits - a branch that is the XOR of two
+// earlier ones is invisible to it. TAGE, matching whole paths, has no such limit.
+//
+// HASHED PERCEPTRON (Tarjan & Skadron, 2005; GEHL-style):
+// ────────────────────────────────────────────────────────
+// Replace "one weight per history bit" with "one weight per (PC, history segment) hash":
+//
+//   Table t is indexed by PC ⊕ fold(history[L_t-1:0]); y = Σ_t W_t[index_t]
+//
+// Each table's weight stands for a whole path of length L_t, so non-linear functions of
+// the history are learnable, and the adder has one input per table instead of one per
+// bit. The threshold adapts at run time (O-GEHL's rule, as in corrector.go).
+//
+// CONTEXT ISOLATION:
+// ──────────────────
+// Weights are untagged, so the context is hashed into every index and each context has
+// its own history register. As with the statistical corrector, that separates contexts
+// statistically, not with the hard tag match the TAGE tables use.
+//
+// CONFIGURATION:
+// ──────────────
+// History lengths, weight widths, and table sizes are run-time configuration so the same
+// harness can sweep them. The defaults spend the same storage as the TAGE tables
+// (8 × 1024 × 24 = 196,608 bits):
+//
+//   Perceptron         512 perceptrons × (48 + 1) weights × 8 bits  = 200,704 bits
+//   Hashed perceptron  16 tables × 2048 weights × 6 bits            = 196,608 bits
+//
+// Hardware: (defaults, Stats().Transistors)
+//   Perceptron:         ~1.24M transistors (1.21M weights + history, 38K adder tree and
+//                       weight incrementers)
+//   Hashed perceptron:  ~1.21M transistors (1.20M weights, history and folded registers,
+//                       10K adder tree and incrementers)
+//   TAGE for reference: ~1.34M (TAGETransistors)
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+package tage
+
+import "math/bits"
+
+// DirectionPredictor is the interface TAGEPredictor and its alternatives share: predict
+// a conditional branch, then report its outcome through Update (prediction was right) or
+// OnMispredict (it was wrong).
+type DirectionPredictor interface {
+	Predict(pc uint64, ctx uint8) (taken bool, confidence uint8)
+	Update(pc uint64, ctx uint8, taken bool)
+	OnMispredict(pc uint64, ctx uint8, actualTaken bool)
+	Reset()
+}
+
+var (
+	_ DirectionPredictor = (*TAGEPredictor)(nil)
+	_ DirectionPredictor = (*PerceptronPredictor)(nil)
+	_ DirectionPredictor = (*HashedPerceptronPredictor)(nil)
+)
+
+const (
+	// PerceptronMaxHistory: Longest configurable history (History + LongHistory).
+	PerceptronMaxHistory = LongHistoryLength
+
+	// PerceptronMaxWeightBits: Widest configurable weight (int16 storage).
+	PerceptronMaxWeightBits = 16
+
+	// PerceptronMaxIndexBits: Largest configurable table (64K rows).
+	PerceptronMaxIndexBits = 16
+
+	// fullAdderTransistors: Mirror-adder cell used for the adder-tree estimates.
+	fullAdderTransistors = 28
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// CONFIGURATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// PerceptronConfig sizes a PerceptronPredictor. Out-of-range fields are clamped.
+type PerceptronConfig struct {
+	HistoryLength int // History bits each perceptron weighs (1-256)
+	WeightBits    int // Signed weight width (2-16)
+	IndexBits     int // log2 of the number of perceptrons (1-16)
+	Threshold     int // Training threshold θ; 0 = ⌊1.93·h + 14⌋
+}
+
+// DefaultPerceptronConfig spends the TAGE tables' storage.
+func DefaultPerceptronConfig() PerceptronConfig {
+	return PerceptronConfig{HistoryLength: 48, WeightBits: 8, IndexBits: 9}
+}
+
+// HashedPerceptronConfig sizes a HashedPerceptronPredictor. Out-of-range fields are
+// clamped.
+type HashedPerceptronConfig struct {
+	HistoryLengths []int // One weight table per length (0 = PC only), each ≤ 256
+	WeightBits     int   // Signed weight width (2-16)
+	IndexBits      int   // log2 of the weights per table (1-16)
+	Threshold      int   // Initial training threshold; 0 = number of tables
+}
+
+// DefaultHashedPerceptronConfig spends the TAGE tables' storage.
+func DefaultHashedPerceptronConfig() HashedPerceptronConfig {
+	return HashedPerceptronConfig{
+		HistoryLengths: []int{0, 2, 4, 6, 9, 13, 18, 25, 34, 46, 62, 84, 113, 152, 205, 256},
+		WeightBits:     6,
+		IndexBits:      11,
+	}
+}
+
+// clampInt limits v to [lo, hi].
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
+// normalized returns c with every field in range and the default threshold filled in.
+func (c PerceptronConfig) normalized() PerceptronConfig {
+	c.HistoryLength = clampInt(c.HistoryLength, 1, PerceptronMaxHistory)
+	c.WeightBits = clampInt(c.WeightBits, 2, PerceptronMaxWeightBits)
+	c.IndexBits = clampInt(c.IndexBits, 1, PerceptronMaxIndexBits)
+	if c.Threshold <= 0 {
+		c.Threshold = int(1.93*float64(c.HistoryLength) + 14)
+	}
+	return c
+}
+
+// normalized returns c with every field in range; the lengths are copied.
+func (c HashedPerceptronConfig) normalized() HashedPerceptronConfig {
+	if len(c.HistoryLengths) == 0 {
+		c.HistoryLengths = DefaultHashedPerceptronConfig().HistoryLengths
+	}
+	lengths := make([]int, len(c.HistoryLengths))
+	for t, l := range c.HistoryLengths {
+		lengths[t] = clampInt(l, 0, PerceptronMaxHistory)
+	}
+	c.HistoryLengths = lengths
+	c.WeightBits = clampInt(c.WeightBits, 2, PerceptronMaxWeightBits)
+	c.IndexBits = clampInt(c.IndexBits, 1, PerceptronMaxIndexBits)
+	if c.Threshold <= 0 {
+		c.Threshold = len(lengths)
+	}
+	return c
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SHARED HELPERS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// historyBit returns global history bit i (0 = newest) from the 256-bit register pair.
+//
+//go:inline
+func historyBit(history uint64, long *LongHistory, i int) bool {
+	if i < HistoryWidth {
+		return (history>>i)&1 != 0
+	}
+	i -= HistoryWidth
+	return (long[i>>6]>>(i&63))&1 != 0
+}
+
+// neuralIndex hashes PC and context into a table index; callers XOR in history.
+//
+//go:inline
+func neuralIndex(pc uint64, ctx uint8, indexBits int) uint32 {
+	pcBits := hashPC(pc, NeuralSalt, indexBits)
+	ctxBits := uint32(ctx) << max(indexBits-ContextWidth, 0)
+	return pcBits ^ ctxBits
+}
+
+// saturatingStep moves w one step toward the outcome within [lo, hi].
+//
+//go:inline
+func saturatingStep(w *int16, up bool, lo, hi int16) {
+	if up && *w < hi {
+		*w++
+	} else if !up && *w > lo {
+		*w--
+	}
+}
+
+// neuralConfidence maps |sum| against the training threshold to TAGE's 0-2 scale:
+// beyond θ (training leaves it alone) = 2, beyond θ/2 = 1, else 0.
+//
+//go:inline
+func neuralConfidence(sum, threshold int32) uint8 {
+	switch a := abs32(sum); {
+	case a > threshold:
+		return 2
+	case a > threshold/2:
+		return 1
+	}
+	return 0
+}
+
+// weightLimits returns the signed range of a width-bit weight.
+func weightLimits(width int) (lo, hi int16) {
+	return int16(-1 << (width - 1)), int16(1<<(width-1) - 1)
+}
+
+// countSaturated counts weights sitting at either limit.
+func countSaturated(weights []int16, lo, hi int16) uint64 {
+	var n uint64
+	for _, w := range weights {
+		if w == lo || w == hi {
+			n++
+		}
+	}
+	return n
+}
+
+// NeuralStats is debug information for either neural predictor.
+// NOT synthesized - for simulation/testing only.
+type NeuralStats struct {
+	BranchCount      uint64  // Branches trained
+	Tables           int     // Perceptrons (classic) or weight tables (hashed)
+	Weights          uint64  // Total weights
+	WeightBits       int     // Width of each weight
+	Threshold        int32   // Current training threshold
+	SaturatedWeights float64 // Fraction of weights at a limit (too narrow if high)
+	StorageBits      uint64  // Weights + history registers
+	Transistors      uint64  // Estimate (6T per state bit + adder tree)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// PERCEPTRON PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// ALGORITHM (Predict):
+//   1. row = Weights[index(pc, ctx)]       (bias + h history weights)
+//   2. y = row[0] + Σ (bit_i ? row[i+1] : -row[i+1])
+//   3. Predict y ≥ 0; cache the lookup for training
+//
+// ALGORITHM (Update / OnMispredict):
+//   1. Reuse the cached lookup if it is for this PC and context, else look up again
+//   2. Wrong or |y| ≤ θ: every weight steps toward agreement with the outcome
+//   3. Shift the outcome into the context's history
+//
+// The perceptron rule trains on its own error, so Update and OnMispredict do the same
+// thing; both exist so the predictor drops in wherever TAGE is used.
+//
+// Hardware: (h+1)-input adder tree of weight-width operands, one row read per predict
+//
+// SystemVerilog equivalent:
+//   logic signed [W-1:0] weights [0:N-1][0:H];
+//   logic [H-1:0]        history [0:7];
+//
+//   always_comb begin
+//     y = weights[idx][0];
+//     for (int i = 0; i < H; i++)
+//       y += history[ctx][i] ? weights[idx][i+1] : -weights[idx][i+1];
+//     taken = (y >= 0);
+//   end
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// perceptronLookup is one prediction's state carried to training.
+type perceptronLookup struct {
+	row       uint32
+	sum       int32
+	predicted bool
+}
+
+// PerceptronPredictor is the classic global-history perceptron predictor.
+type PerceptronPredictor struct {
+	Config      PerceptronConfig         // Normalized configuration
+	Weights     []int16                  // Rows of 1 + HistoryLength weights, bias first
+	History     [NumContexts]uint64      // History bits 0-63 per context
+	LongHistory [NumContexts]LongHistory // History bits 64-255 per context
+	BranchCount uint64                   // Branches trained
+
+	wmin, wmax int16
+	lastPC     uint64
+	lastCtx    uint8
+	lastValid  bool
+	last       perceptronLookup
+}
+
+// NewPerceptronPredictor creates a perceptron predictor with zero weights.
+func NewPerceptronPredictor(cfg PerceptronConfig) *PerceptronPredictor {
+	cfg = cfg.normalized()
+	p := &PerceptronPredictor{
+		Config:  cfg,
+		Weights: make([]int16, (1<<cfg.IndexBits)*(cfg.HistoryLength+1)),
+	}
+	p.wmin, p.wmax = weightLimits(cfg.WeightBits)
+	return p
+}
+
+// lookup computes the perceptron output for pc in ctx (no state change).
+func (p *PerceptronPredictor) lookup(pc uint64, ctx uint8) perceptronLookup {
+	h := p.Config.HistoryLength
+	idx := neuralIndex(pc, ctx, p.Config.IndexBits) & (1<<p.Config.IndexBits - 1)
+	row := p.Weights[int(idx)*(h+1) : int(idx+1)*(h+1)]
+
+	// Wire: y = w0 + Σ ±w_i (adder tree)
+	sum := int32(row[0])
+	for i := 0; i < h; i++ {
+		if historyBit(p.History[ctx], &p.LongHistory[ctx], i) {
+			sum += int32(row[i+1])
+		} else {
+			sum -= int32(row[i+1])
+		}
+	}
+	return perceptronLookup{row: idx, sum: sum, predicted: sum >= 0}
+}
+
+func (p *PerceptronPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence uint8) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+	l := p.lookup(pc, ctx)
+	p.lastPC, p.lastCtx, p.lastValid, p.last = pc, ctx, true, l
+	return l.predicted, neuralConfidence(l.sum, int32(p.Config.Threshold))
+}
+
+func (p *PerceptronPredictor) Update(pc uint64, ctx uint8, taken bool) {
+	p.train(pc, ctx, taken)
+}
+
+func (p *PerceptronPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
+	p.train(pc, ctx, actualTaken)
+}
+
+// train applies the perceptron rule and shifts the outcome into the history.
+func (p *PerceptronPredictor) train(pc uint64, ctx uint8, taken bool) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+
+	// STEP 1: The lookup this outcome belongs to
+	l := p.last
+	if !p.lastValid || p.lastPC != pc || p.lastCtx != ctx {
+		l = p.lookup(pc, ctx)
+	}
+	p.lastValid = false
+
+	// STEP 2: Train on a miss or a low-margin hit
+	if l.predicted != taken || abs32(l.sum) <= int32(p.Config.Threshold) {
+		h := p.Config.HistoryLength
+		row := p.Weights[int(l.row)*(h+1) : int(l.row+1)*(h+1)]
+		saturatingStep(&row[0], taken, p.wmin, p.wmax)
+		for i := 0; i < h; i++ {
+			// x_i · t: agree with the outcome when the history bit matches it
+			agree := historyBit(p.History[ctx], &p.LongHistory[ctx], i) == taken
+			saturatingStep(&row[i+1], agree, p.wmin, p.wmax)
+		}
+	}
+
+	// STEP 3: Shift history
+	shiftLongHistory(p.History[ctx], &p.LongHistory[ctx])
+	p.History[ctx] = p.History[ctx]<<1 | takenBit(taken)
+	p.BranchCount++
+}
+
+// Reset clears the weights and histories; the configuration is kept.
+func (p *PerceptronPredictor) Reset() {
+	clear(p.Weights)
+	p.History = [NumContexts]uint64{}
+	p.LongHistory = [NumContexts]LongHistory{}
+	p.BranchCount = 0
+	p.lastValid = false
+}
+
+// Stats reports occupancy and the cost estimate.
+func (p *PerceptronPredictor) Stats() NeuralStats {
+	c := p.Config
+	s := NeuralStats{
+		BranchCount: p.BranchCount,
+		Tables:      1 << c.IndexBits,
+		Weights:     uint64(len(p.Weights)),
+		WeightBits:  c.WeightBits,
+		Threshold:   int32(c.Threshold),
+	}
+	s.SaturatedWeights = float64(countSaturated(p.Weights, p.wmin, p.wmax)) / float64(s.Weights)
+	s.StorageBits = s.Weights*uint64(c.WeightBits) + NumContexts*uint64(c.HistoryLength)
+
+	// Adder tree: h full-adder rows of (W + log2 h) bits; the same again for the
+	// (h+1) weight incrementers on the training path
+	width := uint64(c.WeightBits + bits.Len(uint(c.HistoryLength)))
+	s.Transistors = s.StorageBits*transistorsPerBit + 2*uint64(c.HistoryLength)*width*fullAdderTransistors
+	return s
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// HASHED PERCEPTRON PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// ALGORITHM (Predict):
+//   1. For each table t: index_t = PC ⊕ ctx ⊕ fold(history[L_t-1:0]) ⊕ t·0x9E5
+//   2. y = Σ W_t[index_t]
+//   3. Predict y ≥ 0; cache the indices for training
+//
+// ALGORITHM (Update / OnMispredict):
+//   1. Reuse the cached lookup if it is for this PC and context, else look up again
+//   2. Wrong or |y| ≤ θ: every selected weight steps toward the outcome
+//   3. Adapt θ (O-GEHL): wrong bumps a counter up, correct-but-unsure bumps it down;
+//      at either end θ moves by one
+//   4. Shift the outcome into the context's history
+//
+// Hardware: T-input adder tree, T folded-history registers per context
+//
+// SystemVerilog equivalent:
+//   logic signed [W-1:0] weights [0:T-1][0:(1<<B)-1];
+//
+//   always_comb begin
+//     y = 0;
+//     for (int t = 0; t < T; t++) y += weights[t][pc_hash ^ fold[t][ctx] ^ t*12'h9E5];
+//     taken = (y >= 0);
+//   end
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// hashedLookup is one prediction's state carried to training.
+type hashedLookup struct {
+	indices   []uint32
+	sum       int32
+	predicted bool
+}
+
+// HashedPerceptronPredictor sums one weight per (PC, history segment) hash.
+type HashedPerceptronPredictor struct {
+	Config      HashedPerceptronConfig   // Normalized configuration
+	Weights     [][]int16                // [table][index]
+	History     [NumContexts]uint64      // History bits 0-63 per context
+	LongHistory [NumContexts]LongHistory // History bits 64-255 per context
+	Threshold   int32                    // Current training threshold
+	BranchCount uint64                   // Branches trained
+
+	thresholdCtr int8
+	wmin, wmax   int16
+	lastPC       uint64
+	lastCtx      uint8
+	lastValid    bool
+	last         hashedLookup
+}
+
+// NewHashedPerceptronPredictor creates a hashed perceptron with zero weights.
+func NewHashedPerceptronPredictor(cfg HashedPerceptronConfig) *HashedPerceptronPredictor {
+	cfg = cfg.normalized()
+	p := &HashedPerceptronPredictor{
+		Config:    cfg,
+		Weights:   make([][]int16, len(cfg.HistoryLengths)),
+		Threshold: int32(cfg.Threshold),
+	}
+	for t := range p.Weights {
+		p.Weights[t] = make([]int16, 1<<cfg.IndexBits)
+	}
+	p.wmin, p.wmax = weightLimits(cfg.WeightBits)
+	return p
+}
+
+// lookup sums the selected weights for pc in ctx (no state change).
+func (p *HashedPerceptronPredictor) lookup(pc uint64, ctx uint8) hashedLookup {
+	b := p.Config.IndexBits
+	mask := uint32(1)<<b - 1
+	base := neuralIndex(pc, ctx, b)
+
+	l := hashedLookup{indices: make([]uint32, len(p.Weights))}
+	for t, length := range p.Config.HistoryLengths {
+		idx := base ^ uint32(t)*0x9E5
+		if length > 0 {
+			idx ^= foldHistory(p.History[ctx], &p.LongHistory[ctx], length, b)
+		}
+		l.indices[t] = idx & mask
+		l.sum += int32(p.Weights[t][l.indices[t]])
+	}
+	l.predicted = l.sum >= 0
+	return l
+}
+
+func (p *HashedPerceptronPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence uint8) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+	l := p.lookup(pc, ctx)
+	p.lastPC, p.lastCtx, p.lastValid, p.last = pc, ctx, true, l
+	return l.predicted, neuralConfidence(l.sum, p.Threshold)
+}
+
+func (p *HashedPerceptronPredictor) Update(pc uint64, ctx uint8, taken bool) {
+	p.train(pc, ctx, taken)
+}
+
+func (p *HashedPerceptronPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
+	p.train(pc, ctx, actualTaken)
+}
+
+// train steps the selected weights, adapts the threshold, and shifts the history.
+func (p *HashedPerceptronPredictor) train(pc uint64, ctx uint8, taken bool) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+
+	// STEP 1: The lookup this outcome belongs to
+	l := p.last
+	if !p.lastValid || p.lastPC != pc || p.lastCtx != ctx {
+		l = p.lookup(pc, ctx)
+	}
+	p.lastValid = false
+
+	// STEP 2: Train on a miss or a low-margin hit
+	wrong := l.predicted != taken
+	unsure := abs32(l.sum) <= p.Threshold
+	if wrong || unsure {
+		for t, idx := range l.indices {
+			saturatingStep(&p.Weights[t][idx], taken, p.wmin, p.wmax)
+		}
+	}
+
+	// STEP 3: Adapt the threshold
+	switch {
+	case wrong:
+		p.thresholdCtr++
+		if p.thresholdCtr >= scThresholdCounterMax {
+			p.Threshold++
+			p.thresholdCtr = 0
+		}
+	case unsure:
+		p.thresholdCtr--
+		if p.thresholdCtr <= scThresholdCounterMin {
+			if p.Threshold > 1 {
+				p.Threshold--
+			}
+			p.thresholdCtr = 0
+		}
+	}
+
+	// STEP 4: Shift history
+	shiftLongHistory(p.History[ctx], &p.LongHistory[ctx])
+	p.History[ctx] = p.History[ctx]<<1 | takenBit(taken)
+	p.BranchCount++
+}
+
+// Reset clears the weights, histories and threshold; the configuration is kept.
+func (p *HashedPerceptronPredictor) Reset() {
+	for t := range p.Weights {
+		clear(p.Weights[t])
+	}
+	p.History = [NumContexts]uint64{}
+	p.LongHistory = [NumContexts]LongHistory{}
+	p.Threshold = int32(p.Config.Threshold)
+	p.thresholdCtr = 0
+	p.BranchCount = 0
+	p.lastValid = false
+}
+
+// Stats reports occupancy and the cost estimate.
+func (p *HashedPerceptronPredictor) Stats() NeuralStats {
+	c := p.Config
+	tables := len(c.HistoryLengths)
+	s := NeuralStats{
+		BranchCount: p.BranchCount,
+		Tables:      tables,
+		Weights:     uint64(tables) << c.IndexBits,
+		WeightBits:  c.WeightBits,
+		Threshold:   p.Threshold,
+	}
+	var saturated uint64
+	longest := 0
+	for t := range p.Weights {
+		saturated += countSaturated(p.Weights[t], p.wmin, p.wmax)
+		longest = max(longest, c.HistoryLengths[t])
+	}
+	s.SaturatedWeights = float64(saturated) / float64(s.Weights)
+
+	// Weights + history + one folded register per table per context + threshold
+	s.StorageBits = s.Weights*uint64(c.WeightBits) + NumContexts*uint64(longest) +
+		NumContexts*uint64(tables*c.IndexBits) + 8 + SCThresholdCounterBits
+
+	// Adder tree: T full-adder rows of (W + log2 T) bits, and T incrementers
+	width := uint64(c.WeightBits + bits.Len(uint(tables)))
+	s.Transistors = s.StorageBits*transistorsPerBit + 2*uint64(tables)*width*fullAdderTransistors
+	return s
+}
@@ -0,0 +1,393 @@
+package tage
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Perceptron and Hashed Perceptron - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The neural predictors are here to be compared with TAGE, so the tests check that they
+// are the textbook designs - including the textbook weakness - before any comparison is
+// trusted:
+//   1. Configuration: clamping, the θ formula, storage matching the TAGE budget
+//   2. Behaviour: saturation at the configured weight width, context separation, the
+//      metadata cache, reset
+//   3. Learning: the perceptron learns linear correlations but not XOR; the hashed
+//      perceptron learns both
+//   4. Comparison: TAGE and both neural predictors on identical streams through
+//      DirectionPredictor
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. CONFIGURATION TESTS
+//    Defaults, clamping, storage
+//
+// 2. BEHAVIOUR TESTS
+//    Weight saturation, contexts, metadata cache, reset
+//
+// 3. LEARNING TESTS
+//    Linear vs non-linear correlations
+//
+// 4. COMPARISON TESTS
+//    TAGE, perceptron, hashed perceptron on the same streams
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runDirection runs a trace in order through any DirectionPredictor and returns its
+// predictions
+func runDirection(pred DirectionPredictor, trace []traceBranch) []bool {
+	predictions := make([]bool, len(trace))
+	for i, b := range trace {
+		taken, _ := pred.Predict(b.pc, 0)
+		predictions[i] = taken
+		if taken == b.taken {
+			pred.Update(b.pc, 0, b.taken)
+		} else {
+			pred.OnMispredict(b.pc, 0, b.taken)
+		}
+	}
+	return predictions
+}
+
+// xorTrace builds iterations of two random branches A and B, then C = A XOR B
+func xorTrace(iterations int, seed int64) (trace []traceBranch, pcC uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	pcA, pcB := uint64(1)<<22|0x1000, uint64(2)<<22|0x2000
+	pcC = uint64(3)<<22 | 0x3000
+	for i := 0; i < iterations; i++ {
+		a, b := rng.Intn(2) == 0, rng.Intn(2) == 0
+		trace = append(trace, traceBranch{pcA, a}, traceBranch{pcB, b}, traceBranch{pcC, a != b})
+	}
+	return trace, pcC
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. CONFIGURATION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestNeural_ConfigNormalization(t *testing.T) {
+	// WHAT: Out-of-range fields are clamped, θ defaults to ⌊1.93h + 14⌋ (hashed: number of
+	//       tables), and the hashed lengths are copied
+	// WHY: Sweeps should not crash or alias the caller's slice
+	// HARDWARE: Parameter ranges of the RTL generator
+
+	p := NewPerceptronPredictor(PerceptronConfig{HistoryLength: 1000, WeightBits: 1, IndexBits: 40})
+	if c := p.Config; c.HistoryLength != 256 || c.WeightBits != 2 || c.IndexBits != 16 {
+		t.Errorf("Clamped config %+v", c)
+	}
+	if c := NewPerceptronPredictor(PerceptronConfig{HistoryLength: 32}).Config; c.Threshold != 75 {
+		t.Errorf("θ for h = 32 is %d, expected ⌊1.93·32 + 14⌋ = 75", c.Threshold)
+	}
+
+	lengths := []int{0, 8, 500}
+	h := NewHashedPerceptronPredictor(HashedPerceptronConfig{HistoryLengths: lengths, WeightBits: 20, IndexBits: 0})
+	lengths[1] = 99
+	if c := h.Config; c.HistoryLengths[1] != 8 || c.HistoryLengths[2] != 256 || c.WeightBits != 16 || c.IndexBits != 1 {
+		t.Errorf("Clamped config %+v", c)
+	}
+	if h.Threshold != 3 {
+		t.Errorf("Initial θ %d, expected one per table (3)", h.Threshold)
+	}
+	if len(NewHashedPerceptronPredictor(HashedPerceptronConfig{}).Weights) != 16 {
+		t.Error("Empty HistoryLengths did not select the default tables")
+	}
+}
+
+func TestNeural_DefaultStorageMatchesTAGE(t *testing.T) {
+	// WHAT: The default configurations hold within 3% of the TAGE tables' weight storage
+	// WHY: An accuracy comparison is only fair at equal budget
+	// HARDWARE: 8 × 1024 × 24-bit TAGE entries = 196,608 bits
+
+	tageBits := uint64(NumTables * EntriesPerTable * EntryWidth)
+	for name, s := range map[string]NeuralStats{
+		"perceptron":        NewPerceptronPredictor(DefaultPerceptronConfig()).Stats(),
+		"hashed perceptron": NewHashedPerceptronPredictor(DefaultHashedPerceptronConfig()).Stats(),
+	} {
+		weightBits := s.Weights * uint64(s.WeightBits)
+		t.Logf("%s: %d weight bits (TAGE %d), %dK transistors", name, weightBits, tageBits, s.Transistors/1000)
+		if weightBits < tageBits*97/100 || weightBits > tageBits*103/100 {
+			t.Errorf("%s: %d weight bits, TAGE has %d", name, weightBits, tageBits)
+		}
+		if s.Transistors < s.StorageBits*transistorsPerBit {
+			t.Errorf("%s: transistors %d below storage cost", name, s.Transistors)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. BEHAVIOUR TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestNeural_WeightsSaturateAtWidth(t *testing.T) {
+	// WHAT: With 3-bit weights, training never leaves [-4, 3], and Stats reports the
+	//       saturated fraction
+	// WHY: Weight width is a configuration knob; the model must honour it exactly
+	// HARDWARE: W-bit saturating incrementers
+
+	p := NewPerceptronPredictor(PerceptronConfig{HistoryLength: 16, WeightBits: 3, IndexBits: 4})
+	h := NewHashedPerceptronPredictor(HashedPerceptronConfig{HistoryLengths: []int{0, 4, 16}, WeightBits: 3, IndexBits: 4})
+	trace, _ := biasedTrace(2000, 1)
+	runDirection(p, trace)
+	runDirection(h, trace)
+
+	for _, w := range p.Weights {
+		if w < -4 || w > 3 {
+			t.Fatalf("Perceptron weight %d outside 3-bit range", w)
+		}
+	}
+	for _, table := range h.Weights {
+		for _, w := range table {
+			if w < -4 || w > 3 {
+				t.Fatalf("Hashed weight %d outside 3-bit range", w)
+			}
+		}
+	}
+	if p.Stats().SaturatedWeights == 0 || h.Stats().SaturatedWeights == 0 {
+		t.Error("No saturated weights reported with 3-bit weights")
+	}
+}
+
+func TestNeural_ContextsKeepSeparateHistory(t *testing.T) {
+	// WHAT: Outcomes in context 1 shift only context 1's history; out-of-range contexts
+	//       clamp to 0
+	// WHY: Same isolation contract as TAGE's per-context history registers
+	// HARDWARE: history[ctx] write enable
+
+	for _, pred := range []DirectionPredictor{
+		NewPerceptronPredictor(DefaultPerceptronConfig()),
+		NewHashedPerceptronPredictor(DefaultHashedPerceptronConfig()),
+	} {
+		pred.Update(0x1000, 1, true)
+		pred.Update(0x1000, 1, true)
+		pred.Update(0x1000, 42, true)
+
+		var history [NumContexts]uint64
+		switch p := pred.(type) {
+		case *PerceptronPredictor:
+			history = p.History
+		case *HashedPerceptronPredictor:
+			history = p.History
+		}
+		if history[1] != 0b11 || history[0] != 0b1 {
+			t.Errorf("%T: histories ctx0 0x%X ctx1 0x%X, expected 0x1 and 0x3", pred, history[0], history[1])
+		}
+	}
+}
+
+func TestNeural_UpdateWithoutPredictMatches(t *testing.T) {
+	// WHAT: Training with and without a preceding Predict ends in identical weights
+	// WHY: Like TAGE, the cached lookup is an optimisation; the fallback must agree
+	// HARDWARE: Last-prediction register vs re-read on the update port
+
+	trace := correlatedTrace(1000, 2)
+	cfg := PerceptronConfig{HistoryLength: 24, WeightBits: 6, IndexBits: 6}
+
+	cached, fresh := NewPerceptronPredictor(cfg), NewPerceptronPredictor(cfg)
+	hCached := NewHashedPerceptronPredictor(DefaultHashedPerceptronConfig())
+	hFresh := NewHashedPerceptronPredictor(DefaultHashedPerceptronConfig())
+	runDirection(cached, trace)
+	runDirection(hCached, trace)
+	for _, b := range trace {
+		fresh.Update(b.pc, 0, b.taken)
+		hFresh.Update(b.pc, 0, b.taken)
+	}
+
+	for i := range cached.Weights {
+		if cached.Weights[i] != fresh.Weights[i] {
+			t.Fatalf("Perceptron weight %d: %d with Predict, %d without", i, cached.Weights[i], fresh.Weights[i])
+		}
+	}
+	for tb := range hCached.Weights {
+		for i := range hCached.Weights[tb] {
+			if hCached.Weights[tb][i] != hFresh.Weights[tb][i] {
+				t.Fatalf("Hashed table %d weight %d differs", tb, i)
+			}
+		}
+	}
+	if hCached.Threshold != hFresh.Threshold {
+		t.Errorf("Hashed θ %d with Predict, %d without", hCached.Threshold, hFresh.Threshold)
+	}
+}
+
+func TestNeural_ResetKeepsConfig(t *testing.T) {
+	// WHAT: Reset zeroes weights, histories, counts and θ adaptation; the config stays
+	// WHY: Same contract as TAGEPredictor.Reset
+	// HARDWARE: Reset network over the weight SRAM and history registers
+
+	p := NewPerceptronPredictor(PerceptronConfig{HistoryLength: 20, WeightBits: 5, IndexBits: 5})
+	h := NewHashedPerceptronPredictor(HashedPerceptronConfig{HistoryLengths: []int{0, 8}, WeightBits: 5, IndexBits: 5})
+	trace := correlatedTrace(500, 3)
+	runDirection(p, trace)
+	runDirection(h, trace)
+	p.Reset()
+	h.Reset()
+
+	for _, w := range p.Weights {
+		if w != 0 {
+			t.Fatal("Perceptron weights survived Reset")
+		}
+	}
+	for _, table := range h.Weights {
+		for _, w := range table {
+			if w != 0 {
+				t.Fatal("Hashed weights survived Reset")
+			}
+		}
+	}
+	if p.History != [NumContexts]uint64{} || h.History != [NumContexts]uint64{} || p.BranchCount != 0 || h.BranchCount != 0 {
+		t.Error("History or branch count survived Reset")
+	}
+	if h.Threshold != int32(h.Config.Threshold) {
+		t.Errorf("θ %d after Reset, expected %d", h.Threshold, h.Config.Threshold)
+	}
+	if p.Config.HistoryLength != 20 || p.Config.WeightBits != 5 || len(h.Weights) != 2 {
+		t.Error("Reset changed the configuration")
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. LEARNING TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// The defining property of the perceptron: one weight per history bit can express
+// "same as the branch 41 back" but not "A XOR B". The hashed perceptron indexes weights
+// by the history itself, so it can express both.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestNeural_PerceptronLearnsLinearNotXOR(t *testing.T) {
+	// WHAT: The perceptron predicts a branch equal to one 41 back perfectly, and C = A XOR B
+	//       no better than a coin
+	// WHY: Minsky & Papert's limit, and the reason hashed and TAGE-like designs exist
+	// HARDWARE: One weight per history bit
+
+	distant, pcB := distantTrace(3000, 40, 1)
+	xor, pcC := xorTrace(5000, 2)
+	cfg := DefaultPerceptronConfig()
+
+	linear := branchAccuracy(distant, runDirection(NewPerceptronPredictor(cfg), distant), len(distant)/2, pcB)
+	nonLinear := branchAccuracy(xor, runDirection(NewPerceptronPredictor(cfg), xor), len(xor)/2, pcC)
+	t.Logf("Perceptron: distance-41 copy %.1f%%, XOR %.1f%%", linear*100, nonLinear*100)
+
+	if linear < 0.99 {
+		t.Errorf("Linear correlation accuracy %.1f%%, expected ≥ 99%%", linear*100)
+	}
+	if nonLinear > 0.60 {
+		t.Errorf("XOR accuracy %.1f%%: a perceptron cannot learn XOR", nonLinear*100)
+	}
+}
+
+func TestNeural_HashedLearnsBoth(t *testing.T) {
+	// WHAT: The hashed perceptron predicts both the distance-41 copy and XOR, and a
+	//       distance-101 copy through its 128+ bit tables
+	// WHY: Path-indexed weights remove the linearity limit
+	// HARDWARE: Folded-history indexing per table
+
+	type target struct {
+		name  string
+		trace []traceBranch
+		pc    uint64
+	}
+	var targets []target
+	for _, gap := range []int{40, 100} {
+		trace, pc := distantTrace(3000, gap, 1)
+		targets = append(targets, target{fmt.Sprintf("distance-%d", gap+1), trace, pc})
+	}
+	trace, pc := xorTrace(5000, 2)
+	targets = append(targets, target{"XOR", trace, pc})
+
+	cfg := DefaultHashedPerceptronConfig()
+	for _, tc := range targets {
+		acc := branchAccuracy(tc.trace, runDirection(NewHashedPerceptronPredictor(cfg), tc.trace), len(tc.trace)/2, tc.pc)
+		t.Logf("Hashed perceptron: %s %.1f%%", tc.name, acc*100)
+		if acc < 0.99 {
+			t.Errorf("%s accuracy %.1f%%, expected ≥ 99%%", tc.name, acc*100)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 4. COMPARISON TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Identical streams, in order, through the DirectionPredictor interface. The core's
+// BranchPredictor joins the comparison on real kernel traces in the root package
+// (branchtrace_test.go), which cannot be imported from here.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestNeural_CompareWithTAGE(t *testing.T) {
+	// WHAT: TAGE, perceptron and hashed perceptron run the same synthetic streams; every
+	//       predictor sees every branch, TAGE learns XOR, and the table is logged
+	// WHY: The comparison the TAGE choice has to survive
+	// HARDWARE: Equal storage budgets (see TestNeural_DefaultStorageMatchesTAGE)
+
+	type stream struct {
+		name  string
+		trace []traceBranch
+		pc    uint64 // Score only this branch; 0 = all
+	}
+	distant, pcB := distantTrace(3000, 40, 1)
+	xor, pcC := xorTrace(5000, 2)
+	biased, pcBiased := biasedTrace(20000, 4)
+	streams := []stream{
+		{"correlated", correlatedTrace(5000, 3), 0},
+		{"distance-41", distant, pcB},
+		{"xor", xor, pcC},
+		{"biased-85%", biased, pcBiased},
+		{"loop-30", loopTrace(300, 30), 0},
+	}
+	predictors := []struct {
+		name string
+		new  func() DirectionPredictor
+	}{
+		{"tage", func() DirectionPredictor { return NewTAGEPredictor() }},
+		{"perceptron", func() DirectionPredictor { return NewPerceptronPredictor(DefaultPerceptronConfig()) }},
+		{"hashed", func() DirectionPredictor { return NewHashedPerceptronPredictor(DefaultHashedPerceptronConfig()) }},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s", "STREAM")
+	for _, p := range predictors {
+		fmt.Fprintf(&b, " %11s", p.name)
+	}
+	accuracy := map[string]map[string]float64{}
+	for _, s := range streams {
+		fmt.Fprintf(&b, "\n%-12s", s.name)
+		accuracy[s.name] = map[string]float64{}
+		for _, p := range predictors {
+			pred := p.new()
+			predictions := runDirection(pred, s.trace)
+
+			acc := accuracyAfter(s.trace, predictions, len(s.trace)/2)
+			if s.pc != 0 {
+				acc = branchAccuracy(s.trace, predictions, len(s.trace)/2, s.pc)
+			}
+			accuracy[s.name][p.name] = acc
+			fmt.Fprintf(&b, " %10.1f%%", acc*100)
+		}
+	}
+	t.Logf("Accuracy after warmup:\n%s", b.String())
+
+	if accuracy["xor"]["tage"] < 0.99 {
+		t.Errorf("TAGE XOR accuracy %.1f%%, expected ≥ 99%%", accuracy["xor"]["tage"]*100)
+	}
+	for _, s := range streams {
+		for _, p := range predictors {
+			if accuracy[s.name][p.name] < 0.45 {
+				t.Errorf("%s on %s: %.1f%%, worse than guessing", p.name, s.name, accuracy[s.name][p.name]*100)
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE Branch Predictor - Speculative History with Checkpoint Repair
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// OVERVIEW:
+// ─────────
+// Predict() reads History[ctx], and Update()/OnMispredict() shift the real outcome into it.
+// That is exact when every branch resolves before the next one is predicted - a unit model.
+// A pipelined front end predicts several branches before the oldest resolves, so:
+//
+//   1. PREDICTIONS USE STALE HISTORY
+//      With N branches in flight, History[ctx] is missing the newest N outcomes. A branch
+//      that depends on the one just before it cannot see it. Correlations shorter than the
+//      pipeline depth are invisible to every table.
+//
+//   2. TRAINING USES DIFFERENT HISTORY
+//      By the time a branch resolves, History[ctx] has moved on. Update() indexes the tables
+//      with a history the prediction never used, so it trains entries the next lookup of
+//      the same path will not read.
+//
+// THE FIX:
+// ────────
+// Keep a second register per context, SpecHistory[ctx], that fetch shifts the PREDICTED
+// direction into as soon as it predicts. Each prediction carries a checkpoint - the
+// SpecHistory it was made with plus the provider metadata - down the pipeline, and both
+// repair and training use the checkpoint, never the live registers.
+//
+//   PredictSpeculative  Look up with SpecHistory, return a checkpoint, shift the
+//                       prediction into SpecHistory
+//   Repair              A branch resolved against its prediction: every younger branch is
+//                       on the wrong path, so SpecHistory becomes the checkpoint plus the
+//                       real outcome
+//   Flush               Everything in flight is discarded (exception, interrupt, context
+//                       switch): SpecHistory becomes the architectural History
+//   Commit              Train with the checkpoint's history and provider, then shift the
+//                       real outcome into the architectural History
+//
+// LIFECYCLE:
+// ──────────
+//
+//   FETCH          taken, conf, cp := PredictSpeculative(pc, ctx)
+//                  SpecHistory = {cp.History[62:0], taken}
+//        │
+//   EXECUTE        outcome != predicted?
+//        │           Repair(cp, outcome)    SpecHistory = {cp.History[62:0], outcome}
+//        │           discard younger checkpoints, refetch
+//        │
+//   RETIRE         Commit(cp, outcome)      History = {cp.History[62:0], outcome}
+//
+// INVARIANT:
+// ──────────
+// Branches commit in program order and wrong-path branches never commit, so at Commit
+// the architectural History equals cp.History. The checkpoint is the history the
+// prediction was made with AND the history training uses - the two can no longer drift.
+// With one branch in flight at a time, PredictSpeculative + Commit makes exactly the
+// predictions and table updates Predict + Update/OnMispredict makes.
+//
+// COST:
+// ─────
+//   SpecHistory: 8 contexts × 64 bits = 512 flip-flops
+//   Checkpoint:  64-bit history + 16-bit prediction metadata per in-flight branch
+//                (the metadata already travelled with the branch for Update)
+//   Long history (extensions.go): the model checkpoints all 192 extra bits; hardware
+//                keeps them in a circular buffer and checkpoints its head pointer
+//
+// Only the history is checkpointed. Tables change only at Commit, so there is no
+// speculative table state to repair.
+//
+// SCOPE:
+// ──────
+// The SUPRAX Core's front end defaults to its own 4-bit counters, BTB and ITTAGE. This
+// predictor drives the Core's conditional branches only when plugged in through
+// Core.SetDirectionPredictor (specdirection.go in the root module), which maps each
+// call here onto the fetch sequence number; specdirection_test.go measures it there.
+// runPipeline in speculative_test.go is the trace-driven model of the same lifecycle.
+//
+// SystemVerilog equivalent:
+//   typedef struct packed {
+//     logic [63:0]          pc;
+//     logic [2:0]           ctx;
+//     logic [63:0]          history;   // spec_history[ctx] at prediction
+//     prediction_metadata_t meta;
+//   } history_checkpoint_t;
+//
+//   logic [63:0] spec_history [0:7];
+//
+//   always_ff @(posedge clk) begin
+//     if (flush_en)
+//       spec_history[flush_ctx] <= history[flush_ctx];
+//     else if (repair_en)
+//       spec_history[repair_cp.ctx] <= {repair_cp.history[62:0], repair_taken};
+//     else if (predict_en)
+//       spec_history[ctx] <= {spec_history[ctx][62:0], prediction};
+//   end
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+package tage
+
+// HistoryCheckpoint is the state a speculative prediction carries down the pipeline.
+// Hardware: Pipeline register from fetch to retire (one per in-flight branch)
+type HistoryCheckpoint struct {
+	PC      uint64             // Branch PC
+	Ctx     uint8              // Hardware context (clamped)
+	History uint64             // SpecHistory[Ctx] the prediction was made with
+	Long    LongHistory        // SpecLongHistory[Ctx] the prediction was made with
+	Meta    PredictionMetadata // Provider found by the lookup (Meta.Predicted = direction)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SPECULATIVE PREDICTION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// PredictSpeculative predicts with the speculative history and shifts the prediction in.
+//
+// ALGORITHM:
+//   1. Clamp context
+//   2. Look up with SpecHistory[ctx] (same tables, hashes, and selection as Predict)
+//   3. Capture the checkpoint: history used + provider metadata
+//   4. Shift the predicted direction into SpecHistory[ctx]
+//
+// Hardware: Predict path + one extra 64-bit shift register write
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func (p *TAGEPredictor) PredictSpeculative(pc uint64, ctx uint8) (taken bool, confidence uint8, cp HistoryCheckpoint) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+
+	// Wire: history = spec_history[ctx]
+	history := p.SpecHistory[ctx]
+	long := p.SpecLongHistory[ctx]
+	taken, confidence = p.predictWithHistory(pc, ctx, history, &long)
+
+	// Reg: checkpoint <= {pc, ctx, history, last_prediction}
+	cp = HistoryCheckpoint{
+		PC:      pc,
+		Ctx:     ctx,
+		History: history,
+		Long:    long,
+		Meta:    p.LastPrediction,
+	}
+
+	// Reg: spec_history[ctx] <= {history[62:0], taken}
+	shiftLongHistory(history, &p.SpecLongHistory[ctx])
+	p.SpecHistory[ctx] = (history << 1) | takenBit(taken)
+	return taken, confidence, cp
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// REPAIR AND FLUSH
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Repair rewinds SpecHistory to a mispredicted branch and applies its real outcome.
+// The caller discards every checkpoint younger than cp in the same context - those
+// branches were fetched down the wrong path.
+//
+// Flush rewinds SpecHistory to the architectural History, discarding every in-flight
+// prediction in the context.
+//
+// Hardware: 64-bit mux into the spec_history write port
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func (p *TAGEPredictor) Repair(cp HistoryCheckpoint, taken bool) {
+	// Reg: spec_history[cp.ctx] <= {cp.history[62:0], taken}
+	p.SpecLongHistory[cp.Ctx] = cp.Long
+	shiftLongHistory(cp.History, &p.SpecLongHistory[cp.Ctx])
+	p.SpecHistory[cp.Ctx] = (cp.History << 1) | takenBit(taken)
+}
+
+func (p *TAGEPredictor) Flush(ctx uint8) {
+	if ctx >= NumContexts {
+		ctx = 0
+	}
+
+	// Reg: spec_history[ctx] <= history[ctx]
+	p.SpecHistory[ctx] = p.History[ctx]
+	p.SpecLongHistory[ctx] = p.LongHistory[ctx]
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// COMMIT
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Commit trains the predictor with a retired branch's checkpoint and real outcome.
+//
+// ALGORITHM:
+//   1. Restore the history and provider metadata the prediction was made with
+//      (History[ctx] already equals cp.History when commits are in program order)
+//   2. Correct prediction: Update (conservative learning)
+//      Misprediction:      OnMispredict (allocation)
+//      Both shift the real outcome into History[ctx]
+//   3. Clear the metadata cache so a later Update() cannot reuse it
+//
+// Commit does not touch SpecHistory: a misprediction was repaired when it resolved.
+//
+// Hardware: The existing update path, fed from the checkpoint register instead of the
+//           last-prediction register
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func (p *TAGEPredictor) Commit(cp HistoryCheckpoint, taken bool) {
+	// STEP 1: Training sees what the prediction saw
+	p.History[cp.Ctx] = cp.History
+	p.LongHistory[cp.Ctx] = cp.Long
+	p.LastPC = cp.PC
+	p.LastCtx = cp.Ctx
+	p.LastPrediction = cp.Meta
+
+	// STEP 2
+	if taken == cp.Meta.Predicted {
+		p.Update(cp.PC, cp.Ctx, taken)
+	} else {
+		p.OnMispredict(cp.PC, cp.Ctx, taken)
+	}
+
+	// STEP 3
+	p.LastPC = 0
+	p.LastCtx = 0
+	p.LastPrediction = PredictionMetadata{ProviderTable: -1}
+}
+
+// takenBit converts a direction to the bit shifted into a history register.
+//
+//go:inline
+func takenBit(taken bool) uint64 {
+	if taken {
+		return 1
+	}
+	return 0
+}
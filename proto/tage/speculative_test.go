@@ -0,0 +1,442 @@
+package tage
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX TAGE Speculative History - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Speculative history must be invisible when nothing is speculative, and must recover the
+// in-order accuracy when a lot is. So the suite checks three things:
+//   1. Register behaviour: what PredictSpeculative, Repair, Flush and Commit do to the
+//      speculative and architectural history registers
+//   2. Equivalence: one branch in flight at a time gives bit-identical predictions and
+//      tables to Predict + Update/OnMispredict
+//   3. Pipelining: with many branches in flight, the speculative model keeps near in-order
+//      accuracy and the non-speculative model does not
+//
+// THE PIPELINE MODEL:
+// ───────────────────
+// runPipeline keeps up to `depth` predicted branches in flight and resolves the oldest,
+// like a front end running `depth` branches ahead of retire. A misprediction discards the
+// younger branches and refetches them, so every counted prediction is the one the correct
+// path actually used. depth = 1 is the in-order unit model.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. REGISTER TESTS
+//    Speculative shift, repair, flush, commit, context isolation, reset
+//
+// 2. EQUIVALENCE TESTS
+//    In-order traces match the non-speculative model exactly
+//
+// 3. PIPELINE TESTS
+//    Accuracy with branches in flight, speculative vs non-speculative
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// traceBranch is one dynamic branch of a trace
+type traceBranch struct {
+	pc    uint64
+	taken bool
+}
+
+// correlatedTrace builds iterations of four branches whose outcomes depend on branches a
+// few positions earlier - invisible to a history that lags by more than that
+//
+//	A  random
+//	B  same as A (distance 1)
+//	C  opposite of A (distance 2)
+//	D  taken two iterations out of three
+func correlatedTrace(iterations int, seed int64) []traceBranch {
+	rng := rand.New(rand.NewSource(seed))
+	pcA, pcB := uint64(1)<<22|0x1000, uint64(2)<<22|0x2000
+	pcC, pcD := uint64(3)<<22|0x3000, uint64(4)<<22|0x4000
+
+	trace := make([]traceBranch, 0, iterations*4)
+	for i := 0; i < iterations; i++ {
+		a := rng.Intn(2) == 0
+		trace = append(trace,
+			traceBranch{pcA, a},
+			traceBranch{pcB, a},
+			traceBranch{pcC, !a},
+			traceBranch{pcD, i%3 != 2})
+	}
+	return trace
+}
+
+// runPipeline runs a trace with up to depth predictions in flight and returns the
+// committed predictions in program order
+func runPipeline(pred *TAGEPredictor, trace []traceBranch, depth int, speculative bool) []bool {
+	type inFlight struct {
+		idx       int
+		predicted bool
+		cp        HistoryCheckpoint
+	}
+	const ctx = 0
+
+	predictions := make([]bool, 0, len(trace))
+	var window []inFlight
+	next := 0
+	for len(predictions) < len(trace) {
+		// Fetch: fill the window
+		for len(window) < depth && next < len(trace) {
+			b := trace[next]
+			f := inFlight{idx: next}
+			if speculative {
+				f.predicted, _, f.cp = pred.PredictSpeculative(b.pc, ctx)
+			} else {
+				f.predicted, _ = pred.Predict(b.pc, ctx)
+			}
+			window = append(window, f)
+			next++
+		}
+
+		// Resolve and retire the oldest
+		f := window[0]
+		window = window[1:]
+		b := trace[f.idx]
+		predictions = append(predictions, f.predicted)
+
+		mispredicted := f.predicted != b.taken
+		switch {
+		case speculative:
+			if mispredicted {
+				pred.Repair(f.cp, b.taken)
+			}
+			pred.Commit(f.cp, b.taken)
+		case mispredicted:
+			pred.OnMispredict(b.pc, ctx, b.taken)
+		default:
+			pred.Update(b.pc, ctx, b.taken)
+		}
+
+		// Wrong path: discard and refetch the younger branches
+		if mispredicted {
+			window = window[:0]
+			next = f.idx + 1
+		}
+	}
+	return predictions
+}
+
+// accuracyAfter scores predictions against the trace, skipping the warmup
+func accuracyAfter(trace []traceBranch, predictions []bool, warmup int) float64 {
+	correct := 0
+	for i := warmup; i < len(trace); i++ {
+		if predictions[i] == trace[i].taken {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(trace)-warmup)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. REGISTER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// SpecHistory runs ahead of History by the predictions in flight. Repair and Flush are the
+// only ways back; Commit is the only way History moves in speculative mode.
+//
+// Hardware: spec_history and history shift registers, checkpoint pipeline registers
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSpeculative_ShiftsPredictionAtFetch(t *testing.T) {
+	// WHAT: Each PredictSpeculative shifts its prediction into SpecHistory and checkpoints
+	//       the history it used; History does not move
+	// WHY: The next prediction must see this one without waiting for it to resolve
+	// HARDWARE: {spec_history[62:0], prediction}
+
+	pred := NewTAGEPredictor()
+	ctx := uint8(2)
+
+	var cps []HistoryCheckpoint
+	var want uint64
+	for i := 0; i < 5; i++ {
+		taken, _, cp := pred.PredictSpeculative(uint64(i+1)<<22, ctx)
+		if cp.History != want {
+			t.Fatalf("Prediction %d: checkpoint history 0x%X, expected 0x%X", i, cp.History, want)
+		}
+		if cp.Meta.Predicted != taken || cp.Ctx != ctx {
+			t.Fatalf("Prediction %d: checkpoint %+v does not match prediction %v", i, cp, taken)
+		}
+		want = want<<1 | takenBit(taken)
+		cps = append(cps, cp)
+	}
+
+	if pred.SpecHistory[ctx] != want {
+		t.Errorf("SpecHistory = 0x%X, expected 0x%X", pred.SpecHistory[ctx], want)
+	}
+	if pred.History[ctx] != 0 {
+		t.Errorf("History = 0x%X, should not move before commit", pred.History[ctx])
+	}
+}
+
+func TestSpeculative_RepairRewindsToCheckpoint(t *testing.T) {
+	// WHAT: Repair on a middle branch drops every younger prediction and applies the real
+	//       outcome; committing the survivors leaves History equal to SpecHistory
+	// WHY: Wrong-path predictions must not leak into the history of the correct path
+	// HARDWARE: spec_history <= {cp.history[62:0], outcome}
+
+	pred := NewTAGEPredictor()
+	ctx := uint8(0)
+
+	var cps []HistoryCheckpoint
+	var predicted []bool
+	for i := 0; i < 6; i++ {
+		taken, _, cp := pred.PredictSpeculative(uint64(i+1)<<22, ctx)
+		cps = append(cps, cp)
+		predicted = append(predicted, taken)
+	}
+
+	// Branch 2 resolves against its prediction
+	outcome := !predicted[2]
+	pred.Repair(cps[2], outcome)
+
+	want := cps[2].History<<1 | takenBit(outcome)
+	if pred.SpecHistory[ctx] != want {
+		t.Fatalf("SpecHistory = 0x%X after repair, expected 0x%X", pred.SpecHistory[ctx], want)
+	}
+
+	// Retire branches 0-2: the architectural history catches up exactly
+	pred.Commit(cps[0], predicted[0])
+	pred.Commit(cps[1], predicted[1])
+	pred.Commit(cps[2], outcome)
+	if pred.History[ctx] != pred.SpecHistory[ctx] {
+		t.Errorf("History 0x%X != SpecHistory 0x%X with nothing in flight",
+			pred.History[ctx], pred.SpecHistory[ctx])
+	}
+}
+
+func TestSpeculative_FlushRestoresArchitectural(t *testing.T) {
+	// WHAT: Flush discards every in-flight prediction of one context only
+	// WHY: Exceptions and interrupts squash everything after the last retired instruction
+	// HARDWARE: spec_history[ctx] <= history[ctx]
+
+	pred := NewTAGEPredictor()
+
+	// Commit some history in context 1, then run ahead in contexts 1 and 3
+	for i := 0; i < 4; i++ {
+		taken, _, cp := pred.PredictSpeculative(uint64(i+1)<<22, 1)
+		pred.Commit(cp, taken)
+	}
+	committed := pred.History[1]
+	for i := 0; i < 4; i++ {
+		pred.PredictSpeculative(uint64(i+9)<<22, 1)
+		pred.PredictSpeculative(uint64(i+9)<<22, 3)
+	}
+	other := pred.SpecHistory[3]
+
+	pred.Flush(1)
+
+	if pred.SpecHistory[1] != committed {
+		t.Errorf("Context 1: SpecHistory 0x%X after flush, expected 0x%X", pred.SpecHistory[1], committed)
+	}
+	if pred.SpecHistory[3] != other {
+		t.Errorf("Context 3: SpecHistory changed by another context's flush")
+	}
+
+	// Out-of-range contexts clamp to 0, like every other entry point
+	pred.SpecHistory[0] = 0xFF
+	pred.Flush(NumContexts + 1)
+	if pred.SpecHistory[0] != pred.History[0] {
+		t.Error("Flush with an invalid context should clamp to context 0")
+	}
+}
+
+func TestSpeculative_CommitClearsMetadataCache(t *testing.T) {
+	// WHAT: Commit leaves no cached provider behind, correct or mispredicted
+	// WHY: A later Update() for the same PC must search, not reuse a retired prediction
+	// HARDWARE: last_prediction register invalidated
+
+	for _, mispredict := range []bool{false, true} {
+		pred := NewTAGEPredictor()
+		taken, _, cp := pred.PredictSpeculative(0x5000, 0)
+		pred.Commit(cp, taken != mispredict)
+
+		if pred.LastPrediction.ProviderEntry != nil || pred.LastPrediction.ProviderTable != -1 {
+			t.Errorf("mispredict=%v: metadata cache still set: %+v", mispredict, pred.LastPrediction)
+		}
+	}
+}
+
+func TestSpeculative_ResetClearsSpecHistory(t *testing.T) {
+	// WHAT: Reset and NewTAGEPredictor zero the speculative registers
+	// WHY: A stale speculative history would index the tables with another run's path
+	// HARDWARE: Parallel register clear
+
+	pred := NewTAGEPredictor()
+	for ctx := uint8(0); ctx < NumContexts; ctx++ {
+		if pred.SpecHistory[ctx] != 0 {
+			t.Fatalf("Context %d: SpecHistory 0x%X at init", ctx, pred.SpecHistory[ctx])
+		}
+		pred.SpecHistory[ctx] = ^uint64(0)
+	}
+
+	pred.Reset()
+
+	for ctx := uint8(0); ctx < NumContexts; ctx++ {
+		if pred.SpecHistory[ctx] != 0 {
+			t.Errorf("Context %d: SpecHistory 0x%X after reset", ctx, pred.SpecHistory[ctx])
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. EQUIVALENCE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// With one branch in flight, speculation never runs ahead of a resolved outcome, so the
+// speculative model must be the non-speculative model exactly - same predictions, same
+// table contents, same history.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSpeculative_InOrderMatchesNonSpeculative(t *testing.T) {
+	// WHAT: Depth-1 runs of both models agree prediction for prediction, and end with
+	//       identical tables, histories, and branch counts
+	// WHY: Speculative history is a pipeline fix; it must not change what TAGE learns
+	// HARDWARE: Checkpoint register == last-prediction register when nothing overlaps
+
+	traces := map[string][]traceBranch{
+		"correlated": correlatedTrace(3000, 1),
+		"random":     nil,
+	}
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 12000; i++ {
+		pc := uint64(rng.Intn(64)+1)<<22 | uint64(rng.Intn(1024))<<12
+		traces["random"] = append(traces["random"], traceBranch{pc, rng.Intn(3) != 0})
+	}
+
+	for name, trace := range traces {
+		base := NewTAGEPredictor()
+		spec := NewTAGEPredictor()
+		want := runPipeline(base, trace, 1, false)
+		got := runPipeline(spec, trace, 1, true)
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: prediction %d differs (speculative %v, in-order %v)", name, i, got[i], want[i])
+			}
+		}
+		for tb := 0; tb < NumTables; tb++ {
+			if spec.Tables[tb] != base.Tables[tb] {
+				t.Errorf("%s: table %d differs", name, tb)
+			}
+		}
+		if spec.History != base.History || spec.SpecHistory[0] != base.History[0] {
+			t.Errorf("%s: history 0x%X / spec 0x%X, in-order 0x%X",
+				name, spec.History[0], spec.SpecHistory[0], base.History[0])
+		}
+		if spec.BranchCount != base.BranchCount {
+			t.Errorf("%s: branch count %d, in-order %d", name, spec.BranchCount, base.BranchCount)
+		}
+		t.Logf("%s: accuracy %.1f%% in both models", name, accuracyAfter(trace, got, 0)*100)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. PIPELINE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// With `depth` branches in flight, the non-speculative model predicts with a history that
+// lags by up to depth outcomes and trains with whatever History holds at resolve. The
+// speculative model predicts and trains with the same, up-to-date path history.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSpeculative_PipelinedAccuracy(t *testing.T) {
+	// WHAT: On the correlated trace with 8 branches in flight, the speculative model stays
+	//       within 2 points of the in-order accuracy and beats the non-speculative model by
+	//       more than 10
+	// WHY: This is the case the speculative history exists for - short-distance
+	//      correlations in a deep front end
+	// HARDWARE: Full fetch → repair → retire loop
+
+	trace := correlatedTrace(5000, 3)
+	const warmup = 2000
+
+	inOrder := accuracyAfter(trace, runPipeline(NewTAGEPredictor(), trace, 1, false), warmup)
+	for _, depth := range []int{4, 8, 16} {
+		stale := accuracyAfter(trace, runPipeline(NewTAGEPredictor(), trace, depth, false), warmup)
+		spec := accuracyAfter(trace, runPipeline(NewTAGEPredictor(), trace, depth, true), warmup)
+		t.Logf("Depth %2d: in-order %.1f%%, non-speculative %.1f%%, speculative %.1f%%",
+			depth, inOrder*100, stale*100, spec*100)
+
+		if spec < inOrder-0.02 {
+			t.Errorf("Depth %d: speculative %.1f%% falls short of in-order %.1f%%", depth, spec*100, inOrder*100)
+		}
+		if spec < stale+0.10 {
+			t.Errorf("Depth %d: speculative %.1f%% not clearly above non-speculative %.1f%%",
+				depth, spec*100, stale*100)
+		}
+	}
+}
+
+func TestSpeculative_FlushMidStream(t *testing.T) {
+	// WHAT: Flushing the whole window at random points (like interrupts) and refetching
+	//       leaves History and SpecHistory equal and the accuracy unchanged
+	// WHY: Flush is the other recovery path; a wrong restore would poison every
+	//      prediction after it
+	// HARDWARE: spec_history <= history, then refetch
+
+	trace := correlatedTrace(3000, 4)
+	rng := rand.New(rand.NewSource(5))
+	pred := NewTAGEPredictor()
+
+	type inFlight struct {
+		idx int
+		cp  HistoryCheckpoint
+	}
+	var window []inFlight
+	predictions := make([]bool, 0, len(trace))
+	next := 0
+	for len(predictions) < len(trace) {
+		for len(window) < 8 && next < len(trace) {
+			_, _, cp := pred.PredictSpeculative(trace[next].pc, 0)
+			window = append(window, inFlight{next, cp})
+			next++
+		}
+
+		// Interrupt: everything in flight is squashed
+		if rng.Intn(50) == 0 {
+			pred.Flush(0)
+			if pred.SpecHistory[0] != pred.History[0] {
+				t.Fatal("Flush did not restore the architectural history")
+			}
+			next = window[0].idx
+			window = window[:0]
+			continue
+		}
+
+		f := window[0]
+		window = window[1:]
+		b := trace[f.idx]
+		if pred.History[0] != f.cp.History {
+			t.Fatalf("Branch %d: History 0x%X at commit, checkpoint 0x%X", f.idx, pred.History[0], f.cp.History)
+		}
+		predictions = append(predictions, f.cp.Meta.Predicted)
+		if f.cp.Meta.Predicted != b.taken {
+			pred.Repair(f.cp, b.taken)
+			window = window[:0]
+			next = f.idx + 1
+		}
+		pred.Commit(f.cp, b.taken)
+	}
+
+	acc := accuracyAfter(trace, predictions, 1000)
+	t.Logf("Accuracy with interrupts: %.1f%%", acc*100)
+	if acc < 0.80 {
+		t.Errorf("Accuracy %.1f%% with flushes, expected the speculative model's ~85%%", acc*100)
+	}
+}
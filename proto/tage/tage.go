@@ -542,6 +542,8 @@ type PredictionMetadata struct {
 	ProviderEntry *TAGEEntry // Pointer to provider entry (for Go model only)
 	Predicted     bool       // What was predicted
 	Confidence    uint8      // Confidence level (0-2)
+
+	Ext ExtensionMetadata // Extension lookups (valid when any extension is enabled)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -553,15 +555,19 @@ type PredictionMetadata struct {
 // COMPONENTS:
 //   Tables:         8 predictor tables (Table 0 = base, Tables 1-7 = history)
 //   History:        Per-context global branch history registers (8 × 64-bit)
+//   SpecHistory:    Per-context speculative history for pipelined fetch (speculative.go)
 //   BranchCount:    Counter for triggering periodic aging
 //   AgingEnabled:   Enable/disable aging (for testing)
 //   LastPrediction: Cached metadata from most recent prediction
 //   LastPC/LastCtx: PC and context of most recent prediction
+//   Extensions:     Optional loop predictor, statistical corrector, and long-history
+//                   tables (extensions.go), each with its own enable flag
 //
 // MEMORY FOOTPRINT:
 //   Tables: 8 × 1024 × 24 bits = 196,608 bits = 24KB SRAM
 //   ValidBits: 8 × 1024 bits = 8,192 bits = 1KB flip-flops
 //   History: 8 × 64 bits = 512 bits = 64 bytes flip-flops
+//   SpecHistory: 8 × 64 bits = 512 bits = 64 bytes flip-flops
 //   Total: ~25KB
 //
 // Hardware: ~1.34M transistors
@@ -593,11 +599,23 @@ type PredictionMetadata struct {
 type TAGEPredictor struct {
 	Tables         [NumTables]TAGETable // 8 predictor tables
 	History        [NumContexts]uint64  // Per-context history registers
+	SpecHistory    [NumContexts]uint64  // Per-context speculative history registers
 	BranchCount    uint64               // Counter for aging trigger
 	AgingEnabled   bool                 // Enable periodic aging
 	LastPrediction PredictionMetadata   // Cached prediction metadata
 	LastPC         uint64               // PC of last prediction
 	LastCtx        uint8                // Context of last prediction
+
+	// TAGE-SC-L extensions (extensions.go) - all disabled by default
+	LongHistory        [NumContexts]LongHistory // History bits 64-255 per context
+	SpecLongHistory    [NumContexts]LongHistory // Speculative copy
+	LongTables         [NumLongTables]TAGETable // Tagged tables with 128/256-bit history
+	Loop               LoopPredictor            // Fixed trip-count loops
+	Corrector          StatisticalCorrector     // GEHL statistical corrector
+	LongHistoryEnabled bool                     // Consult LongTables
+	LoopEnabled        bool                     // Consult Loop
+	SCEnabled          bool                     // Consult Corrector
+	extCounts          [numComponents]componentCounts
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -724,14 +742,19 @@ func NewTAGEPredictor() *TAGEPredictor {
 	// SystemVerilog:
 	//   for (int ctx = 0; ctx < 8; ctx++) begin
 	//     history[ctx] <= 64'b0;
+	//     spec_history[ctx] <= 64'b0;
 	//   end
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	for ctx := 0; ctx < NumContexts; ctx++ {
 		pred.History[ctx] = 0
+		pred.SpecHistory[ctx] = 0
 	}
 
 	pred.BranchCount = 0
 
+	// TAGE-SC-L extensions: empty tables, initial corrector threshold (all disabled)
+	pred.resetExtensions()
+
 	return pred
 }
 
@@ -750,17 +773,51 @@ func NewTAGEPredictor() *TAGEPredictor {
 //
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+// hashPC hashes a PC down to width bits.
+//
+// ALGORITHM:
+//   1. Drop the two alignment bits (instructions are 4-byte aligned)
+//   2. Multiply by the golden ratio prime: every bit moves up into the high half
+//   3. XOR the high half back down, so all 64 bits depend on the whole PC
+//   4. Multiply by a per-user odd constant (HashPrime + 2×salt), take the top width bits
+//
+// WHY HASH THE WHOLE PC:
+//   Taking a fixed bit range (say pc[21:12] for the index, pc[34:22] for the tag) only
+//   separates branches that differ in those bits: a 32-bit core whose code fits in a few
+//   KB would put every branch in one base entry with one tag. A plain XOR fold is no better on regular
+//   addresses: slices that repeat every `width` bits cancel.
+//
+// PER-USER SALT:
+//   Each table, the tag, and each extension uses its own salt. A different multiplier gives
+//   an independent top slice, so two branches sharing a slot in one table almost never
+//   share it in another, or share a tag.
+//
+// Hardware: The model needs the spread, not the multipliers. RTL would use a fixed XOR
+//   network over the implemented PC bits with the same property (every input bit reaches
+//   every output bit, no repeating-slice cancellation).
+//
+// ───────────────────────────────────────────────────────────────────────────────────────────────
+
+//go:inline
+func hashPC(pc uint64, salt int, width int) uint32 {
+	x := (pc >> 2) * HashPrime
+	x ^= x >> 32
+	x *= HashPrime + uint64(salt)<<1
+	return uint32(x >> (64 - width))
+}
+
 // ───────────────────────────────────────────────────────────────────────────────────────────────
 // hashIndex computes the table index from PC and history.
 //
 // ALGORITHM:
-//   1. Extract PC bits (shifted by table-specific amount for decorrelation)
+//   1. Hash the PC (salted per table for decorrelation)
 //   2. If history table: mix history bits using golden ratio prime
 //   3. XOR PC and history components
 //   4. Mask to index width
 //
-// TABLE-SPECIFIC SHIFTS:
-//   Each table uses a different PC shift: 12 + tableNum
+// TABLE-SPECIFIC SALT:
+//   Each table hashes the PC with salt tableNum (see hashPC)
 //   This decorrelates tables - same PC maps to different indices in each table.
 //   Reduces systematic aliasing when multiple tables match.
 //
@@ -787,8 +844,8 @@ func NewTAGEPredictor() *TAGEPredictor {
 //     logic [63:0] h;
 //     logic [31:0] hist_bits;
 //
-//     // Table-specific PC shift for decorrelation
-//     pc_bits = (pc >> (12 + table_num)) & 10'h3FF;
+//     // Table-specific PC hash for decorrelation
+//     pc_bits = hash_pc(pc, table_num, 10);
 //
 //     // Base predictor: no history component
 //     if (history_len == 0) return pc_bits;
@@ -810,13 +867,11 @@ func NewTAGEPredictor() *TAGEPredictor {
 //go:inline
 func hashIndex(pc uint64, history uint64, historyLen int, tableNum int) uint32 {
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	// Step 1: Extract PC bits with table-specific shift
-	// Each table looks at different PC bits for decorrelation
-	// Wire: pc_shift = 12 + table_num
-	// Wire: pc_bits = (pc >> pc_shift) & INDEX_MASK
+	// Step 1: Hash the PC with a table-specific salt
+	// Each table maps the same PC somewhere else for decorrelation
+	// Wire: pc_bits = hash_pc(pc, table_num, INDEX_WIDTH)
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	pcShift := 12 + tableNum
-	pcBits := uint32((pc >> pcShift) & IndexMask)
+	pcBits := hashPC(pc, tableNum, IndexWidth)
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Step 2: Base predictor (Table 0) uses only PC, no history
@@ -864,34 +919,32 @@ func hashIndex(pc uint64, history uint64, historyLen int, tableNum int) uint32 {
 // hashTag extracts a tag from the PC for collision detection.
 //
 // ALGORITHM:
-//   XOR high and low PC bit ranges to use more entropy.
-//   This ensures branches with different addresses get different tags.
-//
-// WHY XOR:
-//   Simple PCs might only differ in low bits (sequential code).
-//   XORing high and low bits uses more of the PC's entropy.
-//   Example: 0x1234_5678_0000_0000 and 0x0000_0000_1234_5678 get different tags.
+//   Hash the PC to 13 bits with its own salt (TagSalt), so the tag is independent of
+//   every table's index: two branches sharing a slot almost always differ in tag.
 //
-// Hardware: Two 13-bit extractions + XOR gate
+// Hardware: 13-bit PC hash (see hashPC)
 // Timing: ~20ps
 //
 // SystemVerilog:
 //   function automatic logic [12:0] hash_tag(input logic [63:0] pc);
-//     logic [12:0] low_bits  = pc[34:22] & 13'h1FFF;
-//     logic [12:0] high_bits = pc[52:40] & 13'h1FFF;
-//     return low_bits ^ high_bits;
+//     return hash_pc(pc, TAG_SALT, 13);
 //   endfunction
 //
 // ───────────────────────────────────────────────────────────────────────────────────────────────
 
+// hashPC salts. Tables 0-7 and the long-history tables use their table number.
+const (
+	TagSalt       = 2 * NumTables // Past every table's salt
+	SCSalt        = TagSalt + 1   // Statistical corrector index
+	LoopIndexSalt = TagSalt + 2
+	LoopTagSalt   = TagSalt + 3
+	NeuralSalt    = TagSalt + 4 // Perceptron rows
+)
+
 //go:inline
 func hashTag(pc uint64) uint16 {
-	// Wire: low_bits = pc[34:22]
-	lowBits := uint16((pc >> 22) & TagMask)
-	// Wire: high_bits = pc[52:40]
-	highBits := uint16((pc >> 40) & TagMask)
-	// Wire: tag = low_bits ^ high_bits
-	return lowBits ^ highBits
+	// Wire: tag = hash_pc(pc, TAG_SALT, TAG_WIDTH)
+	return uint16(hashPC(pc, TagSalt, TagWidth))
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -996,7 +1049,22 @@ func (p *TAGEPredictor) Predict(pc uint64, ctx uint8) (taken bool, confidence ui
 	// Read context's history register
 	// Wire: history = history_regs[ctx]
 	// ─────────────────────────────────────────────────────────────────────────────────────────
-	history := p.History[ctx]
+	return p.predictWithHistory(pc, ctx, p.History[ctx], &p.LongHistory[ctx])
+}
+
+// predictWithHistory is the lookup behind Predict and PredictSpeculative.
+// The caller picks the history registers: architectural or speculative.
+// Enabled TAGE-SC-L extensions (extensions.go) refine the tagged tables' answer.
+func (p *TAGEPredictor) predictWithHistory(pc uint64, ctx uint8, history uint64, long *LongHistory) (taken bool, confidence uint8) {
+	taken, confidence = p.tagePredict(pc, ctx, history)
+	if p.extensionsEnabled() {
+		taken, confidence = p.extendPrediction(pc, ctx, history, long, taken, confidence)
+	}
+	return taken, confidence
+}
+
+// tagePredict is the tagged-table lookup: base predictor + Tables 1-7.
+func (p *TAGEPredictor) tagePredict(pc uint64, ctx uint8, history uint64) (taken bool, confidence uint8) {
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
 	// Compute tag from PC (shared across all tables)
@@ -1182,6 +1250,18 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 		ctx = 0
 	}
 
+	// With extensions on, the final prediction can differ from the tagged tables' own,
+	// so the tables are trained by whether THEY were right (see train)
+	if p.extensionsEnabled() {
+		p.train(pc, ctx, taken)
+		return
+	}
+	p.tageUpdate(pc, ctx, taken)
+}
+
+// tageUpdate is the tagged-table half of Update.
+func (p *TAGEPredictor) tageUpdate(pc uint64, ctx uint8, taken bool) {
+
 	history := p.History[ctx]
 	tag := hashTag(pc)
 
@@ -1302,6 +1382,7 @@ func (p *TAGEPredictor) Update(pc uint64, ctx uint8, taken bool) {
 	if taken {
 		takenBit = 1
 	}
+	shiftLongHistory(history, &p.LongHistory[ctx])
 	p.History[ctx] = (history << 1) | takenBit
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
@@ -1393,6 +1474,16 @@ func (p *TAGEPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
 		ctx = 0
 	}
 
+	if p.extensionsEnabled() {
+		p.train(pc, ctx, actualTaken)
+		return
+	}
+	p.tageOnMispredict(pc, ctx, actualTaken)
+}
+
+// tageOnMispredict is the tagged-table half of OnMispredict.
+func (p *TAGEPredictor) tageOnMispredict(pc uint64, ctx uint8, actualTaken bool) {
+
 	history := p.History[ctx]
 	tag := hashTag(pc)
 
@@ -1489,6 +1580,7 @@ func (p *TAGEPredictor) OnMispredict(pc uint64, ctx uint8, actualTaken bool) {
 	if actualTaken {
 		takenBit = 1
 	}
+	shiftLongHistory(p.History[ctx], &p.LongHistory[ctx])
 	p.History[ctx] = (p.History[ctx] << 1) | takenBit
 
 	// ─────────────────────────────────────────────────────────────────────────────────────────
@@ -1690,12 +1782,20 @@ func allocateEntry(table *TAGETable, tableNum int, pc uint64, ctx uint8, tag uin
 // findLRUVictim selects an entry to evict for allocation.
 //
 // ALGORITHM:
+//   0. If the preferred entry is invalid or has useful=false: return it
 //   1. Search 8 entries around preferred index (bidirectional: -4 to +3)
 //   2. If any entry is invalid (free slot): return immediately
 //   3. If any entry has useful=false: return immediately
 //   4. Otherwise: return oldest entry (highest age)
 //
+// WHY THE PREFERRED ENTRY FIRST:
+//   Lookups read exactly one entry per table: the preferred index. An entry written
+//   anywhere else is never found by the branch it was allocated for. Scanning from -4
+//   first put almost every allocation in a neighbour, so tables 1-7 learned nothing.
+//   The neighbourhood scan now only runs when the preferred entry is useful.
+//
 // PRIORITY ORDER:
+//   0. Preferred entry free or non-useful: Best - the lookup will find it
 //   1. Free slots (invalid): Best - no eviction needed
 //   2. Non-useful entries: Good - entry wasn't helping anyway
 //   3. Oldest entries: Acceptable - entry is stale
@@ -1719,6 +1819,10 @@ func allocateEntry(table *TAGETable, tableNum int, pc uint64, ctx uint8, tag uin
 //     logic [2:0] max_age = 0;
 //     logic [9:0] victim_idx = preferred_idx;
 //
+//     // Preferred entry free or non-useful: take it
+//     if (!table.valid_bits[preferred_idx[9:6]][preferred_idx[5:0]] ||
+//         !table.entries[preferred_idx].useful) return preferred_idx;
+//
 //     // Bidirectional search [-4, +3]
 //     for (int offset = -4; offset < 4; offset++) begin
 //       logic [9:0] idx = (preferred_idx + offset) & INDEX_MASK;
@@ -1745,6 +1849,16 @@ func allocateEntry(table *TAGETable, tableNum int, pc uint64, ctx uint8, tag uin
 
 //go:inline
 func findLRUVictim(table *TAGETable, preferredIdx uint32) uint32 {
+	// ─────────────────────────────────────────────────────────────────────────────────────────
+	// Preferred slot first: it is the only slot Predict() reads for this index
+	// Wire: take_preferred = !valid[preferred_idx] | !entries[preferred_idx].useful
+	// ─────────────────────────────────────────────────────────────────────────────────────────
+	prefWord := preferredIdx >> 6
+	prefBit := preferredIdx & 63
+	if (table.ValidBits[prefWord]>>prefBit)&1 == 0 || !table.Entries[preferredIdx].Useful {
+		return preferredIdx
+	}
+
 	maxAge := uint8(0)
 	victimIdx := preferredIdx
 
@@ -1910,37 +2024,47 @@ func updateCounterWithHysteresis(entry *TAGEEntry, taken bool) {
 func (p *TAGEPredictor) AgeAllEntries() {
 	// Skip Table 0 (base predictor - always valid, never replaced)
 	for t := 1; t < NumTables; t++ {
-		table := &p.Tables[t]
+		ageTable(&p.Tables[t])
+	}
 
-		// Fast bitmap scan (skip empty words)
-		for w := 0; w < ValidBitmapWords; w++ {
-			validMask := table.ValidBits[w]
-			if validMask == 0 {
-				continue // Skip empty word
-			}
+	// Long-history tables age with the rest when enabled
+	if p.LongHistoryEnabled {
+		for t := range p.LongTables {
+			ageTable(&p.LongTables[t])
+		}
+	}
+}
 
-			baseIdx := w * 64
+// ageTable ages every valid entry of one history table.
+func ageTable(table *TAGETable) {
+	// Fast bitmap scan (skip empty words)
+	for w := 0; w < ValidBitmapWords; w++ {
+		validMask := table.ValidBits[w]
+		if validMask == 0 {
+			continue // Skip empty word
+		}
 
-			// Process each valid bit
-			for validMask != 0 {
-				bitPos := bits.TrailingZeros64(validMask)
-				idx := baseIdx + bitPos
+		baseIdx := w * 64
 
-				entry := &table.Entries[idx]
+		// Process each valid bit
+		for validMask != 0 {
+			bitPos := bits.TrailingZeros64(validMask)
+			idx := baseIdx + bitPos
 
-				// Increment age (saturating)
-				if entry.Age < MaxAge {
-					entry.Age++
-				}
+			entry := &table.Entries[idx]
 
-				// Clear useful bit when entry gets old
-				if entry.Age >= MaxAge/2 {
-					entry.Useful = false
-				}
+			// Increment age (saturating)
+			if entry.Age < MaxAge {
+				entry.Age++
+			}
 
-				// Clear processed bit
-				validMask &^= 1 << bitPos
+			// Clear useful bit when entry gets old
+			if entry.Age >= MaxAge/2 {
+				entry.Useful = false
 			}
+
+			// Clear processed bit
+			validMask &^= 1 << bitPos
 		}
 	}
 }
@@ -1952,7 +2076,7 @@ func (p *TAGEPredictor) AgeAllEntries() {
 // Reset clears all learned state, returning predictor to initial condition.
 //
 // ACTIONS:
-//   1. Clear all history registers to 0
+//   1. Clear all history registers to 0 (architectural and speculative)
 //   2. Invalidate all entries in tables 1-7
 //   3. Reset branch count
 //   4. Clear cached metadata
@@ -1974,6 +2098,7 @@ func (p *TAGEPredictor) AgeAllEntries() {
 //     // Clear history registers
 //     for (int ctx = 0; ctx < NUM_CONTEXTS; ctx++) begin
 //       history[ctx] <= 64'b0;
+//       spec_history[ctx] <= 64'b0;
 //     end
 //
 //     // Invalidate history tables
@@ -1989,9 +2114,12 @@ func (p *TAGEPredictor) AgeAllEntries() {
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 
 func (p *TAGEPredictor) Reset() {
-	// Clear history registers
+	// Clear history registers (architectural and speculative)
 	for ctx := 0; ctx < NumContexts; ctx++ {
 		p.History[ctx] = 0
+		p.SpecHistory[ctx] = 0
+		p.LongHistory[ctx] = LongHistory{}
+		p.SpecLongHistory[ctx] = LongHistory{}
 	}
 
 	// Invalidate history tables (word-level clear)
@@ -2004,6 +2132,9 @@ func (p *TAGEPredictor) Reset() {
 	// Clear cached metadata
 	p.LastPrediction.ProviderTable = -1
 	p.LastPrediction.ProviderEntry = nil
+
+	// Clear extension state (enable flags are kept, like AgingEnabled)
+	p.resetExtensions()
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
@@ -2021,6 +2152,13 @@ type TAGEStats struct {
 	AverageAge     [NumTables]float32 // Mean age per table
 	UsefulEntries  [NumTables]uint32  // Entries with useful=true per table
 	AverageCounter [NumTables]float32 // Mean counter value per table
+
+	// TAGE-SC-L extensions (see extensions.go)
+	LongEntriesUsed [NumLongTables]uint32 // Valid entries per long-history table
+	LongHistory     ComponentStats        // Long-history tables
+	Loop            ComponentStats        // Loop predictor
+	Corrector       ComponentStats        // Statistical corrector
+	Transistors     uint64                // Estimate for the enabled configuration
 }
 
 func (p *TAGEPredictor) Stats() TAGEStats {
@@ -2055,6 +2193,7 @@ func (p *TAGEPredictor) Stats() TAGEStats {
 		}
 	}
 
+	p.extensionStats(&stats)
 	return stats
 }
 
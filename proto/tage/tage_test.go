@@ -324,8 +324,8 @@ func TestInit_LastPredictionCleared(t *testing.T) {
 // Key properties:
 //   - Base predictor uses PC only (no history)
 //   - History tables mix PC with history using golden ratio prime
-//   - Each table uses different PC shift for decorrelation
-//   - Tag XORs high/low PC bits for entropy
+//   - Each table hashes the whole PC with its own salt for decorrelation
+//   - Tag hashes the whole PC with a salt of its own
 //   - Hash functions must be deterministic (same input → same output)
 //   - Different PCs can produce same tag (aliasing) - predictor must handle this
 //
@@ -355,9 +355,9 @@ func TestHash_BasePredictorNoDependence(t *testing.T) {
 }
 
 func TestHash_TableSpecificShifts(t *testing.T) {
-	// WHAT: Different tables use different PC bit ranges
+	// WHAT: Different tables map the same PC to different indices
 	// WHY: Decorrelates tables, prevents systematic aliasing
-	// HARDWARE: Table number changes PC shift amount (12 + tableNum)
+	// HARDWARE: Table number is the PC hash salt
 	//
 	// Without decorrelation, all tables would alias on the same branches,
 	// reducing the benefit of multiple history lengths.
@@ -409,12 +409,12 @@ func TestHash_PrimeMixing(t *testing.T) {
 }
 
 func TestHash_TagXORMixing(t *testing.T) {
-	// WHAT: Tag extraction XORs high and low PC bits
-	// WHY: Uses more PC entropy, reduces collisions
-	// HARDWARE: Two 13-bit extractions + XOR gate
+	// WHAT: PCs that differ only in high bits or only in low bits get different tags
+	// WHY: Uses all the PC entropy, reduces collisions
+	// HARDWARE: 13-bit PC hash
 	//
 	// Simple PCs might only differ in low bits (sequential code).
-	// XORing high and low bits uses more entropy.
+	// Hashing every bit uses all the entropy.
 
 	// PC with distinct high/low patterns
 	pc1 := uint64(0x1234567800000000)
@@ -423,9 +423,32 @@ func TestHash_TagXORMixing(t *testing.T) {
 	tag1 := hashTag(pc1)
 	tag2 := hashTag(pc2)
 
-	// XOR mixing should make these different
+	// Hashing should make these different
 	if tag1 == tag2 {
-		t.Error("Tag XOR mixing produced identical tags for different PC regions")
+		t.Error("Tag hashing produced identical tags for different PC regions")
+	}
+}
+
+func TestHash_SmallPCsSpread(t *testing.T) {
+	// WHAT: 256 branches one word apart, as in a 1 KB program on a 32-bit core, spread over
+	//       the base table and get nearly all-distinct tags and table-1 indices
+	// WHY: Index and tag once came from pc[21:12] and pc[34:22], so a small program put
+	//      every branch in one base entry with one tag and tables 1-7 could not tell them
+	//      apart; callers had to scale their PCs up to get any prediction at all
+	// HARDWARE: PC hash covers the low address bits
+
+	bases := make(map[uint32]bool)
+	tagged := make(map[[2]uint32]bool) // (table 1 index, tag)
+	for i := uint64(0); i < 256; i++ {
+		pc := 0x1000 + 4*i
+		bases[hashIndex(pc, 0, 0, 0)] = true
+		tagged[[2]uint32{hashIndex(pc, 0, 4, 1), uint32(hashTag(pc))}] = true
+	}
+	if len(bases) < 200 {
+		t.Errorf("256 adjacent branches share %d base entries (expected ≥200)", len(bases))
+	}
+	if len(tagged) < 250 {
+		t.Errorf("256 adjacent branches have %d distinct (index, tag) pairs (expected ≥250)", len(tagged))
 	}
 }
 
@@ -1989,6 +2012,44 @@ func TestLRU_NonUsefulPreferredOverOld(t *testing.T) {
 	t.Log("Non-useful preferred over old useful (priority order verified)")
 }
 
+func TestLRU_PreferredSlotFirst(t *testing.T) {
+	// WHAT: A free or non-useful preferred slot is the victim, even with free neighbours
+	// WHY: Lookups read only the preferred slot - an entry allocated beside it is never hit
+	// HARDWARE: Preferred-entry check ahead of the 8-way scan
+
+	pred := NewTAGEPredictor()
+	table := &pred.Tables[1]
+	idx := uint32(100)
+
+	// Empty table: every neighbour is free too
+	if victim := findLRUVictim(table, idx); victim != idx {
+		t.Errorf("Free preferred slot: victim %d, expected %d", victim, idx)
+	}
+
+	// Valid but not useful
+	table.ValidBits[idx>>6] |= 1 << (idx & 63)
+	table.Entries[idx] = TAGEEntry{Useful: false}
+	if victim := findLRUVictim(table, idx); victim != idx {
+		t.Errorf("Non-useful preferred slot: victim %d, expected %d", victim, idx)
+	}
+
+	// Useful: fall back to the neighbourhood
+	table.Entries[idx].Useful = true
+	if victim := findLRUVictim(table, idx); victim == idx {
+		t.Error("Useful preferred slot should not be evicted while neighbours are free")
+	}
+
+	// End to end: an allocated entry is found by the next lookup with the same history
+	pc := uint64(5)<<22 | 0x5000
+	pred.History[0] = 0xB
+	pred.OnMispredict(pc, 0, true) // No provider: allocates in Table 1
+	pred.History[0] = 0xB
+	pred.Predict(pc, 0)
+	if pred.LastPrediction.ProviderTable != 1 {
+		t.Errorf("Provider table %d after allocation, expected 1", pred.LastPrediction.ProviderTable)
+	}
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
 // 9. AGING TESTS
 // ═══════════════════════════════════════════════════════════════════════════════════════════════
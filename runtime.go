@@ -0,0 +1,595 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// RUNTIME LIBRARY: crt0 AND A SMALL libc
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: Compiled programs need something to run them: a stack, zeroed bss,
+//
+//	a call to main and a way to exit and print (syscall.go). The library
+//	ships as source and is built by our own assembler and compiler, so
+//	it exercises the same toolchain as the programs it supports.
+//
+// CONTENTS:
+//
+//	crt0.s      _start: zero bss, align sp, exit(main(argc, argv, envp))
+//	libc.s      syscalls:   write, _exit, exit
+//	            memory:     memcpy, memmove, memset, memcmp
+//	            strings:    strlen, strcmp, strcpy
+//	            arithmetic: abs, __divsi3, __modsi3 (signed; DIV and REM
+//	                        are unsigned), __clzsi2, __ctzsi2,
+//	                        __popcountsi2 (no bit-count instructions)
+//	printf.c    printf, vprintf, sprintf, snprintf, vsnprintf, puts,
+//	            putchar
+//
+// PRINTF: Conversions d i u x X o c s p %, flags '-' and '0', a field
+//
+//	width, and l/h length modifiers (ignored: everything is 32 bits).
+//	No precision. printf stages output in a 64-byte buffer and writes
+//	it with one SysWrite per buffer (and one at the end of each call).
+//
+// HEADERS: #include <stdio.h>, <string.h>, <stdlib.h>, <unistd.h>,
+//
+//	<stddef.h> and <stdarg.h> read the declarations in ccHeaders; the
+//	front end (cc.go) ignores any other #include.
+//
+// ENTRY STATE: Both loaders (Core.LoadImage, Core.LoadELF) leave sp at
+//
+//	argc with argv and envp above it, as initStack lays them out.
+//
+// MINECRAFT ANALOGY: The starter kit every new player spawns with: a bed,
+//
+//	a crafting table and a few tools, so nobody starts by punching trees.
+
+// crt0Source is the program entry point
+const crt0Source = `
+	.text
+	.globl _start
+_start:
+	# Zero bss (loaders do too, but a reset must not rely on that)
+	la    t0, __bss_start
+	la    t1, __bss_end
+	addi  t2, t1, -3
+.Lbss_words:
+	sltu  t3, t0, t2           # Room for a whole word?
+	beqz  t3, .Lbss_bytes
+	sw    zero, 0(t0)
+	addi  t0, t0, 4
+	j     .Lbss_words
+.Lbss_bytes:
+	sltu  t3, t0, t1
+	beqz  t3, .Lbss_done
+	sb    zero, 0(t0)
+	addi  t0, t0, 1
+	j     .Lbss_bytes
+.Lbss_done:
+
+	# argc at sp, argv above it, envp after argv's NULL
+	lw    a0, 0(sp)
+	addi  a1, sp, 4
+	addi  t0, a0, 1
+	add   t0, t0, t0
+	add   t0, t0, t0
+	add   a2, a1, t0
+	andi  sp, sp, -16          # StackAlign
+	mv    fp, zero             # Outermost frame
+
+	call  main
+	call  exit
+`
+
+// libcSource holds the assembly half of the C library
+const libcSource = `
+	.text
+
+# ─── System calls (syscall.go) ───────────────────────────────────────
+
+	.globl write
+write:                         # a0 = fd, a1 = buf, a2 = len
+	system 1
+	ret
+
+	.globl exit
+exit:
+	.globl _exit
+_exit:                         # a0 = status
+	system 0
+	j     _exit                # Not reached
+
+# ─── Memory ──────────────────────────────────────────────────────────
+
+	.globl memcpy
+memcpy:                        # a0 = dst, a1 = src, a2 = n; returns dst
+	mv    t0, a0
+	or    t1, a0, a1
+	andi  t1, t1, 3
+	bnez  t1, .Lmemcpy_bytes   # Misaligned: byte by byte
+	addi  t4, zero, 16
+.Lmemcpy_16:
+	sltu  t1, a2, t4
+	bnez  t1, .Lmemcpy_words
+	lw    t1, 0(a1)
+	lw    t2, 4(a1)
+	lw    t3, 8(a1)
+	lw    t5, 12(a1)
+	sw    t1, 0(t0)
+	sw    t2, 4(t0)
+	sw    t3, 8(t0)
+	sw    t5, 12(t0)
+	addi  a1, a1, 16
+	addi  t0, t0, 16
+	addi  a2, a2, -16
+	j     .Lmemcpy_16
+.Lmemcpy_words:
+	addi  t4, zero, 4
+.Lmemcpy_word:
+	sltu  t1, a2, t4
+	bnez  t1, .Lmemcpy_bytes
+	lw    t1, 0(a1)
+	sw    t1, 0(t0)
+	addi  a1, a1, 4
+	addi  t0, t0, 4
+	addi  a2, a2, -4
+	j     .Lmemcpy_word
+.Lmemcpy_bytes:
+	beqz  a2, .Lmemcpy_done
+	lbu   t1, 0(a1)
+	sb    t1, 0(t0)
+	addi  a1, a1, 1
+	addi  t0, t0, 1
+	addi  a2, a2, -1
+	j     .Lmemcpy_bytes
+.Lmemcpy_done:
+	ret
+
+	.globl memmove
+memmove:                       # Like memcpy, but the ranges may overlap
+	sltu  t0, a1, a0           # src < dst ...
+	add   t1, a1, a2
+	sltu  t1, a0, t1           # ... and dst < src + n: copy backwards
+	and   t0, t0, t1
+	beqz  t0, memcpy
+	add   t0, a0, a2
+	add   t1, a1, a2
+.Lmemmove_bytes:
+	beq   t0, a0, .Lmemmove_done
+	addi  t0, t0, -1
+	addi  t1, t1, -1
+	lbu   t2, 0(t1)
+	sb    t2, 0(t0)
+	j     .Lmemmove_bytes
+.Lmemmove_done:
+	ret
+
+	.globl memset
+memset:                        # a0 = dst, a1 = byte, a2 = n; returns dst
+	mv    t0, a0
+	andi  a1, a1, 255
+.Lmemset_head:                 # Bytes up to a word boundary
+	beqz  a2, .Lmemset_done
+	andi  t1, t0, 3
+	beqz  t1, .Lmemset_body
+	sb    a1, 0(t0)
+	addi  t0, t0, 1
+	addi  a2, a2, -1
+	j     .Lmemset_head
+.Lmemset_body:
+	li    t1, 0x01010101       # Replicate the byte into a word
+	mul   t1, a1, t1
+	addi  t4, zero, 4
+.Lmemset_words:
+	sltu  t2, a2, t4
+	bnez  t2, .Lmemset_tail
+	sw    t1, 0(t0)
+	addi  t0, t0, 4
+	addi  a2, a2, -4
+	j     .Lmemset_words
+.Lmemset_tail:
+	beqz  a2, .Lmemset_done
+	sb    a1, 0(t0)
+	addi  t0, t0, 1
+	addi  a2, a2, -1
+	j     .Lmemset_tail
+.Lmemset_done:
+	ret
+
+	.globl memcmp
+memcmp:                        # Returns <0, 0 or >0 (bytes unsigned)
+	beqz  a2, .Lmemcmp_equal
+	lbu   t0, 0(a0)
+	lbu   t1, 0(a1)
+	bne   t0, t1, .Lmemcmp_differ
+	addi  a0, a0, 1
+	addi  a1, a1, 1
+	addi  a2, a2, -1
+	j     memcmp
+.Lmemcmp_differ:
+	sub   a0, t0, t1
+	ret
+.Lmemcmp_equal:
+	mv    a0, zero
+	ret
+
+# ─── Strings ─────────────────────────────────────────────────────────
+
+	.globl strlen
+strlen:
+	mv    t0, a0
+.Lstrlen_loop:
+	lbu   t1, 0(t0)
+	beqz  t1, .Lstrlen_done
+	addi  t0, t0, 1
+	j     .Lstrlen_loop
+.Lstrlen_done:
+	sub   a0, t0, a0
+	ret
+
+	.globl strcmp
+strcmp:
+	lbu   t0, 0(a0)
+	lbu   t1, 0(a1)
+	bne   t0, t1, .Lstrcmp_differ
+	beqz  t0, .Lstrcmp_differ  # Both ended: t0 - t1 = 0
+	addi  a0, a0, 1
+	addi  a1, a1, 1
+	j     strcmp
+.Lstrcmp_differ:
+	sub   a0, t0, t1
+	ret
+
+	.globl strcpy
+strcpy:                        # Returns dst
+	mv    t0, a0
+.Lstrcpy_loop:
+	lbu   t1, 0(a1)
+	sb    t1, 0(t0)
+	addi  a1, a1, 1
+	addi  t0, t0, 1
+	bnez  t1, .Lstrcpy_loop
+	ret
+
+# ─── Arithmetic the ISA lacks ────────────────────────────────────────
+
+	.globl abs
+abs:
+	bge   a0, zero, .Labs_done
+	neg   a0, a0
+.Labs_done:
+	ret
+
+	.globl __divsi3
+__divsi3:                      # Signed a0 / a1, truncating toward zero
+	xor   t0, a0, a1           # Bit 31: the quotient is negative
+	bge   a0, zero, .Ldiv_a
+	neg   a0, a0
+.Ldiv_a:
+	bge   a1, zero, .Ldiv_b
+	neg   a1, a1
+.Ldiv_b:
+	div   a0, a0, a1
+	bge   t0, zero, .Ldiv_done
+	neg   a0, a0
+.Ldiv_done:
+	ret
+
+	.globl __modsi3
+__modsi3:                      # Signed a0 % a1, sign of the dividend
+	mv    t0, a0
+	bge   a0, zero, .Lmod_a
+	neg   a0, a0
+.Lmod_a:
+	bge   a1, zero, .Lmod_b
+	neg   a1, a1
+.Lmod_b:
+	rem   a0, a0, a1
+	bge   t0, zero, .Lmod_done
+	neg   a0, a0
+.Lmod_done:
+	ret
+
+	.globl __clzsi2
+__clzsi2:                      # Leading zeros (32 for 0)
+	addi  t0, zero, 32
+	beqz  a0, .Lclz_done
+	mv    t0, zero
+.Lclz_loop:
+	blt   a0, zero, .Lclz_done # Top bit set
+	add   a0, a0, a0
+	addi  t0, t0, 1
+	j     .Lclz_loop
+.Lclz_done:
+	mv    a0, t0
+	ret
+
+	.globl __ctzsi2
+__ctzsi2:                      # Trailing zeros (32 for 0)
+	addi  t0, zero, 32
+	beqz  a0, .Lctz_done
+	mv    t0, zero
+	addi  t2, zero, 1
+.Lctz_loop:
+	andi  t1, a0, 1
+	bnez  t1, .Lctz_done
+	srl   a0, a0, t2
+	addi  t0, t0, 1
+	j     .Lctz_loop
+.Lctz_done:
+	mv    a0, t0
+	ret
+
+	.globl __popcountsi2
+__popcountsi2:                 # Set bits: clear the lowest until none left
+	mv    t0, zero
+.Lpopcount_loop:
+	beqz  a0, .Lpopcount_done
+	addi  t1, a0, -1
+	and   a0, a0, t1
+	addi  t0, t0, 1
+	j     .Lpopcount_loop
+.Lpopcount_done:
+	mv    a0, t0
+	ret
+`
+
+// printfSource holds the C half of the C library
+const printfSource = `
+#include <stdio.h>
+#include <string.h>
+#include <unistd.h>
+
+/* fmt_out collects formatted output: staged for write (fd >= 0) or
+   stored into a caller's buffer (fd < 0, excess dropped) */
+typedef struct {
+	char *buf;
+	unsigned len;
+	unsigned cap;
+	int fd;
+	int total;
+} fmt_out;
+
+static void fmt_flush(fmt_out *o) {
+	if (o->fd >= 0 && o->len > 0) {
+		write(o->fd, o->buf, o->len);
+		o->len = 0;
+	}
+}
+
+static void fmt_put(fmt_out *o, int c) {
+	if (o->len == o->cap)
+		fmt_flush(o);
+	if (o->len < o->cap)
+		o->buf[o->len++] = c;
+	o->total++;
+}
+
+static void fmt_pad(fmt_out *o, int c, int n) {
+	while (n-- > 0)
+		fmt_put(o, c);
+}
+
+static void fmt_format(fmt_out *o, const char *fmt, va_list ap) {
+	char digits[16];
+	for (; *fmt; fmt++) {
+		if (*fmt != '%') {
+			fmt_put(o, *fmt);
+			continue;
+		}
+		fmt++;
+
+		int left = 0, zero = 0, width = 0;
+		for (;; fmt++) {
+			if (*fmt == '-')
+				left = 1;
+			else if (*fmt == '0')
+				zero = 1;
+			else
+				break;
+		}
+		while (*fmt >= '0' && *fmt <= '9')
+			width = width * 10 + (*fmt++ - '0');
+		while (*fmt == 'l' || *fmt == 'h')
+			fmt++;
+
+		int c = *fmt;
+		if (c == 0)
+			break;
+		const char *s = digits;
+		int len = 1, sign = 0;
+		if (c == 'd' || c == 'i' || c == 'u' || c == 'x' || c == 'X' || c == 'o' || c == 'p') {
+			unsigned v = va_arg(ap, unsigned);
+			unsigned base = 10;
+			const char *dig = "0123456789abcdef";
+			if (c == 'd' || c == 'i') {
+				if ((int)v < 0) {
+					sign = '-';
+					v = -v;
+				}
+			} else if (c == 'x' || c == 'p') {
+				base = 16;
+			} else if (c == 'X') {
+				base = 16;
+				dig = "0123456789ABCDEF";
+			} else if (c == 'o') {
+				base = 8;
+			}
+			char *p = digits + sizeof(digits);
+			do {
+				*--p = dig[v % base];
+				v /= base;
+			} while (v);
+			if (c == 'p') {
+				*--p = 'x';
+				*--p = '0';
+			}
+			s = p;
+			len = digits + sizeof(digits) - p;
+		} else if (c == 's') {
+			s = va_arg(ap, const char *);
+			if (!s)
+				s = "(null)";
+			len = strlen(s);
+		} else if (c == 'c') {
+			digits[0] = va_arg(ap, int);
+		} else {
+			digits[0] = c; /* %% and unknown conversions */
+		}
+
+		int pad = width - len - (sign != 0);
+		if (!left && !zero)
+			fmt_pad(o, ' ', pad);
+		if (sign)
+			fmt_put(o, sign);
+		if (!left && zero)
+			fmt_pad(o, '0', pad);
+		while (len-- > 0)
+			fmt_put(o, *s++);
+		if (left)
+			fmt_pad(o, ' ', pad);
+	}
+}
+
+int vprintf(const char *fmt, va_list ap) {
+	char buf[64];
+	fmt_out o;
+	o.buf = buf;
+	o.len = 0;
+	o.cap = sizeof(buf);
+	o.fd = 1;
+	o.total = 0;
+	fmt_format(&o, fmt, ap);
+	fmt_flush(&o);
+	return o.total;
+}
+
+int printf(const char *fmt, ...) {
+	va_list ap;
+	va_start(ap, fmt);
+	int n = vprintf(fmt, ap);
+	va_end(ap);
+	return n;
+}
+
+int vsnprintf(char *buf, size_t size, const char *fmt, va_list ap) {
+	fmt_out o;
+	o.buf = buf;
+	o.len = 0;
+	o.cap = size ? size - 1 : 0;
+	o.fd = -1;
+	o.total = 0;
+	fmt_format(&o, fmt, ap);
+	if (size)
+		buf[o.len] = 0;
+	return o.total;
+}
+
+int snprintf(char *buf, size_t size, const char *fmt, ...) {
+	va_list ap;
+	va_start(ap, fmt);
+	int n = vsnprintf(buf, size, fmt, ap);
+	va_end(ap);
+	return n;
+}
+
+int sprintf(char *buf, const char *fmt, ...) {
+	va_list ap;
+	va_start(ap, fmt);
+	int n = vsnprintf(buf, 0x7FFFFFFF, fmt, ap);
+	va_end(ap);
+	return n;
+}
+
+int putchar(int c) {
+	char b = c;
+	write(1, &b, 1);
+	return c & 255;
+}
+
+int puts(const char *s) {
+	write(1, s, strlen(s));
+	write(1, "\n", 1);
+	return 0;
+}
+`
+
+// ccHeaders are the headers #include can read (see lexer.include)
+var ccHeaders = map[string]string{
+	"stddef.h": `
+typedef unsigned int size_t;
+typedef int ptrdiff_t;
+#define NULL ((void *)0)
+`,
+	"stdarg.h": `
+/* va_list, va_start, va_arg and va_end are built into the compiler */
+`,
+	"unistd.h": `
+#include <stddef.h>
+int write(int fd, const void *buf, size_t n);
+void _exit(int status);
+`,
+	"stdlib.h": `
+#include <stddef.h>
+#define EXIT_SUCCESS 0
+#define EXIT_FAILURE 1
+void exit(int status);
+int abs(int x);
+`,
+	"string.h": `
+#include <stddef.h>
+void *memcpy(void *dst, const void *src, size_t n);
+void *memmove(void *dst, const void *src, size_t n);
+void *memset(void *dst, int c, size_t n);
+int memcmp(const void *a, const void *b, size_t n);
+size_t strlen(const char *s);
+int strcmp(const char *a, const char *b);
+char *strcpy(char *dst, const char *src);
+`,
+	"stdio.h": `
+#include <stddef.h>
+#include <stdarg.h>
+#define EOF (-1)
+int printf(const char *fmt, ...);
+int vprintf(const char *fmt, va_list ap);
+int sprintf(char *buf, const char *fmt, ...);
+int snprintf(char *buf, size_t size, const char *fmt, ...);
+int vsnprintf(char *buf, size_t size, const char *fmt, va_list ap);
+int putchar(int c);
+int puts(const char *s);
+`,
+}
+
+// RuntimeObjects builds crt0 and the C library from source
+//
+// crt0 comes first, so _start is at the start of .text.
+func RuntimeObjects() ([]*Object, error) {
+	crt0, err := Assemble("crt0.s", crt0Source)
+	if err != nil {
+		return nil, err
+	}
+	libc, err := Assemble("libc.s", libcSource)
+	if err != nil {
+		return nil, err
+	}
+	printf, err := CompileObject("printf.c", printfSource)
+	if err != nil {
+		return nil, err
+	}
+	return []*Object{crt0, libc, printf}, nil
+}
+
+// LinkProgram links objects with the runtime (nil script = default)
+func LinkProgram(objs []*Object, script *LinkerScript) (*Image, error) {
+	rt, err := RuntimeObjects()
+	if err != nil {
+		return nil, err
+	}
+	return Link(append(rt, objs...), script)
+}
+
+// BuildC compiles one C file and links it with the runtime
+func BuildC(name, src string) (*Image, error) {
+	obj, err := CompileObject(name, src)
+	if err != nil {
+		return nil, err
+	}
+	return LinkProgram([]*Object{obj}, nil)
+}
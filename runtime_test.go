@@ -0,0 +1,362 @@
+package suprax32
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Runtime Library - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The runtime is only useful if compiled programs start, print and exit on the real core, so
+// every test builds C with BuildC, runs it on Core and checks the console and exit status.
+// Each program also runs with the ABI checker on: library code must keep the convention the
+// compiler relies on.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. STARTUP AND EXIT TESTS
+//    Exit status, bss zeroing, argv/envp through LoadELF and LoadImage
+//
+// 2. LIBRARY TESTS
+//    printf conversions, string and memory routines, software arithmetic
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runC builds and runs a C program, returning its console output and exit status
+func runC(t *testing.T, src string) (string, int32) {
+	t.Helper()
+	img, err := BuildC("test.c", src)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	core.SetABICheck(true)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	core.Run(2_000_000)
+
+	status, ok := core.Exited()
+	if !ok {
+		t.Fatalf("program did not exit; console so far: %q", console.String())
+	}
+	for _, v := range core.ABICheck().Violations() {
+		t.Errorf("ABI violation: %s", v.Format(core.Symbols()))
+	}
+	return console.String(), int32(status)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. STARTUP AND EXIT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestRuntime_ExitStatus(t *testing.T) {
+	// WHAT: main's return value becomes the exit status; exit() leaves without returning
+	// WHY: Test programs report pass/fail through the status
+	// HARDWARE: SysExit halts the core at commit
+	// CATEGORY: [INTEGRATION]
+
+	if _, status := runC(t, `int main() { return 42; }`); status != 42 {
+		t.Errorf("return 42: status = %d", status)
+	}
+
+	out, status := runC(t, `
+#include <stdio.h>
+#include <stdlib.h>
+void fail(void) { printf("leaving\n"); exit(-3); }
+int main() { fail(); printf("unreachable\n"); return 0; }`)
+	if status != -3 || out != "leaving\n" {
+		t.Errorf("exit(-3): status = %d, output %q", status, out)
+	}
+}
+
+func TestRuntime_HaltStopsCore(t *testing.T) {
+	// WHAT: After SysExit, Cycle and Run do nothing
+	// WHY: Runners call Run with a generous budget and read cycles afterwards
+	// CATEGORY: [UNIT]
+
+	img, err := BuildC("test.c", `int main() { return 0; }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := NewCore(1 << 20)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatal(err)
+	}
+	core.Run(1_000_000)
+	if _, ok := core.Exited(); !ok {
+		t.Fatal("program did not exit")
+	}
+	cycles, insts := core.cycles, core.instructions
+	core.Run(2_000_000)
+	if core.cycles != cycles || core.instructions != insts {
+		t.Errorf("core kept running after exit: %d→%d cycles", cycles, core.cycles)
+	}
+}
+
+func TestRuntime_BSSZeroed(t *testing.T) {
+	// WHAT: crt0 clears bss even when memory already held stale data
+	// WHY: A reset does not clear memory; C requires zeroed statics
+	// CATEGORY: [INTEGRATION]
+
+	img, err := BuildC("test.c", `
+char odd[7];
+int big[33];
+int main() {
+	int sum = 0;
+	for (int i = 0; i < 7; i++) sum += odd[i];
+	for (int i = 0; i < 33; i++) sum += big[i];
+	return sum;
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := NewCore(1 << 20)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatal(err)
+	}
+	start, end := img.Symbols["__bss_start"], img.Symbols["__bss_end"]
+	for a := start; a < end; a++ {
+		core.memory[a] = 0xA5 // Stale contents the loader did not clear
+	}
+	core.Run(1_000_000)
+	if status, ok := core.Exited(); !ok || status != 0 {
+		t.Errorf("sum of bss = %d (exited %v), expected 0", status, ok)
+	}
+}
+
+func TestRuntime_ArgvFromELF(t *testing.T) {
+	// WHAT: argc, argv and envp reach main through the ELF loader's initial stack
+	// WHY: crt0 must agree with initStack's layout
+	// CATEGORY: [INTEGRATION]
+
+	img, err := BuildC("test.c", `
+#include <stdio.h>
+int main(int argc, char **argv, char **envp) {
+	for (int i = 0; i < argc; i++)
+		printf("%s|", argv[i]);
+	printf("%s %d\n", envp[0], argv[argc] == 0);
+	return argc;
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var elf bytes.Buffer
+	if err := img.WriteELF(&elf); err != nil {
+		t.Fatal(err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	if err := core.LoadELF(bytes.NewReader(elf.Bytes()), []string{"prog", "a b"}, []string{"HOME=/"}); err != nil {
+		t.Fatal(err)
+	}
+	core.Run(1_000_000)
+
+	if status, _ := core.Exited(); status != 2 {
+		t.Errorf("status = %d, expected argc = 2", status)
+	}
+	if got, want := console.String(), "prog|a b|HOME=/ 1\n"; got != want {
+		t.Errorf("output %q, expected %q", got, want)
+	}
+}
+
+func TestRuntime_ArgvFromImage(t *testing.T) {
+	// WHAT: Arguments given to LoadImage reach main as argc and argv, with an empty envp
+	// WHY: The simulator command runs .c, .s and .sxo programs through LoadImage and
+	//      must hand them their arguments exactly as it does an ELF executable
+	// CATEGORY: [INTEGRATION]
+
+	img, err := BuildC("test.c", `
+#include <stdio.h>
+int main(int argc, char **argv, char **envp) {
+	for (int i = 0; i < argc; i++)
+		printf("%s|", argv[i]);
+	printf("%d %d\n", argv[argc] == 0, envp[0] == 0);
+	return argc;
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	if err := core.LoadImage(img, "prog.c", "-v", "x y"); err != nil {
+		t.Fatal(err)
+	}
+	core.Run(1_000_000)
+
+	if status, _ := core.Exited(); status != 3 {
+		t.Errorf("status = %d, expected argc = 3", status)
+	}
+	if got, want := console.String(), "prog.c|-v|x y|1 1\n"; got != want {
+		t.Errorf("output %q, expected %q", got, want)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. LIBRARY TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestRuntime_Printf(t *testing.T) {
+	// WHAT: Every supported conversion, flag and width
+	// WHY: printf is how benchmarks and tests report results
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	out, _ := runC(t, `
+#include <stdio.h>
+int main() {
+	printf("%d %i %u %x %X %o\n", -42, 0, 4000000000u, 255, 0xBEEF, 8);
+	printf("%c%s%% %s\n", 'A', "bc", (char *)0);
+	printf("[%5d][%-5d][%05d][%3s][%-3s]\n", 42, 42, -42, "x", "y");
+	printf("%d %d %p\n", -2147483647 - 1, 2147483647, (void *)0x1234);
+	printf("%ld %hd %lu\n", 1L, 2, 3ul);
+	return 0;
+}`)
+	want := "-42 0 4000000000 ff BEEF 10\n" +
+		"Abc% (null)\n" +
+		"[   42][42   ][-0042][  x][y  ]\n" +
+		"-2147483648 2147483647 0x1234\n" +
+		"1 2 3\n"
+	if out != want {
+		t.Errorf("output:\n%s\nexpected:\n%s", out, want)
+	}
+}
+
+func TestRuntime_PrintfLongOutput(t *testing.T) {
+	// WHAT: Output longer than printf's staging buffer arrives whole and in order
+	// WHY: The buffer is flushed mid-call with SysWrite
+	// CATEGORY: [BOUNDARY]
+
+	out, _ := runC(t, `
+#include <stdio.h>
+int main() {
+	for (int i = 0; i < 10; i++)
+		printf("%s%d", "line-of-text-", i);
+	printf("%s\n", "0123456789012345678901234567890123456789012345678901234567890123456789");
+	return 0;
+}`)
+	want := ""
+	for i := 0; i < 10; i++ {
+		want += "line-of-text-" + string(rune('0'+i))
+	}
+	want += "0123456789012345678901234567890123456789012345678901234567890123456789\n"
+	if out != want {
+		t.Errorf("output %q, expected %q", out, want)
+	}
+}
+
+func TestRuntime_Sprintf(t *testing.T) {
+	// WHAT: sprintf and snprintf write NUL-terminated strings; snprintf truncates but
+	//       returns the full length; puts and putchar add nothing unexpected
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	out, _ := runC(t, `
+#include <stdio.h>
+#include <string.h>
+int main() {
+	char buf[16];
+	int n = sprintf(buf, "%s-%03d", "id", 7);
+	printf("%s %d %d\n", buf, n, (int)strlen(buf));
+	n = snprintf(buf, 5, "%d", 123456789);
+	printf("%s %d\n", buf, n);
+	n = snprintf(buf, 0, "abc");
+	putchar('0' + n);
+	putchar('\n');
+	puts("done");
+	return 0;
+}`)
+	if want := "id-007 6 6\n1234 9\n3\ndone\n"; out != want {
+		t.Errorf("output %q, expected %q", out, want)
+	}
+}
+
+func TestRuntime_StringAndMemory(t *testing.T) {
+	// WHAT: memcpy/memmove/memset/memcmp/strlen/strcmp/strcpy, aligned and not
+	// WHY: The word-at-a-time paths only run for aligned pointers
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	out, status := runC(t, `
+#include <stdio.h>
+#include <string.h>
+char a[64], b[64];
+int main() {
+	int bad = 0;
+	for (int i = 0; i < 64; i++) a[i] = i;
+
+	for (int off = 0; off < 4; off++) {
+		for (int n = 0; n < 40; n += 7) {
+			memset(b, 0x55, 64);
+			memcpy(b + off, a + 1, n);
+			for (int i = 0; i < 64; i++) {
+				int want = i >= off && i < off + n ? i - off + 1 : 0x55;
+				if (b[i] != want) bad++;
+			}
+			memset(b + off, 0x80 + n, n);
+			for (int i = 0; i < n; i++)
+				if ((unsigned char)b[off + i] != 0x80 + n) bad++;
+			if (b[off + n] != 0x55) bad++;
+		}
+	}
+
+	memcpy(b, a, 64);
+	memmove(b + 3, b, 20);  /* Overlapping, forward */
+	for (int i = 0; i < 20; i++) if (b[3 + i] != i) bad++;
+	memcpy(b, a, 64);
+	memmove(b, b + 5, 20);  /* Overlapping, backward */
+	for (int i = 0; i < 20; i++) if (b[i] != i + 5) bad++;
+
+	char s[8];
+	strcpy(s, "hello");
+	printf("%d %d %d %d\n", (int)strlen(s), strlen("") == 0, strcmp(s, "hello"), strcmp("abc", "abd") < 0);
+	printf("%d %d %d\n", strcmp("b", "abc") > 0, memcmp("ab\x80", "ab\x01", 3) > 0, memcmp("x", "y", 0));
+	return bad;
+}`)
+	if status != 0 {
+		t.Errorf("%d memory routine mismatches", status)
+	}
+	if want := "5 1 0 1\n1 1 0\n"; out != want {
+		t.Errorf("output %q, expected %q", out, want)
+	}
+}
+
+func TestRuntime_SoftwareArithmetic(t *testing.T) {
+	// WHAT: Signed division and bit counting, which the ISA does not provide
+	// WHY: DIV and REM are unsigned; there are no count instructions
+	// CATEGORY: [UNIT] [BOUNDARY]
+
+	out, _ := runC(t, `
+#include <stdio.h>
+#include <stdlib.h>
+int __divsi3(int a, int b);
+int __modsi3(int a, int b);
+int __clzsi2(unsigned x);
+int __ctzsi2(unsigned x);
+int __popcountsi2(unsigned x);
+int main() {
+	printf("%d %d %d %d\n", __divsi3(7, 2), __divsi3(-7, 2), __divsi3(7, -2), __divsi3(-7, -2));
+	printf("%d %d %d %d\n", __modsi3(7, 2), __modsi3(-7, 2), __modsi3(7, -2), __modsi3(-7, -2));
+	printf("%d %d %d %d\n", __clzsi2(0), __clzsi2(1), __clzsi2(0x80000000), __clzsi2(0x00F00000));
+	printf("%d %d %d %d\n", __ctzsi2(0), __ctzsi2(1), __ctzsi2(0x80000000), __ctzsi2(0x00F00000));
+	printf("%d %d %d %d\n", __popcountsi2(0), __popcountsi2(0xFFFFFFFF), __popcountsi2(0x8001), abs(-9));
+	return 0;
+}`)
+	want := "3 -3 -3 3\n" +
+		"1 -1 1 -1\n" +
+		"32 31 0 8\n" +
+		"32 0 31 20\n" +
+		"0 32 2 9\n"
+	if out != want {
+		t.Errorf("output:\n%s\nexpected:\n%s", out, want)
+	}
+}
@@ -0,0 +1,88 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// PLUGGABLE SPECULATIVE DIRECTION PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: branchtrace.go replays committed branches one at a time, so every
+//
+//	candidate there predicts with perfect training timing. In the core,
+//	fetch predicts a dozen branches before the oldest commits. A
+//	history-based predictor (proto/tage) that only learns outcomes at
+//	commit predicts them all with history that is missing the newest
+//	outcomes. A SpeculativeDirectionPredictor replaces the 4-bit
+//	counters' direction for every conditional branch the front end
+//	fetches and is told exactly what happened to each one.
+//
+// THE LIFECYCLE (seq is the branch's fetch sequence number):
+//
+//	fetch     PredictSpeculative(seq, pc)   direction; extend the
+//	                                        speculative history with it
+//	commit    Repair(seq, outcome)          only if the direction was
+//	                                        wrong: every younger branch
+//	                                        is on the wrong path
+//	          Commit(seq, outcome)          train, in program order
+//	redirect  Flush()                       every branch still in flight
+//	                                        is gone (mispredict, trap,
+//	                                        fence, CSR write)
+//
+//	Wrong-path branches are predicted but never committed; Repair or
+//	Flush is the last the predictor hears of them.
+//
+// RESOLVING AT COMMIT: The core checks branches when they commit, so a
+//
+//	mispredicted branch is already the oldest in flight. Repair and
+//	Commit leave the speculative and committed histories equal, and the
+//	Flush from the redirect that follows finds nothing left to discard.
+//	A core that redirected at execute would call Repair alone.
+//
+// USAGE:
+//
+//	core.SetDirectionPredictor(p)   // nil restores the 4-bit counters
+//
+//	The counters still train and still give the confidence for branch
+//	target prefetch; only the fetched direction changes.
+//
+// MINECRAFT ANALOGY: A scout who calls each fork in the tunnel while the
+//
+//	miners are still three forks behind, and who has to be told which
+//	calls they dug past and which ones sent them down a dead end.
+
+// SpeculativeDirectionPredictor predicts conditional branches at fetch
+// with a history extended by its own predictions (see above)
+type SpeculativeDirectionPredictor interface {
+	PredictSpeculative(seq uint64, pc uint32) (taken bool)
+	Repair(seq uint64, taken bool)
+	Flush()
+	Commit(seq uint64, taken bool)
+}
+
+// SetDirectionPredictor makes p predict every conditional branch the front
+// end fetches (nil restores the 4-bit counters)
+func (c *Core) SetDirectionPredictor(p SpeculativeDirectionPredictor) {
+	c.direction = p
+}
+
+// predictDirection overrides a conditional branch's fetched direction and
+// target with the plugged-in predictor's
+func (c *Core) predictDirection(inst Instruction, taken bool, target uint32) (bool, uint32) {
+	if c.direction == nil || !inst.IsBranch {
+		return taken, target
+	}
+	if c.direction.PredictSpeculative(inst.Seq, inst.PC) {
+		return true, uint32(int32(inst.PC) + inst.Imm)
+	}
+	return false, inst.PC + 4
+}
+
+// commitDirection repairs (if mispredicted) and trains the plugged-in
+// predictor with a committed conditional branch
+func (c *Core) commitDirection(e *WindowEntry) {
+	if c.direction == nil || !e.IsBranch {
+		return
+	}
+	if e.BranchTaken != e.Predicted {
+		c.direction.Repair(e.Seq, e.BranchTaken)
+	}
+	c.direction.Commit(e.Seq, e.BranchTaken)
+}
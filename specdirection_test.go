@@ -0,0 +1,298 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"suprax/proto/tage"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Pluggable Speculative Direction Predictor - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The hook is only as good as what it tells the predictor. A recording predictor checks
+// the lifecycle against the core: every committed conditional branch was predicted and
+// commits once, in fetch order; Repair comes only for a wrong direction; nothing the
+// predictor saw is left unaccounted for after a flush. Then proto/tage's TAGE runs every
+// standard kernel in the core twice, once learning only at commit and once with
+// speculative history, to show what the checkpoints buy in a real front end.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. LIFECYCLE TESTS
+//    Predict, repair, flush and commit as the core calls them
+//
+// 2. TAGE IN THE CORE
+//    4-bit counters vs TAGE at commit vs speculative TAGE on kernel runs
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// recordingDirection predicts a fixed direction and checks the core's calls
+type recordingDirection struct {
+	t        *testing.T
+	taken    bool
+	inFlight map[uint64]uint32 // seq → pc, predicted and not yet committed or discarded
+	last     uint64            // Last committed seq
+
+	predicted, repaired, flushed, committed int
+}
+
+func (r *recordingDirection) PredictSpeculative(seq uint64, pc uint32) bool {
+	if _, dup := r.inFlight[seq]; dup {
+		r.t.Errorf("seq %d predicted twice", seq)
+	}
+	r.inFlight[seq] = pc
+	r.predicted++
+	return r.taken
+}
+
+func (r *recordingDirection) Repair(seq uint64, taken bool) {
+	if _, ok := r.inFlight[seq]; !ok {
+		r.t.Errorf("Repair of seq %d, which is not in flight", seq)
+	}
+	if taken == r.taken {
+		r.t.Errorf("Repair of seq %d with the predicted direction", seq)
+	}
+	for s := range r.inFlight {
+		if s > seq {
+			delete(r.inFlight, s)
+		}
+	}
+	r.repaired++
+}
+
+func (r *recordingDirection) Flush() {
+	clear(r.inFlight)
+	r.flushed++
+}
+
+func (r *recordingDirection) Commit(seq uint64, taken bool) {
+	if _, ok := r.inFlight[seq]; !ok {
+		r.t.Errorf("Commit of seq %d, which is not in flight", seq)
+	}
+	if seq <= r.last && r.committed > 0 {
+		r.t.Errorf("Commit of seq %d after seq %d", seq, r.last)
+	}
+	for s := range r.inFlight {
+		if s < seq {
+			r.t.Errorf("seq %d still in flight when younger seq %d commits", s, seq)
+			delete(r.inFlight, s)
+		}
+	}
+	delete(r.inFlight, seq)
+	r.last = seq
+	r.committed++
+}
+
+// tageFrontEnd plugs proto/tage's TAGE into the core (context 0)
+//
+// Speculative: PredictSpeculative, Repair, Flush and Commit with checkpoints. Otherwise the
+// model as it was before speculative history: Predict at fetch with the committed history,
+// Update or OnMispredict at commit.
+type tageFrontEnd struct {
+	p           *tage.TAGEPredictor
+	speculative bool
+	checkpoints map[uint64]tage.HistoryCheckpoint
+	predictions map[uint64]fetchedBranch
+}
+
+// fetchedBranch is what the non-speculative model remembers from fetch
+type fetchedBranch struct {
+	pc    uint32
+	taken bool
+}
+
+func newTAGEFrontEnd(speculative bool) *tageFrontEnd {
+	return &tageFrontEnd{
+		p: tage.NewTAGEPredictor(), speculative: speculative,
+		checkpoints: map[uint64]tage.HistoryCheckpoint{}, predictions: map[uint64]fetchedBranch{},
+	}
+}
+
+func (f *tageFrontEnd) PredictSpeculative(seq uint64, pc uint32) bool {
+	if f.speculative {
+		taken, _, cp := f.p.PredictSpeculative(uint64(pc), 0)
+		f.checkpoints[seq] = cp
+		return taken
+	}
+	taken, _ := f.p.Predict(uint64(pc), 0)
+	f.predictions[seq] = fetchedBranch{pc, taken}
+	return taken
+}
+
+func (f *tageFrontEnd) Repair(seq uint64, taken bool) {
+	if cp, ok := f.checkpoints[seq]; ok {
+		f.p.Repair(cp, taken)
+	}
+	for s := range f.checkpoints {
+		if s > seq {
+			delete(f.checkpoints, s)
+		}
+	}
+	for s := range f.predictions {
+		if s > seq {
+			delete(f.predictions, s)
+		}
+	}
+}
+
+func (f *tageFrontEnd) Flush() {
+	if f.speculative {
+		f.p.Flush(0)
+	}
+	clear(f.checkpoints)
+	clear(f.predictions)
+}
+
+func (f *tageFrontEnd) Commit(seq uint64, taken bool) {
+	if f.speculative {
+		f.p.Commit(f.checkpoints[seq], taken)
+		delete(f.checkpoints, seq)
+		return
+	}
+	b := f.predictions[seq]
+	if taken == b.taken {
+		f.p.Update(uint64(b.pc), 0, taken)
+	} else {
+		f.p.OnMispredict(uint64(b.pc), 0, taken)
+	}
+	delete(f.predictions, seq)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. LIFECYCLE TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSpecDirection_Lifecycle(t *testing.T) {
+	// WHAT: Running a kernel with an always-not-taken predictor plugged in, every committed
+	//       conditional branch commits once in fetch order, Repair comes exactly for the
+	//       taken ones, and the program still runs correctly
+	// WHY: A checkpointed predictor trusts these calls; a missed Commit or a stray Repair
+	//      corrupts its history for every branch after it
+	// HARDWARE: predictDirection at fetch, commitDirection at commit, Flush on redirect
+	// CATEGORY: [INTEGRATION]
+
+	rec := &recordingDirection{t: t, inFlight: map[uint64]uint32{}}
+	var core *Core
+	k := StandardKernels()[2] // sort: data-dependent and loop branches
+	r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+		c.SetBranchTrace(true)
+		c.SetDirectionPredictor(rec)
+		core = c
+	}})
+	if r.Err != nil || !r.Passed {
+		t.Fatalf("%s: err %v, status %d", k.Name, r.Err, r.Status)
+	}
+
+	trace := core.BranchTrace()
+	taken := 0
+	for _, br := range trace {
+		if br.Taken {
+			taken++
+		}
+	}
+	if rec.committed != len(trace) {
+		t.Errorf("%d commits for %d committed conditional branches", rec.committed, len(trace))
+	}
+	if rec.repaired != taken {
+		t.Errorf("%d repairs for %d taken branches predicted not-taken", rec.repaired, taken)
+	}
+	if rec.predicted < rec.committed || rec.flushed == 0 {
+		t.Errorf("predicted %d, flushed %d: the wrong path never reached the predictor", rec.predicted, rec.flushed)
+	}
+}
+
+func TestSpecDirection_Unplug(t *testing.T) {
+	// WHAT: SetDirectionPredictor(nil) gives the same run as never plugging one in
+	// WHY: The 4-bit counters are the default; the hook must cost nothing when unused
+	// HARDWARE: Mux select on the fetched direction
+	// CATEGORY: [UNIT]
+
+	k := StandardKernels()[2]
+	plain := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000})
+	unplugged := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+		c.SetDirectionPredictor(newTAGEFrontEnd(true))
+		c.SetDirectionPredictor(nil)
+	}})
+	if plain.Cycles != unplugged.Cycles || plain.Stats.Branch.Mispredicts != unplugged.Stats.Branch.Mispredicts {
+		t.Errorf("unplugged run: %d cycles, %d mispredicts; default: %d cycles, %d mispredicts",
+			unplugged.Cycles, unplugged.Stats.Branch.Mispredicts, plain.Cycles, plain.Stats.Branch.Mispredicts)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. TAGE IN THE CORE
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// Every standard kernel (one iteration) runs three times: with the core's 4-bit counters,
+// with TAGE predicting from committed history only, and with TAGE's speculative history.
+// Mispredicts are the core's own count (every branch and jump redirected at commit), so
+// the table shows what each front end costs end to end. Run with -v to read it.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestSpecDirection_TAGEInCore(t *testing.T) {
+	// WHAT: Every kernel passes with TAGE driving the front end; speculative history
+	//       mispredicts less in total than training TAGE at commit alone
+	// WHY: The replay in branchtrace_test.go trains before every prediction; in the core a
+	//      dozen branches are in flight, which is what PredictSpeculative exists for
+	// HARDWARE: proto/tage behind SpeculativeDirectionPredictor
+	// CATEGORY: [INTEGRATION]
+
+	if testing.Short() {
+		t.Skip("runs every kernel on the cycle-level core three times")
+	}
+
+	type frontEnd struct {
+		name string
+		make func() SpeculativeDirectionPredictor
+	}
+	frontEnds := []frontEnd{
+		{"counters", func() SpeculativeDirectionPredictor { return nil }},
+		{"tage at commit", func() SpeculativeDirectionPredictor { return newTAGEFrontEnd(false) }},
+		{"tage speculative", func() SpeculativeDirectionPredictor { return newTAGEFrontEnd(true) }},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-14s", "KERNEL")
+	for _, f := range frontEnds {
+		fmt.Fprintf(&b, " %18s %10s", f.name, "cycles")
+	}
+	b.WriteString("\n")
+	mispredicts := make([]uint64, len(frontEnds))
+	cycles := make([]uint64, len(frontEnds))
+	for _, k := range StandardKernels() {
+		fmt.Fprintf(&b, "%-14s", k.Name)
+		for i, f := range frontEnds {
+			r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+				if p := f.make(); p != nil {
+					c.SetDirectionPredictor(p)
+				}
+			}})
+			if r.Err != nil || !r.Passed {
+				t.Fatalf("%s/%s: err %v, status %d", k.Name, f.name, r.Err, r.Status)
+			}
+			mispredicts[i] += r.Stats.Branch.Mispredicts
+			cycles[i] += r.Cycles
+			fmt.Fprintf(&b, " %18d %10d", r.Stats.Branch.Mispredicts, r.Cycles)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%-14s", "TOTAL")
+	for i := range frontEnds {
+		fmt.Fprintf(&b, " %18d %10d", mispredicts[i], cycles[i])
+	}
+	t.Logf("mispredicts and cycles per front end:\n%s", b.String())
+
+	if mispredicts[2] >= mispredicts[1] {
+		t.Errorf("speculative TAGE mispredicted %d times, TAGE at commit %d", mispredicts[2], mispredicts[1])
+	}
+}
@@ -0,0 +1,1123 @@
+package suprax32
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// STRUCTURED STATISTICS
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY A TYPED TREE:
+//
+// Core.GetStats and L1ICache.GetStats render human-readable boxes. They are
+// fine for a terminal, but dashboards and regression checks need numbers
+// they can parse, compare and subtract.
+//
+// THE MODEL:
+//
+//	Stats
+//	├── Core          cycles, instructions, IPC, CPI
+//	├── Window        dispatch/issue/commit counts, occupancy, flushes
+//	├── Branch        resolved branches, mispredicts, RSB activity
+//	├── Indirect      JALR targets: BTB, ITTAGE, per source (see indirect.go)
+//	├── L1I           accesses, hits, fills + one record per buffer
+//	│                 + plugged-in prefetcher (see prefetcher.go)
+//	├── L1D           accesses, hits, writes, evictions + prefetch queue
+//	│                 + prefetch outcomes per specialist (see prefetch.go)
+//	│                 + plugged-in prefetcher
+//	│                 + prefetch throttle and its decisions (see throttle.go)
+//	├── L1DPredictor  ensemble accuracy + one record per specialist
+//	├── AddrSpec      load address speculation, per specialist (see addrspec.go)
+//	├── ValuePred     load value prediction, per component (see valuepred.go)
+//	├── LSUs          one record per load/store unit
+//	├── Units         ALU/MUL/DIV issue counts and utilization
+//	├── MMU           TLB hits/misses, page walks, faults (see mmu.go)
+//	├── Bus           device accesses, one record per device (see bus.go)
+//	├── CPIStack      commit slots by stall cause (see cpistack.go)
+//	└── CPIRegions    the same, per registered PC range
+//
+// FIELD KINDS:
+//
+//	Counters (uint64): monotonically increasing event counts.
+//	                   Delta subtracts them.
+//	Gauges:            instantaneous values (current occupancy, buffer
+//	                   bounds, flags). Delta keeps the newer value.
+//	                   Non-uint64 fields are gauges; uint64 gauges carry
+//	                   the tag stat:"gauge".
+//	Ratios (float64):  derived from counters by derive(), so a delta
+//	                   reports ratios for the interval, not the whole run.
+//	Logs:              records of events, tagged stat:"log". They are not
+//	                   CSV columns; Delta keeps the newer snapshot's.
+//
+// USAGE:
+//
+//	before := core.Snapshot()
+//	core.Run(100000)
+//	interval := core.Snapshot().Delta(before)
+//	data, _ := interval.JSON()
+//	interval.WriteCSV(os.Stdout, true)
+
+// Stats is a point-in-time snapshot of every counter in the core
+type Stats struct {
+	Core         CoreStats         `json:"core"`
+	Window       WindowStats       `json:"window"`
+	Branch       BranchStats       `json:"branch"`
+	Indirect     IndirectStats     `json:"indirect"`
+	L1I          L1IStats          `json:"l1i"`
+	L1D          L1DStats          `json:"l1d"`
+	L1DPredictor L1DPredictorStats `json:"l1d_predictor"`
+	AddrSpec     AddrSpecStats     `json:"addr_spec"`
+	ValuePred    ValuePredStats    `json:"value_pred"`
+	LSUs         []LSUStats        `json:"lsus"`
+	Units        UnitStats         `json:"units"`
+	MMU          MMUStats          `json:"mmu"`
+	Bus          BusStats          `json:"bus"`
+	CPIStack     CPIStack          `json:"cpi_stack"`
+	CPIRegions   []CPIRegion       `json:"cpi_regions"`
+}
+
+// CoreStats holds top-level execution counters
+type CoreStats struct {
+	Cycles       uint64 `json:"cycles"`
+	Instructions uint64 `json:"instructions"`
+	Loads        uint64 `json:"loads"`
+	Stores       uint64 `json:"stores"`
+	Traps        uint64 `json:"traps"`
+
+	IPC float64 `json:"ipc"`
+	CPI float64 `json:"cpi"`
+}
+
+// WindowStats describes the unified scheduler + ROB (INNOVATION #35)
+type WindowStats struct {
+	Size      int `json:"size"`
+	Occupancy int `json:"occupancy"`
+
+	Dispatched   uint64 `json:"dispatched"`
+	Issued       uint64 `json:"issued"`
+	Committed    uint64 `json:"committed"`
+	Flushes      uint64 `json:"flushes"`
+	Squashed     uint64 `json:"squashed"`
+	OccupancySum uint64 `json:"occupancy_sum"`
+	FullCycles   uint64 `json:"full_cycles"`
+	Samples      uint64 `json:"samples"`
+
+	AvgOccupancy float64 `json:"avg_occupancy"`
+	FullRate     float64 `json:"full_rate"`
+}
+
+// BranchStats describes direction and return prediction (INNOVATIONS #29-33)
+type BranchStats struct {
+	Resolved      uint64 `json:"resolved"`
+	Mispredicts   uint64 `json:"mispredicts"`
+	Lookups       uint64 `json:"lookups"`
+	RSBPushes     uint64 `json:"rsb_pushes"`
+	RSBPops       uint64 `json:"rsb_pops"`
+	RSBOverflows  uint64 `json:"rsb_overflows"`
+	RSBUnderflows uint64 `json:"rsb_underflows"`
+	RSBDepth      int    `json:"rsb_depth"`
+
+	Accuracy float64 `json:"accuracy"`
+	MPKI     float64 `json:"mpki"`
+}
+
+// IndirectStats describes JALR target prediction (see indirect.go)
+//
+// Jumps and returns are committed JALRs; BTB lookups happen at fetch,
+// wrong path included.
+type IndirectStats struct {
+	Enabled           bool                `json:"enabled"`
+	Jumps             uint64              `json:"jumps"` // Indirect jumps and calls
+	Mispredicts       uint64              `json:"mispredicts"`
+	Returns           uint64              `json:"returns"`
+	ReturnMispredicts uint64              `json:"return_mispredicts"`
+	BTBLookups        uint64              `json:"btb_lookups"`
+	BTBHits           uint64              `json:"btb_hits"`
+	BTBEntries        int                 `json:"btb_entries"`
+	Allocations       uint64              `json:"allocations"`    // ITTAGE entries claimed
+	AllocFailures     uint64              `json:"alloc_failures"` // ...or not, every candidate useful
+	Sources           []TargetSourceStats `json:"sources"`
+
+	Accuracy       float64 `json:"accuracy"`
+	ReturnAccuracy float64 `json:"return_accuracy"`
+	BTBHitRate     float64 `json:"btb_hit_rate"`
+	MPKI           float64 `json:"mpki"` // Indirect jump mispredicts only
+}
+
+// TargetSourceStats describes the JALRs one source predicted
+type TargetSourceStats struct {
+	Name      string `json:"name" stat:"key"`
+	Predicted uint64 `json:"predicted"`
+	Correct   uint64 `json:"correct"`
+
+	Accuracy float64 `json:"accuracy"`
+}
+
+// L1IStats describes the quad-buffered instruction cache (INNOVATIONS #21-28)
+type L1IStats struct {
+	Accesses uint64 `json:"accesses"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+	Fills    uint64 `json:"fills"`
+	Flushes  uint64 `json:"flushes"`
+
+	ActiveBuffers   int              `json:"active_buffers"`
+	IndirectEntries int              `json:"indirect_entries"`
+	Buffers         []L1IBufferStats `json:"buffers"`
+
+	// Plugged-in prefetcher only (the coverage scoring has no queue)
+	Port           PrefetchPortStats  `json:"port"`
+	Prefetch       PrefetchQueueStats `json:"prefetch"`
+	PrefetchFills  uint64             `json:"prefetch_fills"`
+	PrefetchUseful uint64             `json:"prefetch_useful"`
+
+	HitRate          float64 `json:"hit_rate"`
+	PrefetchAccuracy float64 `json:"prefetch_accuracy"` // PrefetchUseful / PrefetchFills
+}
+
+// L1IBufferStats describes one of the four L1I buffers
+type L1IBufferStats struct {
+	Index      int    `json:"index"`
+	Active     bool   `json:"active"`
+	BaseAddr   uint32 `json:"base_addr"`
+	EndAddr    uint32 `json:"end_addr"`
+	ValidLines int    `json:"valid_lines"`
+	Branches   int    `json:"branches"`
+	LastAccess uint64 `json:"last_access" stat:"gauge"`
+}
+
+// L1DStats describes the data cache and its prefetch queue
+type L1DStats struct {
+	Accesses       uint64 `json:"accesses"`
+	Hits           uint64 `json:"hits"`
+	Misses         uint64 `json:"misses"`
+	Writes         uint64 `json:"writes"`
+	WriteHits      uint64 `json:"write_hits"`
+	Fills          uint64 `json:"fills"`
+	Evictions      uint64 `json:"evictions"`
+	DirtyEvictions uint64 `json:"dirty_evictions"`
+	ValidLines     int    `json:"valid_lines"`
+	DirtyLines     int    `json:"dirty_lines"`
+
+	Prefetch        PrefetchQueueStats    `json:"prefetch"`
+	Prefetchers     []PrefetcherStats     `json:"prefetchers"`
+	PrefetchedLines int                   `json:"prefetched_lines"` // Resident, not yet used
+	Port            PrefetchPortStats     `json:"port"`
+	Throttle        PrefetchThrottleStats `json:"throttle"`
+
+	HitRate      float64 `json:"hit_rate"`
+	WriteHitRate float64 `json:"write_hit_rate"`
+}
+
+// PrefetcherStats describes the prefetches made on one specialist's
+// predictions (see prefetch.go)
+type PrefetcherStats struct {
+	Name          string `json:"name" stat:"key"`
+	Issued        uint64 `json:"issued"`
+	Useful        uint64 `json:"useful"`
+	Late          uint64 `json:"late"`
+	UnusedEvicted uint64 `json:"unused_evicted"`
+	Polluting     uint64 `json:"polluting"`
+
+	Accuracy      float64 `json:"accuracy"`       // Useful / Issued
+	LateRate      float64 `json:"late_rate"`      // Late / (Useful + Late)
+	PollutionRate float64 `json:"pollution_rate"` // Polluting / Issued
+}
+
+// PrefetchQueueStats describes the prefetch queue (INNOVATIONS #67-68)
+type PrefetchQueueStats struct {
+	Enqueued       uint64 `json:"enqueued"`
+	DropsFull      uint64 `json:"drops_full"`
+	DropsDuplicate uint64 `json:"drops_duplicate"`
+	Issued         uint64 `json:"issued"`
+	Completed      uint64 `json:"completed"`
+	Redundant      uint64 `json:"redundant"` // Line cached before the request was served
+	Displaced      uint64 `json:"displaced"` // Replaced by a higher-priority request
+	Occupancy      int    `json:"occupancy"`
+}
+
+// PrefetchPortStats describes a plugged-in prefetcher's port (see
+// prefetcher.go); Name is empty while the built-in prefetch runs
+type PrefetchPortStats struct {
+	Name       string `json:"name"`
+	Events     uint64 `json:"events"`
+	Candidates uint64 `json:"candidates"`
+	OverDegree uint64 `json:"over_degree"`
+	Throttled  uint64 `json:"throttled"`
+}
+
+// PrefetchThrottleStats describes the feedback-directed prefetch throttle
+// (see throttle.go)
+type PrefetchThrottleStats struct {
+	Enabled   bool                 `json:"enabled"`
+	Level     string               `json:"level"`
+	Intervals uint64               `json:"intervals"`
+	Raised    uint64               `json:"raised"`
+	Lowered   uint64               `json:"lowered"`
+	Levels    []ThrottleLevelStats `json:"levels"`
+
+	// The newest ThrottleLogSize level changes; a delta keeps those made
+	// during the interval
+	Decisions []ThrottleDecision `json:"decisions" stat:"log"`
+}
+
+// ThrottleLevelStats describes the time spent at one throttle level
+type ThrottleLevelStats struct {
+	Name    string `json:"name" stat:"key"`
+	Cycles  uint64 `json:"cycles"`
+	Entered uint64 `json:"entered"` // Changes into this level
+}
+
+// L1DPredictorStats describes the 5-way address predictor (INNOVATION #59)
+type L1DPredictorStats struct {
+	Loads       uint64 `json:"loads"`
+	Predictions uint64 `json:"predictions"`
+	Correct     uint64 `json:"correct"`
+
+	Predictors []SubPredictorStats `json:"predictors"`
+
+	Accuracy float64 `json:"accuracy"`
+	Coverage float64 `json:"coverage"`
+}
+
+// SubPredictorStats describes one specialist (INNOVATIONS #60-64)
+//
+// Offered/Correct score the specialist on its own, whether or not the
+// meta-predictor picked it. Selected/SelectedCorrect score it only when
+// its prediction was the one used.
+type SubPredictorStats struct {
+	Name            string `json:"name" stat:"key"`
+	Enabled         bool   `json:"enabled"` // See SetL1DPredictorEnabled
+	Offered         uint64 `json:"offered"`
+	Correct         uint64 `json:"correct"`
+	Selected        uint64 `json:"selected"`
+	SelectedCorrect uint64 `json:"selected_correct"`
+
+	Accuracy         float64 `json:"accuracy"`
+	SelectedAccuracy float64 `json:"selected_accuracy"`
+	Coverage         float64 `json:"coverage"`
+}
+
+// AddrSpecStats describes load address speculation (see addrspec.go)
+//
+// Counts cover committed loads only; wrong-path loads are not scored.
+type AddrSpecStats struct {
+	Enabled    bool                     `json:"enabled"`
+	Loads      uint64                   `json:"loads"`
+	Speculated uint64                   `json:"speculated"`
+	Correct    uint64                   `json:"correct"`
+	Replays    uint64                   `json:"replays"`
+	Flushes    uint64                   `json:"flushes"`
+	Squashed   uint64                   `json:"squashed"`
+	Predictors []AddrSpecPredictorStats `json:"predictors"`
+
+	Coverage    float64 `json:"coverage"`
+	Accuracy    float64 `json:"accuracy"`
+	AvgSquashed float64 `json:"avg_squashed"` // Recovery cost per flush
+}
+
+// AddrSpecPredictorStats describes the loads speculated on one
+// specialist's prediction
+type AddrSpecPredictorStats struct {
+	Name       string `json:"name" stat:"key"`
+	Speculated uint64 `json:"speculated"`
+	Correct    uint64 `json:"correct"`
+	Replays    uint64 `json:"replays"`
+	Flushes    uint64 `json:"flushes"`
+	Squashed   uint64 `json:"squashed"`
+
+	Coverage    float64 `json:"coverage"`
+	Accuracy    float64 `json:"accuracy"`
+	AvgSquashed float64 `json:"avg_squashed"`
+}
+
+// ValuePredStats describes load value prediction (see valuepred.go)
+//
+// Counts cover committed loads only; wrong-path loads are not scored.
+type ValuePredStats struct {
+	Enabled    bool                  `json:"enabled"`
+	Loads      uint64                `json:"loads"`
+	Predicted  uint64                `json:"predicted"`
+	Correct    uint64                `json:"correct"`
+	Flushes    uint64                `json:"flushes"`
+	Squashed   uint64                `json:"squashed"`
+	Components []ValueComponentStats `json:"components"`
+
+	Coverage    float64 `json:"coverage"`
+	Accuracy    float64 `json:"accuracy"`
+	AvgSquashed float64 `json:"avg_squashed"` // Recovery cost per flush
+}
+
+// ValueComponentStats describes the loads one component predicted
+type ValueComponentStats struct {
+	Name      string `json:"name" stat:"key"`
+	Predicted uint64 `json:"predicted"`
+	Correct   uint64 `json:"correct"`
+	Flushes   uint64 `json:"flushes"`
+	Squashed  uint64 `json:"squashed"`
+
+	Coverage float64 `json:"coverage"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// LSUStats describes one load/store unit (INNOVATION #69)
+type LSUStats struct {
+	Index      int    `json:"index"`
+	Busy       bool   `json:"busy"`
+	Loads      uint64 `json:"loads"`
+	Stores     uint64 `json:"stores"`
+	BusyCycles uint64 `json:"busy_cycles"`
+	Misses     uint64 `json:"misses"`
+	SCFailures uint64 `json:"sc_failures"`
+
+	Utilization float64 `json:"utilization"`
+}
+
+// UnitStats describes the ALUs, multiplier and divider (INNOVATIONS #56-58)
+type UnitStats struct {
+	ALUOps        uint64 `json:"alu_ops"`
+	BranchOps     uint64 `json:"branch_ops"`
+	MULOps        uint64 `json:"mul_ops"`
+	DIVOps        uint64 `json:"div_ops"`
+	DIVBusyCycles uint64 `json:"div_busy_cycles"`
+
+	ALUUtilization float64 `json:"alu_utilization"`
+	MULUtilization float64 `json:"mul_utilization"`
+	DIVUtilization float64 `json:"div_utilization"`
+}
+
+// MMUStats describes the TLBs and the page walker (see mmu.go)
+type MMUStats struct {
+	Enabled    bool     `json:"enabled"`
+	ITLB       TLBStats `json:"itlb"`
+	DTLB       TLBStats `json:"dtlb"`
+	Walks      uint64   `json:"walks"`
+	WalkCycles uint64   `json:"walk_cycles"`
+	PTEReads   uint64   `json:"pte_reads"`
+	PTEMisses  uint64   `json:"pte_misses"` // PTE reads that missed the L1D
+	PageFaults uint64   `json:"page_faults"`
+
+	AvgWalkCycles float64 `json:"avg_walk_cycles"`
+}
+
+// BusStats describes the system bus (see bus.go)
+type BusStats struct {
+	BusyCycles uint64        `json:"busy_cycles"` // Cycles a device access was in flight
+	Devices    []DeviceStats `json:"devices"`
+}
+
+// DeviceStats describes one device on the bus
+type DeviceStats struct {
+	Name   string `json:"name" stat:"key"`
+	Base   uint32 `json:"base"`
+	Size   uint32 `json:"size"`
+	Reads  uint64 `json:"reads"`
+	Writes uint64 `json:"writes"`
+}
+
+// TLBStats describes one TLB
+type TLBStats struct {
+	Entries int    `json:"entries"`
+	Valid   int    `json:"valid"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"` // Lookups that started a page walk
+	Flushes uint64 `json:"flushes"`
+
+	HitRate float64 `json:"hit_rate"`
+}
+
+// String returns the short name of a specialist predictor
+func (id PredictorID) String() string {
+	switch id {
+	case PredictorStride:
+		return "stride"
+	case PredictorMarkov:
+		return "markov"
+	case PredictorConstant:
+		return "constant"
+	case PredictorDelta:
+		return "delta"
+	case PredictorContext:
+		return "context"
+	case PredictorPrefetcher:
+		return "prefetcher"
+	default:
+		return "none"
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// COMPONENT SNAPSHOTS
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// Stats returns the window's counters
+func (w *Window) Stats() WindowStats {
+	return WindowStats{
+		Size:         WindowSize,
+		Occupancy:    w.count,
+		Dispatched:   w.dispatched,
+		Issued:       w.issued,
+		Committed:    w.committed,
+		Flushes:      w.flushes,
+		Squashed:     w.squashed,
+		OccupancySum: w.occupancySum,
+		FullCycles:   w.fullCycles,
+		Samples:      w.samples,
+	}
+}
+
+// Stats returns the branch predictor's lookup and RSB counters
+//
+// Resolved and Mispredicts are filled in by Core.Snapshot, since only the
+// commit stage knows whether a prediction was right.
+func (bp *BranchPredictor) Stats() BranchStats {
+	return BranchStats{
+		Lookups:       bp.predictions,
+		RSBPushes:     bp.rsbPushes,
+		RSBPops:       bp.rsbPops,
+		RSBOverflows:  bp.rsbOverflows,
+		RSBUnderflows: bp.rsbUnderflows,
+		RSBDepth:      bp.rsbTop,
+	}
+}
+
+// Stats returns the BTB and ITTAGE counters with one record per source
+func (ip *IndirectPredictor) Stats() IndirectStats {
+	s := IndirectStats{
+		Enabled:           ip.on,
+		Jumps:             ip.jumps,
+		Mispredicts:       ip.mispredicts,
+		Returns:           ip.returns,
+		ReturnMispredicts: ip.returnMispredicts,
+		BTBLookups:        ip.btb.lookups,
+		BTBHits:           ip.btb.hits,
+		BTBEntries:        ip.btb.validEntries(),
+		Allocations:       ip.ittage.allocations,
+		AllocFailures:     ip.ittage.allocFailures,
+		Sources:           make([]TargetSourceStats, len(ip.perSource)),
+	}
+	for i, p := range ip.perSource {
+		s.Sources[i] = TargetSourceStats{
+			Name:      TargetSource(i).String(),
+			Predicted: p.predicted,
+			Correct:   p.correct,
+		}
+	}
+	return s
+}
+
+// Stats returns the instruction cache's counters and per-buffer state
+func (c *L1ICache) Stats() L1IStats {
+	s := L1IStats{
+		Accesses: c.accesses,
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Fills:    c.fills,
+		Flushes:  c.flushes,
+		Buffers:  make([]L1IBufferStats, L1IBufferCount),
+
+		Port:           c.port.Stats(),
+		PrefetchFills:  c.prefetchFills,
+		PrefetchUseful: c.prefetchUseful,
+	}
+	if c.port != nil {
+		s.Prefetch = c.port.queue.Stats()
+	}
+
+	for i := range c.buffers {
+		buffer := &c.buffers[i]
+		b := L1IBufferStats{
+			Index:      i,
+			Active:     buffer.active,
+			BaseAddr:   buffer.baseAddr,
+			EndAddr:    buffer.endAddr,
+			LastAccess: buffer.lastAccess,
+		}
+		for set := range buffer.sets {
+			for way := range buffer.sets[set] {
+				if buffer.sets[set][way].Valid {
+					b.ValidLines++
+				}
+			}
+		}
+		for j := range buffer.branches {
+			if buffer.branches[j].Valid {
+				b.Branches++
+			}
+		}
+		if buffer.active {
+			s.ActiveBuffers++
+		}
+		s.Buffers[i] = b
+	}
+
+	for i := range c.indirectPredictor {
+		if c.indirectPredictor[i].Valid {
+			s.IndirectEntries++
+		}
+	}
+
+	return s
+}
+
+// Stats returns the data cache's counters
+func (c *L1DCache) Stats() L1DStats {
+	s := L1DStats{
+		Accesses:       c.accesses,
+		Hits:           c.hits,
+		Misses:         c.accesses - c.hits,
+		Writes:         c.writes,
+		WriteHits:      c.writeHits,
+		Fills:          c.fills,
+		Evictions:      c.evictions,
+		DirtyEvictions: c.dirtyEvictions,
+		Prefetch:       c.prefetchQueue.Stats(),
+		Prefetchers:    make([]PrefetcherStats, len(c.prefetchAcct.perSource)),
+		Port:           c.port.Stats(),
+		Throttle:       c.throttle.Stats(),
+	}
+
+	for i, p := range c.prefetchAcct.perSource {
+		s.Prefetchers[i] = PrefetcherStats{
+			Name:          PredictorID(i + 1).String(),
+			Issued:        p.issued,
+			Useful:        p.useful,
+			Late:          p.late,
+			UnusedEvicted: p.unusedEvicted,
+			Polluting:     p.polluting,
+		}
+	}
+
+	for set := range c.sets {
+		for way := range c.sets[set] {
+			line := &c.sets[set][way]
+			if line.Valid {
+				s.ValidLines++
+				if line.Dirty {
+					s.DirtyLines++
+				}
+				if line.Prefetched {
+					s.PrefetchedLines++
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// Stats returns the prefetch queue's counters
+func (pq *PrefetchQueue) Stats() PrefetchQueueStats {
+	return PrefetchQueueStats{
+		Enqueued:       pq.enqueued,
+		DropsFull:      pq.dropsFull,
+		DropsDuplicate: pq.dropsDuplicate,
+		Issued:         pq.issued,
+		Completed:      pq.completed,
+		Redundant:      pq.redundant,
+		Displaced:      pq.displaced,
+		Occupancy:      pq.count,
+	}
+}
+
+// Stats returns the port's counters (zero for no port)
+func (p *PrefetchPort) Stats() PrefetchPortStats {
+	if p == nil {
+		return PrefetchPortStats{}
+	}
+	return PrefetchPortStats{
+		Name:       p.prefetcher.Name(),
+		Events:     p.events,
+		Candidates: p.candidates,
+		OverDegree: p.overDegree,
+		Throttled:  p.throttled,
+	}
+}
+
+// Stats returns the throttle's counters and decision log
+func (t *prefetchThrottle) Stats() PrefetchThrottleStats {
+	s := PrefetchThrottleStats{
+		Enabled:   t.on,
+		Level:     PrefetchDefault.String(),
+		Intervals: t.intervals,
+		Raised:    t.raised,
+		Lowered:   t.lowered,
+		Levels:    make([]ThrottleLevelStats, len(prefetchLevels)),
+		Decisions: append([]ThrottleDecision(nil), t.log...),
+	}
+	if t.on {
+		s.Level = t.level.String()
+	}
+	for i := range s.Levels {
+		s.Levels[i] = ThrottleLevelStats{
+			Name:    PrefetchLevel(i).String(),
+			Cycles:  t.cycles[i],
+			Entered: t.entered[i],
+		}
+	}
+	return s
+}
+
+// Stats returns the ensemble's counters with one record per specialist
+func (p *L1DPredictor) Stats() L1DPredictorStats {
+	s := L1DPredictorStats{
+		Loads:       p.loadsRecorded,
+		Predictions: p.totalPredictions,
+		Correct:     p.correctPredictions,
+		Predictors:  make([]SubPredictorStats, len(p.subOffered)),
+	}
+
+	for i := range p.subOffered {
+		s.Predictors[i] = SubPredictorStats{
+			Name:            PredictorID(i + 1).String(),
+			Enabled:         !p.disabled[i],
+			Offered:         p.subOffered[i],
+			Correct:         p.subCorrect[i],
+			Selected:        p.subSelected[i],
+			SelectedCorrect: p.subSelectedCorrect[i],
+		}
+	}
+
+	return s
+}
+
+// Stats returns the address speculation counters
+func (a *addrSpecState) Stats() AddrSpecStats {
+	s := AddrSpecStats{
+		Enabled:    a.on,
+		Loads:      a.loads,
+		Predictors: make([]AddrSpecPredictorStats, len(a.perPredictor)),
+	}
+	for i, p := range a.perPredictor {
+		s.Predictors[i] = AddrSpecPredictorStats{
+			Name:       PredictorID(i + 1).String(),
+			Speculated: p.speculated,
+			Correct:    p.correct,
+			Replays:    p.replays,
+			Flushes:    p.flushes,
+			Squashed:   p.squashed,
+		}
+		s.Speculated += p.speculated
+		s.Correct += p.correct
+		s.Replays += p.replays
+		s.Flushes += p.flushes
+		s.Squashed += p.squashed
+	}
+	return s
+}
+
+// Stats returns the value predictor's counters
+func (vp *ValuePredictor) Stats() ValuePredStats {
+	s := ValuePredStats{
+		Enabled:    vp.on,
+		Loads:      vp.loads,
+		Components: make([]ValueComponentStats, len(vp.perComponent)),
+	}
+	for i, p := range vp.perComponent {
+		s.Components[i] = ValueComponentStats{
+			Name:      ValueComponent(i + 1).String(),
+			Predicted: p.predicted,
+			Correct:   p.correct,
+			Flushes:   p.flushes,
+			Squashed:  p.squashed,
+		}
+		s.Predicted += p.predicted
+		s.Correct += p.correct
+		s.Flushes += p.flushes
+		s.Squashed += p.squashed
+	}
+	return s
+}
+
+// Stats returns the LSU's counters
+func (lsu *LSU) Stats() LSUStats {
+	return LSUStats{
+		Busy:       lsu.busy,
+		Loads:      lsu.loads,
+		Stores:     lsu.stores,
+		BusyCycles: lsu.busyCycles,
+		Misses:     lsu.misses,
+		SCFailures: lsu.scFailures,
+	}
+}
+
+// Stats returns the TLB's counters
+func (t *TLB) Stats() TLBStats {
+	return TLBStats{
+		Entries: len(t.entries),
+		Valid:   t.validEntries(),
+		Hits:    t.hits,
+		Misses:  t.misses,
+		Flushes: t.flushes,
+	}
+}
+
+// Stats returns the MMU's counters
+func (m *MMU) Stats() MMUStats {
+	return MMUStats{
+		Enabled:    m.Enabled(),
+		ITLB:       m.itlb.Stats(),
+		DTLB:       m.dtlb.Stats(),
+		Walks:      m.walks,
+		WalkCycles: m.walkCycles,
+		PTEReads:   m.pteReads,
+		PTEMisses:  m.pteMisses,
+		PageFaults: m.pageFaults,
+	}
+}
+
+// Stats returns the bus's counters
+func (b *Bus) Stats() BusStats {
+	s := BusStats{
+		BusyCycles: b.busyCycles,
+		Devices:    make([]DeviceStats, len(b.regions)),
+	}
+	for i, r := range b.regions {
+		s.Devices[i] = DeviceStats{Name: r.name, Base: r.base, Size: r.size, Reads: r.reads, Writes: r.writes}
+	}
+	return s
+}
+
+// Snapshot captures every counter in the core as a typed tree
+//
+// The snapshot is a deep copy: running the core further does not change it.
+func (c *Core) Snapshot() *Stats {
+	s := &Stats{
+		Core: CoreStats{
+			Cycles:       c.cycles,
+			Instructions: c.instructions,
+			Loads:        c.loads,
+			Stores:       c.stores,
+			Traps:        c.traps,
+		},
+		Window:       c.window.Stats(),
+		Branch:       c.branchPred.Stats(),
+		Indirect:     c.branchPred.indirect.Stats(),
+		L1I:          c.icache.Stats(),
+		L1D:          c.dcache.Stats(),
+		L1DPredictor: c.dcache.predictor.Stats(),
+		AddrSpec:     c.addrSpec.Stats(),
+		ValuePred:    c.valuePred.Stats(),
+		LSUs:         make([]LSUStats, len(c.lsus)),
+		Units: UnitStats{
+			ALUOps:        c.aluOps,
+			BranchOps:     c.branchOps,
+			MULOps:        c.mulOps,
+			DIVOps:        c.divOps,
+			DIVBusyCycles: c.divBusyCycles,
+		},
+		MMU:        c.mmu.Stats(),
+		Bus:        c.bus.Stats(),
+		CPIStack:   c.cpi,
+		CPIRegions: append([]CPIRegion(nil), c.cpiRegions...),
+	}
+
+	s.Branch.Resolved = c.branches
+	s.Branch.Mispredicts = c.branchMispredicts
+
+	for i, lsu := range c.lsus {
+		s.LSUs[i] = lsu.Stats()
+		s.LSUs[i].Index = i
+	}
+
+	s.derive()
+	return s
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// DERIVED RATIOS, DELTAS AND SERIALIZATION
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// ratio divides two counters, returning 0 for an empty denominator
+func ratio(num, den uint64) float64 {
+	if den == 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
+
+// derive recomputes every float64 field from the counters
+//
+// Called after a snapshot and after a delta, so ratios always describe
+// the same interval as the counters next to them.
+func (s *Stats) derive() {
+	cycles := s.Core.Cycles
+
+	s.Core.IPC = ratio(s.Core.Instructions, cycles)
+	s.Core.CPI = ratio(cycles, s.Core.Instructions)
+
+	s.Window.AvgOccupancy = ratio(s.Window.OccupancySum, s.Window.Samples)
+	s.Window.FullRate = ratio(s.Window.FullCycles, s.Window.Samples)
+
+	s.Branch.Accuracy = 0
+	if s.Branch.Resolved > 0 {
+		s.Branch.Accuracy = 1 - ratio(s.Branch.Mispredicts, s.Branch.Resolved)
+	}
+	s.Branch.MPKI = ratio(s.Branch.Mispredicts*1000, s.Core.Instructions)
+
+	ind := &s.Indirect
+	ind.Accuracy = ratio(ind.Jumps-ind.Mispredicts, ind.Jumps)
+	ind.ReturnAccuracy = ratio(ind.Returns-ind.ReturnMispredicts, ind.Returns)
+	ind.BTBHitRate = ratio(ind.BTBHits, ind.BTBLookups)
+	ind.MPKI = ratio(ind.Mispredicts*1000, s.Core.Instructions)
+	for i := range ind.Sources {
+		src := &ind.Sources[i]
+		src.Accuracy = ratio(src.Correct, src.Predicted)
+	}
+
+	s.L1I.HitRate = ratio(s.L1I.Hits, s.L1I.Accesses)
+	s.L1I.PrefetchAccuracy = ratio(s.L1I.PrefetchUseful, s.L1I.PrefetchFills)
+
+	s.L1D.HitRate = ratio(s.L1D.Hits, s.L1D.Accesses)
+	s.L1D.WriteHitRate = ratio(s.L1D.WriteHits, s.L1D.Writes)
+	for i := range s.L1D.Prefetchers {
+		pf := &s.L1D.Prefetchers[i]
+		pf.Accuracy = ratio(pf.Useful, pf.Issued)
+		pf.LateRate = ratio(pf.Late, pf.Useful+pf.Late)
+		pf.PollutionRate = ratio(pf.Polluting, pf.Issued)
+	}
+
+	s.L1DPredictor.derive()
+
+	a := &s.AddrSpec
+	a.Coverage = ratio(a.Speculated, a.Loads)
+	a.Accuracy = ratio(a.Correct, a.Speculated)
+	a.AvgSquashed = ratio(a.Squashed, a.Flushes)
+	for i := range a.Predictors {
+		sub := &a.Predictors[i]
+		sub.Coverage = ratio(sub.Speculated, a.Loads)
+		sub.Accuracy = ratio(sub.Correct, sub.Speculated)
+		sub.AvgSquashed = ratio(sub.Squashed, sub.Flushes)
+	}
+
+	v := &s.ValuePred
+	v.Coverage = ratio(v.Predicted, v.Loads)
+	v.Accuracy = ratio(v.Correct, v.Predicted)
+	v.AvgSquashed = ratio(v.Squashed, v.Flushes)
+	for i := range v.Components {
+		sub := &v.Components[i]
+		sub.Coverage = ratio(sub.Predicted, v.Loads)
+		sub.Accuracy = ratio(sub.Correct, sub.Predicted)
+	}
+
+	for i := range s.LSUs {
+		s.LSUs[i].Utilization = ratio(s.LSUs[i].BusyCycles, cycles)
+	}
+
+	s.Units.ALUUtilization = ratio(s.Units.ALUOps+s.Units.BranchOps, cycles*NumALUs)
+	s.Units.MULUtilization = ratio(s.Units.MULOps, cycles*NumMULs)
+	s.Units.DIVUtilization = ratio(s.Units.DIVBusyCycles, cycles*NumDIVs)
+
+	s.MMU.ITLB.HitRate = ratio(s.MMU.ITLB.Hits, s.MMU.ITLB.Hits+s.MMU.ITLB.Misses)
+	s.MMU.DTLB.HitRate = ratio(s.MMU.DTLB.Hits, s.MMU.DTLB.Hits+s.MMU.DTLB.Misses)
+	s.MMU.AvgWalkCycles = ratio(s.MMU.WalkCycles, s.MMU.Walks)
+
+	s.CPIStack.derive()
+	for i := range s.CPIRegions {
+		s.CPIRegions[i].Stack.derive()
+	}
+}
+
+// derive recomputes the ensemble's ratios (also used by ReplayLoadTrace)
+func (p *L1DPredictorStats) derive() {
+	p.Accuracy = ratio(p.Correct, p.Predictions)
+	p.Coverage = ratio(p.Predictions, p.Loads)
+	for i := range p.Predictors {
+		sub := &p.Predictors[i]
+		sub.Accuracy = ratio(sub.Correct, sub.Offered)
+		sub.SelectedAccuracy = ratio(sub.SelectedCorrect, sub.Selected)
+		sub.Coverage = ratio(sub.Offered, p.Loads)
+	}
+}
+
+// Delta returns the activity between an earlier snapshot and this one
+//
+// ALGORITHM:
+//
+//	STEP 1: Deep-copy this snapshot
+//	STEP 2: Subtract every counter of prev (gauges keep their new value)
+//	STEP 3: Recompute ratios for the interval
+//
+// Both snapshots must come from the same core; prev may be nil, in which
+// case the result is a copy of s.
+func (s *Stats) Delta(prev *Stats) *Stats {
+	d := s.clone()
+	if prev != nil {
+		subtractCounters(reflect.ValueOf(d).Elem(), reflect.ValueOf(prev).Elem())
+		d.L1D.Throttle.Decisions = decisionsAfter(d.L1D.Throttle.Decisions, prev.Core.Cycles)
+	}
+	d.derive()
+	return d
+}
+
+// decisionsAfter drops the throttle decisions made by the given cycle
+func decisionsAfter(log []ThrottleDecision, cycle uint64) []ThrottleDecision {
+	for i, d := range log {
+		if d.Cycle > cycle {
+			return log[i:]
+		}
+	}
+	return nil
+}
+
+// clone returns a deep copy of the snapshot
+func (s *Stats) clone() *Stats {
+	d := *s
+	copySlices(reflect.ValueOf(&d).Elem())
+	return &d
+}
+
+// copySlices gives every slice reachable from v its own storage
+//
+// Walking the tree instead of listing the slices means a record added
+// later cannot be shared by accident (Delta subtracts in place).
+func copySlices(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			copySlices(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		v.Set(c)
+		for i := 0; i < c.Len(); i++ {
+			copySlices(c.Index(i))
+		}
+	}
+}
+
+// subtractCounters walks two values of the same type and subtracts
+// prev's counters from cur in place (slice elements matched by sliceKey)
+func subtractCounters(cur, prev reflect.Value) {
+	switch cur.Kind() {
+	case reflect.Struct:
+		t := cur.Type()
+		for i := 0; i < cur.NumField(); i++ {
+			if kind := t.Field(i).Tag.Get("stat"); kind == "gauge" || kind == "log" {
+				continue
+			}
+			subtractCounters(cur.Field(i), prev.Field(i))
+		}
+	case reflect.Slice:
+		// Pair elements by key, not position: an element added between
+		// the snapshots would shift every one after it. An element prev
+		// does not have started from zero and keeps its counts.
+		prevAt := make(map[string]int, prev.Len())
+		for j := 0; j < prev.Len(); j++ {
+			prevAt[sliceKey(prev.Index(j), j)] = j
+		}
+		for i := 0; i < cur.Len(); i++ {
+			if j, ok := prevAt[sliceKey(cur.Index(i), i)]; ok {
+				subtractCounters(cur.Index(i), prev.Index(j))
+			}
+		}
+	case reflect.Uint64:
+		cur.SetUint(cur.Uint() - prev.Uint())
+	}
+}
+
+// JSON serializes the snapshot as indented JSON
+func (s *Stats) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// CSVHeader returns the flattened column names, e.g. "l1d.prefetch.issued"
+//
+// Slice elements are keyed by their stat:"key" field when they have one
+// ("l1d_predictor.predictors.stride.offered"), otherwise by index
+// ("lsus.0.loads").
+func (s *Stats) CSVHeader() []string {
+	var names []string
+	flattenStats(reflect.ValueOf(s).Elem(), "", func(name, _ string) {
+		names = append(names, name)
+	})
+	return names
+}
+
+// CSVRecord returns the values in the same order as CSVHeader
+func (s *Stats) CSVRecord() []string {
+	var values []string
+	flattenStats(reflect.ValueOf(s).Elem(), "", func(_, value string) {
+		values = append(values, value)
+	})
+	return values
+}
+
+// WriteCSV writes one row per call, optionally preceded by the header
+//
+// Writing successive deltas with header=false after the first produces a
+// time series suitable for spreadsheets and plotting scripts.
+func (s *Stats) WriteCSV(w io.Writer, header bool) error {
+	cw := csv.NewWriter(w)
+	if header {
+		if err := cw.Write(s.CSVHeader()); err != nil {
+			return err
+		}
+	}
+	if err := cw.Write(s.CSVRecord()); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// flattenStats visits every leaf field with its dotted JSON path
+func flattenStats(v reflect.Value, prefix string, emit func(name, value string)) {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			switch field.Tag.Get("stat") {
+			case "key":
+				continue // Already part of the path
+			case "log":
+				continue // Not a fixed set of columns
+			}
+			flattenStats(v.Field(i), join(field.Tag.Get("json")), emit)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			flattenStats(v.Index(i), join(sliceKey(v.Index(i), i)), emit)
+		}
+	case reflect.Uint64, reflect.Uint32, reflect.Uint8:
+		emit(prefix, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Int:
+		emit(prefix, strconv.FormatInt(v.Int(), 10))
+	case reflect.Float64:
+		emit(prefix, strconv.FormatFloat(v.Float(), 'g', 6, 64))
+	case reflect.Bool:
+		emit(prefix, strconv.FormatBool(v.Bool()))
+	case reflect.String:
+		emit(prefix, v.String())
+	}
+}
+
+// sliceKey names a slice element by its stat:"key" field or its index
+func sliceKey(v reflect.Value, index int) string {
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).Tag.Get("stat") == "key" {
+				return v.Field(i).String()
+			}
+		}
+	}
+	return strconv.Itoa(index)
+}
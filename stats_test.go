@@ -0,0 +1,210 @@
+package suprax32
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Structured Statistics - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Snapshots feed dashboards and regression scripts, so the properties that matter are the
+// ones a script relies on: counters subtract, gauges do not, ratios describe the interval,
+// and every exported row lines up with its header.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. SNAPSHOT AND DELTA TESTS
+//    Counters, gauges, ratios, slice pairing
+//
+// 2. EXPORT TESTS
+//    JSON round trip, CSV header/record alignment
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// runStatsProgram runs the array-sum program for the given number of cycles
+func runStatsProgram(t *testing.T, cycles uint64) *Core {
+	t.Helper()
+	core := NewCore(1 << 20)
+	core.LoadProgram(CreateArraySumProgram(), 0x1000)
+	core.Run(cycles)
+	return core
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. SNAPSHOT AND DELTA TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestStats_DeltaSubtractsCounters(t *testing.T) {
+	// WHAT: A delta's counters are the difference of two snapshots; gauges keep the newer value
+	// WHY: Interval statistics are the reason snapshots exist
+	// HARDWARE: Performance counters read twice, subtracted in software
+	// CATEGORY: [UNIT] [INTEGRATION]
+
+	core := runStatsProgram(t, 2000)
+	before := core.Snapshot()
+	core.Run(3000)
+	after := core.Snapshot()
+
+	d := after.Delta(before)
+	if d.Core.Cycles != after.Core.Cycles-before.Core.Cycles {
+		t.Errorf("Cycles = %d, expected %d", d.Core.Cycles, after.Core.Cycles-before.Core.Cycles)
+	}
+	if d.Core.Instructions != after.Core.Instructions-before.Core.Instructions {
+		t.Errorf("Instructions = %d, expected %d",
+			d.Core.Instructions, after.Core.Instructions-before.Core.Instructions)
+	}
+	if d.Window.Size != after.Window.Size || d.Window.Occupancy != after.Window.Occupancy {
+		t.Error("Gauges changed by Delta")
+	}
+	if want := ratio(d.Core.Instructions, d.Core.Cycles); d.Core.IPC != want {
+		t.Errorf("IPC = %v, expected the interval's %v", d.Core.IPC, want)
+	}
+
+	// The snapshots themselves are untouched
+	if after.Core.Cycles != core.Snapshot().Core.Cycles {
+		t.Error("Delta modified the newer snapshot")
+	}
+}
+
+func TestStats_DeltaNilPrev(t *testing.T) {
+	// WHAT: Delta(nil) is a copy of the snapshot
+	// WHY: The first interval of a time series has no predecessor
+	// HARDWARE: N/A (software aggregation)
+	// CATEGORY: [BOUNDARY]
+
+	s := runStatsProgram(t, 1000).Snapshot()
+	d := s.Delta(nil)
+	if d.Core != s.Core || len(d.LSUs) != len(s.LSUs) {
+		t.Error("Delta(nil) differs from the snapshot")
+	}
+	d.LSUs[0].Loads++
+	if d.LSUs[0].Loads == s.LSUs[0].Loads {
+		t.Error("Delta shares slice storage with the snapshot")
+	}
+}
+
+func TestStats_DeltaLeavesSnapshotsUntouched(t *testing.T) {
+	// WHAT: Neither snapshot changes when a delta is taken, including every per-record slice
+	// WHY: Delta subtracts in place on a copy; a slice the copy shares with the newer
+	//      snapshot is subtracted there too, and the next delta from it is wrong
+	// HARDWARE: N/A (software aggregation)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	before := &Stats{Bus: BusStats{Devices: []DeviceStats{{Name: "timer", Reads: 1, Writes: 2}}}}
+	after := &Stats{Bus: BusStats{Devices: []DeviceStats{{Name: "timer", Reads: 5, Writes: 7}}}}
+	before.LSUs = []LSUStats{{}}
+	after.LSUs = []LSUStats{{}}
+
+	wantBefore, _ := before.JSON()
+	wantAfter, _ := after.JSON()
+	d := after.Delta(before)
+	if got := d.Bus.Devices[0]; got.Reads != 4 || got.Writes != 5 {
+		t.Errorf("delta reads %d writes %d, expected 4 and 5", got.Reads, got.Writes)
+	}
+	if got, _ := before.JSON(); !bytes.Equal(got, wantBefore) {
+		t.Error("Delta modified the older snapshot")
+	}
+	if got, _ := after.JSON(); !bytes.Equal(got, wantAfter) {
+		t.Error("Delta modified the newer snapshot")
+	}
+}
+
+func TestStats_DeltaPairsSliceEntriesByKey(t *testing.T) {
+	// WHAT: Slice entries are subtracted by their stat:"key", not their position
+	// WHY: An entry added between two snapshots shifts the ones after it; pairing by
+	//      index subtracts the wrong entries and wraps counters below zero
+	// HARDWARE: N/A (software aggregation)
+	// CATEGORY: [UNIT] [REGRESSION]
+
+	prev := &Stats{L1DPredictor: L1DPredictorStats{Predictors: []SubPredictorStats{
+		{Name: "stride", Offered: 10},
+		{Name: "markov", Offered: 100},
+	}}}
+	cur := &Stats{L1DPredictor: L1DPredictorStats{Predictors: []SubPredictorStats{
+		{Name: "stride", Offered: 15},
+		{Name: "added", Offered: 7},
+		{Name: "markov", Offered: 130},
+	}}}
+
+	d := cur.Delta(prev)
+	want := map[string]uint64{"stride": 5, "added": 7, "markov": 30}
+	for _, sub := range d.L1DPredictor.Predictors {
+		if sub.Offered != want[sub.Name] {
+			t.Errorf("%s: Offered = %d, expected %d", sub.Name, sub.Offered, want[sub.Name])
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. EXPORT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestStats_JSONRoundTrip(t *testing.T) {
+	// WHAT: JSON output decodes back into an identical snapshot
+	// WHY: Scripts load saved runs and compare them
+	// HARDWARE: N/A (software export)
+	// CATEGORY: [UNIT]
+
+	s := runStatsProgram(t, 2000).Snapshot()
+	data, err := s.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Stats
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := back.JSON()
+	if !bytes.Equal(data, again) {
+		t.Error("JSON round trip changed the snapshot")
+	}
+}
+
+func TestStats_CSVColumnsAlign(t *testing.T) {
+	// WHAT: The header and every record have the same number of columns, in a stable order
+	// WHY: Successive deltas are appended as rows under one header
+	// HARDWARE: N/A (software export)
+	// CATEGORY: [UNIT] [INVARIANT]
+
+	core := runStatsProgram(t, 1000)
+	first := core.Snapshot()
+	core.Run(1000)
+	second := core.Snapshot().Delta(first)
+
+	var buf bytes.Buffer
+	if err := first.WriteCSV(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.WriteCSV(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("%d rows, expected header + 2", len(rows))
+	}
+	for i, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			t.Errorf("Row %d has %d columns, header has %d", i+1, len(row), len(rows[0]))
+		}
+	}
+	header := first.CSVHeader()
+	for i, name := range second.CSVHeader() {
+		if header[i] != name {
+			t.Fatalf("Column %d is %q, then %q", i, header[i], name)
+		}
+	}
+}
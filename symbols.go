@@ -0,0 +1,218 @@
+package suprax32
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// SYMBOL TABLE
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// Maps addresses back to names so the disassembler, pipeline tracer,
+// CPI profiler and debugger can say "main+0x8" instead of "0x1008".
+// Filled by Core.LoadImage (from the linker's symbols) and Core.LoadELF
+// (from .symtab).
+//
+// LOOKUP: The nearest function or object symbol at or below the address,
+//
+//	as long as the address is inside it (when its size is known).
+//	Absolute symbols (__bss_start, ...) can be found by name only.
+//
+// MINECRAFT ANALOGY: Signs on the doors of a village
+//
+//	Coordinates are exact, but "third house past the blacksmith" is
+//	what people actually understand.
+
+// SymbolKind classifies a symbol for lookups
+type SymbolKind uint8
+
+const (
+	SymFunc   SymbolKind = iota // Code (in .text)
+	SymObject                   // Data (in .data or .bss)
+	SymAbs                      // Address-only (linker-defined bounds)
+)
+
+// SymbolEntry is one named address
+type SymbolEntry struct {
+	Name string
+	Addr uint32
+	Size uint32 // 0 = unknown (extends to the next symbol)
+	Kind SymbolKind
+}
+
+// SymbolTable answers address → name and name → address queries
+//
+// A nil *SymbolTable is valid and knows no symbols.
+type SymbolTable struct {
+	all     []SymbolEntry     // Every symbol, sorted by address then name
+	entries []SymbolEntry     // Functions and objects sorted by address
+	byName  map[string]uint32 // Every symbol, including absolute ones
+}
+
+// NewSymbolTable builds a table from a list of symbols
+func NewSymbolTable(syms []SymbolEntry) *SymbolTable {
+	st := &SymbolTable{byName: make(map[string]uint32, len(syms))}
+	st.all = append([]SymbolEntry(nil), syms...)
+	sort.SliceStable(st.all, func(i, j int) bool {
+		if st.all[i].Addr != st.all[j].Addr {
+			return st.all[i].Addr < st.all[j].Addr
+		}
+		return st.all[i].Name < st.all[j].Name
+	})
+	for _, s := range st.all {
+		st.byName[s.Name] = s.Addr
+		if s.Kind != SymAbs {
+			st.entries = append(st.entries, s)
+		}
+	}
+	// Functions sort after objects at the same address so they win lookups
+	sort.SliceStable(st.entries, func(i, j int) bool {
+		a, b := st.entries[i], st.entries[j]
+		if a.Addr != b.Addr {
+			return a.Addr < b.Addr
+		}
+		return a.Kind > b.Kind
+	})
+	return st
+}
+
+// Addr returns the address of a named symbol
+func (st *SymbolTable) Addr(name string) (uint32, bool) {
+	if st == nil {
+		return 0, false
+	}
+	addr, ok := st.byName[name]
+	return addr, ok
+}
+
+// Lookup finds the symbol containing addr and the offset into it
+func (st *SymbolTable) Lookup(addr uint32) (sym SymbolEntry, offset uint32, ok bool) {
+	if st == nil {
+		return SymbolEntry{}, 0, false
+	}
+	i := sort.Search(len(st.entries), func(i int) bool { return st.entries[i].Addr > addr }) - 1
+	if i < 0 {
+		return SymbolEntry{}, 0, false
+	}
+	sym = st.entries[i]
+	offset = addr - sym.Addr
+	if sym.Size != 0 && offset >= sym.Size {
+		return SymbolEntry{}, 0, false
+	}
+	return sym, offset, true
+}
+
+// Format renders addr as "name" or "name+0x10" ("" if unknown)
+func (st *SymbolTable) Format(addr uint32) string {
+	sym, off, ok := st.Lookup(addr)
+	switch {
+	case !ok:
+		return ""
+	case off == 0:
+		return sym.Name
+	default:
+		return fmt.Sprintf("%s+0x%x", sym.Name, off)
+	}
+}
+
+// Functions returns the function symbols in address order
+func (st *SymbolTable) Functions() []SymbolEntry {
+	if st == nil {
+		return nil
+	}
+	var funcs []SymbolEntry
+	for _, s := range st.entries {
+		if s.Kind == SymFunc {
+			funcs = append(funcs, s)
+		}
+	}
+	return funcs
+}
+
+// Symbols returns every symbol (including absolute ones) in address order
+func (st *SymbolTable) Symbols() []SymbolEntry {
+	if st == nil {
+		return nil
+	}
+	return append([]SymbolEntry(nil), st.all...)
+}
+
+// SymbolTable classifies and sizes the image's symbols
+//
+// Symbols in .text are functions, other symbols in a segment are
+// objects, and linker-defined section bounds are absolute. A symbol
+// extends to the next one in its segment (or the segment end).
+func (img *Image) SymbolTable() *SymbolTable {
+	var syms []SymbolEntry
+	for name, addr := range img.Symbols {
+		s := SymbolEntry{Name: name, Addr: addr, Kind: SymAbs}
+		if !isLinkerSymbol(name) {
+			if seg := img.segmentAt(addr); seg != nil {
+				s.Kind = SymObject
+				if seg.Name == SecText.String() {
+					s.Kind = SymFunc
+				}
+			}
+		}
+		syms = append(syms, s)
+	}
+	sort.Slice(syms, func(i, j int) bool {
+		if syms[i].Addr != syms[j].Addr {
+			return syms[i].Addr < syms[j].Addr
+		}
+		return syms[i].Name < syms[j].Name
+	})
+
+	for i := range syms {
+		if syms[i].Kind == SymAbs {
+			continue
+		}
+		seg := img.segmentAt(syms[i].Addr)
+		end := seg.Addr + seg.Size
+		for _, next := range syms[i+1:] {
+			if next.Kind != SymAbs && next.Addr > syms[i].Addr && next.Addr < end {
+				end = next.Addr
+				break
+			}
+		}
+		syms[i].Size = end - syms[i].Addr
+	}
+	return NewSymbolTable(syms)
+}
+
+// segmentAt returns the segment containing addr (nil if none)
+func (img *Image) segmentAt(addr uint32) *Segment {
+	for i := range img.Segments {
+		seg := &img.Segments[i]
+		if addr >= seg.Addr && addr-seg.Addr < seg.Size {
+			return seg
+		}
+	}
+	return nil
+}
+
+// isLinkerSymbol reports whether Link defined name as a section bound
+func isLinkerSymbol(name string) bool {
+	for k := SectionKind(0); k < NumSections; k++ {
+		sec := strings.TrimPrefix(k.String(), ".")
+		if name == "__"+sec+"_start" || name == "__"+sec+"_end" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSymbols installs a symbol table for tracing, profiling and debugging
+func (c *Core) SetSymbols(st *SymbolTable) {
+	c.symbols = st
+	if c.tracer != nil {
+		c.tracer.symbols = st
+	}
+}
+
+// Symbols returns the loaded symbol table (nil if none)
+func (c *Core) Symbols() *SymbolTable {
+	return c.symbols
+}
@@ -0,0 +1,85 @@
+package suprax32
+
+import "io"
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// SYSTEM CALLS
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: A program needs a way out. Without one it can only spin at the end
+//
+//	of main while the host guesses how many cycles to run, and its
+//	results have to be dug out of memory.
+//
+// INTERFACE:
+//
+//	system N     N = call number (0-4095), arguments in a0-a2,
+//	             result in a0 (SysErr for an unknown call or bad argument)
+//
+//	N  Name      Arguments          Result
+//	0  SysExit   a0 = status        does not return; the core halts
+//	1  SysWrite  a0 = fd (1 or 2)   a0 = bytes written
+//	             a1 = buffer
+//	             a2 = length
+//
+// TIMING: The call takes effect when it commits. A system instruction is
+//
+//	serializing: nothing younger dispatches until it commits, so no
+//	younger store can change the buffer before it is read, and younger
+//	readers of a0 see the result. The cost is a drained window per call.
+//
+// ADDRESSES: Buffers are virtual. With the MMU on (see mmu.go) they go
+//
+//	through the page table, and an unmapped or unreadable buffer fails
+//	the call instead of faulting.
+//
+// MINECRAFT ANALOGY: Ringing the bell for the server admin. Everyone
+//
+//	stops what they are doing until the admin has answered.
+
+// System call numbers (the argument of a system instruction, see trap.go)
+const (
+	SysExit  = 0 // Halt with exit status a0
+	SysWrite = 1 // Write a2 bytes at a1 to file descriptor a0
+)
+
+// SysErr is returned in a0 by a failed system call
+const SysErr = ^uint32(0)
+
+// syscall performs a committed system call
+//
+// Memory is read as the program sees it (Core.ReadMem): stores write the
+// L1D when they issue, so the buffer may still be in a dirty line.
+func (c *Core) syscall(num uint32) {
+	regs := &c.window.regFile
+	a0, a1, a2 := regs[RegA0], regs[RegA0+1], regs[RegA0+2]
+
+	result := SysErr
+	switch num {
+	case SysExit:
+		c.halted = true
+		c.exitCode = a0
+		return
+
+	case SysWrite:
+		if (a0 == 1 || a0 == 2) && uint64(a2) <= uint64(len(c.memory)) {
+			if buf, ok := c.readVirtual(a1, int(a2)); ok {
+				if c.console != nil {
+					c.console.Write(buf)
+				}
+				result = a2
+			}
+		}
+	}
+	regs[RegA0] = result
+}
+
+// SetConsole sets where SysWrite output goes (nil discards it)
+func (c *Core) SetConsole(w io.Writer) {
+	c.console = w
+}
+
+// Exited reports whether the program has called SysExit, and its status
+func (c *Core) Exited() (status uint32, ok bool) {
+	return c.exitCode, c.halted
+}
@@ -0,0 +1,362 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// FEEDBACK-DIRECTED PREFETCH THROTTLING
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: The 5-way predictor (INNOVATION #59) queues a prefetch for every
+//
+//	prediction it makes. When it is wrong, those prefetches still take
+//	the one fill slot per cycle in the prefetch stage and throw live
+//	lines out of the L1D. Nothing turns it down when that happens.
+//
+// THE CONTROLLER: At the end of every interval it reads the prefetch
+//
+//	accounting (see prefetch.go) and moves one level up or down:
+//
+//	LEVEL         DEGREE  DISTANCE  MIN CONFIDENCE
+//	off           0       -         12   (no prefetches at all)
+//	conservative  1       0         12
+//	default       1       0         0    (the unthrottled behaviour)
+//	moderate      2       1         0
+//	aggressive    4       2         0
+//
+//	Degree        lines queued per prediction
+//	Distance      how many strides past the predicted address the first
+//	              of them is (0 = the predicted line itself); the rest
+//	              follow one stride apart. A load without a confident
+//	              stride gets only its predicted line.
+//	MinConfidence the lowest specialist confidence MetaPredictor.SelectBest
+//	              accepts. Address speculation (see addrspec.go) uses the
+//	              same prediction, and a wrong address costs it a replay,
+//	              so it is held to the same bar.
+//
+// THE FEEDBACK (per interval, each counter halved and then added to, so
+// older intervals fade out):
+//
+//	Accuracy   Useful / Issued           did anybody read the lines?
+//	Lateness   Late / (Useful + Late)    did they arrive in time?
+//	Pollution  Polluting / demand misses how many misses did they cause?
+//
+// THE DECISION:
+//
+//	Accuracy ≥ AccuracyHigh     late → up, else stay
+//	between the two             polluting → down,
+//	                            late → up, else stay
+//	Accuracy ≤ AccuracyLow      down
+//	fewer than MinIssued fills  stay (not enough to judge)
+//	off for OffIntervals        back to conservative to try again
+//
+//	Down from conservative is off; up stops at aggressive. Every change
+//	is logged with the feedback that caused it (PrefetchThrottleStats).
+//
+// A plugged-in prefetcher (see prefetcher.go) has its own PrefetchConfig
+// and is not throttled.
+//
+// MINECRAFT ANALOGY: The helper who lays out materials gets told, every
+//
+//	day, how much of yesterday's pile was used. Mostly used but too late:
+//	bring more, further ahead. Mostly left lying around: bring less, and
+//	after a bad enough run, nothing for a few days before trying again.
+
+// PrefetchLevel is one setting of the prefetch throttle
+type PrefetchLevel uint8
+
+const (
+	PrefetchOff          PrefetchLevel = iota // No prefetches
+	PrefetchConservative                      // Confident predictions only
+	PrefetchDefault                           // The unthrottled behaviour
+	PrefetchModerate                          // Two lines, one stride ahead
+	PrefetchAggressive                        // Four lines, two strides ahead
+)
+
+// prefetchLevels holds each level's settings
+var prefetchLevels = [...]struct {
+	name          string
+	degree        int
+	distance      int
+	minConfidence uint8
+}{
+	PrefetchOff:          {"off", 0, 0, 12},
+	PrefetchConservative: {"conservative", 1, 0, 12},
+	PrefetchDefault:      {"default", 1, 0, 0},
+	PrefetchModerate:     {"moderate", 2, 1, 0},
+	PrefetchAggressive:   {"aggressive", 4, 2, 0},
+}
+
+// String returns the level's name
+func (l PrefetchLevel) String() string {
+	return prefetchLevels[l].name
+}
+
+// ThrottleLogSize is the number of level changes kept in the log
+const ThrottleLogSize = 64
+
+// ThrottleConfig holds the controller's interval and thresholds
+//
+// Zero fields take the defaults in parentheses.
+type ThrottleConfig struct {
+	Interval      uint64  // Cycles per interval (4096)
+	AccuracyHigh  float64 // Accurate at or above this (0.75)
+	AccuracyLow   float64 // Inaccurate at or below this (0.40)
+	LateHigh      float64 // Late at or above this (0.10)
+	PollutionHigh float64 // Polluting at or above this (0.05)
+	MinIssued     uint64  // Fewest prefetch fills worth judging (16)
+	OffIntervals  int     // Intervals off before trying again (8)
+}
+
+// withDefaults fills in the zero fields
+func (cfg ThrottleConfig) withDefaults() ThrottleConfig {
+	if cfg.Interval == 0 {
+		cfg.Interval = 4096
+	}
+	if cfg.AccuracyHigh == 0 {
+		cfg.AccuracyHigh = 0.75
+	}
+	if cfg.AccuracyLow == 0 {
+		cfg.AccuracyLow = 0.40
+	}
+	if cfg.LateHigh == 0 {
+		cfg.LateHigh = 0.10
+	}
+	if cfg.PollutionHigh == 0 {
+		cfg.PollutionHigh = 0.05
+	}
+	if cfg.MinIssued == 0 {
+		cfg.MinIssued = 16
+	}
+	if cfg.OffIntervals == 0 {
+		cfg.OffIntervals = 8
+	}
+	return cfg
+}
+
+// throttleFeedback holds the decayed counts the controller judges by
+type throttleFeedback struct {
+	issued    uint64
+	useful    uint64
+	late      uint64
+	polluting uint64
+	misses    uint64 // Demand load misses
+}
+
+// fold halves every count and adds one interval's worth
+func (f *throttleFeedback) fold(interval throttleFeedback) {
+	f.issued = f.issued/2 + interval.issued
+	f.useful = f.useful/2 + interval.useful
+	f.late = f.late/2 + interval.late
+	f.polluting = f.polluting/2 + interval.polluting
+	f.misses = f.misses/2 + interval.misses
+}
+
+func (f throttleFeedback) accuracy() float64  { return ratio(f.useful, f.issued) }
+func (f throttleFeedback) lateness() float64  { return ratio(f.late, f.useful+f.late) }
+func (f throttleFeedback) pollution() float64 { return ratio(f.polluting, f.misses) }
+
+// ThrottleDecision is one level change and the feedback behind it
+type ThrottleDecision struct {
+	Cycle     uint64  `json:"cycle"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Issued    uint64  `json:"issued"` // Decayed prefetch fills judged
+	Accuracy  float64 `json:"accuracy"`
+	Lateness  float64 `json:"lateness"`
+	Pollution float64 `json:"pollution"`
+}
+
+// prefetchThrottle is the controller's state
+type prefetchThrottle struct {
+	on     bool
+	config ThrottleConfig
+	level  PrefetchLevel
+
+	feedback throttleFeedback
+	last     throttleFeedback // Raw totals at the start of the interval
+	start    uint64           // First cycle of the interval
+	offFor   int              // Intervals spent off so far
+
+	// Statistics
+	intervals uint64
+	raised    uint64
+	lowered   uint64
+	cycles    [len(prefetchLevels)]uint64 // Cycles spent at each level
+	entered   [len(prefetchLevels)]uint64 // Changes into each level
+	log       []ThrottleDecision          // The newest ThrottleLogSize changes
+}
+
+// SetPrefetchThrottle turns the feedback-directed throttle on or off
+//
+// Turning it on starts a fresh controller at the default level; turning
+// it off restores the unthrottled behaviour.
+func (c *Core) SetPrefetchThrottle(on bool, config ThrottleConfig) {
+	c.dcache.throttle = prefetchThrottle{on: on, config: config.withDefaults(), level: PrefetchDefault}
+	c.dcache.throttle.last = c.dcache.feedbackTotals()
+	c.dcache.throttle.start = c.cycles
+	c.dcache.predictor.meta.minConfidence = 0
+}
+
+// PrefetchLevel returns the throttle's current level (PrefetchDefault when off)
+func (c *Core) PrefetchLevel() PrefetchLevel {
+	if !c.dcache.throttle.on {
+		return PrefetchDefault
+	}
+	return c.dcache.throttle.level
+}
+
+// queuePrefetch queues the prefetches for one of the 5-way predictor's
+// predictions at the throttle's degree and distance
+func (c *L1DCache) queuePrefetch(pc, addr uint32, predictor PredictorID) {
+	if c.port != nil {
+		return // A plugged-in prefetcher has the queue
+	}
+	if !c.throttle.on {
+		c.prefetchQueue.Enqueue(addr, predictor)
+		return
+	}
+
+	level := prefetchLevels[c.throttle.level]
+	step := c.predictor.stride.lineStep(pc)
+	if step == 0 && level.degree > 0 {
+		c.prefetchQueue.Enqueue(addr, predictor) // Nothing to step along
+		return
+	}
+	for i := 0; i < level.degree; i++ {
+		k := int32(level.distance + i)
+		target := uint32(int32(addr) + k*step)
+		if _, _, cached := c.Probe(target); cached && k > 0 {
+			continue
+		}
+		c.prefetchQueue.Enqueue(target, predictor)
+	}
+}
+
+// lineStep returns the stride a confident entry for pc steps by, at
+// least one line in its direction (0 = no confident stride)
+func (sp *StridePredictor) lineStep(pc uint32) int32 {
+	entry := &sp.entries[sp.getIndex(pc)]
+	if !entry.Valid || entry.Tag != sp.getTag(pc) || entry.Confidence < 4 {
+		return 0
+	}
+	switch {
+	case entry.Stride > 0 && entry.Stride < CacheLineSize:
+		return CacheLineSize
+	case entry.Stride < 0 && entry.Stride > -CacheLineSize:
+		return -CacheLineSize
+	}
+	return entry.Stride
+}
+
+// feedbackTotals sums the prefetch accounting over every source
+func (c *L1DCache) feedbackTotals() throttleFeedback {
+	f := throttleFeedback{misses: c.accesses - c.hits}
+	for _, p := range c.prefetchAcct.perSource {
+		f.issued += p.issued
+		f.useful += p.useful
+		f.late += p.late
+		f.polluting += p.polluting
+	}
+	return f
+}
+
+// throttleTick advances the controller by one cycle
+//
+// ALGORITHM (at the end of an interval):
+//
+//	STEP 1: Fold the interval's counts into the decayed feedback
+//	STEP 2: Pick the next level (nextLevel)
+//	STEP 3: Apply and log it if it changed
+func (c *L1DCache) throttleTick(cycle uint64) {
+	t := &c.throttle
+	if !t.on || c.port != nil {
+		return
+	}
+	t.cycles[t.level]++
+	if cycle-t.start < t.config.Interval {
+		return
+	}
+
+	// STEP 1
+	totals := c.feedbackTotals()
+	t.feedback.fold(throttleFeedback{
+		issued:    totals.issued - t.last.issued,
+		useful:    totals.useful - t.last.useful,
+		late:      totals.late - t.last.late,
+		polluting: totals.polluting - t.last.polluting,
+		misses:    totals.misses - t.last.misses,
+	})
+	t.last = totals
+	t.start = cycle
+	t.intervals++
+
+	// STEP 2
+	next := t.nextLevel()
+
+	// STEP 3
+	if next == t.level {
+		return
+	}
+	if next > t.level {
+		t.raised++
+	} else {
+		t.lowered++
+	}
+	t.entered[next]++
+	t.log = append(t.log, ThrottleDecision{
+		Cycle:     cycle,
+		From:      t.level.String(),
+		To:        next.String(),
+		Issued:    t.feedback.issued,
+		Accuracy:  t.feedback.accuracy(),
+		Lateness:  t.feedback.lateness(),
+		Pollution: t.feedback.pollution(),
+	})
+	if len(t.log) > ThrottleLogSize {
+		t.log = t.log[1:]
+	}
+	t.level = next
+	t.offFor = 0
+	c.predictor.meta.minConfidence = prefetchLevels[next].minConfidence
+}
+
+// nextLevel applies the decision table to the decayed feedback
+func (t *prefetchThrottle) nextLevel() PrefetchLevel {
+	cfg := &t.config
+	if t.level == PrefetchOff {
+		// Off makes no prefetches, so there is nothing to judge: wait
+		// out OffIntervals, then try the most careful level
+		t.offFor++
+		if t.offFor >= cfg.OffIntervals {
+			t.feedback = throttleFeedback{}
+			return PrefetchConservative
+		}
+		return PrefetchOff
+	}
+
+	f := t.feedback
+	if f.issued < cfg.MinIssued {
+		return t.level
+	}
+	late := f.lateness() >= cfg.LateHigh
+	polluting := f.pollution() >= cfg.PollutionHigh
+
+	up, down := t.level, t.level-1
+	if t.level < PrefetchAggressive {
+		up = t.level + 1
+	}
+	switch acc := f.accuracy(); {
+	case acc >= cfg.AccuracyHigh:
+		if late {
+			return up
+		}
+	case acc > cfg.AccuracyLow:
+		if polluting {
+			return down
+		}
+		if late {
+			return up
+		}
+	default:
+		return down
+	}
+	return t.level
+}
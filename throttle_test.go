@@ -0,0 +1,345 @@
+package suprax32
+
+import (
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Feedback-Directed Prefetch Throttling - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// The controller is a small state machine fed by counters, so it is driven directly with the
+// feedback each rule is about and must land on the level the table says. The level settings
+// are checked where they take effect (the queue and the meta-predictor), and one program run
+// checks that a predictor that keeps missing really gets turned down and that the log says so.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. CONTROLLER TESTS
+//    Decision table, the off state, interval feedback and the log
+//
+// 2. LEVEL TESTS
+//    Degree and distance in the queue, the confidence threshold
+//
+// 3. PROGRAM TESTS
+//    Turning down a predictor that misses, stats and deltas
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// newThrottledCache returns an L1D with the throttle on at level
+func newThrottledCache(level PrefetchLevel) *L1DCache {
+	c := NewL1DCache()
+	c.throttle = prefetchThrottle{on: true, config: ThrottleConfig{}.withDefaults(), level: level}
+	return c
+}
+
+// endInterval scores one interval's prefetch outcomes and ends it
+func endInterval(c *L1DCache, cycle *uint64, issued, useful, late, polluting, misses uint64) {
+	acct := &c.prefetchAcct.perSource[PredictorStride-1]
+	acct.issued += issued
+	acct.useful += useful
+	acct.late += late
+	acct.polluting += polluting
+	c.accesses += misses
+	*cycle += c.throttle.config.Interval
+	c.throttleTick(*cycle)
+}
+
+// queuedLines drains the prefetch queue into line numbers
+func queuedLines(pq *PrefetchQueue) []uint32 {
+	var lines []uint32
+	for {
+		addr, _, ok := pq.Dequeue()
+		if !ok {
+			return lines
+		}
+		pq.Complete(addr)
+		lines = append(lines, addr/CacheLineSize)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. CONTROLLER TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestThrottle_DecisionTable(t *testing.T) {
+	// WHAT: Each row of the decision table, from the default level
+	// WHY: The table is the whole policy; a swapped comparison turns a good prefetcher off
+	// HARDWARE: prefetchThrottle.nextLevel
+	// CATEGORY: [UNIT]
+
+	tests := []struct {
+		name  string
+		level PrefetchLevel
+		f     throttleFeedback
+		want  PrefetchLevel
+	}{
+		{"accurate, on time", PrefetchDefault, throttleFeedback{issued: 100, useful: 90, misses: 100}, PrefetchDefault},
+		{"accurate, late", PrefetchDefault, throttleFeedback{issued: 100, useful: 80, late: 20, misses: 100}, PrefetchModerate},
+		{"accurate, late, at the top", PrefetchAggressive, throttleFeedback{issued: 100, useful: 80, late: 20, misses: 100}, PrefetchAggressive},
+		{"middling, polluting", PrefetchDefault, throttleFeedback{issued: 100, useful: 60, late: 20, polluting: 10, misses: 100}, PrefetchConservative},
+		{"middling, late", PrefetchDefault, throttleFeedback{issued: 100, useful: 60, late: 20, misses: 100}, PrefetchModerate},
+		{"middling, neither", PrefetchDefault, throttleFeedback{issued: 100, useful: 60, misses: 100}, PrefetchDefault},
+		{"inaccurate", PrefetchDefault, throttleFeedback{issued: 100, useful: 10, misses: 100}, PrefetchConservative},
+		{"inaccurate, conservative", PrefetchConservative, throttleFeedback{issued: 100, useful: 10, misses: 100}, PrefetchOff},
+		{"too few to judge", PrefetchDefault, throttleFeedback{issued: 10, misses: 100}, PrefetchDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := prefetchThrottle{on: true, config: ThrottleConfig{}.withDefaults(), level: tt.level, feedback: tt.f}
+			if got := th.nextLevel(); got != tt.want {
+				t.Errorf("%s → %s, expected %s", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottle_OffThenRetry(t *testing.T) {
+	// WHAT: Two useless intervals take the default level through conservative to off; off
+	//       lasts OffIntervals intervals and then conservative is tried again, on fresh feedback
+	// WHY: Off makes no prefetches, so without the retry a phase change would never be noticed
+	// HARDWARE: prefetchThrottle.throttleTick, the off state
+	// CATEGORY: [UNIT]
+
+	c := newThrottledCache(PrefetchDefault)
+	var cycle uint64
+	endInterval(c, &cycle, 100, 0, 0, 0, 100)
+	endInterval(c, &cycle, 100, 0, 0, 0, 100)
+	if c.throttle.level != PrefetchOff {
+		t.Fatalf("level %s after two useless intervals, expected off", c.throttle.level)
+	}
+	if c.predictor.meta.minConfidence != prefetchLevels[PrefetchOff].minConfidence {
+		t.Errorf("threshold %d while off", c.predictor.meta.minConfidence)
+	}
+
+	for i := 1; i < c.throttle.config.OffIntervals; i++ {
+		endInterval(c, &cycle, 0, 0, 0, 0, 100)
+		if c.throttle.level != PrefetchOff {
+			t.Fatalf("left off after %d intervals", i)
+		}
+	}
+	endInterval(c, &cycle, 0, 0, 0, 0, 100)
+	if c.throttle.level != PrefetchConservative || c.throttle.feedback.issued != 0 {
+		t.Errorf("level %s with %d old fills remembered; expected a fresh conservative try",
+			c.throttle.level, c.throttle.feedback.issued)
+	}
+
+	s := c.throttle.Stats()
+	if s.Lowered != 2 || s.Raised != 1 || s.Intervals != uint64(2+c.throttle.config.OffIntervals) {
+		t.Errorf("lowered %d, raised %d over %d intervals", s.Lowered, s.Raised, s.Intervals)
+	}
+	log := s.Decisions
+	if len(log) != 3 || log[0].From != "default" || log[1].To != "off" || log[2].To != "conservative" {
+		t.Fatalf("log %+v", log)
+	}
+	if log[0].Cycle != c.throttle.config.Interval || log[0].Accuracy != 0 || log[0].Issued != 100 {
+		t.Errorf("first decision %+v", log[0])
+	}
+}
+
+func TestThrottle_FeedbackDecays(t *testing.T) {
+	// WHAT: Counts are halved every interval, so one bad interval after a long good run lowers
+	//       the accuracy without yet turning the prefetcher down, and the log keeps only the
+	//       newest ThrottleLogSize changes
+	// WHY: A single noisy interval must not flip the level, a lasting change must
+	// HARDWARE: throttleFeedback.fold
+	// CATEGORY: [UNIT]
+
+	c := newThrottledCache(PrefetchDefault)
+	var cycle uint64
+	for i := 0; i < 10; i++ {
+		endInterval(c, &cycle, 100, 90, 0, 0, 100)
+	}
+	endInterval(c, &cycle, 100, 20, 0, 0, 100)
+	if acc := c.throttle.feedback.accuracy(); acc < 0.5 || acc > 0.6 || c.throttle.level != PrefetchDefault {
+		t.Errorf("accuracy %.3f at %s after one bad interval; expected about 0.55, still default", acc, c.throttle.level)
+	}
+
+	// Alternate late and polluting intervals: a change on every one
+	for i := 0; i < 2*ThrottleLogSize; i++ {
+		c.throttle.feedback = throttleFeedback{}
+		if i%2 == 0 {
+			endInterval(c, &cycle, 100, 90, 50, 0, 100)
+		} else {
+			endInterval(c, &cycle, 100, 50, 0, 200, 100)
+		}
+	}
+	if log := c.throttle.log; len(log) != ThrottleLogSize || log[len(log)-1].Cycle != cycle {
+		t.Errorf("log holds %d changes ending at cycle %d, expected %d ending at %d",
+			len(log), log[len(log)-1].Cycle, ThrottleLogSize, cycle)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. LEVEL TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestThrottle_DegreeAndDistance(t *testing.T) {
+	// WHAT: Each level queues its degree of lines, starting its distance of strides past the
+	//       predicted line; word strides step a line at a time; a load without a confident
+	//       stride gets only its predicted line; off queues nothing
+	// WHY: Degree and distance are what the controller turns up and down
+	// HARDWARE: L1DCache.queuePrefetch
+	// CATEGORY: [UNIT]
+
+	const pc = 0x1000
+	stride := func(c *L1DCache, step uint32) {
+		for i := uint32(0); i < 8; i++ {
+			c.predictor.stride.Update(pc, 0x40000+i*step)
+		}
+	}
+	base := uint32(0x40000 / CacheLineSize)
+	tests := []struct {
+		level PrefetchLevel
+		step  uint32 // Trained stride (0 = untrained)
+		want  []uint32
+	}{
+		{PrefetchOff, 256, nil},
+		{PrefetchConservative, 256, []uint32{base}},
+		{PrefetchDefault, 256, []uint32{base}},
+		{PrefetchModerate, 256, []uint32{base + 4, base + 8}},
+		{PrefetchAggressive, 256, []uint32{base + 8, base + 12, base + 16, base + 20}},
+		{PrefetchAggressive, 4, []uint32{base + 2, base + 3, base + 4, base + 5}},
+		{PrefetchAggressive, 0, []uint32{base}},
+	}
+	for _, tt := range tests {
+		c := newThrottledCache(tt.level)
+		if tt.step != 0 {
+			stride(c, tt.step)
+		}
+		c.queuePrefetch(pc, 0x40000, PredictorStride)
+		if got := queuedLines(&c.prefetchQueue); !sameLines(got, tt.want) {
+			t.Errorf("%s, stride %d: queued lines %v, expected %v", tt.level, tt.step, got, tt.want)
+		}
+	}
+
+	// Throttle off: exactly the predicted line, as before the throttle existed
+	c := NewL1DCache()
+	stride(c, 256)
+	c.queuePrefetch(pc, 0x40000, PredictorStride)
+	if got := queuedLines(&c.prefetchQueue); !sameLines(got, []uint32{base}) {
+		t.Errorf("unthrottled: queued lines %v", got)
+	}
+}
+
+func TestThrottle_ConfidenceThreshold(t *testing.T) {
+	// WHAT: SelectBest passes over predictions below minConfidence, with and without meta
+	//       history for the PC
+	// WHY: The conservative level keeps only predictions the specialists are sure of
+	// HARDWARE: MetaPredictor.SelectBest
+	// CATEGORY: [UNIT]
+
+	var predictions [5]struct {
+		addr       uint32
+		confidence uint8
+		valid      bool
+	}
+	predictions[0].addr, predictions[0].confidence, predictions[0].valid = 0x100, 6, true
+	predictions[2].addr, predictions[2].confidence, predictions[2].valid = 0x200, 13, true
+
+	var mp MetaPredictor
+	for _, history := range []bool{false, true} {
+		if history {
+			mp.Update(0x40, PredictorStride, true)
+			mp.Update(0x40, PredictorStride, true)
+		}
+		mp.minConfidence = 0
+		if _, id, _ := mp.SelectBest(0x40, predictions); id != PredictorConstant && !history {
+			t.Errorf("no history: chose %s, expected the more confident constant", id)
+		}
+		if _, id, _ := mp.SelectBest(0x40, predictions); id != PredictorStride && history {
+			t.Errorf("history: chose %s, expected the trusted stride", id)
+		}
+		mp.minConfidence = 12
+		if addr, id, ok := mp.SelectBest(0x40, predictions); !ok || id != PredictorConstant || addr != 0x200 {
+			t.Errorf("history %v, threshold 12: chose %s 0x%x %v, expected constant 0x200", history, id, addr, ok)
+		}
+		mp.minConfidence = 14
+		if _, _, ok := mp.SelectBest(0x40, predictions); ok {
+			t.Errorf("history %v, threshold 14: a prediction below it was chosen", history)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. PROGRAM TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestThrottle_PointerChase(t *testing.T) {
+	// WHAT: On the pointer-chase kernel, whose prefetches are mostly useless, the throttle
+	//       turns the predictor down, spends time off, issues fewer prefetches, and the result
+	//       is unchanged; the stats log every change and a delta keeps only its own
+	// WHY: This is the case the throttle exists for
+	// HARDWARE: Prefetch stage, L1DCache.throttleTick
+	// CATEGORY: [INTEGRATION]
+
+	var src string
+	for _, k := range StandardKernels() {
+		if k.Name == "pointer-chase" {
+			src = "#define ITERATIONS 1\n" + k.Source
+		}
+	}
+	img, err := BuildC("chase.c", src)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	var mid *Stats
+	run := func(on bool) *Stats {
+		core := NewCore(1 << 20)
+		core.SetConsole(&strings.Builder{})
+		core.SetPrefetchThrottle(on, ThrottleConfig{})
+		if err := core.LoadImage(img); err != nil {
+			t.Fatalf("LoadImage: %v", err)
+		}
+		core.Run(500_000)
+		mid = core.Snapshot()
+		core.Run(5_000_000)
+		if status, ok := core.Exited(); !ok || status != 0 {
+			t.Fatalf("throttle %v: exit %d, %v", on, status, ok)
+		}
+		return core.Snapshot()
+	}
+	free, throttled := run(false), run(true)
+
+	issued := func(s *Stats) (n uint64) {
+		for _, p := range s.L1D.Prefetchers {
+			n += p.Issued
+		}
+		return n
+	}
+	th := throttled.L1D.Throttle
+	if !th.Enabled || th.Lowered == 0 || th.Levels[PrefetchOff].Cycles == 0 {
+		t.Errorf("enabled %v, lowered %d times, %d cycles off", th.Enabled, th.Lowered, th.Levels[PrefetchOff].Cycles)
+	}
+	if len(th.Decisions) == 0 || th.Decisions[0].From != "default" || th.Decisions[0].Accuracy > 0.4 {
+		t.Fatalf("decisions %+v", th.Decisions)
+	}
+	if issued(throttled) >= issued(free) {
+		t.Errorf("issued %d prefetches throttled, %d without", issued(throttled), issued(free))
+	}
+	if free.L1D.Throttle.Enabled || free.L1D.Throttle.Level != "default" {
+		t.Errorf("unthrottled run reports %+v", free.L1D.Throttle)
+	}
+
+	d := throttled.Delta(mid)
+	for _, dec := range d.L1D.Throttle.Decisions {
+		if dec.Cycle <= mid.Core.Cycles {
+			t.Errorf("delta kept a decision from cycle %d, before the interval", dec.Cycle)
+		}
+	}
+	if n := len(d.L1D.Throttle.Decisions) + len(mid.L1D.Throttle.Decisions); n != len(th.Decisions) && len(th.Decisions) < ThrottleLogSize {
+		t.Errorf("%d decisions split into %d before and %d during", len(th.Decisions), len(mid.L1D.Throttle.Decisions), n)
+	}
+	for _, col := range d.CSVHeader() {
+		if strings.Contains(col, "decisions") {
+			t.Fatalf("CSV column %q: the log must not change the columns", col)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// CONTROL REGISTERS AND TRAPS
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: A page fault has to stop the program at the faulting instruction,
+//
+//	tell software what went wrong, and let it resume once the page is
+//	mapped. That needs control registers (CSRs), a way to reach them,
+//	and a way back from the handler.
+//
+// ENCODING: Everything lives in the system instruction. The immediate
+//
+//	is split into a function and a 12-bit argument:
+//
+//	[OpSYSTEM:5][rd:5][rs1:5][funct:5][arg:12]
+//
+//	funct  Assembly          Effect
+//	0      system N          system call N (see syscall.go)
+//	1      csrr rd, csr      rd = CSR
+//	2      csrw csr, rs1     CSR = rs1
+//	3      eret              PC = epc (return from a trap)
+//	4      tlbflush          drop every TLB entry (see mmu.go)
+//
+//	Like system calls they are serializing and take effect at commit.
+//	csrw, eret and tlbflush then refetch from the next PC, so no
+//	instruction fetched under the old state survives.
+//
+// TRAPS (precise, INNOVATION #47): A faulting instruction completes
+//
+//	with a cause instead of a result. When it becomes the oldest
+//	instruction, commit stops before it and:
+//
+//	STEP 1: epc = its PC, cause = the fault, badaddr = the address
+//	STEP 2: Flush the window and fetch buffer
+//	STEP 3: Fetch from tvec
+//
+//	Every older instruction has committed and nothing younger has, so
+//	the handler sees exactly the state before the faulting instruction,
+//	and eret retries it.
+//
+//	Loads and stores must be naturally aligned (halfwords at even
+//	addresses, words at multiples of 4). Any other address traps with
+//	CauseLoadMisaligned or CauseStoreMisaligned before it is translated,
+//	so no access ever straddles a cache line or a page.
+//
+// PRIVILEGE: Two modes, machine (reset) and user.
+//
+//	A trap saves the mode in status.PP and enters machine mode; eret
+//	returns to status.PP and leaves user in it. The first entry to
+//	user mode is therefore: set epc and status.PP, then eret.
+//
+//	User mode may not:
+//	  touch a CSR above its level      → CauseIllegalInstruction
+//	    (CSR bits [9:8] = lowest mode allowed, [11:10] = 3 read-only)
+//	  eret, tlbflush                   → CauseIllegalInstruction
+//	  make a system call               → CauseUserCall (the kernel
+//	                                     services it, in machine mode)
+//	  reach a page without PTEUser     → page fault (see mmu.go)
+//	  run with translation off         → page fault on every access
+//
+// SPECULATION: The Window tracks the mode instructions are dispatched
+//
+//	in and stamps it into every entry (WindowEntry.Priv). Checks use
+//	the entry's mode, never the core's, and happen before anything
+//	observable: a CSR is read only at commit, and a user load from a
+//	machine page faults at the DTLB before it reaches the LSU, so not
+//	even a wrong-path load can bring machine data into the L1D.
+//	Modes change only at commit (trap, eret), which flushes everything
+//	younger, so no entry ever runs under a mode it was not fetched in.
+//
+// MINECRAFT ANALOGY: A command block that, when a player walks into an
+//
+//	unloaded chunk, notes where they stood and teleports them to the
+//	admin room; the admin loads the chunk and sends them back.
+
+// System instruction functions (immediate bits [16:12])
+const (
+	SysFnCall     = 0 // system N
+	SysFnCSRRead  = 1 // csrr rd, csr
+	SysFnCSRWrite = 2 // csrw csr, rs1
+	SysFnTrapRet  = 3 // eret
+	SysFnTLBFlush = 4 // tlbflush
+)
+
+// Privilege modes
+const (
+	PrivUser    = 0
+	PrivMachine = 3
+)
+
+// Control and status registers (bits [9:8] = lowest mode, [11:10] = 3 read-only)
+const (
+	CSRPtbr    = 0x180 // Page table base + enable (see mmu.go)
+	CSRStatus  = 0x300 // Previous mode (PP) saved by a trap
+	CSRTvec    = 0x305 // Trap handler address
+	CSRScratch = 0x340 // Free for the handler (e.g. to free a register)
+	CSREpc     = 0x341 // PC of the instruction that trapped
+	CSRCause   = 0x342 // Why (Cause* below)
+	CSRBadAddr = 0x343 // Faulting virtual address
+	CSRCycle   = 0xC00 // Cycle counter (read-only, user mode may read)
+	CSRInstret = 0xC02 // Instructions retired (read-only, user mode may read)
+)
+
+// Status register fields
+const (
+	StatusPPShift = 11                 // Mode before the last trap
+	StatusPP      = 3 << StatusPPShift // (PrivUser or PrivMachine)
+)
+
+// Trap causes (CSRCause)
+const (
+	CauseNone               = 0
+	CauseFetchPageFault     = 1 // Instruction fetch from an unmapped or non-executable page
+	CauseLoadPageFault      = 2 // Load from an unmapped or non-readable page
+	CauseStorePageFault     = 3 // Store to an unmapped or non-writable page
+	CauseIllegalInstruction = 4 // Privileged or undefined system instruction
+	CauseUserCall           = 5 // System call from user mode
+	CauseLoadMisaligned     = 6 // Halfword or word load from an address it does not divide
+	CauseStoreMisaligned    = 7 // Halfword or word store to an address it does not divide
+)
+
+// csrFile holds the trap CSRs (CSRPtbr lives in the MMU)
+type csrFile struct {
+	status  uint32
+	tvec    uint32
+	scratch uint32
+	epc     uint32
+	cause   uint32
+	badAddr uint32
+}
+
+// csrNames maps CSR names to numbers for the assembler
+var csrNames = map[string]uint32{
+	"ptbr":    CSRPtbr,
+	"status":  CSRStatus,
+	"tvec":    CSRTvec,
+	"scratch": CSRScratch,
+	"epc":     CSREpc,
+	"cause":   CSRCause,
+	"badaddr": CSRBadAddr,
+	"cycle":   CSRCycle,
+	"instret": CSRInstret,
+}
+
+// EncodeSystem creates a system instruction with a function and argument
+func EncodeSystem(funct, rd, rs1 uint8, arg uint32) uint32 {
+	return EncodeIFormat(OpSYSTEM, rd, rs1, int32(uint32(funct)<<12|arg&0xFFF))
+}
+
+// systemFunct splits a system instruction's immediate
+func systemFunct(imm int32) (funct uint8, arg uint32) {
+	return uint8(uint32(imm)>>12) & 0x1F, uint32(imm) & 0xFFF
+}
+
+// Privilege returns the mode of the instructions being executed
+func (c *Core) Privilege() uint8 {
+	return c.window.priv
+}
+
+// csrExists reports whether a CSR is implemented
+func csrExists(csr uint32) bool {
+	switch csr {
+	case CSRPtbr, CSRStatus, CSRTvec, CSRScratch, CSREpc, CSRCause, CSRBadAddr,
+		CSRCycle, CSRInstret:
+		return true
+	}
+	return false
+}
+
+// systemCheck decides at issue whether a system instruction may run in
+// its entry's mode (CauseNone), or which trap it takes instead
+func systemCheck(e *WindowEntry) uint8 {
+	funct, arg := systemFunct(e.Imm)
+	switch funct {
+	case SysFnCall:
+		if e.Priv == PrivUser {
+			return CauseUserCall
+		}
+		return CauseNone
+
+	case SysFnCSRRead, SysFnCSRWrite:
+		readOnly := arg>>10 == 3
+		if !csrExists(arg) || uint32(e.Priv) < arg>>8&3 || funct == SysFnCSRWrite && readOnly {
+			return CauseIllegalInstruction
+		}
+		return CauseNone
+
+	case SysFnTrapRet, SysFnTLBFlush:
+		if e.Priv != PrivMachine {
+			return CauseIllegalInstruction
+		}
+		return CauseNone
+	}
+	return CauseIllegalInstruction
+}
+
+// readCSR returns a CSR (systemCheck has vetted the access)
+func (c *Core) readCSR(csr uint32) uint32 {
+	switch csr {
+	case CSRPtbr:
+		return c.mmu.ptbr
+	case CSRStatus:
+		return c.csr.status
+	case CSRTvec:
+		return c.csr.tvec
+	case CSRScratch:
+		return c.csr.scratch
+	case CSREpc:
+		return c.csr.epc
+	case CSRCause:
+		return c.csr.cause
+	case CSRBadAddr:
+		return c.csr.badAddr
+	case CSRCycle:
+		return uint32(c.cycles)
+	case CSRInstret:
+		return uint32(c.instructions)
+	}
+	return 0
+}
+
+// writeCSR sets a CSR (systemCheck has vetted the access)
+func (c *Core) writeCSR(csr, v uint32) {
+	switch csr {
+	case CSRPtbr:
+		c.mmu.setPTBR(v)
+	case CSRStatus:
+		// PP holds a valid mode: anything but user means machine
+		c.csr.status = PrivMachine << StatusPPShift
+		if v&StatusPP>>StatusPPShift == PrivUser {
+			c.csr.status = PrivUser << StatusPPShift
+		}
+	case CSRTvec:
+		c.csr.tvec = v &^ 3
+	case CSRScratch:
+		c.csr.scratch = v
+	case CSREpc:
+		c.csr.epc = v &^ 3
+	case CSRCause:
+		c.csr.cause = v
+	case CSRBadAddr:
+		c.csr.badAddr = v
+	}
+}
+
+// system performs a committed system instruction
+//
+// RETURNS: redirect = true when fetch must restart at next
+func (c *Core) system(e *WindowEntry) (next uint32, redirect bool) {
+	funct, arg := systemFunct(e.Imm)
+	regs := &c.window.regFile
+
+	switch funct {
+	case SysFnCall:
+		c.syscall(arg)
+
+	case SysFnCSRRead:
+		// Nothing younger has dispatched, so writing the committed
+		// register directly is safe
+		if e.Rd != 0 {
+			regs[e.Rd] = c.readCSR(arg)
+		}
+
+	case SysFnCSRWrite:
+		c.writeCSR(arg, regs[e.Rs1])
+		return e.PC + 4, true
+
+	case SysFnTrapRet:
+		c.window.priv = uint8(c.csr.status & StatusPP >> StatusPPShift)
+		c.csr.status = PrivUser << StatusPPShift
+		return c.csr.epc, true
+
+	case SysFnTLBFlush:
+		c.mmu.Flush()
+		return e.PC + 4, true
+	}
+	return 0, false
+}
+
+// fault completes an instruction with a trap cause instead of a result
+func (c *Core) fault(winID int, cause uint8, addr uint32) {
+	entry := c.window.GetEntry(winID)
+	entry.Fault = cause
+	entry.FaultAddr = addr
+	c.window.Complete(winID, 0)
+}
+
+// trap delivers a faulting head instruction's trap (STEPS 1-3)
+func (c *Core) trap(e *WindowEntry) {
+	c.csr.epc = e.PC
+	c.csr.cause = uint32(e.Fault)
+	c.csr.badAddr = e.FaultAddr
+	c.csr.status = uint32(e.Priv) << StatusPPShift
+	c.window.priv = PrivMachine
+	c.traps++
+
+	c.archPC = c.csr.tvec
+	c.redirect(c.csr.tvec)
+}
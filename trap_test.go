@@ -0,0 +1,254 @@
+package suprax32
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Privilege Modes - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// Isolation is only worth anything if user code cannot get around it, so every test runs a
+// small machine-mode kernel that drops into user mode and then lets the user code try
+// everything it should not be able to do. Each forbidden action must trap to the kernel with
+// the right cause, and nothing it touched may be observable afterwards - not even through the
+// cache, by a load that only ran on a wrong path.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. ISOLATION TESTS
+//    Privileged CSRs and instructions, machine pages, system calls from user mode
+//
+// 2. SPECULATION TESTS
+//    Wrong-path user loads of machine pages leave the L1D untouched
+//
+// 3. CSR TESTS
+//    Unknown and read-only CSRs in machine mode
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// userKernel is a minimal machine-mode kernel. It enters user_entry in user mode, forwards
+// user system calls (a7 = call number, 0 or 1), and for any other trap shifts the cause
+// into s1 and resumes after the faulting instruction (at ra for a fetch fault).
+const userKernel = `
+	.text
+_start:
+	la    t0, handler
+	csrw  tvec, t0
+	li    t0, PTBR
+	csrw  ptbr, t0
+	li    t0, 0x3800
+	lw    t0, 0(t0)           # Data page translation into the DTLB (not its first line)
+	csrw  status, zero        # status.PP = user
+	la    t0, user_entry
+	csrw  epc, t0
+	li    s1, 0
+	eret
+
+handler:
+	csrr  t5, cause
+	li    t6, 5               # CauseUserCall
+	beq   t5, t6, ucall
+	li    t6, 4
+	sll   s1, s1, t6
+	or    s1, s1, t5
+	li    t6, 1               # CauseFetchPageFault
+	beq   t5, t6, fetchfault
+	csrr  t5, epc
+	addi  t5, t5, 4
+	csrw  epc, t5
+	eret
+fetchfault:
+	csrw  epc, ra
+	eret
+ucall:
+	csrr  t5, epc
+	addi  t5, t5, 4
+	csrw  epc, t5
+	beq   a7, zero, uexit
+	system 1
+	eret
+uexit:
+	system 0
+`
+
+// runUser runs userKernel followed by user code on its own page (0x2000) mapped with the
+// PTEUser bit; the kernel page is not, and the data page at 0x3000 gets dataFlags
+func runUser(t *testing.T, user string, dataFlags uint32) (*Core, string) {
+	t.Helper()
+	kernel := strings.Replace(userKernel, "PTBR", fmt.Sprint(testPTBR), 1)
+	src := kernel + "\n\t.align 4096\n\t.globl user_entry\nuser_entry:\n" + user
+	return runAsm(t, src, func(c *Core, pt *pageTables, img *Image) {
+		pt.identity(0x1000, 0x2000, PTERead|PTEExec)
+		pt.identity(0x2000, 0x3000, PTERead|PTEExec|PTEUser)
+		pt.identity(0x3000, 0x4000, dataFlags)
+		c.WriteMemWord(0x3000, 0x5EC7E7)
+	})
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. ISOLATION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPriv_UserModeIsolation(t *testing.T) {
+	// WHAT: User code may read cycle and make system calls; every privileged CSR access,
+	//       privileged instruction, machine page access and machine code fetch traps
+	// WHY: A user program must not be able to change or see anything the kernel owns
+	// HARDWARE: systemCheck at issue (entry's mode), PTEUser check at the TLBs, status.PP
+	//           saved by a trap and restored by eret
+	// CATEGORY: [INTEGRATION]
+
+	core, out := runUser(t, `
+	csrr  s2, cycle           # Allowed
+	li    a0, 1
+	la    a1, msg
+	li    a2, 3
+	li    a7, 1
+	system 1                  # Forwarded by the kernel
+	mv    s3, a0
+	li    t1, 0x3000
+	li    s4, 7
+	lw    s4, 0(t1)           # Machine page: load fault
+	csrr  s5, ptbr            # Illegal
+	eret                      # Illegal
+	tlbflush                  # Illegal
+	csrw  status, zero        # Illegal
+	sw    t1, 0(t1)           # Machine page: store fault
+	la    t1, _start
+	jalr  ra, 0(t1)           # Machine code: fetch fault, resumes at ra
+	mv    a0, s1
+	li    a7, 0
+	system 0
+msg:
+	.asciz "ok\n"
+`, PTERead|PTEWrite)
+
+	if out != "ok\n" {
+		t.Errorf("forwarded SysWrite printed %q", out)
+	}
+	// Causes in order: load, illegal x4, store, fetch
+	if status, _ := core.Exited(); status != 0x2444431 {
+		t.Errorf("trap causes 0x%x, expected 0x2444431", status)
+	}
+	regs := &core.window.regFile
+	if regs[RegS0+2] == 0 {
+		t.Error("csrr cycle read 0 in user mode")
+	}
+	if regs[RegS0+3] != 3 {
+		t.Errorf("system call returned %d, expected 3", regs[RegS0+3])
+	}
+	if regs[RegS0+4] != 7 {
+		t.Errorf("faulting load wrote s4 = 0x%x", regs[RegS0+4])
+	}
+	if got := c32(core.ReadMem(0x3000, 4)); got != 0x5EC7E7 {
+		t.Errorf("machine page written from user mode: 0x%x", got)
+	}
+	if core.Privilege() != PrivMachine {
+		t.Errorf("exited in mode %d, expected machine (the kernel exits)", core.Privilege())
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. SPECULATION TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPriv_WrongPathLoadCannotReachMachinePage(t *testing.T) {
+	// WHAT: A user load of a machine page that only runs on a mispredicted path does not
+	//       bring the line into the L1D; the same load of a user page does
+	// WHY: Spectre-style attacks read secrets through what a squashed load left in the cache
+	// HARDWARE: The DTLB permission check uses the entry's mode and fails before the LSU
+	// CATEGORY: [INTEGRATION]
+
+	const user = `
+	li    t1, 0x3000
+	la    t2, slow
+	lw    t3, 0(t2)           # Misses the L1D: the branch resolves late
+	beqz  t3, secret          # Never taken
+	mv    a0, s1
+	li    a7, 0
+	system 0
+secret:
+	lw    s4, 0(t1)           # Wrong path only
+	j     secret
+	.align 64
+slow:
+	.word 1
+`
+	for _, tc := range []struct {
+		name   string
+		flags  uint32
+		cached bool
+	}{
+		{"user page", PTERead | PTEWrite | PTEUser, true},
+		{"machine page", PTERead | PTEWrite, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			core, _ := runUser(t, user, tc.flags)
+			if status, _ := core.Exited(); status != 0 {
+				t.Fatalf("trap causes 0x%x: the load was not on the wrong path", status)
+			}
+			if _, hit := core.dcache.peekSize(0x3000, 4); hit != tc.cached {
+				t.Errorf("line of the wrong-path load cached = %v, expected %v", hit, tc.cached)
+			}
+		})
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. CSR TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestPriv_MachineCSRChecks(t *testing.T) {
+	// WHAT: Even machine mode traps on an unknown CSR or a write to a read-only one, and a
+	//       status write keeps only a valid PP
+	// WHY: Software probing for a CSR must find out it is missing rather than read garbage
+	// HARDWARE: systemCheck decodes CSR bits [11:10] (read-only) and csrExists
+	// CATEGORY: [UNIT]
+
+	cases := []struct {
+		name  string
+		code  string
+		cause uint32
+	}{
+		{"read unknown", "csrr a1, 0x7c0", CauseIllegalInstruction},
+		{"write unknown", "csrw 0x7c0, t1", CauseIllegalInstruction},
+		{"write cycle", "csrw cycle, t1", CauseIllegalInstruction},
+		{"write instret", "csrw instret, t1", CauseIllegalInstruction},
+		{"read instret", "csrr a1, instret", CauseNone},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, _ := runAsm(t, `
+	.text
+_start:
+	la    t0, handler
+	csrw  tvec, t0
+	li    t1, -1
+	`+tc.code+`
+	csrw  status, t1          # PP = 3 (machine)
+	csrr  a1, status
+	li    a0, 0
+	system 0
+
+handler:
+	csrr  a0, cause
+	system 0
+`, func(c *Core, pt *pageTables, img *Image) {})
+
+			if status, _ := core.Exited(); status != tc.cause {
+				t.Errorf("exit status (cause) %d, expected %d", status, tc.cause)
+			}
+			if tc.cause == CauseNone && core.window.regFile[RegA0+1] != StatusPP {
+				t.Errorf("status = 0x%x after writing all ones, expected only PP", core.window.regFile[RegA0+1])
+			}
+		})
+	}
+}
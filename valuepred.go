@@ -0,0 +1,449 @@
+package suprax32
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// LOAD VALUE PREDICTION
+// ═══════════════════════════════════════════════════════════════════════════════
+//
+// WHY: The L1D predictor (INNOVATION #59) guesses where a load goes, which
+//
+//	hides the miss only if the prefetch is early enough. A load that
+//	misses still holds everything that uses its value for DRAMLatency
+//	cycles. Many loads return the value they returned last time (a
+//	flag, a loop-invariant pointer), one a fixed step from it (a counter
+//	kept in memory), or one that follows from the path taken to reach
+//	them. For those the value can be guessed before the load issues.
+//
+// THE COMPONENTS:
+//
+//	LastValuePredictor     value[n+1] = value[n]
+//	StrideValuePredictor   value[n+1] = value[n] + stride
+//	ContextValuePredictor  value depends on the recent branch outcomes
+//	                       (VTAGE: tagged tables indexed by the PC and
+//	                       5, 15 and 40 bits of history; longest match)
+//
+//	Each entry has a 0-15 confidence counter. A component predicts only
+//	from a saturated counter, and any wrong value resets it to 0: a
+//	mispredict costs a refetch, so a value must repeat 15 times first.
+//
+// THE MODE (SetValuePrediction):
+//
+//	STEP 1: Dispatch. ValuePredictor.Predict asks the most specific
+//	        confident component: context, then stride, then last value.
+//	        The value goes into the load's physical register and wakes
+//	        dependents before the load has even issued.
+//	STEP 2: Execute. The load runs as usual.
+//	STEP 3: Verify. Complete compares its result with the prediction.
+//	STEP 4: Commit. A wrong prediction refetches everything younger
+//	        (INNOVATION #48), like a branch mispredict. Every committed
+//	        load trains the components, in program order.
+//
+// IN-FLIGHT INSTANCES: Training at commit is always a step behind the
+//
+//	loads still in the window. The stride component counts its
+//	dispatched but uncommitted instances and predicts that many strides
+//	ahead. The context component indexes with the predicted directions
+//	of the dispatched branches; at commit the committed history is the
+//	same bits, since a load after a mispredicted branch never commits.
+//	A flush empties the window, so both simply reset (squash).
+//
+// STATISTICS (per component, over committed loads):
+//
+//	Coverage   predicted loads / loads
+//	Accuracy   correct predictions / predicted loads
+//	Recovery   flushes and the instructions they squashed
+//
+// MINECRAFT ANALOGY: Crafting with the iron you expect the furnace to
+//
+//	give you before it is done smelting. It has given you iron the
+//	last fifteen times; if it ever hands you gold, you throw away what
+//	you crafted since and start again.
+
+const (
+	ValueTableSize        = 512 // Last-value and stride entries
+	ContextValueTableSize = 256 // Entries per context table
+	ValueConfident        = 15  // Counter value a prediction needs
+)
+
+// contextHistoryLengths are the branch history bits each context table
+// indexes with, shortest first
+var contextHistoryLengths = [...]uint{5, 15, 40}
+
+// ValueComponent identifies which component predicted a value
+type ValueComponent uint8
+
+const (
+	ValueNone    ValueComponent = 0
+	ValueLast    ValueComponent = 1
+	ValueStride  ValueComponent = 2
+	ValueContext ValueComponent = 3
+)
+
+// String returns the component's name
+func (v ValueComponent) String() string {
+	switch v {
+	case ValueLast:
+		return "last"
+	case ValueStride:
+		return "stride"
+	case ValueContext:
+		return "context"
+	default:
+		return "none"
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// LAST-VALUE PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// LastValueEntry remembers one load's last value
+type LastValueEntry struct {
+	Tag        uint16 // PC tag
+	Value      uint32 // Last value loaded
+	Confidence uint8  // Times in a row it repeated (0-15)
+	Valid      bool
+}
+
+// LastValuePredictor predicts that a load returns what it returned last
+type LastValuePredictor struct {
+	entries [ValueTableSize]LastValueEntry
+}
+
+func (lp *LastValuePredictor) getIndex(pc uint32) int {
+	return int((pc >> 2) & (ValueTableSize - 1))
+}
+
+func (lp *LastValuePredictor) getTag(pc uint32) uint16 {
+	return uint16(pc >> 11)
+}
+
+// Predict returns the last value if it has repeated often enough
+func (lp *LastValuePredictor) Predict(pc uint32) (value uint32, confidence uint8, valid bool) {
+	entry := &lp.entries[lp.getIndex(pc)]
+	if entry.Valid && entry.Tag == lp.getTag(pc) && entry.Confidence >= ValueConfident {
+		return entry.Value, entry.Confidence, true
+	}
+	return 0, 0, false
+}
+
+// Update records a committed value and reports whether it repeated
+func (lp *LastValuePredictor) Update(pc uint32, value uint32) (repeated bool) {
+	entry := &lp.entries[lp.getIndex(pc)]
+	tag := lp.getTag(pc)
+
+	if !entry.Valid || entry.Tag != tag {
+		*entry = LastValueEntry{Tag: tag, Value: value, Valid: true}
+		return false
+	}
+	if entry.Value == value {
+		if entry.Confidence < 15 {
+			entry.Confidence++
+		}
+		return true
+	}
+	entry.Value = value
+	entry.Confidence = 0
+	return false
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// STRIDE-VALUE PREDICTOR
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// StrideValueEntry tracks one load's value stride
+type StrideValueEntry struct {
+	Tag        uint16 // PC tag
+	LastValue  uint32 // Last committed value
+	Stride     int32  // Difference between consecutive values
+	Confidence uint8  // Times in a row the stride held (0-15)
+	Inflight   uint8  // Instances dispatched but not committed
+	Valid      bool
+}
+
+// StrideValuePredictor predicts values that step by a constant
+type StrideValuePredictor struct {
+	entries [ValueTableSize]StrideValueEntry
+}
+
+func (sp *StrideValuePredictor) getIndex(pc uint32) int {
+	return int((pc >> 2) & (ValueTableSize - 1))
+}
+
+func (sp *StrideValuePredictor) getTag(pc uint32) uint16 {
+	return uint16(pc >> 11)
+}
+
+// Predict returns the value one stride past the youngest instance in
+// flight, and counts this instance as in flight
+//
+// A zero stride is the last-value component's prediction, not this one's.
+func (sp *StrideValuePredictor) Predict(pc uint32) (value uint32, confidence uint8, valid bool) {
+	entry := &sp.entries[sp.getIndex(pc)]
+	if !entry.Valid || entry.Tag != sp.getTag(pc) {
+		return 0, 0, false
+	}
+
+	ahead := int32(entry.Inflight) + 1
+	if entry.Inflight < 255 {
+		entry.Inflight++
+	}
+	if entry.Confidence >= ValueConfident && entry.Stride != 0 {
+		return uint32(int32(entry.LastValue) + entry.Stride*ahead), entry.Confidence, true
+	}
+	return 0, 0, false
+}
+
+// Update records a committed value
+func (sp *StrideValuePredictor) Update(pc uint32, value uint32) {
+	entry := &sp.entries[sp.getIndex(pc)]
+	tag := sp.getTag(pc)
+
+	if !entry.Valid || entry.Tag != tag {
+		*entry = StrideValueEntry{Tag: tag, LastValue: value, Valid: true}
+		return
+	}
+	if stride := int32(value - entry.LastValue); stride == entry.Stride {
+		if entry.Confidence < 15 {
+			entry.Confidence++
+		}
+	} else {
+		entry.Stride = stride
+		entry.Confidence = 0
+	}
+	entry.LastValue = value
+	if entry.Inflight > 0 {
+		entry.Inflight--
+	}
+}
+
+// squash forgets the instances in flight (the window was flushed)
+func (sp *StrideValuePredictor) squash() {
+	for i := range sp.entries {
+		sp.entries[i].Inflight = 0
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// CONTEXT-VALUE PREDICTOR (VTAGE)
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// ContextValueEntry is one value seen after one branch history
+type ContextValueEntry struct {
+	Tag        uint16 // PC and history tag
+	Value      uint32
+	Confidence uint8 // Times in a row it repeated (0-15)
+	Valid      bool
+}
+
+// ContextValuePredictor predicts values that depend on the path
+type ContextValuePredictor struct {
+	tables [len(contextHistoryLengths)][ContextValueTableSize]ContextValueEntry
+}
+
+// foldHistory XORs the newest length bits of history down to bits bits
+func foldHistory(history uint64, length, bits uint) uint32 {
+	h := history & (1<<length - 1)
+	var folded uint32
+	for ; h != 0; h >>= bits {
+		folded ^= uint32(h & (1<<bits - 1))
+	}
+	return folded
+}
+
+// slot returns table t's entry and tag for a PC under a history
+func (cp *ContextValuePredictor) slot(t int, pc uint32, history uint64) (*ContextValueEntry, uint16) {
+	length := contextHistoryLengths[t]
+	idx := ((pc >> 2) ^ foldHistory(history, length, 8)) & (ContextValueTableSize - 1)
+	tag := uint16(((pc >> 10) ^ foldHistory(history, length, 12)<<1) & 0xFFF)
+	return &cp.tables[t][idx], tag
+}
+
+// provider returns the longest-history table holding this PC and history
+func (cp *ContextValuePredictor) provider(pc uint32, history uint64) (t int, entry *ContextValueEntry) {
+	for t := len(cp.tables) - 1; t >= 0; t-- {
+		entry, tag := cp.slot(t, pc, history)
+		if entry.Valid && entry.Tag == tag {
+			return t, entry
+		}
+	}
+	return -1, nil
+}
+
+// Predict returns the longest match's value if it is confident
+func (cp *ContextValuePredictor) Predict(pc uint32, history uint64) (value uint32, confidence uint8, valid bool) {
+	if _, entry := cp.provider(pc, history); entry != nil && entry.Confidence >= ValueConfident {
+		return entry.Value, entry.Confidence, true
+	}
+	return 0, 0, false
+}
+
+// Update records a committed value
+//
+// ALGORITHM:
+//
+//	STEP 1: The longest match learns the value (confidence up on a
+//	        repeat, reset on a change)
+//	STEP 2: If it was wrong or missing, and allocate is set, claim an
+//	        entry in a longer table: one with no confidence to lose.
+//	        Every candidate that is still confident loses one step, so
+//	        an entry nobody confirms eventually makes room.
+func (cp *ContextValuePredictor) Update(pc uint32, history uint64, value uint32, allocate bool) {
+	t, entry := cp.provider(pc, history)
+	if entry != nil {
+		if entry.Value == value {
+			if entry.Confidence < 15 {
+				entry.Confidence++
+			}
+			return
+		}
+		entry.Value = value
+		entry.Confidence = 0
+	}
+	if !allocate {
+		return
+	}
+
+	for t++; t < len(cp.tables); t++ {
+		entry, tag := cp.slot(t, pc, history)
+		if !entry.Valid || entry.Confidence == 0 {
+			*entry = ContextValueEntry{Tag: tag, Value: value, Valid: true}
+			return
+		}
+		entry.Confidence--
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// VALUE PREDICTOR ENSEMBLE
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// valueCounters are one component's outcomes over committed loads
+type valueCounters struct {
+	predicted uint64 // Loads whose value it supplied
+	correct   uint64 // ...and the load returned it
+	flushes   uint64 // ...and it did not: younger work refetched
+	squashed  uint64 // Instructions those refetches discarded
+}
+
+// ValuePredictor combines the three components
+type ValuePredictor struct {
+	on bool
+
+	last    LastValuePredictor
+	stride  StrideValuePredictor
+	context ContextValuePredictor
+
+	specHistory   uint64 // Predicted directions of dispatched branches
+	commitHistory uint64 // Outcomes of committed branches
+
+	// Statistics
+	loads        uint64           // Committed loads (LW, LB/LH)
+	perComponent [3]valueCounters // Indexed by ValueComponent-1
+}
+
+// NewValuePredictor creates an untrained value predictor (off)
+func NewValuePredictor() *ValuePredictor {
+	return &ValuePredictor{}
+}
+
+// SetValuePrediction turns load value prediction on or off
+func (c *Core) SetValuePrediction(on bool) {
+	c.valuePred.on = on
+}
+
+// Predict returns a load's value from the most specific confident
+// component (STEP 1)
+func (vp *ValuePredictor) Predict(pc uint32) (value uint32, component ValueComponent, valid bool) {
+	context, _, contextOK := vp.context.Predict(pc, vp.specHistory)
+	stride, _, strideOK := vp.stride.Predict(pc) // Always: counts the instance
+	last, _, lastOK := vp.last.Predict(pc)
+
+	switch {
+	case contextOK:
+		return context, ValueContext, true
+	case strideOK:
+		return stride, ValueStride, true
+	case lastOK:
+		return last, ValueLast, true
+	}
+	return 0, ValueNone, false
+}
+
+// Update trains every component with a committed load's value (STEP 4)
+func (vp *ValuePredictor) Update(pc uint32, value uint32) {
+	repeated := vp.last.Update(pc, value)
+	vp.stride.Update(pc, value)
+	vp.context.Update(pc, vp.commitHistory, value, !repeated)
+}
+
+// squash returns the speculative state to the committed one (flush)
+func (vp *ValuePredictor) squash() {
+	vp.specHistory = vp.commitHistory
+	vp.stride.squash()
+}
+
+// predictsValue reports whether a load's value may be predicted
+func predictsValue(e *WindowEntry) bool {
+	return (e.Opcode == OpLW || e.Opcode == OpLDN) && e.PhysRd != InvalidTag && e.Fault == CauseNone
+}
+
+// predictValue runs at dispatch: branches extend the speculative history,
+// predictable loads get a value that wakes their dependents (STEP 1)
+func (c *Core) predictValue(e *WindowEntry) {
+	vp := c.valuePred
+	if !vp.on {
+		return
+	}
+	if e.IsBranch {
+		vp.specHistory <<= 1
+		if e.Predicted {
+			vp.specHistory |= 1
+		}
+		return
+	}
+	if !predictsValue(e) {
+		return
+	}
+
+	if value, component, ok := vp.Predict(e.PC); ok {
+		e.ValuePredicted = true
+		e.PredictedValue = value
+		e.ValueComponent = component
+		c.window.Wakeup(e.PhysRd, value)
+	}
+}
+
+// commitBranch extends the committed history
+func (vp *ValuePredictor) commitBranch(taken bool) {
+	if !vp.on {
+		return
+	}
+	vp.commitHistory <<= 1
+	if taken {
+		vp.commitHistory |= 1
+	}
+}
+
+// commitValue trains on a committing load and reports whether the younger
+// work used a wrong value and must be refetched (STEP 4)
+func (c *Core) commitValue(e *WindowEntry) (refetch bool) {
+	vp := c.valuePred
+	if !vp.on || !predictsValue(e) {
+		return false
+	}
+	vp.loads++
+	vp.Update(e.PC, e.Result)
+	if !e.ValuePredicted {
+		return false
+	}
+
+	s := &vp.perComponent[e.ValueComponent-1]
+	s.predicted++
+	if !e.ValueMispredicted {
+		s.correct++
+		return false
+	}
+	s.flushes++
+	s.squashed += uint64(c.window.GetCount())
+	return true
+}
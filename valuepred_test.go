@@ -0,0 +1,242 @@
+package suprax32
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// SUPRAX Load Value Prediction - Test Suite
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// TEST PHILOSOPHY:
+// ────────────────
+// A predicted value is used before anyone knows it is right, so a single wrong value that
+// survives is a wrong answer. The component tests pin down what each one predicts - in
+// particular that the stride component looks past the instances still in flight - and the
+// program tests check that answers do not change when predictions go wrong.
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// TEST ORGANIZATION
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+//
+// 1. COMPONENT TESTS
+//    Confidence threshold, stride in flight, context under different histories
+//
+// 2. CORRECTNESS TESTS
+//    Standard kernels, a value that changes after it became confident
+//
+// 3. STATISTICS TESTS
+//    Off by default, per-component rows add up
+//
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+// changingSource loads the same global 2000 times, then a different value for the rest of
+// the loop: the first load after the change was predicted with full confidence
+const changingSource = `
+int flag = 5;
+
+int main() {
+	int i, sum;
+	sum = 0;
+	for (i = 0; i < 3000; i++) {
+		if (i == 2000)
+			flag = 9;
+		sum = sum + flag;
+	}
+	return sum & 0xffff;
+}
+`
+
+// runValueProgram runs a C program with value prediction on or off
+func runValueProgram(t *testing.T, src string, on bool) (*Core, uint32) {
+	t.Helper()
+	img, err := BuildC("value.c", src)
+	if err != nil {
+		t.Fatalf("BuildC: %v", err)
+	}
+	core := NewCore(1 << 20)
+	var console bytes.Buffer
+	core.SetConsole(&console)
+	core.SetValuePrediction(on)
+	if err := core.LoadImage(img); err != nil {
+		t.Fatalf("LoadImage: %v", err)
+	}
+	core.Run(2_000_000)
+	status, ok := core.Exited()
+	if !ok {
+		t.Fatalf("program did not exit (pc 0x%x)", core.pc)
+	}
+	return core, status
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 1. COMPONENT TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestValuePred_LastValueNeedsFullConfidence(t *testing.T) {
+	// WHAT: A value is predicted only after it repeated ValueConfident times, and one change
+	//       stops predictions until it has repeated that often again
+	// WHY: Every wrong value costs a refetch of the whole window
+	// CATEGORY: [UNIT]
+
+	var lp LastValuePredictor
+	const pc = 0x1000
+	lp.Update(pc, 7)
+	for i := 0; i < ValueConfident; i++ {
+		if _, _, ok := lp.Predict(pc); ok {
+			t.Fatalf("predicted after %d repeats", i)
+		}
+		lp.Update(pc, 7)
+	}
+	if v, _, ok := lp.Predict(pc); !ok || v != 7 {
+		t.Fatalf("predict = %d, %v after %d repeats", v, ok, ValueConfident)
+	}
+	lp.Update(pc, 8)
+	if _, _, ok := lp.Predict(pc); ok {
+		t.Error("still predicting after the value changed")
+	}
+}
+
+func TestValuePred_StrideLooksPastInFlight(t *testing.T) {
+	// WHAT: With instances dispatched but not committed, each new prediction is one more
+	//       stride ahead; commits and a squash bring it back
+	// WHY: A loop keeps several instances of the same load in the window, and the table
+	//      only learns at commit
+	// HARDWARE: StrideValueEntry.Inflight, squash on every flush
+	// CATEGORY: [UNIT]
+
+	var sp StrideValuePredictor
+	const pc = 0x2000
+	for i := 0; i <= ValueConfident+1; i++ {
+		sp.Update(pc, uint32(100+4*i))
+	}
+	last := uint32(100 + 4*(ValueConfident+1))
+
+	for ahead := uint32(1); ahead <= 3; ahead++ {
+		if v, _, ok := sp.Predict(pc); !ok || v != last+4*ahead {
+			t.Fatalf("prediction %d: %d, %v; expected %d", ahead, v, ok, last+4*ahead)
+		}
+	}
+	sp.Update(pc, last+4) // Oldest in flight commits
+	if v, _, _ := sp.Predict(pc); v != last+4*4 {
+		t.Errorf("after one commit: %d, expected %d", v, last+4*4)
+	}
+	sp.squash()
+	if v, _, _ := sp.Predict(pc); v != last+4*2 {
+		t.Errorf("after squash: %d, expected %d", v, last+4*2)
+	}
+}
+
+func TestValuePred_ContextSeparatesHistories(t *testing.T) {
+	// WHAT: A load whose value alternates with the last branch outcome is predicted by the
+	//       context component under both histories; last value never becomes confident
+	// WHY: Values chosen by the path taken are what the tagged tables are for
+	// CATEGORY: [UNIT]
+
+	vp := NewValuePredictor()
+	const pc = 0x3000
+	for i := 0; i < 4*ValueConfident; i++ {
+		vp.commitHistory = uint64(i & 1)
+		vp.Update(pc, uint32(10+i&1))
+	}
+	for _, h := range []uint64{0, 1} {
+		vp.specHistory = h
+		v, component, ok := vp.Predict(pc)
+		if !ok || component != ValueContext || v != uint32(10+h) {
+			t.Errorf("history %d: %d from %s (%v), expected %d from context", h, v, component, ok, 10+h)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 2. CORRECTNESS TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestValuePred_KernelsPass(t *testing.T) {
+	// WHAT: Every standard kernel passes its self-check with value prediction on, alone and
+	//       together with address speculation, and values were predicted
+	// WHY: Wrong values that escape the commit check corrupt results silently
+	// HARDWARE: predictValue at dispatch, Complete verifies, commitValue refetches
+	// CATEGORY: [INTEGRATION]
+
+	if testing.Short() {
+		t.Skip("runs every kernel on the cycle-level core")
+	}
+	for _, k := range StandardKernels() {
+		for _, addrSpec := range []bool{false, true} {
+			name := k.Name
+			if addrSpec {
+				name += "+addrspec"
+			}
+			t.Run(name, func(t *testing.T) {
+				r := RunKernel(k, SuiteOptions{Iterations: 1, MaxCycles: 5_000_000, Configure: func(c *Core) {
+					c.SetValuePrediction(true)
+					c.SetAddrSpeculation(addrSpec)
+				}})
+				if r.Err != nil || !r.Passed {
+					t.Fatalf("err %v, status %d, output %q", r.Err, r.Status, r.Output)
+				}
+				if s := r.Stats.ValuePred; s.Predicted == 0 {
+					t.Errorf("no value predicted out of %d loads", s.Loads)
+				}
+			})
+		}
+	}
+}
+
+func TestValuePred_ChangedValueRecovers(t *testing.T) {
+	// WHAT: A global read 2000 times and then changed gives the same sum with prediction on
+	//       as off, and the confident wrong prediction flushed
+	// WHY: The stale value had already reached the add by the time the load returned
+	// HARDWARE: ValueMispredicted set by Complete, refetch at commit
+	// CATEGORY: [INTEGRATION]
+
+	const want = (2000*5 + 1000*9) & 0xffff
+	_, off := runValueProgram(t, changingSource, false)
+	core, on := runValueProgram(t, changingSource, true)
+	if off != want || on != want {
+		t.Fatalf("exit status off %d, on %d, expected %d", off, on, want)
+	}
+	if s := core.Snapshot().ValuePred; s.Flushes == 0 || s.Correct == 0 {
+		t.Errorf("correct %d, flushes %d: the change was not predicted through", s.Correct, s.Flushes)
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+// 3. STATISTICS TESTS
+// ═══════════════════════════════════════════════════════════════════════════════════════════════
+
+func TestValuePred_OffByDefault(t *testing.T) {
+	// WHAT: A new core predicts no values and reports the mode disabled
+	// WHY: Existing results and performance baselines must not move unless asked
+	// CATEGORY: [UNIT]
+
+	core, _ := runValueProgram(t, changingSource, false)
+	if s := core.Snapshot().ValuePred; s.Enabled || s.Predicted != 0 || s.Loads != 0 {
+		t.Errorf("enabled %v, predicted %d, loads %d", s.Enabled, s.Predicted, s.Loads)
+	}
+}
+
+func TestValuePred_StatsConsistent(t *testing.T) {
+	// WHAT: Each prediction is correct or flushed, and the component rows add up
+	// WHY: Coverage and accuracy per component are what the predictor is evaluated by
+	// CATEGORY: [UNIT]
+
+	core, _ := runValueProgram(t, changingSource, true)
+	s := core.Snapshot().ValuePred
+	if !s.Enabled || s.Correct+s.Flushes != s.Predicted || s.Predicted > s.Loads {
+		t.Errorf("enabled %v, loads %d, predicted %d, correct %d, flushes %d",
+			s.Enabled, s.Loads, s.Predicted, s.Correct, s.Flushes)
+	}
+	var predicted, correct uint64
+	for _, c := range s.Components {
+		predicted += c.Predicted
+		correct += c.Correct
+	}
+	if predicted != s.Predicted || correct != s.Correct {
+		t.Errorf("component rows sum to %d/%d; totals %d/%d", correct, predicted, s.Correct, s.Predicted)
+	}
+}